@@ -0,0 +1,71 @@
+// Comando de migración de Postgres a Scylla. Copia salas, miembros, mensajes, reacciones,
+// confirmaciones de lectura y salas p2p a las tablas desnormalizadas de Scylla. Guarda un
+// checkpoint después de cada sala, así que si se interrumpe basta con volver a ejecutarlo.
+//
+//	go run ./cmd/campaing-app-chat-backfill                 # continúa desde el checkpoint
+//	go run ./cmd/campaing-app-chat-backfill -restart        # vuelve a copiar todo
+//	go run ./cmd/campaing-app-chat-backfill -room <room_id> # vuelve a copiar una sala
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/database"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+)
+
+var name = flag.String("name", "default", "nombre del checkpoint")
+var batchSize = flag.Int("batch", 500, "mensajes por página dentro de cada sala")
+var restart = flag.Bool("restart", false, "ignora el checkpoint y empieza desde la primera sala")
+var roomID = flag.String("room", "", "copia solo esta sala (no usa el checkpoint)")
+
+func main() {
+	flag.Parse()
+
+	if database.CQLDB() == nil {
+		log.Fatal("no hay conexión a Scylla; revisa CASSANDRA_HOSTS y CASSANDRA_KEYSPACE")
+	}
+
+	// Ctrl+C detiene la copia después de la sala en curso; el checkpoint queda guardado
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	backfill := roomsrepository.NewScyllaBackfill(database.DB(), database.CQLDB())
+
+	if *roomID != "" {
+		copied, err := backfill.CopyRoom(ctx, *roomID, *batchSize)
+		if err != nil {
+			log.Fatalf("Error al copiar la sala %s: %v", *roomID, err)
+		}
+		log.Printf("Sala %s copiada: %d mensajes", *roomID, copied)
+		return
+	}
+
+	lastReport := time.Time{}
+	progress, err := backfill.Run(ctx, roomsrepository.BackfillOptions{
+		Name:      *name,
+		BatchSize: *batchSize,
+		Restart:   *restart,
+		Progress: func(p roomsrepository.BackfillProgress) {
+			if time.Since(lastReport) < 5*time.Second && p.RoomsDone != p.RoomsTotal {
+				return
+			}
+			lastReport = time.Now()
+			percent := 0.0
+			if p.RoomsTotal > 0 {
+				percent = float64(p.RoomsDone) * 100 / float64(p.RoomsTotal)
+			}
+			log.Printf("Salas %d/%d (%.1f%%), mensajes %d, última sala %s, %s", p.RoomsDone, p.RoomsTotal, percent, p.MessagesDone, p.LastRoomID, p.Elapsed.Round(time.Second))
+		},
+	})
+	if err != nil {
+		log.Fatalf("Backfill interrumpido en la sala %d/%d (se puede reanudar): %v", progress.RoomsDone, progress.RoomsTotal, err)
+	}
+	log.Printf("Backfill completo: %d salas, %d mensajes en %s", progress.RoomsDone, progress.MessagesDone, progress.Elapsed.Round(time.Second))
+}
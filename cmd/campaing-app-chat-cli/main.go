@@ -212,6 +212,7 @@ func sendMessage(content string) {
 		Type:         "user",
 		RoomId:       room.Id,
 		Content:      message,
+		KeyVersion:   &room.KeyVersion,
 		ContactName:  &me.Name,
 		ContactPhone: &me.Phone,
 	})
@@ -0,0 +1,124 @@
+// Comando de migraciones de esquema. Usa los ficheros de migrations/ embebidos en el
+// binario y registra cada versión aplicada en schema_migrations de cada store.
+//
+//	go run ./cmd/campaing-app-chat-migrate status
+//	go run ./cmd/campaing-app-chat-migrate up                     # Postgres y Scylla
+//	go run ./cmd/campaing-app-chat-migrate -store postgres up
+//	go run ./cmd/campaing-app-chat-migrate -store cassandra -steps 1 down
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/migrations"
+	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/cassandra"
+	dbpq "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+)
+
+var store = flag.String("store", "all", "store a migrar: postgres, cassandra o all")
+var steps = flag.Int("steps", 1, "migraciones a revertir con down")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "uso: %s [-store postgres|cassandra|all] [-steps n] up|down|status\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := flag.Arg(0)
+	if command != "up" && command != "down" && command != "status" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if command == "down" && *store == "all" {
+		log.Fatal("down necesita -store postgres o -store cassandra")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for _, migrator := range newMigrators(*store) {
+		switch command {
+		case "status":
+			printStatus(ctx, migrator)
+		case "up":
+			applied, err := migrator.Up(ctx)
+			for _, m := range applied {
+				log.Printf("%s: %04d_%s aplicada", migrator.Store(), m.Version, m.Name)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("%s: esquema en la versión %d", migrator.Store(), migrator.Latest())
+		case "down":
+			reverted, err := migrator.Down(ctx, *steps)
+			for _, m := range reverted {
+				log.Printf("%s: %04d_%s revertida", migrator.Store(), m.Version, m.Name)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+// newMigrators conecta directamente y no a través del paquete database, que se niega a
+// arrancar con el esquema desactualizado.
+func newMigrators(store string) []*migrations.Migrator {
+	var migrators []*migrations.Migrator
+
+	if store == "all" || store == migrations.StorePostgres {
+		db, err := dbpq.ConnectToNewSQLInstance(dbpq.DefaultConnectionString)
+		if err != nil {
+			log.Fatal("ERROR CONNECTING TO DB: ", err)
+		}
+		migrator, err := migrations.NewPostgresMigrator(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		migrators = append(migrators, migrator)
+	}
+
+	if store == "all" || store == migrations.StoreCassandra {
+		session, err := cassandra.Connect(cassandra.DefaultConnectionConfig)
+		if err != nil {
+			log.Fatal("ERROR CONNECTING TO CASSANDRA: ", err)
+		}
+		migrator, err := migrations.NewCassandraMigrator(session)
+		if err != nil {
+			log.Fatal(err)
+		}
+		migrators = append(migrators, migrator)
+	}
+
+	if len(migrators) == 0 {
+		log.Fatalf("store desconocido: %q (postgres, cassandra o all)", store)
+	}
+	return migrators
+}
+
+func printStatus(ctx context.Context, migrator *migrations.Migrator) {
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%s (el código espera la versión %d)\n", migrator.Store(), migrator.Latest())
+	for _, s := range status {
+		appliedAt := ""
+		if !s.AppliedAt.IsZero() {
+			appliedAt = s.AppliedAt.Local().Format(time.DateTime)
+		}
+		fmt.Printf("  %04d  %-24s %-9s %s\n", s.Version, s.Name, s.State, appliedAt)
+	}
+}
@@ -0,0 +1,85 @@
+// Comando que vuelve a cifrar el encryption_data de todas las salas (y su historial de claves)
+// con la versión actual de la clave maestra, sin cambiar las claves de las salas. El servicio
+// puede seguir funcionando mientras tanto, siempre que todas las instancias tengan ya la
+// versión nueva en su keyring (ver utils/keyprovider.go). Usa el mismo store que el servicio
+// (CHAT_STORE_MODE); con dual-write cifra en Postgres y refleja cada sala en Scylla. Se puede
+// repetir: las claves que ya están en la versión actual no se tocan.
+//
+//	go run ./cmd/campaing-app-chat-rewrap            # cifra de nuevo las claves pendientes
+//	go run ./cmd/campaing-app-chat-rewrap -dry-run   # solo cuenta las claves pendientes
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/database"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
+)
+
+var dryRun = flag.Bool("dry-run", false, "solo cuenta las claves que no están en la versión actual")
+
+func main() {
+	flag.Parse()
+
+	mode := os.Getenv("CHAT_STORE_MODE")
+	if mode == "" {
+		mode = "postgres"
+		if scylladb, _ := strconv.ParseBool(os.Getenv("USE_SCYLLADB")); scylladb {
+			mode = "scylla"
+		}
+	}
+	if mode != "postgres" && database.CQLDB() == nil {
+		log.Fatalf("CHAT_STORE_MODE=%s requiere una conexión a Scylla", mode)
+	}
+
+	current, err := utils.CurrentMasterKeyVersion()
+	if err != nil {
+		log.Fatalf("No hay clave maestra actual: %v", err)
+	}
+	log.Printf("Versión actual de la clave maestra: %d (store %s)", current, mode)
+
+	// Ctrl+C detiene el proceso después de la sala en curso; basta con volver a ejecutarlo
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rewrap := roomsrepository.NewKeyRewrap(database.DB(), database.CQLDB())
+	lastReport := time.Time{}
+	opts := roomsrepository.RewrapOptions{
+		DryRun: *dryRun,
+		Progress: func(p roomsrepository.RewrapProgress) {
+			if time.Since(lastReport) < 5*time.Second {
+				return
+			}
+			lastReport = time.Now()
+			log.Printf("Claves revisadas %d, cifradas de nuevo %d, pendientes %d, %s", p.Scanned, p.Rewrapped, p.Pending, p.Elapsed.Round(time.Second))
+		},
+	}
+
+	var progress roomsrepository.RewrapProgress
+	switch mode {
+	case "postgres":
+		progress, err = rewrap.RewrapPostgres(ctx, opts)
+	case "scylla":
+		progress, err = rewrap.RewrapScylla(ctx, opts)
+	case "dual", "dual-verify":
+		opts.AfterRoom = roomsrepository.NewScyllaBackfill(database.DB(), database.CQLDB()).MirrorRoom
+		progress, err = rewrap.RewrapPostgres(ctx, opts)
+	default:
+		log.Fatalf("CHAT_STORE_MODE inválido para el rewrap: %q (postgres, dual, dual-verify o scylla)", mode)
+	}
+	if err != nil {
+		log.Fatalf("Rewrap interrumpido (se puede repetir) tras %d claves: %v", progress.Scanned, err)
+	}
+	log.Printf("Rewrap completo: %d claves revisadas, %d cifradas de nuevo, %d pendientes en %s", progress.Scanned, progress.Rewrapped, progress.Pending, progress.Elapsed.Round(time.Second))
+	if progress.Pending > 0 && !*dryRun {
+		log.Printf("Quedan claves que cambiaron durante el rewrap; vuelve a ejecutarlo")
+	}
+}
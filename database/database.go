@@ -1,26 +1,41 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/migrations"
 	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/cassandra"
 	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+	"github.com/redis/go-redis/v9"
 	"github.com/scylladb-solutions/gocql/v2"
 )
 
 var db *sql.DB
 var cassandraDB *gocql.Session
+var connectOnce sync.Once
 
+var redisClient redis.UniversalClient
+var redisOnce sync.Once
+
+// La conexión se abre en el primer uso y no al importar el paquete, para que los tests
+// de handlers con repositorios en memoria no necesiten bases de datos.
 func CQLDB() *gocql.Session {
+	connectOnce.Do(connect)
 	return cassandraDB
 }
 
 func DB() *sql.DB {
+	connectOnce.Do(connect)
 	return db
 }
 
-func init() {
+func connect() {
 	instance, err := dbpq.ConnectToNewSQLInstance(dbpq.DefaultConnectionString)
 	if err != nil {
 		log.Fatal("ERROR CONNECTING TO DB: ", err)
@@ -30,7 +45,68 @@ func init() {
 	cassandraDB_, err := cassandra.Connect(cassandra.DefaultConnectionConfig)
 	if err != nil {
 		log.Println("ERROR CONNECTING TO CASSANDRA: ", err)
-		return
+	} else {
+		cassandraDB = cassandraDB_
 	}
-	cassandraDB = cassandraDB_
+
+	checkSchema()
+}
+
+// checkSchema se niega a arrancar contra un esquema más viejo que el que espera el código.
+// Con CHAT_AUTO_MIGRATE=true aplica antes las migraciones pendientes.
+func checkSchema() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	autoMigrate, _ := strconv.ParseBool(os.Getenv("CHAT_AUTO_MIGRATE"))
+
+	postgresMigrator, err := migrations.NewPostgresMigrator(db)
+	if err != nil {
+		log.Fatal("ERROR LOADING MIGRATIONS: ", err)
+	}
+	migrators := []*migrations.Migrator{postgresMigrator}
+	if cassandraDB != nil {
+		cassandraMigrator, err := migrations.NewCassandraMigrator(cassandraDB)
+		if err != nil {
+			log.Fatal("ERROR LOADING MIGRATIONS: ", err)
+		}
+		migrators = append(migrators, cassandraMigrator)
+	}
+
+	for _, migrator := range migrators {
+		if autoMigrate {
+			applied, err := migrator.Up(ctx)
+			for _, m := range applied {
+				log.Printf("Migración %s %04d_%s aplicada", migrator.Store(), m.Version, m.Name)
+			}
+			if err != nil {
+				log.Fatal("ERROR MIGRATING SCHEMA: ", err)
+			}
+		}
+		if err := migrator.Check(ctx); err != nil {
+			log.Fatal("ERROR CHECKING SCHEMA: ", err)
+		}
+	}
+}
+
+// Redis devuelve un cliente propio para lo que pkg/cache no ofrece, como los contadores
+// atómicos. Se configura con REDIS_URL o, si no está, con REDIS_ADDR; sin ninguna de las
+// dos devuelve nil.
+func Redis() redis.UniversalClient {
+	redisOnce.Do(func() {
+		options := &redis.Options{Addr: os.Getenv("REDIS_ADDR")}
+		if url := os.Getenv("REDIS_URL"); url != "" {
+			parsed, err := redis.ParseURL(url)
+			if err != nil {
+				log.Println("ERROR PARSING REDIS_URL: ", err)
+				return
+			}
+			options = parsed
+		}
+		if options.Addr == "" {
+			return
+		}
+		redisClient = redis.NewClient(options)
+	})
+	return redisClient
 }
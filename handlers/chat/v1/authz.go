@@ -0,0 +1,259 @@
+package chatv1handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
+	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/api"
+)
+
+// Autorización de las RPC del servicio de chat.
+//
+// roomAuthorizer es un interceptor de connect que, antes de llegar al manejador, autentica al
+// llamante, resuelve la sala (y el mensaje) que referencia la petición y aplica las reglas
+// declaradas para cada RPC en roomPolicies (ver authz_policies.go). Los manejadores leen el
+// resultado con roomAccessFrom en lugar de repetir la validación del token, la lectura de la
+// sala y las comprobaciones de rol. Una RPC sin política se rechaza.
+//
+// Los errores son siempre los mismos:
+//   - Unauthenticated: token ausente o inválido.
+//   - InvalidArgument: la petición no indica la sala o el mensaje, o la sala no es del tipo
+//     que admite la RPC.
+//   - NotFound: la sala o el mensaje no existen, o el llamante no participa en la sala.
+//   - PermissionDenied: el llamante participa, pero su rol o los permisos de la sala no le
+//     permiten la operación.
+//   - Internal: error del repositorio.
+
+// authMode es cómo se identifica el llamante de una RPC.
+type authMode int
+
+const (
+	// authSession exige el token de sesión de un usuario
+	authSession authMode = iota + 1
+	// authPublic exige el token público de los endpoints internos
+	authPublic
+	// authStream es un stream de servidor que valida la sesión en el manejador: el
+	// interceptor de streaming no tiene acceso al mensaje de la petición
+	authStream
+)
+
+// roomPolicy son las reglas de una RPC. Solo auth es obligatorio; sin room ni message la RPC
+// no referencia ninguna sala.
+type roomPolicy struct {
+	auth authMode
+
+	// room devuelve el ID de la sala de la petición
+	room func(proto.Message) string
+	// optionalRoom permite peticiones sin sala; si la indican, se exige participar en ella
+	optionalRoom bool
+	// message devuelve el ID del mensaje de la petición; la sala es la del mensaje
+	message func(proto.Message) string
+	// messages devuelve los mensajes sobre los que actúa la petición: tienen que ser de la
+	// sala y haberlos enviado el llamante
+	messages func(proto.Message) []string
+
+	// roomType restringe la RPC a salas p2p o group
+	roomType string
+	// roles son los roles del llamante que admite la RPC (cualquiera si está vacío)
+	roles []string
+	// sender exige que el llamante sea quien envió el mensaje
+	sender bool
+	// allow es una regla adicional sobre la sala y la petición
+	allow func(room *chatv1.Room, msg proto.Message) bool
+}
+
+// roomAccess es lo que el interceptor resolvió para la petición.
+type roomAccess struct {
+	userID  int                 // 0 en los endpoints con token público
+	room    *chatv1.Room        // nil si la petición no referencia una sala
+	message *chatv1.MessageData // mensaje de la petición (GetMessageSimple), si lo hay
+}
+
+type roomAccessKey struct{}
+
+// roomAccessFrom devuelve el acceso que resolvió el interceptor. Sin él la petición no pasó
+// por la autorización y se rechaza.
+func roomAccessFrom(ctx context.Context) (*roomAccess, error) {
+	access, ok := ctx.Value(roomAccessKey{}).(*roomAccess)
+	if !ok {
+		return nil, connect.NewError(connect.CodeInternal, errors.New(utils.ERRORS.INTERNAL_SERVER_ERROR))
+	}
+	return access, nil
+}
+
+type roomAuthorizer struct {
+	logger   *slog.Logger
+	rooms    roomsrepository.RoomsRepository
+	policies map[string]roomPolicy
+
+	// Identificación del llamante; los tests las sustituyen
+	session func(req connect.AnyRequest) (int, error)
+	public  func(header http.Header) bool
+}
+
+func newRoomAuthorizer(logger *slog.Logger, rooms roomsrepository.RoomsRepository) *roomAuthorizer {
+	return &roomAuthorizer{
+		logger:   logger,
+		rooms:    rooms,
+		policies: roomPolicies,
+		session:  sessionUserID,
+		public: func(header http.Header) bool {
+			ok, _ := utils.ValidatePublicToken(header)
+			return ok
+		},
+	}
+}
+
+func sessionUserID(req connect.AnyRequest) (int, error) {
+	session, err := api.CheckSessionFromConnectRequest(req)
+	if err != nil {
+		return 0, err
+	}
+	if session == nil {
+		return 0, errors.New("sesión vacía")
+	}
+	return session.UserID, nil
+}
+
+func (a *roomAuthorizer) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		access, err := a.authorize(ctx, req.Spec().Procedure, req)
+		if err != nil {
+			return nil, err
+		}
+		return next(context.WithValue(ctx, roomAccessKey{}, access), req)
+	}
+}
+
+func (a *roomAuthorizer) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (a *roomAuthorizer) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		procedure := conn.Spec().Procedure
+		if policy, ok := a.policies[procedure]; !ok || policy.auth != authStream {
+			a.logger.Warn("RPC de streaming sin política de autorización", "procedure", procedure)
+			return authzError(connect.CodePermissionDenied, utils.ERRORS.PERMISSION_DENIED, conn.RequestHeader())
+		}
+		return next(ctx, conn)
+	}
+}
+
+// authorize aplica la política de la RPC y devuelve el acceso resuelto.
+func (a *roomAuthorizer) authorize(ctx context.Context, procedure string, req connect.AnyRequest) (*roomAccess, error) {
+	policy, ok := a.policies[procedure]
+	if !ok {
+		a.logger.Warn("RPC sin política de autorización", "procedure", procedure)
+		return nil, authzError(connect.CodePermissionDenied, utils.ERRORS.PERMISSION_DENIED, req.Header())
+	}
+
+	switch policy.auth {
+	case authPublic:
+		if !a.public(req.Header()) {
+			return nil, authzError(connect.CodeUnauthenticated, utils.ERRORS.INVALID_TOKEN, req.Header())
+		}
+		return &roomAccess{}, nil
+	case authSession:
+	default:
+		a.logger.Warn("Política de autorización inválida para una RPC unaria", "procedure", procedure)
+		return nil, authzError(connect.CodePermissionDenied, utils.ERRORS.PERMISSION_DENIED, req.Header())
+	}
+
+	userID, err := a.session(req)
+	if err != nil {
+		return nil, authzError(connect.CodeUnauthenticated, utils.ERRORS.INVALID_TOKEN, req.Header())
+	}
+	access := &roomAccess{userID: userID}
+
+	msg, _ := req.Any().(proto.Message)
+	var roomID string
+	switch {
+	case policy.message != nil:
+		access.message, err = a.lookupMessage(ctx, userID, policy.message(msg), req.Header())
+		if err != nil {
+			return nil, err
+		}
+		roomID = access.message.RoomId
+	case policy.room != nil:
+		roomID = policy.room(msg)
+		if roomID == "" {
+			if policy.optionalRoom {
+				return access, nil
+			}
+			return nil, authzError(connect.CodeInvalidArgument, utils.ERRORS.INVALID_REQUEST_DATA, req.Header())
+		}
+	default:
+		return access, nil
+	}
+
+	access.room, err = a.rooms.GetRoom(ctx, userID, roomID, false, true)
+	if err != nil {
+		a.logger.Error("Error leyendo la sala para autorizar", "procedure", procedure, "roomID", roomID, "error", err)
+		return nil, authzError(connect.CodeInternal, utils.ERRORS.INTERNAL_SERVER_ERROR, req.Header())
+	}
+	if access.room == nil {
+		return nil, authzError(connect.CodeNotFound, utils.ERRORS.NOT_FOUND, req.Header())
+	}
+
+	if policy.roomType != "" && access.room.Type != policy.roomType {
+		return nil, authzError(connect.CodeInvalidArgument, utils.ERRORS.INVALID_REQUEST_DATA, req.Header())
+	}
+	if len(policy.roles) > 0 && !slices.Contains(policy.roles, access.room.Role) {
+		return nil, authzError(connect.CodePermissionDenied, utils.ERRORS.PERMISSION_DENIED, req.Header())
+	}
+	if policy.sender && access.message.SenderId != int32(userID) {
+		return nil, authzError(connect.CodePermissionDenied, utils.ERRORS.PERMISSION_DENIED, req.Header())
+	}
+	if policy.messages != nil {
+		for _, id := range policy.messages(msg) {
+			message, err := a.lookupMessage(ctx, userID, id, req.Header())
+			if err != nil {
+				return nil, err
+			}
+			if message.RoomId != access.room.Id {
+				return nil, authzError(connect.CodeNotFound, utils.ERRORS.NOT_FOUND, req.Header())
+			}
+			if message.SenderId != int32(userID) {
+				return nil, authzError(connect.CodePermissionDenied, utils.ERRORS.PERMISSION_DENIED, req.Header())
+			}
+		}
+	}
+	if policy.allow != nil && !policy.allow(access.room, msg) {
+		return nil, authzError(connect.CodePermissionDenied, utils.ERRORS.PERMISSION_DENIED, req.Header())
+	}
+
+	return access, nil
+}
+
+// lookupMessage lee un mensaje de la petición; un mensaje borrado no existe.
+func (a *roomAuthorizer) lookupMessage(ctx context.Context, userID int, messageID string, header http.Header) (*chatv1.MessageData, error) {
+	if messageID == "" {
+		return nil, authzError(connect.CodeInvalidArgument, utils.ERRORS.INVALID_REQUEST_DATA, header)
+	}
+	message, err := a.rooms.GetMessageSimple(ctx, userID, messageID)
+	if err != nil {
+		a.logger.Error("Error leyendo el mensaje para autorizar", "messageID", messageID, "error", err)
+		return nil, authzError(connect.CodeInternal, utils.ERRORS.INTERNAL_SERVER_ERROR, header)
+	}
+	if message == nil {
+		return nil, authzError(connect.CodeNotFound, utils.ERRORS.NOT_FOUND, header)
+	}
+	return message, nil
+}
+
+func authzError(code connect.Code, reason string, header http.Header) error {
+	return connect.NewError(code, api.UpdateResponseInfoErrorMessage(errors.New(reason), header))
+}
@@ -0,0 +1,169 @@
+package chatv1handler
+
+import (
+	"google.golang.org/protobuf/proto"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1/chatv1connect"
+)
+
+// roomPolicies son las reglas de autorización de cada RPC del servicio (ver authz.go). Toda
+// RPC nueva necesita su entrada: sin ella el interceptor la rechaza. Las reglas que dependen
+// del contenido (menciones en p2p, usuario bloqueado, dueño de una exportación) siguen en el
+// manejador.
+var roomPolicies = map[string]roomPolicy{
+	// Endpoints internos
+	chatv1connect.ChatServiceEraseUserDataProcedure:     {auth: authPublic},
+	chatv1connect.ChatServiceListRoomCacheKeysProcedure: {auth: authPublic},
+	chatv1connect.ChatServiceGetCacheEntryProcedure:     {auth: authPublic},
+	chatv1connect.ChatServiceFlushCacheProcedure:        {auth: authPublic},
+	chatv1connect.ChatServiceGetCacheStatsProcedure:     {auth: authPublic},
+
+	chatv1connect.ChatServiceStreamMessagesProcedure:            {auth: authStream},
+	chatv1connect.ChatServiceDownloadRoomHistoryExportProcedure: {auth: authStream},
+
+	// Solo sesión: no referencian una sala, o cada manejador comprueba que el recurso sea
+	// del llamante
+	chatv1connect.ChatServiceCreateRoomProcedure:               {auth: authSession},
+	chatv1connect.ChatServiceGetRoomsProcedure:                 {auth: authSession},
+	chatv1connect.ChatServiceInitialSyncProcedure:              {auth: authSession},
+	chatv1connect.ChatServiceGetSenderMessageProcedure:         {auth: authSession},
+	chatv1connect.ChatServiceGetRoomHistoryExportProcedure:     {auth: authSession},
+	chatv1connect.ChatServiceExportUserDataProcedure:           {auth: authSession},
+	chatv1connect.ChatServiceGetUserDataExportProcedure:        {auth: authSession},
+	chatv1connect.ChatServiceDownloadUserDataExportProcedure:   {auth: authSession},
+	chatv1connect.ChatServiceUpdateStreamSubscriptionProcedure: {auth: authSession},
+	chatv1connect.ChatServiceRegisterDeviceKeyProcedure:        {auth: authSession},
+
+	// Sin room_id consulta las claves de usuarios concretos; con room_id, las de los
+	// participantes de la sala
+	chatv1connect.ChatServiceGetDeviceKeysProcedure: {
+		auth:         authSession,
+		room:         requestField((*chatv1.GetDeviceKeysRequest).GetRoomId),
+		optionalRoom: true,
+	},
+
+	// Salas
+	chatv1connect.ChatServiceGetRoomProcedure:             {auth: authSession, room: requestField((*chatv1.GetRoomRequest).GetId)},
+	chatv1connect.ChatServiceGetRoomParticipantsProcedure: {auth: authSession, room: requestField((*chatv1.GetRoomParticipantsRequest).GetId)},
+	chatv1connect.ChatServicePinRoomProcedure:             {auth: authSession, room: requestField((*chatv1.PinRoomRequest).GetId)},
+	chatv1connect.ChatServiceMuteRoomProcedure:            {auth: authSession, room: requestField((*chatv1.MuteRoomRequest).GetId)},
+	// Un member de un grupo solo puede salir él mismo: ni sacar a otros ni cerrar la sala
+	chatv1connect.ChatServiceLeaveRoomProcedure: {
+		auth: authSession,
+		room: requestField((*chatv1.LeaveRoomRequest).GetId),
+		allow: requestRule(func(room *chatv1.Room, req *chatv1.LeaveRoomRequest) bool {
+			return room.Type != "group" || room.Role != "MEMBER" || (len(req.Participants) == 0 && !req.LeaveAll)
+		}),
+	},
+	// Los members editan el grupo si la sala lo permite; la retención solo la cambia el owner
+	chatv1connect.ChatServiceUpdateRoomProcedure: {
+		auth:     authSession,
+		room:     requestField((*chatv1.UpdateRoomRequest).GetId),
+		roomType: "group",
+		allow: requestRule(func(room *chatv1.Room, req *chatv1.UpdateRoomRequest) bool {
+			if req.RetentionDays != nil && room.Role != "OWNER" {
+				return false
+			}
+			return room.Role != "MEMBER" || room.EditGroup
+		}),
+	},
+	chatv1connect.ChatServiceAddParticipantToRoomProcedure: {
+		auth:     authSession,
+		room:     requestField((*chatv1.AddParticipantToRoomRequest).GetId),
+		roomType: "group",
+		allow:    roomRule(func(room *chatv1.Room) bool { return room.Role != "MEMBER" || room.AddMember }),
+	},
+	chatv1connect.ChatServiceUpdateParticipantRoomProcedure: {
+		auth:  authSession,
+		room:  requestField((*chatv1.UpdateParticipantRoomRequest).GetId),
+		roles: []string{"OWNER", "ADMIN"},
+	},
+	chatv1connect.ChatServiceBlockUserProcedure: {
+		auth:     authSession,
+		room:     requestField((*chatv1.BlockUserRequest).GetId),
+		roomType: "p2p",
+	},
+
+	// Mensajes
+	chatv1connect.ChatServiceSendMessageProcedure: {
+		auth:  authSession,
+		room:  requestField((*chatv1.SendMessageRequest).GetRoomId),
+		allow: roomRule(func(room *chatv1.Room) bool { return room.Type != "group" || room.Role != "MEMBER" || room.SendMessage }),
+	},
+	chatv1connect.ChatServiceEditMessageProcedure: {
+		auth:    authSession,
+		message: requestField((*chatv1.EditMessageRequest).GetMessageId),
+		sender:  true,
+	},
+	chatv1connect.ChatServiceDeleteMessageProcedure: {
+		auth:     authSession,
+		room:     requestField((*chatv1.DeleteMessageRequest).GetRoomId),
+		messages: requestList((*chatv1.DeleteMessageRequest).GetMessageIds),
+	},
+	chatv1connect.ChatServiceGetMessageHistoryProcedure:  {auth: authSession, room: requestField((*chatv1.GetMessageHistoryRequest).GetId)},
+	chatv1connect.ChatServiceGetMessageProcedure:         {auth: authSession, message: requestField((*chatv1.GetMessageRequest).GetId)},
+	chatv1connect.ChatServiceReactToMessageProcedure:     {auth: authSession, message: requestField((*chatv1.ReactToMessageRequest).GetMessageId)},
+	chatv1connect.ChatServiceMarkMessagesAsReadProcedure: {auth: authSession, room: requestField((*chatv1.MarkMessagesAsReadRequest).GetRoomId)},
+	// Las lecturas y reacciones de un mensaje solo las consulta quien lo envió
+	chatv1connect.ChatServiceGetMessageReadProcedure: {
+		auth:    authSession,
+		message: requestField((*chatv1.GetMessageReadRequest).GetId),
+		sender:  true,
+	},
+	chatv1connect.ChatServiceGetMessageReactionsProcedure: {
+		auth:    authSession,
+		message: requestField((*chatv1.GetMessageReactionsRequest).GetId),
+		sender:  true,
+	},
+	// El archivo lleva los mensajes descifrados
+	chatv1connect.ChatServiceExportRoomHistoryProcedure: {
+		auth:  authSession,
+		room:  requestField((*chatv1.ExportRoomHistoryRequest).GetId),
+		allow: roomRule(canExportRoomHistory),
+	},
+
+	// Claves de la sala: en grupos la rotan owners y admins
+	chatv1connect.ChatServiceRotateRoomKeyProcedure: {
+		auth:  authSession,
+		room:  requestField((*chatv1.RotateRoomKeyRequest).GetRoomId),
+		allow: roomRule(func(room *chatv1.Room) bool { return room.Type == "p2p" || room.Role != "MEMBER" }),
+	},
+	chatv1connect.ChatServiceGetRoomKeysProcedure:  {auth: authSession, room: requestField((*chatv1.GetRoomKeysRequest).GetRoomId)},
+	chatv1connect.ChatServiceShareRoomKeyProcedure: {auth: authSession, room: requestField((*chatv1.ShareRoomKeyRequest).GetRoomId)},
+}
+
+// requestField adapta el getter de un campo de la petición a roomPolicy.
+func requestField[T proto.Message](get func(T) string) func(proto.Message) string {
+	return func(msg proto.Message) string {
+		req, ok := msg.(T)
+		if !ok {
+			return ""
+		}
+		return get(req)
+	}
+}
+
+func requestList[T proto.Message](get func(T) []string) func(proto.Message) []string {
+	return func(msg proto.Message) []string {
+		req, ok := msg.(T)
+		if !ok {
+			return nil
+		}
+		return get(req)
+	}
+}
+
+// requestRule adapta una regla sobre la sala y la petición a roomPolicy.allow.
+func requestRule[T proto.Message](rule func(*chatv1.Room, T) bool) func(*chatv1.Room, proto.Message) bool {
+	return func(room *chatv1.Room, msg proto.Message) bool {
+		req, ok := msg.(T)
+		return ok && rule(room, req)
+	}
+}
+
+func roomRule(rule func(*chatv1.Room) bool) func(*chatv1.Room, proto.Message) bool {
+	return func(room *chatv1.Room, _ proto.Message) bool {
+		return rule(room)
+	}
+}
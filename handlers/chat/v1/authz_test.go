@@ -0,0 +1,369 @@
+package chatv1handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1/chatv1connect"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+)
+
+// Usuarios del fixture de autorización
+const (
+	authzOwner    = 1
+	authzMember   = 2
+	authzAdmin    = 3
+	authzOutsider = 4
+)
+
+type authzFixture struct {
+	repo     roomsrepository.RoomsRepository
+	group    *chatv1.Room
+	p2p      *chatv1.Room
+	messages map[string]*chatv1.MessageData
+}
+
+// newAuthzFixture crea un grupo de Ana (owner), Luis (member) y Eva (admin) en el que los
+// members no pueden escribir, añadir ni editar, y un p2p entre Ana y Luis. Otto no está en
+// ninguna sala.
+func newAuthzFixture(t *testing.T) *authzFixture {
+	t.Helper()
+	ctx := context.Background()
+
+	repo := roomsrepository.NewMemoryRoomRepository([]roomsrepository.User{
+		{ID: authzOwner, Name: "Ana", Phone: "+5800000001"},
+		{ID: authzMember, Name: "Luis", Phone: "+5800000002"},
+		{ID: authzAdmin, Name: "Eva", Phone: "+5800000003"},
+		{ID: authzOutsider, Name: "Otto", Phone: "+5800000004"},
+	})
+	repo.(*roomsrepository.MemoryRoomRepository).SetKeyGenerator(func() (string, error) { return "authz-key", nil })
+
+	group, err := repo.CreateRoom(ctx, authzOwner, &chatv1.CreateRoomRequest{
+		Type:         "group",
+		Name:         proto.String("Coordinación"),
+		Participants: []int32{authzMember, authzAdmin},
+		SendMessage:  proto.Bool(false),
+		AddMember:    proto.Bool(false),
+		EditGroup:    proto.Bool(false),
+	})
+	if err != nil {
+		t.Fatalf("CreateRoom group: %v", err)
+	}
+	err = repo.UpdateParticipantRoom(ctx, authzOwner, &chatv1.UpdateParticipantRoomRequest{Id: group.Id, Participant: authzAdmin, Role: "ADMIN"})
+	if err != nil {
+		t.Fatalf("UpdateParticipantRoom: %v", err)
+	}
+	p2p, err := repo.CreateRoom(ctx, authzOwner, &chatv1.CreateRoomRequest{Type: "p2p", Participants: []int32{authzMember}})
+	if err != nil {
+		t.Fatalf("CreateRoom p2p: %v", err)
+	}
+
+	send := func(user int, room *chatv1.Room, content string) *chatv1.MessageData {
+		t.Helper()
+		req := &chatv1.SendMessageRequest{RoomId: room.Id, Content: content, Type: "user_message"}
+		msg, err := repo.SaveMessage(ctx, user, req, room, &req.Content)
+		if err != nil {
+			t.Fatalf("SaveMessage: %v", err)
+		}
+		return msg
+	}
+
+	return &authzFixture{
+		repo:  repo,
+		group: group,
+		p2p:   p2p,
+		messages: map[string]*chatv1.MessageData{
+			"owner":  send(authzOwner, group, "hola"),
+			"member": send(authzMember, group, "buenas"),
+			"p2p":    send(authzOwner, p2p, "privado"),
+		},
+	}
+}
+
+// newTestAuthorizer identifica al llamante por las cabeceras X-User (sesión) y X-Public
+// (token público).
+func newTestAuthorizer(rooms roomsrepository.RoomsRepository) *roomAuthorizer {
+	a := newRoomAuthorizer(slog.Default(), rooms)
+	a.session = func(req connect.AnyRequest) (int, error) {
+		userID, err := strconv.Atoi(req.Header().Get("X-User"))
+		if err != nil || userID == 0 {
+			return 0, errors.New("sin sesión")
+		}
+		return userID, nil
+	}
+	a.public = func(header http.Header) bool {
+		return header.Get("X-Public") == "ok"
+	}
+	return a
+}
+
+type authzCase struct {
+	name      string
+	procedure string
+	user      int  // 0 = sin sesión
+	public    bool // con el token público
+	req       connect.AnyRequest
+	code      connect.Code // 0 = permitida
+}
+
+func TestRoomAuthorizer(t *testing.T) {
+	f := newAuthzFixture(t)
+	group, p2p, msgs := f.group.Id, f.p2p.Id, f.messages
+
+	cases := []authzCase{
+		// Endpoints internos
+		{"erase con token público", chatv1connect.ChatServiceEraseUserDataProcedure, 0, true, connect.NewRequest(&chatv1.EraseUserDataRequest{UserId: 9}), 0},
+		{"erase con sesión", chatv1connect.ChatServiceEraseUserDataProcedure, authzOwner, false, connect.NewRequest(&chatv1.EraseUserDataRequest{UserId: 9}), connect.CodeUnauthenticated},
+		{"listar caché con token público", chatv1connect.ChatServiceListRoomCacheKeysProcedure, 0, true, connect.NewRequest(&chatv1.ListRoomCacheKeysRequest{RoomId: group}), 0},
+		{"listar caché sin token", chatv1connect.ChatServiceListRoomCacheKeysProcedure, 0, false, connect.NewRequest(&chatv1.ListRoomCacheKeysRequest{RoomId: group}), connect.CodeUnauthenticated},
+		{"entrada de caché con token público", chatv1connect.ChatServiceGetCacheEntryProcedure, 0, true, connect.NewRequest(&chatv1.GetCacheEntryRequest{}), 0},
+		{"entrada de caché con sesión", chatv1connect.ChatServiceGetCacheEntryProcedure, authzOwner, false, connect.NewRequest(&chatv1.GetCacheEntryRequest{}), connect.CodeUnauthenticated},
+		{"vaciar caché con token público", chatv1connect.ChatServiceFlushCacheProcedure, 0, true, connect.NewRequest(&chatv1.FlushCacheRequest{}), 0},
+		{"vaciar caché sin token", chatv1connect.ChatServiceFlushCacheProcedure, 0, false, connect.NewRequest(&chatv1.FlushCacheRequest{}), connect.CodeUnauthenticated},
+		{"estadísticas con token público", chatv1connect.ChatServiceGetCacheStatsProcedure, 0, true, connect.NewRequest(&chatv1.GetCacheStatsRequest{}), 0},
+		{"estadísticas sin token", chatv1connect.ChatServiceGetCacheStatsProcedure, 0, false, connect.NewRequest(&chatv1.GetCacheStatsRequest{}), connect.CodeUnauthenticated},
+
+		// El stream valida su sesión en el manejador; por la ruta unaria no pasa
+		{"stream por la ruta unaria", chatv1connect.ChatServiceStreamMessagesProcedure, authzOwner, false, connect.NewRequest(&chatv1.StreamMessagesRequest{}), connect.CodePermissionDenied},
+		{"descarga de exportación por la ruta unaria", chatv1connect.ChatServiceDownloadRoomHistoryExportProcedure, authzOwner, false, connect.NewRequest(&chatv1.DownloadRoomHistoryExportRequest{Id: "x"}), connect.CodePermissionDenied},
+
+		// Solo sesión
+		{"crear sala", chatv1connect.ChatServiceCreateRoomProcedure, authzOutsider, false, connect.NewRequest(&chatv1.CreateRoomRequest{}), 0},
+		{"crear sala sin sesión", chatv1connect.ChatServiceCreateRoomProcedure, 0, false, connect.NewRequest(&chatv1.CreateRoomRequest{}), connect.CodeUnauthenticated},
+		{"listar salas", chatv1connect.ChatServiceGetRoomsProcedure, authzMember, false, connect.NewRequest(&chatv1.GetRoomsRequest{}), 0},
+		{"listar salas sin sesión", chatv1connect.ChatServiceGetRoomsProcedure, 0, false, connect.NewRequest(&chatv1.GetRoomsRequest{}), connect.CodeUnauthenticated},
+		{"sync", chatv1connect.ChatServiceInitialSyncProcedure, authzMember, false, connect.NewRequest(&chatv1.InitialSyncRequest{}), 0},
+		{"sync sin sesión", chatv1connect.ChatServiceInitialSyncProcedure, 0, false, connect.NewRequest(&chatv1.InitialSyncRequest{}), connect.CodeUnauthenticated},
+		{"mensaje por sender id", chatv1connect.ChatServiceGetSenderMessageProcedure, authzMember, false, connect.NewRequest(&chatv1.GetSenderMessageRequest{}), 0},
+		{"mensaje por sender id sin sesión", chatv1connect.ChatServiceGetSenderMessageProcedure, 0, false, connect.NewRequest(&chatv1.GetSenderMessageRequest{}), connect.CodeUnauthenticated},
+		{"consultar exportación", chatv1connect.ChatServiceGetRoomHistoryExportProcedure, authzOwner, false, connect.NewRequest(&chatv1.GetRoomHistoryExportRequest{Id: "x"}), 0},
+		{"consultar exportación sin sesión", chatv1connect.ChatServiceGetRoomHistoryExportProcedure, 0, false, connect.NewRequest(&chatv1.GetRoomHistoryExportRequest{Id: "x"}), connect.CodeUnauthenticated},
+		{"exportar datos", chatv1connect.ChatServiceExportUserDataProcedure, authzOutsider, false, connect.NewRequest(&chatv1.ExportUserDataRequest{}), 0},
+		{"exportar datos sin sesión", chatv1connect.ChatServiceExportUserDataProcedure, 0, false, connect.NewRequest(&chatv1.ExportUserDataRequest{}), connect.CodeUnauthenticated},
+		{"consultar exportación de datos", chatv1connect.ChatServiceGetUserDataExportProcedure, authzOutsider, false, connect.NewRequest(&chatv1.GetUserDataExportRequest{Id: "x"}), 0},
+		{"consultar exportación de datos sin sesión", chatv1connect.ChatServiceGetUserDataExportProcedure, 0, false, connect.NewRequest(&chatv1.GetUserDataExportRequest{Id: "x"}), connect.CodeUnauthenticated},
+		{"descargar datos", chatv1connect.ChatServiceDownloadUserDataExportProcedure, authzOutsider, false, connect.NewRequest(&chatv1.DownloadUserDataExportRequest{Handle: "x"}), 0},
+		{"descargar datos sin sesión", chatv1connect.ChatServiceDownloadUserDataExportProcedure, 0, false, connect.NewRequest(&chatv1.DownloadUserDataExportRequest{Handle: "x"}), connect.CodeUnauthenticated},
+		{"actualizar suscripción", chatv1connect.ChatServiceUpdateStreamSubscriptionProcedure, authzMember, false, connect.NewRequest(&chatv1.UpdateStreamSubscriptionRequest{}), 0},
+		{"actualizar suscripción sin sesión", chatv1connect.ChatServiceUpdateStreamSubscriptionProcedure, 0, false, connect.NewRequest(&chatv1.UpdateStreamSubscriptionRequest{}), connect.CodeUnauthenticated},
+		{"registrar dispositivo", chatv1connect.ChatServiceRegisterDeviceKeyProcedure, authzOutsider, false, connect.NewRequest(&chatv1.RegisterDeviceKeyRequest{}), 0},
+		{"registrar dispositivo sin sesión", chatv1connect.ChatServiceRegisterDeviceKeyProcedure, 0, false, connect.NewRequest(&chatv1.RegisterDeviceKeyRequest{}), connect.CodeUnauthenticated},
+
+		// Directorio de claves: la sala es opcional
+		{"claves de usuarios", chatv1connect.ChatServiceGetDeviceKeysProcedure, authzOutsider, false, connect.NewRequest(&chatv1.GetDeviceKeysRequest{UserIds: []int32{authzOwner}}), 0},
+		{"claves de la sala", chatv1connect.ChatServiceGetDeviceKeysProcedure, authzMember, false, connect.NewRequest(&chatv1.GetDeviceKeysRequest{RoomId: proto.String(group)}), 0},
+		{"claves de una sala ajena", chatv1connect.ChatServiceGetDeviceKeysProcedure, authzOutsider, false, connect.NewRequest(&chatv1.GetDeviceKeysRequest{RoomId: proto.String(group)}), connect.CodeNotFound},
+
+		// Salas
+		{"ver sala", chatv1connect.ChatServiceGetRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.GetRoomRequest{Id: group}), 0},
+		{"ver sala ajena", chatv1connect.ChatServiceGetRoomProcedure, authzOutsider, false, connect.NewRequest(&chatv1.GetRoomRequest{Id: group}), connect.CodeNotFound},
+		{"ver sala inexistente", chatv1connect.ChatServiceGetRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.GetRoomRequest{Id: "no-existe"}), connect.CodeNotFound},
+		{"ver sala sin id", chatv1connect.ChatServiceGetRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.GetRoomRequest{}), connect.CodeInvalidArgument},
+		{"ver sala sin sesión", chatv1connect.ChatServiceGetRoomProcedure, 0, false, connect.NewRequest(&chatv1.GetRoomRequest{Id: group}), connect.CodeUnauthenticated},
+		{"participantes", chatv1connect.ChatServiceGetRoomParticipantsProcedure, authzMember, false, connect.NewRequest(&chatv1.GetRoomParticipantsRequest{Id: group}), 0},
+		{"participantes de sala ajena", chatv1connect.ChatServiceGetRoomParticipantsProcedure, authzOutsider, false, connect.NewRequest(&chatv1.GetRoomParticipantsRequest{Id: group}), connect.CodeNotFound},
+		{"fijar sala", chatv1connect.ChatServicePinRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.PinRoomRequest{Id: group}), 0},
+		{"fijar sala ajena", chatv1connect.ChatServicePinRoomProcedure, authzOutsider, false, connect.NewRequest(&chatv1.PinRoomRequest{Id: group}), connect.CodeNotFound},
+		{"silenciar sala", chatv1connect.ChatServiceMuteRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.MuteRoomRequest{Id: p2p}), 0},
+		{"silenciar sala ajena", chatv1connect.ChatServiceMuteRoomProcedure, authzOutsider, false, connect.NewRequest(&chatv1.MuteRoomRequest{Id: p2p}), connect.CodeNotFound},
+		{"member sale del grupo", chatv1connect.ChatServiceLeaveRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.LeaveRoomRequest{Id: group}), 0},
+		{"member saca a otro", chatv1connect.ChatServiceLeaveRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.LeaveRoomRequest{Id: group, Participants: []int32{authzAdmin}}), connect.CodePermissionDenied},
+		{"member cierra el grupo", chatv1connect.ChatServiceLeaveRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.LeaveRoomRequest{Id: group, LeaveAll: true}), connect.CodePermissionDenied},
+		{"admin saca a un member", chatv1connect.ChatServiceLeaveRoomProcedure, authzAdmin, false, connect.NewRequest(&chatv1.LeaveRoomRequest{Id: group, Participants: []int32{authzMember}}), 0},
+		{"member sale del p2p", chatv1connect.ChatServiceLeaveRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.LeaveRoomRequest{Id: p2p}), 0},
+		{"salir de sala ajena", chatv1connect.ChatServiceLeaveRoomProcedure, authzOutsider, false, connect.NewRequest(&chatv1.LeaveRoomRequest{Id: group}), connect.CodeNotFound},
+		{"member edita el grupo", chatv1connect.ChatServiceUpdateRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.UpdateRoomRequest{Id: group, Name: proto.String("x")}), connect.CodePermissionDenied},
+		{"admin edita el grupo", chatv1connect.ChatServiceUpdateRoomProcedure, authzAdmin, false, connect.NewRequest(&chatv1.UpdateRoomRequest{Id: group, Name: proto.String("x")}), 0},
+		{"admin cambia la retención", chatv1connect.ChatServiceUpdateRoomProcedure, authzAdmin, false, connect.NewRequest(&chatv1.UpdateRoomRequest{Id: group, RetentionDays: proto.Int32(30)}), connect.CodePermissionDenied},
+		{"owner cambia la retención", chatv1connect.ChatServiceUpdateRoomProcedure, authzOwner, false, connect.NewRequest(&chatv1.UpdateRoomRequest{Id: group, RetentionDays: proto.Int32(30)}), 0},
+		{"editar un p2p", chatv1connect.ChatServiceUpdateRoomProcedure, authzOwner, false, connect.NewRequest(&chatv1.UpdateRoomRequest{Id: p2p, Name: proto.String("x")}), connect.CodeInvalidArgument},
+		{"member añade participantes", chatv1connect.ChatServiceAddParticipantToRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.AddParticipantToRoomRequest{Id: group, Participants: []int32{authzOutsider}}), connect.CodePermissionDenied},
+		{"admin añade participantes", chatv1connect.ChatServiceAddParticipantToRoomProcedure, authzAdmin, false, connect.NewRequest(&chatv1.AddParticipantToRoomRequest{Id: group, Participants: []int32{authzOutsider}}), 0},
+		{"añadir a un p2p", chatv1connect.ChatServiceAddParticipantToRoomProcedure, authzOwner, false, connect.NewRequest(&chatv1.AddParticipantToRoomRequest{Id: p2p, Participants: []int32{authzOutsider}}), connect.CodeInvalidArgument},
+		{"member cambia un rol", chatv1connect.ChatServiceUpdateParticipantRoomProcedure, authzMember, false, connect.NewRequest(&chatv1.UpdateParticipantRoomRequest{Id: group, Participant: authzAdmin, Role: "MEMBER"}), connect.CodePermissionDenied},
+		{"admin cambia un rol", chatv1connect.ChatServiceUpdateParticipantRoomProcedure, authzAdmin, false, connect.NewRequest(&chatv1.UpdateParticipantRoomRequest{Id: group, Participant: authzMember, Role: "ADMIN"}), 0},
+		{"owner cambia un rol", chatv1connect.ChatServiceUpdateParticipantRoomProcedure, authzOwner, false, connect.NewRequest(&chatv1.UpdateParticipantRoomRequest{Id: group, Participant: authzMember, Role: "ADMIN"}), 0},
+		{"bloquear en p2p", chatv1connect.ChatServiceBlockUserProcedure, authzMember, false, connect.NewRequest(&chatv1.BlockUserRequest{Id: p2p}), 0},
+		{"bloquear en grupo", chatv1connect.ChatServiceBlockUserProcedure, authzOwner, false, connect.NewRequest(&chatv1.BlockUserRequest{Id: group}), connect.CodeInvalidArgument},
+		{"bloquear en sala ajena", chatv1connect.ChatServiceBlockUserProcedure, authzOutsider, false, connect.NewRequest(&chatv1.BlockUserRequest{Id: p2p}), connect.CodeNotFound},
+
+		// Mensajes
+		{"member escribe sin permiso", chatv1connect.ChatServiceSendMessageProcedure, authzMember, false, connect.NewRequest(&chatv1.SendMessageRequest{RoomId: group}), connect.CodePermissionDenied},
+		{"admin escribe en el grupo", chatv1connect.ChatServiceSendMessageProcedure, authzAdmin, false, connect.NewRequest(&chatv1.SendMessageRequest{RoomId: group}), 0},
+		{"member escribe en p2p", chatv1connect.ChatServiceSendMessageProcedure, authzMember, false, connect.NewRequest(&chatv1.SendMessageRequest{RoomId: p2p}), 0},
+		{"escribir en sala ajena", chatv1connect.ChatServiceSendMessageProcedure, authzOutsider, false, connect.NewRequest(&chatv1.SendMessageRequest{RoomId: p2p}), connect.CodeNotFound},
+		{"escribir sin sala", chatv1connect.ChatServiceSendMessageProcedure, authzOwner, false, connect.NewRequest(&chatv1.SendMessageRequest{}), connect.CodeInvalidArgument},
+		{"editar mensaje propio", chatv1connect.ChatServiceEditMessageProcedure, authzOwner, false, connect.NewRequest(&chatv1.EditMessageRequest{MessageId: msgs["owner"].Id}), 0},
+		{"editar mensaje ajeno", chatv1connect.ChatServiceEditMessageProcedure, authzAdmin, false, connect.NewRequest(&chatv1.EditMessageRequest{MessageId: msgs["owner"].Id}), connect.CodePermissionDenied},
+		{"editar mensaje de sala ajena", chatv1connect.ChatServiceEditMessageProcedure, authzOutsider, false, connect.NewRequest(&chatv1.EditMessageRequest{MessageId: msgs["owner"].Id}), connect.CodeNotFound},
+		{"editar mensaje inexistente", chatv1connect.ChatServiceEditMessageProcedure, authzOwner, false, connect.NewRequest(&chatv1.EditMessageRequest{MessageId: "no-existe"}), connect.CodeNotFound},
+		{"editar sin mensaje", chatv1connect.ChatServiceEditMessageProcedure, authzOwner, false, connect.NewRequest(&chatv1.EditMessageRequest{}), connect.CodeInvalidArgument},
+		{"borrar mensajes propios", chatv1connect.ChatServiceDeleteMessageProcedure, authzMember, false, connect.NewRequest(&chatv1.DeleteMessageRequest{RoomId: group, MessageIds: []string{msgs["member"].Id}}), 0},
+		{"borrar mensaje ajeno", chatv1connect.ChatServiceDeleteMessageProcedure, authzMember, false, connect.NewRequest(&chatv1.DeleteMessageRequest{RoomId: group, MessageIds: []string{msgs["member"].Id, msgs["owner"].Id}}), connect.CodePermissionDenied},
+		{"borrar mensaje de otra sala", chatv1connect.ChatServiceDeleteMessageProcedure, authzOwner, false, connect.NewRequest(&chatv1.DeleteMessageRequest{RoomId: group, MessageIds: []string{msgs["p2p"].Id}}), connect.CodeNotFound},
+		{"borrar en sala ajena", chatv1connect.ChatServiceDeleteMessageProcedure, authzOutsider, false, connect.NewRequest(&chatv1.DeleteMessageRequest{RoomId: group, MessageIds: []string{msgs["owner"].Id}}), connect.CodeNotFound},
+		{"historial", chatv1connect.ChatServiceGetMessageHistoryProcedure, authzMember, false, connect.NewRequest(&chatv1.GetMessageHistoryRequest{Id: group}), 0},
+		{"historial de sala ajena", chatv1connect.ChatServiceGetMessageHistoryProcedure, authzOutsider, false, connect.NewRequest(&chatv1.GetMessageHistoryRequest{Id: group}), connect.CodeNotFound},
+		{"ver mensaje", chatv1connect.ChatServiceGetMessageProcedure, authzMember, false, connect.NewRequest(&chatv1.GetMessageRequest{Id: msgs["owner"].Id}), 0},
+		{"ver mensaje de sala ajena", chatv1connect.ChatServiceGetMessageProcedure, authzAdmin, false, connect.NewRequest(&chatv1.GetMessageRequest{Id: msgs["p2p"].Id}), connect.CodeNotFound},
+		{"reaccionar", chatv1connect.ChatServiceReactToMessageProcedure, authzMember, false, connect.NewRequest(&chatv1.ReactToMessageRequest{MessageId: msgs["owner"].Id}), 0},
+		{"reaccionar en sala ajena", chatv1connect.ChatServiceReactToMessageProcedure, authzOutsider, false, connect.NewRequest(&chatv1.ReactToMessageRequest{MessageId: msgs["owner"].Id}), connect.CodeNotFound},
+		{"marcar leídos", chatv1connect.ChatServiceMarkMessagesAsReadProcedure, authzMember, false, connect.NewRequest(&chatv1.MarkMessagesAsReadRequest{RoomId: group}), 0},
+		{"marcar leídos en sala ajena", chatv1connect.ChatServiceMarkMessagesAsReadProcedure, authzOutsider, false, connect.NewRequest(&chatv1.MarkMessagesAsReadRequest{RoomId: group}), connect.CodeNotFound},
+		{"lecturas del mensaje propio", chatv1connect.ChatServiceGetMessageReadProcedure, authzOwner, false, connect.NewRequest(&chatv1.GetMessageReadRequest{Id: msgs["owner"].Id}), 0},
+		{"lecturas de mensaje ajeno", chatv1connect.ChatServiceGetMessageReadProcedure, authzMember, false, connect.NewRequest(&chatv1.GetMessageReadRequest{Id: msgs["owner"].Id}), connect.CodePermissionDenied},
+		{"reacciones del mensaje propio", chatv1connect.ChatServiceGetMessageReactionsProcedure, authzMember, false, connect.NewRequest(&chatv1.GetMessageReactionsRequest{Id: msgs["member"].Id}), 0},
+		{"reacciones de mensaje ajeno", chatv1connect.ChatServiceGetMessageReactionsProcedure, authzOwner, false, connect.NewRequest(&chatv1.GetMessageReactionsRequest{Id: msgs["member"].Id}), connect.CodePermissionDenied},
+		{"owner exporta", chatv1connect.ChatServiceExportRoomHistoryProcedure, authzOwner, false, connect.NewRequest(&chatv1.ExportRoomHistoryRequest{Id: group}), 0},
+		{"admin exporta", chatv1connect.ChatServiceExportRoomHistoryProcedure, authzAdmin, false, connect.NewRequest(&chatv1.ExportRoomHistoryRequest{Id: group}), 0},
+		{"member exporta", chatv1connect.ChatServiceExportRoomHistoryProcedure, authzMember, false, connect.NewRequest(&chatv1.ExportRoomHistoryRequest{Id: group}), connect.CodePermissionDenied},
+
+		// Claves de la sala
+		{"member rota la clave del grupo", chatv1connect.ChatServiceRotateRoomKeyProcedure, authzMember, false, connect.NewRequest(&chatv1.RotateRoomKeyRequest{RoomId: group}), connect.CodePermissionDenied},
+		{"admin rota la clave del grupo", chatv1connect.ChatServiceRotateRoomKeyProcedure, authzAdmin, false, connect.NewRequest(&chatv1.RotateRoomKeyRequest{RoomId: group}), 0},
+		{"member rota la clave del p2p", chatv1connect.ChatServiceRotateRoomKeyProcedure, authzMember, false, connect.NewRequest(&chatv1.RotateRoomKeyRequest{RoomId: p2p}), 0},
+		{"claves de la sala propia", chatv1connect.ChatServiceGetRoomKeysProcedure, authzMember, false, connect.NewRequest(&chatv1.GetRoomKeysRequest{RoomId: group}), 0},
+		{"claves de sala ajena", chatv1connect.ChatServiceGetRoomKeysProcedure, authzOutsider, false, connect.NewRequest(&chatv1.GetRoomKeysRequest{RoomId: group}), connect.CodeNotFound},
+		{"entregar clave", chatv1connect.ChatServiceShareRoomKeyProcedure, authzMember, false, connect.NewRequest(&chatv1.ShareRoomKeyRequest{RoomId: group}), 0},
+		{"entregar clave en sala ajena", chatv1connect.ChatServiceShareRoomKeyProcedure, authzOutsider, false, connect.NewRequest(&chatv1.ShareRoomKeyRequest{RoomId: group}), connect.CodeNotFound},
+
+		{"rpc sin política", "/services.chat.v1.ChatService/Unknown", authzOwner, false, connect.NewRequest(&chatv1.GetRoomRequest{Id: group}), connect.CodePermissionDenied},
+	}
+
+	authorizer := newTestAuthorizer(f.repo)
+	covered := map[string]bool{}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.user != 0 {
+				tc.req.Header().Set("X-User", strconv.Itoa(tc.user))
+			}
+			if tc.public {
+				tc.req.Header().Set("X-Public", "ok")
+			}
+
+			access, err := authorizer.authorize(context.Background(), tc.procedure, tc.req)
+			if tc.code == 0 {
+				if err != nil {
+					t.Fatalf("debía permitirse: %v", err)
+				}
+				if access.userID != tc.user {
+					t.Fatalf("usuario %d, se esperaba %d", access.userID, tc.user)
+				}
+				return
+			}
+			if got := connect.CodeOf(err); err == nil || got != tc.code {
+				t.Fatalf("código %v (%v), se esperaba %v", got, err, tc.code)
+			}
+		})
+		covered[tc.procedure] = true
+	}
+
+	// Cada RPC del servicio tiene política y al menos un caso en la tabla
+	methods := chatv1.File_services_chat_v1_service_proto.Services().ByName("ChatService").Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		procedure := fmt.Sprintf("/%s/%s", chatv1connect.ChatServiceName, method.Name())
+		policy, ok := roomPolicies[procedure]
+		if !ok {
+			t.Errorf("%s no tiene política de autorización", procedure)
+			continue
+		}
+		if streaming := method.IsStreamingServer() || method.IsStreamingClient(); streaming != (policy.auth == authStream) {
+			t.Errorf("%s: la política no corresponde al tipo de RPC", procedure)
+		}
+		if !covered[procedure] {
+			t.Errorf("%s no tiene casos en la tabla", procedure)
+		}
+	}
+}
+
+// El acceso resuelto llega al manejador: la sala y el mensaje de la petición.
+func TestRoomAuthorizerAccess(t *testing.T) {
+	f := newAuthzFixture(t)
+	authorizer := newTestAuthorizer(f.repo)
+
+	req := connect.NewRequest(&chatv1.GetMessageRequest{Id: f.messages["member"].Id})
+	req.Header().Set("X-User", strconv.Itoa(authzAdmin))
+	access, err := authorizer.authorize(context.Background(), chatv1connect.ChatServiceGetMessageProcedure, req)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if access.room == nil || access.room.Id != f.group.Id || access.room.Role != "ADMIN" {
+		t.Fatalf("sala resuelta inesperada: %v", access.room)
+	}
+	if access.message == nil || access.message.Id != f.messages["member"].Id {
+		t.Fatalf("mensaje resuelto inesperado: %v", access.message)
+	}
+
+	if _, err := roomAccessFrom(context.Background()); connect.CodeOf(err) != connect.CodeInternal {
+		t.Fatalf("sin interceptor debe fallar cerrado: %v", err)
+	}
+}
+
+type failingRooms struct {
+	roomsrepository.RoomsRepository
+}
+
+func (failingRooms) GetRoom(ctx context.Context, userId int, roomId string, allData bool, cache bool) (*chatv1.Room, error) {
+	return nil, errors.New("sin conexión")
+}
+
+func TestRoomAuthorizerRepositoryError(t *testing.T) {
+	f := newAuthzFixture(t)
+	authorizer := newTestAuthorizer(failingRooms{f.repo})
+
+	req := connect.NewRequest(&chatv1.GetRoomRequest{Id: f.group.Id})
+	req.Header().Set("X-User", strconv.Itoa(authzOwner))
+	if _, err := authorizer.authorize(context.Background(), chatv1connect.ChatServiceGetRoomProcedure, req); connect.CodeOf(err) != connect.CodeInternal {
+		t.Fatalf("se esperaba Internal: %v", err)
+	}
+}
+
+type fakeStreamConn struct {
+	connect.StreamingHandlerConn
+	procedure string
+}
+
+func (c fakeStreamConn) Spec() connect.Spec         { return connect.Spec{Procedure: c.procedure} }
+func (c fakeStreamConn) RequestHeader() http.Header { return http.Header{} }
+
+func TestRoomAuthorizerStreaming(t *testing.T) {
+	authorizer := newTestAuthorizer(nil)
+	next := authorizer.WrapStreamingHandler(func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		return nil
+	})
+
+	if err := next(context.Background(), fakeStreamConn{procedure: chatv1connect.ChatServiceStreamMessagesProcedure}); err != nil {
+		t.Fatalf("StreamMessages debe pasar: %v", err)
+	}
+	err := next(context.Background(), fakeStreamConn{procedure: chatv1connect.ChatServiceGetRoomProcedure})
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Fatalf("una RPC unaria no puede servirse como stream: %v", err)
+	}
+}
+
+// NewServiceHandler instala la autorización: sin sesión la petición no llega al manejador.
+func TestServiceHandlerInstallsAuthorizer(t *testing.T) {
+	f := newAuthzFixture(t)
+	path, handler := NewServiceHandler(HandlerDeps{Rooms: f.repo, MemoryExports: true})
+	mux := http.NewServeMux()
+	mux.Handle(path, handler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := chatv1connect.NewChatServiceClient(server.Client(), server.URL)
+	_, err := client.GetRoom(context.Background(), connect.NewRequest(&chatv1.GetRoomRequest{Id: f.group.Id}))
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Fatalf("se esperaba Unauthenticated: %v", err)
+	}
+}
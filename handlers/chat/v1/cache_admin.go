@@ -0,0 +1,145 @@
+package chatv1handler
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/api"
+)
+
+// Endpoints internos para inspeccionar y limpiar la caché de salas y mensajes, por ejemplo
+// cuando un usuario ve un nombre de sala viejo. Se autentican con el token público, igual que
+// EraseUserData. Las estadísticas y el LRU son de la réplica que atiende la petición.
+
+const cacheFlushRoomsPageSize = 50
+
+func (h *handlerImpl) ListRoomCacheKeys(ctx context.Context, req *connect.Request[chatv1.ListRoomCacheKeysRequest]) (*connect.Response[chatv1.ListRoomCacheKeysResponse], error) {
+	if _, err := roomAccessFrom(ctx); err != nil {
+		return nil, err
+	}
+	if req.Msg.RoomId == "" {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+
+	keys, err := roomsrepository.RoomCacheKeys(ctx, req.Msg.RoomId)
+	if err != nil {
+		h.logger.Error("Error listando la caché de la sala", "roomID", req.Msg.RoomId, "error", err)
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+
+	res := &chatv1.ListRoomCacheKeysResponse{
+		Keys:    make([]*chatv1.RoomCacheKey, len(keys)),
+		Replica: roomsrepository.CacheReplica(),
+	}
+	for i, key := range keys {
+		res.Keys[i] = &chatv1.RoomCacheKey{Key: key.Key, InRedis: key.InRedis, InLocal: key.InLocal}
+	}
+	return connect.NewResponse(res), nil
+}
+
+func (h *handlerImpl) GetCacheEntry(ctx context.Context, req *connect.Request[chatv1.GetCacheEntryRequest]) (*connect.Response[chatv1.GetCacheEntryResponse], error) {
+	if _, err := roomAccessFrom(ctx); err != nil {
+		return nil, err
+	}
+
+	entries, err := roomsrepository.GetCacheEntry(ctx, req.Msg.Key)
+	if errors.Is(err, roomsrepository.ErrInvalidCacheKey) {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+	if err != nil {
+		h.logger.Error("Error leyendo la entrada de caché", "key", req.Msg.Key, "error", err)
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+
+	res := &chatv1.GetCacheEntryResponse{
+		Entries: make([]*chatv1.CacheEntry, 0, len(entries)),
+		Replica: roomsrepository.CacheReplica(),
+	}
+	for _, entry := range entries {
+		cached := &chatv1.CacheEntry{Tier: entry.Tier}
+		switch value := entry.Value.(type) {
+		case *chatv1.Room:
+			cached.Value = &chatv1.CacheEntry_Room{Room: value}
+		case *chatv1.MessageData:
+			cached.Value = &chatv1.CacheEntry_Message{Message: value}
+		default:
+			cached.Value = &chatv1.CacheEntry_Raw{Raw: entry.Raw}
+		}
+		res.Entries = append(res.Entries, cached)
+	}
+	return connect.NewResponse(res), nil
+}
+
+func (h *handlerImpl) FlushCache(ctx context.Context, req *connect.Request[chatv1.FlushCacheRequest]) (*connect.Response[chatv1.FlushCacheResponse], error) {
+	if _, err := roomAccessFrom(ctx); err != nil {
+		return nil, err
+	}
+
+	var deleted int
+	var err error
+	switch target := req.Msg.Target.(type) {
+	case *chatv1.FlushCacheRequest_RoomId:
+		if target.RoomId == "" {
+			return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+		}
+		deleted, err = roomsrepository.FlushRoomCache(ctx, target.RoomId)
+	case *chatv1.FlushCacheRequest_UserId:
+		if target.UserId <= 0 {
+			return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+		}
+		deleted, err = h.flushUserCache(ctx, int(target.UserId))
+	default:
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+	if err != nil {
+		h.logger.Error("Error limpiando la caché", "roomID", req.Msg.GetRoomId(), "userID", req.Msg.GetUserId(), "error", err)
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+
+	h.logger.Info("Caché limpiada", "roomID", req.Msg.GetRoomId(), "userID", req.Msg.GetUserId(), "keysDeleted", deleted)
+	return connect.NewResponse(&chatv1.FlushCacheResponse{KeysDeleted: int32(deleted)}), nil
+}
+
+// flushUserCache invalida la caché de todas las salas actuales del usuario.
+func (h *handlerImpl) flushUserCache(ctx context.Context, userID int) (int, error) {
+	var roomIDs []string
+	cursor := ""
+	for {
+		page, meta, err := h.roomsRepository.GetRoomList(ctx, userID, &chatv1.GetRoomsRequest{Limit: cacheFlushRoomsPageSize, Cursor: cursor})
+		if err != nil {
+			return 0, err
+		}
+		for _, room := range page {
+			roomIDs = append(roomIDs, room.Id)
+		}
+		if len(page) == 0 || meta == nil || meta.NextCursor == "" {
+			break
+		}
+		cursor = meta.NextCursor
+	}
+	return roomsrepository.FlushUserCache(ctx, userID, roomIDs)
+}
+
+func (h *handlerImpl) GetCacheStats(ctx context.Context, req *connect.Request[chatv1.GetCacheStatsRequest]) (*connect.Response[chatv1.GetCacheStatsResponse], error) {
+	if _, err := roomAccessFrom(ctx); err != nil {
+		return nil, err
+	}
+
+	return connect.NewResponse(&chatv1.GetCacheStatsResponse{
+		Rooms:    cacheStatsToProto(roomsrepository.RoomCacheStats()),
+		Messages: cacheStatsToProto(roomsrepository.MessageCacheStats()),
+		Replica:  roomsrepository.CacheReplica(),
+	}), nil
+}
+
+func cacheStatsToProto(stats roomsrepository.CacheStats) *chatv1.CacheStats {
+	return &chatv1.CacheStats{
+		LocalHits: stats.LocalHits,
+		RedisHits: stats.RemoteHits,
+		Misses:    stats.Misses,
+	}
+}
@@ -0,0 +1,130 @@
+package chatv1handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"connectrpc.com/connect"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/api"
+)
+
+// Salas cifradas de extremo a extremo (ver repository/rooms/e2e.go). El servidor no tiene la
+// clave de estas salas: guarda las claves públicas de los dispositivos y reparte las claves de
+// sala que los clientes envuelven para cada dispositivo. Lo que necesita el texto plano (la
+// vista previa del push, content_decrypted) queda vacío o con un texto genérico.
+
+const (
+	// Usuarios por consulta al directorio de claves
+	maxDeviceKeyUsers = 100
+	// Vista previa del push de los mensajes de salas e2e
+	e2ePushPreview = "Mensaje cifrado"
+)
+
+func (h *handlerImpl) RegisterDeviceKey(ctx context.Context, req *connect.Request[chatv1.RegisterDeviceKeyRequest]) (*connect.Response[chatv1.RegisterDeviceKeyResponse], error) {
+	access, err := roomAccessFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := access.userID
+
+	if req.Msg.DeviceId == "" || req.Msg.PublicKey == "" {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+
+	if err := h.roomsRepository.RegisterDeviceKey(ctx, userID, req.Msg.DeviceId, req.Msg.PublicKey); err != nil {
+		h.logger.Error("Error registrando la clave del dispositivo", "userID", userID, "deviceID", req.Msg.DeviceId, "error", err)
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+
+	return connect.NewResponse(&chatv1.RegisterDeviceKeyResponse{Success: true}), nil
+}
+
+func (h *handlerImpl) GetDeviceKeys(ctx context.Context, req *connect.Request[chatv1.GetDeviceKeysRequest]) (*connect.Response[chatv1.GetDeviceKeysResponse], error) {
+	access, err := roomAccessFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var roomID string
+	var userIDs []int
+	if access.room != nil {
+		// La autorización ya comprobó que el llamante participa en la sala
+		roomID = access.room.Id
+	} else {
+		if len(req.Msg.UserIds) == 0 || len(req.Msg.UserIds) > maxDeviceKeyUsers {
+			return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+		}
+		for _, id := range req.Msg.UserIds {
+			userIDs = append(userIDs, int(id))
+		}
+		slices.Sort(userIDs)
+		userIDs = slices.Compact(userIDs)
+	}
+
+	keys, err := h.roomsRepository.GetDeviceKeys(ctx, userIDs, roomID)
+	if err != nil {
+		h.logger.Error("Error leyendo las claves de los dispositivos", "roomID", roomID, "error", err)
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+
+	return connect.NewResponse(&chatv1.GetDeviceKeysResponse{Keys: keys}), nil
+}
+
+func (h *handlerImpl) ShareRoomKey(ctx context.Context, req *connect.Request[chatv1.ShareRoomKeyRequest]) (*connect.Response[chatv1.ShareRoomKeyResponse], error) {
+	access, err := roomAccessFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := access.userID
+
+	if req.Msg.DeviceId == "" {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+
+	room := access.room
+
+	err = h.roomsRepository.ShareRoomKey(ctx, userID, room.Id, req.Msg.KeyVersion, req.Msg.DeviceId, req.Msg.Keys)
+	if err != nil {
+		return nil, h.e2eKeyError(err, "Error entregando la clave de la sala", room.Id, req.Header())
+	}
+
+	h.outbox.notify()
+
+	return connect.NewResponse(&chatv1.ShareRoomKeyResponse{Success: true}), nil
+}
+
+// rotateE2ERoomKey es RotateRoomKey en salas e2e: el cliente genera la clave nueva y la
+// entrega envuelta para los dispositivos de los participantes.
+func (h *handlerImpl) rotateE2ERoomKey(ctx context.Context, userID int, room *chatv1.Room, req *connect.Request[chatv1.RotateRoomKeyRequest]) (*connect.Response[chatv1.RotateRoomKeyResponse], error) {
+	if req.Msg.GetDeviceId() == "" {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+
+	version, err := h.roomsRepository.RotateE2ERoomKey(ctx, userID, room.Id, req.Msg.GetDeviceId(), req.Msg.Keys)
+	if err != nil {
+		return nil, h.e2eKeyError(err, "Error rotando la clave de la sala e2e", room.Id, req.Header())
+	}
+
+	h.outbox.notify()
+
+	return connect.NewResponse(&chatv1.RotateRoomKeyResponse{KeyVersion: version}), nil
+}
+
+// e2eKeyError convierte los errores de validación de las claves envueltas en
+// InvalidRequestDataCode; el resto se registra y se devuelve como error interno.
+func (h *handlerImpl) e2eKeyError(err error, msg string, roomID string, header http.Header) error {
+	switch {
+	case errors.Is(err, roomsrepository.ErrInvalidWrappedKeys),
+		errors.Is(err, roomsrepository.ErrUnknownDevice),
+		errors.Is(err, roomsrepository.ErrUnknownKeyVersion),
+		errors.Is(err, roomsrepository.ErrNotE2ERoom):
+		return api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, header)
+	}
+	h.logger.Error(msg, "roomID", roomID, "error", err)
+	return api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, header)
+}
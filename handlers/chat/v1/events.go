@@ -17,6 +17,8 @@ func (e ChatEvent) Subject() string {
 	switch detail := e.event.Event.(type) {
 	case *chatv1.MessageEvent_RoomJoin:
 		return chatDirectEventSubject(int(detail.RoomJoin.UserId))
+	case *chatv1.MessageEvent_ReadState:
+		return chatDirectEventSubject(int(detail.ReadState.UserId))
 	default:
 		return chatRoomEventSubject(e.roomID)
 	}
@@ -23,6 +23,7 @@ import (
 	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
 	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1/chatv1connect"
 	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	tokensrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/tokens"
 	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
 	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/api"
 	natsmanager "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/broker/nats"
@@ -32,15 +33,51 @@ import (
 
 type handlerImpl struct {
 	logger          *slog.Logger
-	nc              *nats.Conn                                 // Cliente de NATS
-	js              jetstream.JetStream                        // Nuevo cliente de JetStream
-	sm              *events.StreamManager[chatv1.MessageEvent] // Gestor de streams para la instancia actual
-	dispatcher      *events.EventDispatcher
+	nc              *nats.Conn          // Cliente de NATS
+	js              jetstream.JetStream // Nuevo cliente de JetStream
+	dispatcher      Dispatcher
 	roomsRepository roomsrepository.RoomsRepository
+	outbox          *outboxRelay // Publica los eventos escritos en el outbox por las mutaciones
+	exports         *roomExportJobs
+	userExports     *userExportJobs
+	erasure         *userErasure
 }
 
-// NewHandler crea una nueva instancia del manejador del servicio de chat.
-func NewHandler() chatv1connect.ChatServiceHandler {
+// Dispatcher reparte los eventos de chat en segundo plano; *events.EventDispatcher lo
+// implementa y los tests pueden sustituirlo por uno que solo registre los eventos.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, event events.Event)
+}
+
+// HandlerDeps son las dependencias del manejador. newHandlerDeps las construye a partir de
+// NATS y la base de datos; NewServiceHandler permite inyectarlas (por ejemplo
+// repositorios en memoria) para probar la lógica del manejador de forma aislada.
+type HandlerDeps struct {
+	Logger     *slog.Logger
+	NC         *nats.Conn          // Opcional: sin él StreamMessages y UpdateStreamSubscription responden Unavailable
+	JetStream  jetstream.JetStream // Opcional: sin él no se arranca el relay del outbox y StreamMessages responde Unavailable
+	Dispatcher Dispatcher
+	Rooms      roomsrepository.RoomsRepository
+	Tokens     tokensrepository.TokensRepository // Opcional: sin él el borrado y la exportación de usuarios no tocan los tokens
+
+	// Guarda el estado y el archivo de las exportaciones en memoria en vez de en la caché
+	// compartida; solo sirve con una instancia (CHAT_STORE_MODE=memory y tests)
+	MemoryExports bool
+
+	// Retención del historial: días por defecto (0 = para siempre) y cada cuánto se purga.
+	// Con RetentionInterval en cero no se arranca el job.
+	RetentionDays     int
+	RetentionInterval time.Duration
+
+	// Cada cuánto se reconcilian los contadores de no leídos de Redis; cero no arranca el job
+	UnreadReconcileInterval time.Duration
+
+	// Cada cuánto se anulan los seq reservados sin mensaje; cero no arranca el job
+	SeqReconcileInterval time.Duration
+}
+
+// newHandlerDeps construye las dependencias del manejador a partir de NATS y la base de datos.
+func newHandlerDeps() HandlerDeps {
 	nm, err := natsmanager.Get()
 	if err != nil {
 		log.Fatal(err)
@@ -62,38 +99,171 @@ func NewHandler() chatv1connect.ChatServiceHandler {
 	}
 
 	// Inicializar streams de NATS
-	if err := natsmanager.EnsureStreams(context.Background(), js, requiredStreams...); err != nil {
+	streams := append(append([]jetstream.StreamConfig{}, requiredStreams...), userErasureStreams()...)
+	if err := natsmanager.EnsureStreams(context.Background(), js, streams...); err != nil {
 		log.Printf("Error al inicializar streams de NATS: %v", err)
 		// No fatal, pero registramos el error
 	}
 
 	logger := slog.Default()
-	repo := roomsrepository.NewSQLRoomRepository(database.DB())
-	if scylladb, _ := strconv.ParseBool(os.Getenv("USE_SCYLLADB")); scylladb {
-		repo = roomsrepository.NewScyllaRoomRepository(database.CQLDB(), repo)
-	}
 	dispatcher, err := events.NewEventDispatcher(nc, logger, 5)
 	if err != nil {
 		log.Fatalf("Failed to create event dispatcher: %v", err)
 	}
 
-	return &handlerImpl{
-		logger:          logger,
-		sm:              events.NewStreamManager[chatv1.MessageEvent](logger),
-		nc:              nc,
-		js:              js,
-		roomsRepository: repo,
-		dispatcher:      dispatcher,
+	return HandlerDeps{
+		Logger:     logger,
+		NC:         nc,
+		JetStream:  js,
+		Dispatcher: dispatcher,
+		Rooms:      newRoomsRepository(),
+		Tokens:     newTokensRepository(),
+
+		MemoryExports: os.Getenv("CHAT_STORE_MODE") == "memory",
+
+		RetentionDays:     retentionDefaultDays(),
+		RetentionInterval: retentionPurgeInterval,
+
+		UnreadReconcileInterval: unreadReconcileInterval,
+		SeqReconcileInterval:    seqReconcileInterval,
+	}
+}
+
+// newHandlerWithDeps crea el manejador con las dependencias indicadas. Los métodos esperan el
+// acceso que resuelve roomAuthorizer: hay que servirlo con NewServiceHandler.
+func newHandlerWithDeps(deps HandlerDeps) chatv1connect.ChatServiceHandler {
+	if deps.Logger == nil {
+		deps.Logger = slog.Default()
+	}
+
+	h := &handlerImpl{
+		logger:          deps.Logger,
+		nc:              deps.NC,
+		js:              deps.JetStream,
+		roomsRepository: deps.Rooms,
+		dispatcher:      deps.Dispatcher,
+		exports:         newRoomExportJobs(deps.Logger, deps.Rooms, deps.MemoryExports),
+		userExports:     newUserExportJobs(deps.Logger, deps.Rooms, deps.Tokens, deps.MemoryExports),
+	}
+
+	if deps.JetStream != nil {
+		h.outbox = newOutboxRelay(deps.Logger, deps.JetStream, deps.Rooms)
+		go h.outbox.run(context.Background())
+	}
+
+	h.erasure = &userErasure{logger: deps.Logger, rooms: deps.Rooms, tokens: deps.Tokens, outbox: h.outbox}
+	if deps.NC != nil {
+		// Las invalidaciones de la caché de salas llegan al LRU de todas las réplicas
+		if _, err := roomsrepository.StartCacheInvalidation(deps.NC); err != nil {
+			deps.Logger.Error("No se pudo suscribir a las invalidaciones de caché", "error", err)
+		}
+	}
+	if deps.JetStream != nil {
+		if _, err := h.erasure.consume(context.Background(), deps.JetStream); err != nil {
+			deps.Logger.Error("No se pudo suscribir al evento de usuario eliminado", "error", err)
+		}
+	}
+
+	if deps.RetentionInterval > 0 {
+		job := &retentionJob{
+			logger:      deps.Logger,
+			repo:        deps.Rooms,
+			outbox:      h.outbox,
+			defaultDays: deps.RetentionDays,
+			interval:    deps.RetentionInterval,
+		}
+		go job.run(context.Background())
+	}
+
+	if deps.UnreadReconcileInterval > 0 {
+		job := &unreadReconcileJob{
+			logger:   deps.Logger,
+			repo:     deps.Rooms,
+			interval: deps.UnreadReconcileInterval,
+		}
+		go job.run(context.Background())
+	}
+
+	if deps.SeqReconcileInterval > 0 {
+		job := &seqReconcileJob{
+			logger:   deps.Logger,
+			repo:     deps.Rooms,
+			interval: deps.SeqReconcileInterval,
+		}
+		go job.run(context.Background())
+	}
+
+	return h
+}
+
+// newRoomsRepository elige el store según CHAT_STORE_MODE:
+//   - postgres (por defecto): solo Postgres.
+//   - dual: escribe en Postgres y refleja cada mutación en Scylla; lee de Postgres.
+//   - dual-verify: como dual, y además compara cada lectura contra Scylla.
+//   - scylla: solo Scylla (equivale a USE_SCYLLADB=true, que se mantiene por compatibilidad).
+//   - memory: todo en memoria, para desarrollo local; los usuarios se cargan del JSON
+//     indicado en CHAT_MEMORY_USERS. Los datos se pierden al reiniciar.
+//
+// Ver SCYLLA_GUIDE.md para el backfill y el cut-over entre modos.
+func newRoomsRepository() roomsrepository.RoomsRepository {
+	mode := os.Getenv("CHAT_STORE_MODE")
+	if mode == "" {
+		mode = "postgres"
+		if scylladb, _ := strconv.ParseBool(os.Getenv("USE_SCYLLADB")); scylladb {
+			mode = "scylla"
+		}
+	}
+	if mode == "memory" {
+		return newMemoryRoomsRepository(os.Getenv("CHAT_MEMORY_USERS"))
+	}
+
+	// Con Redis configurado, los no leídos de Postgres salen de contadores (ver unread_counters.go)
+	sqlRepo := roomsrepository.NewSQLRoomRepositoryWithUnreadCounters(database.DB(), database.Redis())
+	if mode != "postgres" && database.CQLDB() == nil {
+		log.Fatalf("CHAT_STORE_MODE=%s requiere una conexión a Scylla", mode)
+	}
+
+	var repo roomsrepository.RoomsRepository
+	switch mode {
+	case "postgres":
+		repo = sqlRepo
+	case "scylla":
+		repo = roomsrepository.NewScyllaRoomRepository(database.CQLDB(), sqlRepo)
+	case "dual", "dual-verify":
+		scyllaRepo := roomsrepository.NewScyllaRoomRepository(database.CQLDB(), sqlRepo)
+		mirror := roomsrepository.NewScyllaBackfill(database.DB(), database.CQLDB())
+		repo = roomsrepository.NewDualWriteRoomRepository(sqlRepo, scyllaRepo, mirror, mode == "dual-verify")
+	default:
+		log.Fatalf("CHAT_STORE_MODE inválido: %q (postgres, dual, dual-verify, scylla o memory)", mode)
+	}
+
+	// Las lecturas concurrentes de la misma sala, mensaje o usuario comparten consulta
+	return roomsrepository.NewCoalescingRoomRepository(repo)
+}
+
+// newMemoryRoomsRepository crea el repositorio en memoria sembrando los usuarios del
+// fichero JSON indicado ([{"id": 1, "name": "...", "phone": "..."}]).
+func newMemoryRoomsRepository(usersFile string) roomsrepository.RoomsRepository {
+	var users []roomsrepository.User
+	if usersFile != "" {
+		data, err := os.ReadFile(usersFile)
+		if err != nil {
+			log.Fatalf("No se pudo leer CHAT_MEMORY_USERS: %v", err)
+		}
+		if err := json.Unmarshal(data, &users); err != nil {
+			log.Fatalf("CHAT_MEMORY_USERS inválido: %v", err)
+		}
 	}
+	return roomsrepository.NewMemoryRoomRepository(users)
 }
 
 // CreateRoom implements chatv1connect.ChatServiceHandler.
 func (h *handlerImpl) CreateRoom(ctx context.Context, req *connect.Request[chatv1.CreateRoomRequest]) (*connect.Response[chatv1.CreateRoomResponse], error) {
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userID := access.userID
 
 	if len(req.Msg.Participants) < 1 {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
@@ -114,27 +284,8 @@ func (h *handlerImpl) CreateRoom(ctx context.Context, req *connect.Request[chatv
 
 	generalParams, _ := api.GeneralParamsFromConnectRequest(req)
 
-	req.Msg.Participants = append(req.Msg.Participants, int32(userID))
-
-	joinedAt := time.Now().UTC().Format(time.RFC3339)
-	for _, id := range req.Msg.Participants {
-		newRoomObject := proto.Clone(room).(*chatv1.Room)
-		if id == int32(userID) {
-			newRoomObject.Role = "OWNER"
-		} else {
-			newRoomObject.Role = "MEMBER"
-		}
-		event := &chatv1.MessageEvent{
-			RoomId: room.Id,
-			Event: &chatv1.MessageEvent_RoomJoin{RoomJoin: &chatv1.RoomJoinEvent{
-				JoinedAt:    joinedAt,
-				UserId:      id,
-				OwnerUserId: int32(userID),
-			}},
-		}
-
-		h.publishChatEvent(generalParams, room.GetId(), event)
-	}
+	// Los RoomJoin se escribieron en el outbox junto con la sala
+	h.outbox.notify()
 	room.Role = "OWNER"
 
 	//subcribirse al topico del grupo
@@ -161,13 +312,16 @@ func (h *handlerImpl) CreateRoom(ctx context.Context, req *connect.Request[chatv
 }
 
 func (h *handlerImpl) GetRooms(ctx context.Context, req *connect.Request[chatv1.GetRoomsRequest]) (*connect.Response[chatv1.GetRoomsResponse], error) {
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userID := access.userID
 
 	rooms, meta, err := h.roomsRepository.GetRoomList(ctx, userID, req.Msg)
+	if errors.Is(err, roomsrepository.ErrInvalidCursor) {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -185,11 +339,13 @@ func (h *handlerImpl) GetRooms(ctx context.Context, req *connect.Request[chatv1.
 }
 
 func (h *handlerImpl) GetRoom(ctx context.Context, req *connect.Request[chatv1.GetRoomRequest]) (*connect.Response[chatv1.GetRoomResponse], error) {
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userID := access.userID
 
+	// La autorización ya comprobó la sala; aquí se lee completa y sin caché
 	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.Id, true, false)
 	if err != nil {
 		return nil, err
@@ -208,34 +364,21 @@ func (h *handlerImpl) GetRoom(ctx context.Context, req *connect.Request[chatv1.G
 }
 
 func (h *handlerImpl) LeaveRoom(ctx context.Context, req *connect.Request[chatv1.LeaveRoomRequest]) (*connect.Response[chatv1.LeaveRoomResponse], error) {
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userID := access.userID
 
 	generalParams, _ := api.GeneralParamsFromConnectRequest(req)
 
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.Id, true, true)
-	if err != nil {
-		return nil, err
-	}
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
+	// Los members de un grupo solo pueden salir ellos mismos (ver authz_policies.go)
+	room := access.room
 
 	switch room.Type {
 	case "p2p":
 		req.Msg.LeaveAll = true
 	case "group":
-		if room.Role == "MEMBER" && len(req.Msg.Participants) > 0 {
-			return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
-		}
-
-		if req.Msg.LeaveAll && room.Role == "MEMBER" {
-			return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
-		}
-
 		if len(req.Msg.Participants) == 0 {
 			req.Msg.Participants = []int32{int32(userID)}
 		}
@@ -260,10 +403,14 @@ func (h *handlerImpl) LeaveRoom(ctx context.Context, req *connect.Request[chatv1
 		return nil, err
 	}
 
+	if room.Type == "group" && !room.E2E && !req.Msg.LeaveAll {
+		h.rotateAfterRemoval(ctx, userID, room.Id)
+	}
+
 	if !req.Msg.LeaveAll {
+		// El relay del outbox publica los mensajes de sistema
 		for _, user := range users {
-
-			msg, err := h.roomsRepository.SaveMessage(ctx, userID, &chatv1.SendMessageRequest{
+			_, err := h.roomsRepository.SaveMessage(ctx, userID, &chatv1.SendMessageRequest{
 				RoomId:  room.Id,
 				Content: user.Phone,
 				Type:    "system_message",
@@ -272,14 +419,6 @@ func (h *handlerImpl) LeaveRoom(ctx context.Context, req *connect.Request[chatv1
 			if err != nil {
 				return nil, err
 			}
-
-			event := &chatv1.MessageEvent{
-				Event: &chatv1.MessageEvent_Message{
-					Message: msg,
-				},
-			}
-
-			h.publishChatEvent(generalParams, room.GetId(), event)
 		}
 	}
 
@@ -302,13 +441,8 @@ func (h *handlerImpl) LeaveRoom(ctx context.Context, req *connect.Request[chatv1
 		}
 	}
 
-	event := &chatv1.MessageEvent{
-		Event: &chatv1.MessageEvent_RoomLeave{RoomLeave: &chatv1.RoomLeaveEvent{
-			UsersId: req.Msg.Participants,
-		}},
-	}
-
-	h.publishChatEvent(generalParams, room.GetId(), event)
+	// El evento RoomLeave se escribió en el outbox junto con la salida de la sala
+	h.outbox.notify()
 
 	return connect.NewResponse(&chatv1.LeaveRoomResponse{
 		Success: true,
@@ -317,21 +451,14 @@ func (h *handlerImpl) LeaveRoom(ctx context.Context, req *connect.Request[chatv1
 }
 
 func (h *handlerImpl) GetRoomParticipants(ctx context.Context, req *connect.Request[chatv1.GetRoomParticipantsRequest]) (*connect.Response[chatv1.GetRoomParticipantsResponse], error) {
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
-	if err != nil {
-		return nil, err
-	}
-
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.Id, false, true)
-	if err != nil {
+	if _, err := roomAccessFrom(ctx); err != nil {
 		return nil, err
 	}
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
 
 	participants, meta, err := h.roomsRepository.GetRoomParticipants(ctx, req.Msg)
+	if errors.Is(err, roomsrepository.ErrInvalidCursor) {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -347,19 +474,13 @@ func (h *handlerImpl) GetRoomParticipants(ctx context.Context, req *connect.Requ
 // PinRoom implements chatv1connect.ChatServiceHandler.
 func (h *handlerImpl) PinRoom(ctx context.Context, req *connect.Request[chatv1.PinRoomRequest]) (*connect.Response[chatv1.PinRoomResponse], error) {
 
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userID := access.userID
 
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.Id, false, true)
-	if err != nil {
-		return nil, err
-	}
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
+	room := access.room
 
 	err = h.roomsRepository.PinRoom(ctx, userID, req.Msg.Id, !room.IsPinned)
 	if err != nil {
@@ -370,18 +491,13 @@ func (h *handlerImpl) PinRoom(ctx context.Context, req *connect.Request[chatv1.P
 }
 
 func (h *handlerImpl) MuteRoom(ctx context.Context, req *connect.Request[chatv1.MuteRoomRequest]) (*connect.Response[chatv1.MuteRoomResponse], error) {
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userID := access.userID
 
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.Id, false, true)
-	if err != nil {
-		return nil, err
-	}
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
+	room := access.room
 
 	room.IsMuted = !room.IsMuted
 
@@ -420,36 +536,20 @@ func (h *handlerImpl) MuteRoom(ctx context.Context, req *connect.Request[chatv1.
 		}
 	}
 
-	event := &chatv1.MessageEvent{
-		RoomId: room.Id,
-		Event:  &chatv1.MessageEvent_IsRoomUpdated{IsRoomUpdated: true},
-	}
-
-	h.publishChatEvent(generalParams, room.GetId(), event)
+	// El evento is_room_updated se escribió en el outbox junto con el cambio
+	h.outbox.notify()
 
 	return connect.NewResponse(&chatv1.MuteRoomResponse{Success: true}), nil
 }
 
 func (h *handlerImpl) UpdateRoom(ctx context.Context, req *connect.Request[chatv1.UpdateRoomRequest]) (*connect.Response[chatv1.UpdateRoomResponse], error) {
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userID := access.userID
 
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.Id, false, true)
-	if err != nil {
-		return nil, err
-	}
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
-	if room.Type != "group" {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
-	if room.Role == "MEMBER" && !room.EditGroup {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
+	room := access.room
 
 	err = h.roomsRepository.UpdateRoom(ctx, userID, room.Id, req.Msg)
 	if err != nil {
@@ -461,18 +561,9 @@ func (h *handlerImpl) UpdateRoom(ctx context.Context, req *connect.Request[chatv
 		return nil, err
 	}
 
-	generalParams, _ := api.GeneralParamsFromConnectRequest(req)
-
-	event := &chatv1.MessageEvent{
-		RoomId: roomNew.Id,
-		Event:  &chatv1.MessageEvent_IsRoomUpdated{IsRoomUpdated: true},
-	}
-
-	h.publishChatEvent(generalParams, room.GetId(), event)
-
 	// mensaje de sistema para notificar cambio de nombre
 	if req.Msg.Name != nil && *req.Msg.Name != room.Name {
-		msg, err := h.roomsRepository.SaveMessage(ctx, userID, &chatv1.SendMessageRequest{
+		_, err := h.roomsRepository.SaveMessage(ctx, userID, &chatv1.SendMessageRequest{
 			RoomId:  roomNew.Id,
 			Content: *req.Msg.Name,
 			Type:    "system_message",
@@ -482,19 +573,11 @@ func (h *handlerImpl) UpdateRoom(ctx context.Context, req *connect.Request[chatv
 			return nil, err
 		}
 
-		event = &chatv1.MessageEvent{
-			RoomId: roomNew.Id,
-			Event: &chatv1.MessageEvent_Message{
-				Message: msg,
-			},
-		}
-
-		h.publishChatEvent(generalParams, room.GetId(), event)
 	}
 
 	// mensaje de sistema para notificar cambio de foto
 	if req.Msg.PhotoUrl != nil && *req.Msg.PhotoUrl != room.PhotoUrl {
-		msg, err := h.roomsRepository.SaveMessage(ctx, userID, &chatv1.SendMessageRequest{
+		_, err := h.roomsRepository.SaveMessage(ctx, userID, &chatv1.SendMessageRequest{
 			RoomId:  roomNew.Id,
 			Content: *req.Msg.PhotoUrl,
 			Type:    "system_message",
@@ -504,41 +587,23 @@ func (h *handlerImpl) UpdateRoom(ctx context.Context, req *connect.Request[chatv
 			return nil, err
 		}
 
-		event = &chatv1.MessageEvent{
-			RoomId: roomNew.Id,
-			Event: &chatv1.MessageEvent_Message{
-				Message: msg,
-			},
-		}
-
-		h.publishChatEvent(generalParams, room.GetId(), event)
 	}
 
+	// is_room_updated y los mensajes de sistema se escribieron en el outbox
+	h.outbox.notify()
+
 	return connect.NewResponse(&chatv1.UpdateRoomResponse{Success: true}), nil
 }
 
 func (h *handlerImpl) AddParticipantToRoom(ctx context.Context, req *connect.Request[chatv1.AddParticipantToRoomRequest]) (*connect.Response[chatv1.AddParticipantToRoomResponse], error) {
 
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
-	if err != nil {
-		return nil, err
-	}
-
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.Id, false, true)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
+	userID := access.userID
 
-	if room.Type != "group" {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
-	if room.Role == "MEMBER" && !room.AddMember {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
+	room := access.room
 
 	var participants []int
 	for _, id := range req.Msg.Participants {
@@ -551,11 +616,11 @@ func (h *handlerImpl) AddParticipantToRoom(ctx context.Context, req *connect.Req
 
 	generalParams, _ := api.GeneralParamsFromConnectRequest(req)
 
-	joinedAt := time.Now().UTC().Format(time.RFC3339)
+	// Los RoomJoin se escribieron en el outbox junto con los participantes
 	for _, participant := range participantsData {
 		//crear mensaje de notificacion
 
-		msg, err := h.roomsRepository.SaveMessage(ctx, userID, &chatv1.SendMessageRequest{
+		_, err := h.roomsRepository.SaveMessage(ctx, userID, &chatv1.SendMessageRequest{
 			RoomId:  room.Id,
 			Content: participant.Phone,
 			Type:    "system_message",
@@ -564,28 +629,10 @@ func (h *handlerImpl) AddParticipantToRoom(ctx context.Context, req *connect.Req
 		if err != nil {
 			return nil, err
 		}
-
-		event := &chatv1.MessageEvent{
-			RoomId: room.Id,
-			Event: &chatv1.MessageEvent_Message{
-				Message: msg,
-			},
-		}
-
-		h.publishChatEvent(generalParams, msg.RoomId, event)
-
-		room.Role = "MEMBER"
-		event = &chatv1.MessageEvent{
-			RoomId: room.Id,
-			Event: &chatv1.MessageEvent_RoomJoin{RoomJoin: &chatv1.RoomJoinEvent{
-				JoinedAt: joinedAt,
-				UserId:   int32(participant.ID),
-			}},
-		}
-
-		h.publishChatEvent(generalParams, room.GetId(), event)
 	}
 
+	h.outbox.notify()
+
 	//suscribirse al topico
 	if _, err := notificationsv1client.SubscribeToTopic(context.Background(), generalParams, &notificationsv1.SubscribeToTopicRequest{
 		Event: &notificationsv1.SubscribeToTopicRequest_Data{
@@ -602,61 +649,32 @@ func (h *handlerImpl) AddParticipantToRoom(ctx context.Context, req *connect.Req
 }
 
 func (h *handlerImpl) UpdateParticipantRoom(ctx context.Context, req *connect.Request[chatv1.UpdateParticipantRoomRequest]) (*connect.Response[chatv1.UpdateParticipantRoomResponse], error) {
-	userID, err := utils.ValidateAuthToken(req)
-	if err != nil {
-		return nil, err
-	}
-
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.Id, false, true)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
-
-	if room.Role == "MEMBER" {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
+	userID := access.userID
 
 	err = h.roomsRepository.UpdateParticipantRoom(ctx, userID, req.Msg)
 	if err != nil {
 		return nil, err
 	}
 
-	generalParams, _ := api.GeneralParamsFromConnectRequest(req)
-
-	event := &chatv1.MessageEvent{
-		RoomId: room.Id,
-		Event:  &chatv1.MessageEvent_IsRoomUpdated{IsRoomUpdated: true},
-	}
-
-	h.publishChatEvent(generalParams, room.GetId(), event)
+	// El evento is_room_updated se escribió en el outbox junto con el cambio de rol
+	h.outbox.notify()
 
 	return connect.NewResponse(&chatv1.UpdateParticipantRoomResponse{Success: true}), nil
 }
 
 func (h *handlerImpl) BlockUser(ctx context.Context, req *connect.Request[chatv1.BlockUserRequest]) (*connect.Response[chatv1.BlockUserResponse], error) {
 
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
-	if err != nil {
-		return nil, err
-	}
-
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.Id, false, true)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userID := access.userID
 
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
-
-	if room.Type != "p2p" {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
+	room := access.room
 
 	partnerID := int(room.Partner.Id)
 	err = h.roomsRepository.BlockUser(ctx, userID, req.Msg.Id, !room.IsPartnerBlocked, &partnerID)
@@ -674,41 +692,36 @@ func (h *handlerImpl) SendMessage(ctx context.Context, req *connect.Request[chat
 		return nil, err
 	}
 
-	userID, err := utils.ValidateAuthToken(req)
-	if err != nil {
-		return nil, err
-	}
-
-	if req.Msg.RoomId == "" {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
-	}
-
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.RoomId, false, true)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userID := access.userID
 
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
-
-	room = utils.FormatRoom(room)
+	room := utils.FormatRoom(access.room)
 
-	if room.Type == "group" && room.Role == "MEMBER" && !room.SendMessage {
+	if len(req.Msg.Mentions) > 0 && room.Type == "p2p" {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
 	}
 
-	if len(req.Msg.Mentions) > 0 && room.Type == "p2p" {
+	if room.Type == "p2p" && room.IsPartnerBlocked {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
 	}
 
-	if room.Type == "p2p" && room.IsPartnerBlocked {
+	// El mensaje guarda la versión de la clave con la que se cifró
+	keyVersion, encryptionData, err := h.messageKey(ctx, room, req.Msg.KeyVersion)
+	if errors.Is(err, roomsrepository.ErrUnknownKeyVersion) {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
 	}
+	if err != nil {
+		return nil, err
+	}
+	req.Msg.KeyVersion = &keyVersion
 
+	// En salas e2e el servidor no puede descifrar: content_decrypted queda vacío
 	var contentDecrypted string
-	if req.Msg.Content != "" {
-		contentDecrypted, err = utils.DecryptMessage(req.Msg.Content, room.EncryptionData)
+	if req.Msg.Content != "" && !room.E2E {
+		contentDecrypted, err = utils.DecryptMessage(req.Msg.Content, encryptionData)
 		if err != nil {
 			h.logger.Error("Error al desencriptar el contenido", "error", err)
 		}
@@ -721,6 +734,9 @@ func (h *handlerImpl) SendMessage(ctx context.Context, req *connect.Request[chat
 		return nil, err
 	}
 
+	// Los eventos del mensaje (status y mensaje) los publica el relay del outbox
+	h.outbox.notify()
+
 	h.dispatcher.Dispatch(context.Background(), events.FanoutEvent{
 		OnFanount: func(ctx context.Context, event events.FanoutEvent) {
 			//TODO: Revisar si esto es necesario, porque si son muchos participantes, puede tardar.
@@ -734,28 +750,6 @@ func (h *handlerImpl) SendMessage(ctx context.Context, req *connect.Request[chat
 				h.logger.Info("Successfully fanned out message metadata", "roomID", msg.Id, "messageID", msg.SenderId)
 			}
 
-			senderEvent := &chatv1.MessageEvent{
-				RoomId: room.Id,
-				Event: &chatv1.MessageEvent_StatusUpdate{
-					StatusUpdate: &chatv1.MessageStatusUpdate{
-						MessageId: msg.GetId(),
-						Status:    msg.GetStatus(),
-						UpdatedAt: msg.GetUpdatedAt(),
-						UserId:    int32(userID),
-						SenderId:  int32(userID),
-					},
-				},
-			}
-			remitentsEvent := &chatv1.MessageEvent{
-				RoomId: room.Id,
-				Event: &chatv1.MessageEvent_Message{
-					Message: msg,
-				},
-			}
-
-			h.publishChatEvent(generalParams, msg.RoomId, senderEvent)
-			h.publishChatEvent(generalParams, msg.RoomId, remitentsEvent)
-
 			var participantsIds []int32
 			if room.Type == "p2p" {
 				participantsIds = append(participantsIds, int32(room.Partner.Id))
@@ -787,6 +781,11 @@ func (h *handlerImpl) SendMessage(ctx context.Context, req *connect.Request[chat
 				}
 			}
 
+			pushPreview := contentDecrypted
+			if room.E2E {
+				pushPreview = e2ePushPreview
+			}
+
 			if sendPushNotification {
 
 				if _, err := notificationsv1client.SendPushNotificationEvent(context.Background(), generalParams, &notificationsv1.SendPushNotificationRequest{
@@ -798,7 +797,7 @@ func (h *handlerImpl) SendMessage(ctx context.Context, req *connect.Request[chat
 							RoomName:          room.Name,
 							RoomId:            room.Id,
 							RoomType:          room.Type,
-							MessageContent:    contentDecrypted,
+							MessageContent:    pushPreview,
 						},
 					},
 				}); err != nil {
@@ -817,15 +816,15 @@ func (h *handlerImpl) SendMessage(ctx context.Context, req *connect.Request[chat
 
 // EditMessage implementa la lógica para editar un mensaje.
 func (h *handlerImpl) EditMessage(ctx context.Context, req *connect.Request[chatv1.EditMessageRequest]) (*connect.Response[chatv1.EditMessageResponse], error) {
-	generalParams, err := api.GeneralParamsFromConnectRequest(req)
-	if err != nil {
+	if _, err := api.GeneralParamsFromConnectRequest(req); err != nil {
 		return nil, err
 	}
 
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.UnauthorizedCode, req.Header())
+		return nil, err
 	}
+	userID := access.userID
 
 	message, err := h.roomsRepository.GetMessage(ctx, userID, req.Msg.MessageId)
 	if err != nil {
@@ -835,24 +834,36 @@ func (h *handlerImpl) EditMessage(ctx context.Context, req *connect.Request[chat
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
 	}
 
-	if message.SenderId != int32(userID) {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.UnauthorizedCode, req.Header())
+	// Igual que en SendMessage: el contenido nuevo puede venir cifrado con otra versión de la
+	// clave que el original (por ejemplo, si la clave rotó desde que se envió)
+	room := utils.FormatRoom(access.room)
+	keyVersion, encryptionData, err := h.messageKey(ctx, room, req.Msg.KeyVersion)
+	if errors.Is(err, roomsrepository.ErrUnknownKeyVersion) {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+	if err != nil {
+		return nil, err
+	}
+	req.Msg.KeyVersion = &keyVersion
+
+	var contentDecrypted string
+	if req.Msg.NewContent != "" && !room.E2E {
+		contentDecrypted, err = utils.DecryptMessage(req.Msg.NewContent, encryptionData)
+		if err != nil {
+			h.logger.Error("Error al desencriptar el contenido", "error", err)
+		}
 	}
 
-	err = h.roomsRepository.UpdateMessage(ctx, userID, req.Msg.MessageId, req.Msg.NewContent)
+	err = h.roomsRepository.UpdateMessage(ctx, userID, req.Msg, &contentDecrypted)
 	if err != nil {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
 	}
 
 	message.Content = req.Msg.NewContent
+	message.KeyVersion = keyVersion
 	message.Edited = true
 
-	event := &chatv1.MessageEvent{
-		RoomId: message.RoomId,
-		Event:  &chatv1.MessageEvent_UpdateMessage{UpdateMessage: message},
-	}
-
-	h.publishChatEvent(generalParams, message.RoomId, event)
+	h.outbox.notify()
 
 	response := &chatv1.EditMessageResponse{
 		Success: true,
@@ -863,40 +874,23 @@ func (h *handlerImpl) EditMessage(ctx context.Context, req *connect.Request[chat
 
 // DeleteMessage implementa la lógica para eliminar un mensaje.
 func (h *handlerImpl) DeleteMessage(ctx context.Context, req *connect.Request[chatv1.DeleteMessageRequest]) (*connect.Response[chatv1.DeleteMessageResponse], error) {
-	generalParams, err := api.GeneralParamsFromConnectRequest(req)
-	if err != nil {
+	if _, err := api.GeneralParamsFromConnectRequest(req); err != nil {
 		return nil, err
 	}
 
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
-	if err != nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.UnauthorizedCode, req.Header())
-	}
-
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.RoomId, false, true)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
-	}
-
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
+		return nil, err
 	}
+	userID := access.userID
 
+	// La autorización comprobó que todos los mensajes son de la sala y del llamante
 	err = h.roomsRepository.DeleteMessage(ctx, userID, req.Msg.MessageIds)
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("no se pudo eliminar el mensaje: %w", err))
 	}
 
-	for i := 0; i < len(req.Msg.MessageIds); i++ {
-
-		event := &chatv1.MessageEvent{
-			Event: &chatv1.MessageEvent_DeleteMessage{DeleteMessage: req.Msg.MessageIds[i]},
-		}
-
-		h.publishChatEvent(generalParams, req.Msg.RoomId, event)
-
-	}
+	h.outbox.notify()
 
 	response := &chatv1.DeleteMessageResponse{Success: true}
 	return connect.NewResponse(response), nil
@@ -904,29 +898,22 @@ func (h *handlerImpl) DeleteMessage(ctx context.Context, req *connect.Request[ch
 
 func (h *handlerImpl) GetMessageHistory(ctx context.Context, req *connect.Request[chatv1.GetMessageHistoryRequest]) (*connect.Response[chatv1.GetMessageHistoryResponse], error) {
 
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.UnauthorizedCode, req.Header())
+		return nil, err
 	}
+	userID := access.userID
 
-	if req.Msg.Id == "" {
+	// El rango por seq debe ser válido: 0 <= after_seq < before_seq
+	if (req.Msg.AfterSeq != nil && *req.Msg.AfterSeq < 0) || (req.Msg.BeforeSeq != nil && *req.Msg.BeforeSeq < 1) ||
+		(req.Msg.AfterSeq != nil && req.Msg.BeforeSeq != nil && *req.Msg.AfterSeq >= *req.Msg.BeforeSeq) {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
 	}
 
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.Id, false, true)
-	if err != nil {
-		return nil, err
-	}
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
-
-	//if room.Role == "MEMBER" {
-	//	return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	//}
-
 	messages, meta, err := h.roomsRepository.GetMessagesFromRoom(ctx, userID, req.Msg)
+	if errors.Is(err, roomsrepository.ErrInvalidCursor) {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -940,11 +927,11 @@ func (h *handlerImpl) GetMessageHistory(ctx context.Context, req *connect.Reques
 }
 
 func (h *handlerImpl) GetMessage(ctx context.Context, req *connect.Request[chatv1.GetMessageRequest]) (*connect.Response[chatv1.MessageData], error) {
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.UnauthorizedCode, req.Header())
+		return nil, err
 	}
+	userID := access.userID
 
 	message, err := h.roomsRepository.GetMessage(ctx, userID, req.Msg.Id)
 	if err != nil {
@@ -955,59 +942,32 @@ func (h *handlerImpl) GetMessage(ctx context.Context, req *connect.Request[chatv
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
 	}
 
-	room, err := h.roomsRepository.GetRoom(ctx, userID, message.RoomId, false, true)
-	if err != nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
-	}
-
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
-
 	return connect.NewResponse(message), nil
 }
 
 func (h *handlerImpl) ReactToMessage(ctx context.Context, req *connect.Request[chatv1.ReactToMessageRequest]) (*connect.Response[chatv1.ReactToMessageResponse], error) {
 
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.UnauthorizedCode, req.Header())
+		return nil, err
 	}
+	userID := access.userID
 
-	message, err := h.roomsRepository.GetMessage(ctx, userID, req.Msg.MessageId)
+	err = h.roomsRepository.ReactToMessage(ctx, userID, req.Msg.MessageId, req.Msg.Reaction)
 	if err != nil {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
 	}
 
-	if message == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
+	return connect.NewResponse(&chatv1.ReactToMessageResponse{Success: true}), nil
+}
+
+func (h *handlerImpl) InitialSync(ctx context.Context, req *connect.Request[chatv1.InitialSyncRequest]) (*connect.Response[chatv1.InitialSyncResponse], error) {
 
-	room, err := h.roomsRepository.GetRoom(ctx, userID, message.RoomId, false, true)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
-	}
-
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
-
-	err = h.roomsRepository.ReactToMessage(ctx, userID, req.Msg.MessageId, req.Msg.Reaction)
-	if err != nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
-	}
-
-	return connect.NewResponse(&chatv1.ReactToMessageResponse{Success: true}), nil
-}
-
-func (h *handlerImpl) InitialSync(ctx context.Context, req *connect.Request[chatv1.InitialSyncRequest]) (*connect.Response[chatv1.InitialSyncResponse], error) {
-
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
-	if err != nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.UnauthorizedCode, req.Header())
+		return nil, err
 	}
+	userID := access.userID
 
 	//get current timestamp
 	now := time.Now()
@@ -1055,18 +1015,11 @@ func (h *handlerImpl) InitialSync(ctx context.Context, req *connect.Request[chat
 }
 
 func (h *handlerImpl) MarkMessagesAsRead(ctx context.Context, req *connect.Request[chatv1.MarkMessagesAsReadRequest]) (*connect.Response[chatv1.MarkMessagesAsReadResponse], error) {
-	userID, err := utils.ValidateAuthToken(req)
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	room, err := h.roomsRepository.GetRoom(ctx, userID, req.Msg.RoomId, false, true)
-	if err != nil {
-		return nil, err
-	}
-	if room == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
+	userID := access.userID
 
 	var since string
 	if len(req.Msg.MessageIds) > 0 {
@@ -1085,34 +1038,9 @@ func (h *handlerImpl) MarkMessagesAsRead(ctx context.Context, req *connect.Reque
 		return nil, err
 	}
 
-	generalParams, _ := api.GeneralParamsFromConnectRequest(req)
-
-	readAt := time.Now().UTC().Format(time.RFC3339)
-	for _, msgId := range req.Msg.MessageIds {
-		var senderId int32
-		msg, err := h.roomsRepository.GetMessageSimple(ctx, userID, msgId)
-		if err != nil {
-			return nil, err
-		}
-		if msg == nil {
-			return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-		}
-		senderId = msg.SenderId
-		statusUpdate := &chatv1.MessageStatusUpdate{
-			MessageId: msgId,
-			UpdatedAt: readAt,
-			UserId:    int32(userID),
-			Status:    chatv1.MessageStatus_MESSAGE_STATUS_READ,
-			SenderId:  senderId,
-		}
-		event := &chatv1.MessageEvent{
-			Event: &chatv1.MessageEvent_StatusUpdate{
-				StatusUpdate: statusUpdate,
-			},
-		}
-
-		h.publishChatEvent(generalParams, room.GetId(), event)
-	}
+	// El estado READ de cada mensaje y el read_state de las demás sesiones del usuario se
+	// escribieron en el outbox junto con la lectura
+	h.outbox.notify()
 
 	return connect.NewResponse(&chatv1.MarkMessagesAsReadResponse{
 		Success:     true,
@@ -1121,45 +1049,54 @@ func (h *handlerImpl) MarkMessagesAsRead(ctx context.Context, req *connect.Reque
 }
 
 func (h *handlerImpl) GetMessageRead(ctx context.Context, req *connect.Request[chatv1.GetMessageReadRequest]) (*connect.Response[chatv1.GetMessageReadResponse], error) {
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
-	if err != nil {
+	// Solo quien envió el mensaje (ver authz_policies.go)
+	if _, err := roomAccessFrom(ctx); err != nil {
 		return nil, err
 	}
 
-	message, err := h.roomsRepository.GetMessageSimple(ctx, userID, req.Msg.Id)
+	items, meta, err := h.roomsRepository.GetMessageRead(ctx, req.Msg)
+	if errors.Is(err, roomsrepository.ErrInvalidCursor) {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
 	if err != nil {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
 	}
 
-	if message == nil {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
-	}
+	return connect.NewResponse(&chatv1.GetMessageReadResponse{
+		Items: items,
+		Meta:  meta,
+	}), nil
 
-	if message.SenderId != int32(userID) {
-		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
+}
+
+func (h *handlerImpl) GetMessageReactions(ctx context.Context, req *connect.Request[chatv1.GetMessageReactionsRequest]) (*connect.Response[chatv1.GetMessageReactionsResponse], error) {
+	// Solo quien envió el mensaje (ver authz_policies.go)
+	if _, err := roomAccessFrom(ctx); err != nil {
+		return nil, err
 	}
 
-	items, meta, err := h.roomsRepository.GetMessageRead(ctx, req.Msg)
+	items, meta, err := h.roomsRepository.GetMessageReactions(ctx, req.Msg)
+	if errors.Is(err, roomsrepository.ErrInvalidCursor) {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
 	if err != nil {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
 	}
 
-	return connect.NewResponse(&chatv1.GetMessageReadResponse{
+	return connect.NewResponse(&chatv1.GetMessageReactionsResponse{
 		Items: items,
 		Meta:  meta,
 	}), nil
-
 }
 
-func (h *handlerImpl) GetMessageReactions(ctx context.Context, req *connect.Request[chatv1.GetMessageReactionsRequest]) (*connect.Response[chatv1.GetMessageReactionsResponse], error) {
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
+func (h *handlerImpl) GetSenderMessage(ctx context.Context, req *connect.Request[chatv1.GetSenderMessageRequest]) (*connect.Response[chatv1.GetSenderMessageResponse], error) {
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userID := access.userID
 
-	message, err := h.roomsRepository.GetMessageSimple(ctx, userID, req.Msg.Id)
+	message, err := h.roomsRepository.GetMessageSender(ctx, userID, req.Msg.SenderMessageId)
 	if err != nil {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
 	}
@@ -1168,42 +1105,199 @@ func (h *handlerImpl) GetMessageReactions(ctx context.Context, req *connect.Requ
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
 	}
 
-	if message.SenderId != int32(userID) {
+	return connect.NewResponse(&chatv1.GetSenderMessageResponse{
+		Status: message.Status,
+	}), nil
+}
+
+// ExportRoomHistory lanza en segundo plano la exportación del historial de una sala. El
+// archivo lleva los mensajes descifrados, así que solo la pueden pedir owners y admins.
+func (h *handlerImpl) ExportRoomHistory(ctx context.Context, req *connect.Request[chatv1.ExportRoomHistoryRequest]) (*connect.Response[chatv1.ExportRoomHistoryResponse], error) {
+	access, err := roomAccessFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := access.userID
+
+	if roomExportContentType(req.Msg.Format) == "" {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+
+	room := access.room
+
+	export, err := h.exports.start(ctx, userID, room, req.Msg.Format)
+	if err != nil {
+		h.logger.Error("Error iniciando la exportación", "roomID", room.Id, "error", err)
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+
+	return connect.NewResponse(&chatv1.ExportRoomHistoryResponse{Export: export}), nil
+}
+
+// GetRoomHistoryExport devuelve el progreso de una exportación. El archivo se descarga con
+// DownloadRoomHistoryExport. Solo la consulta quien la pidió.
+func (h *handlerImpl) GetRoomHistoryExport(ctx context.Context, req *connect.Request[chatv1.GetRoomHistoryExportRequest]) (*connect.Response[chatv1.GetRoomHistoryExportResponse], error) {
+	access, err := roomAccessFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := access.userID
+
+	if req.Msg.Id == "" {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+
+	export, err := h.exports.get(ctx, req.Msg.Id)
+	if err != nil {
+		h.logger.Error("Error consultando la exportación", "exportID", req.Msg.Id, "error", err)
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+	if export == nil || int(export.RequestedBy) != userID {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
 	}
 
-	items, meta, err := h.roomsRepository.GetMessageReactions(ctx, req.Msg)
+	return connect.NewResponse(&chatv1.GetRoomHistoryExportResponse{Export: export}), nil
+}
+
+// DownloadRoomHistoryExport envía en trozos el archivo de una exportación terminada. El
+// primer mensaje lleva además el nombre, el tipo y el tamaño del archivo. Solo la descarga
+// quien la pidió.
+func (h *handlerImpl) DownloadRoomHistoryExport(ctx context.Context, req *connect.Request[chatv1.DownloadRoomHistoryExportRequest], stream *connect.ServerStream[chatv1.DownloadRoomHistoryExportResponse]) error {
+	generalParams, err := api.GeneralParamsFromConnectRequest(req)
+	if err != nil {
+		return err
+	}
+
+	session, err := api.CheckSessionFromGeneralParams(generalParams)
 	if err != nil {
+		return err
+	}
+
+	if req.Msg.Id == "" {
+		return api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+
+	export, err := h.exports.get(ctx, req.Msg.Id)
+	if err != nil {
+		h.logger.Error("Error consultando la exportación", "exportID", req.Msg.Id, "error", err)
+		return api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+	if export == nil || int(export.RequestedBy) != session.UserID {
+		return api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
+	}
+	if export.Status != chatv1.ExportStatus_EXPORT_STATUS_COMPLETED {
+		return api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+
+	first := true
+	err = h.exports.download(ctx, export.Id, func(chunk []byte) error {
+		resp := &chatv1.DownloadRoomHistoryExportResponse{Chunk: chunk}
+		if first {
+			resp.FileName = export.FileName
+			resp.ContentType = export.ContentType
+			resp.SizeBytes = export.SizeBytes
+			first = false
+		}
+		return stream.Send(resp)
+	})
+	if err != nil {
+		h.logger.Error("Error descargando la exportación", "exportID", export.Id, "error", err)
+		return api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+	return nil
+}
+
+// ExportUserData lanza en segundo plano la exportación de los datos de chat del usuario.
+func (h *handlerImpl) ExportUserData(ctx context.Context, req *connect.Request[chatv1.ExportUserDataRequest]) (*connect.Response[chatv1.ExportUserDataResponse], error) {
+	access, err := roomAccessFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := access.userID
+
+	export, err := h.userExports.start(ctx, userID)
+	if err != nil {
+		h.logger.Error("Error iniciando la exportación de datos", "userID", userID, "error", err)
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
 	}
 
-	return connect.NewResponse(&chatv1.GetMessageReactionsResponse{
-		Items: items,
-		Meta:  meta,
-	}), nil
+	return connect.NewResponse(&chatv1.ExportUserDataResponse{Export: export}), nil
 }
 
-func (h *handlerImpl) GetSenderMessage(ctx context.Context, req *connect.Request[chatv1.GetSenderMessageRequest]) (*connect.Response[chatv1.GetSenderMessageResponse], error) {
-	//validate auth token
-	userID, err := utils.ValidateAuthToken(req)
+// GetUserDataExport devuelve el progreso de una exportación de datos y, cuando termina, el
+// handle para descargarla. Solo la consulta el propio usuario.
+func (h *handlerImpl) GetUserDataExport(ctx context.Context, req *connect.Request[chatv1.GetUserDataExportRequest]) (*connect.Response[chatv1.GetUserDataExportResponse], error) {
+	access, err := roomAccessFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userID := access.userID
 
-	message, err := h.roomsRepository.GetMessageSender(ctx, userID, req.Msg.SenderMessageId)
+	if req.Msg.Id == "" {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+
+	export, err := h.userExports.get(ctx, req.Msg.Id)
 	if err != nil {
+		h.logger.Error("Error consultando la exportación de datos", "exportID", req.Msg.Id, "error", err)
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
 	}
+	if export == nil || int(export.UserId) != userID {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
+	}
 
-	if message == nil {
+	return connect.NewResponse(&chatv1.GetUserDataExportResponse{Export: export}), nil
+}
+
+// DownloadUserDataExport devuelve el archivo de una exportación de datos. Un handle
+// caducado, ajeno o inválido responde NotFound.
+func (h *handlerImpl) DownloadUserDataExport(ctx context.Context, req *connect.Request[chatv1.DownloadUserDataExportRequest]) (*connect.Response[chatv1.DownloadUserDataExportResponse], error) {
+	access, err := roomAccessFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := access.userID
+
+	if req.Msg.Handle == "" {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+
+	export, content, err := h.userExports.download(ctx, userID, req.Msg.Handle)
+	if err != nil {
+		h.logger.Error("Error descargando la exportación de datos", "userID", userID, "error", err)
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+	if export == nil {
 		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
 	}
 
-	return connect.NewResponse(&chatv1.GetSenderMessageResponse{
-		Status: message.Status,
+	return connect.NewResponse(&chatv1.DownloadUserDataExportResponse{
+		FileName:    export.FileName,
+		ContentType: export.ContentType,
+		Content:     content,
 	}), nil
 }
 
+// EraseUserData borra los datos de chat de un usuario que eliminó su cuenta. Es un
+// endpoint interno: se autentica con el token público, no con una sesión.
+func (h *handlerImpl) EraseUserData(ctx context.Context, req *connect.Request[chatv1.EraseUserDataRequest]) (*connect.Response[chatv1.EraseUserDataResponse], error) {
+	if _, err := roomAccessFrom(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.Msg.UserId <= 0 {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+	}
+
+	report, err := h.erasure.erase(ctx, int(req.Msg.UserId))
+	if err != nil {
+		h.logger.Error("Error borrando los datos del usuario", "userID", req.Msg.UserId, "error", err)
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+
+	return connect.NewResponse(&chatv1.EraseUserDataResponse{Report: report}), nil
+}
+
 // StreamMessages gestiona una conexión de streaming para eventos en tiempo real.
 // Si se proporciona un roomID, se suscribe solo a esa sala.
 // Si no se proporciona roomID, se suscribe a todas las salas del usuario.
@@ -1223,93 +1317,81 @@ func (h *handlerImpl) StreamMessages(ctx context.Context, req *connect.Request[c
 		err := api.UpdateResponseInfoErrorMessage(errors.New("client_id_needed"), req.Header())
 		return connect.NewError(connect.CodeInvalidArgument, err)
 	}
-
-	h.sm.Register(generalParams, stream)
-	defer h.sm.Unregister(generalParams) // Garantiza la limpieza cuando el cliente se desconecta.
-
-	specificRoomID := req.Msg.GetRoomId()
-
-	allowedRooms, _, err := h.roomsRepository.GetRoomList(ctx, session.UserID, nil)
-	if err != nil {
-		return fmt.Errorf("no se pudieron obtener las salas del usuario: %w", err)
+	if h.nc == nil || h.js == nil {
+		err := api.UpdateResponseInfoErrorMessage(errStreamUnavailable, req.Header())
+		return connect.NewError(connect.CodeUnavailable, err)
 	}
 
-	var allowedRoomsIds []string
-	for _, room := range allowedRooms {
-		allowedRoomsIds = append(allowedRoomsIds, room.GetId())
+	roomIDs := req.Msg.GetRoomIds()
+	if specificRoomID := req.Msg.GetRoomId(); specificRoomID != "" {
+		roomIDs = append(roomIDs, specificRoomID)
 	}
 
-	roomsConsumers := map[string]jetstream.ConsumeContext{}
+	sub := newStreamSubscription(roomIDs, req.Msg.GetEventTypes())
+	defer sub.stopAll()
 
-	defer (func() {
-		for _, cons := range roomsConsumers {
-			cons.Stop()
-		}
-	})()
+	// Cola de salida acotada: los consumers encolan y una sola goroutine escribe en el stream
+	queue := newOutboundQueue(h.logger, clientID, session.UserID)
+	defer queue.close()
+	writeErr := make(chan error, 1)
+	go func() {
+		writeErr <- queue.run(ctx, stream)
+	}()
 
 	directConsumerKey := fmt.Sprintf("client-%s-direct", clientID)
-	directConsumer, err := h.subscribeAndConsume(
-		ctx,
-		StreamChatEventsName,
-		chatDirectEventSubject(session.UserID),
-		directConsumerKey,
-		generalParams,
-		stream,
-		roomsConsumers,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to direct events: %w", err)
-	}
-	roomsConsumers[directConsumerKey] = directConsumer
-
-	if specificRoomID != "" && slices.Contains(allowedRoomsIds, specificRoomID) {
-		h.logger.Info("Usuario suscribiéndose a una sala específica", "clientID", clientID, "roomID", specificRoomID)
-		subject := chatRoomEventSubject(specificRoomID)
-
-		roomConsumer, err := h.subscribeAndConsume(
+	if _, err := sub.addConsumer(directConsumerKey, func() (jetstream.ConsumeContext, error) {
+		return h.subscribeAndConsume(
 			ctx,
 			StreamChatEventsName,
-			subject,
-			fmt.Sprintf("client-%s-room-%s", clientID, specificRoomID),
+			chatDirectEventSubject(session.UserID),
+			directConsumerKey,
 			generalParams,
-			stream,
-			roomsConsumers,
+			queue,
+			sub,
 		)
-		if err != nil {
-			return fmt.Errorf("failed to subscribe to room %s events: %w", specificRoomID, err)
-		}
-		roomsConsumers[specificRoomID] = roomConsumer
+	}); err != nil {
+		return fmt.Errorf("failed to subscribe to direct events: %w", err)
+	}
+
+	if len(roomIDs) > 0 {
+		h.logger.Info("Usuario suscribiéndose a salas específicas", "clientID", clientID, "roomIDs", roomIDs)
 	} else {
 		h.logger.Info("Usuario suscribiéndose a todas sus salas", "clientID", clientID)
+	}
 
-		if len(allowedRooms) == 0 {
-			h.logger.Warn("El usuario no pertenece a ninguna sala, esperando a que cree una o que ingrese en una", "clientID", clientID)
-		}
+	if err := h.syncRoomConsumers(ctx, session.UserID, generalParams, queue, sub, directConsumerKey); err != nil {
+		return err
+	}
 
-		for _, roomID := range allowedRoomsIds {
-			subject := chatRoomEventSubject(roomID)
+	// Permite cambiar el filtro del stream sin reconectar (ver UpdateStreamSubscription)
+	updates, err := h.nc.Subscribe(chatStreamSubscriptionSubject(session.UserID, clientID), func(msg *nats.Msg) {
+		update := &chatv1.UpdateStreamSubscriptionRequest{}
+		if err := proto.Unmarshal(msg.Data, update); err != nil {
+			h.logger.Error("Error al decodificar actualización de suscripción", "error", err, "clientID", clientID)
+			return
+		}
 
-			roomConsumer, err := h.subscribeAndConsume(
-				ctx,
-				StreamChatEventsName,
-				subject,
-				fmt.Sprintf("client-%s-room-%s", clientID, roomID),
-				generalParams,
-				stream,
-				roomsConsumers,
-			)
-			if err != nil {
-				return fmt.Errorf("failed to subscribe to room %s events: %w", roomID, err)
-			}
-			roomsConsumers[roomID] = roomConsumer
+		sub.setFilter(update.GetRoomIds(), update.GetEventTypes())
+		success := true
+		if err := h.syncRoomConsumers(ctx, session.UserID, generalParams, queue, sub, directConsumerKey); err != nil {
+			h.logger.Error("Error al aplicar actualización de suscripción", "error", err, "clientID", clientID)
+			success = false
 		}
+
+		h.logger.Info("Suscripción del stream actualizada", "clientID", clientID, "roomIDs", update.GetRoomIds(), "eventTypes", update.GetEventTypes())
+		reply, _ := proto.Marshal(&chatv1.UpdateStreamSubscriptionResponse{Success: success})
+		msg.Respond(reply)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to stream subscription updates: %w", err)
 	}
+	defer updates.Unsubscribe()
 
 	h.logger.Info("Stream de usuario activo y escuchando eventos", "clientID", clientID)
 
-	h.sm.Send(generalParams, &chatv1.MessageEvent{
+	queue.push(&chatv1.MessageEvent{
 		Event: &chatv1.MessageEvent_Connected{Connected: true},
-	})
+	}, nil)
 
 	ticker := time.NewTicker(15 * time.Second)
 	done := make(chan bool)
@@ -1319,34 +1401,144 @@ func (h *handlerImpl) StreamMessages(ctx context.Context, req *connect.Request[c
 			case <-done:
 				return
 			case <-ticker.C:
-				h.sm.Send(generalParams, &chatv1.MessageEvent{
+				queue.push(&chatv1.MessageEvent{
 					Event: &chatv1.MessageEvent_Connected{Connected: true},
-				})
+				}, nil)
 			}
 		}
 	}()
 
-	<-ctx.Done()
+	var streamErr error
+	select {
+	case <-ctx.Done():
+	case streamErr = <-writeErr:
+	}
 	done <- true
 
+	if errors.Is(streamErr, errStreamResync) {
+		h.logger.Warn("Cliente desconectado por quedarse atrás, debe resincronizar", "clientID", clientID)
+		err := api.UpdateResponseInfoErrorMessage(errStreamResync, req.Header())
+		return connect.NewError(connect.CodeResourceExhausted, err)
+	}
+	if streamErr != nil {
+		h.logger.Error("Error escribiendo en el stream del usuario", "error", streamErr, "clientID", clientID)
+		return streamErr
+	}
+
 	h.logger.Info("Cliente desconectado, cerrando stream y desuscribiendo de NATS", "clientID", clientID)
 	return nil
 }
 
+// UpdateStreamSubscription implements chatv1connect.ChatServiceHandler.
+func (h *handlerImpl) UpdateStreamSubscription(ctx context.Context, req *connect.Request[chatv1.UpdateStreamSubscriptionRequest]) (*connect.Response[chatv1.UpdateStreamSubscriptionResponse], error) {
+	access, err := roomAccessFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := access.userID
+
+	generalParams, _ := api.GeneralParamsFromConnectRequest(req)
+	if generalParams.ClientId == "" {
+		err := api.UpdateResponseInfoErrorMessage(errors.New("client_id_needed"), req.Header())
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if h.nc == nil {
+		err := api.UpdateResponseInfoErrorMessage(errStreamUnavailable, req.Header())
+		return nil, connect.NewError(connect.CodeUnavailable, err)
+	}
+
+	data, err := proto.Marshal(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// El stream puede vivir en otra instancia, por eso la actualización viaja por NATS
+	reply, err := h.nc.RequestWithContext(ctx, chatStreamSubscriptionSubject(userID, generalParams.ClientId), data)
+	if errors.Is(err, nats.ErrNoResponders) {
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.NotFoundCode, req.Header())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &chatv1.UpdateStreamSubscriptionResponse{}
+	if err := proto.Unmarshal(reply.Data, res); err != nil {
+		return nil, err
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+// syncRoomConsumers ajusta los consumers de salas al filtro actual: crea los que faltan
+// y detiene los de salas que ya no aplican. Solo se consideran las salas del usuario.
+func (h *handlerImpl) syncRoomConsumers(
+	ctx context.Context,
+	userID int,
+	generalParams api.GeneralParams,
+	queue *outboundQueue,
+	sub *streamSubscription,
+	directConsumerKey string,
+) error {
+	clientID := generalParams.ClientId
+
+	allowedRooms, _, err := h.roomsRepository.GetRoomList(ctx, userID, nil)
+	if err != nil {
+		return fmt.Errorf("no se pudieron obtener las salas del usuario: %w", err)
+	}
+
+	wantedRooms := map[string]bool{}
+	for _, room := range allowedRooms {
+		if sub.wantsRoom(room.GetId()) {
+			wantedRooms[room.GetId()] = true
+		}
+	}
+
+	if len(wantedRooms) == 0 {
+		h.logger.Warn("El usuario no pertenece a ninguna sala del filtro, esperando a que cree una o que ingrese en una", "clientID", clientID)
+	}
+
+	for _, key := range sub.consumerKeys() {
+		if key != directConsumerKey && !wantedRooms[key] {
+			sub.removeConsumer(key)
+		}
+	}
+
+	for roomID := range wantedRooms {
+		if _, err := sub.addConsumer(roomID, func() (jetstream.ConsumeContext, error) {
+			return h.subscribeAndConsume(
+				ctx,
+				StreamChatEventsName,
+				chatRoomEventSubject(roomID),
+				fmt.Sprintf("client-%s-room-%s", clientID, roomID),
+				generalParams,
+				queue,
+				sub,
+			)
+		}); err != nil {
+			return fmt.Errorf("failed to subscribe to room %s events: %w", roomID, err)
+		}
+	}
+
+	return nil
+}
+
+// handleJetStreamMessage procesa un evento de JetStream y lo encola para el cliente.
+// Devuelve true si el evento quedó en la cola, que se encarga de hacer el ack al escribirlo.
 func (h handlerImpl) handleJetStreamMessage(
 	ctx context.Context,
 	generalParams api.GeneralParams,
 	msg jetstream.Msg,
-	stream *connect.ServerStream[chatv1.MessageEvent],
-	roomsConsumers map[string]jetstream.ConsumeContext,
-) {
+	queue *outboundQueue,
+	sub *streamSubscription,
+) (queued bool) {
 	clientID := generalParams.ClientId
 	session, _ := api.CheckSessionFromGeneralParams(generalParams)
 
 	sendEvent := func(eventSubject string, event *chatv1.MessageEvent) {
 		payload, _ := protojson.Marshal(event)
 		h.logger.Info("Enviando evento al stream del usuario", "subject", eventSubject, "clientID", clientID, "roomID", event.RoomId, "payload", string(payload))
-		h.sm.Send(generalParams, event)
+		queue.push(event, func() { msg.Ack() })
+		queued = true
 	}
 
 	var data eventPayload
@@ -1368,40 +1560,46 @@ func (h handlerImpl) handleJetStreamMessage(
 		return
 	}
 
+	// Los cambios de suscripción (join/leave) se aplican aunque el filtro descarte el evento
+	allowed := sub.allows(event)
+
 	switch detail := event.Event.(type) {
 	case *chatv1.MessageEvent_RoomJoin:
-		room, err := h.roomsRepository.GetRoom(context.Background(), session.UserID, roomID, true, true)
-		if err != nil {
-			h.logger.Error("Error fetching room", "error", err)
-			return
-		}
-		event.Room = room
-		if detail.RoomJoin.GetUserId() == int32(session.UserID) || data.UserId == session.UserID {
-			durableName := fmt.Sprintf("client-%s-room-%s", clientID, roomID)
-
-			if _, ok := roomsConsumers[roomID]; ok {
-				h.logger.Info("Already subscribed to room, skipping", "roomID", roomID)
-			} else {
-				roomConsumer, err := h.subscribeAndConsume(
+		if (detail.RoomJoin.GetUserId() == int32(session.UserID) || data.UserId == session.UserID) && sub.wantsRoom(roomID) {
+			added, err := sub.addConsumer(roomID, func() (jetstream.ConsumeContext, error) {
+				return h.subscribeAndConsume(
 					ctx,
 					StreamChatEventsName,
 					natsSubject,
-					durableName,
+					fmt.Sprintf("client-%s-room-%s", clientID, roomID),
 					generalParams,
-					stream,
-					roomsConsumers,
+					queue,
+					sub,
 				)
-				if err != nil {
-					h.logger.Error("Failed to subscribe to new room on RoomJoin event", "error", err, "roomID", roomID)
-				} else {
-					roomsConsumers[roomID] = roomConsumer // Store it for cleanup
-					h.logger.Info("Successfully subscribed to new room on RoomJoin event", "roomID", roomID)
-				}
+			})
+			if err != nil {
+				h.logger.Error("Failed to subscribe to new room on RoomJoin event", "error", err, "roomID", roomID)
+			} else if added {
+				h.logger.Info("Successfully subscribed to new room on RoomJoin event", "roomID", roomID)
+			} else {
+				h.logger.Info("Already subscribed to room, skipping", "roomID", roomID)
 			}
 		}
+		if !allowed {
+			return
+		}
+		room, err := h.roomsRepository.GetRoom(context.Background(), session.UserID, roomID, true, true)
+		if err != nil {
+			h.logger.Error("Error fetching room", "error", err)
+			return
+		}
+		event.Room = room
 		sendEvent(msg.Subject(), event)
 
 	case *chatv1.MessageEvent_IsRoomUpdated:
+		if !allowed {
+			return
+		}
 		room, err := h.roomsRepository.GetRoom(context.Background(), session.UserID, roomID, true, true)
 		if err != nil {
 			h.logger.Error("Error fetching room", "error", err)
@@ -1411,6 +1609,9 @@ func (h handlerImpl) handleJetStreamMessage(
 		sendEvent(natsSubject, event)
 
 	case *chatv1.MessageEvent_StatusUpdate:
+		if !allowed {
+			return
+		}
 		if detail.StatusUpdate.Status == chatv1.MessageStatus_MESSAGE_STATUS_SENT {
 			if dispatchUserId == session.UserID {
 				sendEvent(natsSubject, event)
@@ -1419,13 +1620,19 @@ func (h handlerImpl) handleJetStreamMessage(
 			sendEvent(natsSubject, event)
 		}
 
+	case *chatv1.MessageEvent_ReadState:
+		// Solo para las sesiones del usuario que leyó
+		if allowed && detail.ReadState.GetUserId() == int32(session.UserID) {
+			sendEvent(msg.Subject(), event)
+		}
+
 	case *chatv1.MessageEvent_RoomLeave:
-		sendEvent(natsSubject, event)
+		if allowed {
+			sendEvent(natsSubject, event)
+		}
 
 		if slices.Contains(detail.RoomLeave.GetUsersId(), int32(session.UserID)) {
-			if consumer, ok := roomsConsumers[roomID]; ok {
-				consumer.Stop()
-				delete(roomsConsumers, roomID)
+			if sub.removeConsumer(roomID) {
 				h.logger.Info("Successfully unsubscribed from room on RoomLeave event", "roomID", roomID)
 			} else {
 				h.logger.Warn("Attempted to unsubscribe from a room not found in consumers map", "roomID", roomID)
@@ -1433,8 +1640,12 @@ func (h handlerImpl) handleJetStreamMessage(
 		}
 
 	default:
-		sendEvent(natsSubject, event)
+		if allowed {
+			sendEvent(natsSubject, event)
+		}
 	}
+
+	return queued
 }
 
 func (h *handlerImpl) subscribeAndConsume(
@@ -1443,9 +1654,13 @@ func (h *handlerImpl) subscribeAndConsume(
 	filterSubject string,
 	durableName string,
 	generalParams api.GeneralParams,
-	stream *connect.ServerStream[chatv1.MessageEvent],
-	roomsConsumers map[string]jetstream.ConsumeContext,
+	queue *outboundQueue,
+	sub *streamSubscription,
 ) (jetstream.ConsumeContext, error) {
+	if h.js == nil {
+		return nil, errStreamUnavailable
+	}
+
 	consumerConfig := jetstream.ConsumerConfig{
 		Durable:       durableName,
 		AckPolicy:     jetstream.AckExplicitPolicy,
@@ -1460,8 +1675,9 @@ func (h *handlerImpl) subscribeAndConsume(
 	}
 
 	consumeCtx, err := cons.Consume(func(msg jetstream.Msg) {
-		h.handleJetStreamMessage(ctx, generalParams, msg, stream, roomsConsumers)
-		msg.Ack()
+		if !h.handleJetStreamMessage(ctx, generalParams, msg, queue, sub) {
+			msg.Ack()
+		}
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to start consuming messages for subject %s: %w", filterSubject, err)
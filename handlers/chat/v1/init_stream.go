@@ -11,6 +11,9 @@ import (
 const (
 	StreamChatEventsName                = "CHAT_EVENTS"
 	StreamChatDirectEventsSubjectPrefix = "CHAT_DIRECT_EVENTS"
+
+	// Subject de NATS core (fuera del stream) para actualizar el filtro de un stream activo
+	ChatStreamSubscriptionSubjectPrefix = "CHAT_STREAM_SUBSCRIPTION"
 )
 
 var requiredStreams = []jetstream.StreamConfig{
@@ -35,3 +38,7 @@ func chatRoomEventSubject(roomId string) string {
 func chatDirectEventSubject(userId int) string {
 	return strings.Join([]string{StreamChatDirectEventsSubjectPrefix, strconv.Itoa(userId)}, ".")
 }
+
+func chatStreamSubscriptionSubject(userId int, clientId string) string {
+	return strings.Join([]string{ChatStreamSubscriptionSubjectPrefix, strconv.Itoa(userId), clientId}, ".")
+}
@@ -0,0 +1,208 @@
+package chatv1handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+)
+
+const (
+	outboxRelayBatchSize    = 100
+	outboxRelayPollInterval = time.Second
+	outboxRelayRetention    = 24 * time.Hour
+)
+
+// outboxRelay publica en JetStream los eventos escritos en el outbox por las mutaciones.
+// Los handlers lo despiertan con notify después de cada mutación; el sondeo periódico
+// recoge lo que quede pendiente (caídas de NATS, reinicios del proceso, etc).
+type outboxRelay struct {
+	logger *slog.Logger
+	js     jetstream.JetStream
+	repo   roomsrepository.RoomsRepository
+	wake   chan struct{}
+}
+
+func newOutboxRelay(logger *slog.Logger, js jetstream.JetStream, repo roomsrepository.RoomsRepository) *outboxRelay {
+	return &outboxRelay{
+		logger: logger,
+		js:     js,
+		repo:   repo,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// notify despierta al relay sin bloquear. Sin JetStream no hay relay y los eventos
+// se quedan en el outbox.
+func (r *outboxRelay) notify() {
+	if r == nil {
+		return
+	}
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *outboxRelay) run(ctx context.Context) {
+	ticker := time.NewTicker(outboxRelayPollInterval)
+	defer ticker.Stop()
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+
+	for {
+		for r.relayBatch(ctx) == outboxRelayBatchSize {
+			// Lote completo: probablemente quedan más eventos pendientes
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		case <-purge.C:
+			if n, err := r.repo.PurgeOutboxEvents(ctx, time.Now().Add(-outboxRelayRetention)); err != nil {
+				r.logger.Error("Error purgando el outbox", "error", err)
+			} else if n > 0 {
+				r.logger.Info("Outbox purgado", "deleted", n)
+			}
+		}
+	}
+}
+
+// relayBatch publica un lote y devuelve cuántos eventos reclamó. Si un evento de una
+// sala falla, los siguientes de esa sala del lote también se marcan como fallidos; después
+// ClaimOutboxEvents no los entrega mientras el anterior siga pendiente, aunque su reintento
+// venza antes.
+func (r *outboxRelay) relayBatch(ctx context.Context) int {
+	outboxEvents, err := r.repo.ClaimOutboxEvents(ctx, outboxRelayBatchSize)
+	if err != nil {
+		r.logger.Error("Error leyendo eventos del outbox", "error", err)
+		return 0
+	}
+
+	failedRooms := map[string]bool{}
+	for _, outboxEvent := range outboxEvents {
+		err := fmt.Errorf("evento anterior de la sala pendiente")
+		if !failedRooms[outboxEvent.RoomID] {
+			err = r.publish(ctx, outboxEvent)
+		}
+
+		if err != nil {
+			failedRooms[outboxEvent.RoomID] = true
+			r.logger.Warn("No se pudo publicar evento del outbox, se reintentará", "error", err, "eventID", outboxEvent.ID, "kind", outboxEvent.Kind, "attempts", outboxEvent.Attempts)
+			if err := r.repo.MarkOutboxEventFailed(ctx, outboxEvent, err); err != nil {
+				r.logger.Error("Error marcando evento del outbox como fallido", "error", err, "eventID", outboxEvent.ID)
+			}
+			continue
+		}
+
+		if err := r.repo.MarkOutboxEventSent(ctx, outboxEvent); err != nil {
+			// Se volverá a publicar; JetStream lo descarta por Nats-Msg-Id
+			r.logger.Error("Error marcando evento del outbox como enviado", "error", err, "eventID", outboxEvent.ID)
+		}
+	}
+
+	return len(outboxEvents)
+}
+
+// publish arma los eventos de chat del registro del outbox y los publica en JetStream.
+func (r *outboxRelay) publish(ctx context.Context, outboxEvent roomsrepository.OutboxEvent) error {
+	chatEvents, err := r.hydrate(ctx, outboxEvent)
+	if err != nil {
+		return err
+	}
+
+	for i, event := range chatEvents {
+		// Id estable por evento: los reintentos no generan duplicados en JetStream
+		event.EventId = fmt.Sprintf("%s-%d", outboxEvent.ID, i)
+		if event.RoomId == "" {
+			event.RoomId = outboxEvent.RoomID
+		}
+
+		chatEvent := ChatEvent{roomID: outboxEvent.RoomID, userID: outboxEvent.UserID, event: event}
+		payload, err := chatEvent.Payload()
+		if err != nil {
+			return err
+		}
+		if _, err := r.js.Publish(ctx, chatEvent.Subject(), payload, jetstream.WithMsgID(event.EventId)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *outboxRelay) hydrate(ctx context.Context, outboxEvent roomsrepository.OutboxEvent) ([]*chatv1.MessageEvent, error) {
+	switch outboxEvent.Kind {
+	case roomsrepository.OutboxMessageCreated:
+		msg, err := r.repo.GetMessage(ctx, outboxEvent.UserID, outboxEvent.MessageID)
+		if err != nil {
+			return nil, err
+		}
+		if msg == nil {
+			return nil, nil
+		}
+
+		var chatEvents []*chatv1.MessageEvent
+		if msg.Type == "user_message" {
+			chatEvents = append(chatEvents, &chatv1.MessageEvent{
+				RoomId: outboxEvent.RoomID,
+				Event: &chatv1.MessageEvent_StatusUpdate{
+					StatusUpdate: &chatv1.MessageStatusUpdate{
+						MessageId: msg.GetId(),
+						Status:    msg.GetStatus(),
+						UpdatedAt: msg.GetUpdatedAt(),
+						UserId:    int32(outboxEvent.UserID),
+						SenderId:  int32(outboxEvent.UserID),
+					},
+				},
+			})
+		}
+		return append(chatEvents, &chatv1.MessageEvent{
+			RoomId: outboxEvent.RoomID,
+			Event:  &chatv1.MessageEvent_Message{Message: msg},
+		}), nil
+
+	case roomsrepository.OutboxMessageUpdated:
+		msg, err := r.repo.GetMessage(ctx, outboxEvent.UserID, outboxEvent.MessageID)
+		if err != nil {
+			return nil, err
+		}
+		if msg == nil {
+			return nil, nil
+		}
+		return []*chatv1.MessageEvent{{
+			RoomId: outboxEvent.RoomID,
+			Event:  &chatv1.MessageEvent_UpdateMessage{UpdateMessage: msg},
+		}}, nil
+
+	case roomsrepository.OutboxReadState:
+		// El conteo de no leídos se lee al publicar, tras aplicarse la lectura
+		event, ok := outboxEvent.Event.GetEvent().(*chatv1.MessageEvent_ReadState)
+		if !ok {
+			r.logger.Warn("Evento read_state del outbox sin contenido, se descarta", "eventID", outboxEvent.ID)
+			return nil, nil
+		}
+		room, err := r.repo.GetRoom(ctx, outboxEvent.UserID, outboxEvent.RoomID, false, false)
+		if err != nil {
+			return nil, err
+		}
+		if room != nil {
+			event.ReadState.UnreadCount = room.UnreadCount
+		}
+		return []*chatv1.MessageEvent{outboxEvent.Event}, nil
+
+	default:
+		if outboxEvent.Event == nil {
+			r.logger.Warn("Evento del outbox sin contenido, se descarta", "eventID", outboxEvent.ID, "kind", outboxEvent.Kind)
+			return nil, nil
+		}
+		return []*chatv1.MessageEvent{outboxEvent.Event}, nil
+	}
+}
@@ -1,6 +1,10 @@
 package chatv1handler
 
 import (
+	"log/slog"
+	"net/http"
+
+	"connectrpc.com/connect"
 	"connectrpc.com/vanguard"
 	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1/chatv1connect"
 	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/server"
@@ -9,5 +13,15 @@ import (
 var options = server.ServiceHandlerOptions()
 
 func RegisterServiceHandler() *vanguard.Service {
-	return vanguard.NewService(chatv1connect.NewChatServiceHandler(NewHandler(), options...))
+	return vanguard.NewService(NewServiceHandler(newHandlerDeps(), options...))
+}
+
+// NewServiceHandler crea el handler HTTP del servicio de chat con las dependencias indicadas.
+// Siempre instala la autorización de las RPC (ver authz.go) después de las opciones recibidas.
+func NewServiceHandler(deps HandlerDeps, opts ...connect.HandlerOption) (string, http.Handler) {
+	if deps.Logger == nil {
+		deps.Logger = slog.Default()
+	}
+	opts = append(opts[:len(opts):len(opts)], connect.WithInterceptors(newRoomAuthorizer(deps.Logger, deps.Rooms)))
+	return chatv1connect.NewChatServiceHandler(newHandlerWithDeps(deps), opts...)
 }
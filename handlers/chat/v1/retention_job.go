@@ -0,0 +1,80 @@
+package chatv1handler
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/config"
+)
+
+const (
+	retentionPurgeInterval  = time.Hour
+	retentionPurgeBatchSize = 500
+	// Pasadas máximas por ejecución, para no acaparar la base de datos con salas muy atrasadas
+	retentionMaxPasses = 20
+)
+
+// retentionDefaultDays lee la retención global (chat.retentionDays). Sin valor, o con un
+// valor inválido, el historial se conserva para siempre salvo en las salas que fijen la suya.
+func retentionDefaultDays() int {
+	value := config.GetString("chat.retentionDays")
+	if value == "" {
+		return 0
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		slog.Warn("chat.retentionDays inválido, se conserva el historial", "value", value)
+		return 0
+	}
+	return days
+}
+
+// retentionJob purga periódicamente los mensajes vencidos según la retención de cada sala.
+// El evento HistoryPurged lo escribe el repositorio en el outbox; el job solo despierta al relay.
+type retentionJob struct {
+	logger      *slog.Logger
+	repo        roomsrepository.RoomsRepository
+	outbox      *outboxRelay
+	defaultDays int
+	interval    time.Duration
+}
+
+func (j *retentionJob) run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *retentionJob) purge(ctx context.Context) {
+	for pass := 0; pass < retentionMaxPasses; pass++ {
+		purges, err := j.repo.PurgeExpiredMessages(ctx, j.defaultDays, time.Now(), retentionPurgeBatchSize)
+		if err != nil {
+			j.logger.Error("Error purgando mensajes vencidos", "error", err)
+			return
+		}
+
+		pending := false
+		for _, purge := range purges {
+			j.logger.Info("Historial purgado por retención", "roomID", purge.RoomID, "before", purge.Before, "messages", len(purge.MessageIDs))
+			pending = pending || !purge.Complete
+		}
+		if len(purges) > 0 {
+			j.outbox.notify()
+		}
+		if !pending {
+			return
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package chatv1handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+// Mensajes por página al leer el historial para exportarlo
+const roomExportPageSize = 200
+
+var errUnsupportedExportFormat = errors.New("unsupported export format")
+
+// canExportRoomHistory indica si el usuario puede exportar el historial de la sala: solo
+// owners y admins, ya que el archivo contiene los mensajes descifrados.
+func canExportRoomHistory(room *chatv1.Room) bool {
+	return room.Role == "OWNER" || room.Role == "ADMIN"
+}
+
+func roomExportFileName(roomID string, format chatv1.ExportFormat, at time.Time) string {
+	extension := map[chatv1.ExportFormat]string{
+		chatv1.ExportFormat_EXPORT_FORMAT_JSON: "json",
+		chatv1.ExportFormat_EXPORT_FORMAT_CSV:  "csv",
+		chatv1.ExportFormat_EXPORT_FORMAT_HTML: "html",
+	}[format]
+	return fmt.Sprintf("room-%s-%s.%s", roomID, at.UTC().Format("20060102-150405"), extension)
+}
+
+func roomExportContentType(format chatv1.ExportFormat) string {
+	switch format {
+	case chatv1.ExportFormat_EXPORT_FORMAT_JSON:
+		return "application/json"
+	case chatv1.ExportFormat_EXPORT_FORMAT_CSV:
+		return "text/csv; charset=utf-8"
+	case chatv1.ExportFormat_EXPORT_FORMAT_HTML:
+		return "text/html; charset=utf-8"
+	default:
+		return ""
+	}
+}
+
+// exportedRoom y exportedMessage son el modelo del archivo, común a los tres formatos.
+type exportedRoom struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type"`
+	ExportedAt  string `json:"exported_at"`
+	ExportedBy  int    `json:"exported_by"`
+}
+
+type exportedMessage struct {
+	ID                 string             `json:"id"`
+	Seq                int64              `json:"seq"`
+	Type               string             `json:"type"`
+	Event              string             `json:"event,omitempty"`
+	SenderID           int32              `json:"sender_id"`
+	SenderName         string             `json:"sender_name"`
+	SenderPhone        string             `json:"sender_phone,omitempty"`
+	Content            string             `json:"content"`
+	CreatedAt          string             `json:"created_at"`
+	UpdatedAt          string             `json:"updated_at"`
+	Edited             bool               `json:"edited"`
+	Deleted            bool               `json:"deleted"`
+	Reply              *exportedReply     `json:"reply,omitempty"`
+	ForwardedFrom      string             `json:"forwarded_from,omitempty"`
+	Reactions          []exportedReaction `json:"reactions,omitempty"`
+	Attachment         string             `json:"attachment,omitempty"`
+	AudioTranscription string             `json:"audio_transcription,omitempty"`
+	Location           *exportedLocation  `json:"location,omitempty"`
+	Contact            *exportedContact   `json:"contact,omitempty"`
+}
+
+type exportedReply struct {
+	ID         string `json:"id"`
+	SenderName string `json:"sender_name"`
+	Content    string `json:"content"`
+}
+
+type exportedReaction struct {
+	UserID   string `json:"user_id"`
+	UserName string `json:"user_name"`
+	Reaction string `json:"reaction"`
+}
+
+type exportedLocation struct {
+	Name      string  `json:"name,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type exportedContact struct {
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// decryptExportContent descifra el contenido con la clave de la sala. Los mensajes de
+// sistema no van cifrados; si el contenido no se puede descifrar se exporta tal cual.
+func decryptExportContent(content string, messageType string, keyVersion int32, keys []*chatv1.RoomKey) string {
+	if content == "" || messageType == "system_message" {
+		return content
+	}
+	encryptionData, err := roomsrepository.RoomKeyFor(keys, keyVersion)
+	if err != nil || encryptionData == "" {
+		return content
+	}
+	decrypted, err := utils.DecryptMessage(content, encryptionData)
+	if err != nil || decrypted == "" {
+		return content
+	}
+	return decrypted
+}
+
+// newExportedMessage convierte un mensaje del historial; keys son las de GetRoomKeys y names
+// resuelve el nombre de quien reaccionó, ya que las reacciones del historial solo traen el ID.
+func newExportedMessage(msg *chatv1.MessageData, keys []*chatv1.RoomKey, names map[string]string) exportedMessage {
+	exported := exportedMessage{
+		ID:                 msg.Id,
+		Seq:                msg.Seq,
+		Type:               msg.Type,
+		Event:              msg.GetEvent(),
+		SenderID:           msg.SenderId,
+		SenderName:         msg.SenderName,
+		SenderPhone:        msg.SenderPhone,
+		Content:            decryptExportContent(msg.Content, msg.Type, msg.KeyVersion, keys),
+		CreatedAt:          msg.CreatedAt,
+		UpdatedAt:          msg.UpdatedAt,
+		Edited:             msg.Edited,
+		Deleted:            msg.IsDeleted,
+		ForwardedFrom:      msg.GetForwardedMessageSenderName(),
+		Attachment:         msg.GetFile(),
+		AudioTranscription: msg.GetAudioTranscription(),
+	}
+	if msg.Reply != nil {
+		exported.Reply = &exportedReply{
+			ID:         msg.Reply.Id,
+			SenderName: msg.Reply.SenderName,
+			Content:    decryptExportContent(msg.Reply.Content, msg.Reply.Type, msg.Reply.KeyVersion, keys),
+		}
+	}
+	for _, reaction := range msg.Reactions {
+		name := reaction.ReactedByName
+		if name == "" {
+			name = names[reaction.ReactedById]
+		}
+		exported.Reactions = append(exported.Reactions, exportedReaction{
+			UserID:   reaction.ReactedById,
+			UserName: name,
+			Reaction: reaction.Reaction,
+		})
+	}
+	if msg.LocationLatitude != nil || msg.LocationLongitude != nil {
+		exported.Location = &exportedLocation{
+			Name:      msg.GetLocationName(),
+			Latitude:  msg.GetLocationLatitude(),
+			Longitude: msg.GetLocationLongitude(),
+		}
+	}
+	if msg.ContactId != nil || msg.ContactPhone != nil {
+		exported.Contact = &exportedContact{
+			ID:    msg.GetContactId(),
+			Name:  msg.GetContactName(),
+			Phone: msg.GetContactPhone(),
+		}
+	}
+	return exported
+}
+
+// roomExportWriter escribe el archivo de forma incremental para no cargar el historial
+// completo en memoria.
+type roomExportWriter interface {
+	begin(room exportedRoom) error
+	write(msg exportedMessage) error
+	end() error
+}
+
+func newRoomExportWriter(format chatv1.ExportFormat, w io.Writer) (roomExportWriter, error) {
+	switch format {
+	case chatv1.ExportFormat_EXPORT_FORMAT_JSON:
+		return &jsonExportWriter{w: w}, nil
+	case chatv1.ExportFormat_EXPORT_FORMAT_CSV:
+		return &csvExportWriter{w: csv.NewWriter(w)}, nil
+	case chatv1.ExportFormat_EXPORT_FORMAT_HTML:
+		return &htmlExportWriter{w: w}, nil
+	default:
+		return nil, errUnsupportedExportFormat
+	}
+}
+
+// exportRoomHistory recorre el historial de la sala por seq (incluye los mensajes
+// eliminados, que se exportan sin contenido) y lo escribe en el formato indicado.
+// progress se llama después de cada página con los mensajes exportados y el total estimado.
+func exportRoomHistory(ctx context.Context, repo roomsrepository.RoomsRepository, userID int, room *chatv1.Room, format chatv1.ExportFormat, w io.Writer, progress func(exported, total int64)) error {
+	writer, err := newRoomExportWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	// El total es la última seq de la sala; los mensajes que lleguen durante la exportación
+	// también se incluyen
+	last, _, err := repo.GetMessagesFromRoom(ctx, userID, &chatv1.GetMessageHistoryRequest{
+		Id:        room.Id,
+		Limit:     1,
+		BeforeSeq: proto.Int64(math.MaxInt64),
+	})
+	if err != nil {
+		return err
+	}
+	var total int64
+	if len(last) > 0 {
+		total = last[0].Seq
+	}
+
+	err = writer.begin(exportedRoom{
+		ID:          room.Id,
+		Name:        room.Name,
+		Description: room.Description,
+		Type:        room.Type,
+		ExportedAt:  time.Now().UTC().Format(time.RFC3339),
+		ExportedBy:  userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	keys, err := repo.GetRoomKeys(ctx, room.Id)
+	if err != nil {
+		return err
+	}
+
+	var exported int64
+	cursor := ""
+	names := map[string]string{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		messages, meta, err := repo.GetMessagesFromRoom(ctx, userID, &chatv1.GetMessageHistoryRequest{
+			Id:       room.Id,
+			Limit:    roomExportPageSize,
+			AfterSeq: proto.Int64(0),
+			Cursor:   cursor,
+		})
+		if err != nil {
+			return err
+		}
+		if err := resolveReactionNames(ctx, repo, messages, names); err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			if err := writer.write(newExportedMessage(msg, keys, names)); err != nil {
+				return err
+			}
+		}
+		exported += int64(len(messages))
+		if total < exported {
+			total = exported
+		}
+		if progress != nil {
+			progress(exported, total)
+		}
+
+		if len(messages) == 0 || meta == nil || meta.NextCursor == "" {
+			break
+		}
+		cursor = meta.NextCursor
+	}
+
+	return writer.end()
+}
+
+// resolveReactionNames agrega a names los usuarios de las reacciones de la página que aún
+// no se conocen.
+func resolveReactionNames(ctx context.Context, repo roomsrepository.RoomsRepository, messages []*chatv1.MessageData, names map[string]string) error {
+	var ids []int
+	for _, msg := range messages {
+		for _, reaction := range msg.Reactions {
+			if _, ok := names[reaction.ReactedById]; ok || reaction.ReactedByName != "" {
+				continue
+			}
+			id, err := strconv.Atoi(reaction.ReactedById)
+			if err != nil {
+				continue
+			}
+			names[reaction.ReactedById] = ""
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	users, err := repo.GetUsersByID(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		names[strconv.Itoa(user.ID)] = user.Name
+	}
+	return nil
+}
+
+type jsonExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonExportWriter) begin(room exportedRoom) error {
+	data, err := json.Marshal(room)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(j.w, "{\"room\":%s,\"messages\":[", data)
+	return err
+}
+
+func (j *jsonExportWriter) write(msg exportedMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if j.count > 0 {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.count++
+	_, err = fmt.Fprintf(j.w, "\n%s", data)
+	return err
+}
+
+func (j *jsonExportWriter) end() error {
+	_, err := io.WriteString(j.w, "\n]}\n")
+	return err
+}
+
+var roomExportCSVHeader = []string{
+	"seq", "id", "created_at", "updated_at", "sender_id", "sender_name", "sender_phone", "type", "event",
+	"content", "edited", "deleted", "reply_to_id", "reply_to_sender", "reply_to_content", "forwarded_from",
+	"reactions", "attachment", "audio_transcription", "location", "contact",
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) begin(room exportedRoom) error {
+	return c.w.Write(roomExportCSVHeader)
+}
+
+func (c *csvExportWriter) write(msg exportedMessage) error {
+	var replyID, replySender, replyContent string
+	if msg.Reply != nil {
+		replyID, replySender, replyContent = msg.Reply.ID, msg.Reply.SenderName, msg.Reply.Content
+	}
+	reactions := make([]string, 0, len(msg.Reactions))
+	for _, reaction := range msg.Reactions {
+		reactions = append(reactions, reaction.Reaction+" "+reaction.UserName)
+	}
+	var location, contact string
+	if msg.Location != nil {
+		location = strings.TrimSpace(fmt.Sprintf("%s %g,%g", msg.Location.Name, msg.Location.Latitude, msg.Location.Longitude))
+	}
+	if msg.Contact != nil {
+		contact = strings.TrimSpace(msg.Contact.Name + " " + msg.Contact.Phone)
+	}
+
+	err := c.w.Write([]string{
+		strconv.FormatInt(msg.Seq, 10), msg.ID, msg.CreatedAt, msg.UpdatedAt, strconv.Itoa(int(msg.SenderID)),
+		msg.SenderName, msg.SenderPhone, msg.Type, msg.Event, msg.Content, strconv.FormatBool(msg.Edited),
+		strconv.FormatBool(msg.Deleted), replyID, replySender, replyContent, msg.ForwardedFrom,
+		strings.Join(reactions, "; "), msg.Attachment, msg.AudioTranscription, location, contact,
+	})
+	if err != nil {
+		return err
+	}
+	// Flush por mensaje para que el progreso refleje lo que ya está escrito
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvExportWriter) end() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// La transcripción HTML es un único archivo sin recursos externos: los adjuntos se
+// incluyen como enlace y no se descargan.
+var roomExportHTML = template.Must(template.New("export").Parse(`
+{{define "begin"}}<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+body{font-family:-apple-system,"Segoe UI",Roboto,sans-serif;background:#f4f5f7;color:#1d2129;margin:0;padding:24px}
+header{max-width:760px;margin:0 auto 24px}
+header h1{margin:0 0 4px;font-size:22px}
+header p{margin:0;color:#65676b;font-size:13px}
+main{max-width:760px;margin:0 auto}
+.msg{background:#fff;border-radius:8px;padding:10px 14px;margin-bottom:8px;box-shadow:0 1px 2px rgba(0,0,0,.08)}
+.msg.system{background:transparent;box-shadow:none;text-align:center;color:#65676b;font-size:13px}
+.msg.deleted .content{color:#8a8d91;font-style:italic}
+.meta{font-size:12px;color:#65676b;margin-bottom:4px}
+.meta strong{color:#1d2129}
+.content{white-space:pre-wrap;word-wrap:break-word}
+.reply{border-left:3px solid #c7cbd1;padding:2px 8px;margin-bottom:6px;font-size:13px;color:#4b4f56}
+.extra{font-size:13px;margin-top:6px;color:#4b4f56}
+.reactions{margin-top:6px;font-size:13px}
+.reactions span{background:#eef0f3;border-radius:10px;padding:1px 8px;margin-right:4px}
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Name}}</h1>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+<p>Sala {{.ID}} · exportada el {{.ExportedAt}}</p>
+</header>
+<main>
+{{end}}
+{{define "message"}}<div class="msg{{if eq .Type "system_message"}} system{{end}}{{if .Deleted}} deleted{{end}}" id="m{{.Seq}}">
+{{if ne .Type "system_message"}}<div class="meta"><strong>{{.SenderName}}</strong>{{if .SenderPhone}} · {{.SenderPhone}}{{end}} · {{.CreatedAt}}{{if .Edited}} · editado {{.UpdatedAt}}{{end}}{{if .ForwardedFrom}} · reenviado de {{.ForwardedFrom}}{{end}}</div>
+{{end}}{{with .Reply}}<div class="reply"><strong>{{.SenderName}}</strong>: {{.Content}}</div>
+{{end}}<div class="content">{{if .Deleted}}Mensaje eliminado{{else if eq .Type "system_message"}}{{if .Event}}[{{.Event}}] {{end}}{{.Content}}{{else}}{{.Content}}{{end}}</div>
+{{if .Attachment}}<div class="extra">Adjunto: <a href="{{.Attachment}}">{{.Attachment}}</a></div>
+{{end}}{{if .AudioTranscription}}<div class="extra">Transcripción: {{.AudioTranscription}}</div>
+{{end}}{{with .Location}}<div class="extra">Ubicación: {{.Name}} ({{.Latitude}}, {{.Longitude}})</div>
+{{end}}{{with .Contact}}<div class="extra">Contacto: {{.Name}} {{.Phone}}</div>
+{{end}}{{if .Reactions}}<div class="reactions">{{range .Reactions}}<span title="{{.UserName}}">{{.Reaction}} {{.UserName}}</span>{{end}}</div>
+{{end}}</div>
+{{end}}
+{{define "end"}}</main>
+</body>
+</html>
+{{end}}`))
+
+type htmlExportWriter struct {
+	w io.Writer
+}
+
+func (h *htmlExportWriter) begin(room exportedRoom) error {
+	return roomExportHTML.ExecuteTemplate(h.w, "begin", room)
+}
+
+func (h *htmlExportWriter) write(msg exportedMessage) error {
+	return roomExportHTML.ExecuteTemplate(h.w, "message", msg)
+}
+
+func (h *htmlExportWriter) end() error {
+	return roomExportHTML.ExecuteTemplate(h.w, "end", nil)
+}
@@ -0,0 +1,323 @@
+package chatv1handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
+	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/cache"
+)
+
+const (
+	// Tiempo que se conservan el estado y el archivo de una exportación
+	roomExportTTL = 24 * time.Hour
+	// Duración máxima de una exportación
+	roomExportTimeout = 30 * time.Minute
+	// Exportaciones simultáneas por instancia; el resto espera en PENDING
+	roomExportConcurrency = 2
+	// Una exportación RUNNING que no avanza en este tiempo se da por interrumpida (por
+	// ejemplo, porque la instancia que la ejecutaba se reinició)
+	roomExportStaleAfter = 5 * time.Minute
+	// Tamaño máximo del archivo de una exportación; una más grande falla
+	exportMaxSize = 64 << 20
+	// El archivo se guarda y se descarga en trozos de este tamaño
+	exportChunkSize = 1 << 20
+)
+
+var errExportTooLarge = fmt.Errorf("export exceeds %d bytes", exportMaxSize)
+
+// exportLimitWriter corta la escritura del archivo cuando supera exportMaxSize, para no
+// acumular en memoria una exportación que no se va a poder guardar.
+type exportLimitWriter struct {
+	w       io.Writer
+	written int64
+}
+
+func (l *exportLimitWriter) Write(p []byte) (int, error) {
+	if l.written+int64(len(p)) > exportMaxSize {
+		return 0, errExportTooLarge
+	}
+	n, err := l.w.Write(p)
+	l.written += int64(n)
+	return n, err
+}
+
+// exportChunks parte el archivo en trozos de exportChunkSize.
+func exportChunks(content []byte) [][]byte {
+	var chunks [][]byte
+	for len(content) > exportChunkSize {
+		chunks = append(chunks, content[:exportChunkSize])
+		content = content[exportChunkSize:]
+	}
+	return append(chunks, content)
+}
+
+// exportRecord es el estado de una exportación (RoomHistoryExport o UserDataExport).
+type exportRecord interface {
+	proto.Message
+	GetId() string
+}
+
+// exportStore guarda el estado y el archivo de las exportaciones. En producción se usa la
+// caché compartida para que cualquier instancia pueda responder las consultas de progreso.
+// El archivo se guarda en trozos de exportChunkSize.
+type exportStore[T exportRecord] interface {
+	save(ctx context.Context, export T) error
+	saveContent(ctx context.Context, id string, content []byte) error
+	// load devuelve false si la exportación no existe o ya expiró
+	load(ctx context.Context, id string) (T, bool, error)
+	// loadContent entrega los trozos del archivo en orden
+	loadContent(ctx context.Context, id string, fn func(chunk []byte) error) error
+}
+
+// newExportStore crea el store en memoria o en la caché (HandlerDeps.MemoryExports); kind
+// separa las claves de cada tipo de exportación en la caché.
+func newExportStore[T exportRecord](memory bool, kind string, newRecord func() T) exportStore[T] {
+	if memory {
+		return &memoryExportStore[T]{exports: make(map[string]T), contents: make(map[string][][]byte)}
+	}
+	return cacheExportStore[T]{kind: kind, newRecord: newRecord}
+}
+
+type cacheExportStore[T exportRecord] struct {
+	kind      string
+	newRecord func() T
+}
+
+func (c cacheExportStore[T]) key(id string) string {
+	return fmt.Sprintf("endpoint:chat:%s:{%s}", c.kind, id)
+}
+
+func (c cacheExportStore[T]) save(ctx context.Context, export T) error {
+	data, err := protojson.Marshal(export)
+	if err != nil {
+		return err
+	}
+	return cache.Set(ctx, c.key(export.GetId()), string(data), roomExportTTL)
+}
+
+// saveContent guarda cada trozo cifrado con la clave maestra (utils.SealBlob): el archivo
+// lleva los mensajes descifrados y la caché no debe tenerlos en claro. El número de trozos
+// se guarda al final, así que un archivo a medio guardar no se puede descargar.
+func (c cacheExportStore[T]) saveContent(ctx context.Context, id string, content []byte) error {
+	chunks := exportChunks(content)
+	for i, chunk := range chunks {
+		sealed, err := utils.SealBlob(chunk)
+		if err != nil {
+			return err
+		}
+		if err := cache.Set(ctx, fmt.Sprintf("%s:content:%d", c.key(id), i), sealed, roomExportTTL); err != nil {
+			return err
+		}
+	}
+	return cache.Set(ctx, c.key(id)+":content", strconv.Itoa(len(chunks)), roomExportTTL)
+}
+
+func (c cacheExportStore[T]) load(ctx context.Context, id string) (T, bool, error) {
+	export := c.newRecord()
+	value, err := cache.Get(ctx, c.key(id))
+	if err != nil || value == "" {
+		return export, false, nil
+	}
+	if err := protojson.Unmarshal([]byte(value), export); err != nil {
+		return export, false, err
+	}
+	return export, true, nil
+}
+
+func (c cacheExportStore[T]) loadContent(ctx context.Context, id string, fn func(chunk []byte) error) error {
+	value, err := cache.Get(ctx, c.key(id)+":content")
+	if err != nil {
+		return err
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("export %s has no content", id)
+	}
+	for i := 0; i < count; i++ {
+		sealed, err := cache.Get(ctx, fmt.Sprintf("%s:content:%d", c.key(id), i))
+		if err != nil {
+			return err
+		}
+		chunk, err := utils.OpenBlob(sealed)
+		if err != nil {
+			return fmt.Errorf("export %s chunk %d: %w", id, i, err)
+		}
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memoryExportStore se usa con CHAT_STORE_MODE=memory y en los tests.
+type memoryExportStore[T exportRecord] struct {
+	mu       sync.Mutex
+	exports  map[string]T
+	contents map[string][][]byte
+}
+
+func (m *memoryExportStore[T]) save(ctx context.Context, export T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exports[export.GetId()] = proto.Clone(export).(T)
+	return nil
+}
+
+func (m *memoryExportStore[T]) saveContent(ctx context.Context, id string, content []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.contents[id] = exportChunks(content)
+	return nil
+}
+
+func (m *memoryExportStore[T]) load(ctx context.Context, id string) (T, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	export, ok := m.exports[id]
+	if !ok {
+		return export, false, nil
+	}
+	return proto.Clone(export).(T), true, nil
+}
+
+func (m *memoryExportStore[T]) loadContent(ctx context.Context, id string, fn func(chunk []byte) error) error {
+	m.mu.Lock()
+	chunks, ok := m.contents[id]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("export %s has no content", id)
+	}
+	for _, chunk := range chunks {
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// roomExportJobs ejecuta las exportaciones de historial en segundo plano.
+type roomExportJobs struct {
+	logger *slog.Logger
+	repo   roomsrepository.RoomsRepository
+	store  exportStore[*chatv1.RoomHistoryExport]
+	slots  chan struct{}
+}
+
+func newRoomExportJobs(logger *slog.Logger, repo roomsrepository.RoomsRepository, memoryStore bool) *roomExportJobs {
+	return &roomExportJobs{
+		logger: logger,
+		repo:   repo,
+		store:  newExportStore(memoryStore, "export", func() *chatv1.RoomHistoryExport { return &chatv1.RoomHistoryExport{} }),
+		slots:  make(chan struct{}, roomExportConcurrency),
+	}
+}
+
+// start registra la exportación como PENDING y la lanza en segundo plano.
+func (j *roomExportJobs) start(ctx context.Context, userID int, room *chatv1.Room, format chatv1.ExportFormat) (*chatv1.RoomHistoryExport, error) {
+	now := time.Now()
+	export := &chatv1.RoomHistoryExport{
+		Id:          uuid.NewString(),
+		RoomId:      room.Id,
+		Format:      format,
+		Status:      chatv1.ExportStatus_EXPORT_STATUS_PENDING,
+		CreatedAt:   now.UTC().Format(time.RFC3339),
+		UpdatedAt:   now.UTC().Format(time.RFC3339),
+		FileName:    roomExportFileName(room.Id, format, now),
+		ContentType: roomExportContentType(format),
+		RequestedBy: int32(userID),
+	}
+	if err := j.store.save(ctx, export); err != nil {
+		return nil, err
+	}
+
+	go j.run(proto.Clone(export).(*chatv1.RoomHistoryExport), userID, room)
+
+	return export, nil
+}
+
+func (j *roomExportJobs) run(export *chatv1.RoomHistoryExport, userID int, room *chatv1.Room) {
+	ctx, cancel := context.WithTimeout(context.Background(), roomExportTimeout)
+	defer cancel()
+
+	select {
+	case j.slots <- struct{}{}:
+		defer func() { <-j.slots }()
+	case <-ctx.Done():
+		j.finish(export, nil, ctx.Err())
+		return
+	}
+
+	export.Status = chatv1.ExportStatus_EXPORT_STATUS_RUNNING
+	j.update(ctx, export)
+
+	var buf bytes.Buffer
+	err := exportRoomHistory(ctx, j.repo, userID, room, export.Format, &exportLimitWriter{w: &buf}, func(exported, total int64) {
+		export.MessagesExported = exported
+		export.MessagesTotal = total
+		j.update(ctx, export)
+	})
+	j.finish(export, buf.Bytes(), err)
+}
+
+func (j *roomExportJobs) finish(export *chatv1.RoomHistoryExport, content []byte, err error) {
+	// El contexto del job puede haber expirado; el estado final se guarda igualmente
+	ctx := context.Background()
+
+	if err == nil {
+		err = j.store.saveContent(ctx, export.Id, content)
+	}
+	if err != nil {
+		j.logger.Error("Error exportando el historial", "exportID", export.Id, "roomID", export.RoomId, "error", err)
+		export.Status = chatv1.ExportStatus_EXPORT_STATUS_FAILED
+		export.ErrorMessage = err.Error()
+	} else {
+		j.logger.Info("Historial exportado", "exportID", export.Id, "roomID", export.RoomId, "messages", export.MessagesExported, "bytes", len(content))
+		export.Status = chatv1.ExportStatus_EXPORT_STATUS_COMPLETED
+		export.SizeBytes = int64(len(content))
+	}
+	j.update(ctx, export)
+}
+
+func (j *roomExportJobs) update(ctx context.Context, export *chatv1.RoomHistoryExport) {
+	export.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := j.store.save(ctx, export); err != nil {
+		j.logger.Error("Error guardando el estado de la exportación", "exportID", export.Id, "error", err)
+	}
+}
+
+// get devuelve la exportación. Las exportaciones RUNNING que dejaron de avanzar se informan
+// como FAILED.
+func (j *roomExportJobs) get(ctx context.Context, id string) (*chatv1.RoomHistoryExport, error) {
+	export, ok, err := j.store.load(ctx, id)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	if export.Status == chatv1.ExportStatus_EXPORT_STATUS_RUNNING {
+		updatedAt, err := time.Parse(time.RFC3339, export.UpdatedAt)
+		if err == nil && time.Since(updatedAt) > roomExportStaleAfter {
+			export.Status = chatv1.ExportStatus_EXPORT_STATUS_FAILED
+			export.ErrorMessage = "export interrupted"
+		}
+	}
+
+	return export, nil
+}
+
+// download entrega en orden los trozos del archivo de una exportación terminada.
+func (j *roomExportJobs) download(ctx context.Context, id string, fn func(chunk []byte) error) error {
+	return j.store.loadContent(ctx, id, fn)
+}
@@ -0,0 +1,267 @@
+package chatv1handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+)
+
+// exportFixture crea una sala de grupo con una respuesta, una edición, una reacción, un
+// adjunto y un mensaje eliminado. Ana (1) es owner y Luis (2) miembro.
+func exportFixture(t *testing.T) (roomsrepository.RoomsRepository, *chatv1.Room, map[string]*chatv1.MessageData) {
+	t.Helper()
+	ctx := context.Background()
+
+	repo := roomsrepository.NewMemoryRoomRepository([]roomsrepository.User{
+		{ID: 1, Name: "Ana", Phone: "+5800000001"},
+		{ID: 2, Name: "Luis", Phone: "+5800000002"},
+	})
+	repo.(*roomsrepository.MemoryRoomRepository).SetKeyGenerator(func() (string, error) { return "export-key", nil })
+
+	created, err := repo.CreateRoom(ctx, 1, &chatv1.CreateRoomRequest{
+		Type:         "group",
+		Name:         proto.String("Coordinación <zona 3>"),
+		Participants: []int32{2},
+	})
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	room, err := repo.GetRoom(ctx, 1, created.Id, true, false)
+	if err != nil || room == nil {
+		t.Fatalf("GetRoom: %v", err)
+	}
+
+	send := func(user int, req *chatv1.SendMessageRequest) *chatv1.MessageData {
+		t.Helper()
+		req.RoomId = room.Id
+		req.Type = "user_message"
+		msg, err := repo.SaveMessage(ctx, user, req, room, &req.Content)
+		if err != nil {
+			t.Fatalf("SaveMessage: %v", err)
+		}
+		return msg
+	}
+
+	messages := map[string]*chatv1.MessageData{}
+	messages["first"] = send(1, &chatv1.SendMessageRequest{Content: "hola"})
+	messages["reply"] = send(2, &chatv1.SendMessageRequest{Content: "sí, <b>mañana</b>", ReplyId: proto.String(messages["first"].Id)})
+	messages["file"] = send(1, &chatv1.SendMessageRequest{Content: "acta", File: proto.String("https://files.example.com/acta.pdf")})
+	messages["deleted"] = send(2, &chatv1.SendMessageRequest{Content: "borrar"})
+
+	if err := repo.UpdateMessage(ctx, 1, &chatv1.EditMessageRequest{MessageId: messages["first"].Id, NewContent: "hola a todos"}, nil); err != nil {
+		t.Fatalf("UpdateMessage: %v", err)
+	}
+	if err := repo.ReactToMessage(ctx, 1, messages["reply"].Id, "👍"); err != nil {
+		t.Fatalf("ReactToMessage: %v", err)
+	}
+	if err := repo.DeleteMessage(ctx, 2, []string{messages["deleted"].Id}); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+
+	return repo, room, messages
+}
+
+func TestExportRoomHistoryJSON(t *testing.T) {
+	repo, room, messages := exportFixture(t)
+
+	var buf bytes.Buffer
+	var lastExported, lastTotal int64
+	err := exportRoomHistory(context.Background(), repo, 1, room, chatv1.ExportFormat_EXPORT_FORMAT_JSON, &buf, func(exported, total int64) {
+		lastExported, lastTotal = exported, total
+	})
+	if err != nil {
+		t.Fatalf("exportRoomHistory: %v", err)
+	}
+
+	var archive struct {
+		Room     exportedRoom      `json:"room"`
+		Messages []exportedMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &archive); err != nil {
+		t.Fatalf("JSON inválido: %v\n%s", err, buf.String())
+	}
+	if archive.Room.ID != room.Id || archive.Room.ExportedBy != 1 {
+		t.Fatalf("cabecera inesperada: %+v", archive.Room)
+	}
+	if lastExported != int64(len(archive.Messages)) || lastTotal < lastExported {
+		t.Fatalf("progreso %d/%d con %d mensajes exportados", lastExported, lastTotal, len(archive.Messages))
+	}
+
+	byID := map[string]exportedMessage{}
+	for i, msg := range archive.Messages {
+		if i > 0 && msg.Seq <= archive.Messages[i-1].Seq {
+			t.Fatalf("mensajes fuera de orden: seq %d después de %d", msg.Seq, archive.Messages[i-1].Seq)
+		}
+		byID[msg.ID] = msg
+	}
+
+	first := byID[messages["first"].Id]
+	if first.Content != "hola a todos" || !first.Edited || first.SenderName != "Ana" {
+		t.Fatalf("mensaje editado inesperado: %+v", first)
+	}
+	reply := byID[messages["reply"].Id]
+	if reply.Reply == nil || reply.Reply.ID != messages["first"].Id {
+		t.Fatalf("respuesta sin referencia: %+v", reply)
+	}
+	if len(reply.Reactions) != 1 || reply.Reactions[0].Reaction != "👍" || reply.Reactions[0].UserName != "Ana" {
+		t.Fatalf("reacciones inesperadas: %+v", reply.Reactions)
+	}
+	if file := byID[messages["file"].Id]; file.Attachment != "https://files.example.com/acta.pdf" {
+		t.Fatalf("adjunto inesperado: %+v", file)
+	}
+	if deleted, ok := byID[messages["deleted"].Id]; !ok || !deleted.Deleted || deleted.Content != "" {
+		t.Fatalf("el mensaje eliminado debe exportarse sin contenido: %+v", deleted)
+	}
+}
+
+func TestExportRoomHistoryCSV(t *testing.T) {
+	repo, room, messages := exportFixture(t)
+
+	var buf bytes.Buffer
+	if err := exportRoomHistory(context.Background(), repo, 1, room, chatv1.ExportFormat_EXPORT_FORMAT_CSV, &buf, nil); err != nil {
+		t.Fatalf("exportRoomHistory: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV inválido: %v", err)
+	}
+	if strings.Join(records[0], ",") != strings.Join(roomExportCSVHeader, ",") {
+		t.Fatalf("cabecera inesperada: %v", records[0])
+	}
+
+	column := map[string]int{}
+	for i, name := range records[0] {
+		column[name] = i
+	}
+	var found bool
+	for _, record := range records[1:] {
+		if record[column["id"]] != messages["reply"].Id {
+			continue
+		}
+		found = true
+		if record[column["content"]] != "sí, <b>mañana</b>" || record[column["reply_to_id"]] != messages["first"].Id || record[column["reactions"]] != "👍 Ana" {
+			t.Fatalf("fila inesperada: %v", record)
+		}
+	}
+	if !found {
+		t.Fatalf("la respuesta no está en el CSV")
+	}
+}
+
+func TestExportRoomHistoryHTML(t *testing.T) {
+	repo, room, _ := exportFixture(t)
+
+	var buf bytes.Buffer
+	if err := exportRoomHistory(context.Background(), repo, 1, room, chatv1.ExportFormat_EXPORT_FORMAT_HTML, &buf, nil); err != nil {
+		t.Fatalf("exportRoomHistory: %v", err)
+	}
+
+	html := buf.String()
+	for _, want := range []string{"<!DOCTYPE html>", "Coordinación &lt;zona 3&gt;", "sí, &lt;b&gt;mañana&lt;/b&gt;", "https://files.example.com/acta.pdf", "Mensaje eliminado", "</html>"} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("el HTML no contiene %q", want)
+		}
+	}
+	if strings.Contains(html, "<b>mañana</b>") || strings.Contains(html, "<script") || strings.Contains(html, "<link") {
+		t.Fatalf("el HTML debe escapar el contenido y no cargar recursos externos")
+	}
+}
+
+func TestRoomExportJobs(t *testing.T) {
+	repo, room, _ := exportFixture(t)
+	ctx := context.Background()
+
+	jobs := newRoomExportJobs(slog.Default(), repo, true)
+	member, err := repo.GetRoom(ctx, 2, room.Id, false, false)
+	if err != nil {
+		t.Fatalf("GetRoom: %v", err)
+	}
+	if canExportRoomHistory(member) || !canExportRoomHistory(room) {
+		t.Fatalf("solo owners y admins pueden exportar (owner %q, miembro %q)", room.Role, member.Role)
+	}
+
+	export, err := jobs.start(ctx, 1, room, chatv1.ExportFormat_EXPORT_FORMAT_CSV)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if export.Status != chatv1.ExportStatus_EXPORT_STATUS_PENDING || !strings.HasSuffix(export.FileName, ".csv") {
+		t.Fatalf("exportación inicial inesperada: %v", export)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		current, err := jobs.get(ctx, export.Id)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if current.Status == chatv1.ExportStatus_EXPORT_STATUS_COMPLETED {
+			var content []byte
+			if err := jobs.download(ctx, export.Id, func(chunk []byte) error {
+				content = append(content, chunk...)
+				return nil
+			}); err != nil {
+				t.Fatalf("download: %v", err)
+			}
+			if len(content) == 0 || int64(len(content)) != current.SizeBytes || current.MessagesExported == 0 || current.MessagesExported != current.MessagesTotal {
+				t.Fatalf("exportación completa inesperada: %v (%d bytes)", current, len(content))
+			}
+			break
+		}
+		if current.Status == chatv1.ExportStatus_EXPORT_STATUS_FAILED {
+			t.Fatalf("la exportación falló: %s", current.ErrorMessage)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("la exportación no terminó: %v", current)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if missing, err := jobs.get(ctx, "no-existe"); err != nil || missing != nil {
+		t.Fatalf("una exportación inexistente debe devolver nil: %v %v", missing, err)
+	}
+}
+
+// El archivo se guarda en trozos que se descargan en orden, y una exportación que supera
+// el tamaño máximo falla en lugar de acumularse en memoria.
+func TestExportContentChunks(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryExportStore[*chatv1.RoomHistoryExport]{exports: make(map[string]*chatv1.RoomHistoryExport), contents: make(map[string][][]byte)}
+
+	content := bytes.Repeat([]byte("0123456789"), exportChunkSize/4)
+	if err := store.saveContent(ctx, "x", content); err != nil {
+		t.Fatalf("saveContent: %v", err)
+	}
+	var chunks [][]byte
+	if err := store.loadContent(ctx, "x", func(chunk []byte) error {
+		chunks = append(chunks, chunk)
+		return nil
+	}); err != nil {
+		t.Fatalf("loadContent: %v", err)
+	}
+	if len(chunks) != 3 || len(chunks[0]) != exportChunkSize || !bytes.Equal(bytes.Join(chunks, nil), content) {
+		t.Fatalf("trozos inesperados: %d", len(chunks))
+	}
+	if err := store.loadContent(ctx, "no-existe", func([]byte) error { return nil }); err == nil {
+		t.Fatal("una exportación sin archivo no se puede descargar")
+	}
+
+	var buf bytes.Buffer
+	w := &exportLimitWriter{w: &buf}
+	if _, err := w.Write(make([]byte, exportMaxSize)); err != nil {
+		t.Fatalf("el tamaño máximo debe caber: %v", err)
+	}
+	if _, err := w.Write([]byte{0}); err != errExportTooLarge {
+		t.Fatalf("se esperaba errExportTooLarge, error %v", err)
+	}
+}
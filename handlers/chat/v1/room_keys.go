@@ -0,0 +1,100 @@
+package chatv1handler
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/api"
+)
+
+// Rotación de la clave de cifrado de las salas (ver repository/rooms/room_keys.go). En grupos
+// la rotan owners y admins, y se rota sola cuando alguien sale o es removido; en p2p puede
+// rotarla cualquiera de los dos. La rotación publica is_room_updated desde el outbox. En salas
+// e2e la clave la genera el cliente (ver e2e.go) y la salida de participantes no la rota: la
+// rota el cliente al recibir el evento.
+
+func (h *handlerImpl) RotateRoomKey(ctx context.Context, req *connect.Request[chatv1.RotateRoomKeyRequest]) (*connect.Response[chatv1.RotateRoomKeyResponse], error) {
+	access, err := roomAccessFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := access.userID
+
+	room := access.room
+	if room.E2E {
+		return h.rotateE2ERoomKey(ctx, userID, room, req)
+	}
+
+	version, err := h.roomsRepository.RotateRoomKey(ctx, userID, room.Id)
+	if err != nil {
+		h.logger.Error("Error rotando la clave de la sala", "roomID", room.Id, "error", err)
+		return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InternalServerErrorCode, req.Header())
+	}
+
+	h.outbox.notify()
+
+	return connect.NewResponse(&chatv1.RotateRoomKeyResponse{KeyVersion: version}), nil
+}
+
+func (h *handlerImpl) GetRoomKeys(ctx context.Context, req *connect.Request[chatv1.GetRoomKeysRequest]) (*connect.Response[chatv1.GetRoomKeysResponse], error) {
+	access, err := roomAccessFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := access.userID
+
+	room := access.room
+
+	var keys []*chatv1.RoomKey
+	if room.E2E {
+		if req.Msg.GetDeviceId() == "" {
+			return nil, api.UpdateResponseInfoErrorMessageFromCode(api.InvalidRequestDataCode, req.Header())
+		}
+		keys, err = h.roomsRepository.GetWrappedRoomKeys(ctx, room.Id, userID, req.Msg.GetDeviceId())
+	} else {
+		keys, err = h.roomsRepository.GetRoomKeys(ctx, room.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return connect.NewResponse(&chatv1.GetRoomKeysResponse{Keys: keys}), nil
+}
+
+// messageKey resuelve la clave con la que el cliente cifró un mensaje nuevo: sin versión o
+// con la actual es la de la sala; una anterior se busca en el historial (un cliente que aún no
+// recibió la rotación). En salas e2e solo se comprueba la versión: el servidor no tiene la clave.
+func (h *handlerImpl) messageKey(ctx context.Context, room *chatv1.Room, version *int32) (int32, string, error) {
+	if room.E2E {
+		if version == nil {
+			return room.KeyVersion, "", nil
+		}
+		if *version < 1 || *version > room.KeyVersion {
+			return 0, "", roomsrepository.ErrUnknownKeyVersion
+		}
+		return *version, "", nil
+	}
+	if version == nil || *version == room.KeyVersion {
+		return room.KeyVersion, room.EncryptionData, nil
+	}
+	keys, err := h.roomsRepository.GetRoomKeys(ctx, room.Id)
+	if err != nil {
+		return 0, "", err
+	}
+	encryptionData, err := roomsrepository.RoomKeyFor(keys, *version)
+	if err != nil {
+		return 0, "", err
+	}
+	return *version, encryptionData, nil
+}
+
+// rotateAfterRemoval rota la clave de un grupo cuando salen participantes, para que no puedan
+// leer los mensajes nuevos. El error solo se registra: la salida ya se confirmó.
+func (h *handlerImpl) rotateAfterRemoval(ctx context.Context, userID int, roomID string) {
+	if _, err := h.roomsRepository.RotateRoomKey(ctx, userID, roomID); err != nil {
+		h.logger.Error("Error rotando la clave tras la salida de participantes", "roomID", roomID, "error", err)
+	}
+}
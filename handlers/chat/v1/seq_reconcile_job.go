@@ -0,0 +1,45 @@
+package chatv1handler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+)
+
+const (
+	seqReconcileInterval = 5 * time.Minute
+	// Margen desde la última reserva de la sala: pasado este tiempo ningún mensaje con un seq
+	// reservado sigue en vuelo
+	seqReconcileGrace = time.Minute
+)
+
+// seqReconcileJob anula periódicamente los seq que se reservaron sin que llegara a escribirse
+// su mensaje, para que el historial por seq no tenga huecos.
+type seqReconcileJob struct {
+	logger   *slog.Logger
+	repo     roomsrepository.RoomsRepository
+	interval time.Duration
+}
+
+func (j *seqReconcileJob) run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		voided, err := j.repo.VoidAbandonedSeqs(ctx, time.Now().Add(-seqReconcileGrace))
+		if err != nil {
+			j.logger.Error("Error anulando los seq abandonados", "error", err)
+		}
+		if voided > 0 {
+			j.logger.Info("Seq abandonados anulados", "voided", voided)
+		}
+	}
+}
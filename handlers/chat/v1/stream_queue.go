@@ -0,0 +1,293 @@
+package chatv1handler
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"connectrpc.com/connect"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+const (
+	// Máximo de eventos pendientes por conexión antes de forzar un resync
+	streamQueueLimit = 256
+	// Máximo tiempo que un evento puede esperar en la cola. Debe ser menor que el
+	// AckWait de los consumers (30s por defecto) para no provocar reentregas.
+	streamQueueMaxLag = 20 * time.Second
+)
+
+// errStreamResync indica que el cliente se quedó atrás y debe reconectar y resincronizar.
+var errStreamResync = errors.New("resync")
+
+var (
+	streamQueues        sync.Map // clientID -> *outboundQueue
+	streamQueuesResyncs atomic.Int64
+)
+
+func init() {
+	// Visible en /debug/vars (expvar) para detectar clientes lentos
+	expvar.Publish("chat_stream_queues", expvar.Func(streamQueuesSnapshot))
+}
+
+type outboundItem struct {
+	event    *chatv1.MessageEvent
+	ack      func() // confirma el mensaje de JetStream una vez escrito en el stream
+	key      string // llave de coalescencia, vacía si el evento no se puede fusionar
+	queuedAt time.Time
+}
+
+// outboundQueue es la cola acotada de salida de un stream. Los consumers de JetStream
+// encolan sin bloquear y una sola goroutine escribe en el stream, confirmando cada
+// mensaje después de escribirlo.
+//
+// Política: typing, status, estado de lectura y pings se fusionan por llave (gana el más reciente);
+// typing se descarta si la cola está llena; el resto nunca se descarta y, si la cola
+// se llena o se atrasa demasiado, el cliente se desconecta con errStreamResync.
+type outboundQueue struct {
+	clientID string
+	userID   int
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	items    []*outboundItem
+	pending  map[string]*outboundItem
+	notify   chan struct{}
+	overflow chan struct{}
+	slow     bool
+	closed   bool
+
+	maxDepth  atomic.Int64
+	sent      atomic.Int64
+	coalesced atomic.Int64
+	dropped   atomic.Int64
+}
+
+func newOutboundQueue(logger *slog.Logger, clientID string, userID int) *outboundQueue {
+	q := &outboundQueue{
+		clientID: clientID,
+		userID:   userID,
+		logger:   logger,
+		pending:  map[string]*outboundItem{},
+		notify:   make(chan struct{}, 1),
+		overflow: make(chan struct{}),
+	}
+	streamQueues.Store(clientID, q)
+	return q
+}
+
+// push encola un evento. ack puede ser nil para eventos que no vienen de JetStream.
+func (q *outboundQueue) push(event *chatv1.MessageEvent, ack func()) {
+	if ack == nil {
+		ack = func() {}
+	}
+	key := coalesceKey(event)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		// El stream ya terminó; el mensaje queda sin ack y JetStream lo reentregará
+		return
+	}
+
+	if key != "" {
+		if prev, ok := q.pending[key]; ok {
+			if isStaleStatus(prev.event, event) {
+				ack()
+			} else {
+				prev.ack()
+				prev.event = event
+				prev.ack = ack
+			}
+			q.coalesced.Add(1)
+			return
+		}
+	}
+
+	if len(q.items) >= streamQueueLimit || q.lagging() {
+		if _, ok := event.Event.(*chatv1.MessageEvent_Typing); ok {
+			q.dropped.Add(1)
+			ack()
+			return
+		}
+		q.markOverflow()
+		return
+	}
+
+	item := &outboundItem{event: event, ack: ack, key: key, queuedAt: time.Now()}
+	q.items = append(q.items, item)
+	if key != "" {
+		q.pending[key] = item
+	}
+
+	depth := int64(len(q.items))
+	if depth > q.maxDepth.Load() {
+		q.maxDepth.Store(depth)
+	}
+	if !q.slow && depth >= streamQueueLimit/2 {
+		q.slow = true
+		q.logger.Warn("Cliente lento: la cola de salida del stream supera la mitad del límite", "clientID", q.clientID, "userID", q.userID, "depth", depth)
+	}
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// lagging indica si el evento más antiguo lleva demasiado tiempo en la cola.
+func (q *outboundQueue) lagging() bool {
+	return len(q.items) > 0 && time.Since(q.items[0].queuedAt) > streamQueueMaxLag
+}
+
+func (q *outboundQueue) markOverflow() {
+	select {
+	case <-q.overflow:
+	default:
+		close(q.overflow)
+		streamQueuesResyncs.Add(1)
+		q.logger.Warn("Cliente demasiado atrasado, se fuerza resync", "clientID", q.clientID, "userID", q.userID, "depth", len(q.items))
+	}
+}
+
+func (q *outboundQueue) pop() *outboundItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return nil
+	}
+	item := q.items[0]
+	q.items[0] = nil
+	q.items = q.items[1:]
+	if item.key != "" {
+		delete(q.pending, item.key)
+	}
+	if q.slow && len(q.items) < streamQueueLimit/4 {
+		q.slow = false
+	}
+	return item
+}
+
+// eventSender es la parte del stream que usa run; *connect.ServerStream la implementa.
+type eventSender interface {
+	Send(*chatv1.MessageEvent) error
+}
+
+var _ eventSender = (*connect.ServerStream[chatv1.MessageEvent])(nil)
+
+// run escribe los eventos en el stream hasta que el contexto termine, la escritura
+// falle o el cliente se atrase. Es el único que llama a stream.Send.
+func (q *outboundQueue) run(ctx context.Context, stream eventSender) error {
+	for {
+		for item := q.pop(); item != nil; item = q.pop() {
+			if err := stream.Send(item.event); err != nil {
+				return fmt.Errorf("failed to write stream event: %w", err)
+			}
+			item.ack()
+			q.sent.Add(1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.overflow:
+			return errStreamResync
+		case <-q.notify:
+		}
+	}
+}
+
+// close descarta los eventos pendientes sin ack para que JetStream los reentregue.
+func (q *outboundQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.items = nil
+	q.pending = map[string]*outboundItem{}
+	streamQueues.CompareAndDelete(q.clientID, q)
+}
+
+// coalesceKey devuelve la llave con la que se fusionan los eventos que solo importan en
+// su último estado. Los mensajes nunca se fusionan.
+func coalesceKey(event *chatv1.MessageEvent) string {
+	switch detail := event.Event.(type) {
+	case *chatv1.MessageEvent_Typing:
+		return fmt.Sprintf("typing:%s:%d", event.RoomId, detail.Typing.GetUserId())
+	case *chatv1.MessageEvent_StatusUpdate:
+		return fmt.Sprintf("status:%s:%s:%d", event.RoomId, detail.StatusUpdate.GetMessageId(), detail.StatusUpdate.GetUserId())
+	case *chatv1.MessageEvent_ReadState:
+		return fmt.Sprintf("read_state:%s", event.RoomId)
+	case *chatv1.MessageEvent_HistoryPurged:
+		// Cada purga avanza el corte: basta con entregar el último
+		return fmt.Sprintf("history_purged:%s", event.RoomId)
+	case *chatv1.MessageEvent_Connected:
+		return "connected"
+	default:
+		return ""
+	}
+}
+
+// isStaleStatus evita que un status atrasado (p. ej. DELIVERED) pise uno ya encolado (READ).
+func isStaleStatus(prev, next *chatv1.MessageEvent) bool {
+	prevStatus, ok := prev.Event.(*chatv1.MessageEvent_StatusUpdate)
+	if !ok {
+		return false
+	}
+	nextStatus, ok := next.Event.(*chatv1.MessageEvent_StatusUpdate)
+	if !ok {
+		return false
+	}
+	return nextStatus.StatusUpdate.GetStatus() < prevStatus.StatusUpdate.GetStatus()
+}
+
+type streamQueueStats struct {
+	UserID    int   `json:"user_id"`
+	Depth     int   `json:"depth"`
+	MaxDepth  int64 `json:"max_depth"`
+	LagMs     int64 `json:"lag_ms"`
+	Sent      int64 `json:"sent"`
+	Coalesced int64 `json:"coalesced"`
+	Dropped   int64 `json:"dropped"`
+}
+
+func (q *outboundQueue) stats() streamQueueStats {
+	q.mu.Lock()
+	depth := len(q.items)
+	var lag time.Duration
+	if depth > 0 {
+		lag = time.Since(q.items[0].queuedAt)
+	}
+	q.mu.Unlock()
+
+	return streamQueueStats{
+		UserID:    q.userID,
+		Depth:     depth,
+		MaxDepth:  q.maxDepth.Load(),
+		LagMs:     lag.Milliseconds(),
+		Sent:      q.sent.Load(),
+		Coalesced: q.coalesced.Load(),
+		Dropped:   q.dropped.Load(),
+	}
+}
+
+func streamQueuesSnapshot() any {
+	clients := map[string]streamQueueStats{}
+	streamQueues.Range(func(key, value any) bool {
+		clients[key.(string)] = value.(*outboundQueue).stats()
+		return true
+	})
+	return map[string]any{
+		"limit":   streamQueueLimit,
+		"resyncs": streamQueuesResyncs.Load(),
+		"clients": clients,
+	}
+}
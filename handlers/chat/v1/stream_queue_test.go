@@ -0,0 +1,287 @@
+package chatv1handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// fakeEventStream registra lo que run escribe y, con failAfter, falla a partir de esa escritura.
+type fakeEventStream struct {
+	sent      []*chatv1.MessageEvent
+	failAfter int // 0 = nunca falla
+	onSend    func(*chatv1.MessageEvent)
+}
+
+func (s *fakeEventStream) Send(event *chatv1.MessageEvent) error {
+	if s.failAfter > 0 && len(s.sent) >= s.failAfter {
+		return errors.New("cliente desconectado")
+	}
+	s.sent = append(s.sent, event)
+	if s.onSend != nil {
+		s.onSend(event)
+	}
+	return nil
+}
+
+// ackLog registra qué eventos se confirmaron; ack(name) devuelve la función que pasa push.
+type ackLog map[string]int
+
+func (l ackLog) ack(name string) func() {
+	return func() { l[name]++ }
+}
+
+func newTestQueue(t *testing.T) *outboundQueue {
+	t.Helper()
+	q := newOutboundQueue(slog.Default(), "test-"+strings.ReplaceAll(t.Name(), "/", "-"), 1)
+	t.Cleanup(q.close)
+	return q
+}
+
+// drain escribe lo encolado con run y devuelve el error con el que terminó.
+func drain(t *testing.T, q *outboundQueue, stream *fakeEventStream) error {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- q.run(ctx, stream) }()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		select {
+		case err := <-done:
+			return err
+		default:
+		}
+		if q.stats().Depth == 0 {
+			break
+		}
+	}
+	cancel()
+	return <-done
+}
+
+func messageEvent(roomID, id string) *chatv1.MessageEvent {
+	return &chatv1.MessageEvent{RoomId: roomID, Event: &chatv1.MessageEvent_Message{Message: &chatv1.MessageData{Id: id}}}
+}
+
+func typingEvent(roomID string, userID int32, typing bool) *chatv1.MessageEvent {
+	return &chatv1.MessageEvent{RoomId: roomID, Event: &chatv1.MessageEvent_Typing{Typing: &chatv1.TypingEvent{UserId: userID, IsTyping: typing}}}
+}
+
+func statusEvent(roomID, messageID string, userID int32, status chatv1.MessageStatus) *chatv1.MessageEvent {
+	return &chatv1.MessageEvent{RoomId: roomID, Event: &chatv1.MessageEvent_StatusUpdate{StatusUpdate: &chatv1.MessageStatusUpdate{MessageId: messageID, UserId: userID, Status: status}}}
+}
+
+func readStateEvent(roomID, lastRead string) *chatv1.MessageEvent {
+	return &chatv1.MessageEvent{RoomId: roomID, Event: &chatv1.MessageEvent_ReadState{ReadState: &chatv1.ReadStateEvent{RoomId: roomID, LastReadMessageId: lastRead}}}
+}
+
+func TestOutboundQueueCoalescing(t *testing.T) {
+	const (
+		delivered = chatv1.MessageStatus_MESSAGE_STATUS_DELIVERED
+		read      = chatv1.MessageStatus_MESSAGE_STATUS_READ
+	)
+	type push struct {
+		name  string
+		event *chatv1.MessageEvent
+	}
+
+	cases := []struct {
+		name      string
+		pushes    []push
+		sent      []string // nombres de los eventos escritos, en orden
+		acked     []string // confirmados, escritos o descartados por la fusión
+		coalesced int64
+	}{
+		{
+			name:      "typing del mismo usuario se fusiona",
+			pushes:    []push{{"empieza", typingEvent("sala-1", 7, true)}, {"termina", typingEvent("sala-1", 7, false)}},
+			sent:      []string{"termina"},
+			acked:     []string{"empieza", "termina"},
+			coalesced: 1,
+		},
+		{
+			name:   "typing de usuarios distintos no se fusiona",
+			pushes: []push{{"ana", typingEvent("sala-1", 7, true)}, {"luis", typingEvent("sala-1", 8, true)}},
+			sent:   []string{"ana", "luis"},
+			acked:  []string{"ana", "luis"},
+		},
+		{
+			name:      "status más reciente reemplaza al encolado",
+			pushes:    []push{{"entregado", statusEvent("sala-1", "m1", 7, delivered)}, {"leido", statusEvent("sala-1", "m1", 7, read)}},
+			sent:      []string{"leido"},
+			acked:     []string{"entregado", "leido"},
+			coalesced: 1,
+		},
+		{
+			name:      "status atrasado no pisa al encolado",
+			pushes:    []push{{"leido", statusEvent("sala-1", "m1", 7, read)}, {"entregado", statusEvent("sala-1", "m1", 7, delivered)}},
+			sent:      []string{"leido"},
+			acked:     []string{"leido", "entregado"},
+			coalesced: 1,
+		},
+		{
+			name:   "status de mensajes distintos no se fusiona",
+			pushes: []push{{"m1", statusEvent("sala-1", "m1", 7, read)}, {"m2", statusEvent("sala-1", "m2", 7, read)}},
+			sent:   []string{"m1", "m2"},
+			acked:  []string{"m1", "m2"},
+		},
+		{
+			name:      "read_state de la sala conserva el último",
+			pushes:    []push{{"primero", readStateEvent("sala-1", "m1")}, {"mensaje", messageEvent("sala-1", "m3")}, {"segundo", readStateEvent("sala-1", "m2")}},
+			sent:      []string{"segundo", "mensaje"},
+			acked:     []string{"primero", "segundo", "mensaje"},
+			coalesced: 1,
+		},
+		{
+			name:   "read_state de salas distintas no se fusiona",
+			pushes: []push{{"sala-1", readStateEvent("sala-1", "m1")}, {"sala-2", readStateEvent("sala-2", "m1")}},
+			sent:   []string{"sala-1", "sala-2"},
+			acked:  []string{"sala-1", "sala-2"},
+		},
+		{
+			name:   "los mensajes nunca se fusionan",
+			pushes: []push{{"uno", messageEvent("sala-1", "m1")}, {"dos", messageEvent("sala-1", "m1")}},
+			sent:   []string{"uno", "dos"},
+			acked:  []string{"uno", "dos"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := newTestQueue(t)
+			acks := ackLog{}
+			names := map[*chatv1.MessageEvent]string{}
+			for _, p := range tc.pushes {
+				names[p.event] = p.name
+				q.push(p.event, acks.ack(p.name))
+			}
+
+			stream := &fakeEventStream{}
+			if err := drain(t, q, stream); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+
+			var sent []string
+			for _, event := range stream.sent {
+				sent = append(sent, names[event])
+			}
+			if fmt.Sprint(sent) != fmt.Sprint(tc.sent) {
+				t.Fatalf("escritos = %v, se esperaba %v", sent, tc.sent)
+			}
+			for _, name := range tc.acked {
+				if acks[name] != 1 {
+					t.Fatalf("%q confirmado %d veces, se esperaba 1 (%v)", name, acks[name], acks)
+				}
+			}
+			if len(acks) != len(tc.acked) {
+				t.Fatalf("confirmados = %v, se esperaba %v", acks, tc.acked)
+			}
+			if got := q.stats().Coalesced; got != tc.coalesced {
+				t.Fatalf("coalesced = %d, se esperaba %d", got, tc.coalesced)
+			}
+		})
+	}
+}
+
+func TestOutboundQueueResync(t *testing.T) {
+	cases := []struct {
+		name    string
+		prepare func(q *outboundQueue, acks ackLog) // deja la cola al límite o atrasada
+		queued  int                                 // mensajes que se escriben antes del resync
+	}{
+		{
+			name: "cola llena",
+			prepare: func(q *outboundQueue, acks ackLog) {
+				for i := range streamQueueLimit {
+					q.push(messageEvent("sala-1", fmt.Sprintf("m%d", i)), acks.ack(fmt.Sprintf("m%d", i)))
+				}
+			},
+			queued: streamQueueLimit,
+		},
+		{
+			name: "evento más antiguo con demasiado retraso",
+			prepare: func(q *outboundQueue, acks ackLog) {
+				q.push(messageEvent("sala-1", "m0"), acks.ack("m0"))
+				q.mu.Lock()
+				q.items[0].queuedAt = time.Now().Add(-streamQueueMaxLag - time.Second)
+				q.mu.Unlock()
+			},
+			queued: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := newTestQueue(t)
+			acks := ackLog{}
+			tc.prepare(q, acks)
+
+			// typing se descarta (y se confirma) sin forzar el resync
+			q.push(typingEvent("sala-1", 7, true), acks.ack("typing"))
+			if acks["typing"] != 1 || q.stats().Dropped != 1 {
+				t.Fatalf("typing con la cola al límite: acks %d, dropped %d", acks["typing"], q.stats().Dropped)
+			}
+			select {
+			case <-q.overflow:
+				t.Fatalf("descartar typing no debe forzar el resync")
+			default:
+			}
+
+			// Un mensaje no se descarta: se fuerza el resync y queda sin ack para reentregarse
+			q.push(messageEvent("sala-1", "desborde"), acks.ack("desborde"))
+			stream := &fakeEventStream{}
+			if err := q.run(context.Background(), stream); !errors.Is(err, errStreamResync) {
+				t.Fatalf("run = %v, se esperaba errStreamResync", err)
+			}
+			if acks["desborde"] != 0 {
+				t.Fatalf("el mensaje que desbordó la cola se confirmó")
+			}
+			if len(stream.sent) != tc.queued {
+				t.Fatalf("se escribieron %d mensajes, se esperaban los %d encolados", len(stream.sent), tc.queued)
+			}
+			for i, event := range stream.sent {
+				id := event.GetMessage().GetId()
+				if id != fmt.Sprintf("m%d", i) || acks[id] != 1 {
+					t.Fatalf("posición %d: %q con %d acks", i, id, acks[id])
+				}
+			}
+		})
+	}
+}
+
+// El ack llega después de escribir en el stream; si la escritura falla, el evento y los
+// siguientes quedan sin ack para que JetStream los reentregue.
+func TestOutboundQueueAckOnWrite(t *testing.T) {
+	q := newTestQueue(t)
+	acks := ackLog{}
+	for _, id := range []string{"m1", "m2", "m3"} {
+		q.push(messageEvent("sala-1", id), acks.ack(id))
+	}
+
+	stream := &fakeEventStream{failAfter: 1}
+	stream.onSend = func(event *chatv1.MessageEvent) {
+		if id := event.GetMessage().GetId(); acks[id] != 0 {
+			t.Fatalf("%s se confirmó antes de escribirse", id)
+		}
+	}
+	if err := q.run(context.Background(), stream); err == nil {
+		t.Fatalf("run debía devolver el error de escritura")
+	}
+	if acks["m1"] != 1 || acks["m2"] != 0 || acks["m3"] != 0 {
+		t.Fatalf("acks = %v, solo m1 llegó a escribirse", acks)
+	}
+
+	// Al cerrar, lo pendiente se descarta sin ack y lo que llegue después se ignora
+	q.close()
+	q.push(messageEvent("sala-1", "m4"), acks.ack("m4"))
+	if acks["m4"] != 0 || q.stats().Depth != 0 {
+		t.Fatalf("una cola cerrada no debe aceptar ni confirmar eventos")
+	}
+}
@@ -0,0 +1,165 @@
+package chatv1handler
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// errStreamUnavailable indica que la instancia no tiene NATS o JetStream (son opcionales en
+// HandlerDeps) y no puede servir streams.
+var errStreamUnavailable = errors.New("stream_unavailable")
+
+// streamSubscription guarda el filtro y los consumers de JetStream de un stream activo.
+// Se comparte entre los callbacks de los consumers y las actualizaciones del filtro,
+// por eso todo acceso pasa por el mutex.
+type streamSubscription struct {
+	mu         sync.Mutex
+	roomIDs    map[string]struct{} // vacío = todas las salas del usuario
+	eventTypes map[chatv1.StreamEventType]struct{}
+	consumers  map[string]jetstream.ConsumeContext
+}
+
+func newStreamSubscription(roomIDs []string, eventTypes []chatv1.StreamEventType) *streamSubscription {
+	s := &streamSubscription{consumers: map[string]jetstream.ConsumeContext{}}
+	s.setFilter(roomIDs, eventTypes)
+	return s
+}
+
+// setFilter reemplaza el filtro completo. Listas vacías significan "sin filtro".
+func (s *streamSubscription) setFilter(roomIDs []string, eventTypes []chatv1.StreamEventType) {
+	rooms := map[string]struct{}{}
+	for _, id := range roomIDs {
+		if id != "" {
+			rooms[id] = struct{}{}
+		}
+	}
+	types := map[chatv1.StreamEventType]struct{}{}
+	for _, t := range eventTypes {
+		if t != chatv1.StreamEventType_STREAM_EVENT_TYPE_UNSPECIFIED {
+			types[t] = struct{}{}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roomIDs = rooms
+	s.eventTypes = types
+}
+
+// wantsRoom indica si el filtro actual incluye la sala.
+func (s *streamSubscription) wantsRoom(roomID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.wantsRoomLocked(roomID)
+}
+
+func (s *streamSubscription) wantsRoomLocked(roomID string) bool {
+	if len(s.roomIDs) == 0 {
+		return true
+	}
+	_, ok := s.roomIDs[roomID]
+	return ok
+}
+
+// allows indica si el evento pasa el filtro de salas y de tipos de evento.
+func (s *streamSubscription) allows(event *chatv1.MessageEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.wantsRoomLocked(event.RoomId) {
+		return false
+	}
+	if len(s.eventTypes) == 0 {
+		return true
+	}
+	_, ok := s.eventTypes[streamEventTypeOf(event)]
+	return ok
+}
+
+// addConsumer crea el consumer con subscribe si todavía no existe uno para key.
+// Devuelve false si ya existía.
+func (s *streamSubscription) addConsumer(key string, subscribe func() (jetstream.ConsumeContext, error)) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.consumers[key]; ok {
+		return false, nil
+	}
+	cons, err := subscribe()
+	if err != nil {
+		return false, err
+	}
+	s.consumers[key] = cons
+	return true, nil
+}
+
+// removeConsumer detiene y elimina el consumer de key. Devuelve false si no existía.
+func (s *streamSubscription) removeConsumer(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cons, ok := s.consumers[key]
+	if !ok {
+		return false
+	}
+	cons.Stop()
+	delete(s.consumers, key)
+	return true
+}
+
+// consumerKeys devuelve las llaves de los consumers activos.
+func (s *streamSubscription) consumerKeys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.consumers))
+	for key := range s.consumers {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// stopAll detiene todos los consumers. Se llama al cerrar el stream.
+func (s *streamSubscription) stopAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, cons := range s.consumers {
+		cons.Stop()
+		delete(s.consumers, key)
+	}
+}
+
+// streamEventTypeOf traduce el oneof del evento al tipo usado por los filtros.
+func streamEventTypeOf(event *chatv1.MessageEvent) chatv1.StreamEventType {
+	switch event.Event.(type) {
+	case *chatv1.MessageEvent_Message:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_MESSAGE
+	case *chatv1.MessageEvent_StatusUpdate:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_STATUS_UPDATE
+	case *chatv1.MessageEvent_IsRoomUpdated:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_ROOM_UPDATED
+	case *chatv1.MessageEvent_RoomJoin:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_ROOM_JOIN
+	case *chatv1.MessageEvent_RoomLeave:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_ROOM_LEAVE
+	case *chatv1.MessageEvent_Typing:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_TYPING
+	case *chatv1.MessageEvent_Error:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_ERROR
+	case *chatv1.MessageEvent_UpdateMessage:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_UPDATE_MESSAGE
+	case *chatv1.MessageEvent_DeleteMessage:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_DELETE_MESSAGE
+	case *chatv1.MessageEvent_ReadState:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_READ_STATE
+	case *chatv1.MessageEvent_HistoryPurged:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_HISTORY_PURGED
+	default:
+		return chatv1.StreamEventType_STREAM_EVENT_TYPE_UNSPECIFIED
+	}
+}
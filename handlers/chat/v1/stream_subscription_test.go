@@ -0,0 +1,89 @@
+package chatv1handler
+
+import (
+	"testing"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+func TestStreamSubscriptionAllows(t *testing.T) {
+	message := &chatv1.MessageEvent{RoomId: "sala-1", Event: &chatv1.MessageEvent_Message{Message: &chatv1.MessageData{Id: "m1"}}}
+	typing := &chatv1.MessageEvent{RoomId: "sala-1", Event: &chatv1.MessageEvent_Typing{Typing: &chatv1.TypingEvent{}}}
+	otherRoom := &chatv1.MessageEvent{RoomId: "sala-2", Event: &chatv1.MessageEvent_Message{Message: &chatv1.MessageData{Id: "m2"}}}
+	unknown := &chatv1.MessageEvent{RoomId: "sala-1"}
+
+	cases := []struct {
+		name       string
+		roomIDs    []string
+		eventTypes []chatv1.StreamEventType
+		event      *chatv1.MessageEvent
+		want       bool
+	}{
+		{"sin filtro", nil, nil, message, true},
+		{"sin filtro, evento sin tipo", nil, nil, unknown, true},
+		{"sala incluida", []string{"sala-1"}, nil, message, true},
+		{"sala excluida", []string{"sala-1"}, nil, otherRoom, false},
+		{"tipo incluido", nil, []chatv1.StreamEventType{chatv1.StreamEventType_STREAM_EVENT_TYPE_MESSAGE}, message, true},
+		{"tipo excluido", nil, []chatv1.StreamEventType{chatv1.StreamEventType_STREAM_EVENT_TYPE_MESSAGE}, typing, false},
+		{"evento sin tipo con filtro de tipos", nil, []chatv1.StreamEventType{chatv1.StreamEventType_STREAM_EVENT_TYPE_MESSAGE}, unknown, false},
+		{"sala y tipo incluidos", []string{"sala-1"}, []chatv1.StreamEventType{chatv1.StreamEventType_STREAM_EVENT_TYPE_TYPING}, typing, true},
+		{"tipo incluido en otra sala", []string{"sala-1"}, []chatv1.StreamEventType{chatv1.StreamEventType_STREAM_EVENT_TYPE_MESSAGE}, otherRoom, false},
+		{"UNSPECIFIED no filtra", nil, []chatv1.StreamEventType{chatv1.StreamEventType_STREAM_EVENT_TYPE_UNSPECIFIED}, typing, true},
+		{"ID de sala vacío no filtra", []string{""}, nil, otherRoom, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sub := newStreamSubscription(tc.roomIDs, tc.eventTypes)
+			if got := sub.allows(tc.event); got != tc.want {
+				t.Fatalf("allows = %v, se esperaba %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStreamSubscriptionWantsRoom(t *testing.T) {
+	cases := []struct {
+		name    string
+		roomIDs []string
+		roomID  string
+		want    bool
+	}{
+		{"sin filtro", nil, "sala-1", true},
+		{"sala incluida", []string{"sala-1", "sala-2"}, "sala-2", true},
+		{"sala excluida", []string{"sala-1"}, "sala-3", false},
+		{"solo IDs vacíos", []string{"", ""}, "sala-3", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// El filtro de tipos no afecta a las salas
+			sub := newStreamSubscription(tc.roomIDs, []chatv1.StreamEventType{chatv1.StreamEventType_STREAM_EVENT_TYPE_TYPING})
+			if got := sub.wantsRoom(tc.roomID); got != tc.want {
+				t.Fatalf("wantsRoom(%q) = %v, se esperaba %v", tc.roomID, got, tc.want)
+			}
+		})
+	}
+}
+
+// setFilter reemplaza el filtro completo: lo que no se vuelve a enviar deja de filtrar.
+func TestStreamSubscriptionSetFilter(t *testing.T) {
+	message := &chatv1.MessageEvent{RoomId: "sala-2", Event: &chatv1.MessageEvent_Message{Message: &chatv1.MessageData{Id: "m1"}}}
+	readState := &chatv1.MessageEvent{RoomId: "sala-2", Event: &chatv1.MessageEvent_ReadState{ReadState: &chatv1.ReadStateEvent{}}}
+
+	sub := newStreamSubscription([]string{"sala-1"}, []chatv1.StreamEventType{chatv1.StreamEventType_STREAM_EVENT_TYPE_MESSAGE})
+	if sub.wantsRoom("sala-2") || sub.allows(message) {
+		t.Fatalf("el filtro inicial no debía incluir sala-2")
+	}
+
+	sub.setFilter([]string{"sala-2"}, []chatv1.StreamEventType{chatv1.StreamEventType_STREAM_EVENT_TYPE_READ_STATE})
+	if sub.wantsRoom("sala-1") || !sub.wantsRoom("sala-2") {
+		t.Fatalf("setFilter no reemplazó las salas")
+	}
+	if sub.allows(message) || !sub.allows(readState) {
+		t.Fatalf("setFilter no reemplazó los tipos de evento")
+	}
+
+	sub.setFilter(nil, nil)
+	if !sub.wantsRoom("sala-1") || !sub.allows(message) || !sub.allows(readState) {
+		t.Fatalf("un filtro vacío debe dejar pasar todo")
+	}
+}
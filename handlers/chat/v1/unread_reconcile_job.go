@@ -0,0 +1,44 @@
+package chatv1handler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+)
+
+const (
+	unreadReconcileInterval = 10 * time.Minute
+	// Usuarios revisados por ejecución, elegidos al azar entre los que tienen contadores
+	unreadReconcileBatchSize = 200
+)
+
+// unreadReconcileJob corrige periódicamente los contadores de no leídos de Redis que se
+// desviaron de la base de datos (mensajes borrados, purgas, carreras al inicializarlos).
+type unreadReconcileJob struct {
+	logger   *slog.Logger
+	repo     roomsrepository.RoomsRepository
+	interval time.Duration
+}
+
+func (j *unreadReconcileJob) run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := j.repo.ReconcileUnreadCounters(ctx, unreadReconcileBatchSize)
+		if err != nil {
+			j.logger.Error("Error reconciliando los contadores de no leídos", "error", err)
+		}
+		if report != nil && report.Repaired > 0 {
+			j.logger.Info("Contadores de no leídos corregidos", "users", report.Users, "repaired", report.Repaired)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package chatv1handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	tokensrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/tokens"
+)
+
+// Salas por página al recorrer las salas del usuario
+const userExportRoomsPageSize = 50
+
+func userExportFileName(userID int, at time.Time) string {
+	return fmt.Sprintf("chat-user-%d-%s.json", userID, at.UTC().Format("20060102-150405"))
+}
+
+// userDataArchive es el archivo de la exportación de datos de un usuario. Los mensajes
+// reutilizan el modelo de la exportación de historial.
+type userDataArchive struct {
+	User         exportedUser          `json:"user"`
+	ExportedAt   string                `json:"exported_at"`
+	Rooms        []exportedUserRoom    `json:"rooms"`
+	LeftRooms    []string              `json:"left_rooms"`
+	Messages     []exportedUserMessage `json:"messages"`
+	Reactions    []exportedUserReact   `json:"reactions"`
+	ReadReceipts []exportedReadReceipt `json:"read_receipts"`
+	PushTokens   []exportedPushToken   `json:"push_tokens"`
+}
+
+type exportedUser struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Phone string `json:"phone,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+type exportedUserRoom struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Type             string `json:"type"`
+	Role             string `json:"role"`
+	Partner          string `json:"partner,omitempty"`
+	Pinned           bool   `json:"pinned"`
+	Muted            bool   `json:"muted"`
+	PartnerBlocked   bool   `json:"partner_blocked"`
+	RetentionDays    *int32 `json:"retention_days,omitempty"`
+	CreatedAt        string `json:"created_at"`
+	LastMessageAt    string `json:"last_message_at,omitempty"`
+	MessagesSent     int    `json:"messages_sent"`
+	ReadReceiptCount int    `json:"read_receipts"`
+}
+
+type exportedUserMessage struct {
+	RoomID string `json:"room_id"`
+	exportedMessage
+}
+
+type exportedUserReact struct {
+	RoomID    string `json:"room_id"`
+	MessageID string `json:"message_id"`
+	Reaction  string `json:"reaction"`
+}
+
+type exportedReadReceipt struct {
+	RoomID    string `json:"room_id"`
+	MessageID string `json:"message_id"`
+	ReadAt    string `json:"read_at"`
+}
+
+type exportedPushToken struct {
+	Token           string `json:"token"`
+	Platform        string `json:"platform,omitempty"`
+	PlatformVersion string `json:"platform_version,omitempty"`
+	Device          string `json:"device,omitempty"`
+	Lang            string `json:"lang,omitempty"`
+	Voip            bool   `json:"voip"`
+}
+
+// exportUserData reúne los datos de chat del usuario y los escribe como JSON. Recorre el
+// historial de cada sala en la que participa; de las salas que dejó o que se eliminaron se
+// listan los IDs y se exportan solo los mensajes que envió, porque el resto del historial
+// ya no es accesible para el usuario. progress se llama después de cada sala con las salas
+// exportadas y el total.
+func exportUserData(ctx context.Context, repo roomsrepository.RoomsRepository, tokens tokensrepository.TokensRepository, userID int, w io.Writer, progress func(exported, total int)) error {
+	archive := userDataArchive{
+		ExportedAt:   time.Now().UTC().Format(time.RFC3339),
+		Rooms:        []exportedUserRoom{},
+		Messages:     []exportedUserMessage{},
+		Reactions:    []exportedUserReact{},
+		ReadReceipts: []exportedReadReceipt{},
+		PushTokens:   []exportedPushToken{},
+	}
+
+	user, err := repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	archive.User = exportedUser{ID: userID}
+	if user != nil {
+		archive.User.Name = user.Name
+		archive.User.Phone = user.Phone
+		if user.Email != nil {
+			archive.User.Email = *user.Email
+		}
+	}
+
+	var rooms []*chatv1.Room
+	cursor := ""
+	for {
+		page, meta, err := repo.GetRoomList(ctx, userID, &chatv1.GetRoomsRequest{Limit: userExportRoomsPageSize, Cursor: cursor})
+		if err != nil {
+			return err
+		}
+		rooms = append(rooms, page...)
+		if len(page) == 0 || meta == nil || meta.NextCursor == "" {
+			break
+		}
+		cursor = meta.NextCursor
+	}
+
+	archive.LeftRooms, err = repo.GetRoomListDeleted(ctx, userID, "")
+	if err != nil {
+		return err
+	}
+	if archive.LeftRooms == nil {
+		archive.LeftRooms = []string{}
+	}
+
+	// Salas fuera de la lista en las que envió mensajes: las que dejó o se eliminaron
+	listed := map[string]bool{}
+	for _, room := range rooms {
+		listed[room.Id] = true
+	}
+	messageRooms, err := repo.GetUserMessageRooms(ctx, userID)
+	if err != nil {
+		return err
+	}
+	var formerRooms []string
+	for _, roomID := range messageRooms {
+		if !listed[roomID] {
+			formerRooms = append(formerRooms, roomID)
+		}
+	}
+
+	total := len(rooms) + len(formerRooms)
+	for i, room := range rooms {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		exported, err := exportUserRoom(ctx, repo, userID, room, &archive)
+		if err != nil {
+			return fmt.Errorf("room %s: %w", room.Id, err)
+		}
+		archive.Rooms = append(archive.Rooms, exported)
+		if progress != nil {
+			progress(i+1, total)
+		}
+	}
+	for i, roomID := range formerRooms {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := exportUserSentMessages(ctx, repo, userID, roomID, &archive); err != nil {
+			return fmt.Errorf("room %s: %w", roomID, err)
+		}
+		if progress != nil {
+			progress(len(rooms)+i+1, total)
+		}
+	}
+
+	if tokens != nil {
+		registered, err := tokens.GetUserTokens(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, token := range registered {
+			archive.PushTokens = append(archive.PushTokens, exportedPushToken{
+				Token:           token.Token,
+				Platform:        token.Platform,
+				PlatformVersion: token.PlatformVersion,
+				Device:          token.Device,
+				Lang:            token.Lang,
+				Voip:            token.IsVoip,
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(archive)
+}
+
+// exportUserSentMessages agrega al archivo los mensajes que el usuario envió en una sala en
+// la que ya no participa.
+func exportUserSentMessages(ctx context.Context, repo roomsrepository.RoomsRepository, userID int, roomID string, archive *userDataArchive) error {
+	messages, err := repo.GetUserSentMessages(ctx, userID, roomID)
+	if err != nil || len(messages) == 0 {
+		return err
+	}
+	keys, err := repo.GetRoomKeys(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		if msg.Type != "system_message" {
+			archive.Messages = append(archive.Messages, exportedUserMessage{
+				RoomID:          roomID,
+				exportedMessage: newExportedMessage(msg, keys, nil),
+			})
+		}
+	}
+	return nil
+}
+
+// exportUserRoom agrega al archivo los mensajes, reacciones y lecturas del usuario en la
+// sala y devuelve sus ajustes.
+func exportUserRoom(ctx context.Context, repo roomsrepository.RoomsRepository, userID int, listed *chatv1.Room, archive *userDataArchive) (exportedUserRoom, error) {
+	exported := exportedUserRoom{
+		ID:             listed.Id,
+		Name:           listed.Name,
+		Type:           listed.Type,
+		Role:           listed.Role,
+		Pinned:         listed.IsPinned,
+		Muted:          listed.IsMuted,
+		PartnerBlocked: listed.IsPartnerBlocked,
+		RetentionDays:  listed.RetentionDays,
+		CreatedAt:      listed.CreatedAt,
+		LastMessageAt:  listed.LastMessageAt,
+	}
+	if listed.Partner != nil {
+		exported.Partner = listed.Partner.Name
+	}
+
+	room, err := repo.GetRoom(ctx, userID, listed.Id, false, true)
+	if err != nil {
+		return exported, err
+	}
+	if room == nil {
+		return exported, nil
+	}
+	keys, err := repo.GetRoomKeys(ctx, room.Id)
+	if err != nil {
+		return exported, err
+	}
+
+	reactedBy := strconv.Itoa(userID)
+	sent := map[string]bool{}
+	cursor := ""
+	for {
+		messages, meta, err := repo.GetMessagesFromRoom(ctx, userID, &chatv1.GetMessageHistoryRequest{
+			Id:       room.Id,
+			Limit:    roomExportPageSize,
+			AfterSeq: proto.Int64(0),
+			Cursor:   cursor,
+		})
+		if err != nil {
+			return exported, err
+		}
+		for _, msg := range messages {
+			if int(msg.SenderId) == userID && msg.Type != "system_message" {
+				archive.Messages = append(archive.Messages, exportedUserMessage{
+					RoomID:          room.Id,
+					exportedMessage: newExportedMessage(msg, keys, nil),
+				})
+				exported.MessagesSent++
+				sent[msg.Id] = true
+			}
+			for _, reaction := range msg.Reactions {
+				if reaction.ReactedById == reactedBy {
+					archive.Reactions = append(archive.Reactions, exportedUserReact{
+						RoomID:    room.Id,
+						MessageID: msg.Id,
+						Reaction:  reaction.Reaction,
+					})
+				}
+			}
+		}
+		if len(messages) == 0 || meta == nil || meta.NextCursor == "" {
+			break
+		}
+		cursor = meta.NextCursor
+	}
+
+	receipts, err := repo.GetUserReadReceipts(ctx, userID, room.Id)
+	if err != nil {
+		return exported, err
+	}
+	// Los mensajes propios cuentan como leídos por su remitente; no son lecturas
+	for _, receipt := range receipts {
+		if sent[receipt.MessageID] {
+			continue
+		}
+		exported.ReadReceiptCount++
+		archive.ReadReceipts = append(archive.ReadReceipts, exportedReadReceipt{
+			RoomID:    room.Id,
+			MessageID: receipt.MessageID,
+			ReadAt:    receipt.ReadAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return exported, nil
+}
@@ -0,0 +1,193 @@
+package chatv1handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	tokensrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/tokens"
+)
+
+const (
+	// Validez del handle de descarga. El archivo se conserva roomExportTTL; mientras exista,
+	// GetUserDataExport emite un handle nuevo cuando el anterior caduca
+	userExportHandleTTL = time.Hour
+	// Exportaciones de usuario simultáneas por instancia; recorren todas sus salas
+	userExportConcurrency = 1
+)
+
+// userExportJobs ejecuta las exportaciones de datos de usuario en segundo plano, igual que
+// roomExportJobs con el historial de una sala.
+type userExportJobs struct {
+	logger *slog.Logger
+	repo   roomsrepository.RoomsRepository
+	tokens tokensrepository.TokensRepository
+	store  exportStore[*chatv1.UserDataExport]
+	slots  chan struct{}
+}
+
+func newUserExportJobs(logger *slog.Logger, repo roomsrepository.RoomsRepository, tokens tokensrepository.TokensRepository, memoryStore bool) *userExportJobs {
+	return &userExportJobs{
+		logger: logger,
+		repo:   repo,
+		tokens: tokens,
+		store:  newExportStore(memoryStore, "user-export", func() *chatv1.UserDataExport { return &chatv1.UserDataExport{} }),
+		slots:  make(chan struct{}, userExportConcurrency),
+	}
+}
+
+// start registra la exportación como PENDING y la lanza en segundo plano.
+func (j *userExportJobs) start(ctx context.Context, userID int) (*chatv1.UserDataExport, error) {
+	now := time.Now()
+	export := &chatv1.UserDataExport{
+		Id:          uuid.NewString(),
+		UserId:      int32(userID),
+		Status:      chatv1.ExportStatus_EXPORT_STATUS_PENDING,
+		CreatedAt:   now.UTC().Format(time.RFC3339),
+		UpdatedAt:   now.UTC().Format(time.RFC3339),
+		FileName:    userExportFileName(userID, now),
+		ContentType: "application/json",
+	}
+	if err := j.store.save(ctx, export); err != nil {
+		return nil, err
+	}
+
+	go j.run(proto.Clone(export).(*chatv1.UserDataExport))
+
+	return export, nil
+}
+
+func (j *userExportJobs) run(export *chatv1.UserDataExport) {
+	ctx, cancel := context.WithTimeout(context.Background(), roomExportTimeout)
+	defer cancel()
+
+	select {
+	case j.slots <- struct{}{}:
+		defer func() { <-j.slots }()
+	case <-ctx.Done():
+		j.finish(export, nil, ctx.Err())
+		return
+	}
+
+	export.Status = chatv1.ExportStatus_EXPORT_STATUS_RUNNING
+	j.update(ctx, export)
+
+	var buf bytes.Buffer
+	err := exportUserData(ctx, j.repo, j.tokens, int(export.UserId), &exportLimitWriter{w: &buf}, func(exported, total int) {
+		export.RoomsExported = int32(exported)
+		export.RoomsTotal = int32(total)
+		j.update(ctx, export)
+	})
+	j.finish(export, buf.Bytes(), err)
+}
+
+func (j *userExportJobs) finish(export *chatv1.UserDataExport, content []byte, err error) {
+	// El contexto del job puede haber expirado; el estado final se guarda igualmente
+	ctx := context.Background()
+
+	if err == nil {
+		err = j.store.saveContent(ctx, export.Id, content)
+	}
+	if err == nil {
+		err = issueUserExportHandle(export)
+	}
+	if err != nil {
+		j.logger.Error("Error exportando los datos del usuario", "exportID", export.Id, "userID", export.UserId, "error", err)
+		export.Status = chatv1.ExportStatus_EXPORT_STATUS_FAILED
+		export.ErrorMessage = err.Error()
+	} else {
+		j.logger.Info("Datos de usuario exportados", "exportID", export.Id, "userID", export.UserId, "rooms", export.RoomsExported, "bytes", len(content))
+		export.Status = chatv1.ExportStatus_EXPORT_STATUS_COMPLETED
+	}
+	j.update(ctx, export)
+}
+
+func (j *userExportJobs) update(ctx context.Context, export *chatv1.UserDataExport) {
+	export.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := j.store.save(ctx, export); err != nil {
+		j.logger.Error("Error guardando el estado de la exportación", "exportID", export.Id, "error", err)
+	}
+}
+
+// get devuelve la exportación. Las RUNNING que dejaron de avanzar se informan como FAILED y
+// las COMPLETED con el handle caducado reciben uno nuevo.
+func (j *userExportJobs) get(ctx context.Context, id string) (*chatv1.UserDataExport, error) {
+	export, ok, err := j.store.load(ctx, id)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	switch export.Status {
+	case chatv1.ExportStatus_EXPORT_STATUS_RUNNING:
+		updatedAt, err := time.Parse(time.RFC3339, export.UpdatedAt)
+		if err == nil && time.Since(updatedAt) > roomExportStaleAfter {
+			export.Status = chatv1.ExportStatus_EXPORT_STATUS_FAILED
+			export.ErrorMessage = "export interrupted"
+		}
+	case chatv1.ExportStatus_EXPORT_STATUS_COMPLETED:
+		expiresAt, err := time.Parse(time.RFC3339, export.DownloadExpiresAt)
+		if err != nil || time.Now().After(expiresAt) {
+			if err := issueUserExportHandle(export); err != nil {
+				return nil, err
+			}
+			j.update(ctx, export)
+		}
+	}
+
+	return export, nil
+}
+
+// download devuelve la exportación y su archivo si el handle es válido, no caducó y
+// pertenece al usuario. Cualquier otro caso devuelve nil.
+func (j *userExportJobs) download(ctx context.Context, userID int, handle string) (*chatv1.UserDataExport, []byte, error) {
+	id, _, ok := strings.Cut(handle, ".")
+	if !ok {
+		return nil, nil, nil
+	}
+	export, ok, err := j.store.load(ctx, id)
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+	if export.Status != chatv1.ExportStatus_EXPORT_STATUS_COMPLETED || int(export.UserId) != userID {
+		return nil, nil, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(export.DownloadHandle), []byte(handle)) != 1 {
+		return nil, nil, nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, export.DownloadExpiresAt)
+	if err != nil || time.Now().After(expiresAt) {
+		return nil, nil, nil
+	}
+
+	var content []byte
+	err = j.store.loadContent(ctx, id, func(chunk []byte) error {
+		content = append(content, chunk...)
+		return nil
+	})
+	if err != nil || len(content) == 0 {
+		return nil, nil, err
+	}
+	return export, content, nil
+}
+
+// issueUserExportHandle genera un handle de descarga nuevo: el ID de la exportación y un
+// secreto aleatorio, para que el handle no se pueda deducir del ID.
+func issueUserExportHandle(export *chatv1.UserDataExport) error {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	export.DownloadHandle = export.Id + "." + base64.RawURLEncoding.EncodeToString(secret)
+	export.DownloadExpiresAt = time.Now().Add(userExportHandleTTL).UTC().Format(time.RFC3339)
+	return nil
+}
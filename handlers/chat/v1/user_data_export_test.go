@@ -0,0 +1,176 @@
+package chatv1handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	tokensv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/tokens/v1"
+	tokensrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/tokens"
+)
+
+func TestExportUserData(t *testing.T) {
+	repo, room, messages := exportFixture(t)
+	ctx := context.Background()
+
+	if err := repo.PinRoom(ctx, 2, room.Id, true); err != nil {
+		t.Fatalf("PinRoom: %v", err)
+	}
+	if _, err := repo.MarkMessagesAsRead(ctx, 2, room.Id, []string{messages["first"].Id}, ""); err != nil {
+		t.Fatalf("MarkMessagesAsRead: %v", err)
+	}
+	if err := repo.ReactToMessage(ctx, 2, messages["file"].Id, "❤️"); err != nil {
+		t.Fatalf("ReactToMessage: %v", err)
+	}
+	tokens := tokensrepository.NewMemoryTokensRepository()
+	if err := tokens.SaveToken(ctx, 2, &tokensv1.SaveTokenRequest{Token: "fcm-luis", Platform: "ANDROID"}); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	var buf bytes.Buffer
+	var lastExported, lastTotal int
+	err := exportUserData(ctx, repo, tokens, 2, &buf, func(exported, total int) {
+		lastExported, lastTotal = exported, total
+	})
+	if err != nil {
+		t.Fatalf("exportUserData: %v", err)
+	}
+
+	var archive userDataArchive
+	if err := json.Unmarshal(buf.Bytes(), &archive); err != nil {
+		t.Fatalf("JSON inválido: %v\n%s", err, buf.String())
+	}
+	if archive.User.ID != 2 || archive.User.Name != "Luis" || lastExported != 1 || lastTotal != 1 {
+		t.Fatalf("cabecera inesperada: %+v (progreso %d/%d)", archive.User, lastExported, lastTotal)
+	}
+	if len(archive.Rooms) != 1 || !archive.Rooms[0].Pinned || archive.Rooms[0].Role != "MEMBER" {
+		t.Fatalf("salas inesperadas: %+v", archive.Rooms)
+	}
+
+	// Solo los mensajes de Luis, descifrados
+	var reply *exportedUserMessage
+	for i, msg := range archive.Messages {
+		if msg.SenderID != 2 {
+			t.Fatalf("mensaje de otro usuario en la exportación: %+v", msg)
+		}
+		if msg.ID == messages["reply"].Id {
+			reply = &archive.Messages[i]
+		}
+	}
+	if reply == nil || reply.Content != "sí, <b>mañana</b>" || reply.RoomID != room.Id {
+		t.Fatalf("respuesta inesperada: %+v", reply)
+	}
+
+	if len(archive.Reactions) != 1 || archive.Reactions[0].MessageID != messages["file"].Id || archive.Reactions[0].Reaction != "❤️" {
+		t.Fatalf("reacciones inesperadas: %+v", archive.Reactions)
+	}
+	if len(archive.ReadReceipts) != 1 || archive.ReadReceipts[0].MessageID != messages["first"].Id {
+		t.Fatalf("lecturas inesperadas: %+v", archive.ReadReceipts)
+	}
+	if len(archive.PushTokens) != 1 || archive.PushTokens[0].Token != "fcm-luis" {
+		t.Fatalf("tokens inesperados: %+v", archive.PushTokens)
+	}
+}
+
+// Los mensajes enviados en una sala que el usuario dejó también se exportan.
+func TestExportUserDataLeftRoom(t *testing.T) {
+	repo, room, messages := exportFixture(t)
+	ctx := context.Background()
+
+	if _, err := repo.LeaveRoom(ctx, 2, room.Id, []int32{2}, false); err != nil {
+		t.Fatalf("LeaveRoom: %v", err)
+	}
+
+	var buf bytes.Buffer
+	var lastExported, lastTotal int
+	err := exportUserData(ctx, repo, nil, 2, &buf, func(exported, total int) {
+		lastExported, lastTotal = exported, total
+	})
+	if err != nil {
+		t.Fatalf("exportUserData: %v", err)
+	}
+
+	var archive userDataArchive
+	if err := json.Unmarshal(buf.Bytes(), &archive); err != nil {
+		t.Fatalf("JSON inválido: %v\n%s", err, buf.String())
+	}
+	if len(archive.Rooms) != 0 || !slices.Contains(archive.LeftRooms, room.Id) || lastExported != 1 || lastTotal != 1 {
+		t.Fatalf("salas inesperadas: %+v %v (progreso %d/%d)", archive.Rooms, archive.LeftRooms, lastExported, lastTotal)
+	}
+	var reply *exportedUserMessage
+	for i, msg := range archive.Messages {
+		if msg.SenderID != 2 || msg.RoomID != room.Id {
+			t.Fatalf("mensaje inesperado en la exportación: %+v", msg)
+		}
+		if msg.ID == messages["reply"].Id {
+			reply = &archive.Messages[i]
+		}
+	}
+	if reply == nil || reply.Content != "sí, <b>mañana</b>" {
+		t.Fatalf("no se exportó el mensaje de la sala que dejó: %+v", archive.Messages)
+	}
+}
+
+func TestUserExportJobsDownloadHandle(t *testing.T) {
+	repo, _, _ := exportFixture(t)
+	ctx := context.Background()
+
+	jobs := newUserExportJobs(slog.Default(), repo, tokensrepository.NewMemoryTokensRepository(), true)
+	export, err := jobs.start(ctx, 1)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for export.Status != chatv1.ExportStatus_EXPORT_STATUS_COMPLETED {
+		if export.Status == chatv1.ExportStatus_EXPORT_STATUS_FAILED {
+			t.Fatalf("la exportación falló: %s", export.ErrorMessage)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("la exportación no terminó: %v", export)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if export, err = jobs.get(ctx, export.Id); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+	}
+	if export.DownloadHandle == "" {
+		t.Fatalf("exportación completa sin handle: %v", export)
+	}
+
+	if got, content, err := jobs.download(ctx, 1, export.DownloadHandle); err != nil || got == nil || len(content) == 0 {
+		t.Fatalf("descarga con handle válido: %v %d %v", got, len(content), err)
+	}
+	for name, handle := range map[string]string{
+		"sin secreto":     export.Id,
+		"secreto erróneo": export.Id + ".otro",
+	} {
+		if got, _, err := jobs.download(ctx, 1, handle); err != nil || got != nil {
+			t.Fatalf("%s: la descarga debe rechazarse", name)
+		}
+	}
+	if got, _, _ := jobs.download(ctx, 2, export.DownloadHandle); got != nil {
+		t.Fatalf("otro usuario no puede descargar la exportación")
+	}
+
+	// Un handle caducado se rechaza, y la siguiente consulta emite otro
+	export.DownloadExpiresAt = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	if err := jobs.store.save(ctx, export); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if got, _, _ := jobs.download(ctx, 1, export.DownloadHandle); got != nil {
+		t.Fatalf("el handle caducado debe rechazarse")
+	}
+	renewed, err := jobs.get(ctx, export.Id)
+	if err != nil || renewed.DownloadHandle == export.DownloadHandle {
+		t.Fatalf("el handle caducado debe renovarse: %v %v", renewed, err)
+	}
+	if got, _, _ := jobs.download(ctx, 1, renewed.DownloadHandle); got == nil {
+		t.Fatalf("el handle renovado debe servir")
+	}
+}
@@ -0,0 +1,224 @@
+package chatv1handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/database"
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	roomsrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/rooms"
+	tokensrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/tokens"
+	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/config"
+)
+
+const (
+	userErasureDefaultSubject = "USERS.deleted"
+	userErasureDefaultStream  = "CHAT_USER_ERASURE"
+	userErasureConsumer       = "chat-user-erasure"
+	userErasureTimeout        = 10 * time.Minute
+	// Borrados en curso a la vez en cada instancia (MaxAckPending del consumer)
+	userErasureConcurrency = 4
+	// Reintentos de un borrado que falló o no quedó verificado: se duplica el retraso en
+	// cada entrega hasta userErasureRetryMax
+	userErasureRetryBase = 30 * time.Second
+	userErasureRetryMax  = 30 * time.Minute
+)
+
+// userErasureSubject lee el subject del evento de usuario eliminado (chat.userDeletedSubject).
+func userErasureSubject() string {
+	if subject := config.GetString("chat.userDeletedSubject"); subject != "" {
+		return subject
+	}
+	return userErasureDefaultSubject
+}
+
+// userErasureStreams devuelve el stream que hay que crear para el evento de usuario
+// eliminado. Si el servicio de usuarios ya lo guarda en un stream propio
+// (chat.userDeletedStream) se consume de ese y no se crea ninguno.
+func userErasureStreams() []jetstream.StreamConfig {
+	if config.GetString("chat.userDeletedStream") != "" {
+		return nil
+	}
+	return []jetstream.StreamConfig{{
+		Name:      userErasureDefaultStream,
+		Subjects:  []string{userErasureSubject()},
+		Storage:   jetstream.FileStorage,
+		Retention: jetstream.WorkQueuePolicy,
+	}}
+}
+
+func userErasureStream() string {
+	if stream := config.GetString("chat.userDeletedStream"); stream != "" {
+		return stream
+	}
+	return userErasureDefaultStream
+}
+
+// userErasureRetryDelay es el retraso antes de volver a entregar un evento que ya se
+// entregó delivered veces.
+func userErasureRetryDelay(delivered uint64) time.Duration {
+	delay := userErasureRetryBase
+	for i := uint64(1); i < delivered && delay < userErasureRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, userErasureRetryMax)
+}
+
+// newTokensRepository elige el store de tokens igual que el servicio de tokens: en memoria
+// con CHAT_STORE_MODE=memory y en Postgres en cualquier otro modo.
+func newTokensRepository() tokensrepository.TokensRepository {
+	if os.Getenv("CHAT_STORE_MODE") == "memory" {
+		return tokensrepository.NewMemoryTokensRepository()
+	}
+	return tokensrepository.NewSQLTokensRepository(database.DB())
+}
+
+// userErasure borra los datos de chat de un usuario: primero salas y mensajes, después
+// los tokens de notificaciones, y al final verifica que no quede nada. Las dos fases son
+// idempotentes, así que ante un error basta con repetir la petición (o el evento).
+type userErasure struct {
+	logger *slog.Logger
+	rooms  roomsrepository.RoomsRepository
+	tokens tokensrepository.TokensRepository
+	outbox *outboxRelay
+}
+
+func (e *userErasure) erase(ctx context.Context, userID int) (*chatv1.UserErasureReport, error) {
+	erased, err := e.rooms.EraseUserData(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase rooms data: %w", err)
+	}
+	e.rotateLeftRooms(ctx, userID, erased.RoomsLeft)
+	// Los RoomLeave ya están en el outbox aunque falle lo que sigue
+	e.outbox.notify()
+
+	report := &chatv1.UserErasureReport{
+		UserId:             int32(userID),
+		MessagesAnonymised: int32(erased.MessagesAnonymised),
+		RoomsLeft:          erased.RoomsLeft,
+		RoomsClosed:        erased.RoomsClosed,
+		OwnersPromoted:     map[string]int32{},
+		ErasedAt:           time.Now().UTC().Format(time.RFC3339),
+	}
+	for roomID, owner := range erased.OwnersPromoted {
+		report.OwnersPromoted[roomID] = int32(owner)
+	}
+
+	if e.tokens != nil {
+		report.TokensDeleted, err = e.tokens.DeleteUserTokens(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to erase messaging tokens: %w", err)
+		}
+	}
+
+	report.Remaining, err = e.rooms.VerifyUserErasure(ctx, userID, erased.Rooms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify erasure: %w", err)
+	}
+	if e.tokens != nil {
+		tokens, err := e.tokens.CountUserTokens(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify erasure: %w", err)
+		}
+		if tokens > 0 {
+			report.Remaining = append(report.Remaining, fmt.Sprintf("messaging tokens: %d", tokens))
+		}
+	}
+	report.Verified = len(report.Remaining) == 0
+
+	logLevel := slog.LevelInfo
+	if !report.Verified {
+		logLevel = slog.LevelWarn
+	}
+	e.logger.Log(ctx, logLevel, "Datos de usuario borrados",
+		"userID", userID,
+		"messagesAnonymised", report.MessagesAnonymised,
+		"tokensDeleted", report.TokensDeleted,
+		"roomsLeft", len(report.RoomsLeft),
+		"roomsClosed", len(report.RoomsClosed),
+		"ownersPromoted", len(report.OwnersPromoted),
+		"remaining", report.Remaining,
+	)
+	return report, nil
+}
+
+// rotateLeftRooms rota la clave de los grupos de los que se retiró al usuario, igual que
+// rotateAfterRemoval tras LeaveRoom. Los E2E no tienen clave en el servidor y se saltan. El
+// error solo se registra: un reintento del borrado ya no vería esas salas.
+func (e *userErasure) rotateLeftRooms(ctx context.Context, userID int, roomIDs []string) {
+	for _, roomID := range roomIDs {
+		if _, err := e.rooms.RotateRoomKey(ctx, 0, roomID); err != nil && !errors.Is(err, roomsrepository.ErrE2ERoom) {
+			e.logger.Error("Error rotando la clave tras borrar al usuario", "userID", userID, "roomID", roomID, "error", err)
+		}
+	}
+}
+
+// userDeletedEvent es el payload del evento de usuario eliminado que publica el servicio
+// de usuarios.
+type userDeletedEvent struct {
+	UserID int `json:"user_id"`
+}
+
+// consume atiende el evento de usuario eliminado con un consumer durable de JetStream
+// compartido por todas las instancias, así que cada evento lo procesa una sola y no se
+// pierde si ninguna está conectada cuando se publica.
+func (e *userErasure) consume(ctx context.Context, js jetstream.JetStream) (jetstream.ConsumeContext, error) {
+	cons, err := js.CreateOrUpdateConsumer(ctx, userErasureStream(), jetstream.ConsumerConfig{
+		Durable:       userErasureConsumer,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+		FilterSubject: userErasureSubject(),
+		AckWait:       userErasureTimeout + time.Minute,
+		MaxAckPending: userErasureConcurrency,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create or update consumer %s: %w", userErasureConsumer, err)
+	}
+	return cons.Consume(e.handle)
+}
+
+// handle lanza el borrado fuera de la goroutine del consumer, que se bloquearía durante
+// todo el borrado. El evento se confirma solo cuando el borrado quedó verificado; si falla
+// o queda algo, se vuelve a entregar con un retraso creciente.
+func (e *userErasure) handle(msg jetstream.Msg) {
+	var event userDeletedEvent
+	if err := json.Unmarshal(msg.Data(), &event); err != nil || event.UserID <= 0 {
+		e.logger.Error("Evento de usuario eliminado inválido", "subject", msg.Subject(), "data", string(msg.Data()), "error", err)
+		_ = msg.Term()
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), userErasureTimeout)
+		defer cancel()
+
+		report, err := e.erase(ctx, event.UserID)
+		if err == nil && report.Verified {
+			if err := msg.Ack(); err != nil {
+				e.logger.Error("Error confirmando el evento de usuario eliminado", "userID", event.UserID, "error", err)
+			}
+			return
+		}
+
+		var delivered uint64 = 1
+		if meta, metaErr := msg.Metadata(); metaErr == nil {
+			delivered = meta.NumDelivered
+		}
+		delay := userErasureRetryDelay(delivered)
+		if err != nil {
+			e.logger.Error("Error borrando los datos del usuario, se reintentará", "userID", event.UserID, "attempt", delivered, "retryIn", delay, "error", err)
+		} else {
+			e.logger.Warn("Borrado de usuario sin verificar, se reintentará", "userID", event.UserID, "attempt", delivered, "retryIn", delay, "remaining", report.Remaining)
+		}
+		if err := msg.NakWithDelay(delay); err != nil {
+			e.logger.Error("Error devolviendo el evento de usuario eliminado", "userID", event.UserID, "error", err)
+		}
+	}()
+}
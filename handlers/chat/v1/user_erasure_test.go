@@ -0,0 +1,80 @@
+package chatv1handler
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	tokensv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/tokens/v1"
+	tokensrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/tokens"
+)
+
+func TestUserErasure(t *testing.T) {
+	repo, room, messages := exportFixture(t)
+	ctx := context.Background()
+
+	tokens := tokensrepository.NewMemoryTokensRepository()
+	if err := tokens.SaveToken(ctx, 1, &tokensv1.SaveTokenRequest{Token: "fcm-ana"}); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	if err := tokens.SaveToken(ctx, 2, &tokensv1.SaveTokenRequest{Token: "fcm-luis"}); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	keys, err := repo.GetRoomKeys(ctx, room.Id)
+	if err != nil || len(keys) == 0 {
+		t.Fatalf("GetRoomKeys: %v %v", keys, err)
+	}
+
+	erasure := &userErasure{logger: slog.Default(), rooms: repo, tokens: tokens}
+	report, err := erasure.erase(ctx, 1)
+	if err != nil {
+		t.Fatalf("erase: %v", err)
+	}
+	if !report.Verified || len(report.Remaining) != 0 || report.TokensDeleted != 1 || report.MessagesAnonymised != 2 {
+		t.Fatalf("informe inesperado: %v", report)
+	}
+	if report.OwnersPromoted[room.Id] != 2 {
+		t.Fatalf("Luis debe quedar como owner: %v", report.OwnersPromoted)
+	}
+	// Como al salir del grupo, la clave cambia para que Ana no lea los mensajes nuevos
+	if rotated, err := repo.GetRoomKeys(ctx, room.Id); err != nil || rotated[0].Version != keys[0].Version+1 {
+		t.Fatalf("la clave del grupo no rotó: %v %v", rotated, err)
+	}
+	if n, _ := tokens.CountUserTokens(ctx, 2); n != 1 {
+		t.Fatalf("se borraron tokens de otro usuario")
+	}
+
+	msg, err := repo.GetMessage(ctx, 2, messages["first"].Id)
+	if err != nil {
+		t.Fatalf("GetMessage: %v", err)
+	}
+	if msg != nil && (msg.Content != "" || !msg.IsDeleted) {
+		t.Fatalf("el mensaje de Ana conserva contenido: %v", msg)
+	}
+	if reply, _ := repo.GetMessage(ctx, 2, messages["reply"].Id); reply == nil || len(reply.Reactions) != 0 {
+		t.Fatalf("la reacción de Ana debe borrarse: %v", reply)
+	}
+
+	// El evento de usuario eliminado es idempotente: repetirlo no deja rastros
+	again, err := erasure.erase(ctx, 1)
+	if err != nil || !again.Verified || again.TokensDeleted != 0 {
+		t.Fatalf("segundo borrado: %v %v", again, err)
+	}
+}
+
+// Los reintentos de un borrado duplican el retraso en cada entrega hasta el máximo.
+func TestUserErasureRetryDelay(t *testing.T) {
+	for delivered, want := range map[uint64]time.Duration{
+		0:  userErasureRetryBase,
+		1:  userErasureRetryBase,
+		2:  2 * userErasureRetryBase,
+		3:  4 * userErasureRetryBase,
+		20: userErasureRetryMax,
+	} {
+		if got := userErasureRetryDelay(delivered); got != want {
+			t.Errorf("entrega %d: retraso %v, se esperaba %v", delivered, got, want)
+		}
+	}
+}
@@ -4,16 +4,20 @@ import (
 	"context"
 
 	"connectrpc.com/connect"
-	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/database"
 	tokensv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/tokens/v1"
 	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/tokens/v1/tokensv1connect"
 	tokensrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/tokens"
 	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
 )
 
-var handler tokensv1connect.TokensServiceHandler = &handlerImpl{}
+type handlerImpl struct {
+	tokensRepository tokensrepository.TokensRepository
+}
 
-type handlerImpl struct{}
+// NewHandler crea el manejador del servicio de tokens con el repositorio indicado.
+func NewHandler(tokensRepository tokensrepository.TokensRepository) tokensv1connect.TokensServiceHandler {
+	return &handlerImpl{tokensRepository: tokensRepository}
+}
 
 func (h *handlerImpl) SaveToken(ctx context.Context, req *connect.Request[tokensv1.SaveTokenRequest]) (*connect.Response[tokensv1.SaveTokenResponse], error) {
 	//validate auth token
@@ -22,9 +26,7 @@ func (h *handlerImpl) SaveToken(ctx context.Context, req *connect.Request[tokens
 		return nil, err
 	}
 
-	roomRepository := tokensrepository.NewSQLTokensRepository(database.DB())
-
-	err = roomRepository.SaveToken(ctx, userID, req.Msg)
+	err = h.tokensRepository.SaveToken(ctx, userID, req.Msg)
 	if err != nil {
 		return nil, err
 	}
@@ -1,13 +1,26 @@
 package tokensv1handler
 
 import (
+	"os"
+
 	"connectrpc.com/vanguard"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/database"
 	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/tokens/v1/tokensv1connect"
+	tokensrepository "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/repository/tokens"
 	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/server"
 )
 
 var options = server.ServiceHandlerOptions()
 
 func RegisterServiceHandler() *vanguard.Service {
-	return vanguard.NewService(tokensv1connect.NewTokensServiceHandler(handler, options...))
+	return vanguard.NewService(tokensv1connect.NewTokensServiceHandler(NewHandler(newTokensRepository()), options...))
+}
+
+// newTokensRepository usa el repositorio en memoria con CHAT_STORE_MODE=memory, igual que
+// el servicio de chat; en cualquier otro modo los tokens viven en Postgres.
+func newTokensRepository() tokensrepository.TokensRepository {
+	if os.Getenv("CHAT_STORE_MODE") == "memory" {
+		return tokensrepository.NewMemoryTokensRepository()
+	}
+	return tokensrepository.NewSQLTokensRepository(database.DB())
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/catalogs"
 	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/handlers"
 	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
 	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/config"
 	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/server"
 )
@@ -22,6 +23,11 @@ var (
 func main() {
 	server.InitEnvironment()
 
+	// Sin clave maestra no se pueden crear ni leer salas: mejor no arrancar
+	if _, err := utils.CurrentMasterKeyVersion(); err != nil {
+		log.Fatalf("No hay clave maestra actual: %v", err)
+	}
+
 	server.InitRedis()
 	server.InitNats()
 
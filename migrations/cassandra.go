@@ -0,0 +1,110 @@
+package migrations
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/scylladb-solutions/gocql/v2"
+)
+
+type cassandraDriver struct {
+	session *gocql.Session
+}
+
+// NewCassandraMigrator usa las migraciones embebidas en migrations/cassandra. Las
+// sentencias se ejecutan en el keyspace de la sesión (los USE de los ficheros se ignoran),
+// así que el keyspace debe existir antes de conectar.
+func NewCassandraMigrator(session *gocql.Session) (*Migrator, error) {
+	return newMigrator(StoreCassandra, ".cql", &cassandraDriver{session: session})
+}
+
+func (d *cassandraDriver) ensureVersionTable(ctx context.Context) error {
+	return d.session.Query(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version int PRIMARY KEY,
+		name text,
+		checksum text,
+		applied_at timestamp
+	)`).WithContext(ctx).Exec()
+}
+
+func (d *cassandraDriver) applied(ctx context.Context) ([]AppliedMigration, error) {
+	iter := d.session.Query(`SELECT version, name, checksum, applied_at FROM schema_migrations`).WithContext(ctx).Iter()
+
+	var applied []AppliedMigration
+	var a AppliedMigration
+	for iter.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt) {
+		applied = append(applied, a)
+	}
+	return applied, iter.Close()
+}
+
+// up ejecuta las sentencias una a una: CQL no tiene transacciones de DDL, así que una
+// migración fallida puede quedar a medias y los ficheros deben ser idempotentes.
+func (d *cassandraDriver) up(ctx context.Context, m Migration) (bool, error) {
+	if err := d.exec(ctx, m.Up); err != nil {
+		return false, err
+	}
+
+	// LWT: si dos instancias migran a la vez, solo una registra la versión
+	applied, err := d.session.Query(
+		`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?) IF NOT EXISTS`,
+		m.Version, m.Name, m.Checksum, time.Now(),
+	).WithContext(ctx).MapScanCAS(map[string]interface{}{})
+	return applied, err
+}
+
+func (d *cassandraDriver) down(ctx context.Context, m Migration) error {
+	if err := d.exec(ctx, m.Down); err != nil {
+		return err
+	}
+	return d.session.Query(`DELETE FROM schema_migrations WHERE version = ?`, m.Version).WithContext(ctx).Exec()
+}
+
+func (d *cassandraDriver) exec(ctx context.Context, script string) error {
+	for _, stmt := range splitCQL(script) {
+		if err := d.session.Query(stmt).WithContext(ctx).Exec(); err != nil {
+			if alreadyApplied(stmt, err) {
+				continue
+			}
+			return err
+		}
+		if err := d.session.AwaitSchemaAgreement(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitCQL separa un fichero en sentencias, descartando comentarios de línea y USE.
+func splitCQL(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	var statements []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" || strings.HasPrefix(strings.ToUpper(stmt), "USE ") {
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+	return statements
+}
+
+// alreadyApplied tolera los ALTER TABLE que CQL no permite escribir de forma idempotente:
+// añadir una columna que ya existe o quitar una que ya no está. Pasa al registrar un
+// keyspace cuyos ficheros se aplicaron a mano con cqlsh.
+func alreadyApplied(stmt string, err error) bool {
+	if !strings.HasPrefix(strings.ToUpper(stmt), "ALTER TABLE") {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "conflicts with an existing column") ||
+		strings.Contains(msg, "already exists") ||
+		strings.Contains(msg, "was not found in table")
+}
@@ -0,0 +1,287 @@
+// Package migrations embebe los esquemas de Postgres y Cassandra y los aplica en orden.
+// Cada store guarda en schema_migrations la versión, el nombre y el checksum (sha256) de
+// los ficheros aplicados, así que un fichero modificado después de aplicarse se detecta.
+//
+// Los ficheros se llaman NNNN_nombre.sql|.cql y su reversión NNNN_nombre.down.sql|.down.cql.
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed postgres/*.sql cassandra/*.cql
+var files embed.FS
+
+const (
+	StorePostgres  = "postgres"
+	StoreCassandra = "cassandra"
+)
+
+var (
+	// ErrSchemaOutdated indica que al store le faltan migraciones que el código necesita.
+	ErrSchemaOutdated = errors.New("schema outdated")
+	// ErrChecksumMismatch indica que un fichero ya aplicado cambió desde que se aplicó.
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string // Vacío si no hay fichero .down
+	Checksum string // sha256 del fichero up
+}
+
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Estados de MigrationStatus.
+const (
+	StateApplied  = "applied"
+	StatePending  = "pending"
+	StateModified = "modified" // Aplicada, pero el fichero embebido ya no coincide
+	StateUnknown  = "unknown"  // Aplicada por una versión más nueva del código
+)
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	State     string
+	AppliedAt time.Time
+}
+
+// driver ejecuta las migraciones contra un store y mantiene su tabla de versiones.
+type driver interface {
+	ensureVersionTable(ctx context.Context) error
+	applied(ctx context.Context) ([]AppliedMigration, error)
+	// up aplica la migración y la registra; devuelve false si otra instancia ya lo hizo.
+	up(ctx context.Context, m Migration) (bool, error)
+	down(ctx context.Context, m Migration) error
+}
+
+type Migrator struct {
+	store      string
+	driver     driver
+	migrations []Migration
+}
+
+func newMigrator(store string, ext string, d driver) (*Migrator, error) {
+	migrations, err := load(files, store, ext)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{store: store, driver: d, migrations: migrations}, nil
+}
+
+// load lee las migraciones de dir ordenadas por versión.
+func load(fsys fs.FS, dir string, ext string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ext) {
+			continue
+		}
+		base, isDown := strings.CutSuffix(strings.TrimSuffix(fileName, ext), ".down")
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %s/%s", dir, fileName)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("duplicate migration version %d in %s (%s, %s)", version, dir, m.Name, name)
+		}
+		if isDown {
+			m.Down = string(content)
+			continue
+		}
+		sum := sha256.Sum256(content)
+		m.Up = string(content)
+		m.Checksum = hex.EncodeToString(sum[:])
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %s/%04d_%s has a down file but no up file", dir, m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func (m *Migrator) Store() string {
+	return m.store
+}
+
+// Latest es la versión de esquema que espera el código.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.driver.ensureVersionTable(ctx); err != nil {
+		return nil, fmt.Errorf("%s: failed to create schema_migrations: %w", m.store, err)
+	}
+	applied, err := m.driver.applied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read schema_migrations: %w", m.store, err)
+	}
+
+	appliedByVersion := make(map[int]AppliedMigration)
+	for _, a := range applied {
+		appliedByVersion[a.Version] = a
+	}
+
+	var status []MigrationStatus
+	for _, migration := range m.migrations {
+		s := MigrationStatus{Version: migration.Version, Name: migration.Name, State: StatePending}
+		if a, ok := appliedByVersion[migration.Version]; ok {
+			s.AppliedAt = a.AppliedAt
+			s.State = StateApplied
+			if a.Checksum != migration.Checksum {
+				s.State = StateModified
+			}
+			delete(appliedByVersion, migration.Version)
+		}
+		status = append(status, s)
+	}
+	for _, a := range appliedByVersion {
+		status = append(status, MigrationStatus{Version: a.Version, Name: a.Name, State: StateUnknown, AppliedAt: a.AppliedAt})
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+
+	return status, nil
+}
+
+// verify falla si algún fichero aplicado cambió y devuelve las migraciones pendientes.
+func (m *Migrator) verify(ctx context.Context) ([]Migration, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var modified []string
+	pending := make(map[int]bool)
+	for _, s := range status {
+		switch s.State {
+		case StateModified:
+			modified = append(modified, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		case StatePending:
+			pending[s.Version] = true
+		}
+	}
+	if len(modified) > 0 {
+		return nil, fmt.Errorf("%w in %s: %s", ErrChecksumMismatch, m.store, strings.Join(modified, ", "))
+	}
+
+	var migrations []Migration
+	for _, migration := range m.migrations {
+		if pending[migration.Version] {
+			migrations = append(migrations, migration)
+		}
+	}
+	return migrations, nil
+}
+
+// Up aplica en orden todas las migraciones pendientes y devuelve las aplicadas.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := m.verify(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		applied, err := m.driver.up(ctx, migration)
+		if err != nil {
+			return done, fmt.Errorf("%s: migration %04d_%s failed: %w", m.store, migration.Version, migration.Name, err)
+		}
+		if applied {
+			done = append(done, migration)
+		}
+	}
+	return done, nil
+}
+
+// Down revierte las últimas steps migraciones aplicadas, de la más nueva a la más vieja.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if _, err := m.verify(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.driver.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(applied, func(a, b AppliedMigration) int { return b.Version - a.Version })
+
+	var done []Migration
+	for _, a := range applied[:min(steps, len(applied))] {
+		i := slices.IndexFunc(m.migrations, func(migration Migration) bool { return migration.Version == a.Version })
+		if i < 0 {
+			return done, fmt.Errorf("%s: migration %04d_%s is not known by this binary", m.store, a.Version, a.Name)
+		}
+		migration := m.migrations[i]
+		if migration.Down == "" {
+			return done, fmt.Errorf("%s: migration %04d_%s has no down file", m.store, migration.Version, migration.Name)
+		}
+		if err := m.driver.down(ctx, migration); err != nil {
+			return done, fmt.Errorf("%s: reverting %04d_%s failed: %w", m.store, migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Check falla si el store no tiene aplicadas todas las migraciones que el código espera o
+// si alguna aplicada no coincide con su fichero. Versiones más nuevas que el código se
+// aceptan, para poder desplegar el esquema antes que la aplicación.
+func (m *Migrator) Check(ctx context.Context) error {
+	pending, err := m.verify(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(pending))
+	for i, migration := range pending {
+		names[i] = fmt.Sprintf("%04d_%s", migration.Version, migration.Name)
+	}
+	return fmt.Errorf("%w: %s is missing %s (run the migrate command or set CHAT_AUTO_MIGRATE=true)", ErrSchemaOutdated, m.store, strings.Join(names, ", "))
+}
@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+// Los ficheros embebidos deben tener versiones consecutivas y su reversión.
+func TestEmbeddedMigrations(t *testing.T) {
+	for store, ext := range map[string]string{StorePostgres: ".sql", StoreCassandra: ".cql"} {
+		migrations, err := load(files, store, ext)
+		if err != nil {
+			t.Fatalf("%s: %v", store, err)
+		}
+		if len(migrations) == 0 {
+			t.Fatalf("%s: no hay migraciones embebidas", store)
+		}
+		for i, m := range migrations {
+			if m.Version != i+1 {
+				t.Errorf("%s: versión %d en la posición %d, se esperaban versiones consecutivas", store, m.Version, i)
+			}
+			if m.Down == "" {
+				t.Errorf("%s: %04d_%s no tiene fichero .down", store, m.Version, m.Name)
+			}
+			if m.Checksum == "" {
+				t.Errorf("%s: %04d_%s sin checksum", store, m.Version, m.Name)
+			}
+		}
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"nombre sin versión": {"pg/init.sql": {Data: []byte("SELECT 1;")}},
+		"versión duplicada": {
+			"pg/0001_a.sql": {Data: []byte("SELECT 1;")},
+			"pg/0001_b.sql": {Data: []byte("SELECT 2;")},
+		},
+		"down sin up": {"pg/0001_a.down.sql": {Data: []byte("SELECT 1;")}},
+	}
+	for name, fsys := range cases {
+		if _, err := load(fsys, "pg", ".sql"); err == nil {
+			t.Errorf("%s: se esperaba error", name)
+		}
+	}
+}
+
+func TestChecksumChangesWithContent(t *testing.T) {
+	before, err := load(fstest.MapFS{"pg/0001_a.sql": {Data: []byte("SELECT 1;")}}, "pg", ".sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := load(fstest.MapFS{"pg/0001_a.sql": {Data: []byte("SELECT 2;")}}, "pg", ".sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before[0].Checksum == after[0].Checksum {
+		t.Fatal("el checksum no cambió al modificar el fichero")
+	}
+}
+
+func TestSplitCQL(t *testing.T) {
+	script := `-- comentario; con punto y coma
+USE chat_keyspace;
+
+CREATE TABLE IF NOT EXISTS a (
+    id int PRIMARY KEY -- comentario en línea
+);
+ALTER TABLE a ADD b text;
+`
+	want := []string{
+		"CREATE TABLE IF NOT EXISTS a (\n    id int PRIMARY KEY -- comentario en línea\n)",
+		"ALTER TABLE a ADD b text",
+	}
+	if got := splitCQL(script); !slices.Equal(got, want) {
+		t.Fatalf("splitCQL = %q, se esperaba %q", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// postgresLockID serializa las migraciones entre instancias que arrancan a la vez.
+const postgresLockID = 7_202_604_035
+
+type postgresDriver struct {
+	db *sql.DB
+}
+
+// NewPostgresMigrator usa las migraciones embebidas en migrations/postgres.
+func NewPostgresMigrator(db *sql.DB) (*Migrator, error) {
+	return newMigrator(StorePostgres, ".sql", &postgresDriver{db: db})
+}
+
+func (d *postgresDriver) ensureVersionTable(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS public.schema_migrations (
+		version    INT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		checksum   TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`)
+	return err
+}
+
+func (d *postgresDriver) applied(ctx context.Context) ([]AppliedMigration, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM public.schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// up ejecuta el fichero y lo registra en la misma transacción, así que una migración
+// fallida no queda a medias.
+func (d *postgresDriver) up(ctx context.Context, m Migration) (bool, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, postgresLockID); err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM public.schema_migrations WHERE version = $1)`, m.Version).Scan(&exists); err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO public.schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`,
+		m.Version, m.Name, m.Checksum, time.Now()); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (d *postgresDriver) down(ctx context.Context, m Migration) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, postgresLockID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, m.Down); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM public.schema_migrations WHERE version = $1`, m.Version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
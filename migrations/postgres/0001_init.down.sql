@@ -0,0 +1,9 @@
+-- Reverts 0001_init (PostgreSQL)
+-- public."user" and the extensions are shared with other services and are kept.
+DROP TABLE IF EXISTS public.messaging_token;
+DROP TABLE IF EXISTS public.room_message_reaction;
+DROP TABLE IF EXISTS public.room_message_tag;
+DROP TABLE IF EXISTS public.room_message_meta;
+DROP TABLE IF EXISTS public.room_message;
+DROP TABLE IF EXISTS public.room_member;
+DROP TABLE IF EXISTS public.room;
@@ -0,0 +1,2 @@
+-- Reverts 0002_chat_outbox (PostgreSQL)
+DROP TABLE IF EXISTS public.chat_outbox;
@@ -0,0 +1,19 @@
+-- Transactional outbox for chat events (PostgreSQL)
+-- Rows are written in the same transaction as the mutation and published by the relay.
+CREATE TABLE IF NOT EXISTS public.chat_outbox (
+    seq              BIGSERIAL PRIMARY KEY,
+    id               UUID NOT NULL UNIQUE,
+    room_id          UUID NOT NULL,
+    user_id          INT NOT NULL,
+    kind             TEXT NOT NULL,  -- message_created | message_updated | message_deleted | room_leave
+    message_id       UUID,
+    payload          BYTEA,          -- services.chat.v1.MessageEvent (protobuf), null when hydrated on publish
+    attempts         INT NOT NULL DEFAULT 0,
+    last_error       TEXT,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at          TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_outbox_pending ON public.chat_outbox(next_attempt_at, seq) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_chat_outbox_sent ON public.chat_outbox(sent_at) WHERE sent_at IS NOT NULL;
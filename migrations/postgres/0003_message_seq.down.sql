@@ -0,0 +1,4 @@
+-- Reverts 0003_message_seq (PostgreSQL)
+DROP INDEX IF EXISTS public.uq_room_message_room_seq;
+ALTER TABLE public.room_message DROP COLUMN IF EXISTS seq;
+ALTER TABLE public.room DROP COLUMN IF EXISTS last_seq;
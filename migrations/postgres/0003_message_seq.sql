@@ -0,0 +1,20 @@
+-- Per-room, gap-free message sequence numbers (PostgreSQL)
+-- room.last_seq is incremented inside the SaveMessage transaction; the row lock serializes
+-- concurrent senders of the same room and a rollback releases the number.
+ALTER TABLE public.room ADD COLUMN IF NOT EXISTS last_seq BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE public.room_message ADD COLUMN IF NOT EXISTS seq BIGINT;
+
+-- Backfill existing messages in creation order
+WITH numbered AS (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY room_id ORDER BY created_at, id) AS seq
+    FROM public.room_message
+)
+UPDATE public.room_message AS msg
+SET seq = numbered.seq
+FROM numbered
+WHERE msg.id = numbered.id AND msg.seq IS NULL;
+
+UPDATE public.room AS room
+SET last_seq = COALESCE((SELECT MAX(seq) FROM public.room_message WHERE room_id = room.id), 0);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_room_message_room_seq ON public.room_message(room_id, seq);
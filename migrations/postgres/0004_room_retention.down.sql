@@ -0,0 +1,3 @@
+-- Reverts 0004_room_retention (PostgreSQL)
+ALTER TABLE public.room DROP COLUMN IF EXISTS history_purged_before;
+ALTER TABLE public.room DROP COLUMN IF EXISTS retention_days;
@@ -0,0 +1,5 @@
+-- Per-room message retention (PostgreSQL)
+-- retention_days NULL follows the global chat.retentionDays policy, 0 keeps history forever.
+-- history_purged_before is the watermark of the retention job: older messages are tombstones.
+ALTER TABLE public.room ADD COLUMN IF NOT EXISTS retention_days INT;
+ALTER TABLE public.room ADD COLUMN IF NOT EXISTS history_purged_before TIMESTAMPTZ;
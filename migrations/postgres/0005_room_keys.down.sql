@@ -0,0 +1,4 @@
+-- Reverts 0005_room_keys (PostgreSQL)
+DROP TABLE IF EXISTS public.room_key;
+ALTER TABLE public.room_message DROP COLUMN IF EXISTS key_version;
+ALTER TABLE public.room DROP COLUMN IF EXISTS key_version;
@@ -0,0 +1,14 @@
+-- Room key versioning and rotation (PostgreSQL)
+-- room.encription_data keeps the current key and room.key_version its number. Retired keys move
+-- to room_key so older messages stay readable. room_message.key_version is the key the client
+-- used for the content; NULL (messages from before rotation) means version 1.
+ALTER TABLE public.room ADD COLUMN IF NOT EXISTS key_version INT NOT NULL DEFAULT 1;
+ALTER TABLE public.room_message ADD COLUMN IF NOT EXISTS key_version INT;
+
+CREATE TABLE IF NOT EXISTS public.room_key (
+    room_id          UUID NOT NULL REFERENCES public.room(id) ON DELETE CASCADE,
+    version          INT NOT NULL,
+    encryption_data  TEXT NOT NULL,
+    retired_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (room_id, version)
+);
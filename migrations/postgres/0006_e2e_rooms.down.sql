@@ -0,0 +1,4 @@
+-- Reverts 0006_e2e_rooms (PostgreSQL)
+DROP TABLE IF EXISTS public.room_device_key;
+DROP TABLE IF EXISTS public.user_device_key;
+ALTER TABLE public.room DROP COLUMN IF EXISTS e2e;
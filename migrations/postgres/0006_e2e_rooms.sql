@@ -0,0 +1,27 @@
+-- Opt-in end-to-end encrypted rooms (PostgreSQL)
+-- An e2e room has no server-side key: room.encription_data stays NULL and room.key_version only
+-- numbers the keys the clients generate. Each device registers its public key in
+-- user_device_key, and members distribute every room key version wrapped for each device in
+-- room_device_key. The server stores and relays ciphertext only.
+ALTER TABLE public.room ADD COLUMN IF NOT EXISTS e2e BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS public.user_device_key (
+    user_id     INT NOT NULL,
+    device_id   TEXT NOT NULL,
+    public_key  TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, device_id)
+);
+
+CREATE TABLE IF NOT EXISTS public.room_device_key (
+    room_id           UUID NOT NULL REFERENCES public.room(id) ON DELETE CASCADE,
+    user_id           INT NOT NULL,
+    device_id         TEXT NOT NULL,
+    version           INT NOT NULL,
+    wrapped_key       TEXT NOT NULL,
+    sender_user_id    INT NOT NULL,
+    sender_device_id  TEXT NOT NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (room_id, user_id, device_id, version)
+);
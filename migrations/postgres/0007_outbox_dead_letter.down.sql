@@ -0,0 +1,2 @@
+-- Reverts 0007_outbox_dead_letter (PostgreSQL)
+DROP TABLE IF EXISTS public.chat_outbox_dead_letter;
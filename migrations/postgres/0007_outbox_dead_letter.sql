@@ -0,0 +1,15 @@
+-- Dead letters for the chat outbox (PostgreSQL)
+-- Rows whose payload can never be decoded are moved here by the relay instead of being claimed
+-- again on every poll.
+CREATE TABLE IF NOT EXISTS public.chat_outbox_dead_letter (
+    id          UUID PRIMARY KEY,
+    room_id     UUID NOT NULL,
+    user_id     INT NOT NULL,
+    kind        TEXT NOT NULL,
+    message_id  UUID,
+    payload     BYTEA,
+    attempts    INT NOT NULL,
+    error       TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    dead_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- Reverts 0008_outbox_ordering (PostgreSQL)
+DROP INDEX IF EXISTS public.idx_chat_outbox_room_pending;
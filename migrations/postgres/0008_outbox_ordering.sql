@@ -0,0 +1,4 @@
+-- Per-room ordering for the chat outbox (PostgreSQL)
+-- The relay only claims an event when no older unsent event of the same room is waiting for a
+-- retry or held by another relay, so it looks up pending events by room.
+CREATE INDEX IF NOT EXISTS idx_chat_outbox_room_pending ON public.chat_outbox(room_id, seq) WHERE sent_at IS NULL;
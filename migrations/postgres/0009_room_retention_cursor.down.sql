@@ -0,0 +1,2 @@
+-- Reverts 0009_room_retention_cursor (PostgreSQL)
+ALTER TABLE public.room DROP COLUMN IF EXISTS history_purged_after_id;
@@ -0,0 +1,7 @@
+-- Keyset cursor for the retention job (PostgreSQL)
+-- A full batch only moves history_purged_before up to the created_at of its last message; when
+-- a batch worth of messages shares that created_at the next pass would select the same rows
+-- forever. history_purged_after_id is the id of the last purged message, and the next pass
+-- continues after (history_purged_before, history_purged_after_id). NULL once the purge caught
+-- up with the cutoff.
+ALTER TABLE public.room ADD COLUMN IF NOT EXISTS history_purged_after_id UUID;
@@ -90,6 +90,55 @@ const (
 	// ChatServiceStreamMessagesProcedure is the fully-qualified name of the ChatService's
 	// StreamMessages RPC.
 	ChatServiceStreamMessagesProcedure = "/services.chat.v1.ChatService/StreamMessages"
+	// ChatServiceExportRoomHistoryProcedure is the fully-qualified name of the ChatService's
+	// ExportRoomHistory RPC.
+	ChatServiceExportRoomHistoryProcedure = "/services.chat.v1.ChatService/ExportRoomHistory"
+	// ChatServiceGetRoomHistoryExportProcedure is the fully-qualified name of the ChatService's
+	// GetRoomHistoryExport RPC.
+	ChatServiceGetRoomHistoryExportProcedure = "/services.chat.v1.ChatService/GetRoomHistoryExport"
+	// ChatServiceDownloadRoomHistoryExportProcedure is the fully-qualified name of the ChatService's
+	// DownloadRoomHistoryExport RPC.
+	ChatServiceDownloadRoomHistoryExportProcedure = "/services.chat.v1.ChatService/DownloadRoomHistoryExport"
+	// ChatServiceExportUserDataProcedure is the fully-qualified name of the ChatService's
+	// ExportUserData RPC.
+	ChatServiceExportUserDataProcedure = "/services.chat.v1.ChatService/ExportUserData"
+	// ChatServiceGetUserDataExportProcedure is the fully-qualified name of the ChatService's
+	// GetUserDataExport RPC.
+	ChatServiceGetUserDataExportProcedure = "/services.chat.v1.ChatService/GetUserDataExport"
+	// ChatServiceDownloadUserDataExportProcedure is the fully-qualified name of the ChatService's
+	// DownloadUserDataExport RPC.
+	ChatServiceDownloadUserDataExportProcedure = "/services.chat.v1.ChatService/DownloadUserDataExport"
+	// ChatServiceEraseUserDataProcedure is the fully-qualified name of the ChatService's EraseUserData
+	// RPC.
+	ChatServiceEraseUserDataProcedure = "/services.chat.v1.ChatService/EraseUserData"
+	// ChatServiceRotateRoomKeyProcedure is the fully-qualified name of the ChatService's RotateRoomKey
+	// RPC.
+	ChatServiceRotateRoomKeyProcedure = "/services.chat.v1.ChatService/RotateRoomKey"
+	// ChatServiceGetRoomKeysProcedure is the fully-qualified name of the ChatService's GetRoomKeys RPC.
+	ChatServiceGetRoomKeysProcedure = "/services.chat.v1.ChatService/GetRoomKeys"
+	// ChatServiceRegisterDeviceKeyProcedure is the fully-qualified name of the ChatService's
+	// RegisterDeviceKey RPC.
+	ChatServiceRegisterDeviceKeyProcedure = "/services.chat.v1.ChatService/RegisterDeviceKey"
+	// ChatServiceGetDeviceKeysProcedure is the fully-qualified name of the ChatService's GetDeviceKeys
+	// RPC.
+	ChatServiceGetDeviceKeysProcedure = "/services.chat.v1.ChatService/GetDeviceKeys"
+	// ChatServiceShareRoomKeyProcedure is the fully-qualified name of the ChatService's ShareRoomKey
+	// RPC.
+	ChatServiceShareRoomKeyProcedure = "/services.chat.v1.ChatService/ShareRoomKey"
+	// ChatServiceListRoomCacheKeysProcedure is the fully-qualified name of the ChatService's
+	// ListRoomCacheKeys RPC.
+	ChatServiceListRoomCacheKeysProcedure = "/services.chat.v1.ChatService/ListRoomCacheKeys"
+	// ChatServiceGetCacheEntryProcedure is the fully-qualified name of the ChatService's GetCacheEntry
+	// RPC.
+	ChatServiceGetCacheEntryProcedure = "/services.chat.v1.ChatService/GetCacheEntry"
+	// ChatServiceFlushCacheProcedure is the fully-qualified name of the ChatService's FlushCache RPC.
+	ChatServiceFlushCacheProcedure = "/services.chat.v1.ChatService/FlushCache"
+	// ChatServiceGetCacheStatsProcedure is the fully-qualified name of the ChatService's GetCacheStats
+	// RPC.
+	ChatServiceGetCacheStatsProcedure = "/services.chat.v1.ChatService/GetCacheStats"
+	// ChatServiceUpdateStreamSubscriptionProcedure is the fully-qualified name of the ChatService's
+	// UpdateStreamSubscription RPC.
+	ChatServiceUpdateStreamSubscriptionProcedure = "/services.chat.v1.ChatService/UpdateStreamSubscription"
 )
 
 // ChatServiceClient is a client for the services.chat.v1.ChatService service.
@@ -163,6 +212,70 @@ type ChatServiceClient interface {
 	// Stream unidireccional para mensajes en tiempo real
 	// 🔒 Need private token to access this endpoint
 	StreamMessages(context.Context, *connect.Request[v1.StreamMessagesRequest]) (*connect.ServerStreamForClient[v1.MessageEvent], error)
+	// Exportar el historial de un room (solo owners y admins). La exportación corre en segundo
+	// plano; el progreso se consulta con GetRoomHistoryExport y el archivo se descarga con
+	// DownloadRoomHistoryExport
+	// 🔒 Need private token to access this endpoint
+	ExportRoomHistory(context.Context, *connect.Request[v1.ExportRoomHistoryRequest]) (*connect.Response[v1.ExportRoomHistoryResponse], error)
+	// Estado de una exportación de historial
+	// 🔒 Need private token to access this endpoint
+	GetRoomHistoryExport(context.Context, *connect.Request[v1.GetRoomHistoryExportRequest]) (*connect.Response[v1.GetRoomHistoryExportResponse], error)
+	// Descargar en trozos el archivo de una exportación de historial terminada (solo quien la
+	// pidió)
+	// 🔒 Need private token to access this endpoint
+	DownloadRoomHistoryExport(context.Context, *connect.Request[v1.DownloadRoomHistoryExportRequest]) (*connect.ServerStreamForClient[v1.DownloadRoomHistoryExportResponse], error)
+	// Exportar los datos de chat del usuario autenticado (portabilidad): salas, mensajes
+	// enviados, reacciones, lecturas, ajustes de cada sala y tokens de notificaciones. La
+	// exportación corre en segundo plano; el progreso se consulta con GetUserDataExport
+	// 🔒 Need private token to access this endpoint
+	ExportUserData(context.Context, *connect.Request[v1.ExportUserDataRequest]) (*connect.Response[v1.ExportUserDataResponse], error)
+	// Estado de una exportación de datos de usuario; cuando termina incluye un handle de
+	// descarga que caduca
+	// 🔒 Need private token to access this endpoint
+	GetUserDataExport(context.Context, *connect.Request[v1.GetUserDataExportRequest]) (*connect.Response[v1.GetUserDataExportResponse], error)
+	// Descargar el archivo de una exportación de datos de usuario con su handle
+	// 🔒 Need private token to access this endpoint
+	DownloadUserDataExport(context.Context, *connect.Request[v1.DownloadUserDataExportRequest]) (*connect.Response[v1.DownloadUserDataExportResponse], error)
+	// Borrar los datos de chat de un usuario que eliminó su cuenta (derecho al olvido). Uso
+	// interno entre servicios; también se dispara con el evento de usuario eliminado en NATS
+	// 🔓 Need public token to access this endpoint
+	EraseUserData(context.Context, *connect.Request[v1.EraseUserDataRequest]) (*connect.Response[v1.EraseUserDataResponse], error)
+	// Rotar la clave de cifrado de la sala (owners y admins en grupos). Los mensajes
+	// anteriores se siguen leyendo con las claves de GetRoomKeys
+	// 🔒 Need private token to access this endpoint
+	RotateRoomKey(context.Context, *connect.Request[v1.RotateRoomKeyRequest]) (*connect.Response[v1.RotateRoomKeyResponse], error)
+	// Todas las versiones de la clave de la sala, para leer el historial. En salas e2e, las
+	// envueltas para el dispositivo indicado
+	// 🔒 Need private token to access this endpoint
+	GetRoomKeys(context.Context, *connect.Request[v1.GetRoomKeysRequest]) (*connect.Response[v1.GetRoomKeysResponse], error)
+	// Registrar (o reemplazar) la clave pública de un dispositivo del usuario, para recibir
+	// las claves de las salas e2e
+	// 🔒 Need private token to access this endpoint
+	RegisterDeviceKey(context.Context, *connect.Request[v1.RegisterDeviceKeyRequest]) (*connect.Response[v1.RegisterDeviceKeyResponse], error)
+	// Directorio de claves públicas de los dispositivos de los usuarios o de los participantes
+	// de una sala
+	// 🔒 Need private token to access this endpoint
+	GetDeviceKeys(context.Context, *connect.Request[v1.GetDeviceKeysRequest]) (*connect.Response[v1.GetDeviceKeysResponse], error)
+	// Entregar una versión de la clave de una sala e2e envuelta para otros dispositivos
+	// (participantes o dispositivos nuevos)
+	// 🔒 Need private token to access this endpoint
+	ShareRoomKey(context.Context, *connect.Request[v1.ShareRoomKeyRequest]) (*connect.Response[v1.ShareRoomKeyResponse], error)
+	// Claves cacheadas de una sala (set de miembros) y si siguen en Redis y en el LRU local.
+	// Uso interno de operación
+	// 🔓 Need public token to access this endpoint
+	ListRoomCacheKeys(context.Context, *connect.Request[v1.ListRoomCacheKeysRequest]) (*connect.Response[v1.ListRoomCacheKeysResponse], error)
+	// Valor cacheado de una clave de sala o mensaje, en el LRU local y en Redis
+	// 🔓 Need public token to access this endpoint
+	GetCacheEntry(context.Context, *connect.Request[v1.GetCacheEntryRequest]) (*connect.Response[v1.GetCacheEntryResponse], error)
+	// Invalidar la caché de una sala o la de las salas de un usuario en todas las réplicas
+	// 🔓 Need public token to access this endpoint
+	FlushCache(context.Context, *connect.Request[v1.FlushCacheRequest]) (*connect.Response[v1.FlushCacheResponse], error)
+	// Aciertos y fallos de la caché de salas y mensajes de la réplica que responde
+	// 🔓 Need public token to access this endpoint
+	GetCacheStats(context.Context, *connect.Request[v1.GetCacheStatsRequest]) (*connect.Response[v1.GetCacheStatsResponse], error)
+	// Actualizar el filtro (salas y tipos de eventos) de un stream activo
+	// 🔒 Need private token to access this endpoint
+	UpdateStreamSubscription(context.Context, *connect.Request[v1.UpdateStreamSubscriptionRequest]) (*connect.Response[v1.UpdateStreamSubscriptionResponse], error)
 }
 
 // NewChatServiceClient constructs a client for the services.chat.v1.ChatService service. By
@@ -315,34 +428,154 @@ func NewChatServiceClient(httpClient connect.HTTPClient, baseURL string, opts ..
 			connect.WithIdempotency(connect.IdempotencyIdempotent),
 			connect.WithClientOptions(opts...),
 		),
+		exportRoomHistory: connect.NewClient[v1.ExportRoomHistoryRequest, v1.ExportRoomHistoryResponse](
+			httpClient,
+			baseURL+ChatServiceExportRoomHistoryProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("ExportRoomHistory")),
+			connect.WithClientOptions(opts...),
+		),
+		getRoomHistoryExport: connect.NewClient[v1.GetRoomHistoryExportRequest, v1.GetRoomHistoryExportResponse](
+			httpClient,
+			baseURL+ChatServiceGetRoomHistoryExportProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("GetRoomHistoryExport")),
+			connect.WithClientOptions(opts...),
+		),
+		downloadRoomHistoryExport: connect.NewClient[v1.DownloadRoomHistoryExportRequest, v1.DownloadRoomHistoryExportResponse](
+			httpClient,
+			baseURL+ChatServiceDownloadRoomHistoryExportProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("DownloadRoomHistoryExport")),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		exportUserData: connect.NewClient[v1.ExportUserDataRequest, v1.ExportUserDataResponse](
+			httpClient,
+			baseURL+ChatServiceExportUserDataProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("ExportUserData")),
+			connect.WithClientOptions(opts...),
+		),
+		getUserDataExport: connect.NewClient[v1.GetUserDataExportRequest, v1.GetUserDataExportResponse](
+			httpClient,
+			baseURL+ChatServiceGetUserDataExportProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("GetUserDataExport")),
+			connect.WithClientOptions(opts...),
+		),
+		downloadUserDataExport: connect.NewClient[v1.DownloadUserDataExportRequest, v1.DownloadUserDataExportResponse](
+			httpClient,
+			baseURL+ChatServiceDownloadUserDataExportProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("DownloadUserDataExport")),
+			connect.WithClientOptions(opts...),
+		),
+		eraseUserData: connect.NewClient[v1.EraseUserDataRequest, v1.EraseUserDataResponse](
+			httpClient,
+			baseURL+ChatServiceEraseUserDataProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("EraseUserData")),
+			connect.WithClientOptions(opts...),
+		),
+		rotateRoomKey: connect.NewClient[v1.RotateRoomKeyRequest, v1.RotateRoomKeyResponse](
+			httpClient,
+			baseURL+ChatServiceRotateRoomKeyProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("RotateRoomKey")),
+			connect.WithClientOptions(opts...),
+		),
+		getRoomKeys: connect.NewClient[v1.GetRoomKeysRequest, v1.GetRoomKeysResponse](
+			httpClient,
+			baseURL+ChatServiceGetRoomKeysProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("GetRoomKeys")),
+			connect.WithClientOptions(opts...),
+		),
+		registerDeviceKey: connect.NewClient[v1.RegisterDeviceKeyRequest, v1.RegisterDeviceKeyResponse](
+			httpClient,
+			baseURL+ChatServiceRegisterDeviceKeyProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("RegisterDeviceKey")),
+			connect.WithClientOptions(opts...),
+		),
+		getDeviceKeys: connect.NewClient[v1.GetDeviceKeysRequest, v1.GetDeviceKeysResponse](
+			httpClient,
+			baseURL+ChatServiceGetDeviceKeysProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("GetDeviceKeys")),
+			connect.WithClientOptions(opts...),
+		),
+		shareRoomKey: connect.NewClient[v1.ShareRoomKeyRequest, v1.ShareRoomKeyResponse](
+			httpClient,
+			baseURL+ChatServiceShareRoomKeyProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("ShareRoomKey")),
+			connect.WithClientOptions(opts...),
+		),
+		listRoomCacheKeys: connect.NewClient[v1.ListRoomCacheKeysRequest, v1.ListRoomCacheKeysResponse](
+			httpClient,
+			baseURL+ChatServiceListRoomCacheKeysProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("ListRoomCacheKeys")),
+			connect.WithClientOptions(opts...),
+		),
+		getCacheEntry: connect.NewClient[v1.GetCacheEntryRequest, v1.GetCacheEntryResponse](
+			httpClient,
+			baseURL+ChatServiceGetCacheEntryProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("GetCacheEntry")),
+			connect.WithClientOptions(opts...),
+		),
+		flushCache: connect.NewClient[v1.FlushCacheRequest, v1.FlushCacheResponse](
+			httpClient,
+			baseURL+ChatServiceFlushCacheProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("FlushCache")),
+			connect.WithClientOptions(opts...),
+		),
+		getCacheStats: connect.NewClient[v1.GetCacheStatsRequest, v1.GetCacheStatsResponse](
+			httpClient,
+			baseURL+ChatServiceGetCacheStatsProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("GetCacheStats")),
+			connect.WithClientOptions(opts...),
+		),
+		updateStreamSubscription: connect.NewClient[v1.UpdateStreamSubscriptionRequest, v1.UpdateStreamSubscriptionResponse](
+			httpClient,
+			baseURL+ChatServiceUpdateStreamSubscriptionProcedure,
+			connect.WithSchema(chatServiceMethods.ByName("UpdateStreamSubscription")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // chatServiceClient implements ChatServiceClient.
 type chatServiceClient struct {
-	sendMessage           *connect.Client[v1.SendMessageRequest, v1.SendMessageResponse]
-	editMessage           *connect.Client[v1.EditMessageRequest, v1.EditMessageResponse]
-	deleteMessage         *connect.Client[v1.DeleteMessageRequest, v1.DeleteMessageResponse]
-	reactToMessage        *connect.Client[v1.ReactToMessageRequest, v1.ReactToMessageResponse]
-	getRooms              *connect.Client[v1.GetRoomsRequest, v1.GetRoomsResponse]
-	createRoom            *connect.Client[v1.CreateRoomRequest, v1.CreateRoomResponse]
-	getRoom               *connect.Client[v1.GetRoomRequest, v1.GetRoomResponse]
-	getMessageHistory     *connect.Client[v1.GetMessageHistoryRequest, v1.GetMessageHistoryResponse]
-	getRoomParticipants   *connect.Client[v1.GetRoomParticipantsRequest, v1.GetRoomParticipantsResponse]
-	pinRoom               *connect.Client[v1.PinRoomRequest, v1.PinRoomResponse]
-	muteRoom              *connect.Client[v1.MuteRoomRequest, v1.MuteRoomResponse]
-	leaveRoom             *connect.Client[v1.LeaveRoomRequest, v1.LeaveRoomResponse]
-	addParticipantToRoom  *connect.Client[v1.AddParticipantToRoomRequest, v1.AddParticipantToRoomResponse]
-	updateRoom            *connect.Client[v1.UpdateRoomRequest, v1.UpdateRoomResponse]
-	updateParticipantRoom *connect.Client[v1.UpdateParticipantRoomRequest, v1.UpdateParticipantRoomResponse]
-	blockUser             *connect.Client[v1.BlockUserRequest, v1.BlockUserResponse]
-	getSenderMessage      *connect.Client[v1.GetSenderMessageRequest, v1.GetSenderMessageResponse]
-	getMessage            *connect.Client[v1.GetMessageRequest, v1.MessageData]
-	getMessageRead        *connect.Client[v1.GetMessageReadRequest, v1.GetMessageReadResponse]
-	getMessageReactions   *connect.Client[v1.GetMessageReactionsRequest, v1.GetMessageReactionsResponse]
-	markMessagesAsRead    *connect.Client[v1.MarkMessagesAsReadRequest, v1.MarkMessagesAsReadResponse]
-	initialSync           *connect.Client[v1.InitialSyncRequest, v1.InitialSyncResponse]
-	streamMessages        *connect.Client[v1.StreamMessagesRequest, v1.MessageEvent]
+	sendMessage               *connect.Client[v1.SendMessageRequest, v1.SendMessageResponse]
+	editMessage               *connect.Client[v1.EditMessageRequest, v1.EditMessageResponse]
+	deleteMessage             *connect.Client[v1.DeleteMessageRequest, v1.DeleteMessageResponse]
+	reactToMessage            *connect.Client[v1.ReactToMessageRequest, v1.ReactToMessageResponse]
+	getRooms                  *connect.Client[v1.GetRoomsRequest, v1.GetRoomsResponse]
+	createRoom                *connect.Client[v1.CreateRoomRequest, v1.CreateRoomResponse]
+	getRoom                   *connect.Client[v1.GetRoomRequest, v1.GetRoomResponse]
+	getMessageHistory         *connect.Client[v1.GetMessageHistoryRequest, v1.GetMessageHistoryResponse]
+	getRoomParticipants       *connect.Client[v1.GetRoomParticipantsRequest, v1.GetRoomParticipantsResponse]
+	pinRoom                   *connect.Client[v1.PinRoomRequest, v1.PinRoomResponse]
+	muteRoom                  *connect.Client[v1.MuteRoomRequest, v1.MuteRoomResponse]
+	leaveRoom                 *connect.Client[v1.LeaveRoomRequest, v1.LeaveRoomResponse]
+	addParticipantToRoom      *connect.Client[v1.AddParticipantToRoomRequest, v1.AddParticipantToRoomResponse]
+	updateRoom                *connect.Client[v1.UpdateRoomRequest, v1.UpdateRoomResponse]
+	updateParticipantRoom     *connect.Client[v1.UpdateParticipantRoomRequest, v1.UpdateParticipantRoomResponse]
+	blockUser                 *connect.Client[v1.BlockUserRequest, v1.BlockUserResponse]
+	getSenderMessage          *connect.Client[v1.GetSenderMessageRequest, v1.GetSenderMessageResponse]
+	getMessage                *connect.Client[v1.GetMessageRequest, v1.MessageData]
+	getMessageRead            *connect.Client[v1.GetMessageReadRequest, v1.GetMessageReadResponse]
+	getMessageReactions       *connect.Client[v1.GetMessageReactionsRequest, v1.GetMessageReactionsResponse]
+	markMessagesAsRead        *connect.Client[v1.MarkMessagesAsReadRequest, v1.MarkMessagesAsReadResponse]
+	initialSync               *connect.Client[v1.InitialSyncRequest, v1.InitialSyncResponse]
+	streamMessages            *connect.Client[v1.StreamMessagesRequest, v1.MessageEvent]
+	exportRoomHistory         *connect.Client[v1.ExportRoomHistoryRequest, v1.ExportRoomHistoryResponse]
+	getRoomHistoryExport      *connect.Client[v1.GetRoomHistoryExportRequest, v1.GetRoomHistoryExportResponse]
+	downloadRoomHistoryExport *connect.Client[v1.DownloadRoomHistoryExportRequest, v1.DownloadRoomHistoryExportResponse]
+	exportUserData            *connect.Client[v1.ExportUserDataRequest, v1.ExportUserDataResponse]
+	getUserDataExport         *connect.Client[v1.GetUserDataExportRequest, v1.GetUserDataExportResponse]
+	downloadUserDataExport    *connect.Client[v1.DownloadUserDataExportRequest, v1.DownloadUserDataExportResponse]
+	eraseUserData             *connect.Client[v1.EraseUserDataRequest, v1.EraseUserDataResponse]
+	rotateRoomKey             *connect.Client[v1.RotateRoomKeyRequest, v1.RotateRoomKeyResponse]
+	getRoomKeys               *connect.Client[v1.GetRoomKeysRequest, v1.GetRoomKeysResponse]
+	registerDeviceKey         *connect.Client[v1.RegisterDeviceKeyRequest, v1.RegisterDeviceKeyResponse]
+	getDeviceKeys             *connect.Client[v1.GetDeviceKeysRequest, v1.GetDeviceKeysResponse]
+	shareRoomKey              *connect.Client[v1.ShareRoomKeyRequest, v1.ShareRoomKeyResponse]
+	listRoomCacheKeys         *connect.Client[v1.ListRoomCacheKeysRequest, v1.ListRoomCacheKeysResponse]
+	getCacheEntry             *connect.Client[v1.GetCacheEntryRequest, v1.GetCacheEntryResponse]
+	flushCache                *connect.Client[v1.FlushCacheRequest, v1.FlushCacheResponse]
+	getCacheStats             *connect.Client[v1.GetCacheStatsRequest, v1.GetCacheStatsResponse]
+	updateStreamSubscription  *connect.Client[v1.UpdateStreamSubscriptionRequest, v1.UpdateStreamSubscriptionResponse]
 }
 
 // SendMessage calls services.chat.v1.ChatService.SendMessage.
@@ -460,6 +693,91 @@ func (c *chatServiceClient) StreamMessages(ctx context.Context, req *connect.Req
 	return c.streamMessages.CallServerStream(ctx, req)
 }
 
+// ExportRoomHistory calls services.chat.v1.ChatService.ExportRoomHistory.
+func (c *chatServiceClient) ExportRoomHistory(ctx context.Context, req *connect.Request[v1.ExportRoomHistoryRequest]) (*connect.Response[v1.ExportRoomHistoryResponse], error) {
+	return c.exportRoomHistory.CallUnary(ctx, req)
+}
+
+// GetRoomHistoryExport calls services.chat.v1.ChatService.GetRoomHistoryExport.
+func (c *chatServiceClient) GetRoomHistoryExport(ctx context.Context, req *connect.Request[v1.GetRoomHistoryExportRequest]) (*connect.Response[v1.GetRoomHistoryExportResponse], error) {
+	return c.getRoomHistoryExport.CallUnary(ctx, req)
+}
+
+// DownloadRoomHistoryExport calls services.chat.v1.ChatService.DownloadRoomHistoryExport.
+func (c *chatServiceClient) DownloadRoomHistoryExport(ctx context.Context, req *connect.Request[v1.DownloadRoomHistoryExportRequest]) (*connect.ServerStreamForClient[v1.DownloadRoomHistoryExportResponse], error) {
+	return c.downloadRoomHistoryExport.CallServerStream(ctx, req)
+}
+
+// ExportUserData calls services.chat.v1.ChatService.ExportUserData.
+func (c *chatServiceClient) ExportUserData(ctx context.Context, req *connect.Request[v1.ExportUserDataRequest]) (*connect.Response[v1.ExportUserDataResponse], error) {
+	return c.exportUserData.CallUnary(ctx, req)
+}
+
+// GetUserDataExport calls services.chat.v1.ChatService.GetUserDataExport.
+func (c *chatServiceClient) GetUserDataExport(ctx context.Context, req *connect.Request[v1.GetUserDataExportRequest]) (*connect.Response[v1.GetUserDataExportResponse], error) {
+	return c.getUserDataExport.CallUnary(ctx, req)
+}
+
+// DownloadUserDataExport calls services.chat.v1.ChatService.DownloadUserDataExport.
+func (c *chatServiceClient) DownloadUserDataExport(ctx context.Context, req *connect.Request[v1.DownloadUserDataExportRequest]) (*connect.Response[v1.DownloadUserDataExportResponse], error) {
+	return c.downloadUserDataExport.CallUnary(ctx, req)
+}
+
+// EraseUserData calls services.chat.v1.ChatService.EraseUserData.
+func (c *chatServiceClient) EraseUserData(ctx context.Context, req *connect.Request[v1.EraseUserDataRequest]) (*connect.Response[v1.EraseUserDataResponse], error) {
+	return c.eraseUserData.CallUnary(ctx, req)
+}
+
+// RotateRoomKey calls services.chat.v1.ChatService.RotateRoomKey.
+func (c *chatServiceClient) RotateRoomKey(ctx context.Context, req *connect.Request[v1.RotateRoomKeyRequest]) (*connect.Response[v1.RotateRoomKeyResponse], error) {
+	return c.rotateRoomKey.CallUnary(ctx, req)
+}
+
+// GetRoomKeys calls services.chat.v1.ChatService.GetRoomKeys.
+func (c *chatServiceClient) GetRoomKeys(ctx context.Context, req *connect.Request[v1.GetRoomKeysRequest]) (*connect.Response[v1.GetRoomKeysResponse], error) {
+	return c.getRoomKeys.CallUnary(ctx, req)
+}
+
+// RegisterDeviceKey calls services.chat.v1.ChatService.RegisterDeviceKey.
+func (c *chatServiceClient) RegisterDeviceKey(ctx context.Context, req *connect.Request[v1.RegisterDeviceKeyRequest]) (*connect.Response[v1.RegisterDeviceKeyResponse], error) {
+	return c.registerDeviceKey.CallUnary(ctx, req)
+}
+
+// GetDeviceKeys calls services.chat.v1.ChatService.GetDeviceKeys.
+func (c *chatServiceClient) GetDeviceKeys(ctx context.Context, req *connect.Request[v1.GetDeviceKeysRequest]) (*connect.Response[v1.GetDeviceKeysResponse], error) {
+	return c.getDeviceKeys.CallUnary(ctx, req)
+}
+
+// ShareRoomKey calls services.chat.v1.ChatService.ShareRoomKey.
+func (c *chatServiceClient) ShareRoomKey(ctx context.Context, req *connect.Request[v1.ShareRoomKeyRequest]) (*connect.Response[v1.ShareRoomKeyResponse], error) {
+	return c.shareRoomKey.CallUnary(ctx, req)
+}
+
+// ListRoomCacheKeys calls services.chat.v1.ChatService.ListRoomCacheKeys.
+func (c *chatServiceClient) ListRoomCacheKeys(ctx context.Context, req *connect.Request[v1.ListRoomCacheKeysRequest]) (*connect.Response[v1.ListRoomCacheKeysResponse], error) {
+	return c.listRoomCacheKeys.CallUnary(ctx, req)
+}
+
+// GetCacheEntry calls services.chat.v1.ChatService.GetCacheEntry.
+func (c *chatServiceClient) GetCacheEntry(ctx context.Context, req *connect.Request[v1.GetCacheEntryRequest]) (*connect.Response[v1.GetCacheEntryResponse], error) {
+	return c.getCacheEntry.CallUnary(ctx, req)
+}
+
+// FlushCache calls services.chat.v1.ChatService.FlushCache.
+func (c *chatServiceClient) FlushCache(ctx context.Context, req *connect.Request[v1.FlushCacheRequest]) (*connect.Response[v1.FlushCacheResponse], error) {
+	return c.flushCache.CallUnary(ctx, req)
+}
+
+// GetCacheStats calls services.chat.v1.ChatService.GetCacheStats.
+func (c *chatServiceClient) GetCacheStats(ctx context.Context, req *connect.Request[v1.GetCacheStatsRequest]) (*connect.Response[v1.GetCacheStatsResponse], error) {
+	return c.getCacheStats.CallUnary(ctx, req)
+}
+
+// UpdateStreamSubscription calls services.chat.v1.ChatService.UpdateStreamSubscription.
+func (c *chatServiceClient) UpdateStreamSubscription(ctx context.Context, req *connect.Request[v1.UpdateStreamSubscriptionRequest]) (*connect.Response[v1.UpdateStreamSubscriptionResponse], error) {
+	return c.updateStreamSubscription.CallUnary(ctx, req)
+}
+
 // ChatServiceHandler is an implementation of the services.chat.v1.ChatService service.
 type ChatServiceHandler interface {
 	// Enviar mensaje
@@ -531,6 +849,70 @@ type ChatServiceHandler interface {
 	// Stream unidireccional para mensajes en tiempo real
 	// 🔒 Need private token to access this endpoint
 	StreamMessages(context.Context, *connect.Request[v1.StreamMessagesRequest], *connect.ServerStream[v1.MessageEvent]) error
+	// Exportar el historial de un room (solo owners y admins). La exportación corre en segundo
+	// plano; el progreso se consulta con GetRoomHistoryExport y el archivo se descarga con
+	// DownloadRoomHistoryExport
+	// 🔒 Need private token to access this endpoint
+	ExportRoomHistory(context.Context, *connect.Request[v1.ExportRoomHistoryRequest]) (*connect.Response[v1.ExportRoomHistoryResponse], error)
+	// Estado de una exportación de historial
+	// 🔒 Need private token to access this endpoint
+	GetRoomHistoryExport(context.Context, *connect.Request[v1.GetRoomHistoryExportRequest]) (*connect.Response[v1.GetRoomHistoryExportResponse], error)
+	// Descargar en trozos el archivo de una exportación de historial terminada (solo quien la
+	// pidió)
+	// 🔒 Need private token to access this endpoint
+	DownloadRoomHistoryExport(context.Context, *connect.Request[v1.DownloadRoomHistoryExportRequest], *connect.ServerStream[v1.DownloadRoomHistoryExportResponse]) error
+	// Exportar los datos de chat del usuario autenticado (portabilidad): salas, mensajes
+	// enviados, reacciones, lecturas, ajustes de cada sala y tokens de notificaciones. La
+	// exportación corre en segundo plano; el progreso se consulta con GetUserDataExport
+	// 🔒 Need private token to access this endpoint
+	ExportUserData(context.Context, *connect.Request[v1.ExportUserDataRequest]) (*connect.Response[v1.ExportUserDataResponse], error)
+	// Estado de una exportación de datos de usuario; cuando termina incluye un handle de
+	// descarga que caduca
+	// 🔒 Need private token to access this endpoint
+	GetUserDataExport(context.Context, *connect.Request[v1.GetUserDataExportRequest]) (*connect.Response[v1.GetUserDataExportResponse], error)
+	// Descargar el archivo de una exportación de datos de usuario con su handle
+	// 🔒 Need private token to access this endpoint
+	DownloadUserDataExport(context.Context, *connect.Request[v1.DownloadUserDataExportRequest]) (*connect.Response[v1.DownloadUserDataExportResponse], error)
+	// Borrar los datos de chat de un usuario que eliminó su cuenta (derecho al olvido). Uso
+	// interno entre servicios; también se dispara con el evento de usuario eliminado en NATS
+	// 🔓 Need public token to access this endpoint
+	EraseUserData(context.Context, *connect.Request[v1.EraseUserDataRequest]) (*connect.Response[v1.EraseUserDataResponse], error)
+	// Rotar la clave de cifrado de la sala (owners y admins en grupos). Los mensajes
+	// anteriores se siguen leyendo con las claves de GetRoomKeys
+	// 🔒 Need private token to access this endpoint
+	RotateRoomKey(context.Context, *connect.Request[v1.RotateRoomKeyRequest]) (*connect.Response[v1.RotateRoomKeyResponse], error)
+	// Todas las versiones de la clave de la sala, para leer el historial. En salas e2e, las
+	// envueltas para el dispositivo indicado
+	// 🔒 Need private token to access this endpoint
+	GetRoomKeys(context.Context, *connect.Request[v1.GetRoomKeysRequest]) (*connect.Response[v1.GetRoomKeysResponse], error)
+	// Registrar (o reemplazar) la clave pública de un dispositivo del usuario, para recibir
+	// las claves de las salas e2e
+	// 🔒 Need private token to access this endpoint
+	RegisterDeviceKey(context.Context, *connect.Request[v1.RegisterDeviceKeyRequest]) (*connect.Response[v1.RegisterDeviceKeyResponse], error)
+	// Directorio de claves públicas de los dispositivos de los usuarios o de los participantes
+	// de una sala
+	// 🔒 Need private token to access this endpoint
+	GetDeviceKeys(context.Context, *connect.Request[v1.GetDeviceKeysRequest]) (*connect.Response[v1.GetDeviceKeysResponse], error)
+	// Entregar una versión de la clave de una sala e2e envuelta para otros dispositivos
+	// (participantes o dispositivos nuevos)
+	// 🔒 Need private token to access this endpoint
+	ShareRoomKey(context.Context, *connect.Request[v1.ShareRoomKeyRequest]) (*connect.Response[v1.ShareRoomKeyResponse], error)
+	// Claves cacheadas de una sala (set de miembros) y si siguen en Redis y en el LRU local.
+	// Uso interno de operación
+	// 🔓 Need public token to access this endpoint
+	ListRoomCacheKeys(context.Context, *connect.Request[v1.ListRoomCacheKeysRequest]) (*connect.Response[v1.ListRoomCacheKeysResponse], error)
+	// Valor cacheado de una clave de sala o mensaje, en el LRU local y en Redis
+	// 🔓 Need public token to access this endpoint
+	GetCacheEntry(context.Context, *connect.Request[v1.GetCacheEntryRequest]) (*connect.Response[v1.GetCacheEntryResponse], error)
+	// Invalidar la caché de una sala o la de las salas de un usuario en todas las réplicas
+	// 🔓 Need public token to access this endpoint
+	FlushCache(context.Context, *connect.Request[v1.FlushCacheRequest]) (*connect.Response[v1.FlushCacheResponse], error)
+	// Aciertos y fallos de la caché de salas y mensajes de la réplica que responde
+	// 🔓 Need public token to access this endpoint
+	GetCacheStats(context.Context, *connect.Request[v1.GetCacheStatsRequest]) (*connect.Response[v1.GetCacheStatsResponse], error)
+	// Actualizar el filtro (salas y tipos de eventos) de un stream activo
+	// 🔒 Need private token to access this endpoint
+	UpdateStreamSubscription(context.Context, *connect.Request[v1.UpdateStreamSubscriptionRequest]) (*connect.Response[v1.UpdateStreamSubscriptionResponse], error)
 }
 
 // NewChatServiceHandler builds an HTTP handler from the service implementation. It returns the path
@@ -679,6 +1061,109 @@ func NewChatServiceHandler(svc ChatServiceHandler, opts ...connect.HandlerOption
 		connect.WithIdempotency(connect.IdempotencyIdempotent),
 		connect.WithHandlerOptions(opts...),
 	)
+	chatServiceExportRoomHistoryHandler := connect.NewUnaryHandler(
+		ChatServiceExportRoomHistoryProcedure,
+		svc.ExportRoomHistory,
+		connect.WithSchema(chatServiceMethods.ByName("ExportRoomHistory")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceGetRoomHistoryExportHandler := connect.NewUnaryHandler(
+		ChatServiceGetRoomHistoryExportProcedure,
+		svc.GetRoomHistoryExport,
+		connect.WithSchema(chatServiceMethods.ByName("GetRoomHistoryExport")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceDownloadRoomHistoryExportHandler := connect.NewServerStreamHandler(
+		ChatServiceDownloadRoomHistoryExportProcedure,
+		svc.DownloadRoomHistoryExport,
+		connect.WithSchema(chatServiceMethods.ByName("DownloadRoomHistoryExport")),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceExportUserDataHandler := connect.NewUnaryHandler(
+		ChatServiceExportUserDataProcedure,
+		svc.ExportUserData,
+		connect.WithSchema(chatServiceMethods.ByName("ExportUserData")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceGetUserDataExportHandler := connect.NewUnaryHandler(
+		ChatServiceGetUserDataExportProcedure,
+		svc.GetUserDataExport,
+		connect.WithSchema(chatServiceMethods.ByName("GetUserDataExport")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceDownloadUserDataExportHandler := connect.NewUnaryHandler(
+		ChatServiceDownloadUserDataExportProcedure,
+		svc.DownloadUserDataExport,
+		connect.WithSchema(chatServiceMethods.ByName("DownloadUserDataExport")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceEraseUserDataHandler := connect.NewUnaryHandler(
+		ChatServiceEraseUserDataProcedure,
+		svc.EraseUserData,
+		connect.WithSchema(chatServiceMethods.ByName("EraseUserData")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceRotateRoomKeyHandler := connect.NewUnaryHandler(
+		ChatServiceRotateRoomKeyProcedure,
+		svc.RotateRoomKey,
+		connect.WithSchema(chatServiceMethods.ByName("RotateRoomKey")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceGetRoomKeysHandler := connect.NewUnaryHandler(
+		ChatServiceGetRoomKeysProcedure,
+		svc.GetRoomKeys,
+		connect.WithSchema(chatServiceMethods.ByName("GetRoomKeys")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceRegisterDeviceKeyHandler := connect.NewUnaryHandler(
+		ChatServiceRegisterDeviceKeyProcedure,
+		svc.RegisterDeviceKey,
+		connect.WithSchema(chatServiceMethods.ByName("RegisterDeviceKey")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceGetDeviceKeysHandler := connect.NewUnaryHandler(
+		ChatServiceGetDeviceKeysProcedure,
+		svc.GetDeviceKeys,
+		connect.WithSchema(chatServiceMethods.ByName("GetDeviceKeys")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceShareRoomKeyHandler := connect.NewUnaryHandler(
+		ChatServiceShareRoomKeyProcedure,
+		svc.ShareRoomKey,
+		connect.WithSchema(chatServiceMethods.ByName("ShareRoomKey")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceListRoomCacheKeysHandler := connect.NewUnaryHandler(
+		ChatServiceListRoomCacheKeysProcedure,
+		svc.ListRoomCacheKeys,
+		connect.WithSchema(chatServiceMethods.ByName("ListRoomCacheKeys")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceGetCacheEntryHandler := connect.NewUnaryHandler(
+		ChatServiceGetCacheEntryProcedure,
+		svc.GetCacheEntry,
+		connect.WithSchema(chatServiceMethods.ByName("GetCacheEntry")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceFlushCacheHandler := connect.NewUnaryHandler(
+		ChatServiceFlushCacheProcedure,
+		svc.FlushCache,
+		connect.WithSchema(chatServiceMethods.ByName("FlushCache")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceGetCacheStatsHandler := connect.NewUnaryHandler(
+		ChatServiceGetCacheStatsProcedure,
+		svc.GetCacheStats,
+		connect.WithSchema(chatServiceMethods.ByName("GetCacheStats")),
+		connect.WithHandlerOptions(opts...),
+	)
+	chatServiceUpdateStreamSubscriptionHandler := connect.NewUnaryHandler(
+		ChatServiceUpdateStreamSubscriptionProcedure,
+		svc.UpdateStreamSubscription,
+		connect.WithSchema(chatServiceMethods.ByName("UpdateStreamSubscription")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/services.chat.v1.ChatService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case ChatServiceSendMessageProcedure:
@@ -727,6 +1212,40 @@ func NewChatServiceHandler(svc ChatServiceHandler, opts ...connect.HandlerOption
 			chatServiceInitialSyncHandler.ServeHTTP(w, r)
 		case ChatServiceStreamMessagesProcedure:
 			chatServiceStreamMessagesHandler.ServeHTTP(w, r)
+		case ChatServiceExportRoomHistoryProcedure:
+			chatServiceExportRoomHistoryHandler.ServeHTTP(w, r)
+		case ChatServiceGetRoomHistoryExportProcedure:
+			chatServiceGetRoomHistoryExportHandler.ServeHTTP(w, r)
+		case ChatServiceDownloadRoomHistoryExportProcedure:
+			chatServiceDownloadRoomHistoryExportHandler.ServeHTTP(w, r)
+		case ChatServiceExportUserDataProcedure:
+			chatServiceExportUserDataHandler.ServeHTTP(w, r)
+		case ChatServiceGetUserDataExportProcedure:
+			chatServiceGetUserDataExportHandler.ServeHTTP(w, r)
+		case ChatServiceDownloadUserDataExportProcedure:
+			chatServiceDownloadUserDataExportHandler.ServeHTTP(w, r)
+		case ChatServiceEraseUserDataProcedure:
+			chatServiceEraseUserDataHandler.ServeHTTP(w, r)
+		case ChatServiceRotateRoomKeyProcedure:
+			chatServiceRotateRoomKeyHandler.ServeHTTP(w, r)
+		case ChatServiceGetRoomKeysProcedure:
+			chatServiceGetRoomKeysHandler.ServeHTTP(w, r)
+		case ChatServiceRegisterDeviceKeyProcedure:
+			chatServiceRegisterDeviceKeyHandler.ServeHTTP(w, r)
+		case ChatServiceGetDeviceKeysProcedure:
+			chatServiceGetDeviceKeysHandler.ServeHTTP(w, r)
+		case ChatServiceShareRoomKeyProcedure:
+			chatServiceShareRoomKeyHandler.ServeHTTP(w, r)
+		case ChatServiceListRoomCacheKeysProcedure:
+			chatServiceListRoomCacheKeysHandler.ServeHTTP(w, r)
+		case ChatServiceGetCacheEntryProcedure:
+			chatServiceGetCacheEntryHandler.ServeHTTP(w, r)
+		case ChatServiceFlushCacheProcedure:
+			chatServiceFlushCacheHandler.ServeHTTP(w, r)
+		case ChatServiceGetCacheStatsProcedure:
+			chatServiceGetCacheStatsHandler.ServeHTTP(w, r)
+		case ChatServiceUpdateStreamSubscriptionProcedure:
+			chatServiceUpdateStreamSubscriptionHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -827,3 +1346,71 @@ func (UnimplementedChatServiceHandler) InitialSync(context.Context, *connect.Req
 func (UnimplementedChatServiceHandler) StreamMessages(context.Context, *connect.Request[v1.StreamMessagesRequest], *connect.ServerStream[v1.MessageEvent]) error {
 	return connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.StreamMessages is not implemented"))
 }
+
+func (UnimplementedChatServiceHandler) ExportRoomHistory(context.Context, *connect.Request[v1.ExportRoomHistoryRequest]) (*connect.Response[v1.ExportRoomHistoryResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.ExportRoomHistory is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) GetRoomHistoryExport(context.Context, *connect.Request[v1.GetRoomHistoryExportRequest]) (*connect.Response[v1.GetRoomHistoryExportResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.GetRoomHistoryExport is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) DownloadRoomHistoryExport(context.Context, *connect.Request[v1.DownloadRoomHistoryExportRequest], *connect.ServerStream[v1.DownloadRoomHistoryExportResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.DownloadRoomHistoryExport is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) ExportUserData(context.Context, *connect.Request[v1.ExportUserDataRequest]) (*connect.Response[v1.ExportUserDataResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.ExportUserData is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) GetUserDataExport(context.Context, *connect.Request[v1.GetUserDataExportRequest]) (*connect.Response[v1.GetUserDataExportResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.GetUserDataExport is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) DownloadUserDataExport(context.Context, *connect.Request[v1.DownloadUserDataExportRequest]) (*connect.Response[v1.DownloadUserDataExportResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.DownloadUserDataExport is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) EraseUserData(context.Context, *connect.Request[v1.EraseUserDataRequest]) (*connect.Response[v1.EraseUserDataResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.EraseUserData is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) RotateRoomKey(context.Context, *connect.Request[v1.RotateRoomKeyRequest]) (*connect.Response[v1.RotateRoomKeyResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.RotateRoomKey is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) GetRoomKeys(context.Context, *connect.Request[v1.GetRoomKeysRequest]) (*connect.Response[v1.GetRoomKeysResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.GetRoomKeys is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) RegisterDeviceKey(context.Context, *connect.Request[v1.RegisterDeviceKeyRequest]) (*connect.Response[v1.RegisterDeviceKeyResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.RegisterDeviceKey is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) GetDeviceKeys(context.Context, *connect.Request[v1.GetDeviceKeysRequest]) (*connect.Response[v1.GetDeviceKeysResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.GetDeviceKeys is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) ShareRoomKey(context.Context, *connect.Request[v1.ShareRoomKeyRequest]) (*connect.Response[v1.ShareRoomKeyResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.ShareRoomKey is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) ListRoomCacheKeys(context.Context, *connect.Request[v1.ListRoomCacheKeysRequest]) (*connect.Response[v1.ListRoomCacheKeysResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.ListRoomCacheKeys is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) GetCacheEntry(context.Context, *connect.Request[v1.GetCacheEntryRequest]) (*connect.Response[v1.GetCacheEntryResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.GetCacheEntry is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) FlushCache(context.Context, *connect.Request[v1.FlushCacheRequest]) (*connect.Response[v1.FlushCacheResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.FlushCache is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) GetCacheStats(context.Context, *connect.Request[v1.GetCacheStatsRequest]) (*connect.Response[v1.GetCacheStatsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.GetCacheStats is not implemented"))
+}
+
+func (UnimplementedChatServiceHandler) UpdateStreamSubscription(context.Context, *connect.Request[v1.UpdateStreamSubscriptionRequest]) (*connect.Response[v1.UpdateStreamSubscriptionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("services.chat.v1.ChatService.UpdateStreamSubscription is not implemented"))
+}
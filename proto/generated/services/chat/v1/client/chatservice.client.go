@@ -504,3 +504,323 @@ func InitialSync(ctx context.Context, generalParams api.GeneralParams, req *v1.I
 	}
 	return response, err
 }
+
+// Do a remote call for `services.chat.v1.ChatService@ExportRoomHistory(v1.ExportRoomHistoryRequest) -> v1.ExportRoomHistoryResponse`
+// This method requires a `api.GeneralParams` argument
+func ExportRoomHistory(ctx context.Context, generalParams api.GeneralParams, req *v1.ExportRoomHistoryRequest) (*v1.ExportRoomHistoryResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@ExportRoomHistory(v1.ExportRoomHistoryRequest) -> v1.ExportRoomHistoryResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.ExportRoomHistoryRequest -> %s\n", string(jsonReq))
+	var response *v1.ExportRoomHistoryResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().ExportRoomHistory(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.ExportRoomHistoryResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@GetRoomHistoryExport(v1.GetRoomHistoryExportRequest) -> v1.GetRoomHistoryExportResponse`
+// This method requires a `api.GeneralParams` argument
+func GetRoomHistoryExport(ctx context.Context, generalParams api.GeneralParams, req *v1.GetRoomHistoryExportRequest) (*v1.GetRoomHistoryExportResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@GetRoomHistoryExport(v1.GetRoomHistoryExportRequest) -> v1.GetRoomHistoryExportResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.GetRoomHistoryExportRequest -> %s\n", string(jsonReq))
+	var response *v1.GetRoomHistoryExportResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().GetRoomHistoryExport(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.GetRoomHistoryExportResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@ExportUserData(v1.ExportUserDataRequest) -> v1.ExportUserDataResponse`
+// This method requires a `api.GeneralParams` argument
+func ExportUserData(ctx context.Context, generalParams api.GeneralParams, req *v1.ExportUserDataRequest) (*v1.ExportUserDataResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@ExportUserData(v1.ExportUserDataRequest) -> v1.ExportUserDataResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.ExportUserDataRequest -> %s\n", string(jsonReq))
+	var response *v1.ExportUserDataResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().ExportUserData(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.ExportUserDataResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@GetUserDataExport(v1.GetUserDataExportRequest) -> v1.GetUserDataExportResponse`
+// This method requires a `api.GeneralParams` argument
+func GetUserDataExport(ctx context.Context, generalParams api.GeneralParams, req *v1.GetUserDataExportRequest) (*v1.GetUserDataExportResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@GetUserDataExport(v1.GetUserDataExportRequest) -> v1.GetUserDataExportResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.GetUserDataExportRequest -> %s\n", string(jsonReq))
+	var response *v1.GetUserDataExportResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().GetUserDataExport(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.GetUserDataExportResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@DownloadUserDataExport(v1.DownloadUserDataExportRequest) -> v1.DownloadUserDataExportResponse`
+// This method requires a `api.GeneralParams` argument
+func DownloadUserDataExport(ctx context.Context, generalParams api.GeneralParams, req *v1.DownloadUserDataExportRequest) (*v1.DownloadUserDataExportResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@DownloadUserDataExport(v1.DownloadUserDataExportRequest) -> v1.DownloadUserDataExportResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.DownloadUserDataExportRequest -> %s\n", string(jsonReq))
+	var response *v1.DownloadUserDataExportResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().DownloadUserDataExport(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.DownloadUserDataExportResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@EraseUserData(v1.EraseUserDataRequest) -> v1.EraseUserDataResponse`
+// This method requires a `api.GeneralParams` argument
+func EraseUserData(ctx context.Context, generalParams api.GeneralParams, req *v1.EraseUserDataRequest) (*v1.EraseUserDataResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@EraseUserData(v1.EraseUserDataRequest) -> v1.EraseUserDataResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.EraseUserDataRequest -> %s\n", string(jsonReq))
+	var response *v1.EraseUserDataResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().EraseUserData(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.EraseUserDataResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@RotateRoomKey(v1.RotateRoomKeyRequest) -> v1.RotateRoomKeyResponse`
+// This method requires a `api.GeneralParams` argument
+func RotateRoomKey(ctx context.Context, generalParams api.GeneralParams, req *v1.RotateRoomKeyRequest) (*v1.RotateRoomKeyResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@RotateRoomKey(v1.RotateRoomKeyRequest) -> v1.RotateRoomKeyResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.RotateRoomKeyRequest -> %s\n", string(jsonReq))
+	var response *v1.RotateRoomKeyResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().RotateRoomKey(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.RotateRoomKeyResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@GetRoomKeys(v1.GetRoomKeysRequest) -> v1.GetRoomKeysResponse`
+// This method requires a `api.GeneralParams` argument
+func GetRoomKeys(ctx context.Context, generalParams api.GeneralParams, req *v1.GetRoomKeysRequest) (*v1.GetRoomKeysResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@GetRoomKeys(v1.GetRoomKeysRequest) -> v1.GetRoomKeysResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.GetRoomKeysRequest -> %s\n", string(jsonReq))
+	var response *v1.GetRoomKeysResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().GetRoomKeys(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.GetRoomKeysResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@RegisterDeviceKey(v1.RegisterDeviceKeyRequest) -> v1.RegisterDeviceKeyResponse`
+// This method requires a `api.GeneralParams` argument
+func RegisterDeviceKey(ctx context.Context, generalParams api.GeneralParams, req *v1.RegisterDeviceKeyRequest) (*v1.RegisterDeviceKeyResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@RegisterDeviceKey(v1.RegisterDeviceKeyRequest) -> v1.RegisterDeviceKeyResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.RegisterDeviceKeyRequest -> %s\n", string(jsonReq))
+	var response *v1.RegisterDeviceKeyResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().RegisterDeviceKey(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.RegisterDeviceKeyResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@GetDeviceKeys(v1.GetDeviceKeysRequest) -> v1.GetDeviceKeysResponse`
+// This method requires a `api.GeneralParams` argument
+func GetDeviceKeys(ctx context.Context, generalParams api.GeneralParams, req *v1.GetDeviceKeysRequest) (*v1.GetDeviceKeysResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@GetDeviceKeys(v1.GetDeviceKeysRequest) -> v1.GetDeviceKeysResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.GetDeviceKeysRequest -> %s\n", string(jsonReq))
+	var response *v1.GetDeviceKeysResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().GetDeviceKeys(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.GetDeviceKeysResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@ShareRoomKey(v1.ShareRoomKeyRequest) -> v1.ShareRoomKeyResponse`
+// This method requires a `api.GeneralParams` argument
+func ShareRoomKey(ctx context.Context, generalParams api.GeneralParams, req *v1.ShareRoomKeyRequest) (*v1.ShareRoomKeyResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@ShareRoomKey(v1.ShareRoomKeyRequest) -> v1.ShareRoomKeyResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.ShareRoomKeyRequest -> %s\n", string(jsonReq))
+	var response *v1.ShareRoomKeyResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().ShareRoomKey(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.ShareRoomKeyResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@ListRoomCacheKeys(v1.ListRoomCacheKeysRequest) -> v1.ListRoomCacheKeysResponse`
+// This method requires a `api.GeneralParams` argument
+func ListRoomCacheKeys(ctx context.Context, generalParams api.GeneralParams, req *v1.ListRoomCacheKeysRequest) (*v1.ListRoomCacheKeysResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@ListRoomCacheKeys(v1.ListRoomCacheKeysRequest) -> v1.ListRoomCacheKeysResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.ListRoomCacheKeysRequest -> %s\n", string(jsonReq))
+	var response *v1.ListRoomCacheKeysResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().ListRoomCacheKeys(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.ListRoomCacheKeysResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@GetCacheEntry(v1.GetCacheEntryRequest) -> v1.GetCacheEntryResponse`
+// This method requires a `api.GeneralParams` argument
+func GetCacheEntry(ctx context.Context, generalParams api.GeneralParams, req *v1.GetCacheEntryRequest) (*v1.GetCacheEntryResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@GetCacheEntry(v1.GetCacheEntryRequest) -> v1.GetCacheEntryResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.GetCacheEntryRequest -> %s\n", string(jsonReq))
+	var response *v1.GetCacheEntryResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().GetCacheEntry(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.GetCacheEntryResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@FlushCache(v1.FlushCacheRequest) -> v1.FlushCacheResponse`
+// This method requires a `api.GeneralParams` argument
+func FlushCache(ctx context.Context, generalParams api.GeneralParams, req *v1.FlushCacheRequest) (*v1.FlushCacheResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@FlushCache(v1.FlushCacheRequest) -> v1.FlushCacheResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.FlushCacheRequest -> %s\n", string(jsonReq))
+	var response *v1.FlushCacheResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().FlushCache(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.FlushCacheResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@GetCacheStats(v1.GetCacheStatsRequest) -> v1.GetCacheStatsResponse`
+// This method requires a `api.GeneralParams` argument
+func GetCacheStats(ctx context.Context, generalParams api.GeneralParams, req *v1.GetCacheStatsRequest) (*v1.GetCacheStatsResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@GetCacheStats(v1.GetCacheStatsRequest) -> v1.GetCacheStatsResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.GetCacheStatsRequest -> %s\n", string(jsonReq))
+	var response *v1.GetCacheStatsResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().GetCacheStats(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.GetCacheStatsResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
+
+// Do a remote call for `services.chat.v1.ChatService@UpdateStreamSubscription(v1.UpdateStreamSubscriptionRequest) -> v1.UpdateStreamSubscriptionResponse`
+// This method requires a `api.GeneralParams` argument
+func UpdateStreamSubscription(ctx context.Context, generalParams api.GeneralParams, req *v1.UpdateStreamSubscriptionRequest) (*v1.UpdateStreamSubscriptionResponse, error) {
+	jsonReq, _ := protojson.Marshal(req)
+	log.Println("PROCESSING UNARY GRPC METHOD: services.chat.v1.ChatService@UpdateStreamSubscription(v1.UpdateStreamSubscriptionRequest) -> v1.UpdateStreamSubscriptionResponse")
+	log.Printf("UNARY GRPC REQUEST: v1.UpdateStreamSubscriptionRequest -> %s\n", string(jsonReq))
+	var response *v1.UpdateStreamSubscriptionResponse
+	rpcRequest, err := api.NewRequest(generalParams, req)
+	if err != nil {
+		return response, err
+	}
+	rpcResponse, err := GetChatServiceClient().UpdateStreamSubscription(ctx, rpcRequest)
+	if rpcResponse != nil {
+		response = rpcResponse.Msg
+		jsonRes, _ := protojson.Marshal(response)
+		log.Printf("UNARY GRPC RESPONSE: v1.UpdateStreamSubscriptionResponse -> %s\n", string(jsonRes))
+	}
+	return response, err
+}
@@ -25,7 +25,7 @@ var File_services_chat_v1_service_proto protoreflect.FileDescriptor
 
 const file_services_chat_v1_service_proto_rawDesc = "" +
 	"\n" +
-	"\x1eservices/chat/v1/service.proto\x12\x10services.chat.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1cservices/chat/v1/types.proto2\x88\x18\n" +
+	"\x1eservices/chat/v1/service.proto\x12\x10services.chat.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1cservices/chat/v1/types.proto2\xf0+\n" +
 	"\vChatService\x12x\n" +
 	"\vSendMessage\x12$.services.chat.v1.SendMessageRequest\x1a%.services.chat.v1.SendMessageResponse\"\x1c\x82\xd3\xe4\x93\x02\x16:\x01*\"\x11/api/chat/v1/send\x12x\n" +
 	"\vEditMessage\x12$.services.chat.v1.EditMessageRequest\x1a%.services.chat.v1.EditMessageResponse\"\x1c\x82\xd3\xe4\x93\x02\x16:\x01*\"\x11/api/chat/v1/edit\x12\x80\x01\n" +
@@ -52,56 +52,108 @@ const file_services_chat_v1_service_proto_rawDesc = "" +
 	"\x13GetMessageReactions\x12,.services.chat.v1.GetMessageReactionsRequest\x1a-.services.chat.v1.GetMessageReactionsResponse\"+\x82\xd3\xe4\x93\x02%\x12#/api/chat/v1/message/{id}/reactions\x12\x95\x01\n" +
 	"\x12MarkMessagesAsRead\x12+.services.chat.v1.MarkMessagesAsReadRequest\x1a,.services.chat.v1.MarkMessagesAsReadResponse\"$\x82\xd3\xe4\x93\x02\x1e:\x01*\"\x19/api/chat/v1/mark_as_read\x12x\n" +
 	"\vInitialSync\x12$.services.chat.v1.InitialSyncRequest\x1a%.services.chat.v1.InitialSyncResponse\"\x1c\x82\xd3\xe4\x93\x02\x16:\x01*\"\x11/api/chat/v1/sync\x12`\n" +
-	"\x0eStreamMessages\x12'.services.chat.v1.StreamMessagesRequest\x1a\x1e.services.chat.v1.MessageEvent\"\x03\x90\x02\x020\x01B\xec\x01\n" +
+	"\x0eStreamMessages\x12'.services.chat.v1.StreamMessagesRequest\x1a\x1e.services.chat.v1.MessageEvent\"\x03\x90\x02\x020\x01\x12\x96\x01\n" +
+	"\x11ExportRoomHistory\x12*.services.chat.v1.ExportRoomHistoryRequest\x1a+.services.chat.v1.ExportRoomHistoryResponse\"(\x82\xd3\xe4\x93\x02\":\x01*\"\x1d/api/chat/v1/room/{id}/export\x12\x97\x01\n" +
+	"\x14GetRoomHistoryExport\x12-.services.chat.v1.GetRoomHistoryExportRequest\x1a..services.chat.v1.GetRoomHistoryExportResponse\" \x82\xd3\xe4\x93\x02\x1a\x12\x18/api/chat/v1/export/{id}\x12\x8b\x01\n" +
+	"\x19DownloadRoomHistoryExport\x122.services.chat.v1.DownloadRoomHistoryExportRequest\x1a3.services.chat.v1.DownloadRoomHistoryExportResponse\"\x03\x90\x02\x010\x01\x12\x88\x01\n" +
+	"\x0eExportUserData\x12'.services.chat.v1.ExportUserDataRequest\x1a(.services.chat.v1.ExportUserDataResponse\"#\x82\xd3\xe4\x93\x02\x1d:\x01*\"\x18/api/chat/v1/user/export\x12\x93\x01\n" +
+	"\x11GetUserDataExport\x12*.services.chat.v1.GetUserDataExportRequest\x1a+.services.chat.v1.GetUserDataExportResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/api/chat/v1/user/export/{id}\x12\xaf\x01\n" +
+	"\x16DownloadUserDataExport\x12/.services.chat.v1.DownloadUserDataExportRequest\x1a0.services.chat.v1.DownloadUserDataExportResponse\"2\x82\xd3\xe4\x93\x02,\x12*/api/chat/v1/user/export/download/{handle}\x12\x8d\x01\n" +
+	"\rEraseUserData\x12&.services.chat.v1.EraseUserDataRequest\x1a'.services.chat.v1.EraseUserDataResponse\"+\x82\xd3\xe4\x93\x02%:\x01*\" /api/chat/v1/internal/user/erase\x12\x93\x01\n" +
+	"\rRotateRoomKey\x12&.services.chat.v1.RotateRoomKeyRequest\x1a'.services.chat.v1.RotateRoomKeyResponse\"1\x82\xd3\xe4\x93\x02+:\x01*\"&/api/chat/v1/room/{room_id}/key/rotate\x12\x84\x01\n" +
+	"\vGetRoomKeys\x12$.services.chat.v1.GetRoomKeysRequest\x1a%.services.chat.v1.GetRoomKeysResponse\"(\x82\xd3\xe4\x93\x02\"\x12 /api/chat/v1/room/{room_id}/keys\x12\x90\x01\n" +
+	"\x11RegisterDeviceKey\x12*.services.chat.v1.RegisterDeviceKeyRequest\x1a+.services.chat.v1.RegisterDeviceKeyResponse\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\"\x17/api/chat/v1/device/key\x12\x82\x01\n" +
+	"\rGetDeviceKeys\x12&.services.chat.v1.GetDeviceKeysRequest\x1a'.services.chat.v1.GetDeviceKeysResponse\" \x82\xd3\xe4\x93\x02\x1a\x12\x18/api/chat/v1/device/keys\x12\x8f\x01\n" +
+	"\fShareRoomKey\x12%.services.chat.v1.ShareRoomKeyRequest\x1a&.services.chat.v1.ShareRoomKeyResponse\"0\x82\xd3\xe4\x93\x02*:\x01*\"%/api/chat/v1/room/{room_id}/key/share\x12\xa5\x01\n" +
+	"\x11ListRoomCacheKeys\x12*.services.chat.v1.ListRoomCacheKeysRequest\x1a+.services.chat.v1.ListRoomCacheKeysResponse\"7\x82\xd3\xe4\x93\x021\x12//api/chat/v1/internal/cache/room/{room_id}/keys\x12\x8b\x01\n" +
+	"\rGetCacheEntry\x12&.services.chat.v1.GetCacheEntryRequest\x1a'.services.chat.v1.GetCacheEntryResponse\")\x82\xd3\xe4\x93\x02#\x12!/api/chat/v1/internal/cache/entry\x12\x85\x01\n" +
+	"\n" +
+	"FlushCache\x12#.services.chat.v1.FlushCacheRequest\x1a$.services.chat.v1.FlushCacheResponse\",\x82\xd3\xe4\x93\x02&:\x01*\"!/api/chat/v1/internal/cache/flush\x12\x8b\x01\n" +
+	"\rGetCacheStats\x12&.services.chat.v1.GetCacheStatsRequest\x1a'.services.chat.v1.GetCacheStatsResponse\")\x82\xd3\xe4\x93\x02#\x12!/api/chat/v1/internal/cache/stats\x12\xae\x01\n" +
+	"\x18UpdateStreamSubscription\x121.services.chat.v1.UpdateStreamSubscriptionRequest\x1a2.services.chat.v1.UpdateStreamSubscriptionResponse\"+\x82\xd3\xe4\x93\x02%:\x01*\" /api/chat/v1/stream/subscriptionB\xec\x01\n" +
 	"\x14com.services.chat.v1B\fServiceProtoP\x01Zdgithub.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1;chatv1\xa2\x02\x03SCX\xaa\x02\x10Services.Chat.V1\xca\x02\x10Services\\Chat\\V1\xe2\x02\x1cServices\\Chat\\V1\\GPBMetadata\xea\x02\x12Services::Chat::V1b\x06proto3"
 
 var file_services_chat_v1_service_proto_goTypes = []any{
-	(*SendMessageRequest)(nil),            // 0: services.chat.v1.SendMessageRequest
-	(*EditMessageRequest)(nil),            // 1: services.chat.v1.EditMessageRequest
-	(*DeleteMessageRequest)(nil),          // 2: services.chat.v1.DeleteMessageRequest
-	(*ReactToMessageRequest)(nil),         // 3: services.chat.v1.ReactToMessageRequest
-	(*GetRoomsRequest)(nil),               // 4: services.chat.v1.GetRoomsRequest
-	(*CreateRoomRequest)(nil),             // 5: services.chat.v1.CreateRoomRequest
-	(*GetRoomRequest)(nil),                // 6: services.chat.v1.GetRoomRequest
-	(*GetMessageHistoryRequest)(nil),      // 7: services.chat.v1.GetMessageHistoryRequest
-	(*GetRoomParticipantsRequest)(nil),    // 8: services.chat.v1.GetRoomParticipantsRequest
-	(*PinRoomRequest)(nil),                // 9: services.chat.v1.PinRoomRequest
-	(*MuteRoomRequest)(nil),               // 10: services.chat.v1.MuteRoomRequest
-	(*LeaveRoomRequest)(nil),              // 11: services.chat.v1.LeaveRoomRequest
-	(*AddParticipantToRoomRequest)(nil),   // 12: services.chat.v1.AddParticipantToRoomRequest
-	(*UpdateRoomRequest)(nil),             // 13: services.chat.v1.UpdateRoomRequest
-	(*UpdateParticipantRoomRequest)(nil),  // 14: services.chat.v1.UpdateParticipantRoomRequest
-	(*BlockUserRequest)(nil),              // 15: services.chat.v1.BlockUserRequest
-	(*GetSenderMessageRequest)(nil),       // 16: services.chat.v1.GetSenderMessageRequest
-	(*GetMessageRequest)(nil),             // 17: services.chat.v1.GetMessageRequest
-	(*GetMessageReadRequest)(nil),         // 18: services.chat.v1.GetMessageReadRequest
-	(*GetMessageReactionsRequest)(nil),    // 19: services.chat.v1.GetMessageReactionsRequest
-	(*MarkMessagesAsReadRequest)(nil),     // 20: services.chat.v1.MarkMessagesAsReadRequest
-	(*InitialSyncRequest)(nil),            // 21: services.chat.v1.InitialSyncRequest
-	(*StreamMessagesRequest)(nil),         // 22: services.chat.v1.StreamMessagesRequest
-	(*SendMessageResponse)(nil),           // 23: services.chat.v1.SendMessageResponse
-	(*EditMessageResponse)(nil),           // 24: services.chat.v1.EditMessageResponse
-	(*DeleteMessageResponse)(nil),         // 25: services.chat.v1.DeleteMessageResponse
-	(*ReactToMessageResponse)(nil),        // 26: services.chat.v1.ReactToMessageResponse
-	(*GetRoomsResponse)(nil),              // 27: services.chat.v1.GetRoomsResponse
-	(*CreateRoomResponse)(nil),            // 28: services.chat.v1.CreateRoomResponse
-	(*GetRoomResponse)(nil),               // 29: services.chat.v1.GetRoomResponse
-	(*GetMessageHistoryResponse)(nil),     // 30: services.chat.v1.GetMessageHistoryResponse
-	(*GetRoomParticipantsResponse)(nil),   // 31: services.chat.v1.GetRoomParticipantsResponse
-	(*PinRoomResponse)(nil),               // 32: services.chat.v1.PinRoomResponse
-	(*MuteRoomResponse)(nil),              // 33: services.chat.v1.MuteRoomResponse
-	(*LeaveRoomResponse)(nil),             // 34: services.chat.v1.LeaveRoomResponse
-	(*AddParticipantToRoomResponse)(nil),  // 35: services.chat.v1.AddParticipantToRoomResponse
-	(*UpdateRoomResponse)(nil),            // 36: services.chat.v1.UpdateRoomResponse
-	(*UpdateParticipantRoomResponse)(nil), // 37: services.chat.v1.UpdateParticipantRoomResponse
-	(*BlockUserResponse)(nil),             // 38: services.chat.v1.BlockUserResponse
-	(*GetSenderMessageResponse)(nil),      // 39: services.chat.v1.GetSenderMessageResponse
-	(*MessageData)(nil),                   // 40: services.chat.v1.MessageData
-	(*GetMessageReadResponse)(nil),        // 41: services.chat.v1.GetMessageReadResponse
-	(*GetMessageReactionsResponse)(nil),   // 42: services.chat.v1.GetMessageReactionsResponse
-	(*MarkMessagesAsReadResponse)(nil),    // 43: services.chat.v1.MarkMessagesAsReadResponse
-	(*InitialSyncResponse)(nil),           // 44: services.chat.v1.InitialSyncResponse
-	(*MessageEvent)(nil),                  // 45: services.chat.v1.MessageEvent
+	(*SendMessageRequest)(nil),                // 0: services.chat.v1.SendMessageRequest
+	(*EditMessageRequest)(nil),                // 1: services.chat.v1.EditMessageRequest
+	(*DeleteMessageRequest)(nil),              // 2: services.chat.v1.DeleteMessageRequest
+	(*ReactToMessageRequest)(nil),             // 3: services.chat.v1.ReactToMessageRequest
+	(*GetRoomsRequest)(nil),                   // 4: services.chat.v1.GetRoomsRequest
+	(*CreateRoomRequest)(nil),                 // 5: services.chat.v1.CreateRoomRequest
+	(*GetRoomRequest)(nil),                    // 6: services.chat.v1.GetRoomRequest
+	(*GetMessageHistoryRequest)(nil),          // 7: services.chat.v1.GetMessageHistoryRequest
+	(*GetRoomParticipantsRequest)(nil),        // 8: services.chat.v1.GetRoomParticipantsRequest
+	(*PinRoomRequest)(nil),                    // 9: services.chat.v1.PinRoomRequest
+	(*MuteRoomRequest)(nil),                   // 10: services.chat.v1.MuteRoomRequest
+	(*LeaveRoomRequest)(nil),                  // 11: services.chat.v1.LeaveRoomRequest
+	(*AddParticipantToRoomRequest)(nil),       // 12: services.chat.v1.AddParticipantToRoomRequest
+	(*UpdateRoomRequest)(nil),                 // 13: services.chat.v1.UpdateRoomRequest
+	(*UpdateParticipantRoomRequest)(nil),      // 14: services.chat.v1.UpdateParticipantRoomRequest
+	(*BlockUserRequest)(nil),                  // 15: services.chat.v1.BlockUserRequest
+	(*GetSenderMessageRequest)(nil),           // 16: services.chat.v1.GetSenderMessageRequest
+	(*GetMessageRequest)(nil),                 // 17: services.chat.v1.GetMessageRequest
+	(*GetMessageReadRequest)(nil),             // 18: services.chat.v1.GetMessageReadRequest
+	(*GetMessageReactionsRequest)(nil),        // 19: services.chat.v1.GetMessageReactionsRequest
+	(*MarkMessagesAsReadRequest)(nil),         // 20: services.chat.v1.MarkMessagesAsReadRequest
+	(*InitialSyncRequest)(nil),                // 21: services.chat.v1.InitialSyncRequest
+	(*StreamMessagesRequest)(nil),             // 22: services.chat.v1.StreamMessagesRequest
+	(*ExportRoomHistoryRequest)(nil),          // 23: services.chat.v1.ExportRoomHistoryRequest
+	(*GetRoomHistoryExportRequest)(nil),       // 24: services.chat.v1.GetRoomHistoryExportRequest
+	(*DownloadRoomHistoryExportRequest)(nil),  // 25: services.chat.v1.DownloadRoomHistoryExportRequest
+	(*ExportUserDataRequest)(nil),             // 26: services.chat.v1.ExportUserDataRequest
+	(*GetUserDataExportRequest)(nil),          // 27: services.chat.v1.GetUserDataExportRequest
+	(*DownloadUserDataExportRequest)(nil),     // 28: services.chat.v1.DownloadUserDataExportRequest
+	(*EraseUserDataRequest)(nil),              // 29: services.chat.v1.EraseUserDataRequest
+	(*RotateRoomKeyRequest)(nil),              // 30: services.chat.v1.RotateRoomKeyRequest
+	(*GetRoomKeysRequest)(nil),                // 31: services.chat.v1.GetRoomKeysRequest
+	(*RegisterDeviceKeyRequest)(nil),          // 32: services.chat.v1.RegisterDeviceKeyRequest
+	(*GetDeviceKeysRequest)(nil),              // 33: services.chat.v1.GetDeviceKeysRequest
+	(*ShareRoomKeyRequest)(nil),               // 34: services.chat.v1.ShareRoomKeyRequest
+	(*ListRoomCacheKeysRequest)(nil),          // 35: services.chat.v1.ListRoomCacheKeysRequest
+	(*GetCacheEntryRequest)(nil),              // 36: services.chat.v1.GetCacheEntryRequest
+	(*FlushCacheRequest)(nil),                 // 37: services.chat.v1.FlushCacheRequest
+	(*GetCacheStatsRequest)(nil),              // 38: services.chat.v1.GetCacheStatsRequest
+	(*UpdateStreamSubscriptionRequest)(nil),   // 39: services.chat.v1.UpdateStreamSubscriptionRequest
+	(*SendMessageResponse)(nil),               // 40: services.chat.v1.SendMessageResponse
+	(*EditMessageResponse)(nil),               // 41: services.chat.v1.EditMessageResponse
+	(*DeleteMessageResponse)(nil),             // 42: services.chat.v1.DeleteMessageResponse
+	(*ReactToMessageResponse)(nil),            // 43: services.chat.v1.ReactToMessageResponse
+	(*GetRoomsResponse)(nil),                  // 44: services.chat.v1.GetRoomsResponse
+	(*CreateRoomResponse)(nil),                // 45: services.chat.v1.CreateRoomResponse
+	(*GetRoomResponse)(nil),                   // 46: services.chat.v1.GetRoomResponse
+	(*GetMessageHistoryResponse)(nil),         // 47: services.chat.v1.GetMessageHistoryResponse
+	(*GetRoomParticipantsResponse)(nil),       // 48: services.chat.v1.GetRoomParticipantsResponse
+	(*PinRoomResponse)(nil),                   // 49: services.chat.v1.PinRoomResponse
+	(*MuteRoomResponse)(nil),                  // 50: services.chat.v1.MuteRoomResponse
+	(*LeaveRoomResponse)(nil),                 // 51: services.chat.v1.LeaveRoomResponse
+	(*AddParticipantToRoomResponse)(nil),      // 52: services.chat.v1.AddParticipantToRoomResponse
+	(*UpdateRoomResponse)(nil),                // 53: services.chat.v1.UpdateRoomResponse
+	(*UpdateParticipantRoomResponse)(nil),     // 54: services.chat.v1.UpdateParticipantRoomResponse
+	(*BlockUserResponse)(nil),                 // 55: services.chat.v1.BlockUserResponse
+	(*GetSenderMessageResponse)(nil),          // 56: services.chat.v1.GetSenderMessageResponse
+	(*MessageData)(nil),                       // 57: services.chat.v1.MessageData
+	(*GetMessageReadResponse)(nil),            // 58: services.chat.v1.GetMessageReadResponse
+	(*GetMessageReactionsResponse)(nil),       // 59: services.chat.v1.GetMessageReactionsResponse
+	(*MarkMessagesAsReadResponse)(nil),        // 60: services.chat.v1.MarkMessagesAsReadResponse
+	(*InitialSyncResponse)(nil),               // 61: services.chat.v1.InitialSyncResponse
+	(*MessageEvent)(nil),                      // 62: services.chat.v1.MessageEvent
+	(*ExportRoomHistoryResponse)(nil),         // 63: services.chat.v1.ExportRoomHistoryResponse
+	(*GetRoomHistoryExportResponse)(nil),      // 64: services.chat.v1.GetRoomHistoryExportResponse
+	(*DownloadRoomHistoryExportResponse)(nil), // 65: services.chat.v1.DownloadRoomHistoryExportResponse
+	(*ExportUserDataResponse)(nil),            // 66: services.chat.v1.ExportUserDataResponse
+	(*GetUserDataExportResponse)(nil),         // 67: services.chat.v1.GetUserDataExportResponse
+	(*DownloadUserDataExportResponse)(nil),    // 68: services.chat.v1.DownloadUserDataExportResponse
+	(*EraseUserDataResponse)(nil),             // 69: services.chat.v1.EraseUserDataResponse
+	(*RotateRoomKeyResponse)(nil),             // 70: services.chat.v1.RotateRoomKeyResponse
+	(*GetRoomKeysResponse)(nil),               // 71: services.chat.v1.GetRoomKeysResponse
+	(*RegisterDeviceKeyResponse)(nil),         // 72: services.chat.v1.RegisterDeviceKeyResponse
+	(*GetDeviceKeysResponse)(nil),             // 73: services.chat.v1.GetDeviceKeysResponse
+	(*ShareRoomKeyResponse)(nil),              // 74: services.chat.v1.ShareRoomKeyResponse
+	(*ListRoomCacheKeysResponse)(nil),         // 75: services.chat.v1.ListRoomCacheKeysResponse
+	(*GetCacheEntryResponse)(nil),             // 76: services.chat.v1.GetCacheEntryResponse
+	(*FlushCacheResponse)(nil),                // 77: services.chat.v1.FlushCacheResponse
+	(*GetCacheStatsResponse)(nil),             // 78: services.chat.v1.GetCacheStatsResponse
+	(*UpdateStreamSubscriptionResponse)(nil),  // 79: services.chat.v1.UpdateStreamSubscriptionResponse
 }
 var file_services_chat_v1_service_proto_depIdxs = []int32{
 	0,  // 0: services.chat.v1.ChatService.SendMessage:input_type -> services.chat.v1.SendMessageRequest
@@ -127,31 +179,65 @@ var file_services_chat_v1_service_proto_depIdxs = []int32{
 	20, // 20: services.chat.v1.ChatService.MarkMessagesAsRead:input_type -> services.chat.v1.MarkMessagesAsReadRequest
 	21, // 21: services.chat.v1.ChatService.InitialSync:input_type -> services.chat.v1.InitialSyncRequest
 	22, // 22: services.chat.v1.ChatService.StreamMessages:input_type -> services.chat.v1.StreamMessagesRequest
-	23, // 23: services.chat.v1.ChatService.SendMessage:output_type -> services.chat.v1.SendMessageResponse
-	24, // 24: services.chat.v1.ChatService.EditMessage:output_type -> services.chat.v1.EditMessageResponse
-	25, // 25: services.chat.v1.ChatService.DeleteMessage:output_type -> services.chat.v1.DeleteMessageResponse
-	26, // 26: services.chat.v1.ChatService.ReactToMessage:output_type -> services.chat.v1.ReactToMessageResponse
-	27, // 27: services.chat.v1.ChatService.GetRooms:output_type -> services.chat.v1.GetRoomsResponse
-	28, // 28: services.chat.v1.ChatService.CreateRoom:output_type -> services.chat.v1.CreateRoomResponse
-	29, // 29: services.chat.v1.ChatService.GetRoom:output_type -> services.chat.v1.GetRoomResponse
-	30, // 30: services.chat.v1.ChatService.GetMessageHistory:output_type -> services.chat.v1.GetMessageHistoryResponse
-	31, // 31: services.chat.v1.ChatService.GetRoomParticipants:output_type -> services.chat.v1.GetRoomParticipantsResponse
-	32, // 32: services.chat.v1.ChatService.PinRoom:output_type -> services.chat.v1.PinRoomResponse
-	33, // 33: services.chat.v1.ChatService.MuteRoom:output_type -> services.chat.v1.MuteRoomResponse
-	34, // 34: services.chat.v1.ChatService.LeaveRoom:output_type -> services.chat.v1.LeaveRoomResponse
-	35, // 35: services.chat.v1.ChatService.AddParticipantToRoom:output_type -> services.chat.v1.AddParticipantToRoomResponse
-	36, // 36: services.chat.v1.ChatService.UpdateRoom:output_type -> services.chat.v1.UpdateRoomResponse
-	37, // 37: services.chat.v1.ChatService.UpdateParticipantRoom:output_type -> services.chat.v1.UpdateParticipantRoomResponse
-	38, // 38: services.chat.v1.ChatService.BlockUser:output_type -> services.chat.v1.BlockUserResponse
-	39, // 39: services.chat.v1.ChatService.GetSenderMessage:output_type -> services.chat.v1.GetSenderMessageResponse
-	40, // 40: services.chat.v1.ChatService.GetMessage:output_type -> services.chat.v1.MessageData
-	41, // 41: services.chat.v1.ChatService.GetMessageRead:output_type -> services.chat.v1.GetMessageReadResponse
-	42, // 42: services.chat.v1.ChatService.GetMessageReactions:output_type -> services.chat.v1.GetMessageReactionsResponse
-	43, // 43: services.chat.v1.ChatService.MarkMessagesAsRead:output_type -> services.chat.v1.MarkMessagesAsReadResponse
-	44, // 44: services.chat.v1.ChatService.InitialSync:output_type -> services.chat.v1.InitialSyncResponse
-	45, // 45: services.chat.v1.ChatService.StreamMessages:output_type -> services.chat.v1.MessageEvent
-	23, // [23:46] is the sub-list for method output_type
-	0,  // [0:23] is the sub-list for method input_type
+	23, // 23: services.chat.v1.ChatService.ExportRoomHistory:input_type -> services.chat.v1.ExportRoomHistoryRequest
+	24, // 24: services.chat.v1.ChatService.GetRoomHistoryExport:input_type -> services.chat.v1.GetRoomHistoryExportRequest
+	25, // 25: services.chat.v1.ChatService.DownloadRoomHistoryExport:input_type -> services.chat.v1.DownloadRoomHistoryExportRequest
+	26, // 26: services.chat.v1.ChatService.ExportUserData:input_type -> services.chat.v1.ExportUserDataRequest
+	27, // 27: services.chat.v1.ChatService.GetUserDataExport:input_type -> services.chat.v1.GetUserDataExportRequest
+	28, // 28: services.chat.v1.ChatService.DownloadUserDataExport:input_type -> services.chat.v1.DownloadUserDataExportRequest
+	29, // 29: services.chat.v1.ChatService.EraseUserData:input_type -> services.chat.v1.EraseUserDataRequest
+	30, // 30: services.chat.v1.ChatService.RotateRoomKey:input_type -> services.chat.v1.RotateRoomKeyRequest
+	31, // 31: services.chat.v1.ChatService.GetRoomKeys:input_type -> services.chat.v1.GetRoomKeysRequest
+	32, // 32: services.chat.v1.ChatService.RegisterDeviceKey:input_type -> services.chat.v1.RegisterDeviceKeyRequest
+	33, // 33: services.chat.v1.ChatService.GetDeviceKeys:input_type -> services.chat.v1.GetDeviceKeysRequest
+	34, // 34: services.chat.v1.ChatService.ShareRoomKey:input_type -> services.chat.v1.ShareRoomKeyRequest
+	35, // 35: services.chat.v1.ChatService.ListRoomCacheKeys:input_type -> services.chat.v1.ListRoomCacheKeysRequest
+	36, // 36: services.chat.v1.ChatService.GetCacheEntry:input_type -> services.chat.v1.GetCacheEntryRequest
+	37, // 37: services.chat.v1.ChatService.FlushCache:input_type -> services.chat.v1.FlushCacheRequest
+	38, // 38: services.chat.v1.ChatService.GetCacheStats:input_type -> services.chat.v1.GetCacheStatsRequest
+	39, // 39: services.chat.v1.ChatService.UpdateStreamSubscription:input_type -> services.chat.v1.UpdateStreamSubscriptionRequest
+	40, // 40: services.chat.v1.ChatService.SendMessage:output_type -> services.chat.v1.SendMessageResponse
+	41, // 41: services.chat.v1.ChatService.EditMessage:output_type -> services.chat.v1.EditMessageResponse
+	42, // 42: services.chat.v1.ChatService.DeleteMessage:output_type -> services.chat.v1.DeleteMessageResponse
+	43, // 43: services.chat.v1.ChatService.ReactToMessage:output_type -> services.chat.v1.ReactToMessageResponse
+	44, // 44: services.chat.v1.ChatService.GetRooms:output_type -> services.chat.v1.GetRoomsResponse
+	45, // 45: services.chat.v1.ChatService.CreateRoom:output_type -> services.chat.v1.CreateRoomResponse
+	46, // 46: services.chat.v1.ChatService.GetRoom:output_type -> services.chat.v1.GetRoomResponse
+	47, // 47: services.chat.v1.ChatService.GetMessageHistory:output_type -> services.chat.v1.GetMessageHistoryResponse
+	48, // 48: services.chat.v1.ChatService.GetRoomParticipants:output_type -> services.chat.v1.GetRoomParticipantsResponse
+	49, // 49: services.chat.v1.ChatService.PinRoom:output_type -> services.chat.v1.PinRoomResponse
+	50, // 50: services.chat.v1.ChatService.MuteRoom:output_type -> services.chat.v1.MuteRoomResponse
+	51, // 51: services.chat.v1.ChatService.LeaveRoom:output_type -> services.chat.v1.LeaveRoomResponse
+	52, // 52: services.chat.v1.ChatService.AddParticipantToRoom:output_type -> services.chat.v1.AddParticipantToRoomResponse
+	53, // 53: services.chat.v1.ChatService.UpdateRoom:output_type -> services.chat.v1.UpdateRoomResponse
+	54, // 54: services.chat.v1.ChatService.UpdateParticipantRoom:output_type -> services.chat.v1.UpdateParticipantRoomResponse
+	55, // 55: services.chat.v1.ChatService.BlockUser:output_type -> services.chat.v1.BlockUserResponse
+	56, // 56: services.chat.v1.ChatService.GetSenderMessage:output_type -> services.chat.v1.GetSenderMessageResponse
+	57, // 57: services.chat.v1.ChatService.GetMessage:output_type -> services.chat.v1.MessageData
+	58, // 58: services.chat.v1.ChatService.GetMessageRead:output_type -> services.chat.v1.GetMessageReadResponse
+	59, // 59: services.chat.v1.ChatService.GetMessageReactions:output_type -> services.chat.v1.GetMessageReactionsResponse
+	60, // 60: services.chat.v1.ChatService.MarkMessagesAsRead:output_type -> services.chat.v1.MarkMessagesAsReadResponse
+	61, // 61: services.chat.v1.ChatService.InitialSync:output_type -> services.chat.v1.InitialSyncResponse
+	62, // 62: services.chat.v1.ChatService.StreamMessages:output_type -> services.chat.v1.MessageEvent
+	63, // 63: services.chat.v1.ChatService.ExportRoomHistory:output_type -> services.chat.v1.ExportRoomHistoryResponse
+	64, // 64: services.chat.v1.ChatService.GetRoomHistoryExport:output_type -> services.chat.v1.GetRoomHistoryExportResponse
+	65, // 65: services.chat.v1.ChatService.DownloadRoomHistoryExport:output_type -> services.chat.v1.DownloadRoomHistoryExportResponse
+	66, // 66: services.chat.v1.ChatService.ExportUserData:output_type -> services.chat.v1.ExportUserDataResponse
+	67, // 67: services.chat.v1.ChatService.GetUserDataExport:output_type -> services.chat.v1.GetUserDataExportResponse
+	68, // 68: services.chat.v1.ChatService.DownloadUserDataExport:output_type -> services.chat.v1.DownloadUserDataExportResponse
+	69, // 69: services.chat.v1.ChatService.EraseUserData:output_type -> services.chat.v1.EraseUserDataResponse
+	70, // 70: services.chat.v1.ChatService.RotateRoomKey:output_type -> services.chat.v1.RotateRoomKeyResponse
+	71, // 71: services.chat.v1.ChatService.GetRoomKeys:output_type -> services.chat.v1.GetRoomKeysResponse
+	72, // 72: services.chat.v1.ChatService.RegisterDeviceKey:output_type -> services.chat.v1.RegisterDeviceKeyResponse
+	73, // 73: services.chat.v1.ChatService.GetDeviceKeys:output_type -> services.chat.v1.GetDeviceKeysResponse
+	74, // 74: services.chat.v1.ChatService.ShareRoomKey:output_type -> services.chat.v1.ShareRoomKeyResponse
+	75, // 75: services.chat.v1.ChatService.ListRoomCacheKeys:output_type -> services.chat.v1.ListRoomCacheKeysResponse
+	76, // 76: services.chat.v1.ChatService.GetCacheEntry:output_type -> services.chat.v1.GetCacheEntryResponse
+	77, // 77: services.chat.v1.ChatService.FlushCache:output_type -> services.chat.v1.FlushCacheResponse
+	78, // 78: services.chat.v1.ChatService.GetCacheStats:output_type -> services.chat.v1.GetCacheStatsResponse
+	79, // 79: services.chat.v1.ChatService.UpdateStreamSubscription:output_type -> services.chat.v1.UpdateStreamSubscriptionResponse
+	40, // [40:80] is the sub-list for method output_type
+	0,  // [0:40] is the sub-list for method input_type
 	0,  // [0:0] is the sub-list for extension type_name
 	0,  // [0:0] is the sub-list for extension extendee
 	0,  // [0:0] is the sub-list for field type_name
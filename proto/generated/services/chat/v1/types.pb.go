@@ -136,32 +136,223 @@ func (SyncStrategy) EnumDescriptor() ([]byte, []int) {
 	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{1}
 }
 
+// Tipos de eventos del stream, usados para filtrar la suscripción
+type StreamEventType int32
+
+const (
+	StreamEventType_STREAM_EVENT_TYPE_UNSPECIFIED    StreamEventType = 0
+	StreamEventType_STREAM_EVENT_TYPE_MESSAGE        StreamEventType = 1
+	StreamEventType_STREAM_EVENT_TYPE_STATUS_UPDATE  StreamEventType = 2
+	StreamEventType_STREAM_EVENT_TYPE_ROOM_UPDATED   StreamEventType = 3
+	StreamEventType_STREAM_EVENT_TYPE_ROOM_JOIN      StreamEventType = 4
+	StreamEventType_STREAM_EVENT_TYPE_ROOM_LEAVE     StreamEventType = 5
+	StreamEventType_STREAM_EVENT_TYPE_TYPING         StreamEventType = 6
+	StreamEventType_STREAM_EVENT_TYPE_ERROR          StreamEventType = 7
+	StreamEventType_STREAM_EVENT_TYPE_UPDATE_MESSAGE StreamEventType = 8
+	StreamEventType_STREAM_EVENT_TYPE_DELETE_MESSAGE StreamEventType = 9
+	StreamEventType_STREAM_EVENT_TYPE_READ_STATE     StreamEventType = 10
+	StreamEventType_STREAM_EVENT_TYPE_HISTORY_PURGED StreamEventType = 11
+)
+
+// Enum value maps for StreamEventType.
+var (
+	StreamEventType_name = map[int32]string{
+		0:  "STREAM_EVENT_TYPE_UNSPECIFIED",
+		1:  "STREAM_EVENT_TYPE_MESSAGE",
+		2:  "STREAM_EVENT_TYPE_STATUS_UPDATE",
+		3:  "STREAM_EVENT_TYPE_ROOM_UPDATED",
+		4:  "STREAM_EVENT_TYPE_ROOM_JOIN",
+		5:  "STREAM_EVENT_TYPE_ROOM_LEAVE",
+		6:  "STREAM_EVENT_TYPE_TYPING",
+		7:  "STREAM_EVENT_TYPE_ERROR",
+		8:  "STREAM_EVENT_TYPE_UPDATE_MESSAGE",
+		9:  "STREAM_EVENT_TYPE_DELETE_MESSAGE",
+		10: "STREAM_EVENT_TYPE_READ_STATE",
+		11: "STREAM_EVENT_TYPE_HISTORY_PURGED",
+	}
+	StreamEventType_value = map[string]int32{
+		"STREAM_EVENT_TYPE_UNSPECIFIED":    0,
+		"STREAM_EVENT_TYPE_MESSAGE":        1,
+		"STREAM_EVENT_TYPE_STATUS_UPDATE":  2,
+		"STREAM_EVENT_TYPE_ROOM_UPDATED":   3,
+		"STREAM_EVENT_TYPE_ROOM_JOIN":      4,
+		"STREAM_EVENT_TYPE_ROOM_LEAVE":     5,
+		"STREAM_EVENT_TYPE_TYPING":         6,
+		"STREAM_EVENT_TYPE_ERROR":          7,
+		"STREAM_EVENT_TYPE_UPDATE_MESSAGE": 8,
+		"STREAM_EVENT_TYPE_DELETE_MESSAGE": 9,
+		"STREAM_EVENT_TYPE_READ_STATE":     10,
+		"STREAM_EVENT_TYPE_HISTORY_PURGED": 11,
+	}
+)
+
+func (x StreamEventType) Enum() *StreamEventType {
+	p := new(StreamEventType)
+	*p = x
+	return p
+}
+
+func (x StreamEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StreamEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_services_chat_v1_types_proto_enumTypes[2].Descriptor()
+}
+
+func (StreamEventType) Type() protoreflect.EnumType {
+	return &file_services_chat_v1_types_proto_enumTypes[2]
+}
+
+func (x StreamEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StreamEventType.Descriptor instead.
+func (StreamEventType) EnumDescriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{2}
+}
+
+// Formato del archivo de una exportación de historial
+type ExportFormat int32
+
+const (
+	ExportFormat_EXPORT_FORMAT_UNSPECIFIED ExportFormat = 0
+	ExportFormat_EXPORT_FORMAT_JSON        ExportFormat = 1
+	ExportFormat_EXPORT_FORMAT_CSV         ExportFormat = 2
+	ExportFormat_EXPORT_FORMAT_HTML        ExportFormat = 3 // Transcripción autocontenida
+)
+
+// Enum value maps for ExportFormat.
+var (
+	ExportFormat_name = map[int32]string{
+		0: "EXPORT_FORMAT_UNSPECIFIED",
+		1: "EXPORT_FORMAT_JSON",
+		2: "EXPORT_FORMAT_CSV",
+		3: "EXPORT_FORMAT_HTML",
+	}
+	ExportFormat_value = map[string]int32{
+		"EXPORT_FORMAT_UNSPECIFIED": 0,
+		"EXPORT_FORMAT_JSON":        1,
+		"EXPORT_FORMAT_CSV":         2,
+		"EXPORT_FORMAT_HTML":        3,
+	}
+)
+
+func (x ExportFormat) Enum() *ExportFormat {
+	p := new(ExportFormat)
+	*p = x
+	return p
+}
+
+func (x ExportFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ExportFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_services_chat_v1_types_proto_enumTypes[3].Descriptor()
+}
+
+func (ExportFormat) Type() protoreflect.EnumType {
+	return &file_services_chat_v1_types_proto_enumTypes[3]
+}
+
+func (x ExportFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ExportFormat.Descriptor instead.
+func (ExportFormat) EnumDescriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{3}
+}
+
+// Estado de una exportación de historial
+type ExportStatus int32
+
+const (
+	ExportStatus_EXPORT_STATUS_UNSPECIFIED ExportStatus = 0
+	ExportStatus_EXPORT_STATUS_PENDING     ExportStatus = 1
+	ExportStatus_EXPORT_STATUS_RUNNING     ExportStatus = 2
+	ExportStatus_EXPORT_STATUS_COMPLETED   ExportStatus = 3
+	ExportStatus_EXPORT_STATUS_FAILED      ExportStatus = 4
+)
+
+// Enum value maps for ExportStatus.
+var (
+	ExportStatus_name = map[int32]string{
+		0: "EXPORT_STATUS_UNSPECIFIED",
+		1: "EXPORT_STATUS_PENDING",
+		2: "EXPORT_STATUS_RUNNING",
+		3: "EXPORT_STATUS_COMPLETED",
+		4: "EXPORT_STATUS_FAILED",
+	}
+	ExportStatus_value = map[string]int32{
+		"EXPORT_STATUS_UNSPECIFIED": 0,
+		"EXPORT_STATUS_PENDING":     1,
+		"EXPORT_STATUS_RUNNING":     2,
+		"EXPORT_STATUS_COMPLETED":   3,
+		"EXPORT_STATUS_FAILED":      4,
+	}
+)
+
+func (x ExportStatus) Enum() *ExportStatus {
+	p := new(ExportStatus)
+	*p = x
+	return p
+}
+
+func (x ExportStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ExportStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_services_chat_v1_types_proto_enumTypes[4].Descriptor()
+}
+
+func (ExportStatus) Type() protoreflect.EnumType {
+	return &file_services_chat_v1_types_proto_enumTypes[4]
+}
+
+func (x ExportStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ExportStatus.Descriptor instead.
+func (ExportStatus) EnumDescriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{4}
+}
+
 // Estructuras de datos principales
 type Room struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Description      string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	PhotoUrl         string                 `protobuf:"bytes,4,opt,name=photo_url,json=photoUrl,proto3" json:"photo_url,omitempty"`
-	EncryptionData   string                 `protobuf:"bytes,5,opt,name=encryption_data,json=encryptionData,proto3" json:"encryption_data,omitempty"`
-	Type             string                 `protobuf:"bytes,6,opt,name=type,proto3" json:"type,omitempty"`
-	UnreadCount      int32                  `protobuf:"varint,7,opt,name=unread_count,json=unreadCount,proto3" json:"unread_count,omitempty"`
-	Role             string                 `protobuf:"bytes,8,opt,name=role,proto3" json:"role,omitempty"`
-	JoinAllUser      bool                   `protobuf:"varint,10,opt,name=join_all_user,json=joinAllUser,proto3" json:"join_all_user,omitempty"`
-	LastMessageAt    string                 `protobuf:"bytes,11,opt,name=last_message_at,json=lastMessageAt,proto3" json:"last_message_at,omitempty"`
-	SendMessage      bool                   `protobuf:"varint,12,opt,name=send_message,json=sendMessage,proto3" json:"send_message,omitempty"`
-	AddMember        bool                   `protobuf:"varint,13,opt,name=add_member,json=addMember,proto3" json:"add_member,omitempty"`
-	EditGroup        bool                   `protobuf:"varint,14,opt,name=edit_group,json=editGroup,proto3" json:"edit_group,omitempty"`
-	CreatedAt        string                 `protobuf:"bytes,15,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // ISO 8601
-	UpdatedAt        string                 `protobuf:"bytes,16,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // ISO 8601
-	Partner          *RoomParticipant       `protobuf:"bytes,17,opt,name=partner,proto3,oneof" json:"partner,omitempty"`
-	Participants     []*RoomParticipant     `protobuf:"bytes,18,rep,name=participants,proto3" json:"participants,omitempty"`
-	IsPartnerBlocked bool                   `protobuf:"varint,19,opt,name=is_partner_blocked,json=isPartnerBlocked,proto3" json:"is_partner_blocked,omitempty"`
-	IsMuted          bool                   `protobuf:"varint,20,opt,name=is_muted,json=isMuted,proto3" json:"is_muted,omitempty"`
-	IsPinned         bool                   `protobuf:"varint,21,opt,name=is_pinned,json=isPinned,proto3" json:"is_pinned,omitempty"`
-	LastMessage      *MessageData           `protobuf:"bytes,22,opt,name=last_message,json=lastMessage,proto3" json:"last_message,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Id                  string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description         string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	PhotoUrl            string                 `protobuf:"bytes,4,opt,name=photo_url,json=photoUrl,proto3" json:"photo_url,omitempty"`
+	EncryptionData      string                 `protobuf:"bytes,5,opt,name=encryption_data,json=encryptionData,proto3" json:"encryption_data,omitempty"`
+	Type                string                 `protobuf:"bytes,6,opt,name=type,proto3" json:"type,omitempty"`
+	UnreadCount         int32                  `protobuf:"varint,7,opt,name=unread_count,json=unreadCount,proto3" json:"unread_count,omitempty"`
+	Role                string                 `protobuf:"bytes,8,opt,name=role,proto3" json:"role,omitempty"`
+	JoinAllUser         bool                   `protobuf:"varint,10,opt,name=join_all_user,json=joinAllUser,proto3" json:"join_all_user,omitempty"`
+	LastMessageAt       string                 `protobuf:"bytes,11,opt,name=last_message_at,json=lastMessageAt,proto3" json:"last_message_at,omitempty"`
+	SendMessage         bool                   `protobuf:"varint,12,opt,name=send_message,json=sendMessage,proto3" json:"send_message,omitempty"`
+	AddMember           bool                   `protobuf:"varint,13,opt,name=add_member,json=addMember,proto3" json:"add_member,omitempty"`
+	EditGroup           bool                   `protobuf:"varint,14,opt,name=edit_group,json=editGroup,proto3" json:"edit_group,omitempty"`
+	CreatedAt           string                 `protobuf:"bytes,15,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // ISO 8601
+	UpdatedAt           string                 `protobuf:"bytes,16,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // ISO 8601
+	Partner             *RoomParticipant       `protobuf:"bytes,17,opt,name=partner,proto3,oneof" json:"partner,omitempty"`
+	Participants        []*RoomParticipant     `protobuf:"bytes,18,rep,name=participants,proto3" json:"participants,omitempty"`
+	IsPartnerBlocked    bool                   `protobuf:"varint,19,opt,name=is_partner_blocked,json=isPartnerBlocked,proto3" json:"is_partner_blocked,omitempty"`
+	IsMuted             bool                   `protobuf:"varint,20,opt,name=is_muted,json=isMuted,proto3" json:"is_muted,omitempty"`
+	IsPinned            bool                   `protobuf:"varint,21,opt,name=is_pinned,json=isPinned,proto3" json:"is_pinned,omitempty"`
+	LastMessage         *MessageData           `protobuf:"bytes,22,opt,name=last_message,json=lastMessage,proto3" json:"last_message,omitempty"`
+	RetentionDays       *int32                 `protobuf:"varint,23,opt,name=retention_days,json=retentionDays,proto3,oneof" json:"retention_days,omitempty"`              // Días que se conserva el historial; sin valor = política global, 0 = para siempre
+	HistoryPurgedBefore string                 `protobuf:"bytes,24,opt,name=history_purged_before,json=historyPurgedBefore,proto3" json:"history_purged_before,omitempty"` // ISO 8601; los mensajes anteriores fueron eliminados por retención
+	UnreadMentionCount  int32                  `protobuf:"varint,25,opt,name=unread_mention_count,json=unreadMentionCount,proto3" json:"unread_mention_count,omitempty"`   // Mensajes sin leer que mencionan al usuario; solo con los contadores de Redis (Postgres)
+	KeyVersion          int32                  `protobuf:"varint,26,opt,name=key_version,json=keyVersion,proto3" json:"key_version,omitempty"`                             // Versión de encryption_data; las anteriores se piden con GetRoomKeys
+	E2E                 bool                   `protobuf:"varint,27,opt,name=e2e,proto3" json:"e2e,omitempty"`                                                             // Cifrada de extremo a extremo: sin encryption_data, las claves se piden por dispositivo
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
 func (x *Room) Reset() {
@@ -341,6 +532,41 @@ func (x *Room) GetLastMessage() *MessageData {
 	return nil
 }
 
+func (x *Room) GetRetentionDays() int32 {
+	if x != nil && x.RetentionDays != nil {
+		return *x.RetentionDays
+	}
+	return 0
+}
+
+func (x *Room) GetHistoryPurgedBefore() string {
+	if x != nil {
+		return x.HistoryPurgedBefore
+	}
+	return ""
+}
+
+func (x *Room) GetUnreadMentionCount() int32 {
+	if x != nil {
+		return x.UnreadMentionCount
+	}
+	return 0
+}
+
+func (x *Room) GetKeyVersion() int32 {
+	if x != nil {
+		return x.KeyVersion
+	}
+	return 0
+}
+
+func (x *Room) GetE2E() bool {
+	if x != nil {
+		return x.E2E
+	}
+	return false
+}
+
 type RoomParticipant struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	Id               int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -636,6 +862,8 @@ type MessageData struct {
 	Reactions                    []*Reaction            `protobuf:"bytes,31,rep,name=reactions,proto3" json:"reactions,omitempty"`
 	Event                        *string                `protobuf:"bytes,32,opt,name=event,proto3,oneof" json:"event,omitempty"`
 	SenderMessageId              *string                `protobuf:"bytes,33,opt,name=sender_message_id,json=senderMessageId,proto3,oneof" json:"sender_message_id,omitempty"`
+	Seq                          int64                  `protobuf:"varint,34,opt,name=seq,proto3" json:"seq,omitempty"`                                 // Secuencia por sala, sin huecos, asignada al guardar
+	KeyVersion                   int32                  `protobuf:"varint,35,opt,name=key_version,json=keyVersion,proto3" json:"key_version,omitempty"` // Versión de la clave de la sala con la que se cifró el contenido (1 en mensajes anteriores a la rotación)
 	unknownFields                protoimpl.UnknownFields
 	sizeCache                    protoimpl.SizeCache
 }
@@ -901,6 +1129,20 @@ func (x *MessageData) GetSenderMessageId() string {
 	return ""
 }
 
+func (x *MessageData) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *MessageData) GetKeyVersion() int32 {
+	if x != nil {
+		return x.KeyVersion
+	}
+	return 0
+}
+
 type RoomJoinEvent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
@@ -1029,6 +1271,145 @@ func (x *RoomLeaveEvent) GetReason() string {
 	return ""
 }
 
+// Historial eliminado por la política de retención de la sala: los clientes deben borrar
+// de su copia local los mensajes anteriores a before
+type HistoryPurgedEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Before        string                 `protobuf:"bytes,2,opt,name=before,proto3" json:"before,omitempty"` // ISO 8601
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryPurgedEvent) Reset() {
+	*x = HistoryPurgedEvent{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryPurgedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryPurgedEvent) ProtoMessage() {}
+
+func (x *HistoryPurgedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryPurgedEvent.ProtoReflect.Descriptor instead.
+func (*HistoryPurgedEvent) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HistoryPurgedEvent) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *HistoryPurgedEvent) GetBefore() string {
+	if x != nil {
+		return x.Before
+	}
+	return ""
+}
+
+// Estado de lectura de una sala, enviado a todas las sesiones del usuario que leyó
+type ReadStateEvent struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	UserId            int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	RoomId            string                 `protobuf:"bytes,2,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	UnreadCount       int32                  `protobuf:"varint,3,opt,name=unread_count,json=unreadCount,proto3" json:"unread_count,omitempty"`
+	LastReadMessageId string                 `protobuf:"bytes,4,opt,name=last_read_message_id,json=lastReadMessageId,proto3" json:"last_read_message_id,omitempty"` // Marca de lectura (mensaje más reciente leído)
+	LastReadSeq       int64                  `protobuf:"varint,5,opt,name=last_read_seq,json=lastReadSeq,proto3" json:"last_read_seq,omitempty"`
+	ReadAt            string                 `protobuf:"bytes,6,opt,name=read_at,json=readAt,proto3" json:"read_at,omitempty"` // ISO 8601
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ReadStateEvent) Reset() {
+	*x = ReadStateEvent{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadStateEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadStateEvent) ProtoMessage() {}
+
+func (x *ReadStateEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadStateEvent.ProtoReflect.Descriptor instead.
+func (*ReadStateEvent) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReadStateEvent) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ReadStateEvent) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *ReadStateEvent) GetUnreadCount() int32 {
+	if x != nil {
+		return x.UnreadCount
+	}
+	return 0
+}
+
+func (x *ReadStateEvent) GetLastReadMessageId() string {
+	if x != nil {
+		return x.LastReadMessageId
+	}
+	return ""
+}
+
+func (x *ReadStateEvent) GetLastReadSeq() int64 {
+	if x != nil {
+		return x.LastReadSeq
+	}
+	return 0
+}
+
+func (x *ReadStateEvent) GetReadAt() string {
+	if x != nil {
+		return x.ReadAt
+	}
+	return ""
+}
+
 type TypingEvent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
@@ -1040,7 +1421,7 @@ type TypingEvent struct {
 
 func (x *TypingEvent) Reset() {
 	*x = TypingEvent{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[7]
+	mi := &file_services_chat_v1_types_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1052,7 +1433,7 @@ func (x *TypingEvent) String() string {
 func (*TypingEvent) ProtoMessage() {}
 
 func (x *TypingEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[7]
+	mi := &file_services_chat_v1_types_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1065,7 +1446,7 @@ func (x *TypingEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TypingEvent.ProtoReflect.Descriptor instead.
 func (*TypingEvent) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{7}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *TypingEvent) GetUserId() int32 {
@@ -1102,7 +1483,7 @@ type MessageStatusUpdate struct {
 
 func (x *MessageStatusUpdate) Reset() {
 	*x = MessageStatusUpdate{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[8]
+	mi := &file_services_chat_v1_types_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1114,7 +1495,7 @@ func (x *MessageStatusUpdate) String() string {
 func (*MessageStatusUpdate) ProtoMessage() {}
 
 func (x *MessageStatusUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[8]
+	mi := &file_services_chat_v1_types_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1127,7 +1508,7 @@ func (x *MessageStatusUpdate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MessageStatusUpdate.ProtoReflect.Descriptor instead.
 func (*MessageStatusUpdate) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{8}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *MessageStatusUpdate) GetMessageId() string {
@@ -1176,7 +1557,7 @@ type ErrorEvent struct {
 
 func (x *ErrorEvent) Reset() {
 	*x = ErrorEvent{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[9]
+	mi := &file_services_chat_v1_types_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1188,7 +1569,7 @@ func (x *ErrorEvent) String() string {
 func (*ErrorEvent) ProtoMessage() {}
 
 func (x *ErrorEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[9]
+	mi := &file_services_chat_v1_types_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1201,7 +1582,7 @@ func (x *ErrorEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ErrorEvent.ProtoReflect.Descriptor instead.
 func (*ErrorEvent) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{9}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ErrorEvent) GetCode() string {
@@ -1243,6 +1624,8 @@ type MessageEvent struct {
 	//	*MessageEvent_UpdateMessage
 	//	*MessageEvent_DeleteMessage
 	//	*MessageEvent_Connected
+	//	*MessageEvent_ReadState
+	//	*MessageEvent_HistoryPurged
 	Event         isMessageEvent_Event `protobuf_oneof:"event"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -1250,7 +1633,7 @@ type MessageEvent struct {
 
 func (x *MessageEvent) Reset() {
 	*x = MessageEvent{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[10]
+	mi := &file_services_chat_v1_types_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1262,7 +1645,7 @@ func (x *MessageEvent) String() string {
 func (*MessageEvent) ProtoMessage() {}
 
 func (x *MessageEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[10]
+	mi := &file_services_chat_v1_types_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1275,7 +1658,7 @@ func (x *MessageEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MessageEvent.ProtoReflect.Descriptor instead.
 func (*MessageEvent) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{10}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *MessageEvent) GetRoom() *Room {
@@ -1396,6 +1779,24 @@ func (x *MessageEvent) GetConnected() bool {
 	return false
 }
 
+func (x *MessageEvent) GetReadState() *ReadStateEvent {
+	if x != nil {
+		if x, ok := x.Event.(*MessageEvent_ReadState); ok {
+			return x.ReadState
+		}
+	}
+	return nil
+}
+
+func (x *MessageEvent) GetHistoryPurged() *HistoryPurgedEvent {
+	if x != nil {
+		if x, ok := x.Event.(*MessageEvent_HistoryPurged); ok {
+			return x.HistoryPurged
+		}
+	}
+	return nil
+}
+
 type isMessageEvent_Event interface {
 	isMessageEvent_Event()
 }
@@ -1447,6 +1848,16 @@ type MessageEvent_Connected struct {
 	Connected bool `protobuf:"varint,12,opt,name=connected,proto3,oneof"`
 }
 
+type MessageEvent_ReadState struct {
+	// Evento de sincronización del estado de lectura entre dispositivos
+	ReadState *ReadStateEvent `protobuf:"bytes,13,opt,name=read_state,json=readState,proto3,oneof"`
+}
+
+type MessageEvent_HistoryPurged struct {
+	// Evento de purga del historial por retención
+	HistoryPurged *HistoryPurgedEvent `protobuf:"bytes,14,opt,name=history_purged,json=historyPurged,proto3,oneof"`
+}
+
 func (*MessageEvent_Message) isMessageEvent_Event() {}
 
 func (*MessageEvent_StatusUpdate) isMessageEvent_Event() {}
@@ -1467,7 +1878,11 @@ func (*MessageEvent_DeleteMessage) isMessageEvent_Event() {}
 
 func (*MessageEvent_Connected) isMessageEvent_Event() {}
 
-type CreateMention struct {
+func (*MessageEvent_ReadState) isMessageEvent_Event() {}
+
+func (*MessageEvent_HistoryPurged) isMessageEvent_Event() {}
+
+type CreateMention struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Tag           string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
 	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
@@ -1477,7 +1892,7 @@ type CreateMention struct {
 
 func (x *CreateMention) Reset() {
 	*x = CreateMention{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[11]
+	mi := &file_services_chat_v1_types_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1489,7 +1904,7 @@ func (x *CreateMention) String() string {
 func (*CreateMention) ProtoMessage() {}
 
 func (x *CreateMention) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[11]
+	mi := &file_services_chat_v1_types_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1502,7 +1917,7 @@ func (x *CreateMention) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateMention.ProtoReflect.Descriptor instead.
 func (*CreateMention) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{11}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *CreateMention) GetTag() string {
@@ -1537,13 +1952,14 @@ type SendMessageRequest struct {
 	ForwardId         *string                `protobuf:"bytes,14,opt,name=forward_id,json=forwardId,proto3,oneof" json:"forward_id,omitempty"`
 	Event             *string                `protobuf:"bytes,15,opt,name=event,proto3,oneof" json:"event,omitempty"`
 	SenderMessageId   *string                `protobuf:"bytes,16,opt,name=sender_message_id,json=senderMessageId,proto3,oneof" json:"sender_message_id,omitempty"`
+	KeyVersion        *int32                 `protobuf:"varint,17,opt,name=key_version,json=keyVersion,proto3,oneof" json:"key_version,omitempty"` // Versión de la clave con la que se cifró content; sin valor, la actual
 	unknownFields     protoimpl.UnknownFields
 	sizeCache         protoimpl.SizeCache
 }
 
 func (x *SendMessageRequest) Reset() {
 	*x = SendMessageRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[12]
+	mi := &file_services_chat_v1_types_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1555,7 +1971,7 @@ func (x *SendMessageRequest) String() string {
 func (*SendMessageRequest) ProtoMessage() {}
 
 func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[12]
+	mi := &file_services_chat_v1_types_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1568,7 +1984,7 @@ func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SendMessageRequest.ProtoReflect.Descriptor instead.
 func (*SendMessageRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{12}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *SendMessageRequest) GetRoomId() string {
@@ -1683,6 +2099,13 @@ func (x *SendMessageRequest) GetSenderMessageId() string {
 	return ""
 }
 
+func (x *SendMessageRequest) GetKeyVersion() int32 {
+	if x != nil && x.KeyVersion != nil {
+		return *x.KeyVersion
+	}
+	return 0
+}
+
 type SendMessageResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       *MessageData           `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
@@ -1694,7 +2117,7 @@ type SendMessageResponse struct {
 
 func (x *SendMessageResponse) Reset() {
 	*x = SendMessageResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[13]
+	mi := &file_services_chat_v1_types_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1706,7 +2129,7 @@ func (x *SendMessageResponse) String() string {
 func (*SendMessageResponse) ProtoMessage() {}
 
 func (x *SendMessageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[13]
+	mi := &file_services_chat_v1_types_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1719,7 +2142,7 @@ func (x *SendMessageResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SendMessageResponse.ProtoReflect.Descriptor instead.
 func (*SendMessageResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{13}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *SendMessageResponse) GetMessage() *MessageData {
@@ -1747,13 +2170,14 @@ type EditMessageRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
 	NewContent    string                 `protobuf:"bytes,2,opt,name=new_content,json=newContent,proto3" json:"new_content,omitempty"`
+	KeyVersion    *int32                 `protobuf:"varint,3,opt,name=key_version,json=keyVersion,proto3,oneof" json:"key_version,omitempty"` // Versión de la clave con la que se cifró new_content; sin valor, la actual
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *EditMessageRequest) Reset() {
 	*x = EditMessageRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[14]
+	mi := &file_services_chat_v1_types_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1765,7 +2189,7 @@ func (x *EditMessageRequest) String() string {
 func (*EditMessageRequest) ProtoMessage() {}
 
 func (x *EditMessageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[14]
+	mi := &file_services_chat_v1_types_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1778,7 +2202,7 @@ func (x *EditMessageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EditMessageRequest.ProtoReflect.Descriptor instead.
 func (*EditMessageRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{14}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *EditMessageRequest) GetMessageId() string {
@@ -1795,6 +2219,13 @@ func (x *EditMessageRequest) GetNewContent() string {
 	return ""
 }
 
+func (x *EditMessageRequest) GetKeyVersion() int32 {
+	if x != nil && x.KeyVersion != nil {
+		return *x.KeyVersion
+	}
+	return 0
+}
+
 type EditMessageResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       *MessageData           `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
@@ -1806,7 +2237,7 @@ type EditMessageResponse struct {
 
 func (x *EditMessageResponse) Reset() {
 	*x = EditMessageResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[15]
+	mi := &file_services_chat_v1_types_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1818,7 +2249,7 @@ func (x *EditMessageResponse) String() string {
 func (*EditMessageResponse) ProtoMessage() {}
 
 func (x *EditMessageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[15]
+	mi := &file_services_chat_v1_types_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1831,7 +2262,7 @@ func (x *EditMessageResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EditMessageResponse.ProtoReflect.Descriptor instead.
 func (*EditMessageResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{15}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *EditMessageResponse) GetMessage() *MessageData {
@@ -1865,7 +2296,7 @@ type DeleteMessageRequest struct {
 
 func (x *DeleteMessageRequest) Reset() {
 	*x = DeleteMessageRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[16]
+	mi := &file_services_chat_v1_types_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1877,7 +2308,7 @@ func (x *DeleteMessageRequest) String() string {
 func (*DeleteMessageRequest) ProtoMessage() {}
 
 func (x *DeleteMessageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[16]
+	mi := &file_services_chat_v1_types_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1890,7 +2321,7 @@ func (x *DeleteMessageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteMessageRequest.ProtoReflect.Descriptor instead.
 func (*DeleteMessageRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{16}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *DeleteMessageRequest) GetRoomId() string {
@@ -1917,7 +2348,7 @@ type DeleteMessageResponse struct {
 
 func (x *DeleteMessageResponse) Reset() {
 	*x = DeleteMessageResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[17]
+	mi := &file_services_chat_v1_types_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1929,7 +2360,7 @@ func (x *DeleteMessageResponse) String() string {
 func (*DeleteMessageResponse) ProtoMessage() {}
 
 func (x *DeleteMessageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[17]
+	mi := &file_services_chat_v1_types_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1942,7 +2373,7 @@ func (x *DeleteMessageResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteMessageResponse.ProtoReflect.Descriptor instead.
 func (*DeleteMessageResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{17}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *DeleteMessageResponse) GetSuccess() bool {
@@ -1969,7 +2400,7 @@ type MarkMessagesAsReadRequest struct {
 
 func (x *MarkMessagesAsReadRequest) Reset() {
 	*x = MarkMessagesAsReadRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[18]
+	mi := &file_services_chat_v1_types_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1981,7 +2412,7 @@ func (x *MarkMessagesAsReadRequest) String() string {
 func (*MarkMessagesAsReadRequest) ProtoMessage() {}
 
 func (x *MarkMessagesAsReadRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[18]
+	mi := &file_services_chat_v1_types_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1994,7 +2425,7 @@ func (x *MarkMessagesAsReadRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MarkMessagesAsReadRequest.ProtoReflect.Descriptor instead.
 func (*MarkMessagesAsReadRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{18}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *MarkMessagesAsReadRequest) GetRoomId() string {
@@ -2022,7 +2453,7 @@ type MarkMessagesAsReadResponse struct {
 
 func (x *MarkMessagesAsReadResponse) Reset() {
 	*x = MarkMessagesAsReadResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[19]
+	mi := &file_services_chat_v1_types_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2034,7 +2465,7 @@ func (x *MarkMessagesAsReadResponse) String() string {
 func (*MarkMessagesAsReadResponse) ProtoMessage() {}
 
 func (x *MarkMessagesAsReadResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[19]
+	mi := &file_services_chat_v1_types_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2047,7 +2478,7 @@ func (x *MarkMessagesAsReadResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MarkMessagesAsReadResponse.ProtoReflect.Descriptor instead.
 func (*MarkMessagesAsReadResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{19}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *MarkMessagesAsReadResponse) GetSuccess() bool {
@@ -2081,13 +2512,16 @@ type GetMessageHistoryRequest struct {
 	AfterMessageId  *string                `protobuf:"bytes,6,opt,name=after_message_id,json=afterMessageId,proto3,oneof" json:"after_message_id,omitempty"`    // Para paginación
 	AfterDate       *string                `protobuf:"bytes,7,opt,name=after_date,json=afterDate,proto3,oneof" json:"after_date,omitempty"`                     // ISO 8601
 	MessagesPerRoom uint32                 `protobuf:"varint,8,opt,name=messages_per_room,json=messagesPerRoom,proto3" json:"messages_per_room,omitempty"`      // Máximo 100 mensajes por room
+	AfterSeq        *int64                 `protobuf:"varint,9,opt,name=after_seq,json=afterSeq,proto3,oneof" json:"after_seq,omitempty"`                       // Mensajes con seq mayor (orden ascendente)
+	BeforeSeq       *int64                 `protobuf:"varint,10,opt,name=before_seq,json=beforeSeq,proto3,oneof" json:"before_seq,omitempty"`                   // Mensajes con seq menor (orden descendente)
+	Cursor          string                 `protobuf:"bytes,11,opt,name=cursor,proto3" json:"cursor,omitempty"`                                                 // meta.next_cursor de la respuesta anterior; si se indica, page se ignora
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
 
 func (x *GetMessageHistoryRequest) Reset() {
 	*x = GetMessageHistoryRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[20]
+	mi := &file_services_chat_v1_types_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2099,7 +2533,7 @@ func (x *GetMessageHistoryRequest) String() string {
 func (*GetMessageHistoryRequest) ProtoMessage() {}
 
 func (x *GetMessageHistoryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[20]
+	mi := &file_services_chat_v1_types_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2112,7 +2546,7 @@ func (x *GetMessageHistoryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMessageHistoryRequest.ProtoReflect.Descriptor instead.
 func (*GetMessageHistoryRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{20}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *GetMessageHistoryRequest) GetId() string {
@@ -2171,6 +2605,27 @@ func (x *GetMessageHistoryRequest) GetMessagesPerRoom() uint32 {
 	return 0
 }
 
+func (x *GetMessageHistoryRequest) GetAfterSeq() int64 {
+	if x != nil && x.AfterSeq != nil {
+		return *x.AfterSeq
+	}
+	return 0
+}
+
+func (x *GetMessageHistoryRequest) GetBeforeSeq() int64 {
+	if x != nil && x.BeforeSeq != nil {
+		return *x.BeforeSeq
+	}
+	return 0
+}
+
+func (x *GetMessageHistoryRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
 type GetMessageHistoryResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Items         []*MessageData         `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
@@ -2181,7 +2636,7 @@ type GetMessageHistoryResponse struct {
 
 func (x *GetMessageHistoryResponse) Reset() {
 	*x = GetMessageHistoryResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[21]
+	mi := &file_services_chat_v1_types_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2193,7 +2648,7 @@ func (x *GetMessageHistoryResponse) String() string {
 func (*GetMessageHistoryResponse) ProtoMessage() {}
 
 func (x *GetMessageHistoryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[21]
+	mi := &file_services_chat_v1_types_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2206,7 +2661,7 @@ func (x *GetMessageHistoryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMessageHistoryResponse.ProtoReflect.Descriptor instead.
 func (*GetMessageHistoryResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{21}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *GetMessageHistoryResponse) GetItems() []*MessageData {
@@ -2231,13 +2686,14 @@ type GetRoomsRequest struct {
 	Search        string                 `protobuf:"bytes,3,opt,name=search,proto3" json:"search,omitempty"`
 	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
 	Since         string                 `protobuf:"bytes,5,opt,name=since,proto3" json:"since,omitempty"`
+	Cursor        string                 `protobuf:"bytes,6,opt,name=cursor,proto3" json:"cursor,omitempty"` // meta.next_cursor de la respuesta anterior; si se indica, page se ignora
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetRoomsRequest) Reset() {
 	*x = GetRoomsRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[22]
+	mi := &file_services_chat_v1_types_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2249,7 +2705,7 @@ func (x *GetRoomsRequest) String() string {
 func (*GetRoomsRequest) ProtoMessage() {}
 
 func (x *GetRoomsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[22]
+	mi := &file_services_chat_v1_types_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2262,7 +2718,7 @@ func (x *GetRoomsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRoomsRequest.ProtoReflect.Descriptor instead.
 func (*GetRoomsRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{22}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *GetRoomsRequest) GetPage() uint32 {
@@ -2300,6 +2756,13 @@ func (x *GetRoomsRequest) GetSince() string {
 	return ""
 }
 
+func (x *GetRoomsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
 // Response para obtener rooms
 type GetRoomsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -2311,7 +2774,7 @@ type GetRoomsResponse struct {
 
 func (x *GetRoomsResponse) Reset() {
 	*x = GetRoomsResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[23]
+	mi := &file_services_chat_v1_types_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2323,7 +2786,7 @@ func (x *GetRoomsResponse) String() string {
 func (*GetRoomsResponse) ProtoMessage() {}
 
 func (x *GetRoomsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[23]
+	mi := &file_services_chat_v1_types_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2336,7 +2799,7 @@ func (x *GetRoomsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRoomsResponse.ProtoReflect.Descriptor instead.
 func (*GetRoomsResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{23}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *GetRoomsResponse) GetItems() []*Room {
@@ -2366,7 +2829,7 @@ type InitialSyncRequest struct {
 
 func (x *InitialSyncRequest) Reset() {
 	*x = InitialSyncRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[24]
+	mi := &file_services_chat_v1_types_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2378,7 +2841,7 @@ func (x *InitialSyncRequest) String() string {
 func (*InitialSyncRequest) ProtoMessage() {}
 
 func (x *InitialSyncRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[24]
+	mi := &file_services_chat_v1_types_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2391,7 +2854,7 @@ func (x *InitialSyncRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InitialSyncRequest.ProtoReflect.Descriptor instead.
 func (*InitialSyncRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{24}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *InitialSyncRequest) GetLastSyncTimestamp() string {
@@ -2436,7 +2899,7 @@ type InitialSyncResponse struct {
 
 func (x *InitialSyncResponse) Reset() {
 	*x = InitialSyncResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[25]
+	mi := &file_services_chat_v1_types_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2448,7 +2911,7 @@ func (x *InitialSyncResponse) String() string {
 func (*InitialSyncResponse) ProtoMessage() {}
 
 func (x *InitialSyncResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[25]
+	mi := &file_services_chat_v1_types_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2461,7 +2924,7 @@ func (x *InitialSyncResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InitialSyncResponse.ProtoReflect.Descriptor instead.
 func (*InitialSyncResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{25}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *InitialSyncResponse) GetRooms() []*Room {
@@ -2512,7 +2975,7 @@ type RoomWithMessages struct {
 
 func (x *RoomWithMessages) Reset() {
 	*x = RoomWithMessages{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[26]
+	mi := &file_services_chat_v1_types_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2524,7 +2987,7 @@ func (x *RoomWithMessages) String() string {
 func (*RoomWithMessages) ProtoMessage() {}
 
 func (x *RoomWithMessages) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[26]
+	mi := &file_services_chat_v1_types_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2537,7 +3000,7 @@ func (x *RoomWithMessages) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RoomWithMessages.ProtoReflect.Descriptor instead.
 func (*RoomWithMessages) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{26}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *RoomWithMessages) GetRoom() *Room {
@@ -2581,7 +3044,7 @@ type SyncSummary struct {
 
 func (x *SyncSummary) Reset() {
 	*x = SyncSummary{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[27]
+	mi := &file_services_chat_v1_types_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2593,7 +3056,7 @@ func (x *SyncSummary) String() string {
 func (*SyncSummary) ProtoMessage() {}
 
 func (x *SyncSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[27]
+	mi := &file_services_chat_v1_types_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2606,7 +3069,7 @@ func (x *SyncSummary) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SyncSummary.ProtoReflect.Descriptor instead.
 func (*SyncSummary) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{27}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *SyncSummary) GetRoomsSynced() int32 {
@@ -2638,19 +3101,22 @@ func (x *SyncSummary) GetSyncDurationMs() string {
 }
 
 type PaginationMeta struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TotalItems    uint32                 `protobuf:"varint,1,opt,name=total_items,json=totalItems,proto3" json:"total_items,omitempty"`
-	ItemCount     uint32                 `protobuf:"varint,2,opt,name=item_count,json=itemCount,proto3" json:"item_count,omitempty"`
-	ItemsPerPage  uint32                 `protobuf:"varint,3,opt,name=items_per_page,json=itemsPerPage,proto3" json:"items_per_page,omitempty"`
-	TotalPages    uint32                 `protobuf:"varint,4,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
-	CurrentPage   uint32                 `protobuf:"varint,5,opt,name=current_page,json=currentPage,proto3" json:"current_page,omitempty"`
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	TotalItems   uint32                 `protobuf:"varint,1,opt,name=total_items,json=totalItems,proto3" json:"total_items,omitempty"`
+	ItemCount    uint32                 `protobuf:"varint,2,opt,name=item_count,json=itemCount,proto3" json:"item_count,omitempty"`
+	ItemsPerPage uint32                 `protobuf:"varint,3,opt,name=items_per_page,json=itemsPerPage,proto3" json:"items_per_page,omitempty"`
+	TotalPages   uint32                 `protobuf:"varint,4,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
+	CurrentPage  uint32                 `protobuf:"varint,5,opt,name=current_page,json=currentPage,proto3" json:"current_page,omitempty"`
+	// Cursor opaco para pedir la página siguiente; vacío cuando no hay más elementos.
+	// Con cursor el orden es estable aunque lleguen elementos nuevos entre páginas.
+	NextCursor    string `protobuf:"bytes,6,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PaginationMeta) Reset() {
 	*x = PaginationMeta{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[28]
+	mi := &file_services_chat_v1_types_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2662,7 +3128,7 @@ func (x *PaginationMeta) String() string {
 func (*PaginationMeta) ProtoMessage() {}
 
 func (x *PaginationMeta) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[28]
+	mi := &file_services_chat_v1_types_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2675,7 +3141,7 @@ func (x *PaginationMeta) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PaginationMeta.ProtoReflect.Descriptor instead.
 func (*PaginationMeta) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{28}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *PaginationMeta) GetTotalItems() uint32 {
@@ -2713,16 +3179,27 @@ func (x *PaginationMeta) GetCurrentPage() uint32 {
 	return 0
 }
 
+func (x *PaginationMeta) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
 type StreamMessagesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	RoomId        *string                `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3,oneof" json:"room_id,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	RoomId *string                `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3,oneof" json:"room_id,omitempty"`
+	// Salas a escuchar. Vacío = todas las salas del usuario
+	RoomIds []string `protobuf:"bytes,2,rep,name=room_ids,json=roomIds,proto3" json:"room_ids,omitempty"`
+	// Tipos de eventos a recibir. Vacío = todos los eventos
+	EventTypes    []StreamEventType `protobuf:"varint,3,rep,packed,name=event_types,json=eventTypes,proto3,enum=services.chat.v1.StreamEventType" json:"event_types,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *StreamMessagesRequest) Reset() {
 	*x = StreamMessagesRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[29]
+	mi := &file_services_chat_v1_types_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2734,7 +3211,7 @@ func (x *StreamMessagesRequest) String() string {
 func (*StreamMessagesRequest) ProtoMessage() {}
 
 func (x *StreamMessagesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[29]
+	mi := &file_services_chat_v1_types_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2747,7 +3224,7 @@ func (x *StreamMessagesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StreamMessagesRequest.ProtoReflect.Descriptor instead.
 func (*StreamMessagesRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{29}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *StreamMessagesRequest) GetRoomId() string {
@@ -2757,6 +3234,119 @@ func (x *StreamMessagesRequest) GetRoomId() string {
 	return ""
 }
 
+func (x *StreamMessagesRequest) GetRoomIds() []string {
+	if x != nil {
+		return x.RoomIds
+	}
+	return nil
+}
+
+func (x *StreamMessagesRequest) GetEventTypes() []StreamEventType {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+// Reemplaza el filtro del stream activo del cliente (client_id de la sesión)
+type UpdateStreamSubscriptionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Salas a escuchar. Vacío = todas las salas del usuario
+	RoomIds []string `protobuf:"bytes,1,rep,name=room_ids,json=roomIds,proto3" json:"room_ids,omitempty"`
+	// Tipos de eventos a recibir. Vacío = todos los eventos
+	EventTypes    []StreamEventType `protobuf:"varint,2,rep,packed,name=event_types,json=eventTypes,proto3,enum=services.chat.v1.StreamEventType" json:"event_types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateStreamSubscriptionRequest) Reset() {
+	*x = UpdateStreamSubscriptionRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStreamSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStreamSubscriptionRequest) ProtoMessage() {}
+
+func (x *UpdateStreamSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStreamSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*UpdateStreamSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *UpdateStreamSubscriptionRequest) GetRoomIds() []string {
+	if x != nil {
+		return x.RoomIds
+	}
+	return nil
+}
+
+func (x *UpdateStreamSubscriptionRequest) GetEventTypes() []StreamEventType {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+type UpdateStreamSubscriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateStreamSubscriptionResponse) Reset() {
+	*x = UpdateStreamSubscriptionResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStreamSubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStreamSubscriptionResponse) ProtoMessage() {}
+
+func (x *UpdateStreamSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStreamSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*UpdateStreamSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *UpdateStreamSubscriptionResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
 type CreateRoomRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
@@ -2767,13 +3357,14 @@ type CreateRoomRequest struct {
 	AddMember     *bool                  `protobuf:"varint,7,opt,name=add_member,json=addMember,proto3,oneof" json:"add_member,omitempty"`
 	EditGroup     *bool                  `protobuf:"varint,8,opt,name=edit_group,json=editGroup,proto3,oneof" json:"edit_group,omitempty"`
 	Participants  []int32                `protobuf:"varint,10,rep,packed,name=participants,proto3" json:"participants,omitempty"`
+	E2E           *bool                  `protobuf:"varint,11,opt,name=e2e,proto3,oneof" json:"e2e,omitempty"` // Sala cifrada de extremo a extremo; el servidor no guarda su clave
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateRoomRequest) Reset() {
 	*x = CreateRoomRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[30]
+	mi := &file_services_chat_v1_types_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2785,7 +3376,7 @@ func (x *CreateRoomRequest) String() string {
 func (*CreateRoomRequest) ProtoMessage() {}
 
 func (x *CreateRoomRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[30]
+	mi := &file_services_chat_v1_types_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2798,7 +3389,7 @@ func (x *CreateRoomRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateRoomRequest.ProtoReflect.Descriptor instead.
 func (*CreateRoomRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{30}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *CreateRoomRequest) GetType() string {
@@ -2857,6 +3448,13 @@ func (x *CreateRoomRequest) GetParticipants() []int32 {
 	return nil
 }
 
+func (x *CreateRoomRequest) GetE2E() bool {
+	if x != nil && x.E2E != nil {
+		return *x.E2E
+	}
+	return false
+}
+
 type CreateRoomResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -2868,7 +3466,7 @@ type CreateRoomResponse struct {
 
 func (x *CreateRoomResponse) Reset() {
 	*x = CreateRoomResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[31]
+	mi := &file_services_chat_v1_types_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2880,7 +3478,7 @@ func (x *CreateRoomResponse) String() string {
 func (*CreateRoomResponse) ProtoMessage() {}
 
 func (x *CreateRoomResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[31]
+	mi := &file_services_chat_v1_types_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2893,7 +3491,7 @@ func (x *CreateRoomResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateRoomResponse.ProtoReflect.Descriptor instead.
 func (*CreateRoomResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{31}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *CreateRoomResponse) GetSuccess() bool {
@@ -2926,7 +3524,7 @@ type PinRoomRequest struct {
 
 func (x *PinRoomRequest) Reset() {
 	*x = PinRoomRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[32]
+	mi := &file_services_chat_v1_types_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2938,7 +3536,7 @@ func (x *PinRoomRequest) String() string {
 func (*PinRoomRequest) ProtoMessage() {}
 
 func (x *PinRoomRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[32]
+	mi := &file_services_chat_v1_types_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2951,7 +3549,7 @@ func (x *PinRoomRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PinRoomRequest.ProtoReflect.Descriptor instead.
 func (*PinRoomRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{32}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *PinRoomRequest) GetId() string {
@@ -2971,7 +3569,7 @@ type PinRoomResponse struct {
 
 func (x *PinRoomResponse) Reset() {
 	*x = PinRoomResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[33]
+	mi := &file_services_chat_v1_types_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2983,7 +3581,7 @@ func (x *PinRoomResponse) String() string {
 func (*PinRoomResponse) ProtoMessage() {}
 
 func (x *PinRoomResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[33]
+	mi := &file_services_chat_v1_types_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2996,7 +3594,7 @@ func (x *PinRoomResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PinRoomResponse.ProtoReflect.Descriptor instead.
 func (*PinRoomResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{33}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *PinRoomResponse) GetSuccess() bool {
@@ -3022,7 +3620,7 @@ type MuteRoomRequest struct {
 
 func (x *MuteRoomRequest) Reset() {
 	*x = MuteRoomRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[34]
+	mi := &file_services_chat_v1_types_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3034,7 +3632,7 @@ func (x *MuteRoomRequest) String() string {
 func (*MuteRoomRequest) ProtoMessage() {}
 
 func (x *MuteRoomRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[34]
+	mi := &file_services_chat_v1_types_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3047,7 +3645,7 @@ func (x *MuteRoomRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MuteRoomRequest.ProtoReflect.Descriptor instead.
 func (*MuteRoomRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{34}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{38}
 }
 
 func (x *MuteRoomRequest) GetId() string {
@@ -3067,7 +3665,7 @@ type MuteRoomResponse struct {
 
 func (x *MuteRoomResponse) Reset() {
 	*x = MuteRoomResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[35]
+	mi := &file_services_chat_v1_types_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3079,7 +3677,7 @@ func (x *MuteRoomResponse) String() string {
 func (*MuteRoomResponse) ProtoMessage() {}
 
 func (x *MuteRoomResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[35]
+	mi := &file_services_chat_v1_types_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3092,7 +3690,7 @@ func (x *MuteRoomResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MuteRoomResponse.ProtoReflect.Descriptor instead.
 func (*MuteRoomResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{35}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{39}
 }
 
 func (x *MuteRoomResponse) GetSuccess() bool {
@@ -3119,7 +3717,7 @@ type JoinRoomRequest struct {
 
 func (x *JoinRoomRequest) Reset() {
 	*x = JoinRoomRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[36]
+	mi := &file_services_chat_v1_types_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3131,7 +3729,7 @@ func (x *JoinRoomRequest) String() string {
 func (*JoinRoomRequest) ProtoMessage() {}
 
 func (x *JoinRoomRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[36]
+	mi := &file_services_chat_v1_types_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3144,7 +3742,7 @@ func (x *JoinRoomRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use JoinRoomRequest.ProtoReflect.Descriptor instead.
 func (*JoinRoomRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{36}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{40}
 }
 
 func (x *JoinRoomRequest) GetId() string {
@@ -3172,7 +3770,7 @@ type JoinRoomResponse struct {
 
 func (x *JoinRoomResponse) Reset() {
 	*x = JoinRoomResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[37]
+	mi := &file_services_chat_v1_types_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3184,7 +3782,7 @@ func (x *JoinRoomResponse) String() string {
 func (*JoinRoomResponse) ProtoMessage() {}
 
 func (x *JoinRoomResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[37]
+	mi := &file_services_chat_v1_types_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3197,7 +3795,7 @@ func (x *JoinRoomResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use JoinRoomResponse.ProtoReflect.Descriptor instead.
 func (*JoinRoomResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{37}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *JoinRoomResponse) GetSuccess() bool {
@@ -3232,7 +3830,7 @@ type LeaveRoomRequest struct {
 
 func (x *LeaveRoomRequest) Reset() {
 	*x = LeaveRoomRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[38]
+	mi := &file_services_chat_v1_types_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3244,7 +3842,7 @@ func (x *LeaveRoomRequest) String() string {
 func (*LeaveRoomRequest) ProtoMessage() {}
 
 func (x *LeaveRoomRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[38]
+	mi := &file_services_chat_v1_types_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3257,7 +3855,7 @@ func (x *LeaveRoomRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LeaveRoomRequest.ProtoReflect.Descriptor instead.
 func (*LeaveRoomRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{38}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{42}
 }
 
 func (x *LeaveRoomRequest) GetId() string {
@@ -3291,7 +3889,7 @@ type LeaveRoomResponse struct {
 
 func (x *LeaveRoomResponse) Reset() {
 	*x = LeaveRoomResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[39]
+	mi := &file_services_chat_v1_types_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3303,7 +3901,7 @@ func (x *LeaveRoomResponse) String() string {
 func (*LeaveRoomResponse) ProtoMessage() {}
 
 func (x *LeaveRoomResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[39]
+	mi := &file_services_chat_v1_types_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3316,7 +3914,7 @@ func (x *LeaveRoomResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LeaveRoomResponse.ProtoReflect.Descriptor instead.
 func (*LeaveRoomResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{39}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{43}
 }
 
 func (x *LeaveRoomResponse) GetSuccess() bool {
@@ -3342,7 +3940,7 @@ type GetRoomRequest struct {
 
 func (x *GetRoomRequest) Reset() {
 	*x = GetRoomRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[40]
+	mi := &file_services_chat_v1_types_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3354,7 +3952,7 @@ func (x *GetRoomRequest) String() string {
 func (*GetRoomRequest) ProtoMessage() {}
 
 func (x *GetRoomRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[40]
+	mi := &file_services_chat_v1_types_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3367,7 +3965,7 @@ func (x *GetRoomRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRoomRequest.ProtoReflect.Descriptor instead.
 func (*GetRoomRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{40}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *GetRoomRequest) GetId() string {
@@ -3388,7 +3986,7 @@ type GetRoomResponse struct {
 
 func (x *GetRoomResponse) Reset() {
 	*x = GetRoomResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[41]
+	mi := &file_services_chat_v1_types_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3400,7 +3998,7 @@ func (x *GetRoomResponse) String() string {
 func (*GetRoomResponse) ProtoMessage() {}
 
 func (x *GetRoomResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[41]
+	mi := &file_services_chat_v1_types_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3413,7 +4011,7 @@ func (x *GetRoomResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRoomResponse.ProtoReflect.Descriptor instead.
 func (*GetRoomResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{41}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{45}
 }
 
 func (x *GetRoomResponse) GetSuccess() bool {
@@ -3443,13 +4041,14 @@ type GetRoomParticipantsRequest struct {
 	Page          uint32                 `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
 	Limit         uint32                 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
 	Search        string                 `protobuf:"bytes,4,opt,name=search,proto3" json:"search,omitempty"`
+	Cursor        string                 `protobuf:"bytes,5,opt,name=cursor,proto3" json:"cursor,omitempty"` // meta.next_cursor de la respuesta anterior; si se indica, page se ignora
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetRoomParticipantsRequest) Reset() {
 	*x = GetRoomParticipantsRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[42]
+	mi := &file_services_chat_v1_types_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3461,7 +4060,7 @@ func (x *GetRoomParticipantsRequest) String() string {
 func (*GetRoomParticipantsRequest) ProtoMessage() {}
 
 func (x *GetRoomParticipantsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[42]
+	mi := &file_services_chat_v1_types_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3474,7 +4073,7 @@ func (x *GetRoomParticipantsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRoomParticipantsRequest.ProtoReflect.Descriptor instead.
 func (*GetRoomParticipantsRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{42}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{46}
 }
 
 func (x *GetRoomParticipantsRequest) GetId() string {
@@ -3505,6 +4104,13 @@ func (x *GetRoomParticipantsRequest) GetSearch() string {
 	return ""
 }
 
+func (x *GetRoomParticipantsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
 type GetRoomParticipantsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Participants  []*RoomParticipant     `protobuf:"bytes,1,rep,name=participants,proto3" json:"participants,omitempty"`
@@ -3515,7 +4121,7 @@ type GetRoomParticipantsResponse struct {
 
 func (x *GetRoomParticipantsResponse) Reset() {
 	*x = GetRoomParticipantsResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[43]
+	mi := &file_services_chat_v1_types_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3527,7 +4133,7 @@ func (x *GetRoomParticipantsResponse) String() string {
 func (*GetRoomParticipantsResponse) ProtoMessage() {}
 
 func (x *GetRoomParticipantsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[43]
+	mi := &file_services_chat_v1_types_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3540,7 +4146,7 @@ func (x *GetRoomParticipantsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRoomParticipantsResponse.ProtoReflect.Descriptor instead.
 func (*GetRoomParticipantsResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{43}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{47}
 }
 
 func (x *GetRoomParticipantsResponse) GetParticipants() []*RoomParticipant {
@@ -3566,13 +4172,14 @@ type UpdateRoomRequest struct {
 	SendMessage   *bool                  `protobuf:"varint,5,opt,name=send_message,json=sendMessage,proto3,oneof" json:"send_message,omitempty"`
 	AddMember     *bool                  `protobuf:"varint,6,opt,name=add_member,json=addMember,proto3,oneof" json:"add_member,omitempty"`
 	EditGroup     *bool                  `protobuf:"varint,7,opt,name=edit_group,json=editGroup,proto3,oneof" json:"edit_group,omitempty"`
+	RetentionDays *int32                 `protobuf:"varint,8,opt,name=retention_days,json=retentionDays,proto3,oneof" json:"retention_days,omitempty"` // Solo el owner; 0 = para siempre, negativo = volver a la política global
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UpdateRoomRequest) Reset() {
 	*x = UpdateRoomRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[44]
+	mi := &file_services_chat_v1_types_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3584,7 +4191,7 @@ func (x *UpdateRoomRequest) String() string {
 func (*UpdateRoomRequest) ProtoMessage() {}
 
 func (x *UpdateRoomRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[44]
+	mi := &file_services_chat_v1_types_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3597,7 +4204,7 @@ func (x *UpdateRoomRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateRoomRequest.ProtoReflect.Descriptor instead.
 func (*UpdateRoomRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{44}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *UpdateRoomRequest) GetId() string {
@@ -3649,6 +4256,13 @@ func (x *UpdateRoomRequest) GetEditGroup() bool {
 	return false
 }
 
+func (x *UpdateRoomRequest) GetRetentionDays() int32 {
+	if x != nil && x.RetentionDays != nil {
+		return *x.RetentionDays
+	}
+	return 0
+}
+
 type UpdateRoomResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -3659,7 +4273,7 @@ type UpdateRoomResponse struct {
 
 func (x *UpdateRoomResponse) Reset() {
 	*x = UpdateRoomResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[45]
+	mi := &file_services_chat_v1_types_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3671,7 +4285,7 @@ func (x *UpdateRoomResponse) String() string {
 func (*UpdateRoomResponse) ProtoMessage() {}
 
 func (x *UpdateRoomResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[45]
+	mi := &file_services_chat_v1_types_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3684,7 +4298,7 @@ func (x *UpdateRoomResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateRoomResponse.ProtoReflect.Descriptor instead.
 func (*UpdateRoomResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{45}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{49}
 }
 
 func (x *UpdateRoomResponse) GetSuccess() bool {
@@ -3711,7 +4325,7 @@ type AddParticipantToRoomRequest struct {
 
 func (x *AddParticipantToRoomRequest) Reset() {
 	*x = AddParticipantToRoomRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[46]
+	mi := &file_services_chat_v1_types_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3723,7 +4337,7 @@ func (x *AddParticipantToRoomRequest) String() string {
 func (*AddParticipantToRoomRequest) ProtoMessage() {}
 
 func (x *AddParticipantToRoomRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[46]
+	mi := &file_services_chat_v1_types_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3736,7 +4350,7 @@ func (x *AddParticipantToRoomRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddParticipantToRoomRequest.ProtoReflect.Descriptor instead.
 func (*AddParticipantToRoomRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{46}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{50}
 }
 
 func (x *AddParticipantToRoomRequest) GetId() string {
@@ -3763,7 +4377,7 @@ type AddParticipantToRoomResponse struct {
 
 func (x *AddParticipantToRoomResponse) Reset() {
 	*x = AddParticipantToRoomResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[47]
+	mi := &file_services_chat_v1_types_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3775,7 +4389,7 @@ func (x *AddParticipantToRoomResponse) String() string {
 func (*AddParticipantToRoomResponse) ProtoMessage() {}
 
 func (x *AddParticipantToRoomResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[47]
+	mi := &file_services_chat_v1_types_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3788,7 +4402,7 @@ func (x *AddParticipantToRoomResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddParticipantToRoomResponse.ProtoReflect.Descriptor instead.
 func (*AddParticipantToRoomResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{47}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{51}
 }
 
 func (x *AddParticipantToRoomResponse) GetSuccess() bool {
@@ -3816,7 +4430,7 @@ type UpdateParticipantRoomRequest struct {
 
 func (x *UpdateParticipantRoomRequest) Reset() {
 	*x = UpdateParticipantRoomRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[48]
+	mi := &file_services_chat_v1_types_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3828,7 +4442,7 @@ func (x *UpdateParticipantRoomRequest) String() string {
 func (*UpdateParticipantRoomRequest) ProtoMessage() {}
 
 func (x *UpdateParticipantRoomRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[48]
+	mi := &file_services_chat_v1_types_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3841,7 +4455,7 @@ func (x *UpdateParticipantRoomRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateParticipantRoomRequest.ProtoReflect.Descriptor instead.
 func (*UpdateParticipantRoomRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{48}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{52}
 }
 
 func (x *UpdateParticipantRoomRequest) GetId() string {
@@ -3875,7 +4489,7 @@ type UpdateParticipantRoomResponse struct {
 
 func (x *UpdateParticipantRoomResponse) Reset() {
 	*x = UpdateParticipantRoomResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[49]
+	mi := &file_services_chat_v1_types_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3887,7 +4501,7 @@ func (x *UpdateParticipantRoomResponse) String() string {
 func (*UpdateParticipantRoomResponse) ProtoMessage() {}
 
 func (x *UpdateParticipantRoomResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[49]
+	mi := &file_services_chat_v1_types_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3900,7 +4514,7 @@ func (x *UpdateParticipantRoomResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateParticipantRoomResponse.ProtoReflect.Descriptor instead.
 func (*UpdateParticipantRoomResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{49}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{53}
 }
 
 func (x *UpdateParticipantRoomResponse) GetSuccess() bool {
@@ -3926,7 +4540,7 @@ type BlockUserRequest struct {
 
 func (x *BlockUserRequest) Reset() {
 	*x = BlockUserRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[50]
+	mi := &file_services_chat_v1_types_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3938,7 +4552,7 @@ func (x *BlockUserRequest) String() string {
 func (*BlockUserRequest) ProtoMessage() {}
 
 func (x *BlockUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[50]
+	mi := &file_services_chat_v1_types_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3951,7 +4565,7 @@ func (x *BlockUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockUserRequest.ProtoReflect.Descriptor instead.
 func (*BlockUserRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{50}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{54}
 }
 
 func (x *BlockUserRequest) GetId() string {
@@ -3971,7 +4585,7 @@ type BlockUserResponse struct {
 
 func (x *BlockUserResponse) Reset() {
 	*x = BlockUserResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[51]
+	mi := &file_services_chat_v1_types_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3983,7 +4597,7 @@ func (x *BlockUserResponse) String() string {
 func (*BlockUserResponse) ProtoMessage() {}
 
 func (x *BlockUserResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[51]
+	mi := &file_services_chat_v1_types_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3996,7 +4610,7 @@ func (x *BlockUserResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockUserResponse.ProtoReflect.Descriptor instead.
 func (*BlockUserResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{51}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{55}
 }
 
 func (x *BlockUserResponse) GetSuccess() bool {
@@ -4022,7 +4636,7 @@ type GetMessageRequest struct {
 
 func (x *GetMessageRequest) Reset() {
 	*x = GetMessageRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[52]
+	mi := &file_services_chat_v1_types_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4034,7 +4648,7 @@ func (x *GetMessageRequest) String() string {
 func (*GetMessageRequest) ProtoMessage() {}
 
 func (x *GetMessageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[52]
+	mi := &file_services_chat_v1_types_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4047,7 +4661,7 @@ func (x *GetMessageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMessageRequest.ProtoReflect.Descriptor instead.
 func (*GetMessageRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{52}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{56}
 }
 
 func (x *GetMessageRequest) GetId() string {
@@ -4066,7 +4680,7 @@ type GetSenderMessageRequest struct {
 
 func (x *GetSenderMessageRequest) Reset() {
 	*x = GetSenderMessageRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[53]
+	mi := &file_services_chat_v1_types_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4078,7 +4692,7 @@ func (x *GetSenderMessageRequest) String() string {
 func (*GetSenderMessageRequest) ProtoMessage() {}
 
 func (x *GetSenderMessageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[53]
+	mi := &file_services_chat_v1_types_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4091,7 +4705,7 @@ func (x *GetSenderMessageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetSenderMessageRequest.ProtoReflect.Descriptor instead.
 func (*GetSenderMessageRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{53}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{57}
 }
 
 func (x *GetSenderMessageRequest) GetSenderMessageId() string {
@@ -4110,7 +4724,7 @@ type GetSenderMessageResponse struct {
 
 func (x *GetSenderMessageResponse) Reset() {
 	*x = GetSenderMessageResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[54]
+	mi := &file_services_chat_v1_types_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4122,7 +4736,7 @@ func (x *GetSenderMessageResponse) String() string {
 func (*GetSenderMessageResponse) ProtoMessage() {}
 
 func (x *GetSenderMessageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[54]
+	mi := &file_services_chat_v1_types_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4135,7 +4749,7 @@ func (x *GetSenderMessageResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetSenderMessageResponse.ProtoReflect.Descriptor instead.
 func (*GetSenderMessageResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{54}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{58}
 }
 
 func (x *GetSenderMessageResponse) GetStatus() MessageStatus {
@@ -4155,7 +4769,7 @@ type ReactToMessageRequest struct {
 
 func (x *ReactToMessageRequest) Reset() {
 	*x = ReactToMessageRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[55]
+	mi := &file_services_chat_v1_types_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4167,7 +4781,7 @@ func (x *ReactToMessageRequest) String() string {
 func (*ReactToMessageRequest) ProtoMessage() {}
 
 func (x *ReactToMessageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[55]
+	mi := &file_services_chat_v1_types_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4180,7 +4794,7 @@ func (x *ReactToMessageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReactToMessageRequest.ProtoReflect.Descriptor instead.
 func (*ReactToMessageRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{55}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{59}
 }
 
 func (x *ReactToMessageRequest) GetMessageId() string {
@@ -4207,7 +4821,7 @@ type ReactToMessageResponse struct {
 
 func (x *ReactToMessageResponse) Reset() {
 	*x = ReactToMessageResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[56]
+	mi := &file_services_chat_v1_types_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4219,7 +4833,7 @@ func (x *ReactToMessageResponse) String() string {
 func (*ReactToMessageResponse) ProtoMessage() {}
 
 func (x *ReactToMessageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[56]
+	mi := &file_services_chat_v1_types_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4232,7 +4846,7 @@ func (x *ReactToMessageResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReactToMessageResponse.ProtoReflect.Descriptor instead.
 func (*ReactToMessageResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{56}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{60}
 }
 
 func (x *ReactToMessageResponse) GetSuccess() bool {
@@ -4254,13 +4868,14 @@ type GetMessageReadRequest struct {
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	Page          uint32                 `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
 	Limit         uint32                 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Cursor        string                 `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"` // meta.next_cursor de la respuesta anterior; si se indica, page se ignora
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetMessageReadRequest) Reset() {
 	*x = GetMessageReadRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[57]
+	mi := &file_services_chat_v1_types_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4272,7 +4887,7 @@ func (x *GetMessageReadRequest) String() string {
 func (*GetMessageReadRequest) ProtoMessage() {}
 
 func (x *GetMessageReadRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[57]
+	mi := &file_services_chat_v1_types_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4285,7 +4900,7 @@ func (x *GetMessageReadRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMessageReadRequest.ProtoReflect.Descriptor instead.
 func (*GetMessageReadRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{57}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{61}
 }
 
 func (x *GetMessageReadRequest) GetId() string {
@@ -4309,6 +4924,13 @@ func (x *GetMessageReadRequest) GetLimit() uint32 {
 	return 0
 }
 
+func (x *GetMessageReadRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
 type MessageUserRead struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
@@ -4322,7 +4944,7 @@ type MessageUserRead struct {
 
 func (x *MessageUserRead) Reset() {
 	*x = MessageUserRead{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[58]
+	mi := &file_services_chat_v1_types_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4334,7 +4956,7 @@ func (x *MessageUserRead) String() string {
 func (*MessageUserRead) ProtoMessage() {}
 
 func (x *MessageUserRead) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[58]
+	mi := &file_services_chat_v1_types_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4347,7 +4969,7 @@ func (x *MessageUserRead) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MessageUserRead.ProtoReflect.Descriptor instead.
 func (*MessageUserRead) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{58}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{62}
 }
 
 func (x *MessageUserRead) GetUserId() int32 {
@@ -4395,7 +5017,7 @@ type GetMessageReadResponse struct {
 
 func (x *GetMessageReadResponse) Reset() {
 	*x = GetMessageReadResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[59]
+	mi := &file_services_chat_v1_types_proto_msgTypes[63]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4407,7 +5029,7 @@ func (x *GetMessageReadResponse) String() string {
 func (*GetMessageReadResponse) ProtoMessage() {}
 
 func (x *GetMessageReadResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[59]
+	mi := &file_services_chat_v1_types_proto_msgTypes[63]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4420,7 +5042,7 @@ func (x *GetMessageReadResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMessageReadResponse.ProtoReflect.Descriptor instead.
 func (*GetMessageReadResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{59}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{63}
 }
 
 func (x *GetMessageReadResponse) GetItems() []*MessageUserRead {
@@ -4442,13 +5064,14 @@ type GetMessageReactionsRequest struct {
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	Page          uint32                 `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
 	Limit         uint32                 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Cursor        string                 `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"` // meta.next_cursor de la respuesta anterior; si se indica, page se ignora
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetMessageReactionsRequest) Reset() {
 	*x = GetMessageReactionsRequest{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[60]
+	mi := &file_services_chat_v1_types_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4460,7 +5083,7 @@ func (x *GetMessageReactionsRequest) String() string {
 func (*GetMessageReactionsRequest) ProtoMessage() {}
 
 func (x *GetMessageReactionsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[60]
+	mi := &file_services_chat_v1_types_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4473,7 +5096,7 @@ func (x *GetMessageReactionsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMessageReactionsRequest.ProtoReflect.Descriptor instead.
 func (*GetMessageReactionsRequest) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{60}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{64}
 }
 
 func (x *GetMessageReactionsRequest) GetId() string {
@@ -4497,6 +5120,13 @@ func (x *GetMessageReactionsRequest) GetLimit() uint32 {
 	return 0
 }
 
+func (x *GetMessageReactionsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
 type GetMessageReactionsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Items         []*Reaction            `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
@@ -4507,7 +5137,7 @@ type GetMessageReactionsResponse struct {
 
 func (x *GetMessageReactionsResponse) Reset() {
 	*x = GetMessageReactionsResponse{}
-	mi := &file_services_chat_v1_types_proto_msgTypes[61]
+	mi := &file_services_chat_v1_types_proto_msgTypes[65]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4519,7 +5149,7 @@ func (x *GetMessageReactionsResponse) String() string {
 func (*GetMessageReactionsResponse) ProtoMessage() {}
 
 func (x *GetMessageReactionsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_chat_v1_types_proto_msgTypes[61]
+	mi := &file_services_chat_v1_types_proto_msgTypes[65]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4532,7 +5162,7 @@ func (x *GetMessageReactionsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMessageReactionsResponse.ProtoReflect.Descriptor instead.
 func (*GetMessageReactionsResponse) Descriptor() ([]byte, []int) {
-	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{61}
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{65}
 }
 
 func (x *GetMessageReactionsResponse) GetItems() []*Reaction {
@@ -4549,83 +5179,2475 @@ func (x *GetMessageReactionsResponse) GetMeta() *PaginationMeta {
 	return nil
 }
 
-var File_services_chat_v1_types_proto protoreflect.FileDescriptor
+type RoomHistoryExport struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	RoomId           string                 `protobuf:"bytes,2,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Format           ExportFormat           `protobuf:"varint,3,opt,name=format,proto3,enum=services.chat.v1.ExportFormat" json:"format,omitempty"`
+	Status           ExportStatus           `protobuf:"varint,4,opt,name=status,proto3,enum=services.chat.v1.ExportStatus" json:"status,omitempty"`
+	MessagesExported int64                  `protobuf:"varint,5,opt,name=messages_exported,json=messagesExported,proto3" json:"messages_exported,omitempty"`
+	MessagesTotal    int64                  `protobuf:"varint,6,opt,name=messages_total,json=messagesTotal,proto3" json:"messages_total,omitempty"` // Estimado al iniciar; puede diferir si llegan mensajes durante la exportación
+	ErrorMessage     string                 `protobuf:"bytes,7,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	CreatedAt        string                 `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // ISO 8601
+	UpdatedAt        string                 `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // ISO 8601
+	FileName         string                 `protobuf:"bytes,10,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	ContentType      string                 `protobuf:"bytes,11,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	RequestedBy      int32                  `protobuf:"varint,12,opt,name=requested_by,json=requestedBy,proto3" json:"requested_by,omitempty"`
+	SizeBytes        int64                  `protobuf:"varint,13,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // Tamaño del archivo; solo cuando status es COMPLETED
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
 
-const file_services_chat_v1_types_proto_rawDesc = "" +
-	"\n" +
-	"\x1cservices/chat/v1/types.proto\x12\x10services.chat.v1\"\x85\x06\n" +
-	"\x04Room\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
-	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1b\n" +
-	"\tphoto_url\x18\x04 \x01(\tR\bphotoUrl\x12'\n" +
-	"\x0fencryption_data\x18\x05 \x01(\tR\x0eencryptionData\x12\x12\n" +
-	"\x04type\x18\x06 \x01(\tR\x04type\x12!\n" +
-	"\funread_count\x18\a \x01(\x05R\vunreadCount\x12\x12\n" +
-	"\x04role\x18\b \x01(\tR\x04role\x12\"\n" +
-	"\rjoin_all_user\x18\n" +
-	" \x01(\bR\vjoinAllUser\x12&\n" +
-	"\x0flast_message_at\x18\v \x01(\tR\rlastMessageAt\x12!\n" +
-	"\fsend_message\x18\f \x01(\bR\vsendMessage\x12\x1d\n" +
-	"\n" +
-	"add_member\x18\r \x01(\bR\taddMember\x12\x1d\n" +
-	"\n" +
-	"edit_group\x18\x0e \x01(\bR\teditGroup\x12\x1d\n" +
-	"\n" +
-	"created_at\x18\x0f \x01(\tR\tcreatedAt\x12\x1d\n" +
-	"\n" +
-	"updated_at\x18\x10 \x01(\tR\tupdatedAt\x12@\n" +
-	"\apartner\x18\x11 \x01(\v2!.services.chat.v1.RoomParticipantH\x00R\apartner\x88\x01\x01\x12E\n" +
-	"\fparticipants\x18\x12 \x03(\v2!.services.chat.v1.RoomParticipantR\fparticipants\x12,\n" +
-	"\x12is_partner_blocked\x18\x13 \x01(\bR\x10isPartnerBlocked\x12\x19\n" +
-	"\bis_muted\x18\x14 \x01(\bR\aisMuted\x12\x1b\n" +
-	"\tis_pinned\x18\x15 \x01(\bR\bisPinned\x12@\n" +
-	"\flast_message\x18\x16 \x01(\v2\x1d.services.chat.v1.MessageDataR\vlastMessageB\n" +
-	"\n" +
-	"\b_partner\"\xcf\x01\n" +
-	"\x0fRoomParticipant\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
-	"\x05phone\x18\x02 \x01(\tR\x05phone\x12\x12\n" +
-	"\x04name\x18\x03 \x01(\tR\x04name\x12\x16\n" +
-	"\x06avatar\x18\x04 \x01(\tR\x06avatar\x12\x12\n" +
-	"\x04role\x18\x05 \x01(\tR\x04role\x12,\n" +
-	"\x12is_partner_blocked\x18\x06 \x01(\bR\x10isPartnerBlocked\x12(\n" +
-	"\x10is_partner_muted\x18\a \x01(\bR\x0eisPartnerMuted\"t\n" +
-	"\aMention\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12\x10\n" +
-	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x14\n" +
-	"\x05phone\x18\x04 \x01(\tR\x05phone\x12\x1d\n" +
-	"\n" +
-	"message_id\x18\x05 \x01(\tR\tmessageId\"\xf7\x01\n" +
-	"\bReaction\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
-	"\breaction\x18\x02 \x01(\tR\breaction\x12\x1d\n" +
-	"\n" +
-	"message_id\x18\x03 \x01(\tR\tmessageId\x12\"\n" +
-	"\rreacted_by_id\x18\x04 \x01(\tR\vreactedById\x12&\n" +
-	"\x0freacted_by_name\x18\x05 \x01(\tR\rreactedByName\x12*\n" +
-	"\x11reacted_by_avatar\x18\x06 \x01(\tR\x0freactedByAvatar\x12(\n" +
-	"\x10reacted_by_phone\x18\a \x01(\tR\x0ereactedByPhone\"\xf1\r\n" +
-	"\vMessageData\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
-	"\aroom_id\x18\x02 \x01(\tR\x06roomId\x12\x1b\n" +
-	"\tsender_id\x18\x03 \x01(\x05R\bsenderId\x12\x1f\n" +
-	"\vsender_name\x18\x04 \x01(\tR\n" +
-	"senderName\x12#\n" +
-	"\rsender_avatar\x18\x05 \x01(\tR\fsenderAvatar\x12!\n" +
-	"\fsender_phone\x18\x06 \x01(\tR\vsenderPhone\x12\x18\n" +
-	"\acontent\x18\a \x01(\tR\acontent\x128\n" +
-	"\x05reply\x18\b \x01(\v2\x1d.services.chat.v1.MessageDataH\x00R\x05reply\x88\x01\x01\x125\n" +
-	"\x14forwarded_message_id\x18\t \x01(\tH\x01R\x12forwardedMessageId\x88\x01\x01\x12B\n" +
-	"\x1bforwarded_message_sender_id\x18\n" +
-	" \x01(\x05H\x02R\x18forwardedMessageSenderId\x88\x01\x01\x12F\n" +
-	"\x1dforwarded_message_sender_name\x18\v \x01(\tH\x03R\x1aforwardedMessageSenderName\x88\x01\x01\x12J\n" +
-	"\x1fforwarded_message_sender_avatar\x18\f \x01(\tH\x04R\x1cforwardedMessageSenderAvatar\x88\x01\x01\x12H\n" +
-	"\x1eforwarded_message_sender_phone\x18\r \x01(\tH\x05R\x1bforwardedMessageSenderPhone\x88\x01\x01\x125\n" +
-	"\bmentions\x18\x0e \x03(\v2\x19.services.chat.v1.MentionR\bmentions\x127\n" +
-	"\x06status\x18\x0f \x01(\x0e2\x1f.services.chat.v1.MessageStatusR\x06status\x12\x1d\n" +
-	"\n" +
+func (x *RoomHistoryExport) Reset() {
+	*x = RoomHistoryExport{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomHistoryExport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomHistoryExport) ProtoMessage() {}
+
+func (x *RoomHistoryExport) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomHistoryExport.ProtoReflect.Descriptor instead.
+func (*RoomHistoryExport) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *RoomHistoryExport) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RoomHistoryExport) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *RoomHistoryExport) GetFormat() ExportFormat {
+	if x != nil {
+		return x.Format
+	}
+	return ExportFormat_EXPORT_FORMAT_UNSPECIFIED
+}
+
+func (x *RoomHistoryExport) GetStatus() ExportStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ExportStatus_EXPORT_STATUS_UNSPECIFIED
+}
+
+func (x *RoomHistoryExport) GetMessagesExported() int64 {
+	if x != nil {
+		return x.MessagesExported
+	}
+	return 0
+}
+
+func (x *RoomHistoryExport) GetMessagesTotal() int64 {
+	if x != nil {
+		return x.MessagesTotal
+	}
+	return 0
+}
+
+func (x *RoomHistoryExport) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *RoomHistoryExport) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *RoomHistoryExport) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+func (x *RoomHistoryExport) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *RoomHistoryExport) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *RoomHistoryExport) GetRequestedBy() int32 {
+	if x != nil {
+		return x.RequestedBy
+	}
+	return 0
+}
+
+func (x *RoomHistoryExport) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+type ExportRoomHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // Room
+	Format        ExportFormat           `protobuf:"varint,2,opt,name=format,proto3,enum=services.chat.v1.ExportFormat" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRoomHistoryRequest) Reset() {
+	*x = ExportRoomHistoryRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRoomHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRoomHistoryRequest) ProtoMessage() {}
+
+func (x *ExportRoomHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRoomHistoryRequest.ProtoReflect.Descriptor instead.
+func (*ExportRoomHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *ExportRoomHistoryRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ExportRoomHistoryRequest) GetFormat() ExportFormat {
+	if x != nil {
+		return x.Format
+	}
+	return ExportFormat_EXPORT_FORMAT_UNSPECIFIED
+}
+
+type ExportRoomHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Export        *RoomHistoryExport     `protobuf:"bytes,1,opt,name=export,proto3" json:"export,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRoomHistoryResponse) Reset() {
+	*x = ExportRoomHistoryResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRoomHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRoomHistoryResponse) ProtoMessage() {}
+
+func (x *ExportRoomHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRoomHistoryResponse.ProtoReflect.Descriptor instead.
+func (*ExportRoomHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *ExportRoomHistoryResponse) GetExport() *RoomHistoryExport {
+	if x != nil {
+		return x.Export
+	}
+	return nil
+}
+
+type GetRoomHistoryExportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // Exportación
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRoomHistoryExportRequest) Reset() {
+	*x = GetRoomHistoryExportRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRoomHistoryExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRoomHistoryExportRequest) ProtoMessage() {}
+
+func (x *GetRoomHistoryExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRoomHistoryExportRequest.ProtoReflect.Descriptor instead.
+func (*GetRoomHistoryExportRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *GetRoomHistoryExportRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetRoomHistoryExportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Export        *RoomHistoryExport     `protobuf:"bytes,1,opt,name=export,proto3" json:"export,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRoomHistoryExportResponse) Reset() {
+	*x = GetRoomHistoryExportResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[70]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRoomHistoryExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRoomHistoryExportResponse) ProtoMessage() {}
+
+func (x *GetRoomHistoryExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[70]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRoomHistoryExportResponse.ProtoReflect.Descriptor instead.
+func (*GetRoomHistoryExportResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *GetRoomHistoryExportResponse) GetExport() *RoomHistoryExport {
+	if x != nil {
+		return x.Export
+	}
+	return nil
+}
+
+type DownloadRoomHistoryExportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // Exportación
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadRoomHistoryExportRequest) Reset() {
+	*x = DownloadRoomHistoryExportRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[71]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadRoomHistoryExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadRoomHistoryExportRequest) ProtoMessage() {}
+
+func (x *DownloadRoomHistoryExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[71]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadRoomHistoryExportRequest.ProtoReflect.Descriptor instead.
+func (*DownloadRoomHistoryExportRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{71}
+}
+
+func (x *DownloadRoomHistoryExportRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Un trozo del archivo. file_name, content_type y size_bytes solo van en el primero
+type DownloadRoomHistoryExportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileName      string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	ContentType   string                 `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	SizeBytes     int64                  `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	Chunk         []byte                 `protobuf:"bytes,4,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadRoomHistoryExportResponse) Reset() {
+	*x = DownloadRoomHistoryExportResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[72]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadRoomHistoryExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadRoomHistoryExportResponse) ProtoMessage() {}
+
+func (x *DownloadRoomHistoryExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[72]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadRoomHistoryExportResponse.ProtoReflect.Descriptor instead.
+func (*DownloadRoomHistoryExportResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *DownloadRoomHistoryExportResponse) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *DownloadRoomHistoryExportResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *DownloadRoomHistoryExportResponse) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *DownloadRoomHistoryExportResponse) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+type UserDataExport struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId            int32                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status            ExportStatus           `protobuf:"varint,3,opt,name=status,proto3,enum=services.chat.v1.ExportStatus" json:"status,omitempty"`
+	RoomsExported     int32                  `protobuf:"varint,4,opt,name=rooms_exported,json=roomsExported,proto3" json:"rooms_exported,omitempty"`
+	RoomsTotal        int32                  `protobuf:"varint,5,opt,name=rooms_total,json=roomsTotal,proto3" json:"rooms_total,omitempty"`
+	ErrorMessage      string                 `protobuf:"bytes,6,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	CreatedAt         string                 `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // ISO 8601
+	UpdatedAt         string                 `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // ISO 8601
+	FileName          string                 `protobuf:"bytes,9,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	ContentType       string                 `protobuf:"bytes,10,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	DownloadHandle    string                 `protobuf:"bytes,11,opt,name=download_handle,json=downloadHandle,proto3" json:"download_handle,omitempty"`            // Solo cuando status es COMPLETED; se renueva al consultar si ya caducó
+	DownloadExpiresAt string                 `protobuf:"bytes,12,opt,name=download_expires_at,json=downloadExpiresAt,proto3" json:"download_expires_at,omitempty"` // ISO 8601
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *UserDataExport) Reset() {
+	*x = UserDataExport{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[73]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserDataExport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserDataExport) ProtoMessage() {}
+
+func (x *UserDataExport) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[73]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserDataExport.ProtoReflect.Descriptor instead.
+func (*UserDataExport) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{73}
+}
+
+func (x *UserDataExport) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UserDataExport) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *UserDataExport) GetStatus() ExportStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ExportStatus_EXPORT_STATUS_UNSPECIFIED
+}
+
+func (x *UserDataExport) GetRoomsExported() int32 {
+	if x != nil {
+		return x.RoomsExported
+	}
+	return 0
+}
+
+func (x *UserDataExport) GetRoomsTotal() int32 {
+	if x != nil {
+		return x.RoomsTotal
+	}
+	return 0
+}
+
+func (x *UserDataExport) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *UserDataExport) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *UserDataExport) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+func (x *UserDataExport) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *UserDataExport) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *UserDataExport) GetDownloadHandle() string {
+	if x != nil {
+		return x.DownloadHandle
+	}
+	return ""
+}
+
+func (x *UserDataExport) GetDownloadExpiresAt() string {
+	if x != nil {
+		return x.DownloadExpiresAt
+	}
+	return ""
+}
+
+type ExportUserDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportUserDataRequest) Reset() {
+	*x = ExportUserDataRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[74]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportUserDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportUserDataRequest) ProtoMessage() {}
+
+func (x *ExportUserDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[74]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportUserDataRequest.ProtoReflect.Descriptor instead.
+func (*ExportUserDataRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{74}
+}
+
+type ExportUserDataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Export        *UserDataExport        `protobuf:"bytes,1,opt,name=export,proto3" json:"export,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportUserDataResponse) Reset() {
+	*x = ExportUserDataResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[75]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportUserDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportUserDataResponse) ProtoMessage() {}
+
+func (x *ExportUserDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[75]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportUserDataResponse.ProtoReflect.Descriptor instead.
+func (*ExportUserDataResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *ExportUserDataResponse) GetExport() *UserDataExport {
+	if x != nil {
+		return x.Export
+	}
+	return nil
+}
+
+type GetUserDataExportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // Exportación
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserDataExportRequest) Reset() {
+	*x = GetUserDataExportRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[76]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserDataExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserDataExportRequest) ProtoMessage() {}
+
+func (x *GetUserDataExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[76]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserDataExportRequest.ProtoReflect.Descriptor instead.
+func (*GetUserDataExportRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{76}
+}
+
+func (x *GetUserDataExportRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetUserDataExportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Export        *UserDataExport        `protobuf:"bytes,1,opt,name=export,proto3" json:"export,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserDataExportResponse) Reset() {
+	*x = GetUserDataExportResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[77]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserDataExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserDataExportResponse) ProtoMessage() {}
+
+func (x *GetUserDataExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[77]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserDataExportResponse.ProtoReflect.Descriptor instead.
+func (*GetUserDataExportResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{77}
+}
+
+func (x *GetUserDataExportResponse) GetExport() *UserDataExport {
+	if x != nil {
+		return x.Export
+	}
+	return nil
+}
+
+type DownloadUserDataExportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Handle        string                 `protobuf:"bytes,1,opt,name=handle,proto3" json:"handle,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadUserDataExportRequest) Reset() {
+	*x = DownloadUserDataExportRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[78]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadUserDataExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadUserDataExportRequest) ProtoMessage() {}
+
+func (x *DownloadUserDataExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[78]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadUserDataExportRequest.ProtoReflect.Descriptor instead.
+func (*DownloadUserDataExportRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{78}
+}
+
+func (x *DownloadUserDataExportRequest) GetHandle() string {
+	if x != nil {
+		return x.Handle
+	}
+	return ""
+}
+
+type DownloadUserDataExportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileName      string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	ContentType   string                 `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Content       []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadUserDataExportResponse) Reset() {
+	*x = DownloadUserDataExportResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[79]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadUserDataExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadUserDataExportResponse) ProtoMessage() {}
+
+func (x *DownloadUserDataExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[79]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadUserDataExportResponse.ProtoReflect.Descriptor instead.
+func (*DownloadUserDataExportResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{79}
+}
+
+func (x *DownloadUserDataExportResponse) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *DownloadUserDataExportResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *DownloadUserDataExportResponse) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type UserErasureReport struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	UserId             int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	MessagesAnonymised int32                  `protobuf:"varint,2,opt,name=messages_anonymised,json=messagesAnonymised,proto3" json:"messages_anonymised,omitempty"`
+	TokensDeleted      int64                  `protobuf:"varint,3,opt,name=tokens_deleted,json=tokensDeleted,proto3" json:"tokens_deleted,omitempty"`
+	RoomsLeft          []string               `protobuf:"bytes,4,rep,name=rooms_left,json=roomsLeft,proto3" json:"rooms_left,omitempty"`                                                                                           // Grupos de los que se retiró al usuario
+	RoomsClosed        []string               `protobuf:"bytes,5,rep,name=rooms_closed,json=roomsClosed,proto3" json:"rooms_closed,omitempty"`                                                                                     // Salas p2p y grupos que se quedaron sin miembros
+	OwnersPromoted     map[string]int32       `protobuf:"bytes,6,rep,name=owners_promoted,json=ownersPromoted,proto3" json:"owners_promoted,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // Room → nuevo owner
+	Remaining          []string               `protobuf:"bytes,7,rep,name=remaining,proto3" json:"remaining,omitempty"`                                                                                                            // Rastros encontrados por la verificación; vacío si todo se borró
+	Verified           bool                   `protobuf:"varint,8,opt,name=verified,proto3" json:"verified,omitempty"`
+	ErasedAt           string                 `protobuf:"bytes,9,opt,name=erased_at,json=erasedAt,proto3" json:"erased_at,omitempty"` // ISO 8601
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *UserErasureReport) Reset() {
+	*x = UserErasureReport{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[80]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserErasureReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserErasureReport) ProtoMessage() {}
+
+func (x *UserErasureReport) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[80]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserErasureReport.ProtoReflect.Descriptor instead.
+func (*UserErasureReport) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{80}
+}
+
+func (x *UserErasureReport) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *UserErasureReport) GetMessagesAnonymised() int32 {
+	if x != nil {
+		return x.MessagesAnonymised
+	}
+	return 0
+}
+
+func (x *UserErasureReport) GetTokensDeleted() int64 {
+	if x != nil {
+		return x.TokensDeleted
+	}
+	return 0
+}
+
+func (x *UserErasureReport) GetRoomsLeft() []string {
+	if x != nil {
+		return x.RoomsLeft
+	}
+	return nil
+}
+
+func (x *UserErasureReport) GetRoomsClosed() []string {
+	if x != nil {
+		return x.RoomsClosed
+	}
+	return nil
+}
+
+func (x *UserErasureReport) GetOwnersPromoted() map[string]int32 {
+	if x != nil {
+		return x.OwnersPromoted
+	}
+	return nil
+}
+
+func (x *UserErasureReport) GetRemaining() []string {
+	if x != nil {
+		return x.Remaining
+	}
+	return nil
+}
+
+func (x *UserErasureReport) GetVerified() bool {
+	if x != nil {
+		return x.Verified
+	}
+	return false
+}
+
+func (x *UserErasureReport) GetErasedAt() string {
+	if x != nil {
+		return x.ErasedAt
+	}
+	return ""
+}
+
+type EraseUserDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EraseUserDataRequest) Reset() {
+	*x = EraseUserDataRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[81]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EraseUserDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EraseUserDataRequest) ProtoMessage() {}
+
+func (x *EraseUserDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[81]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EraseUserDataRequest.ProtoReflect.Descriptor instead.
+func (*EraseUserDataRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{81}
+}
+
+func (x *EraseUserDataRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type EraseUserDataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Report        *UserErasureReport     `protobuf:"bytes,1,opt,name=report,proto3" json:"report,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EraseUserDataResponse) Reset() {
+	*x = EraseUserDataResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[82]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EraseUserDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EraseUserDataResponse) ProtoMessage() {}
+
+func (x *EraseUserDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[82]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EraseUserDataResponse.ProtoReflect.Descriptor instead.
+func (*EraseUserDataResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{82}
+}
+
+func (x *EraseUserDataResponse) GetReport() *UserErasureReport {
+	if x != nil {
+		return x.Report
+	}
+	return nil
+}
+
+type RoomCacheKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	InRedis       bool                   `protobuf:"varint,2,opt,name=in_redis,json=inRedis,proto3" json:"in_redis,omitempty"`
+	InLocal       bool                   `protobuf:"varint,3,opt,name=in_local,json=inLocal,proto3" json:"in_local,omitempty"` // En el LRU de la réplica que respondió
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomCacheKey) Reset() {
+	*x = RoomCacheKey{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[83]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomCacheKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomCacheKey) ProtoMessage() {}
+
+func (x *RoomCacheKey) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[83]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomCacheKey.ProtoReflect.Descriptor instead.
+func (*RoomCacheKey) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{83}
+}
+
+func (x *RoomCacheKey) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *RoomCacheKey) GetInRedis() bool {
+	if x != nil {
+		return x.InRedis
+	}
+	return false
+}
+
+func (x *RoomCacheKey) GetInLocal() bool {
+	if x != nil {
+		return x.InLocal
+	}
+	return false
+}
+
+type ListRoomCacheKeysRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoomCacheKeysRequest) Reset() {
+	*x = ListRoomCacheKeysRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[84]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoomCacheKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoomCacheKeysRequest) ProtoMessage() {}
+
+func (x *ListRoomCacheKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[84]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoomCacheKeysRequest.ProtoReflect.Descriptor instead.
+func (*ListRoomCacheKeysRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{84}
+}
+
+func (x *ListRoomCacheKeysRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+type ListRoomCacheKeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []*RoomCacheKey        `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	Replica       string                 `protobuf:"bytes,2,opt,name=replica,proto3" json:"replica,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoomCacheKeysResponse) Reset() {
+	*x = ListRoomCacheKeysResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[85]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoomCacheKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoomCacheKeysResponse) ProtoMessage() {}
+
+func (x *ListRoomCacheKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[85]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoomCacheKeysResponse.ProtoReflect.Descriptor instead.
+func (*ListRoomCacheKeysResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{85}
+}
+
+func (x *ListRoomCacheKeysResponse) GetKeys() []*RoomCacheKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *ListRoomCacheKeysResponse) GetReplica() string {
+	if x != nil {
+		return x.Replica
+	}
+	return ""
+}
+
+type CacheEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Tier  string                 `protobuf:"bytes,1,opt,name=tier,proto3" json:"tier,omitempty"` // "local" o "redis"
+	// Types that are valid to be assigned to Value:
+	//
+	//	*CacheEntry_Room
+	//	*CacheEntry_Message
+	//	*CacheEntry_Raw
+	Value         isCacheEntry_Value `protobuf_oneof:"value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CacheEntry) Reset() {
+	*x = CacheEntry{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[86]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CacheEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CacheEntry) ProtoMessage() {}
+
+func (x *CacheEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[86]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CacheEntry.ProtoReflect.Descriptor instead.
+func (*CacheEntry) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{86}
+}
+
+func (x *CacheEntry) GetTier() string {
+	if x != nil {
+		return x.Tier
+	}
+	return ""
+}
+
+func (x *CacheEntry) GetValue() isCacheEntry_Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *CacheEntry) GetRoom() *Room {
+	if x != nil {
+		if x, ok := x.Value.(*CacheEntry_Room); ok {
+			return x.Room
+		}
+	}
+	return nil
+}
+
+func (x *CacheEntry) GetMessage() *MessageData {
+	if x != nil {
+		if x, ok := x.Value.(*CacheEntry_Message); ok {
+			return x.Message
+		}
+	}
+	return nil
+}
+
+func (x *CacheEntry) GetRaw() string {
+	if x != nil {
+		if x, ok := x.Value.(*CacheEntry_Raw); ok {
+			return x.Raw
+		}
+	}
+	return ""
+}
+
+type isCacheEntry_Value interface {
+	isCacheEntry_Value()
+}
+
+type CacheEntry_Room struct {
+	Room *Room `protobuf:"bytes,2,opt,name=room,proto3,oneof"`
+}
+
+type CacheEntry_Message struct {
+	Message *MessageData `protobuf:"bytes,3,opt,name=message,proto3,oneof"`
+}
+
+type CacheEntry_Raw struct {
+	Raw string `protobuf:"bytes,4,opt,name=raw,proto3,oneof"` // Valor de Redis que no se pudo decodificar
+}
+
+func (*CacheEntry_Room) isCacheEntry_Value() {}
+
+func (*CacheEntry_Message) isCacheEntry_Value() {}
+
+func (*CacheEntry_Raw) isCacheEntry_Value() {}
+
+type GetCacheEntryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"` // endpoint:chat:room:... o endpoint:chat:messagesimple:...
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCacheEntryRequest) Reset() {
+	*x = GetCacheEntryRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[87]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCacheEntryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCacheEntryRequest) ProtoMessage() {}
+
+func (x *GetCacheEntryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[87]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCacheEntryRequest.ProtoReflect.Descriptor instead.
+func (*GetCacheEntryRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{87}
+}
+
+func (x *GetCacheEntryRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type GetCacheEntryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*CacheEntry          `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"` // Vacío si la clave no está cacheada
+	Replica       string                 `protobuf:"bytes,2,opt,name=replica,proto3" json:"replica,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCacheEntryResponse) Reset() {
+	*x = GetCacheEntryResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[88]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCacheEntryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCacheEntryResponse) ProtoMessage() {}
+
+func (x *GetCacheEntryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[88]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCacheEntryResponse.ProtoReflect.Descriptor instead.
+func (*GetCacheEntryResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{88}
+}
+
+func (x *GetCacheEntryResponse) GetEntries() []*CacheEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *GetCacheEntryResponse) GetReplica() string {
+	if x != nil {
+		return x.Replica
+	}
+	return ""
+}
+
+type FlushCacheRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Target:
+	//
+	//	*FlushCacheRequest_RoomId
+	//	*FlushCacheRequest_UserId
+	Target        isFlushCacheRequest_Target `protobuf_oneof:"target"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlushCacheRequest) Reset() {
+	*x = FlushCacheRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[89]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlushCacheRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlushCacheRequest) ProtoMessage() {}
+
+func (x *FlushCacheRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[89]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlushCacheRequest.ProtoReflect.Descriptor instead.
+func (*FlushCacheRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{89}
+}
+
+func (x *FlushCacheRequest) GetTarget() isFlushCacheRequest_Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *FlushCacheRequest) GetRoomId() string {
+	if x != nil {
+		if x, ok := x.Target.(*FlushCacheRequest_RoomId); ok {
+			return x.RoomId
+		}
+	}
+	return ""
+}
+
+func (x *FlushCacheRequest) GetUserId() int32 {
+	if x != nil {
+		if x, ok := x.Target.(*FlushCacheRequest_UserId); ok {
+			return x.UserId
+		}
+	}
+	return 0
+}
+
+type isFlushCacheRequest_Target interface {
+	isFlushCacheRequest_Target()
+}
+
+type FlushCacheRequest_RoomId struct {
+	RoomId string `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3,oneof"` // Todas las versiones cacheadas de la sala
+}
+
+type FlushCacheRequest_UserId struct {
+	UserId int32 `protobuf:"varint,2,opt,name=user_id,json=userId,proto3,oneof"` // Las salas vistas por el usuario
+}
+
+func (*FlushCacheRequest_RoomId) isFlushCacheRequest_Target() {}
+
+func (*FlushCacheRequest_UserId) isFlushCacheRequest_Target() {}
+
+type FlushCacheResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KeysDeleted   int32                  `protobuf:"varint,1,opt,name=keys_deleted,json=keysDeleted,proto3" json:"keys_deleted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlushCacheResponse) Reset() {
+	*x = FlushCacheResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[90]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlushCacheResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlushCacheResponse) ProtoMessage() {}
+
+func (x *FlushCacheResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[90]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlushCacheResponse.ProtoReflect.Descriptor instead.
+func (*FlushCacheResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{90}
+}
+
+func (x *FlushCacheResponse) GetKeysDeleted() int32 {
+	if x != nil {
+		return x.KeysDeleted
+	}
+	return 0
+}
+
+type CacheStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LocalHits     int64                  `protobuf:"varint,1,opt,name=local_hits,json=localHits,proto3" json:"local_hits,omitempty"`
+	RedisHits     int64                  `protobuf:"varint,2,opt,name=redis_hits,json=redisHits,proto3" json:"redis_hits,omitempty"`
+	Misses        int64                  `protobuf:"varint,3,opt,name=misses,proto3" json:"misses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CacheStats) Reset() {
+	*x = CacheStats{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[91]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CacheStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CacheStats) ProtoMessage() {}
+
+func (x *CacheStats) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[91]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CacheStats.ProtoReflect.Descriptor instead.
+func (*CacheStats) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{91}
+}
+
+func (x *CacheStats) GetLocalHits() int64 {
+	if x != nil {
+		return x.LocalHits
+	}
+	return 0
+}
+
+func (x *CacheStats) GetRedisHits() int64 {
+	if x != nil {
+		return x.RedisHits
+	}
+	return 0
+}
+
+func (x *CacheStats) GetMisses() int64 {
+	if x != nil {
+		return x.Misses
+	}
+	return 0
+}
+
+type GetCacheStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCacheStatsRequest) Reset() {
+	*x = GetCacheStatsRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[92]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCacheStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCacheStatsRequest) ProtoMessage() {}
+
+func (x *GetCacheStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[92]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCacheStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetCacheStatsRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{92}
+}
+
+type GetCacheStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rooms         *CacheStats            `protobuf:"bytes,1,opt,name=rooms,proto3" json:"rooms,omitempty"`       // GetCachedRoom
+	Messages      *CacheStats            `protobuf:"bytes,2,opt,name=messages,proto3" json:"messages,omitempty"` // GetCachedMessageSimple
+	Replica       string                 `protobuf:"bytes,3,opt,name=replica,proto3" json:"replica,omitempty"`   // Las estadísticas son de esta réplica desde que arrancó
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCacheStatsResponse) Reset() {
+	*x = GetCacheStatsResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[93]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCacheStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCacheStatsResponse) ProtoMessage() {}
+
+func (x *GetCacheStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[93]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCacheStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetCacheStatsResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{93}
+}
+
+func (x *GetCacheStatsResponse) GetRooms() *CacheStats {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+func (x *GetCacheStatsResponse) GetMessages() *CacheStats {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *GetCacheStatsResponse) GetReplica() string {
+	if x != nil {
+		return x.Replica
+	}
+	return ""
+}
+
+type RoomKey struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Version           int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	EncryptionData    string                 `protobuf:"bytes,2,opt,name=encryption_data,json=encryptionData,proto3" json:"encryption_data,omitempty"`              // En salas e2e, la clave envuelta para el dispositivo
+	RetiredAt         string                 `protobuf:"bytes,3,opt,name=retired_at,json=retiredAt,proto3" json:"retired_at,omitempty"`                             // ISO 8601; vacío en la clave actual
+	WrappedByUserId   int32                  `protobuf:"varint,4,opt,name=wrapped_by_user_id,json=wrappedByUserId,proto3" json:"wrapped_by_user_id,omitempty"`      // Salas e2e: quién envolvió la clave
+	WrappedByDeviceId string                 `protobuf:"bytes,5,opt,name=wrapped_by_device_id,json=wrappedByDeviceId,proto3" json:"wrapped_by_device_id,omitempty"` // Salas e2e: con la clave pública de este dispositivo
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *RoomKey) Reset() {
+	*x = RoomKey{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[94]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomKey) ProtoMessage() {}
+
+func (x *RoomKey) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[94]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomKey.ProtoReflect.Descriptor instead.
+func (*RoomKey) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{94}
+}
+
+func (x *RoomKey) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *RoomKey) GetEncryptionData() string {
+	if x != nil {
+		return x.EncryptionData
+	}
+	return ""
+}
+
+func (x *RoomKey) GetRetiredAt() string {
+	if x != nil {
+		return x.RetiredAt
+	}
+	return ""
+}
+
+func (x *RoomKey) GetWrappedByUserId() int32 {
+	if x != nil {
+		return x.WrappedByUserId
+	}
+	return 0
+}
+
+func (x *RoomKey) GetWrappedByDeviceId() string {
+	if x != nil {
+		return x.WrappedByDeviceId
+	}
+	return ""
+}
+
+type RotateRoomKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	DeviceId      *string                `protobuf:"bytes,2,opt,name=device_id,json=deviceId,proto3,oneof" json:"device_id,omitempty"` // Salas e2e: dispositivo que envuelve la clave nueva
+	Keys          []*WrappedRoomKey      `protobuf:"bytes,3,rep,name=keys,proto3" json:"keys,omitempty"`                               // Salas e2e: la clave nueva envuelta para cada dispositivo
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateRoomKeyRequest) Reset() {
+	*x = RotateRoomKeyRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[95]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateRoomKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateRoomKeyRequest) ProtoMessage() {}
+
+func (x *RotateRoomKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[95]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateRoomKeyRequest.ProtoReflect.Descriptor instead.
+func (*RotateRoomKeyRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{95}
+}
+
+func (x *RotateRoomKeyRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *RotateRoomKeyRequest) GetDeviceId() string {
+	if x != nil && x.DeviceId != nil {
+		return *x.DeviceId
+	}
+	return ""
+}
+
+func (x *RotateRoomKeyRequest) GetKeys() []*WrappedRoomKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type RotateRoomKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KeyVersion    int32                  `protobuf:"varint,1,opt,name=key_version,json=keyVersion,proto3" json:"key_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateRoomKeyResponse) Reset() {
+	*x = RotateRoomKeyResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[96]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateRoomKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateRoomKeyResponse) ProtoMessage() {}
+
+func (x *RotateRoomKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[96]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateRoomKeyResponse.ProtoReflect.Descriptor instead.
+func (*RotateRoomKeyResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{96}
+}
+
+func (x *RotateRoomKeyResponse) GetKeyVersion() int32 {
+	if x != nil {
+		return x.KeyVersion
+	}
+	return 0
+}
+
+type GetRoomKeysRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	DeviceId      *string                `protobuf:"bytes,2,opt,name=device_id,json=deviceId,proto3,oneof" json:"device_id,omitempty"` // Obligatorio en salas e2e
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRoomKeysRequest) Reset() {
+	*x = GetRoomKeysRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[97]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRoomKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRoomKeysRequest) ProtoMessage() {}
+
+func (x *GetRoomKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[97]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRoomKeysRequest.ProtoReflect.Descriptor instead.
+func (*GetRoomKeysRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{97}
+}
+
+func (x *GetRoomKeysRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *GetRoomKeysRequest) GetDeviceId() string {
+	if x != nil && x.DeviceId != nil {
+		return *x.DeviceId
+	}
+	return ""
+}
+
+type GetRoomKeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []*RoomKey             `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"` // De la más reciente a la más antigua
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRoomKeysResponse) Reset() {
+	*x = GetRoomKeysResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[98]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRoomKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRoomKeysResponse) ProtoMessage() {}
+
+func (x *GetRoomKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[98]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRoomKeysResponse.ProtoReflect.Descriptor instead.
+func (*GetRoomKeysResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{98}
+}
+
+func (x *GetRoomKeysResponse) GetKeys() []*RoomKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type DeviceKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	DeviceId      string                 `protobuf:"bytes,2,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	PublicKey     string                 `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	UpdatedAt     string                 `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // ISO 8601
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceKey) Reset() {
+	*x = DeviceKey{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[99]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceKey) ProtoMessage() {}
+
+func (x *DeviceKey) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[99]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceKey.ProtoReflect.Descriptor instead.
+func (*DeviceKey) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{99}
+}
+
+func (x *DeviceKey) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *DeviceKey) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *DeviceKey) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *DeviceKey) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+type WrappedRoomKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	DeviceId      string                 `protobuf:"bytes,2,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	WrappedKey    string                 `protobuf:"bytes,3,opt,name=wrapped_key,json=wrappedKey,proto3" json:"wrapped_key,omitempty"` // Clave de la sala cifrada con la clave pública del dispositivo
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WrappedRoomKey) Reset() {
+	*x = WrappedRoomKey{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[100]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WrappedRoomKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WrappedRoomKey) ProtoMessage() {}
+
+func (x *WrappedRoomKey) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[100]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WrappedRoomKey.ProtoReflect.Descriptor instead.
+func (*WrappedRoomKey) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{100}
+}
+
+func (x *WrappedRoomKey) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *WrappedRoomKey) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *WrappedRoomKey) GetWrappedKey() string {
+	if x != nil {
+		return x.WrappedKey
+	}
+	return ""
+}
+
+type RegisterDeviceKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeviceId      string                 `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	PublicKey     string                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterDeviceKeyRequest) Reset() {
+	*x = RegisterDeviceKeyRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[101]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterDeviceKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterDeviceKeyRequest) ProtoMessage() {}
+
+func (x *RegisterDeviceKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[101]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterDeviceKeyRequest.ProtoReflect.Descriptor instead.
+func (*RegisterDeviceKeyRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{101}
+}
+
+func (x *RegisterDeviceKeyRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *RegisterDeviceKeyRequest) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+type RegisterDeviceKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterDeviceKeyResponse) Reset() {
+	*x = RegisterDeviceKeyResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[102]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterDeviceKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterDeviceKeyResponse) ProtoMessage() {}
+
+func (x *RegisterDeviceKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[102]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterDeviceKeyResponse.ProtoReflect.Descriptor instead.
+func (*RegisterDeviceKeyResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{102}
+}
+
+func (x *RegisterDeviceKeyResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type GetDeviceKeysRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserIds       []int32                `protobuf:"varint,1,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	RoomId        *string                `protobuf:"bytes,2,opt,name=room_id,json=roomId,proto3,oneof" json:"room_id,omitempty"` // Los dispositivos de todos los participantes de la sala
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeviceKeysRequest) Reset() {
+	*x = GetDeviceKeysRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[103]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeviceKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeviceKeysRequest) ProtoMessage() {}
+
+func (x *GetDeviceKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[103]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeviceKeysRequest.ProtoReflect.Descriptor instead.
+func (*GetDeviceKeysRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{103}
+}
+
+func (x *GetDeviceKeysRequest) GetUserIds() []int32 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+func (x *GetDeviceKeysRequest) GetRoomId() string {
+	if x != nil && x.RoomId != nil {
+		return *x.RoomId
+	}
+	return ""
+}
+
+type GetDeviceKeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []*DeviceKey           `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeviceKeysResponse) Reset() {
+	*x = GetDeviceKeysResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[104]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeviceKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeviceKeysResponse) ProtoMessage() {}
+
+func (x *GetDeviceKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[104]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeviceKeysResponse.ProtoReflect.Descriptor instead.
+func (*GetDeviceKeysResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{104}
+}
+
+func (x *GetDeviceKeysResponse) GetKeys() []*DeviceKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type ShareRoomKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	KeyVersion    int32                  `protobuf:"varint,2,opt,name=key_version,json=keyVersion,proto3" json:"key_version,omitempty"`
+	DeviceId      string                 `protobuf:"bytes,3,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"` // Dispositivo que envuelve la clave
+	Keys          []*WrappedRoomKey      `protobuf:"bytes,4,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShareRoomKeyRequest) Reset() {
+	*x = ShareRoomKeyRequest{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[105]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShareRoomKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareRoomKeyRequest) ProtoMessage() {}
+
+func (x *ShareRoomKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[105]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareRoomKeyRequest.ProtoReflect.Descriptor instead.
+func (*ShareRoomKeyRequest) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{105}
+}
+
+func (x *ShareRoomKeyRequest) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *ShareRoomKeyRequest) GetKeyVersion() int32 {
+	if x != nil {
+		return x.KeyVersion
+	}
+	return 0
+}
+
+func (x *ShareRoomKeyRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *ShareRoomKeyRequest) GetKeys() []*WrappedRoomKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type ShareRoomKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShareRoomKeyResponse) Reset() {
+	*x = ShareRoomKeyResponse{}
+	mi := &file_services_chat_v1_types_proto_msgTypes[106]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShareRoomKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareRoomKeyResponse) ProtoMessage() {}
+
+func (x *ShareRoomKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_chat_v1_types_proto_msgTypes[106]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareRoomKeyResponse.ProtoReflect.Descriptor instead.
+func (*ShareRoomKeyResponse) Descriptor() ([]byte, []int) {
+	return file_services_chat_v1_types_proto_rawDescGZIP(), []int{106}
+}
+
+func (x *ShareRoomKeyResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+var File_services_chat_v1_types_proto protoreflect.FileDescriptor
+
+const file_services_chat_v1_types_proto_rawDesc = "" +
+	"\n" +
+	"\x1cservices/chat/v1/types.proto\x12\x10services.chat.v1\"\xdd\a\n" +
+	"\x04Room\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1b\n" +
+	"\tphoto_url\x18\x04 \x01(\tR\bphotoUrl\x12'\n" +
+	"\x0fencryption_data\x18\x05 \x01(\tR\x0eencryptionData\x12\x12\n" +
+	"\x04type\x18\x06 \x01(\tR\x04type\x12!\n" +
+	"\funread_count\x18\a \x01(\x05R\vunreadCount\x12\x12\n" +
+	"\x04role\x18\b \x01(\tR\x04role\x12\"\n" +
+	"\rjoin_all_user\x18\n" +
+	" \x01(\bR\vjoinAllUser\x12&\n" +
+	"\x0flast_message_at\x18\v \x01(\tR\rlastMessageAt\x12!\n" +
+	"\fsend_message\x18\f \x01(\bR\vsendMessage\x12\x1d\n" +
+	"\n" +
+	"add_member\x18\r \x01(\bR\taddMember\x12\x1d\n" +
+	"\n" +
+	"edit_group\x18\x0e \x01(\bR\teditGroup\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x0f \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x10 \x01(\tR\tupdatedAt\x12@\n" +
+	"\apartner\x18\x11 \x01(\v2!.services.chat.v1.RoomParticipantH\x00R\apartner\x88\x01\x01\x12E\n" +
+	"\fparticipants\x18\x12 \x03(\v2!.services.chat.v1.RoomParticipantR\fparticipants\x12,\n" +
+	"\x12is_partner_blocked\x18\x13 \x01(\bR\x10isPartnerBlocked\x12\x19\n" +
+	"\bis_muted\x18\x14 \x01(\bR\aisMuted\x12\x1b\n" +
+	"\tis_pinned\x18\x15 \x01(\bR\bisPinned\x12@\n" +
+	"\flast_message\x18\x16 \x01(\v2\x1d.services.chat.v1.MessageDataR\vlastMessage\x12*\n" +
+	"\x0eretention_days\x18\x17 \x01(\x05H\x01R\rretentionDays\x88\x01\x01\x122\n" +
+	"\x15history_purged_before\x18\x18 \x01(\tR\x13historyPurgedBefore\x120\n" +
+	"\x14unread_mention_count\x18\x19 \x01(\x05R\x12unreadMentionCount\x12\x1f\n" +
+	"\vkey_version\x18\x1a \x01(\x05R\n" +
+	"keyVersion\x12\x10\n" +
+	"\x03e2e\x18\x1b \x01(\bR\x03e2eB\n" +
+	"\n" +
+	"\b_partnerB\x11\n" +
+	"\x0f_retention_days\"\xcf\x01\n" +
+	"\x0fRoomParticipant\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
+	"\x05phone\x18\x02 \x01(\tR\x05phone\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x16\n" +
+	"\x06avatar\x18\x04 \x01(\tR\x06avatar\x12\x12\n" +
+	"\x04role\x18\x05 \x01(\tR\x04role\x12,\n" +
+	"\x12is_partner_blocked\x18\x06 \x01(\bR\x10isPartnerBlocked\x12(\n" +
+	"\x10is_partner_muted\x18\a \x01(\bR\x0eisPartnerMuted\"t\n" +
+	"\aMention\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x14\n" +
+	"\x05phone\x18\x04 \x01(\tR\x05phone\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x05 \x01(\tR\tmessageId\"\xf7\x01\n" +
+	"\bReaction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\breaction\x18\x02 \x01(\tR\breaction\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x03 \x01(\tR\tmessageId\x12\"\n" +
+	"\rreacted_by_id\x18\x04 \x01(\tR\vreactedById\x12&\n" +
+	"\x0freacted_by_name\x18\x05 \x01(\tR\rreactedByName\x12*\n" +
+	"\x11reacted_by_avatar\x18\x06 \x01(\tR\x0freactedByAvatar\x12(\n" +
+	"\x10reacted_by_phone\x18\a \x01(\tR\x0ereactedByPhone\"\xa4\x0e\n" +
+	"\vMessageData\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\aroom_id\x18\x02 \x01(\tR\x06roomId\x12\x1b\n" +
+	"\tsender_id\x18\x03 \x01(\x05R\bsenderId\x12\x1f\n" +
+	"\vsender_name\x18\x04 \x01(\tR\n" +
+	"senderName\x12#\n" +
+	"\rsender_avatar\x18\x05 \x01(\tR\fsenderAvatar\x12!\n" +
+	"\fsender_phone\x18\x06 \x01(\tR\vsenderPhone\x12\x18\n" +
+	"\acontent\x18\a \x01(\tR\acontent\x128\n" +
+	"\x05reply\x18\b \x01(\v2\x1d.services.chat.v1.MessageDataH\x00R\x05reply\x88\x01\x01\x125\n" +
+	"\x14forwarded_message_id\x18\t \x01(\tH\x01R\x12forwardedMessageId\x88\x01\x01\x12B\n" +
+	"\x1bforwarded_message_sender_id\x18\n" +
+	" \x01(\x05H\x02R\x18forwardedMessageSenderId\x88\x01\x01\x12F\n" +
+	"\x1dforwarded_message_sender_name\x18\v \x01(\tH\x03R\x1aforwardedMessageSenderName\x88\x01\x01\x12J\n" +
+	"\x1fforwarded_message_sender_avatar\x18\f \x01(\tH\x04R\x1cforwardedMessageSenderAvatar\x88\x01\x01\x12H\n" +
+	"\x1eforwarded_message_sender_phone\x18\r \x01(\tH\x05R\x1bforwardedMessageSenderPhone\x88\x01\x01\x125\n" +
+	"\bmentions\x18\x0e \x03(\v2\x19.services.chat.v1.MentionR\bmentions\x127\n" +
+	"\x06status\x18\x0f \x01(\x0e2\x1f.services.chat.v1.MessageStatusR\x06status\x12\x1d\n" +
+	"\n" +
 	"created_at\x18\x10 \x01(\tR\tcreatedAt\x12\x1d\n" +
 	"\n" +
 	"updated_at\x18\x11 \x01(\tR\tupdatedAt\x12\x12\n" +
@@ -4647,7 +7669,10 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\x04file\x18\x1e \x01(\tH\x0fR\x04file\x88\x01\x01\x128\n" +
 	"\treactions\x18\x1f \x03(\v2\x1a.services.chat.v1.ReactionR\treactions\x12\x19\n" +
 	"\x05event\x18  \x01(\tH\x10R\x05event\x88\x01\x01\x12/\n" +
-	"\x11sender_message_id\x18! \x01(\tH\x11R\x0fsenderMessageId\x88\x01\x01B\b\n" +
+	"\x11sender_message_id\x18! \x01(\tH\x11R\x0fsenderMessageId\x88\x01\x01\x12\x10\n" +
+	"\x03seq\x18\" \x01(\x03R\x03seq\x12\x1f\n" +
+	"\vkey_version\x18# \x01(\x05R\n" +
+	"keyVersionB\b\n" +
 	"\x06_replyB\x17\n" +
 	"\x15_forwarded_message_idB\x1e\n" +
 	"\x1c_forwarded_message_sender_idB \n" +
@@ -4676,7 +7701,17 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\busers_id\x18\x01 \x03(\x05R\ausersId\x12\x17\n" +
 	"\aleft_at\x18\x02 \x01(\tR\x06leftAt\x12\x1b\n" +
 	"\x06reason\x18\x03 \x01(\tH\x00R\x06reason\x88\x01\x01B\t\n" +
-	"\a_reason\"b\n" +
+	"\a_reason\"E\n" +
+	"\x12HistoryPurgedEvent\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x16\n" +
+	"\x06before\x18\x02 \x01(\tR\x06before\"\xd3\x01\n" +
+	"\x0eReadStateEvent\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x17\n" +
+	"\aroom_id\x18\x02 \x01(\tR\x06roomId\x12!\n" +
+	"\funread_count\x18\x03 \x01(\x05R\vunreadCount\x12/\n" +
+	"\x14last_read_message_id\x18\x04 \x01(\tR\x11lastReadMessageId\x12\"\n" +
+	"\rlast_read_seq\x18\x05 \x01(\x03R\vlastReadSeq\x12\x17\n" +
+	"\aread_at\x18\x06 \x01(\tR\x06readAt\"b\n" +
 	"\vTypingEvent\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x1b\n" +
 	"\tis_typing\x18\x02 \x01(\bR\bisTyping\x12\x1d\n" +
@@ -4694,7 +7729,7 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"ErrorEvent\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12\x18\n" +
-	"\adetails\x18\x03 \x01(\tR\adetails\"\xbb\x05\n" +
+	"\adetails\x18\x03 \x01(\tR\adetails\"\xcd\x06\n" +
 	"\fMessageEvent\x12/\n" +
 	"\x04room\x18\x01 \x01(\v2\x16.services.chat.v1.RoomH\x01R\x04room\x88\x01\x01\x12\x17\n" +
 	"\aroom_id\x18\x02 \x01(\tR\x06roomId\x12\x19\n" +
@@ -4710,12 +7745,15 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\x0eupdate_message\x18\n" +
 	" \x01(\v2\x1d.services.chat.v1.MessageDataH\x00R\rupdateMessage\x12'\n" +
 	"\x0edelete_message\x18\v \x01(\tH\x00R\rdeleteMessage\x12\x1e\n" +
-	"\tconnected\x18\f \x01(\bH\x00R\tconnectedB\a\n" +
+	"\tconnected\x18\f \x01(\bH\x00R\tconnected\x12A\n" +
+	"\n" +
+	"read_state\x18\r \x01(\v2 .services.chat.v1.ReadStateEventH\x00R\treadState\x12M\n" +
+	"\x0ehistory_purged\x18\x0e \x01(\v2$.services.chat.v1.HistoryPurgedEventH\x00R\rhistoryPurgedB\a\n" +
 	"\x05eventB\a\n" +
 	"\x05_room\"5\n" +
 	"\rCreateMention\x12\x10\n" +
 	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x12\n" +
-	"\x04user\x18\x02 \x01(\tR\x04user\"\xa0\x06\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\"\xd6\x06\n" +
 	"\x12SendMessageRequest\x12\x17\n" +
 	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x18\n" +
 	"\acontent\x18\x02 \x01(\tR\acontent\x12\x1e\n" +
@@ -4735,7 +7773,9 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"forward_id\x18\x0e \x01(\tH\tR\tforwardId\x88\x01\x01\x12\x19\n" +
 	"\x05event\x18\x0f \x01(\tH\n" +
 	"R\x05event\x88\x01\x01\x12/\n" +
-	"\x11sender_message_id\x18\x10 \x01(\tH\vR\x0fsenderMessageId\x88\x01\x01B\v\n" +
+	"\x11sender_message_id\x18\x10 \x01(\tH\vR\x0fsenderMessageId\x88\x01\x01\x12$\n" +
+	"\vkey_version\x18\x11 \x01(\x05H\fR\n" +
+	"keyVersion\x88\x01\x01B\v\n" +
 	"\t_reply_idB\v\n" +
 	"\t_lifetimeB\x10\n" +
 	"\x0e_location_nameB\x14\n" +
@@ -4747,17 +7787,21 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\x05_fileB\r\n" +
 	"\v_forward_idB\b\n" +
 	"\x06_eventB\x14\n" +
-	"\x12_sender_message_id\"\xa4\x01\n" +
+	"\x12_sender_message_idB\x0e\n" +
+	"\f_key_version\"\xa4\x01\n" +
 	"\x13SendMessageResponse\x127\n" +
 	"\amessage\x18\x01 \x01(\v2\x1d.services.chat.v1.MessageDataR\amessage\x12\x18\n" +
 	"\asuccess\x18\x02 \x01(\bR\asuccess\x12(\n" +
 	"\rerror_message\x18\x03 \x01(\tH\x00R\ferrorMessage\x88\x01\x01B\x10\n" +
-	"\x0e_error_message\"T\n" +
+	"\x0e_error_message\"\x8a\x01\n" +
 	"\x12EditMessageRequest\x12\x1d\n" +
 	"\n" +
 	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x1f\n" +
 	"\vnew_content\x18\x02 \x01(\tR\n" +
-	"newContent\"\xa4\x01\n" +
+	"newContent\x12$\n" +
+	"\vkey_version\x18\x03 \x01(\x05H\x00R\n" +
+	"keyVersion\x88\x01\x01B\x0e\n" +
+	"\f_key_version\"\xa4\x01\n" +
 	"\x13EditMessageResponse\x127\n" +
 	"\amessage\x18\x01 \x01(\v2\x1d.services.chat.v1.MessageDataR\amessage\x12\x18\n" +
 	"\asuccess\x18\x02 \x01(\bR\asuccess\x12(\n" +
@@ -4779,7 +7823,7 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12!\n" +
 	"\fmarked_count\x18\x02 \x01(\x05R\vmarkedCount\x12(\n" +
 	"\rerror_message\x18\x03 \x01(\tH\x00R\ferrorMessage\x88\x01\x01B\x10\n" +
-	"\x0e_error_message\"\xf4\x02\n" +
+	"\x0e_error_message\"\xef\x03\n" +
 	"\x18GetMessageHistoryRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04page\x18\x02 \x01(\rR\x04page\x12\x14\n" +
@@ -4790,20 +7834,29 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\x10after_message_id\x18\x06 \x01(\tH\x02R\x0eafterMessageId\x88\x01\x01\x12\"\n" +
 	"\n" +
 	"after_date\x18\a \x01(\tH\x03R\tafterDate\x88\x01\x01\x12*\n" +
-	"\x11messages_per_room\x18\b \x01(\rR\x0fmessagesPerRoomB\x14\n" +
+	"\x11messages_per_room\x18\b \x01(\rR\x0fmessagesPerRoom\x12 \n" +
+	"\tafter_seq\x18\t \x01(\x03H\x04R\bafterSeq\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"before_seq\x18\n" +
+	" \x01(\x03H\x05R\tbeforeSeq\x88\x01\x01\x12\x16\n" +
+	"\x06cursor\x18\v \x01(\tR\x06cursorB\x14\n" +
 	"\x12_before_message_idB\x0e\n" +
 	"\f_before_dateB\x13\n" +
 	"\x11_after_message_idB\r\n" +
-	"\v_after_date\"\x86\x01\n" +
+	"\v_after_dateB\f\n" +
+	"\n" +
+	"_after_seqB\r\n" +
+	"\v_before_seq\"\x86\x01\n" +
 	"\x19GetMessageHistoryResponse\x123\n" +
 	"\x05items\x18\x01 \x03(\v2\x1d.services.chat.v1.MessageDataR\x05items\x124\n" +
-	"\x04meta\x18\x02 \x01(\v2 .services.chat.v1.PaginationMetaR\x04meta\"}\n" +
+	"\x04meta\x18\x02 \x01(\v2 .services.chat.v1.PaginationMetaR\x04meta\"\x95\x01\n" +
 	"\x0fGetRoomsRequest\x12\x12\n" +
 	"\x04page\x18\x01 \x01(\rR\x04page\x12\x14\n" +
 	"\x05limit\x18\x02 \x01(\rR\x05limit\x12\x16\n" +
 	"\x06search\x18\x03 \x01(\tR\x06search\x12\x12\n" +
 	"\x04type\x18\x04 \x01(\tR\x04type\x12\x14\n" +
-	"\x05since\x18\x05 \x01(\tR\x05since\"v\n" +
+	"\x05since\x18\x05 \x01(\tR\x05since\x12\x16\n" +
+	"\x06cursor\x18\x06 \x01(\tR\x06cursor\"v\n" +
 	"\x10GetRoomsResponse\x12,\n" +
 	"\x05items\x18\x01 \x03(\v2\x16.services.chat.v1.RoomR\x05items\x124\n" +
 	"\x04meta\x18\x02 \x01(\v2 .services.chat.v1.PaginationMetaR\x04meta\"\xeb\x01\n" +
@@ -4827,7 +7880,7 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\frooms_synced\x18\x01 \x01(\x05R\vroomsSynced\x12#\n" +
 	"\rrooms_deleted\x18\x02 \x01(\x05R\froomsDeleted\x12'\n" +
 	"\x0fmessages_synced\x18\x03 \x01(\x05R\x0emessagesSynced\x12(\n" +
-	"\x10sync_duration_ms\x18\x04 \x01(\tR\x0esyncDurationMs\"\xba\x01\n" +
+	"\x10sync_duration_ms\x18\x04 \x01(\tR\x0esyncDurationMs\"\xdb\x01\n" +
 	"\x0ePaginationMeta\x12\x1f\n" +
 	"\vtotal_items\x18\x01 \x01(\rR\n" +
 	"totalItems\x12\x1d\n" +
@@ -4836,11 +7889,22 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\x0eitems_per_page\x18\x03 \x01(\rR\fitemsPerPage\x12\x1f\n" +
 	"\vtotal_pages\x18\x04 \x01(\rR\n" +
 	"totalPages\x12!\n" +
-	"\fcurrent_page\x18\x05 \x01(\rR\vcurrentPage\"A\n" +
+	"\fcurrent_page\x18\x05 \x01(\rR\vcurrentPage\x12\x1f\n" +
+	"\vnext_cursor\x18\x06 \x01(\tR\n" +
+	"nextCursor\"\xa0\x01\n" +
 	"\x15StreamMessagesRequest\x12\x1c\n" +
-	"\aroom_id\x18\x01 \x01(\tH\x00R\x06roomId\x88\x01\x01B\n" +
+	"\aroom_id\x18\x01 \x01(\tH\x00R\x06roomId\x88\x01\x01\x12\x19\n" +
+	"\broom_ids\x18\x02 \x03(\tR\aroomIds\x12B\n" +
+	"\vevent_types\x18\x03 \x03(\x0e2!.services.chat.v1.StreamEventTypeR\n" +
+	"eventTypesB\n" +
 	"\n" +
-	"\b_room_id\"\xf3\x02\n" +
+	"\b_room_id\"\x80\x01\n" +
+	"\x1fUpdateStreamSubscriptionRequest\x12\x19\n" +
+	"\broom_ids\x18\x01 \x03(\tR\aroomIds\x12B\n" +
+	"\vevent_types\x18\x02 \x03(\x0e2!.services.chat.v1.StreamEventTypeR\n" +
+	"eventTypes\"<\n" +
+	" UpdateStreamSubscriptionResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x92\x03\n" +
 	"\x11CreateRoomRequest\x12\x12\n" +
 	"\x04type\x18\x01 \x01(\tR\x04type\x12\x17\n" +
 	"\x04name\x18\x02 \x01(\tH\x00R\x04name\x88\x01\x01\x12%\n" +
@@ -4852,14 +7916,16 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\n" +
 	"edit_group\x18\b \x01(\bH\x05R\teditGroup\x88\x01\x01\x12\"\n" +
 	"\fparticipants\x18\n" +
-	" \x03(\x05R\fparticipantsB\a\n" +
+	" \x03(\x05R\fparticipants\x12\x15\n" +
+	"\x03e2e\x18\v \x01(\bH\x06R\x03e2e\x88\x01\x01B\a\n" +
 	"\x05_nameB\x0e\n" +
 	"\f_descriptionB\f\n" +
 	"\n" +
 	"_photo_urlB\x0f\n" +
 	"\r_send_messageB\r\n" +
 	"\v_add_memberB\r\n" +
-	"\v_edit_group\"\xa4\x01\n" +
+	"\v_edit_groupB\x06\n" +
+	"\x04_e2e\"\xa4\x01\n" +
 	"\x12CreateRoomResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12(\n" +
 	"\rerror_message\x18\x02 \x01(\tH\x00R\ferrorMessage\x88\x01\x01\x12/\n" +
@@ -4902,15 +7968,16 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\rerror_message\x18\x02 \x01(\tH\x00R\ferrorMessage\x88\x01\x01\x12/\n" +
 	"\x04room\x18\x03 \x01(\v2\x16.services.chat.v1.RoomH\x01R\x04room\x88\x01\x01B\x10\n" +
 	"\x0e_error_messageB\a\n" +
-	"\x05_room\"n\n" +
+	"\x05_room\"\x86\x01\n" +
 	"\x1aGetRoomParticipantsRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04page\x18\x02 \x01(\rR\x04page\x12\x14\n" +
 	"\x05limit\x18\x03 \x01(\rR\x05limit\x12\x16\n" +
-	"\x06search\x18\x04 \x01(\tR\x06search\"\x9a\x01\n" +
+	"\x06search\x18\x04 \x01(\tR\x06search\x12\x16\n" +
+	"\x06cursor\x18\x05 \x01(\tR\x06cursor\"\x9a\x01\n" +
 	"\x1bGetRoomParticipantsResponse\x12E\n" +
 	"\fparticipants\x18\x01 \x03(\v2!.services.chat.v1.RoomParticipantR\fparticipants\x124\n" +
-	"\x04meta\x18\x02 \x01(\v2 .services.chat.v1.PaginationMetaR\x04meta\"\xcb\x02\n" +
+	"\x04meta\x18\x02 \x01(\v2 .services.chat.v1.PaginationMetaR\x04meta\"\x8a\x03\n" +
 	"\x11UpdateRoomRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
 	"\x04name\x18\x02 \x01(\tH\x00R\x04name\x88\x01\x01\x12%\n" +
@@ -4920,14 +7987,16 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\n" +
 	"add_member\x18\x06 \x01(\bH\x04R\taddMember\x88\x01\x01\x12\"\n" +
 	"\n" +
-	"edit_group\x18\a \x01(\bH\x05R\teditGroup\x88\x01\x01B\a\n" +
+	"edit_group\x18\a \x01(\bH\x05R\teditGroup\x88\x01\x01\x12*\n" +
+	"\x0eretention_days\x18\b \x01(\x05H\x06R\rretentionDays\x88\x01\x01B\a\n" +
 	"\x05_nameB\x0e\n" +
 	"\f_descriptionB\f\n" +
 	"\n" +
 	"_photo_urlB\x0f\n" +
 	"\r_send_messageB\r\n" +
 	"\v_add_memberB\r\n" +
-	"\v_edit_group\"j\n" +
+	"\v_edit_groupB\x11\n" +
+	"\x0f_retention_days\"j\n" +
 	"\x12UpdateRoomResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12(\n" +
 	"\rerror_message\x18\x02 \x01(\tH\x00R\ferrorMessage\x88\x01\x01B\x10\n" +
@@ -4966,11 +8035,12 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\x16ReactToMessageResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12(\n" +
 	"\rerror_message\x18\x02 \x01(\tH\x00R\ferrorMessage\x88\x01\x01B\x10\n" +
-	"\x0e_error_message\"Q\n" +
+	"\x0e_error_message\"i\n" +
 	"\x15GetMessageReadRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04page\x18\x02 \x01(\rR\x04page\x12\x14\n" +
-	"\x05limit\x18\x03 \x01(\rR\x05limit\"\xa0\x01\n" +
+	"\x05limit\x18\x03 \x01(\rR\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\"\xa0\x01\n" +
 	"\x0fMessageUserRead\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x1b\n" +
 	"\tuser_name\x18\x02 \x01(\tR\buserName\x12\x1f\n" +
@@ -4981,14 +8051,193 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\aread_at\x18\x05 \x01(\tR\x06readAt\"\x87\x01\n" +
 	"\x16GetMessageReadResponse\x127\n" +
 	"\x05items\x18\x01 \x03(\v2!.services.chat.v1.MessageUserReadR\x05items\x124\n" +
-	"\x04meta\x18\x02 \x01(\v2 .services.chat.v1.PaginationMetaR\x04meta\"V\n" +
+	"\x04meta\x18\x02 \x01(\v2 .services.chat.v1.PaginationMetaR\x04meta\"n\n" +
 	"\x1aGetMessageReactionsRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04page\x18\x02 \x01(\rR\x04page\x12\x14\n" +
-	"\x05limit\x18\x03 \x01(\rR\x05limit\"\x85\x01\n" +
+	"\x05limit\x18\x03 \x01(\rR\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\"\x85\x01\n" +
 	"\x1bGetMessageReactionsResponse\x120\n" +
 	"\x05items\x18\x01 \x03(\v2\x1a.services.chat.v1.ReactionR\x05items\x124\n" +
-	"\x04meta\x18\x02 \x01(\v2 .services.chat.v1.PaginationMetaR\x04meta*\xb5\x01\n" +
+	"\x04meta\x18\x02 \x01(\v2 .services.chat.v1.PaginationMetaR\x04meta\"\xe5\x03\n" +
+	"\x11RoomHistoryExport\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\aroom_id\x18\x02 \x01(\tR\x06roomId\x126\n" +
+	"\x06format\x18\x03 \x01(\x0e2\x1e.services.chat.v1.ExportFormatR\x06format\x126\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x1e.services.chat.v1.ExportStatusR\x06status\x12+\n" +
+	"\x11messages_exported\x18\x05 \x01(\x03R\x10messagesExported\x12%\n" +
+	"\x0emessages_total\x18\x06 \x01(\x03R\rmessagesTotal\x12#\n" +
+	"\rerror_message\x18\a \x01(\tR\ferrorMessage\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\tR\tupdatedAt\x12\x1b\n" +
+	"\tfile_name\x18\n" +
+	" \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\v \x01(\tR\vcontentType\x12!\n" +
+	"\frequested_by\x18\f \x01(\x05R\vrequestedBy\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\r \x01(\x03R\tsizeBytes\"b\n" +
+	"\x18ExportRoomHistoryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x126\n" +
+	"\x06format\x18\x02 \x01(\x0e2\x1e.services.chat.v1.ExportFormatR\x06format\"X\n" +
+	"\x19ExportRoomHistoryResponse\x12;\n" +
+	"\x06export\x18\x01 \x01(\v2#.services.chat.v1.RoomHistoryExportR\x06export\"-\n" +
+	"\x1bGetRoomHistoryExportRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"j\n" +
+	"\x1cGetRoomHistoryExportResponse\x12;\n" +
+	"\x06export\x18\x01 \x01(\v2#.services.chat.v1.RoomHistoryExportR\x06exportJ\x04\b\x02\x10\x03R\acontent\"2\n" +
+	" DownloadRoomHistoryExportRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x98\x01\n" +
+	"!DownloadRoomHistoryExportResponse\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x03 \x01(\x03R\tsizeBytes\x12\x14\n" +
+	"\x05chunk\x18\x04 \x01(\fR\x05chunk\"\xb5\x03\n" +
+	"\x0eUserDataExport\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x05R\x06userId\x126\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x1e.services.chat.v1.ExportStatusR\x06status\x12%\n" +
+	"\x0erooms_exported\x18\x04 \x01(\x05R\rroomsExported\x12\x1f\n" +
+	"\vrooms_total\x18\x05 \x01(\x05R\n" +
+	"roomsTotal\x12#\n" +
+	"\rerror_message\x18\x06 \x01(\tR\ferrorMessage\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\tR\tupdatedAt\x12\x1b\n" +
+	"\tfile_name\x18\t \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\n" +
+	" \x01(\tR\vcontentType\x12'\n" +
+	"\x0fdownload_handle\x18\v \x01(\tR\x0edownloadHandle\x12.\n" +
+	"\x13download_expires_at\x18\f \x01(\tR\x11downloadExpiresAt\"\x17\n" +
+	"\x15ExportUserDataRequest\"R\n" +
+	"\x16ExportUserDataResponse\x128\n" +
+	"\x06export\x18\x01 \x01(\v2 .services.chat.v1.UserDataExportR\x06export\"*\n" +
+	"\x18GetUserDataExportRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"U\n" +
+	"\x19GetUserDataExportResponse\x128\n" +
+	"\x06export\x18\x01 \x01(\v2 .services.chat.v1.UserDataExportR\x06export\"7\n" +
+	"\x1dDownloadUserDataExportRequest\x12\x16\n" +
+	"\x06handle\x18\x01 \x01(\tR\x06handle\"z\n" +
+	"\x1eDownloadUserDataExportResponse\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\"\xc2\x03\n" +
+	"\x11UserErasureReport\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12/\n" +
+	"\x13messages_anonymised\x18\x02 \x01(\x05R\x12messagesAnonymised\x12%\n" +
+	"\x0etokens_deleted\x18\x03 \x01(\x03R\rtokensDeleted\x12\x1d\n" +
+	"\n" +
+	"rooms_left\x18\x04 \x03(\tR\troomsLeft\x12!\n" +
+	"\frooms_closed\x18\x05 \x03(\tR\vroomsClosed\x12`\n" +
+	"\x0fowners_promoted\x18\x06 \x03(\v27.services.chat.v1.UserErasureReport.OwnersPromotedEntryR\x0eownersPromoted\x12\x1c\n" +
+	"\tremaining\x18\a \x03(\tR\tremaining\x12\x1a\n" +
+	"\bverified\x18\b \x01(\bR\bverified\x12\x1b\n" +
+	"\terased_at\x18\t \x01(\tR\berasedAt\x1aA\n" +
+	"\x13OwnersPromotedEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"/\n" +
+	"\x14EraseUserDataRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\"T\n" +
+	"\x15EraseUserDataResponse\x12;\n" +
+	"\x06report\x18\x01 \x01(\v2#.services.chat.v1.UserErasureReportR\x06report\"V\n" +
+	"\fRoomCacheKey\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x19\n" +
+	"\bin_redis\x18\x02 \x01(\bR\ainRedis\x12\x19\n" +
+	"\bin_local\x18\x03 \x01(\bR\ainLocal\"3\n" +
+	"\x18ListRoomCacheKeysRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\"i\n" +
+	"\x19ListRoomCacheKeysResponse\x122\n" +
+	"\x04keys\x18\x01 \x03(\v2\x1e.services.chat.v1.RoomCacheKeyR\x04keys\x12\x18\n" +
+	"\areplica\x18\x02 \x01(\tR\areplica\"\xa6\x01\n" +
+	"\n" +
+	"CacheEntry\x12\x12\n" +
+	"\x04tier\x18\x01 \x01(\tR\x04tier\x12,\n" +
+	"\x04room\x18\x02 \x01(\v2\x16.services.chat.v1.RoomH\x00R\x04room\x129\n" +
+	"\amessage\x18\x03 \x01(\v2\x1d.services.chat.v1.MessageDataH\x00R\amessage\x12\x12\n" +
+	"\x03raw\x18\x04 \x01(\tH\x00R\x03rawB\a\n" +
+	"\x05value\"(\n" +
+	"\x14GetCacheEntryRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"i\n" +
+	"\x15GetCacheEntryResponse\x126\n" +
+	"\aentries\x18\x01 \x03(\v2\x1c.services.chat.v1.CacheEntryR\aentries\x12\x18\n" +
+	"\areplica\x18\x02 \x01(\tR\areplica\"S\n" +
+	"\x11FlushCacheRequest\x12\x19\n" +
+	"\aroom_id\x18\x01 \x01(\tH\x00R\x06roomId\x12\x19\n" +
+	"\auser_id\x18\x02 \x01(\x05H\x00R\x06userIdB\b\n" +
+	"\x06target\"7\n" +
+	"\x12FlushCacheResponse\x12!\n" +
+	"\fkeys_deleted\x18\x01 \x01(\x05R\vkeysDeleted\"b\n" +
+	"\n" +
+	"CacheStats\x12\x1d\n" +
+	"\n" +
+	"local_hits\x18\x01 \x01(\x03R\tlocalHits\x12\x1d\n" +
+	"\n" +
+	"redis_hits\x18\x02 \x01(\x03R\tredisHits\x12\x16\n" +
+	"\x06misses\x18\x03 \x01(\x03R\x06misses\"\x16\n" +
+	"\x14GetCacheStatsRequest\"\x9f\x01\n" +
+	"\x15GetCacheStatsResponse\x122\n" +
+	"\x05rooms\x18\x01 \x01(\v2\x1c.services.chat.v1.CacheStatsR\x05rooms\x128\n" +
+	"\bmessages\x18\x02 \x01(\v2\x1c.services.chat.v1.CacheStatsR\bmessages\x12\x18\n" +
+	"\areplica\x18\x03 \x01(\tR\areplica\"\xc9\x01\n" +
+	"\aRoomKey\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x05R\aversion\x12'\n" +
+	"\x0fencryption_data\x18\x02 \x01(\tR\x0eencryptionData\x12\x1d\n" +
+	"\n" +
+	"retired_at\x18\x03 \x01(\tR\tretiredAt\x12+\n" +
+	"\x12wrapped_by_user_id\x18\x04 \x01(\x05R\x0fwrappedByUserId\x12/\n" +
+	"\x14wrapped_by_device_id\x18\x05 \x01(\tR\x11wrappedByDeviceId\"\x95\x01\n" +
+	"\x14RotateRoomKeyRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12 \n" +
+	"\tdevice_id\x18\x02 \x01(\tH\x00R\bdeviceId\x88\x01\x01\x124\n" +
+	"\x04keys\x18\x03 \x03(\v2 .services.chat.v1.WrappedRoomKeyR\x04keysB\f\n" +
+	"\n" +
+	"_device_id\"8\n" +
+	"\x15RotateRoomKeyResponse\x12\x1f\n" +
+	"\vkey_version\x18\x01 \x01(\x05R\n" +
+	"keyVersion\"]\n" +
+	"\x12GetRoomKeysRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12 \n" +
+	"\tdevice_id\x18\x02 \x01(\tH\x00R\bdeviceId\x88\x01\x01B\f\n" +
+	"\n" +
+	"_device_id\"D\n" +
+	"\x13GetRoomKeysResponse\x12-\n" +
+	"\x04keys\x18\x01 \x03(\v2\x19.services.chat.v1.RoomKeyR\x04keys\"\x7f\n" +
+	"\tDeviceKey\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x1b\n" +
+	"\tdevice_id\x18\x02 \x01(\tR\bdeviceId\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x03 \x01(\tR\tpublicKey\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\tR\tupdatedAt\"g\n" +
+	"\x0eWrappedRoomKey\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x1b\n" +
+	"\tdevice_id\x18\x02 \x01(\tR\bdeviceId\x12\x1f\n" +
+	"\vwrapped_key\x18\x03 \x01(\tR\n" +
+	"wrappedKey\"V\n" +
+	"\x18RegisterDeviceKeyRequest\x12\x1b\n" +
+	"\tdevice_id\x18\x01 \x01(\tR\bdeviceId\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\tR\tpublicKey\"5\n" +
+	"\x19RegisterDeviceKeyResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"[\n" +
+	"\x14GetDeviceKeysRequest\x12\x19\n" +
+	"\buser_ids\x18\x01 \x03(\x05R\auserIds\x12\x1c\n" +
+	"\aroom_id\x18\x02 \x01(\tH\x00R\x06roomId\x88\x01\x01B\n" +
+	"\n" +
+	"\b_room_id\"H\n" +
+	"\x15GetDeviceKeysResponse\x12/\n" +
+	"\x04keys\x18\x01 \x03(\v2\x1b.services.chat.v1.DeviceKeyR\x04keys\"\xa2\x01\n" +
+	"\x13ShareRoomKeyRequest\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x1f\n" +
+	"\vkey_version\x18\x02 \x01(\x05R\n" +
+	"keyVersion\x12\x1b\n" +
+	"\tdevice_id\x18\x03 \x01(\tR\bdeviceId\x124\n" +
+	"\x04keys\x18\x04 \x03(\v2 .services.chat.v1.WrappedRoomKeyR\x04keys\"0\n" +
+	"\x14ShareRoomKeyResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess*\xb5\x01\n" +
 	"\rMessageStatus\x12\x1e\n" +
 	"\x1aMESSAGE_STATUS_UNSPECIFIED\x10\x00\x12\x1a\n" +
 	"\x16MESSAGE_STATUS_SENDING\x10\x01\x12\x17\n" +
@@ -5001,7 +8250,32 @@ const file_services_chat_v1_types_proto_rawDesc = "" +
 	"\x12SYNC_STRATEGY_FULL\x10\x01\x12\x18\n" +
 	"\x14SYNC_STRATEGY_RECENT\x10\x02\x12\x19\n" +
 	"\x15SYNC_STRATEGY_MINIMAL\x10\x03\x12\x17\n" +
-	"\x13SYNC_STRATEGY_SMART\x10\x04B\xea\x01\n" +
+	"\x13SYNC_STRATEGY_SMART\x10\x04*\xae\x03\n" +
+	"\x0fStreamEventType\x12!\n" +
+	"\x1dSTREAM_EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19STREAM_EVENT_TYPE_MESSAGE\x10\x01\x12#\n" +
+	"\x1fSTREAM_EVENT_TYPE_STATUS_UPDATE\x10\x02\x12\"\n" +
+	"\x1eSTREAM_EVENT_TYPE_ROOM_UPDATED\x10\x03\x12\x1f\n" +
+	"\x1bSTREAM_EVENT_TYPE_ROOM_JOIN\x10\x04\x12 \n" +
+	"\x1cSTREAM_EVENT_TYPE_ROOM_LEAVE\x10\x05\x12\x1c\n" +
+	"\x18STREAM_EVENT_TYPE_TYPING\x10\x06\x12\x1b\n" +
+	"\x17STREAM_EVENT_TYPE_ERROR\x10\a\x12$\n" +
+	" STREAM_EVENT_TYPE_UPDATE_MESSAGE\x10\b\x12$\n" +
+	" STREAM_EVENT_TYPE_DELETE_MESSAGE\x10\t\x12 \n" +
+	"\x1cSTREAM_EVENT_TYPE_READ_STATE\x10\n" +
+	"\x12$\n" +
+	" STREAM_EVENT_TYPE_HISTORY_PURGED\x10\v*t\n" +
+	"\fExportFormat\x12\x1d\n" +
+	"\x19EXPORT_FORMAT_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12EXPORT_FORMAT_JSON\x10\x01\x12\x15\n" +
+	"\x11EXPORT_FORMAT_CSV\x10\x02\x12\x16\n" +
+	"\x12EXPORT_FORMAT_HTML\x10\x03*\x9a\x01\n" +
+	"\fExportStatus\x12\x1d\n" +
+	"\x19EXPORT_STATUS_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15EXPORT_STATUS_PENDING\x10\x01\x12\x19\n" +
+	"\x15EXPORT_STATUS_RUNNING\x10\x02\x12\x1b\n" +
+	"\x17EXPORT_STATUS_COMPLETED\x10\x03\x12\x18\n" +
+	"\x14EXPORT_STATUS_FAILED\x10\x04B\xea\x01\n" +
 	"\x14com.services.chat.v1B\n" +
 	"TypesProtoP\x01Zdgithub.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1;chatv1\xa2\x02\x03SCX\xaa\x02\x10Services.Chat.V1\xca\x02\x10Services\\Chat\\V1\xe2\x02\x1cServices\\Chat\\V1\\GPBMetadata\xea\x02\x12Services::Chat::V1b\x06proto3"
 
@@ -5017,120 +8291,193 @@ func file_services_chat_v1_types_proto_rawDescGZIP() []byte {
 	return file_services_chat_v1_types_proto_rawDescData
 }
 
-var file_services_chat_v1_types_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_services_chat_v1_types_proto_msgTypes = make([]protoimpl.MessageInfo, 62)
+var file_services_chat_v1_types_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_services_chat_v1_types_proto_msgTypes = make([]protoimpl.MessageInfo, 108)
 var file_services_chat_v1_types_proto_goTypes = []any{
-	(MessageStatus)(0),                    // 0: services.chat.v1.MessageStatus
-	(SyncStrategy)(0),                     // 1: services.chat.v1.SyncStrategy
-	(*Room)(nil),                          // 2: services.chat.v1.Room
-	(*RoomParticipant)(nil),               // 3: services.chat.v1.RoomParticipant
-	(*Mention)(nil),                       // 4: services.chat.v1.Mention
-	(*Reaction)(nil),                      // 5: services.chat.v1.Reaction
-	(*MessageData)(nil),                   // 6: services.chat.v1.MessageData
-	(*RoomJoinEvent)(nil),                 // 7: services.chat.v1.RoomJoinEvent
-	(*RoomLeaveEvent)(nil),                // 8: services.chat.v1.RoomLeaveEvent
-	(*TypingEvent)(nil),                   // 9: services.chat.v1.TypingEvent
-	(*MessageStatusUpdate)(nil),           // 10: services.chat.v1.MessageStatusUpdate
-	(*ErrorEvent)(nil),                    // 11: services.chat.v1.ErrorEvent
-	(*MessageEvent)(nil),                  // 12: services.chat.v1.MessageEvent
-	(*CreateMention)(nil),                 // 13: services.chat.v1.CreateMention
-	(*SendMessageRequest)(nil),            // 14: services.chat.v1.SendMessageRequest
-	(*SendMessageResponse)(nil),           // 15: services.chat.v1.SendMessageResponse
-	(*EditMessageRequest)(nil),            // 16: services.chat.v1.EditMessageRequest
-	(*EditMessageResponse)(nil),           // 17: services.chat.v1.EditMessageResponse
-	(*DeleteMessageRequest)(nil),          // 18: services.chat.v1.DeleteMessageRequest
-	(*DeleteMessageResponse)(nil),         // 19: services.chat.v1.DeleteMessageResponse
-	(*MarkMessagesAsReadRequest)(nil),     // 20: services.chat.v1.MarkMessagesAsReadRequest
-	(*MarkMessagesAsReadResponse)(nil),    // 21: services.chat.v1.MarkMessagesAsReadResponse
-	(*GetMessageHistoryRequest)(nil),      // 22: services.chat.v1.GetMessageHistoryRequest
-	(*GetMessageHistoryResponse)(nil),     // 23: services.chat.v1.GetMessageHistoryResponse
-	(*GetRoomsRequest)(nil),               // 24: services.chat.v1.GetRoomsRequest
-	(*GetRoomsResponse)(nil),              // 25: services.chat.v1.GetRoomsResponse
-	(*InitialSyncRequest)(nil),            // 26: services.chat.v1.InitialSyncRequest
-	(*InitialSyncResponse)(nil),           // 27: services.chat.v1.InitialSyncResponse
-	(*RoomWithMessages)(nil),              // 28: services.chat.v1.RoomWithMessages
-	(*SyncSummary)(nil),                   // 29: services.chat.v1.SyncSummary
-	(*PaginationMeta)(nil),                // 30: services.chat.v1.PaginationMeta
-	(*StreamMessagesRequest)(nil),         // 31: services.chat.v1.StreamMessagesRequest
-	(*CreateRoomRequest)(nil),             // 32: services.chat.v1.CreateRoomRequest
-	(*CreateRoomResponse)(nil),            // 33: services.chat.v1.CreateRoomResponse
-	(*PinRoomRequest)(nil),                // 34: services.chat.v1.PinRoomRequest
-	(*PinRoomResponse)(nil),               // 35: services.chat.v1.PinRoomResponse
-	(*MuteRoomRequest)(nil),               // 36: services.chat.v1.MuteRoomRequest
-	(*MuteRoomResponse)(nil),              // 37: services.chat.v1.MuteRoomResponse
-	(*JoinRoomRequest)(nil),               // 38: services.chat.v1.JoinRoomRequest
-	(*JoinRoomResponse)(nil),              // 39: services.chat.v1.JoinRoomResponse
-	(*LeaveRoomRequest)(nil),              // 40: services.chat.v1.LeaveRoomRequest
-	(*LeaveRoomResponse)(nil),             // 41: services.chat.v1.LeaveRoomResponse
-	(*GetRoomRequest)(nil),                // 42: services.chat.v1.GetRoomRequest
-	(*GetRoomResponse)(nil),               // 43: services.chat.v1.GetRoomResponse
-	(*GetRoomParticipantsRequest)(nil),    // 44: services.chat.v1.GetRoomParticipantsRequest
-	(*GetRoomParticipantsResponse)(nil),   // 45: services.chat.v1.GetRoomParticipantsResponse
-	(*UpdateRoomRequest)(nil),             // 46: services.chat.v1.UpdateRoomRequest
-	(*UpdateRoomResponse)(nil),            // 47: services.chat.v1.UpdateRoomResponse
-	(*AddParticipantToRoomRequest)(nil),   // 48: services.chat.v1.AddParticipantToRoomRequest
-	(*AddParticipantToRoomResponse)(nil),  // 49: services.chat.v1.AddParticipantToRoomResponse
-	(*UpdateParticipantRoomRequest)(nil),  // 50: services.chat.v1.UpdateParticipantRoomRequest
-	(*UpdateParticipantRoomResponse)(nil), // 51: services.chat.v1.UpdateParticipantRoomResponse
-	(*BlockUserRequest)(nil),              // 52: services.chat.v1.BlockUserRequest
-	(*BlockUserResponse)(nil),             // 53: services.chat.v1.BlockUserResponse
-	(*GetMessageRequest)(nil),             // 54: services.chat.v1.GetMessageRequest
-	(*GetSenderMessageRequest)(nil),       // 55: services.chat.v1.GetSenderMessageRequest
-	(*GetSenderMessageResponse)(nil),      // 56: services.chat.v1.GetSenderMessageResponse
-	(*ReactToMessageRequest)(nil),         // 57: services.chat.v1.ReactToMessageRequest
-	(*ReactToMessageResponse)(nil),        // 58: services.chat.v1.ReactToMessageResponse
-	(*GetMessageReadRequest)(nil),         // 59: services.chat.v1.GetMessageReadRequest
-	(*MessageUserRead)(nil),               // 60: services.chat.v1.MessageUserRead
-	(*GetMessageReadResponse)(nil),        // 61: services.chat.v1.GetMessageReadResponse
-	(*GetMessageReactionsRequest)(nil),    // 62: services.chat.v1.GetMessageReactionsRequest
-	(*GetMessageReactionsResponse)(nil),   // 63: services.chat.v1.GetMessageReactionsResponse
+	(MessageStatus)(0),                        // 0: services.chat.v1.MessageStatus
+	(SyncStrategy)(0),                         // 1: services.chat.v1.SyncStrategy
+	(StreamEventType)(0),                      // 2: services.chat.v1.StreamEventType
+	(ExportFormat)(0),                         // 3: services.chat.v1.ExportFormat
+	(ExportStatus)(0),                         // 4: services.chat.v1.ExportStatus
+	(*Room)(nil),                              // 5: services.chat.v1.Room
+	(*RoomParticipant)(nil),                   // 6: services.chat.v1.RoomParticipant
+	(*Mention)(nil),                           // 7: services.chat.v1.Mention
+	(*Reaction)(nil),                          // 8: services.chat.v1.Reaction
+	(*MessageData)(nil),                       // 9: services.chat.v1.MessageData
+	(*RoomJoinEvent)(nil),                     // 10: services.chat.v1.RoomJoinEvent
+	(*RoomLeaveEvent)(nil),                    // 11: services.chat.v1.RoomLeaveEvent
+	(*HistoryPurgedEvent)(nil),                // 12: services.chat.v1.HistoryPurgedEvent
+	(*ReadStateEvent)(nil),                    // 13: services.chat.v1.ReadStateEvent
+	(*TypingEvent)(nil),                       // 14: services.chat.v1.TypingEvent
+	(*MessageStatusUpdate)(nil),               // 15: services.chat.v1.MessageStatusUpdate
+	(*ErrorEvent)(nil),                        // 16: services.chat.v1.ErrorEvent
+	(*MessageEvent)(nil),                      // 17: services.chat.v1.MessageEvent
+	(*CreateMention)(nil),                     // 18: services.chat.v1.CreateMention
+	(*SendMessageRequest)(nil),                // 19: services.chat.v1.SendMessageRequest
+	(*SendMessageResponse)(nil),               // 20: services.chat.v1.SendMessageResponse
+	(*EditMessageRequest)(nil),                // 21: services.chat.v1.EditMessageRequest
+	(*EditMessageResponse)(nil),               // 22: services.chat.v1.EditMessageResponse
+	(*DeleteMessageRequest)(nil),              // 23: services.chat.v1.DeleteMessageRequest
+	(*DeleteMessageResponse)(nil),             // 24: services.chat.v1.DeleteMessageResponse
+	(*MarkMessagesAsReadRequest)(nil),         // 25: services.chat.v1.MarkMessagesAsReadRequest
+	(*MarkMessagesAsReadResponse)(nil),        // 26: services.chat.v1.MarkMessagesAsReadResponse
+	(*GetMessageHistoryRequest)(nil),          // 27: services.chat.v1.GetMessageHistoryRequest
+	(*GetMessageHistoryResponse)(nil),         // 28: services.chat.v1.GetMessageHistoryResponse
+	(*GetRoomsRequest)(nil),                   // 29: services.chat.v1.GetRoomsRequest
+	(*GetRoomsResponse)(nil),                  // 30: services.chat.v1.GetRoomsResponse
+	(*InitialSyncRequest)(nil),                // 31: services.chat.v1.InitialSyncRequest
+	(*InitialSyncResponse)(nil),               // 32: services.chat.v1.InitialSyncResponse
+	(*RoomWithMessages)(nil),                  // 33: services.chat.v1.RoomWithMessages
+	(*SyncSummary)(nil),                       // 34: services.chat.v1.SyncSummary
+	(*PaginationMeta)(nil),                    // 35: services.chat.v1.PaginationMeta
+	(*StreamMessagesRequest)(nil),             // 36: services.chat.v1.StreamMessagesRequest
+	(*UpdateStreamSubscriptionRequest)(nil),   // 37: services.chat.v1.UpdateStreamSubscriptionRequest
+	(*UpdateStreamSubscriptionResponse)(nil),  // 38: services.chat.v1.UpdateStreamSubscriptionResponse
+	(*CreateRoomRequest)(nil),                 // 39: services.chat.v1.CreateRoomRequest
+	(*CreateRoomResponse)(nil),                // 40: services.chat.v1.CreateRoomResponse
+	(*PinRoomRequest)(nil),                    // 41: services.chat.v1.PinRoomRequest
+	(*PinRoomResponse)(nil),                   // 42: services.chat.v1.PinRoomResponse
+	(*MuteRoomRequest)(nil),                   // 43: services.chat.v1.MuteRoomRequest
+	(*MuteRoomResponse)(nil),                  // 44: services.chat.v1.MuteRoomResponse
+	(*JoinRoomRequest)(nil),                   // 45: services.chat.v1.JoinRoomRequest
+	(*JoinRoomResponse)(nil),                  // 46: services.chat.v1.JoinRoomResponse
+	(*LeaveRoomRequest)(nil),                  // 47: services.chat.v1.LeaveRoomRequest
+	(*LeaveRoomResponse)(nil),                 // 48: services.chat.v1.LeaveRoomResponse
+	(*GetRoomRequest)(nil),                    // 49: services.chat.v1.GetRoomRequest
+	(*GetRoomResponse)(nil),                   // 50: services.chat.v1.GetRoomResponse
+	(*GetRoomParticipantsRequest)(nil),        // 51: services.chat.v1.GetRoomParticipantsRequest
+	(*GetRoomParticipantsResponse)(nil),       // 52: services.chat.v1.GetRoomParticipantsResponse
+	(*UpdateRoomRequest)(nil),                 // 53: services.chat.v1.UpdateRoomRequest
+	(*UpdateRoomResponse)(nil),                // 54: services.chat.v1.UpdateRoomResponse
+	(*AddParticipantToRoomRequest)(nil),       // 55: services.chat.v1.AddParticipantToRoomRequest
+	(*AddParticipantToRoomResponse)(nil),      // 56: services.chat.v1.AddParticipantToRoomResponse
+	(*UpdateParticipantRoomRequest)(nil),      // 57: services.chat.v1.UpdateParticipantRoomRequest
+	(*UpdateParticipantRoomResponse)(nil),     // 58: services.chat.v1.UpdateParticipantRoomResponse
+	(*BlockUserRequest)(nil),                  // 59: services.chat.v1.BlockUserRequest
+	(*BlockUserResponse)(nil),                 // 60: services.chat.v1.BlockUserResponse
+	(*GetMessageRequest)(nil),                 // 61: services.chat.v1.GetMessageRequest
+	(*GetSenderMessageRequest)(nil),           // 62: services.chat.v1.GetSenderMessageRequest
+	(*GetSenderMessageResponse)(nil),          // 63: services.chat.v1.GetSenderMessageResponse
+	(*ReactToMessageRequest)(nil),             // 64: services.chat.v1.ReactToMessageRequest
+	(*ReactToMessageResponse)(nil),            // 65: services.chat.v1.ReactToMessageResponse
+	(*GetMessageReadRequest)(nil),             // 66: services.chat.v1.GetMessageReadRequest
+	(*MessageUserRead)(nil),                   // 67: services.chat.v1.MessageUserRead
+	(*GetMessageReadResponse)(nil),            // 68: services.chat.v1.GetMessageReadResponse
+	(*GetMessageReactionsRequest)(nil),        // 69: services.chat.v1.GetMessageReactionsRequest
+	(*GetMessageReactionsResponse)(nil),       // 70: services.chat.v1.GetMessageReactionsResponse
+	(*RoomHistoryExport)(nil),                 // 71: services.chat.v1.RoomHistoryExport
+	(*ExportRoomHistoryRequest)(nil),          // 72: services.chat.v1.ExportRoomHistoryRequest
+	(*ExportRoomHistoryResponse)(nil),         // 73: services.chat.v1.ExportRoomHistoryResponse
+	(*GetRoomHistoryExportRequest)(nil),       // 74: services.chat.v1.GetRoomHistoryExportRequest
+	(*GetRoomHistoryExportResponse)(nil),      // 75: services.chat.v1.GetRoomHistoryExportResponse
+	(*DownloadRoomHistoryExportRequest)(nil),  // 76: services.chat.v1.DownloadRoomHistoryExportRequest
+	(*DownloadRoomHistoryExportResponse)(nil), // 77: services.chat.v1.DownloadRoomHistoryExportResponse
+	(*UserDataExport)(nil),                    // 78: services.chat.v1.UserDataExport
+	(*ExportUserDataRequest)(nil),             // 79: services.chat.v1.ExportUserDataRequest
+	(*ExportUserDataResponse)(nil),            // 80: services.chat.v1.ExportUserDataResponse
+	(*GetUserDataExportRequest)(nil),          // 81: services.chat.v1.GetUserDataExportRequest
+	(*GetUserDataExportResponse)(nil),         // 82: services.chat.v1.GetUserDataExportResponse
+	(*DownloadUserDataExportRequest)(nil),     // 83: services.chat.v1.DownloadUserDataExportRequest
+	(*DownloadUserDataExportResponse)(nil),    // 84: services.chat.v1.DownloadUserDataExportResponse
+	(*UserErasureReport)(nil),                 // 85: services.chat.v1.UserErasureReport
+	(*EraseUserDataRequest)(nil),              // 86: services.chat.v1.EraseUserDataRequest
+	(*EraseUserDataResponse)(nil),             // 87: services.chat.v1.EraseUserDataResponse
+	(*RoomCacheKey)(nil),                      // 88: services.chat.v1.RoomCacheKey
+	(*ListRoomCacheKeysRequest)(nil),          // 89: services.chat.v1.ListRoomCacheKeysRequest
+	(*ListRoomCacheKeysResponse)(nil),         // 90: services.chat.v1.ListRoomCacheKeysResponse
+	(*CacheEntry)(nil),                        // 91: services.chat.v1.CacheEntry
+	(*GetCacheEntryRequest)(nil),              // 92: services.chat.v1.GetCacheEntryRequest
+	(*GetCacheEntryResponse)(nil),             // 93: services.chat.v1.GetCacheEntryResponse
+	(*FlushCacheRequest)(nil),                 // 94: services.chat.v1.FlushCacheRequest
+	(*FlushCacheResponse)(nil),                // 95: services.chat.v1.FlushCacheResponse
+	(*CacheStats)(nil),                        // 96: services.chat.v1.CacheStats
+	(*GetCacheStatsRequest)(nil),              // 97: services.chat.v1.GetCacheStatsRequest
+	(*GetCacheStatsResponse)(nil),             // 98: services.chat.v1.GetCacheStatsResponse
+	(*RoomKey)(nil),                           // 99: services.chat.v1.RoomKey
+	(*RotateRoomKeyRequest)(nil),              // 100: services.chat.v1.RotateRoomKeyRequest
+	(*RotateRoomKeyResponse)(nil),             // 101: services.chat.v1.RotateRoomKeyResponse
+	(*GetRoomKeysRequest)(nil),                // 102: services.chat.v1.GetRoomKeysRequest
+	(*GetRoomKeysResponse)(nil),               // 103: services.chat.v1.GetRoomKeysResponse
+	(*DeviceKey)(nil),                         // 104: services.chat.v1.DeviceKey
+	(*WrappedRoomKey)(nil),                    // 105: services.chat.v1.WrappedRoomKey
+	(*RegisterDeviceKeyRequest)(nil),          // 106: services.chat.v1.RegisterDeviceKeyRequest
+	(*RegisterDeviceKeyResponse)(nil),         // 107: services.chat.v1.RegisterDeviceKeyResponse
+	(*GetDeviceKeysRequest)(nil),              // 108: services.chat.v1.GetDeviceKeysRequest
+	(*GetDeviceKeysResponse)(nil),             // 109: services.chat.v1.GetDeviceKeysResponse
+	(*ShareRoomKeyRequest)(nil),               // 110: services.chat.v1.ShareRoomKeyRequest
+	(*ShareRoomKeyResponse)(nil),              // 111: services.chat.v1.ShareRoomKeyResponse
+	nil,                                       // 112: services.chat.v1.UserErasureReport.OwnersPromotedEntry
 }
 var file_services_chat_v1_types_proto_depIdxs = []int32{
-	3,  // 0: services.chat.v1.Room.partner:type_name -> services.chat.v1.RoomParticipant
-	3,  // 1: services.chat.v1.Room.participants:type_name -> services.chat.v1.RoomParticipant
-	6,  // 2: services.chat.v1.Room.last_message:type_name -> services.chat.v1.MessageData
-	6,  // 3: services.chat.v1.MessageData.reply:type_name -> services.chat.v1.MessageData
-	4,  // 4: services.chat.v1.MessageData.mentions:type_name -> services.chat.v1.Mention
-	0,  // 5: services.chat.v1.MessageData.status:type_name -> services.chat.v1.MessageStatus
-	5,  // 6: services.chat.v1.MessageData.reactions:type_name -> services.chat.v1.Reaction
-	0,  // 7: services.chat.v1.MessageStatusUpdate.status:type_name -> services.chat.v1.MessageStatus
-	2,  // 8: services.chat.v1.MessageEvent.room:type_name -> services.chat.v1.Room
-	6,  // 9: services.chat.v1.MessageEvent.message:type_name -> services.chat.v1.MessageData
-	10, // 10: services.chat.v1.MessageEvent.status_update:type_name -> services.chat.v1.MessageStatusUpdate
-	7,  // 11: services.chat.v1.MessageEvent.room_join:type_name -> services.chat.v1.RoomJoinEvent
-	8,  // 12: services.chat.v1.MessageEvent.room_leave:type_name -> services.chat.v1.RoomLeaveEvent
-	9,  // 13: services.chat.v1.MessageEvent.typing:type_name -> services.chat.v1.TypingEvent
-	11, // 14: services.chat.v1.MessageEvent.error:type_name -> services.chat.v1.ErrorEvent
-	6,  // 15: services.chat.v1.MessageEvent.update_message:type_name -> services.chat.v1.MessageData
-	13, // 16: services.chat.v1.SendMessageRequest.mentions:type_name -> services.chat.v1.CreateMention
-	6,  // 17: services.chat.v1.SendMessageResponse.message:type_name -> services.chat.v1.MessageData
-	6,  // 18: services.chat.v1.EditMessageResponse.message:type_name -> services.chat.v1.MessageData
-	6,  // 19: services.chat.v1.GetMessageHistoryResponse.items:type_name -> services.chat.v1.MessageData
-	30, // 20: services.chat.v1.GetMessageHistoryResponse.meta:type_name -> services.chat.v1.PaginationMeta
-	2,  // 21: services.chat.v1.GetRoomsResponse.items:type_name -> services.chat.v1.Room
-	30, // 22: services.chat.v1.GetRoomsResponse.meta:type_name -> services.chat.v1.PaginationMeta
-	1,  // 23: services.chat.v1.InitialSyncRequest.sync_strategy:type_name -> services.chat.v1.SyncStrategy
-	2,  // 24: services.chat.v1.InitialSyncResponse.rooms:type_name -> services.chat.v1.Room
-	6,  // 25: services.chat.v1.InitialSyncResponse.messages:type_name -> services.chat.v1.MessageData
-	29, // 26: services.chat.v1.InitialSyncResponse.summary:type_name -> services.chat.v1.SyncSummary
-	2,  // 27: services.chat.v1.RoomWithMessages.room:type_name -> services.chat.v1.Room
-	6,  // 28: services.chat.v1.RoomWithMessages.messages:type_name -> services.chat.v1.MessageData
-	2,  // 29: services.chat.v1.CreateRoomResponse.room:type_name -> services.chat.v1.Room
-	3,  // 30: services.chat.v1.JoinRoomRequest.participants:type_name -> services.chat.v1.RoomParticipant
-	2,  // 31: services.chat.v1.JoinRoomResponse.room:type_name -> services.chat.v1.Room
-	2,  // 32: services.chat.v1.GetRoomResponse.room:type_name -> services.chat.v1.Room
-	3,  // 33: services.chat.v1.GetRoomParticipantsResponse.participants:type_name -> services.chat.v1.RoomParticipant
-	30, // 34: services.chat.v1.GetRoomParticipantsResponse.meta:type_name -> services.chat.v1.PaginationMeta
-	0,  // 35: services.chat.v1.GetSenderMessageResponse.status:type_name -> services.chat.v1.MessageStatus
-	60, // 36: services.chat.v1.GetMessageReadResponse.items:type_name -> services.chat.v1.MessageUserRead
-	30, // 37: services.chat.v1.GetMessageReadResponse.meta:type_name -> services.chat.v1.PaginationMeta
-	5,  // 38: services.chat.v1.GetMessageReactionsResponse.items:type_name -> services.chat.v1.Reaction
-	30, // 39: services.chat.v1.GetMessageReactionsResponse.meta:type_name -> services.chat.v1.PaginationMeta
-	40, // [40:40] is the sub-list for method output_type
-	40, // [40:40] is the sub-list for method input_type
-	40, // [40:40] is the sub-list for extension type_name
-	40, // [40:40] is the sub-list for extension extendee
-	0,  // [0:40] is the sub-list for field type_name
+	6,   // 0: services.chat.v1.Room.partner:type_name -> services.chat.v1.RoomParticipant
+	6,   // 1: services.chat.v1.Room.participants:type_name -> services.chat.v1.RoomParticipant
+	9,   // 2: services.chat.v1.Room.last_message:type_name -> services.chat.v1.MessageData
+	9,   // 3: services.chat.v1.MessageData.reply:type_name -> services.chat.v1.MessageData
+	7,   // 4: services.chat.v1.MessageData.mentions:type_name -> services.chat.v1.Mention
+	0,   // 5: services.chat.v1.MessageData.status:type_name -> services.chat.v1.MessageStatus
+	8,   // 6: services.chat.v1.MessageData.reactions:type_name -> services.chat.v1.Reaction
+	0,   // 7: services.chat.v1.MessageStatusUpdate.status:type_name -> services.chat.v1.MessageStatus
+	5,   // 8: services.chat.v1.MessageEvent.room:type_name -> services.chat.v1.Room
+	9,   // 9: services.chat.v1.MessageEvent.message:type_name -> services.chat.v1.MessageData
+	15,  // 10: services.chat.v1.MessageEvent.status_update:type_name -> services.chat.v1.MessageStatusUpdate
+	10,  // 11: services.chat.v1.MessageEvent.room_join:type_name -> services.chat.v1.RoomJoinEvent
+	11,  // 12: services.chat.v1.MessageEvent.room_leave:type_name -> services.chat.v1.RoomLeaveEvent
+	14,  // 13: services.chat.v1.MessageEvent.typing:type_name -> services.chat.v1.TypingEvent
+	16,  // 14: services.chat.v1.MessageEvent.error:type_name -> services.chat.v1.ErrorEvent
+	9,   // 15: services.chat.v1.MessageEvent.update_message:type_name -> services.chat.v1.MessageData
+	13,  // 16: services.chat.v1.MessageEvent.read_state:type_name -> services.chat.v1.ReadStateEvent
+	12,  // 17: services.chat.v1.MessageEvent.history_purged:type_name -> services.chat.v1.HistoryPurgedEvent
+	18,  // 18: services.chat.v1.SendMessageRequest.mentions:type_name -> services.chat.v1.CreateMention
+	9,   // 19: services.chat.v1.SendMessageResponse.message:type_name -> services.chat.v1.MessageData
+	9,   // 20: services.chat.v1.EditMessageResponse.message:type_name -> services.chat.v1.MessageData
+	9,   // 21: services.chat.v1.GetMessageHistoryResponse.items:type_name -> services.chat.v1.MessageData
+	35,  // 22: services.chat.v1.GetMessageHistoryResponse.meta:type_name -> services.chat.v1.PaginationMeta
+	5,   // 23: services.chat.v1.GetRoomsResponse.items:type_name -> services.chat.v1.Room
+	35,  // 24: services.chat.v1.GetRoomsResponse.meta:type_name -> services.chat.v1.PaginationMeta
+	1,   // 25: services.chat.v1.InitialSyncRequest.sync_strategy:type_name -> services.chat.v1.SyncStrategy
+	5,   // 26: services.chat.v1.InitialSyncResponse.rooms:type_name -> services.chat.v1.Room
+	9,   // 27: services.chat.v1.InitialSyncResponse.messages:type_name -> services.chat.v1.MessageData
+	34,  // 28: services.chat.v1.InitialSyncResponse.summary:type_name -> services.chat.v1.SyncSummary
+	5,   // 29: services.chat.v1.RoomWithMessages.room:type_name -> services.chat.v1.Room
+	9,   // 30: services.chat.v1.RoomWithMessages.messages:type_name -> services.chat.v1.MessageData
+	2,   // 31: services.chat.v1.StreamMessagesRequest.event_types:type_name -> services.chat.v1.StreamEventType
+	2,   // 32: services.chat.v1.UpdateStreamSubscriptionRequest.event_types:type_name -> services.chat.v1.StreamEventType
+	5,   // 33: services.chat.v1.CreateRoomResponse.room:type_name -> services.chat.v1.Room
+	6,   // 34: services.chat.v1.JoinRoomRequest.participants:type_name -> services.chat.v1.RoomParticipant
+	5,   // 35: services.chat.v1.JoinRoomResponse.room:type_name -> services.chat.v1.Room
+	5,   // 36: services.chat.v1.GetRoomResponse.room:type_name -> services.chat.v1.Room
+	6,   // 37: services.chat.v1.GetRoomParticipantsResponse.participants:type_name -> services.chat.v1.RoomParticipant
+	35,  // 38: services.chat.v1.GetRoomParticipantsResponse.meta:type_name -> services.chat.v1.PaginationMeta
+	0,   // 39: services.chat.v1.GetSenderMessageResponse.status:type_name -> services.chat.v1.MessageStatus
+	67,  // 40: services.chat.v1.GetMessageReadResponse.items:type_name -> services.chat.v1.MessageUserRead
+	35,  // 41: services.chat.v1.GetMessageReadResponse.meta:type_name -> services.chat.v1.PaginationMeta
+	8,   // 42: services.chat.v1.GetMessageReactionsResponse.items:type_name -> services.chat.v1.Reaction
+	35,  // 43: services.chat.v1.GetMessageReactionsResponse.meta:type_name -> services.chat.v1.PaginationMeta
+	3,   // 44: services.chat.v1.RoomHistoryExport.format:type_name -> services.chat.v1.ExportFormat
+	4,   // 45: services.chat.v1.RoomHistoryExport.status:type_name -> services.chat.v1.ExportStatus
+	3,   // 46: services.chat.v1.ExportRoomHistoryRequest.format:type_name -> services.chat.v1.ExportFormat
+	71,  // 47: services.chat.v1.ExportRoomHistoryResponse.export:type_name -> services.chat.v1.RoomHistoryExport
+	71,  // 48: services.chat.v1.GetRoomHistoryExportResponse.export:type_name -> services.chat.v1.RoomHistoryExport
+	4,   // 49: services.chat.v1.UserDataExport.status:type_name -> services.chat.v1.ExportStatus
+	78,  // 50: services.chat.v1.ExportUserDataResponse.export:type_name -> services.chat.v1.UserDataExport
+	78,  // 51: services.chat.v1.GetUserDataExportResponse.export:type_name -> services.chat.v1.UserDataExport
+	112, // 52: services.chat.v1.UserErasureReport.owners_promoted:type_name -> services.chat.v1.UserErasureReport.OwnersPromotedEntry
+	85,  // 53: services.chat.v1.EraseUserDataResponse.report:type_name -> services.chat.v1.UserErasureReport
+	88,  // 54: services.chat.v1.ListRoomCacheKeysResponse.keys:type_name -> services.chat.v1.RoomCacheKey
+	5,   // 55: services.chat.v1.CacheEntry.room:type_name -> services.chat.v1.Room
+	9,   // 56: services.chat.v1.CacheEntry.message:type_name -> services.chat.v1.MessageData
+	91,  // 57: services.chat.v1.GetCacheEntryResponse.entries:type_name -> services.chat.v1.CacheEntry
+	96,  // 58: services.chat.v1.GetCacheStatsResponse.rooms:type_name -> services.chat.v1.CacheStats
+	96,  // 59: services.chat.v1.GetCacheStatsResponse.messages:type_name -> services.chat.v1.CacheStats
+	105, // 60: services.chat.v1.RotateRoomKeyRequest.keys:type_name -> services.chat.v1.WrappedRoomKey
+	99,  // 61: services.chat.v1.GetRoomKeysResponse.keys:type_name -> services.chat.v1.RoomKey
+	104, // 62: services.chat.v1.GetDeviceKeysResponse.keys:type_name -> services.chat.v1.DeviceKey
+	105, // 63: services.chat.v1.ShareRoomKeyRequest.keys:type_name -> services.chat.v1.WrappedRoomKey
+	64,  // [64:64] is the sub-list for method output_type
+	64,  // [64:64] is the sub-list for method input_type
+	64,  // [64:64] is the sub-list for extension type_name
+	64,  // [64:64] is the sub-list for extension extendee
+	0,   // [0:64] is the sub-list for field type_name
 }
 
 func init() { file_services_chat_v1_types_proto_init() }
@@ -5142,7 +8489,7 @@ func file_services_chat_v1_types_proto_init() {
 	file_services_chat_v1_types_proto_msgTypes[4].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[5].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[6].OneofWrappers = []any{}
-	file_services_chat_v1_types_proto_msgTypes[10].OneofWrappers = []any{
+	file_services_chat_v1_types_proto_msgTypes[12].OneofWrappers = []any{
 		(*MessageEvent_Message)(nil),
 		(*MessageEvent_StatusUpdate)(nil),
 		(*MessageEvent_IsRoomUpdated)(nil),
@@ -5153,34 +8500,49 @@ func file_services_chat_v1_types_proto_init() {
 		(*MessageEvent_UpdateMessage)(nil),
 		(*MessageEvent_DeleteMessage)(nil),
 		(*MessageEvent_Connected)(nil),
+		(*MessageEvent_ReadState)(nil),
+		(*MessageEvent_HistoryPurged)(nil),
 	}
-	file_services_chat_v1_types_proto_msgTypes[12].OneofWrappers = []any{}
-	file_services_chat_v1_types_proto_msgTypes[13].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[14].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[15].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[16].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[17].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[19].OneofWrappers = []any{}
-	file_services_chat_v1_types_proto_msgTypes[20].OneofWrappers = []any{}
-	file_services_chat_v1_types_proto_msgTypes[29].OneofWrappers = []any{}
-	file_services_chat_v1_types_proto_msgTypes[30].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[21].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[22].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[31].OneofWrappers = []any{}
-	file_services_chat_v1_types_proto_msgTypes[33].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[34].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[35].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[37].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[39].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[41].OneofWrappers = []any{}
-	file_services_chat_v1_types_proto_msgTypes[44].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[43].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[45].OneofWrappers = []any{}
-	file_services_chat_v1_types_proto_msgTypes[47].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[48].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[49].OneofWrappers = []any{}
 	file_services_chat_v1_types_proto_msgTypes[51].OneofWrappers = []any{}
-	file_services_chat_v1_types_proto_msgTypes[56].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[53].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[55].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[60].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[86].OneofWrappers = []any{
+		(*CacheEntry_Room)(nil),
+		(*CacheEntry_Message)(nil),
+		(*CacheEntry_Raw)(nil),
+	}
+	file_services_chat_v1_types_proto_msgTypes[89].OneofWrappers = []any{
+		(*FlushCacheRequest_RoomId)(nil),
+		(*FlushCacheRequest_UserId)(nil),
+	}
+	file_services_chat_v1_types_proto_msgTypes[95].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[97].OneofWrappers = []any{}
+	file_services_chat_v1_types_proto_msgTypes[103].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_services_chat_v1_types_proto_rawDesc), len(file_services_chat_v1_types_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   62,
+			NumEnums:      5,
+			NumMessages:   108,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,1135 @@
+package roomsrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	dbpq "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+	"github.com/scylladb-solutions/gocql/v2"
+)
+
+const (
+	backfillDefaultName      = "default"
+	backfillDefaultBatchSize = 500
+	backfillRoomPageSize     = 100
+)
+
+// ScyllaBackfill copia el estado de Postgres a las tablas desnormalizadas de Scylla.
+// Cada copia reescribe la sala o el mensaje completo a partir de Postgres (upserts y
+// reemplazo de particiones), así que repetirla deja el mismo resultado; la excepción son
+// los contadores de no leídos que ajustan MirrorNewMessage y MirrorReadState. La usan el
+// comando de backfill y DualWriteRoomRepository para reflejar cada mutación.
+type ScyllaBackfill struct {
+	db      *sql.DB
+	session *gocql.Session
+}
+
+func NewScyllaBackfill(db *sql.DB, session *gocql.Session) *ScyllaBackfill {
+	return &ScyllaBackfill{
+		db:      db,
+		session: session,
+	}
+}
+
+type BackfillOptions struct {
+	Name      string // Nombre del checkpoint; permite varias corridas independientes
+	BatchSize int    // Mensajes por página dentro de cada sala
+	Restart   bool   // Ignora el checkpoint y empieza desde la primera sala
+	Progress  func(BackfillProgress)
+}
+
+type BackfillProgress struct {
+	RoomsDone    int64
+	RoomsTotal   int64
+	MessagesDone int64
+	LastRoomID   string
+	Elapsed      time.Duration
+}
+
+// Run copia todas las salas en orden de id y guarda un checkpoint después de cada una.
+// Si se interrumpe, la siguiente corrida continúa desde la última sala completa.
+func (b *ScyllaBackfill) Run(ctx context.Context, opts BackfillOptions) (BackfillProgress, error) {
+	if opts.Name == "" {
+		opts.Name = backfillDefaultName
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = backfillDefaultBatchSize
+	}
+
+	progress := BackfillProgress{}
+	startedAt := time.Now()
+	if !opts.Restart {
+		var lastRoomID gocql.UUID
+		var storedStartedAt time.Time
+		err := b.session.Query(`SELECT last_room_id, rooms_done, messages_done, started_at FROM backfill_checkpoints WHERE name = ?`, opts.Name).
+			WithContext(ctx).Scan(&lastRoomID, &progress.RoomsDone, &progress.MessagesDone, &storedStartedAt)
+		if err != nil && err != gocql.ErrNotFound {
+			return progress, fmt.Errorf("error al leer el checkpoint del backfill: %w", err)
+		}
+		if err == nil {
+			if lastRoomID != (gocql.UUID{}) {
+				progress.LastRoomID = lastRoomID.String()
+			}
+			if !storedStartedAt.IsZero() {
+				startedAt = storedStartedAt
+			}
+		}
+	}
+
+	err := dbpq.QueryBuilder().Select("COUNT(*)").From("public.room").RunWith(b.db).QueryRowContext(ctx).Scan(&progress.RoomsTotal)
+	if err != nil {
+		return progress, fmt.Errorf("error al contar las salas: %w", err)
+	}
+
+	// Las claves de dispositivo no son de ninguna sala: se copian enteras en cada corrida
+	if err := b.copyDeviceKeys(ctx); err != nil {
+		return progress, err
+	}
+
+	for {
+		query := dbpq.QueryBuilder().
+			Select("id").
+			From("public.room").
+			OrderBy("id").
+			Limit(backfillRoomPageSize)
+		if progress.LastRoomID != "" {
+			query = query.Where(sq.Gt{"id": progress.LastRoomID})
+		}
+
+		rows, err := query.RunWith(b.db).QueryContext(ctx)
+		if err != nil {
+			return progress, fmt.Errorf("error al listar las salas: %w", err)
+		}
+		var roomIDs []string
+		for rows.Next() {
+			var roomID string
+			if err := rows.Scan(&roomID); err != nil {
+				rows.Close()
+				return progress, err
+			}
+			roomIDs = append(roomIDs, roomID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return progress, err
+		}
+		if len(roomIDs) == 0 {
+			break
+		}
+
+		for _, roomID := range roomIDs {
+			copied, err := b.CopyRoom(ctx, roomID, opts.BatchSize)
+			if err != nil {
+				return progress, fmt.Errorf("error al copiar la sala %s: %w", roomID, err)
+			}
+
+			progress.RoomsDone++
+			progress.MessagesDone += copied
+			progress.LastRoomID = roomID
+			progress.Elapsed = time.Since(startedAt)
+			if err := b.saveCheckpoint(ctx, opts.Name, progress, startedAt, nil); err != nil {
+				return progress, err
+			}
+			if opts.Progress != nil {
+				opts.Progress(progress)
+			}
+		}
+	}
+
+	finishedAt := time.Now()
+	return progress, b.saveCheckpoint(ctx, opts.Name, progress, startedAt, &finishedAt)
+}
+
+// copyDeviceKeys copia user_device_key a device_keys_by_user.
+func (b *ScyllaBackfill) copyDeviceKeys(ctx context.Context) error {
+	rows, err := dbpq.QueryBuilder().
+		Select("user_id", "device_id", "public_key", "updated_at").
+		From("public.user_device_key").
+		RunWith(b.db).
+		QueryContext(ctx)
+	if err != nil {
+		return fmt.Errorf("error al leer las claves de los dispositivos: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID int
+		var deviceID, publicKey string
+		var updatedAt time.Time
+		if err := rows.Scan(&userID, &deviceID, &publicKey, &updatedAt); err != nil {
+			return err
+		}
+		err := b.session.Query(`INSERT INTO device_keys_by_user (user_id, device_id, public_key, updated_at) VALUES (?, ?, ?, ?)`, userID, deviceID, publicKey, updatedAt).
+			WithContext(ctx).Exec()
+		if err != nil {
+			return fmt.Errorf("error al copiar la clave del dispositivo %s: %w", deviceKeyID(userID, deviceID), err)
+		}
+	}
+	return rows.Err()
+}
+
+func (b *ScyllaBackfill) saveCheckpoint(ctx context.Context, name string, progress BackfillProgress, startedAt time.Time, finishedAt *time.Time) error {
+	var lastRoomID *gocql.UUID
+	if progress.LastRoomID != "" {
+		id, err := gocql.ParseUUID(progress.LastRoomID)
+		if err != nil {
+			return fmt.Errorf("ID de sala inválido en el checkpoint: %w", err)
+		}
+		lastRoomID = &id
+	}
+	err := b.session.Query(`INSERT INTO backfill_checkpoints (name, last_room_id, rooms_done, messages_done, started_at, updated_at, finished_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		name, lastRoomID, progress.RoomsDone, progress.MessagesDone, startedAt, time.Now(), finishedAt).WithContext(ctx).Exec()
+	if err != nil {
+		return fmt.Errorf("error al guardar el checkpoint del backfill: %w", err)
+	}
+	return nil
+}
+
+// CopyRoom copia la sala con sus miembros y todos sus mensajes. Devuelve cuántos mensajes copió.
+func (b *ScyllaBackfill) CopyRoom(ctx context.Context, roomID string, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = backfillDefaultBatchSize
+	}
+
+	room, err := b.copyRoomState(ctx, roomID, true)
+	if err != nil || room == nil || room.deletedAt.Valid {
+		return 0, err
+	}
+
+	var copied int64
+	var afterSeq int64 = -1
+	for {
+		messages, err := b.copyMessages(ctx, room, sq.And{sq.Eq{"msg.room_id": roomID}, sq.Gt{"COALESCE(msg.seq, 0)": afterSeq}}, uint64(batchSize))
+		if err != nil {
+			return copied, err
+		}
+		copied += int64(len(messages))
+		if len(messages) < batchSize {
+			return copied, nil
+		}
+		afterSeq = messages[len(messages)-1].seq
+	}
+}
+
+// MirrorRoom refleja en Scylla el estado actual de la sala y sus miembros (sin mensajes).
+// Los contadores que ya existen no se tocan; ver setUnreadCount.
+func (b *ScyllaBackfill) MirrorRoom(ctx context.Context, roomID string) error {
+	_, err := b.copyRoomState(ctx, roomID, false)
+	return err
+}
+
+// MirrorMembership refleja la fila de un solo miembro (fijada, silenciada, rol) sin leer ni
+// reescribir al resto de la sala.
+func (b *ScyllaBackfill) MirrorMembership(ctx context.Context, roomID string, userID int) error {
+	room, err := b.loadRoomDetails(ctx, roomID)
+	if err != nil || room == nil || room.deletedAt.Valid {
+		return err
+	}
+	if err := b.loadMembers(ctx, room, userID, false); err != nil {
+		return err
+	}
+	if len(room.members) == 0 {
+		return nil
+	}
+
+	member := room.members[0]
+	if !member.active() {
+		return b.removeMember(ctx, room.uuid, member.userID, member.removedAt.Time, "removed")
+	}
+	lastMessage, err := b.loadLastMessage(ctx, room.id)
+	if err != nil {
+		return err
+	}
+	return b.copyActiveMember(ctx, room, member, lastMessage)
+}
+
+// MirrorNewMessage refleja un mensaje recién enviado igual que el fan-out de
+// ScyllaRoomRepository.SaveMessage: las filas del mensaje, su estado y el último mensaje de
+// cada miembro activo, y suma uno al contador de no leídos de los demás miembros.
+func (b *ScyllaBackfill) MirrorNewMessage(ctx context.Context, messageID string) error {
+	var roomID string
+	err := dbpq.QueryBuilder().
+		Select("room_id").
+		From("public.room_message").
+		Where(sq.Eq{"id": messageID}).
+		RunWith(b.db).
+		QueryRowContext(ctx).
+		Scan(&roomID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	room, err := b.loadRoomDetails(ctx, roomID)
+	if err != nil || room == nil || room.deletedAt.Valid {
+		return err
+	}
+	if err := b.loadMembers(ctx, room, 0, false); err != nil {
+		return err
+	}
+	messages, err := b.copyMessages(ctx, room, sq.And{sq.Eq{"msg.room_id": roomID}, sq.Eq{"msg.id": messageID}}, 0)
+	if err != nil || len(messages) == 0 {
+		return err
+	}
+
+	err = b.session.Query(`INSERT INTO room_sequences (room_id, last_seq) VALUES (?, ?)`, room.uuid, room.lastSeq).WithContext(ctx).Exec()
+	if err != nil {
+		return fmt.Errorf("error al copiar la secuencia de la sala: %w", err)
+	}
+	lastMessage, err := b.loadLastMessage(ctx, room.id)
+	if err != nil {
+		return err
+	}
+	for _, member := range room.members {
+		if !member.active() {
+			continue
+		}
+		if err := b.copyActiveMember(ctx, room, member, lastMessage); err != nil {
+			return err
+		}
+		if member.userID == messages[0].senderID {
+			continue
+		}
+		err := b.session.Query(`UPDATE room_counters_by_user SET unread_count = unread_count + 1 WHERE user_id = ? AND room_id = ?`, member.userID, room.uuid).WithContext(ctx).Exec()
+		if err != nil {
+			return fmt.Errorf("error al ajustar el contador del usuario %d: %w", member.userID, err)
+		}
+	}
+	return nil
+}
+
+// MirrorMessages refleja las filas de mensajes que ya existían (edición, borrado, reacciones,
+// purga). No reescribe el estado por miembro, las membresías ni los contadores.
+func (b *ScyllaBackfill) MirrorMessages(ctx context.Context, messageIDs []string) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	rows, err := dbpq.QueryBuilder().
+		Select("DISTINCT room_id").
+		From("public.room_message").
+		Where(sq.Eq{"id": messageIDs}).
+		RunWith(b.db).
+		QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+	var roomIDs []string
+	for rows.Next() {
+		var roomID string
+		if err := rows.Scan(&roomID); err != nil {
+			rows.Close()
+			return err
+		}
+		roomIDs = append(roomIDs, roomID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, roomID := range roomIDs {
+		room, err := b.loadRoomDetails(ctx, roomID)
+		if err != nil {
+			return err
+		}
+		if room == nil || room.deletedAt.Valid {
+			continue
+		}
+		_, err = b.copyMessages(ctx, room, sq.And{sq.Eq{"msg.room_id": roomID}, sq.Eq{"msg.id": messageIDs}}, 0)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MirrorReadState refleja las confirmaciones de lectura de un usuario registradas desde since.
+// Solo escribe el estado de ese usuario y resta de su contador los mensajes que en Scylla
+// pasan de DELIVERED a READ, de modo que un mensaje nuevo reflejado a la vez no se pierde.
+func (b *ScyllaBackfill) MirrorReadState(ctx context.Context, roomID string, userID int, since time.Time) error {
+	room, err := b.loadRoomDetails(ctx, roomID)
+	if err != nil || room == nil || room.deletedAt.Valid {
+		return err
+	}
+	if err := b.loadMembers(ctx, room, userID, false); err != nil {
+		return err
+	}
+
+	page, err := b.loadMessages(ctx, sq.And{
+		sq.Eq{"msg.room_id": roomID},
+		sq.Expr(`EXISTS (SELECT 1 FROM public.room_message_meta AS read_meta WHERE read_meta.message_id = msg.id AND read_meta.user_id = ? AND read_meta.read_at >= ?)`, userID, since),
+	}, 0)
+	if err != nil || len(page.messages) == 0 {
+		return err
+	}
+
+	uuids := make([]gocql.UUID, len(page.messages))
+	for i, m := range page.messages {
+		uuids[i] = m.uuid
+	}
+	previous := map[gocql.UUID]int{}
+	iter := b.session.Query(`SELECT message_id, status FROM message_status_by_user WHERE user_id = ? AND room_id = ? AND message_id IN ?`, userID, room.uuid, uuids).WithContext(ctx).Iter()
+	var messageUUID gocql.UUID
+	var status int
+	for iter.Scan(&messageUUID, &status) {
+		previous[messageUUID] = status
+	}
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("error al leer el estado de los mensajes del usuario %d: %w", userID, err)
+	}
+
+	if err := b.writeMessages(ctx, room, page); err != nil {
+		return err
+	}
+
+	var read int64
+	for _, m := range page.messages {
+		if previous[m.uuid] == int(chatv1.MessageStatus_MESSAGE_STATUS_DELIVERED) && page.status(m, userID) == chatv1.MessageStatus_MESSAGE_STATUS_READ {
+			read++
+		}
+	}
+	if read == 0 {
+		return nil
+	}
+	err = b.session.Query(`UPDATE room_counters_by_user SET unread_count = unread_count - ? WHERE user_id = ? AND room_id = ?`, read, userID, room.uuid).WithContext(ctx).Exec()
+	if err != nil {
+		return fmt.Errorf("error al ajustar el contador del usuario %d: %w", userID, err)
+	}
+	return nil
+}
+
+type backfillRoom struct {
+	id            string
+	uuid          gocql.UUID
+	name          sql.NullString
+	image         sql.NullString
+	description   sql.NullString
+	roomType      string
+	encryption    sql.NullString
+	keyVersion    int32
+	keys          []backfillRoomKey
+	e2e           bool
+	wrappedKeys   []backfillWrappedKey
+	joinAllUser   bool
+	sendMessage   bool
+	addMember     bool
+	editGroup     bool
+	createdAt     time.Time
+	updatedAt     time.Time
+	lastMessageAt sql.NullTime
+	deletedAt     sql.NullTime
+	lastSeq       int64
+	retentionDays sql.NullInt32
+	purgedBefore  sql.NullTime
+	members       []backfillMember
+}
+
+type backfillRoomKey struct {
+	version    int32
+	encryption string
+	retiredAt  time.Time
+}
+
+type backfillWrappedKey struct {
+	userID         int
+	deviceID       string
+	version        int32
+	wrappedKey     string
+	senderID       int
+	senderDeviceID string
+	createdAt      time.Time
+}
+
+type backfillMember struct {
+	userID           int
+	role             string
+	isPinned         bool
+	isMuted          bool
+	isPartnerBlocked bool
+	joinedAt         time.Time
+	removedAt        sql.NullTime
+	unreadCount      int64
+}
+
+func (m backfillMember) active() bool {
+	return !m.removedAt.Valid
+}
+
+type backfillLastMessage struct {
+	id          gocql.UUID
+	createdAt   time.Time
+	updatedAt   time.Time
+	content     sql.NullString
+	messageType sql.NullString
+	senderID    int
+	senderName  string
+	senderPhone string
+	status      int
+}
+
+// copyRoomState escribe room_details, room_key_state, room_keys_by_room, room_device_keys_by_room, room_sequences, participantes, rooms_by_user,
+// room_membership_lookup, contadores, p2p_room_by_users y deleted_rooms_by_user. Devuelve
+// nil si la sala no existe en Postgres. overwriteCounters se pasa a setUnreadCount.
+func (b *ScyllaBackfill) copyRoomState(ctx context.Context, roomID string, overwriteCounters bool) (*backfillRoom, error) {
+	room, err := b.loadRoom(ctx, roomID)
+	if err != nil || room == nil {
+		return nil, err
+	}
+
+	if room.deletedAt.Valid {
+		return room, b.copyDeletedRoom(ctx, room)
+	}
+
+	lastMessage, err := b.loadLastMessage(ctx, room.id)
+	if err != nil {
+		return nil, err
+	}
+
+	// room_key_state solo se escribe con LWT, fuera del batch
+	err = putRoomKeyState(ctx, b.session, room.uuid, roomKeyState{encryptionData: nullString(room.encryption), version: roomKeyVersion(room.keyVersion)})
+	if err != nil {
+		return nil, fmt.Errorf("error al copiar la clave de la sala: %w", err)
+	}
+
+	batch := b.session.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`INSERT INTO room_details (room_id, name, description, image, type, e2e, created_at, updated_at, join_all_user, send_message, add_member, edit_group, retention_days, history_purged_before) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		room.uuid, nullString(room.name), nullString(room.description), nullString(room.image), room.roomType, room.e2e, room.createdAt, room.updatedAt, room.joinAllUser, room.sendMessage, room.addMember, room.editGroup, nullInt(room.retentionDays), nullTime(room.purgedBefore))
+	batch.Query(`INSERT INTO room_sequences (room_id, last_seq) VALUES (?, ?)`, room.uuid, room.lastSeq)
+	for _, key := range room.keys {
+		batch.Query(`INSERT INTO room_keys_by_room (room_id, version, encryption_data, retired_at) VALUES (?, ?, ?, ?)`, room.uuid, key.version, key.encryption, key.retiredAt)
+	}
+	for _, key := range room.wrappedKeys {
+		batch.Query(`INSERT INTO room_device_keys_by_room (room_id, user_id, device_id, version, wrapped_key, sender_user_id, sender_device_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			room.uuid, key.userID, key.deviceID, key.version, key.wrappedKey, key.senderID, key.senderDeviceID, key.createdAt)
+	}
+	if err := b.session.ExecuteBatch(batch); err != nil {
+		return nil, fmt.Errorf("error al copiar los detalles de la sala: %w", err)
+	}
+
+	var activeUsers []int
+	for _, member := range room.members {
+		if member.active() {
+			activeUsers = append(activeUsers, member.userID)
+			err = b.copyActiveMember(ctx, room, member, lastMessage)
+			if err == nil {
+				err = b.setUnreadCount(ctx, member.userID, room.uuid, member.unreadCount, overwriteCounters)
+			}
+		} else {
+			err = b.removeMember(ctx, room.uuid, member.userID, member.removedAt.Time, "removed")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if room.roomType == "p2p" {
+		if err := b.copyP2PLookup(ctx, room, activeUsers); err != nil {
+			return nil, err
+		}
+	}
+
+	return room, nil
+}
+
+// loadRoom lee la sala con todos sus miembros y sus contadores de no leídos.
+func (b *ScyllaBackfill) loadRoom(ctx context.Context, roomID string) (*backfillRoom, error) {
+	room, err := b.loadRoomDetails(ctx, roomID)
+	if err != nil || room == nil {
+		return nil, err
+	}
+	return room, b.loadMembers(ctx, room, 0, true)
+}
+
+// loadRoomDetails lee la sala y sus claves, sin miembros. Devuelve nil si no existe.
+func (b *ScyllaBackfill) loadRoomDetails(ctx context.Context, roomID string) (*backfillRoom, error) {
+	room := &backfillRoom{}
+	err := dbpq.QueryBuilder().
+		Select("id", "name", "image", "description", "type", "encription_data", "key_version", "e2e",
+			"COALESCE(join_all_user, false)", "COALESCE(send_message, true)", "COALESCE(add_member, false)", "COALESCE(edit_group, false)",
+			"COALESCE(created_at, NOW())", "COALESCE(updated_at, created_at, NOW())", "\"lastMessageAt\"", "deleted_at", "last_seq", "retention_days", "history_purged_before").
+		From("public.room").
+		Where(sq.Eq{"id": roomID}).
+		RunWith(b.db).
+		QueryRowContext(ctx).
+		Scan(&room.id, &room.name, &room.image, &room.description, &room.roomType, &room.encryption, &room.keyVersion, &room.e2e,
+			&room.joinAllUser, &room.sendMessage, &room.addMember, &room.editGroup,
+			&room.createdAt, &room.updatedAt, &room.lastMessageAt, &room.deletedAt, &room.lastSeq, &room.retentionDays, &room.purgedBefore)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error al leer la sala de Postgres: %w", err)
+	}
+	room.uuid, err = gocql.ParseUUID(room.id)
+	if err != nil {
+		return nil, err
+	}
+
+	keyRows, err := dbpq.QueryBuilder().
+		Select("version", "encryption_data", "retired_at").
+		From("public.room_key").
+		Where(sq.Eq{"room_id": roomID}).
+		RunWith(b.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer las claves de la sala: %w", err)
+	}
+	for keyRows.Next() {
+		var key backfillRoomKey
+		if err := keyRows.Scan(&key.version, &key.encryption, &key.retiredAt); err != nil {
+			keyRows.Close()
+			return nil, err
+		}
+		room.keys = append(room.keys, key)
+	}
+	keyRows.Close()
+	if err := keyRows.Err(); err != nil {
+		return nil, err
+	}
+
+	if room.e2e {
+		wrappedRows, err := dbpq.QueryBuilder().
+			Select("user_id", "device_id", "version", "wrapped_key", "sender_user_id", "sender_device_id", "created_at").
+			From("public.room_device_key").
+			Where(sq.Eq{"room_id": roomID}).
+			RunWith(b.db).
+			QueryContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error al leer las claves envueltas de la sala: %w", err)
+		}
+		for wrappedRows.Next() {
+			var key backfillWrappedKey
+			if err := wrappedRows.Scan(&key.userID, &key.deviceID, &key.version, &key.wrappedKey, &key.senderID, &key.senderDeviceID, &key.createdAt); err != nil {
+				wrappedRows.Close()
+				return nil, err
+			}
+			room.wrappedKeys = append(room.wrappedKeys, key)
+		}
+		wrappedRows.Close()
+		if err := wrappedRows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return room, nil
+}
+
+// loadMembers carga en room los miembros de la sala, o solo el indicado si userID no es 0.
+// Con unread también lee de Postgres el contador de no leídos de cada uno, que es una
+// subconsulta por miembro.
+func (b *ScyllaBackfill) loadMembers(ctx context.Context, room *backfillRoom, userID int, unread bool) error {
+	unreadColumn := "0"
+	if unread {
+		// Misma definición de no leídos que SQLRoomRepository.GetRoom
+		unreadColumn = `(SELECT COUNT(*) FROM public.room_message AS unread_msg
+				LEFT JOIN public.room_message_meta AS unread_meta ON unread_msg.id = unread_meta.message_id AND unread_meta.user_id = member.user_id AND (unread_meta."isDeleted" = false OR unread_meta."isDeleted" IS NULL)
+				WHERE unread_msg.room_id = member.room_id AND unread_msg.deleted_at IS NULL AND unread_meta.read_at IS NULL)`
+	}
+	query := dbpq.QueryBuilder().
+		Select("member.user_id", "member.role", "COALESCE(member.is_pinned, false)", "COALESCE(member.is_muted, false)", "COALESCE(member.is_partner_blocked, false)",
+			"COALESCE(member.created_at, NOW())", "COALESCE(member.removed_at, member.deleted_at)", unreadColumn).
+		From("public.room_member AS member").
+		Where(sq.Eq{"member.room_id": room.id}).
+		OrderBy("member.created_at")
+	if userID != 0 {
+		query = query.Where(sq.Eq{"member.user_id": userID})
+	}
+
+	rows, err := query.RunWith(b.db).QueryContext(ctx)
+	if err != nil {
+		return fmt.Errorf("error al leer los miembros de la sala: %w", err)
+	}
+	defer rows.Close()
+	room.members = nil
+	for rows.Next() {
+		var member backfillMember
+		err := rows.Scan(&member.userID, &member.role, &member.isPinned, &member.isMuted, &member.isPartnerBlocked, &member.joinedAt, &member.removedAt, &member.unreadCount)
+		if err != nil {
+			return err
+		}
+		room.members = append(room.members, member)
+	}
+	return rows.Err()
+}
+
+func (b *ScyllaBackfill) loadLastMessage(ctx context.Context, roomID string) (*backfillLastMessage, error) {
+	var id string
+	lastMessage := &backfillLastMessage{}
+	err := dbpq.QueryBuilder().
+		Select("msg.id", "msg.created_at", "COALESCE(msg.updated_at, msg.created_at)", "msg.content", "msg.type", "msg.sender_id",
+			"COALESCE(sender.name, '')", "COALESCE(sender.phone, '')", "COALESCE(msg.status, 0)").
+		From("public.room_message AS msg").
+		LeftJoin("public.\"user\" AS sender ON sender.id = msg.sender_id").
+		Where(sq.Eq{"msg.room_id": roomID}).
+		Where(sq.Eq{"msg.deleted_at": nil}).
+		OrderBy("msg.created_at DESC").
+		Limit(1).
+		RunWith(b.db).
+		QueryRowContext(ctx).
+		Scan(&id, &lastMessage.createdAt, &lastMessage.updatedAt, &lastMessage.content, &lastMessage.messageType, &lastMessage.senderID,
+			&lastMessage.senderName, &lastMessage.senderPhone, &lastMessage.status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error al leer el último mensaje de la sala: %w", err)
+	}
+	lastMessage.id, err = scyllaMessageUUID(id, lastMessage.createdAt)
+	if err != nil {
+		return nil, err
+	}
+	return lastMessage, nil
+}
+
+// copyActiveMember reescribe la fila del miembro en participants_by_room, rooms_by_user y
+// room_membership_lookup. El contador de no leídos lo ajusta quien lo llama.
+func (b *ScyllaBackfill) copyActiveMember(ctx context.Context, room *backfillRoom, member backfillMember, lastMessage *backfillLastMessage) error {
+	lastMessageAt := member.joinedAt
+	if room.lastMessageAt.Valid {
+		lastMessageAt = room.lastMessageAt.Time
+	}
+	if lastMessage != nil {
+		lastMessageAt = lastMessage.createdAt
+	}
+
+	var oldPinned bool
+	var oldLastMessageAt time.Time
+	err := b.session.Query(`SELECT is_pinned, last_message_at FROM room_membership_lookup WHERE user_id = ? AND room_id = ?`, member.userID, room.uuid).
+		WithContext(ctx).Scan(&oldPinned, &oldLastMessageAt)
+	if err != nil && err != gocql.ErrNotFound {
+		return fmt.Errorf("error al leer la membresía del usuario %d: %w", member.userID, err)
+	}
+
+	batch := b.session.Batch(gocql.LoggedBatch).WithContext(ctx)
+	if err == nil && (oldPinned != member.isPinned || !oldLastMessageAt.Equal(lastMessageAt.Truncate(time.Millisecond))) {
+		batch.Query(`DELETE FROM rooms_by_user WHERE user_id = ? AND is_pinned = ? AND last_message_at = ? AND room_id = ?`, member.userID, oldPinned, oldLastMessageAt, room.uuid)
+	}
+	if lastMessage != nil {
+		batch.Query(`INSERT INTO rooms_by_user (user_id, is_pinned, last_message_at, room_id, room_name, room_image, room_type, is_muted, role, last_message_id, last_message_preview, last_message_type, last_message_sender_id, last_message_sender_name, last_message_sender_phone, last_message_status, last_message_updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			member.userID, member.isPinned, lastMessageAt, room.uuid, nullString(room.name), nullString(room.image), room.roomType, member.isMuted, member.role,
+			lastMessage.id, nullString(lastMessage.content), nullString(lastMessage.messageType), lastMessage.senderID, lastMessage.senderName, lastMessage.senderPhone, lastMessage.status, lastMessage.updatedAt)
+	} else {
+		// Sin último mensaje (sala nueva o historial purgado) las columnas se escriben nulas
+		// para que no quede la vista previa anterior en una fila con la misma clave
+		batch.Query(`INSERT INTO rooms_by_user (user_id, is_pinned, last_message_at, room_id, room_name, room_image, room_type, is_muted, role, last_message_id, last_message_preview, last_message_type, last_message_sender_id, last_message_sender_name, last_message_sender_phone, last_message_status, last_message_updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, null, null, null, null, null, null, null, null)`,
+			member.userID, member.isPinned, lastMessageAt, room.uuid, nullString(room.name), nullString(room.image), room.roomType, member.isMuted, member.role)
+	}
+	batch.Query(`INSERT INTO room_membership_lookup (user_id, room_id, is_pinned, last_message_at) VALUES (?, ?, ?, ?)`, member.userID, room.uuid, member.isPinned, lastMessageAt)
+	batch.Query(`INSERT INTO participants_by_room (room_id, user_id, role, joined_at, is_muted, is_partner_blocked) VALUES (?, ?, ?, ?, ?, ?)`,
+		room.uuid, member.userID, member.role, member.joinedAt, member.isMuted, member.isPartnerBlocked)
+	if err := b.session.ExecuteBatch(batch); err != nil {
+		return fmt.Errorf("error al copiar la membresía del usuario %d: %w", member.userID, err)
+	}
+	return nil
+}
+
+// setUnreadCount lleva el contador al valor indicado. Los counters no admiten asignación,
+// así que se suma la diferencia con el valor actual; una escritura concurrente entre la
+// lectura y la suma desvía el contador. Sin overwrite solo se inicializa un contador que
+// todavía no existe: en dual-write los mensajes y lecturas lo mantienen con incrementos,
+// que sí conmutan, y solo el backfill lo reescribe.
+func (b *ScyllaBackfill) setUnreadCount(ctx context.Context, userID int, roomUUID gocql.UUID, unread int64, overwrite bool) error {
+	var current int64
+	err := b.session.Query(`SELECT unread_count FROM room_counters_by_user WHERE user_id = ? AND room_id = ?`, userID, roomUUID).WithContext(ctx).Scan(&current)
+	if err != nil && err != gocql.ErrNotFound {
+		return fmt.Errorf("error al leer el contador del usuario %d: %w", userID, err)
+	}
+	if err == nil && (current == unread || !overwrite) {
+		return nil
+	}
+	err = b.session.Query(`UPDATE room_counters_by_user SET unread_count = unread_count + ? WHERE user_id = ? AND room_id = ?`, unread-current, userID, roomUUID).WithContext(ctx).Exec()
+	if err != nil {
+		return fmt.Errorf("error al ajustar el contador del usuario %d: %w", userID, err)
+	}
+	return nil
+}
+
+// removeMember replica LeaveRoom/DeleteRoom de ScyllaRoomRepository para un miembro.
+func (b *ScyllaBackfill) removeMember(ctx context.Context, roomUUID gocql.UUID, userID int, removedAt time.Time, reason string) error {
+	var isPinned bool
+	var lastMessageAt time.Time
+	err := b.session.Query(`SELECT is_pinned, last_message_at FROM room_membership_lookup WHERE user_id = ? AND room_id = ?`, userID, roomUUID).
+		WithContext(ctx).Scan(&isPinned, &lastMessageAt)
+	if err != nil && err != gocql.ErrNotFound {
+		return fmt.Errorf("error al leer la membresía del usuario %d: %w", userID, err)
+	}
+
+	batch := b.session.Batch(gocql.LoggedBatch).WithContext(ctx)
+	if err == nil {
+		batch.Query(`DELETE FROM rooms_by_user WHERE user_id = ? AND is_pinned = ? AND last_message_at = ? AND room_id = ?`, userID, isPinned, lastMessageAt, roomUUID)
+	}
+	batch.Query(`DELETE FROM room_membership_lookup WHERE user_id = ? AND room_id = ?`, userID, roomUUID)
+	batch.Query(`DELETE FROM participants_by_room WHERE room_id = ? AND user_id = ?`, roomUUID, userID)
+	batch.Query(`INSERT INTO deleted_rooms_by_user (user_id, deleted_at, room_id, reason) VALUES (?, ?, ?, ?)`, userID, removedAt, roomUUID, reason)
+	if err := b.session.ExecuteBatch(batch); err != nil {
+		return fmt.Errorf("error al quitar al usuario %d de la sala: %w", userID, err)
+	}
+
+	err = b.session.Query(`DELETE FROM room_counters_by_user WHERE user_id = ? AND room_id = ?`, userID, roomUUID).WithContext(ctx).Exec()
+	if err != nil {
+		return fmt.Errorf("error al borrar el contador del usuario %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (b *ScyllaBackfill) copyDeletedRoom(ctx context.Context, room *backfillRoom) error {
+	for _, member := range room.members {
+		removedAt, reason := room.deletedAt.Time, "deleted"
+		if !member.active() {
+			removedAt, reason = member.removedAt.Time, "removed"
+		}
+		if err := b.removeMember(ctx, room.uuid, member.userID, removedAt, reason); err != nil {
+			return err
+		}
+	}
+
+	if room.roomType == "p2p" {
+		if err := b.copyP2PLookup(ctx, room, nil); err != nil {
+			return err
+		}
+	}
+
+	batch := b.session.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`DELETE FROM participants_by_room WHERE room_id = ?`, room.uuid)
+	batch.Query(`DELETE FROM room_details WHERE room_id = ?`, room.uuid)
+	batch.Query(`DELETE FROM room_keys_by_room WHERE room_id = ?`, room.uuid)
+	batch.Query(`DELETE FROM room_device_keys_by_room WHERE room_id = ?`, room.uuid)
+	batch.Query(`DELETE FROM messages_by_room WHERE room_id = ?`, room.uuid)
+	if err := b.session.ExecuteBatch(batch); err != nil {
+		return fmt.Errorf("error al borrar la sala eliminada: %w", err)
+	}
+	if err := deleteRoomKeyState(ctx, b.session, room.uuid); err != nil {
+		return fmt.Errorf("error al borrar la clave de la sala eliminada: %w", err)
+	}
+
+	return nil
+}
+
+// copyP2PLookup registra la pareja de una sala p2p con sus dos miembros activos o, si ya no
+// los tiene, borra la pareja cuando todavía apunta a esta sala.
+func (b *ScyllaBackfill) copyP2PLookup(ctx context.Context, room *backfillRoom, activeUsers []int) error {
+	if len(activeUsers) == 2 {
+		user1, user2 := sortUserIDs(activeUsers[0], activeUsers[1])
+		err := b.session.Query(`INSERT INTO p2p_room_by_users (user1_id, user2_id, room_id) VALUES (?, ?, ?)`, user1, user2, room.uuid).WithContext(ctx).Exec()
+		if err != nil {
+			return fmt.Errorf("error al copiar la sala p2p: %w", err)
+		}
+		return nil
+	}
+
+	if len(room.members) < 2 {
+		return nil
+	}
+	user1, user2 := sortUserIDs(room.members[0].userID, room.members[1].userID)
+	var current gocql.UUID
+	err := b.session.Query(`SELECT room_id FROM p2p_room_by_users WHERE user1_id = ? AND user2_id = ?`, user1, user2).WithContext(ctx).Scan(&current)
+	if err == gocql.ErrNotFound || (err == nil && current != room.uuid) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return b.session.Query(`DELETE FROM p2p_room_by_users WHERE user1_id = ? AND user2_id = ?`, user1, user2).WithContext(ctx).Exec()
+}
+
+type backfillMessage struct {
+	legacyID         string
+	uuid             gocql.UUID
+	senderID         int
+	content          sql.NullString
+	contentDecrypted sql.NullString
+	messageType      sql.NullString
+	createdAt        time.Time
+	updatedAt        time.Time
+	edited           bool
+	isDeleted        bool
+	seq              int64
+	keyVersion       sql.NullInt32
+	replyID          sql.NullString
+	replyCreatedAt   sql.NullTime
+	forwardID        sql.NullString
+	forwardCreatedAt sql.NullTime
+	forwardSenderID  sql.NullInt32
+	file             sql.NullString
+	event            sql.NullString
+	senderMessageID  sql.NullString
+	transcription    sql.NullString
+	lifetime         sql.NullString
+	locationName     sql.NullString
+	locationLat      sql.NullFloat64
+	locationLng      sql.NullFloat64
+	origin           sql.NullString
+	contactID        sql.NullInt32
+	contactName      sql.NullString
+	contactPhone     sql.NullString
+}
+
+// backfillMessagePage son los mensajes leídos de Postgres con sus menciones, reacciones y
+// confirmaciones de lectura, agrupadas por el id de Postgres del mensaje.
+type backfillMessagePage struct {
+	messages  []backfillMessage
+	mentions  map[string][]backfillMessageRow
+	reactions map[string][]backfillMessageRow
+	receipts  map[string][]backfillMessageRow
+}
+
+// copyMessages copia los mensajes que cumplen where (ordenados por seq) con sus menciones,
+// reacciones, confirmaciones de lectura y el estado de los miembros cargados en room.
+func (b *ScyllaBackfill) copyMessages(ctx context.Context, room *backfillRoom, where sq.Sqlizer, limit uint64) ([]backfillMessage, error) {
+	page, err := b.loadMessages(ctx, where, limit)
+	if err != nil || len(page.messages) == 0 {
+		return nil, err
+	}
+	return page.messages, b.writeMessages(ctx, room, page)
+}
+
+// loadMessages lee de Postgres los mensajes que cumplen where, ordenados por seq.
+func (b *ScyllaBackfill) loadMessages(ctx context.Context, where sq.Sqlizer, limit uint64) (*backfillMessagePage, error) {
+	query := dbpq.QueryBuilder().
+		Select("msg.id", "msg.sender_id", "msg.content", "msg.content_decrypted", "msg.type", "msg.created_at", "COALESCE(msg.updated_at, msg.created_at)",
+			"COALESCE(msg.edited, false)", "(msg.deleted_at IS NOT NULL OR COALESCE(msg.\"isDeleted\", false))", "COALESCE(msg.seq, 0)", "msg.key_version",
+			"msg.replied_message_id", "reply.created_at", "msg.forwarded_message_id", "forward.created_at", "msg.forwarded_message_original_sender",
+			"msg.file", "msg.event", "msg.sender_message_id", "msg.audio_transcription", "msg.lifetime",
+			"msg.location_name", "msg.location_latitude", "msg.location_longitude", "msg.origin",
+			"msg.contact_id", "msg.contact_name", "msg.contact_phone").
+		From("public.room_message AS msg").
+		LeftJoin("public.room_message AS reply ON reply.id = msg.replied_message_id").
+		LeftJoin("public.room_message AS forward ON forward.id = msg.forwarded_message_id").
+		Where(where).
+		OrderBy("COALESCE(msg.seq, 0)", "msg.created_at")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	rows, err := query.RunWith(b.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer los mensajes de Postgres: %w", err)
+	}
+	var messages []backfillMessage
+	for rows.Next() {
+		var m backfillMessage
+		err := rows.Scan(&m.legacyID, &m.senderID, &m.content, &m.contentDecrypted, &m.messageType, &m.createdAt, &m.updatedAt,
+			&m.edited, &m.isDeleted, &m.seq, &m.keyVersion,
+			&m.replyID, &m.replyCreatedAt, &m.forwardID, &m.forwardCreatedAt, &m.forwardSenderID,
+			&m.file, &m.event, &m.senderMessageID, &m.transcription, &m.lifetime,
+			&m.locationName, &m.locationLat, &m.locationLng, &m.origin,
+			&m.contactID, &m.contactName, &m.contactPhone)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		m.uuid, err = scyllaMessageUUID(m.legacyID, m.createdAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	page := &backfillMessagePage{messages: messages}
+	if len(messages) == 0 {
+		return page, nil
+	}
+
+	legacyIDs := make([]string, len(messages))
+	for i, m := range messages {
+		legacyIDs[i] = m.legacyID
+	}
+	page.mentions, err = b.loadMessageRows(ctx, dbpq.QueryBuilder().
+		Select("message_id", "user_id", "COALESCE(tag, '')").
+		From("public.room_message_tag").
+		Where(sq.Eq{"message_id": legacyIDs}).
+		Where(sq.Eq{"deleted_at": nil}))
+	if err != nil {
+		return nil, fmt.Errorf("error al leer las menciones: %w", err)
+	}
+	page.reactions, err = b.loadMessageRows(ctx, dbpq.QueryBuilder().
+		Select("\"messageId\"", "\"reactedById\"", "COALESCE(reaction, '')").
+		From("public.room_message_reaction").
+		Where(sq.Eq{"\"messageId\"": legacyIDs}).
+		Where(sq.Eq{"deleted_at": nil}))
+	if err != nil {
+		return nil, fmt.Errorf("error al leer las reacciones: %w", err)
+	}
+	page.receipts, err = b.loadMessageRows(ctx, dbpq.QueryBuilder().
+		Select("message_id", "user_id", "to_char(read_at AT TIME ZONE 'UTC', 'YYYY-MM-DD\"T\"HH24:MI:SS.US\"Z\"')").
+		From("public.room_message_meta").
+		Where(sq.Eq{"message_id": legacyIDs}).
+		Where(sq.NotEq{"read_at": nil}))
+	if err != nil {
+		return nil, fmt.Errorf("error al leer las confirmaciones de lectura: %w", err)
+	}
+	return page, nil
+}
+
+// writeMessages escribe en Scylla los mensajes de la página y el estado de cada miembro
+// activo de room. Un room sin miembros cargados solo escribe las filas de los mensajes.
+func (b *ScyllaBackfill) writeMessages(ctx context.Context, room *backfillRoom, page *backfillMessagePage) error {
+	// Las escrituras de cada mensaje van con el mismo timestamp y los borrados de particiones
+	// con uno anterior, para que las filas reinsertadas sobrevivan al borrado del mismo batch.
+	writeTime := time.Now().UnixMicro()
+	for _, m := range page.messages {
+		batch := b.session.Batch(gocql.LoggedBatch).WithContext(ctx).WithTimestamp(writeTime)
+		batch.Query(`INSERT INTO messages_by_room (room_id, message_id, sender_id, content, content_decrypted, type, created_at, updated_at, edited, is_deleted, sender_message_id, seq, key_version,
+			reply_to_message_id, forwarded_from_message_id, forwarded_message_sender_id, file_url, event, audio_transcription, lifetime, location_name, location_latitude, location_longitude, origin,
+			contact_id, contact_name, contact_phone) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			room.uuid, m.uuid, m.senderID, nullString(m.content), nullString(m.contentDecrypted), nullString(m.messageType), m.createdAt, m.updatedAt, m.edited, m.isDeleted, nullString(m.senderMessageID), m.seq, nullInt(m.keyVersion),
+			referencedMessageUUID(m.replyID, m.replyCreatedAt), referencedMessageUUID(m.forwardID, m.forwardCreatedAt), nullInt(m.forwardSenderID), nullString(m.file), nullString(m.event),
+			nullString(m.transcription), nullString(m.lifetime), nullString(m.locationName), nullFloat(m.locationLat), nullFloat(m.locationLng), nullString(m.origin),
+			nullInt(m.contactID), nullString(m.contactName), nullString(m.contactPhone))
+		if m.seq > 0 {
+			batch.Query(`INSERT INTO messages_by_room_seq (room_id, seq, message_id) VALUES (?, ?, ?)`, room.uuid, m.seq, m.uuid)
+		}
+		batch.Query(`INSERT INTO room_by_message (message_id, room_id) VALUES (?, ?)`, m.uuid, room.uuid)
+		if m.senderMessageID.Valid && m.senderMessageID.String != "" {
+			batch.Query(`INSERT INTO message_by_sender_message_id (sender_message_id, room_id, message_id) VALUES (?, ?, ?)`, m.senderMessageID.String, room.uuid, m.uuid)
+		}
+		if m.uuid.String() != m.legacyID {
+			legacyUUID, err := gocql.ParseUUID(m.legacyID)
+			if err != nil {
+				return err
+			}
+			batch.Query(`INSERT INTO message_id_by_legacy_id (legacy_id, message_id, room_id) VALUES (?, ?, ?)`, legacyUUID, m.uuid, room.uuid)
+		}
+
+		batch.Query(`DELETE FROM mentions_by_message USING TIMESTAMP ? WHERE message_id = ?`, writeTime-1, m.uuid)
+		for _, mention := range page.mentions[m.legacyID] {
+			batch.Query(`INSERT INTO mentions_by_message (message_id, user_id, tag) VALUES (?, ?, ?)`, m.uuid, mention.userID, mention.value)
+		}
+		batch.Query(`DELETE FROM reactions_by_message USING TIMESTAMP ? WHERE message_id = ?`, writeTime-1, m.uuid)
+		for _, reaction := range page.reactions[m.legacyID] {
+			batch.Query(`INSERT INTO reactions_by_message (message_id, user_id, reaction, created_at) VALUES (?, ?, ?, ?)`, m.uuid, reaction.userID, reaction.value, m.updatedAt)
+		}
+		for _, receipt := range page.receipts[m.legacyID] {
+			readAt, err := time.Parse(time.RFC3339Nano, receipt.value)
+			if err != nil {
+				return err
+			}
+			batch.Query(`INSERT INTO read_receipts_by_message (message_id, user_id, read_at) VALUES (?, ?, ?)`, m.uuid, receipt.userID, readAt)
+		}
+
+		if err := b.session.ExecuteBatch(batch); err != nil {
+			return fmt.Errorf("error al copiar el mensaje %s: %w", m.legacyID, err)
+		}
+	}
+
+	// Estado por miembro, igual que el fan-out de ScyllaRoomRepository.SaveMessage. Un batch
+	// por miembro agrupa la página completa en una sola partición.
+	for _, member := range room.members {
+		if !member.active() {
+			continue
+		}
+		batch := b.session.Batch(gocql.UnloggedBatch).WithContext(ctx)
+		for _, m := range page.messages {
+			batch.Query(`INSERT INTO message_status_by_user (user_id, room_id, message_id, status) VALUES (?, ?, ?, ?)`, member.userID, room.uuid, m.uuid, int(page.status(m, member.userID)))
+		}
+		if err := b.session.ExecuteBatch(batch); err != nil {
+			return fmt.Errorf("error al copiar el estado de los mensajes del usuario %d: %w", member.userID, err)
+		}
+	}
+
+	return nil
+}
+
+// status es el estado del mensaje para un miembro: SENT para quien lo envió, READ si el
+// miembro tiene confirmación de lectura y DELIVERED en otro caso.
+func (p *backfillMessagePage) status(m backfillMessage, userID int) chatv1.MessageStatus {
+	if userID == m.senderID {
+		return chatv1.MessageStatus_MESSAGE_STATUS_SENT
+	}
+	for _, receipt := range p.receipts[m.legacyID] {
+		if receipt.userID == userID {
+			return chatv1.MessageStatus_MESSAGE_STATUS_READ
+		}
+	}
+	return chatv1.MessageStatus_MESSAGE_STATUS_DELIVERED
+}
+
+type backfillMessageRow struct {
+	userID int
+	value  string
+}
+
+// loadMessageRows lee filas (message_id, user_id, valor) agrupadas por mensaje.
+func (b *ScyllaBackfill) loadMessageRows(ctx context.Context, query sq.SelectBuilder) (map[string][]backfillMessageRow, error) {
+	rows, err := query.RunWith(b.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string][]backfillMessageRow{}
+	for rows.Next() {
+		var messageID string
+		var row backfillMessageRow
+		if err := rows.Scan(&messageID, &row.userID, &row.value); err != nil {
+			return nil, err
+		}
+		result[messageID] = append(result[messageID], row)
+	}
+	return result, rows.Err()
+}
+
+// scyllaMessageUUID devuelve el timeuuid que identifica en Scylla a un mensaje de Postgres.
+// Los ids basados en tiempo se conservan; los UUID v4 anteriores a la migración se
+// convierten de forma determinista usando created_at y los bytes del id original, así que
+// copiar el mismo mensaje varias veces siempre produce el mismo timeuuid.
+func scyllaMessageUUID(id string, createdAt time.Time) (gocql.UUID, error) {
+	legacy, err := gocql.ParseUUID(id)
+	if err != nil {
+		return gocql.UUID{}, fmt.Errorf("ID de mensaje inválido: %w", err)
+	}
+	if legacy.Version() == 1 {
+		return legacy, nil
+	}
+	clock := uint32(legacy[8])<<8 | uint32(legacy[9])
+	return gocql.TimeUUIDWith(gocql.MinTimeUUID(createdAt).Timestamp(), clock, legacy[10:]), nil
+}
+
+func referencedMessageUUID(id sql.NullString, createdAt sql.NullTime) *gocql.UUID {
+	if !id.Valid || !createdAt.Valid {
+		return nil
+	}
+	messageUUID, err := scyllaMessageUUID(id.String, createdAt.Time)
+	if err != nil {
+		return nil
+	}
+	return &messageUUID
+}
+
+func nullString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func nullInt(value sql.NullInt32) *int {
+	if !value.Valid {
+		return nil
+	}
+	return &[]int{int(value.Int32)}[0]
+}
+
+func nullTime(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+func nullFloat(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
@@ -0,0 +1,155 @@
+package roomsrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/protobuf/proto"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// Consulta y limpieza de la caché para los endpoints internos de operación. Las estadísticas
+// y el LRU son de la réplica que atiende la petición; Redis es compartido.
+
+// ErrInvalidCacheKey indica una clave que no es de la caché de salas o mensajes.
+var ErrInvalidCacheKey = errors.New("invalid cache key")
+
+// CacheStats son los aciertos y fallos de una caché en esta réplica desde que arrancó.
+type CacheStats struct {
+	LocalHits  int64
+	RemoteHits int64
+	Misses     int64
+}
+
+type cacheCounters struct {
+	localHits  atomic.Int64
+	remoteHits atomic.Int64
+	misses     atomic.Int64
+}
+
+var roomCacheCounters, messageCacheCounters cacheCounters
+
+func (c *cacheCounters) record(tier cacheTier) {
+	switch tier {
+	case cacheLocalHit:
+		c.localHits.Add(1)
+	case cacheRemoteHit:
+		c.remoteHits.Add(1)
+	default:
+		c.misses.Add(1)
+	}
+}
+
+func (c *cacheCounters) snapshot() CacheStats {
+	return CacheStats{
+		LocalHits:  c.localHits.Load(),
+		RemoteHits: c.remoteHits.Load(),
+		Misses:     c.misses.Load(),
+	}
+}
+
+// RoomCacheStats devuelve las lecturas de GetCachedRoom.
+func RoomCacheStats() CacheStats {
+	return roomCacheCounters.snapshot()
+}
+
+// MessageCacheStats devuelve las lecturas de GetCachedMessageSimple.
+func MessageCacheStats() CacheStats {
+	return messageCacheCounters.snapshot()
+}
+
+// CacheReplica identifica a la réplica cuyas estadísticas y LRU se consultan.
+func CacheReplica() string {
+	return roomCache.origin
+}
+
+// CachedKey es una clave registrada en el set de una sala y dónde está cacheada ahora.
+type CachedKey struct {
+	Key     string
+	InRedis bool
+	InLocal bool
+}
+
+// RoomCacheKeys lista las claves del set de miembros de la sala. El set no se borra al
+// invalidar, así que puede incluir claves que ya no están en Redis.
+func RoomCacheKeys(ctx context.Context, roomId string) ([]CachedKey, error) {
+	keys, err := roomCache.remote.SMembers(ctx, roomCacheMembersKey(roomId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache members: %w", err)
+	}
+	sort.Strings(keys)
+
+	result := make([]CachedKey, 0, len(keys))
+	for _, key := range keys {
+		value, err := roomCache.remote.Get(ctx, key)
+		_, inLocal := roomCache.local.get(key)
+		result = append(result, CachedKey{Key: key, InRedis: err == nil && value != "", InLocal: inLocal})
+	}
+	return result, nil
+}
+
+// CacheEntry es el valor de una clave en un nivel de la caché. Raw lleva el valor de Redis
+// cuando no se puede decodificar (por ejemplo, el JSON de versiones anteriores).
+type CacheEntry struct {
+	Tier  string // "local" o "redis"
+	Value proto.Message
+	Raw   string
+}
+
+// GetCacheEntry devuelve lo que hay cacheado en la clave en el LRU de esta réplica y en
+// Redis. Solo acepta claves de salas y mensajes.
+func GetCacheEntry(ctx context.Context, key string) ([]CacheEntry, error) {
+	var newValue func() proto.Message
+	switch {
+	case strings.HasPrefix(key, "endpoint:chat:room:") && !strings.HasSuffix(key, ":members"):
+		newValue = func() proto.Message { return &chatv1.Room{} }
+	case strings.HasPrefix(key, "endpoint:chat:messagesimple:"):
+		newValue = func() proto.Message { return &chatv1.MessageData{} }
+	default:
+		return nil, ErrInvalidCacheKey
+	}
+
+	var entries []CacheEntry
+	if value, ok := roomCache.local.get(key); ok {
+		entries = append(entries, CacheEntry{Tier: "local", Value: value})
+	}
+
+	data, err := roomCache.remote.Get(ctx, key)
+	if err != nil || data == "" {
+		return entries, nil
+	}
+	entry := CacheEntry{Tier: "redis"}
+	value := newValue()
+	if strings.HasPrefix(data, cacheValuePrefix) && proto.Unmarshal([]byte(data[len(cacheValuePrefix):]), value) == nil {
+		entry.Value = value
+	} else {
+		entry.Raw = data
+	}
+	return append(entries, entry), nil
+}
+
+// FlushUserCache invalida las versiones cacheadas de las salas vistas por el usuario y
+// devuelve cuántas claves borró. Se borra sala por sala porque las claves de salas
+// distintas no comparten hash tag.
+func FlushUserCache(ctx context.Context, userId int, roomIds []string) (int, error) {
+	if len(roomIds) == 0 {
+		return 0, nil
+	}
+	keys := make([]string, 0, len(roomIds)*2)
+	defer func() { roomCache.invalidate(nil, keys) }()
+
+	for _, roomId := range roomIds {
+		roomKeys := []string{roomCacheKey(roomId, userId, true), roomCacheKey(roomId, userId, false)}
+		keys = append(keys, roomKeys...)
+		roomCache.bumpVersion(ctx, roomId)
+		if err := roomCache.remote.Del(ctx, roomKeys...); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
@@ -0,0 +1,92 @@
+package roomsrepository
+
+import (
+	"context"
+	"testing"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// useMemoryRoomCache reemplaza la caché global por una con Redis en memoria durante el test.
+func useMemoryRoomCache(t *testing.T) *memoryRemoteCache {
+	t.Helper()
+	remote := newMemoryRemoteCache()
+	previous := roomCache
+	roomCache = newTieredCache(remote, localCacheCapacity, localCacheTTL)
+	t.Cleanup(func() { roomCache = previous })
+	return remote
+}
+
+func cacheRoom(t *testing.T, roomId string, userId int, room *chatv1.Room) string {
+	t.Helper()
+	key := roomCacheKey(roomId, userId, true)
+	if _, fill, ok := GetCachedRoom(context.Background(), key); !ok {
+		SetCachedRoom(context.Background(), roomId, fill, room)
+	}
+	return key
+}
+
+func TestCacheAdmin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("las estadísticas distinguen LRU, Redis y fallos", func(t *testing.T) {
+		useMemoryRoomCache(t)
+		before := RoomCacheStats()
+
+		key := cacheRoom(t, "r1", 1, &chatv1.Room{Id: "r1"}) // fallo
+		GetCachedRoom(ctx, key)                              // LRU
+		roomCache.local.invalidateKey(key)
+		GetCachedRoom(ctx, key) // Redis
+
+		after := RoomCacheStats()
+		if after.Misses-before.Misses != 1 || after.LocalHits-before.LocalHits != 1 || after.RemoteHits-before.RemoteHits != 1 {
+			t.Fatalf("estadísticas antes %+v después %+v", before, after)
+		}
+	})
+
+	t.Run("las claves de la sala indican dónde siguen cacheadas", func(t *testing.T) {
+		useMemoryRoomCache(t)
+		cacheRoom(t, "r1", 1, &chatv1.Room{Id: "r1", Name: "Sala"})
+		cacheRoom(t, "r1", 2, &chatv1.Room{Id: "r1", Name: "Sala"})
+
+		keys, err := RoomCacheKeys(ctx, "r1")
+		if err != nil || len(keys) != 2 || !keys[0].InRedis || !keys[0].InLocal {
+			t.Fatalf("RoomCacheKeys = %+v %v", keys, err)
+		}
+
+		entries, err := GetCacheEntry(ctx, keys[0].Key)
+		if err != nil || len(entries) != 2 || entries[1].Value.(*chatv1.Room).Name != "Sala" {
+			t.Fatalf("GetCacheEntry = %+v %v", entries, err)
+		}
+		if _, err := GetCacheEntry(ctx, "otra:clave"); err != ErrInvalidCacheKey {
+			t.Fatalf("GetCacheEntry de una clave ajena = %v", err)
+		}
+
+		deleted, err := FlushRoomCache(ctx, "r1")
+		if err != nil || deleted != 2 {
+			t.Fatalf("FlushRoomCache = %d %v", deleted, err)
+		}
+		keys, _ = RoomCacheKeys(ctx, "r1")
+		for _, key := range keys {
+			if key.InRedis || key.InLocal {
+				t.Fatalf("%s sigue cacheada tras limpiar la sala", key.Key)
+			}
+		}
+	})
+
+	t.Run("limpiar un usuario no toca la caché de los demás", func(t *testing.T) {
+		useMemoryRoomCache(t)
+		mine := cacheRoom(t, "r1", 1, &chatv1.Room{Id: "r1"})
+		theirs := cacheRoom(t, "r1", 2, &chatv1.Room{Id: "r1"})
+
+		if _, err := FlushUserCache(ctx, 1, []string{"r1"}); err != nil {
+			t.Fatalf("FlushUserCache: %v", err)
+		}
+		if _, _, ok := GetCachedRoom(ctx, mine); ok {
+			t.Fatalf("la sala del usuario sigue cacheada")
+		}
+		if _, _, ok := GetCachedRoom(ctx, theirs); !ok {
+			t.Fatalf("se borró la caché de otro usuario")
+		}
+	})
+}
@@ -0,0 +1,437 @@
+package roomsrepository
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/cache"
+)
+
+// Caché de dos niveles para salas y mensajes.
+//
+// El primer nivel es un LRU en memoria del proceso con un TTL corto; el segundo, Redis
+// (pkg/cache), compartido entre réplicas. Las lecturas prueban el LRU y después Redis, y lo
+// que se lee de Redis se copia al LRU. En Redis los valores van codificados en protobuf.
+//
+// Cada invalidación borra Redis y el LRU local y se difunde por NATS para que las demás
+// réplicas descarten su copia local. Si un aviso se pierde, el TTL del LRU limita cuánto se
+// sirve la copia vieja. Los procesos que no arrancan la difusión (CLI, backfill) solo
+// invalidan Redis.
+//
+// Los cambios no actualizan la caché: la invalidan y las lecturas la vuelven a llenar desde
+// la base de datos. Para que una lectura que empezó antes de un cambio no deje en Redis el
+// valor viejo, cada grupo tiene una versión en Redis que cambia con cada invalidación:
+//
+//   - Invalidar: cambiar la versión y después borrar las claves.
+//   - Llenar: leer la versión antes de ir a la base de datos, registrar la clave en el set de
+//     la sala, escribir el valor y volver a leer la versión; si cambió, borrar lo escrito.
+//
+// Así, cualquier escritura vieja o la borra la invalidación o la detecta quien la escribió,
+// sin locks y entre réplicas, con las operaciones simples de pkg/cache.
+
+const (
+	localCacheCapacity = 10000
+	localCacheTTL      = time.Minute
+	remoteCacheTTL     = time.Hour
+
+	cacheInvalidationSubject = "chat.cache.invalidate"
+
+	// Prefijo de los valores en protobuf. Los valores JSON de versiones anteriores no lo
+	// llevan y se tratan como fallo de caché hasta que expiren
+	cacheValuePrefix = "pb1:"
+
+	// Contadores de generación del LRU, repartidos por hash del grupo
+	cacheGenerationStripes = 256
+)
+
+// remoteCache es el segundo nivel. redisCache lo implementa con pkg/cache; los tests usan
+// uno en memoria.
+type remoteCache interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	SAdd(ctx context.Context, key string, members ...string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+	Del(ctx context.Context, keys ...string) error
+}
+
+type redisCache struct{}
+
+func (redisCache) Get(ctx context.Context, key string) (string, error) {
+	return cache.Get(ctx, key)
+}
+
+func (redisCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return cache.Set(ctx, key, value, ttl)
+}
+
+func (redisCache) SAdd(ctx context.Context, key string, members ...string) error {
+	values := make([]any, len(members))
+	for i, member := range members {
+		values[i] = member
+	}
+	return cache.SAdd(ctx, key, values...)
+}
+
+func (redisCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	return cache.SMembers(ctx, key)
+}
+
+func (redisCache) Del(ctx context.Context, keys ...string) error {
+	return cache.Del(ctx, keys...)
+}
+
+// cacheGroup devuelve el grupo de una clave: el ID entre llaves (la sala o el mensaje),
+// que es también el hash tag de Redis. Invalidar un grupo descarta todas sus claves.
+func cacheGroup(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+	end := strings.IndexByte(key[start:], '}')
+	if end < 0 {
+		return key
+	}
+	return key[start+1 : start+end]
+}
+
+type localCacheEntry struct {
+	key, group string
+	value      proto.Message
+	expiresAt  time.Time
+}
+
+// lruCache es el primer nivel. Guarda copias de los mensajes y devuelve copias, así que
+// quien lee puede modificar el resultado.
+//
+// Para que una lectura de Redis que empezó antes de una invalidación no vuelva a guardar el
+// valor viejo, set recibe la generación leída antes de ir a Redis y descarta el valor si el
+// grupo se invalidó entretanto. Las generaciones se reparten en un número fijo de contadores
+// para que no crezcan con el número de salas.
+type lruCache struct {
+	mu          sync.Mutex
+	capacity    int
+	ttl         time.Duration
+	now         func() time.Time
+	items       map[string]*list.Element
+	order       *list.List // el más reciente al frente
+	groups      map[string]map[string]struct{}
+	generations [cacheGenerationStripes]uint64
+}
+
+func newLRUCache(capacity int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		groups:   make(map[string]map[string]struct{}),
+	}
+}
+
+func generationStripe(group string) int {
+	h := fnv.New32a()
+	h.Write([]byte(group))
+	return int(h.Sum32() % cacheGenerationStripes)
+}
+
+func (c *lruCache) get(key string) (proto.Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*localCacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return proto.Clone(entry.value), true
+}
+
+// generation devuelve la generación actual del grupo, para pasarla a set.
+func (c *lruCache) generation(group string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generations[generationStripe(group)]
+}
+
+// set guarda una copia del valor si el grupo no se invalidó desde que se leyó generation.
+func (c *lruCache) set(key, group string, value proto.Message, generation uint64) {
+	c.setFor(key, group, value, generation, c.ttl)
+}
+
+// setFor es set con un TTL distinto del de la caché.
+func (c *lruCache) setFor(key, group string, value proto.Message, generation uint64, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generations[generationStripe(group)] != generation {
+		return
+	}
+	if element, ok := c.items[key]; ok {
+		c.remove(element)
+	}
+
+	entry := &localCacheEntry{key: key, group: group, value: proto.Clone(value), expiresAt: c.now().Add(ttl)}
+	c.items[key] = c.order.PushFront(entry)
+	if c.groups[group] == nil {
+		c.groups[group] = make(map[string]struct{})
+	}
+	c.groups[group][key] = struct{}{}
+
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// invalidateGroup descarta las claves del grupo y las lecturas en curso del grupo.
+func (c *lruCache) invalidateGroup(group string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[generationStripe(group)]++
+	for key := range c.groups[group] {
+		c.remove(c.items[key])
+	}
+}
+
+// invalidateKey descarta una clave y las lecturas en curso de su grupo.
+func (c *lruCache) invalidateKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[generationStripe(cacheGroup(key))]++
+	if element, ok := c.items[key]; ok {
+		c.remove(element)
+	}
+}
+
+func (c *lruCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *lruCache) remove(element *list.Element) {
+	entry := element.Value.(*localCacheEntry)
+	c.order.Remove(element)
+	delete(c.items, entry.key)
+	if keys := c.groups[entry.group]; keys != nil {
+		delete(keys, entry.key)
+		if len(keys) == 0 {
+			delete(c.groups, entry.group)
+		}
+	}
+}
+
+// cacheInvalidation es el aviso que se difunde por NATS: grupos (salas) completos o claves
+// sueltas que las réplicas deben descartar de su LRU.
+type cacheInvalidation struct {
+	Origin string   `json:"origin"`
+	Groups []string `json:"groups,omitempty"`
+	Keys   []string `json:"keys,omitempty"`
+}
+
+// tieredCache combina los dos niveles y la difusión de invalidaciones.
+type tieredCache struct {
+	local  *lruCache
+	remote remoteCache
+	origin string // identifica a la réplica para ignorar sus propios avisos
+
+	mu sync.RWMutex
+	nc *nats.Conn // sin conexión las invalidaciones solo son locales
+}
+
+func newTieredCache(remote remoteCache, capacity int, ttl time.Duration) *tieredCache {
+	return &tieredCache{
+		local:  newLRUCache(capacity, ttl),
+		remote: remote,
+		origin: uuid.NewString(),
+	}
+}
+
+// cacheFill es lo que necesita fill para cachear el valor de una clave que no estaba en
+// caché: la generación del LRU y la versión del grupo en Redis, las dos leídas antes de ir a
+// la base de datos. El valor cero no cachea nada.
+type cacheFill struct {
+	key, group string
+	generation uint64
+	version    string
+}
+
+// cacheVersionKey es la versión del grupo en Redis. Comparte hash tag con las claves del
+// grupo.
+func cacheVersionKey(group string) string {
+	return fmt.Sprintf("endpoint:chat:cache:{%s}:version", group)
+}
+
+// cacheTier indica de dónde salió una lectura de la caché.
+type cacheTier int
+
+const (
+	cacheMiss cacheTier = iota
+	cacheLocalHit
+	cacheRemoteHit
+)
+
+// getCached lee la clave del LRU o, si no está, de Redis. Si tampoco está en Redis devuelve
+// el cacheFill con el que guardar el valor después de leerlo de la base de datos.
+func getCached[T proto.Message](ctx context.Context, c *tieredCache, key string, newValue func() T) (T, cacheFill, bool) {
+	value, fill, tier := lookupCached(ctx, c, key, newValue)
+	return value, fill, tier != cacheMiss
+}
+
+// lookupCached es getCached indicando además el nivel que respondió.
+func lookupCached[T proto.Message](ctx context.Context, c *tieredCache, key string, newValue func() T) (T, cacheFill, cacheTier) {
+	var zero T
+	if value, ok := c.local.get(key); ok {
+		if typed, ok := value.(T); ok {
+			return typed, cacheFill{}, cacheLocalHit
+		}
+	}
+
+	group := cacheGroup(key)
+	generation := c.local.generation(group)
+	value := newValue()
+	if !c.getRemote(ctx, key, value) {
+		return zero, cacheFill{key: key, group: group, generation: generation, version: c.version(ctx, group)}, cacheMiss
+	}
+	c.local.set(key, group, value, generation)
+	return value, cacheFill{}, cacheRemoteHit
+}
+
+// version devuelve la versión del grupo. Una versión que no existe o no se pudo leer vale
+// "", así que en el peor caso fill descarta un valor válido.
+func (c *tieredCache) version(ctx context.Context, group string) string {
+	version, err := c.remote.Get(ctx, cacheVersionKey(group))
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+// getRemote lee y decodifica la clave de Redis.
+func (c *tieredCache) getRemote(ctx context.Context, key string, value proto.Message) bool {
+	data, err := c.remote.Get(ctx, key)
+	if err != nil || !strings.HasPrefix(data, cacheValuePrefix) {
+		return false
+	}
+	return proto.Unmarshal([]byte(data[len(cacheValuePrefix):]), value) == nil
+}
+
+// fill guarda en los dos niveles el valor leído de la base de datos si el grupo no se
+// invalidó desde que se obtuvo el cacheFill. Si se invalidó mientras se escribía, borra el
+// valor de Redis. Las claves de sala deben estar ya registradas en el set de la sala.
+func (c *tieredCache) fill(ctx context.Context, fill cacheFill, value proto.Message) error {
+	if fill.key == "" {
+		return nil
+	}
+	if err := c.setRemote(ctx, fill.key, value); err != nil {
+		return err
+	}
+	if c.version(ctx, fill.group) != fill.version {
+		return c.remote.Del(ctx, fill.key)
+	}
+	c.local.set(fill.key, fill.group, value, fill.generation)
+	return nil
+}
+
+func (c *tieredCache) setRemote(ctx context.Context, key string, value proto.Message) error {
+	data, err := proto.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.remote.Set(ctx, key, cacheValuePrefix+string(data), remoteCacheTTL)
+}
+
+// setLocal guarda el valor solo en el LRU de esta réplica, con su propio TTL. Sirve para
+// datos que no vale la pena compartir en Redis; las invalidaciones los descartan igual.
+func (c *tieredCache) setLocal(key string, value proto.Message, generation uint64, ttl time.Duration) {
+	c.local.setFor(key, cacheGroup(key), value, generation, ttl)
+}
+
+// bumpVersion cambia la versión del grupo en Redis. Se llama antes de borrar sus claves.
+func (c *tieredCache) bumpVersion(ctx context.Context, group string) {
+	if err := c.remote.Set(ctx, cacheVersionKey(group), uuid.NewString(), remoteCacheTTL); err != nil {
+		fmt.Println("error bumping cache version", err)
+	}
+}
+
+// invalidate descarta los grupos y claves del LRU de esta réplica y avisa a las demás.
+// Cambiar la versión y borrar Redis le corresponde a quien llama.
+func (c *tieredCache) invalidate(groups []string, keys []string) {
+	for _, group := range groups {
+		c.local.invalidateGroup(group)
+	}
+	for _, key := range keys {
+		c.local.invalidateKey(key)
+	}
+
+	c.mu.RLock()
+	nc := c.nc
+	c.mu.RUnlock()
+	if nc == nil {
+		return
+	}
+	data, err := json.Marshal(cacheInvalidation{Origin: c.origin, Groups: groups, Keys: keys})
+	if err != nil {
+		return
+	}
+	if err := nc.Publish(cacheInvalidationSubject, data); err != nil {
+		fmt.Println("error publishing cache invalidation", err)
+	}
+}
+
+// applyInvalidation procesa el aviso de otra réplica.
+func (c *tieredCache) applyInvalidation(data []byte) {
+	var invalidation cacheInvalidation
+	if err := json.Unmarshal(data, &invalidation); err != nil {
+		fmt.Println("error decoding cache invalidation", err)
+		return
+	}
+	if invalidation.Origin == c.origin {
+		return
+	}
+	for _, group := range invalidation.Groups {
+		c.local.invalidateGroup(group)
+	}
+	for _, key := range invalidation.Keys {
+		c.local.invalidateKey(key)
+	}
+}
+
+// listen se suscribe a los avisos de las demás réplicas y empieza a difundir los propios.
+// Todas las réplicas reciben cada aviso, así que no se usa queue group.
+func (c *tieredCache) listen(nc *nats.Conn) (*nats.Subscription, error) {
+	sub, err := nc.Subscribe(cacheInvalidationSubject, func(msg *nats.Msg) {
+		c.applyInvalidation(msg.Data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.nc = nc
+	c.mu.Unlock()
+	return sub, nil
+}
+
+// StartCacheInvalidation conecta la caché de salas y mensajes con NATS para que las
+// invalidaciones lleguen al LRU de todas las réplicas.
+func StartCacheInvalidation(nc *nats.Conn) (*nats.Subscription, error) {
+	return roomCache.listen(nc)
+}
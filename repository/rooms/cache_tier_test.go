@@ -0,0 +1,244 @@
+package roomsrepository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// memoryRemoteCache hace de Redis en los tests y cuenta las lecturas.
+type memoryRemoteCache struct {
+	mu     sync.Mutex
+	values map[string]string
+	sets   map[string]map[string]bool
+	gets   int
+}
+
+func newMemoryRemoteCache() *memoryRemoteCache {
+	return &memoryRemoteCache{values: map[string]string{}, sets: map[string]map[string]bool{}}
+}
+
+func (m *memoryRemoteCache) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gets++
+	return m.values[key], nil
+}
+
+func (m *memoryRemoteCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = value
+	return nil
+}
+
+func (m *memoryRemoteCache) SAdd(ctx context.Context, key string, members ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sets[key] == nil {
+		m.sets[key] = map[string]bool{}
+	}
+	for _, member := range members {
+		m.sets[key][member] = true
+	}
+	return nil
+}
+
+func (m *memoryRemoteCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var members []string
+	for member := range m.sets[key] {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (m *memoryRemoteCache) Del(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.values, key)
+		delete(m.sets, key)
+	}
+	return nil
+}
+
+func (m *memoryRemoteCache) getCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.gets
+}
+
+// put cachea el valor como lo hace un repositorio tras un fallo de caché.
+func put[T proto.Message](t *testing.T, c *tieredCache, key string, value T) {
+	t.Helper()
+	ctx := context.Background()
+	_, fill, ok := getCached(ctx, c, key, func() T { return value.ProtoReflect().New().Interface().(T) })
+	if ok {
+		t.Fatalf("%s ya estaba en caché", key)
+	}
+	if err := c.fill(ctx, fill, value); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+}
+
+// invalidateGroup invalida el grupo como DeleteRoomCacheByRoomID.
+func invalidateGroup(c *tieredCache, group string, keys ...string) {
+	ctx := context.Background()
+	c.bumpVersion(ctx, group)
+	c.remote.Del(ctx, keys...)
+	c.invalidate([]string{group}, nil)
+}
+
+func TestTieredCache(t *testing.T) {
+	ctx := context.Background()
+	newRoom := func() *chatv1.Room { return &chatv1.Room{} }
+	key := "endpoint:chat:room:{r1}:user:1"
+
+	t.Run("el LRU evita ir a Redis y devuelve copias", func(t *testing.T) {
+		remote := newMemoryRemoteCache()
+		c := newTieredCache(remote, 10, time.Minute)
+		put(t, c, key, &chatv1.Room{Id: "r1", Name: "Sala"})
+		reads := remote.getCount()
+
+		got, _, ok := getCached(ctx, c, key, newRoom)
+		if !ok || got.Name != "Sala" || remote.getCount() != reads {
+			t.Fatalf("lectura local = %v %v (lecturas de Redis %d)", got, ok, remote.getCount())
+		}
+		got.Name = "modificada"
+		if again, _, _ := getCached(ctx, c, key, newRoom); again.Name != "Sala" {
+			t.Fatalf("modificar el resultado alteró la caché: %v", again)
+		}
+	})
+
+	t.Run("Redis guarda protobuf e ignora valores JSON", func(t *testing.T) {
+		remote := newMemoryRemoteCache()
+		writer := newTieredCache(remote, 10, time.Minute)
+		reader := newTieredCache(remote, 10, time.Minute)
+		put(t, writer, key, &chatv1.Room{Id: "r1", Name: "Sala"})
+		reads := remote.getCount()
+
+		got, _, ok := getCached(ctx, reader, key, newRoom)
+		if !ok || got.Name != "Sala" || remote.getCount() != reads+1 {
+			t.Fatalf("lectura desde Redis = %v %v", got, ok)
+		}
+		if _, _, ok := getCached(ctx, reader, key, newRoom); !ok || remote.getCount() != reads+1 {
+			t.Fatalf("la segunda lectura debe salir del LRU")
+		}
+
+		legacy, _ := json.Marshal(map[string]any{"data": map[string]any{"id": "r2"}})
+		remote.Set(ctx, "endpoint:chat:room:{r2}:user:1", string(legacy), time.Hour)
+		if _, _, ok := getCached(ctx, reader, "endpoint:chat:room:{r2}:user:1", newRoom); ok {
+			t.Fatalf("un valor JSON antiguo debe tratarse como fallo de caché")
+		}
+	})
+
+	t.Run("las invalidaciones de otra réplica vacían el LRU", func(t *testing.T) {
+		remote := newMemoryRemoteCache()
+		a := newTieredCache(remote, 10, time.Minute)
+		b := newTieredCache(remote, 10, time.Minute)
+		put(t, a, key, &chatv1.Room{Id: "r1", Name: "vieja"})
+		if _, _, ok := getCached(ctx, b, key, newRoom); !ok {
+			t.Fatalf("b no encontró la sala en Redis")
+		}
+		msgKey := messageSimpleCacheKey("m1")
+		put(t, b, msgKey, &chatv1.MessageData{Id: "m1"})
+
+		// a cambia la sala: borra Redis y avisa
+		remote.Del(ctx, key, msgKey)
+		a.invalidate([]string{"r1"}, []string{msgKey})
+		data, _ := json.Marshal(cacheInvalidation{Origin: a.origin, Groups: []string{"r1"}, Keys: []string{msgKey}})
+		b.applyInvalidation(data)
+
+		if _, _, ok := getCached(ctx, b, key, newRoom); ok {
+			t.Fatalf("la réplica b sigue sirviendo la sala invalidada")
+		}
+		if _, _, ok := getCached(ctx, b, msgKey, func() *chatv1.MessageData { return &chatv1.MessageData{} }); ok {
+			t.Fatalf("la réplica b sigue sirviendo el mensaje invalidado")
+		}
+
+		// Los avisos propios se ignoran
+		put(t, a, key, &chatv1.Room{Id: "r1"})
+		own, _ := json.Marshal(cacheInvalidation{Origin: a.origin, Groups: []string{"r1"}})
+		a.applyInvalidation(own)
+		if a.local.len() != 1 {
+			t.Fatalf("la réplica descartó su caché por su propio aviso")
+		}
+	})
+
+	t.Run("una lectura anterior a la invalidación no repuebla el LRU", func(t *testing.T) {
+		c := newTieredCache(newMemoryRemoteCache(), 10, time.Minute)
+		generation := c.local.generation("r1")
+		c.invalidate([]string{"r1"}, nil)
+		c.local.set(key, "r1", &chatv1.Room{Id: "r1", Name: "vieja"}, generation)
+		if c.local.len() != 0 {
+			t.Fatalf("se guardó un valor leído antes de la invalidación")
+		}
+	})
+
+	t.Run("una lectura de la base de datos anterior a la invalidación no llena Redis", func(t *testing.T) {
+		remote := newMemoryRemoteCache()
+		a := newTieredCache(remote, 10, time.Minute)
+		b := newTieredCache(remote, 10, time.Minute)
+
+		// a falla la caché y lee la sala de la base de datos; mientras tanto b guarda un
+		// mensaje nuevo e invalida la sala
+		_, fill, _ := getCached(ctx, a, key, newRoom)
+		invalidateGroup(b, "r1", key)
+		if err := a.fill(ctx, fill, &chatv1.Room{Id: "r1", Name: "vieja"}); err != nil {
+			t.Fatalf("fill: %v", err)
+		}
+		if _, _, ok := getCached(ctx, b, key, newRoom); ok {
+			t.Fatalf("quedó en Redis la sala leída antes de la invalidación")
+		}
+		if a.local.len() != 0 {
+			t.Fatalf("quedó en el LRU la sala leída antes de la invalidación")
+		}
+
+		// Una lectura posterior a la invalidación sí se cachea
+		put(t, a, key, &chatv1.Room{Id: "r1", Name: "nueva"})
+		if got, _, ok := getCached(ctx, b, key, newRoom); !ok || got.Name != "nueva" {
+			t.Fatalf("lectura tras la invalidación = %v %v", got, ok)
+		}
+	})
+
+	t.Run("el LRU respeta la capacidad y el TTL", func(t *testing.T) {
+		c := newTieredCache(newMemoryRemoteCache(), 3, time.Minute)
+		now := time.Now()
+		c.local.now = func() time.Time { return now }
+		for i := range 5 {
+			put(t, c, fmt.Sprintf("endpoint:chat:room:{r%d}:user:1", i), &chatv1.Room{Id: fmt.Sprint(i)})
+		}
+		if c.local.len() != 3 || len(c.local.groups) != 3 {
+			t.Fatalf("LRU con %d entradas y %d grupos, capacidad 3", c.local.len(), len(c.local.groups))
+		}
+		if _, ok := c.local.get("endpoint:chat:room:{r0}:user:1"); ok {
+			t.Fatalf("la entrada más antigua debía salir")
+		}
+
+		now = now.Add(2 * time.Minute)
+		if _, ok := c.local.get("endpoint:chat:room:{r4}:user:1"); ok {
+			t.Fatalf("la entrada caducada sigue en el LRU")
+		}
+	})
+}
+
+func TestCacheGroup(t *testing.T) {
+	for key, want := range map[string]string{
+		"endpoint:chat:room:{r1}:shim:user:2":        "r1",
+		"endpoint:chat:messagesimple:messageId:{m1}": "m1",
+		"sin-llaves": "sin-llaves",
+	} {
+		if got := cacheGroup(key); got != want {
+			t.Fatalf("cacheGroup(%q) = %q, se esperaba %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package roomsrepository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor se devuelve cuando el cursor de una petición no se puede decodificar o
+// pertenece a otro listado o a otro backend.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Listados que admiten cursor. El nombre va dentro del cursor para que uno de salas no
+// se pueda usar para paginar mensajes.
+const (
+	cursorRooms        = "rooms"
+	cursorMessages     = "messages"
+	cursorMessagesSeq  = "messages_seq"
+	cursorParticipants = "participants"
+	cursorReads        = "reads"
+	cursorReactions    = "reactions"
+)
+
+// pageCursor es el contenido del cursor opaco que reciben los clientes. Postgres y el
+// repositorio en memoria guardan en Keys la clave de orden del último elemento (keyset);
+// Scylla guarda el paging state del driver.
+type pageCursor struct {
+	List  string   `json:"l"`
+	Keys  []string `json:"k,omitempty"`
+	State []byte   `json:"s,omitempty"`
+}
+
+func encodeCursor(list string, keys ...string) string {
+	return encodePageCursor(pageCursor{List: list, Keys: keys})
+}
+
+func encodeStateCursor(list string, state []byte) string {
+	if len(state) == 0 {
+		return ""
+	}
+	return encodePageCursor(pageCursor{List: list, State: state})
+}
+
+func encodePageCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor devuelve nil si no hay cursor. keys es el número de claves que espera el
+// listado (0 para cursores de Scylla).
+func decodeCursor(cursor string, list string, keys int) (*pageCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.List != list {
+		return nil, ErrInvalidCursor
+	}
+	if keys == 0 && len(c.State) == 0 || keys > 0 && len(c.Keys) != keys {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,85 @@
+package roomsrepository
+
+import (
+	"errors"
+	"fmt"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// Salas cifradas de extremo a extremo (e2e).
+//
+// Una sala e2e no tiene clave en el servidor: encryption_data queda vacío y key_version solo
+// numera las claves que generan los clientes. Cada dispositivo registra su clave pública
+// (user_device_key en Postgres, device_keys_by_user en Scylla) y los participantes entregan
+// cada versión de la clave de la sala envuelta para cada dispositivo (room_device_key,
+// room_device_keys_by_room). El servidor solo guarda y reenvía texto cifrado.
+//
+// RotateE2ERoomKey avanza la versión y guarda la clave nueva envuelta en la misma operación,
+// así que dos rotaciones concurrentes producen versiones distintas en lugar de mezclar claves.
+// ShareRoomKey entrega una versión existente a dispositivos nuevos o a participantes que
+// entraron después; una entrega repetida para el mismo dispositivo y versión reemplaza la
+// anterior (por ejemplo, tras cambiar la clave pública del dispositivo). RotateRoomKey, que
+// genera la clave en el servidor, no se aplica a estas salas.
+
+// OutboxRoomKeyShared es el evento de outbox de una entrega de claves, para que los
+// dispositivos que esperaban la clave la pidan.
+const OutboxRoomKeyShared = "room_key_shared"
+
+var (
+	// ErrE2ERoom indica una operación que necesita la clave de la sala en el servidor.
+	ErrE2ERoom = errors.New("room is end-to-end encrypted")
+	// ErrNotE2ERoom indica una operación de claves por dispositivo en una sala que no es e2e.
+	ErrNotE2ERoom = errors.New("room is not end-to-end encrypted")
+	// ErrUnknownDevice indica un dispositivo sin clave registrada, o de un usuario que no
+	// participa en la sala.
+	ErrUnknownDevice = errors.New("unknown device")
+	// ErrInvalidWrappedKeys indica una entrega de claves vacía, incompleta o con dispositivos
+	// repetidos.
+	ErrInvalidWrappedKeys = errors.New("invalid wrapped room keys")
+)
+
+// newRoomKeySharedEvent construye el evento de outbox de una entrega de claves.
+func newRoomKeySharedEvent(roomID string, userID int) OutboxEvent {
+	return newOutboxEvent(roomID, userID, OutboxRoomKeyShared, "", &chatv1.MessageEvent{
+		RoomId: roomID,
+		Event:  &chatv1.MessageEvent_IsRoomUpdated{IsRoomUpdated: true},
+	})
+}
+
+// validateWrappedKeys comprueba que la entrega tenga al menos una clave, que cada una tenga
+// destino y contenido, y que no repita dispositivos.
+func validateWrappedKeys(keys []*chatv1.WrappedRoomKey) error {
+	if len(keys) == 0 {
+		return ErrInvalidWrappedKeys
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key.UserId <= 0 || key.DeviceId == "" || key.WrappedKey == "" {
+			return ErrInvalidWrappedKeys
+		}
+		id := deviceKeyID(int(key.UserId), key.DeviceId)
+		if seen[id] {
+			return ErrInvalidWrappedKeys
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+func deviceKeyID(userID int, deviceID string) string {
+	return fmt.Sprintf("%d/%s", userID, deviceID)
+}
+
+// wrappedKeyUsers devuelve los usuarios destino de la entrega, sin repetir.
+func wrappedKeyUsers(keys []*chatv1.WrappedRoomKey) []int {
+	var users []int
+	seen := make(map[int]bool)
+	for _, key := range keys {
+		if !seen[int(key.UserId)] {
+			seen[int(key.UserId)] = true
+			users = append(users, int(key.UserId))
+		}
+	}
+	return users
+}
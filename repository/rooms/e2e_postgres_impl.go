@@ -0,0 +1,235 @@
+package roomsrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	dbpq "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+)
+
+func (r *SQLRoomRepository) RegisterDeviceKey(ctx context.Context, userId int, deviceId string, publicKey string) error {
+	_, err := dbpq.QueryBuilder().
+		Insert("public.user_device_key").
+		Columns("user_id", "device_id", "public_key").
+		Values(userId, deviceId, publicKey).
+		Suffix("ON CONFLICT (user_id, device_id) DO UPDATE SET public_key = EXCLUDED.public_key, updated_at = NOW()").
+		RunWith(r.db).
+		ExecContext(ctx)
+	return err
+}
+
+func (r *SQLRoomRepository) GetDeviceKeys(ctx context.Context, userIds []int, roomId string) ([]*chatv1.DeviceKey, error) {
+	query := dbpq.QueryBuilder().
+		Select("k.user_id", "k.device_id", "k.public_key", "k.updated_at").
+		From("public.user_device_key AS k").
+		OrderBy("k.user_id", "k.device_id")
+	if roomId != "" {
+		query = query.InnerJoin("room_member AS m ON m.user_id = k.user_id AND m.room_id = ? AND m.removed_at IS NULL AND m.deleted_at IS NULL", roomId)
+	} else {
+		if len(userIds) == 0 {
+			return nil, nil
+		}
+		query = query.Where(sq.Eq{"k.user_id": userIds})
+	}
+
+	rows, err := query.RunWith(r.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*chatv1.DeviceKey
+	for rows.Next() {
+		key := &chatv1.DeviceKey{}
+		if err := rows.Scan(&key.UserId, &key.DeviceId, &key.PublicKey, &key.UpdatedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RotateE2ERoomKey avanza key_version y guarda la clave nueva envuelta en la misma
+// transacción. El FOR UPDATE sobre la sala da a cada rotación concurrente su propia versión.
+func (r *SQLRoomRepository) RotateE2ERoomKey(ctx context.Context, userId int, roomId string, deviceId string, keys []*chatv1.WrappedRoomKey) (int32, error) {
+	if err := validateWrappedKeys(keys); err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	version, err := lockE2ERoom(ctx, tx, roomId)
+	if err != nil {
+		return 0, err
+	}
+	if err = checkE2EDevices(ctx, tx, roomId, userId, deviceId, keys); err != nil {
+		return 0, err
+	}
+
+	_, err = dbpq.QueryBuilder().
+		Update("room").
+		Set("key_version", version+1).
+		Set("updated_at", sq.Expr("NOW()")).
+		Where(sq.Eq{"id": roomId}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = upsertWrappedKeys(ctx, tx, roomId, version+1, userId, deviceId, keys); err != nil {
+		return 0, err
+	}
+	if err = insertOutboxEvents(ctx, tx, newRoomKeyRotatedEvent(roomId, userId)); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	DeleteRoomCacheByRoomID(ctx, roomId)
+
+	return version + 1, nil
+}
+
+func (r *SQLRoomRepository) ShareRoomKey(ctx context.Context, userId int, roomId string, version int32, deviceId string, keys []*chatv1.WrappedRoomKey) error {
+	if err := validateWrappedKeys(keys); err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := lockE2ERoom(ctx, tx, roomId)
+	if err != nil {
+		return err
+	}
+	if version < 1 || version > current {
+		return ErrUnknownKeyVersion
+	}
+	if err = checkE2EDevices(ctx, tx, roomId, userId, deviceId, keys); err != nil {
+		return err
+	}
+
+	if err = upsertWrappedKeys(ctx, tx, roomId, version, userId, deviceId, keys); err != nil {
+		return err
+	}
+	if err = insertOutboxEvents(ctx, tx, newRoomKeySharedEvent(roomId, userId)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLRoomRepository) GetWrappedRoomKeys(ctx context.Context, roomId string, userId int, deviceId string) ([]*chatv1.RoomKey, error) {
+	rows, err := dbpq.QueryBuilder().
+		Select("version", "wrapped_key", "sender_user_id", "sender_device_id").
+		From("public.room_device_key").
+		Where(sq.Eq{"room_id": roomId, "user_id": userId, "device_id": deviceId}).
+		OrderBy("version DESC").
+		RunWith(r.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*chatv1.RoomKey
+	for rows.Next() {
+		key := &chatv1.RoomKey{}
+		if err := rows.Scan(&key.Version, &key.EncryptionData, &key.WrappedByUserId, &key.WrappedByDeviceId); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// lockE2ERoom bloquea la sala hasta el final de la transacción y devuelve su key_version.
+func lockE2ERoom(ctx context.Context, tx *sql.Tx, roomId string) (int32, error) {
+	var version int32
+	var e2e bool
+	err := dbpq.QueryBuilder().
+		Select("key_version", "e2e").
+		From("room").
+		Where(sq.Eq{"id": roomId}).
+		Where(sq.Eq{"deleted_at": nil}).
+		Suffix("FOR UPDATE").
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&version, &e2e)
+	if err != nil {
+		return 0, err
+	}
+	if !e2e {
+		return 0, ErrNotE2ERoom
+	}
+	return roomKeyVersion(version), nil
+}
+
+// checkE2EDevices comprueba que el dispositivo que envuelve y los de destino estén
+// registrados y sean de participantes de la sala.
+func checkE2EDevices(ctx context.Context, tx *sql.Tx, roomId string, userId int, deviceId string, keys []*chatv1.WrappedRoomKey) error {
+	rows, err := dbpq.QueryBuilder().
+		Select("k.user_id", "k.device_id").
+		From("public.user_device_key AS k").
+		InnerJoin("room_member AS m ON m.user_id = k.user_id AND m.room_id = ? AND m.removed_at IS NULL AND m.deleted_at IS NULL", roomId).
+		Where(sq.Eq{"k.user_id": append(wrappedKeyUsers(keys), userId)}).
+		RunWith(tx).
+		QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	registered := make(map[string]bool)
+	for rows.Next() {
+		var deviceUser int
+		var device string
+		if err := rows.Scan(&deviceUser, &device); err != nil {
+			return err
+		}
+		registered[deviceKeyID(deviceUser, device)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !registered[deviceKeyID(userId, deviceId)] {
+		return ErrUnknownDevice
+	}
+	for _, key := range keys {
+		if !registered[deviceKeyID(int(key.UserId), key.DeviceId)] {
+			return ErrUnknownDevice
+		}
+	}
+	return nil
+}
+
+func upsertWrappedKeys(ctx context.Context, tx *sql.Tx, roomId string, version int32, senderId int, senderDeviceId string, keys []*chatv1.WrappedRoomKey) error {
+	query := dbpq.QueryBuilder().
+		Insert("public.room_device_key").
+		Columns("room_id", "user_id", "device_id", "version", "wrapped_key", "sender_user_id", "sender_device_id")
+	for _, key := range keys {
+		query = query.Values(roomId, key.UserId, key.DeviceId, version, key.WrappedKey, senderId, senderDeviceId)
+	}
+	_, err := query.
+		Suffix("ON CONFLICT (room_id, user_id, device_id, version) DO UPDATE SET wrapped_key = EXCLUDED.wrapped_key, sender_user_id = EXCLUDED.sender_user_id, sender_device_id = EXCLUDED.sender_device_id, created_at = NOW()").
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to store wrapped room keys: %w", err)
+	}
+	return nil
+}
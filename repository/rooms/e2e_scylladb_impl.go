@@ -0,0 +1,241 @@
+package roomsrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	"github.com/scylladb-solutions/gocql/v2"
+)
+
+// Intentos de RotateE2ERoomKey cuando otra rotación gana el LWT sobre key_version
+const e2eRotateAttempts = 5
+
+func (r *ScyllaRoomRepository) RegisterDeviceKey(ctx context.Context, userId int, deviceId string, publicKey string) error {
+	err := r.session.Query(`INSERT INTO device_keys_by_user (user_id, device_id, public_key, updated_at) VALUES (?, ?, ?, ?)`, userId, deviceId, publicKey, time.Now()).
+		WithContext(ctx).Exec()
+	if err != nil {
+		return fmt.Errorf("error al registrar la clave del dispositivo: %w", err)
+	}
+	return nil
+}
+
+func (r *ScyllaRoomRepository) GetDeviceKeys(ctx context.Context, userIds []int, roomId string) ([]*chatv1.DeviceKey, error) {
+	if roomId != "" {
+		roomUUID, err := gocql.ParseUUID(roomId)
+		if err != nil {
+			return nil, fmt.Errorf("ID de sala inválido: %w", err)
+		}
+		userIds = nil
+		iter := r.session.Query(`SELECT user_id FROM participants_by_room WHERE room_id = ?`, roomUUID).WithContext(ctx).Iter()
+		var participantID int
+		for iter.Scan(&participantID) {
+			userIds = append(userIds, participantID)
+		}
+		if err := iter.Close(); err != nil {
+			return nil, fmt.Errorf("error al leer los participantes: %w", err)
+		}
+	}
+	if len(userIds) == 0 {
+		return nil, nil
+	}
+
+	iter := r.session.Query(`SELECT user_id, device_id, public_key, updated_at FROM device_keys_by_user WHERE user_id IN ?`, userIds).WithContext(ctx).Iter()
+	var keys []*chatv1.DeviceKey
+	var userID int
+	var deviceID, publicKey string
+	var updatedAt time.Time
+	for iter.Scan(&userID, &deviceID, &publicKey, &updatedAt) {
+		keys = append(keys, &chatv1.DeviceKey{
+			UserId:    int32(userID),
+			DeviceId:  deviceID,
+			PublicKey: publicKey,
+			UpdatedAt: updatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error al leer las claves de los dispositivos: %w", err)
+	}
+	return keys, nil
+}
+
+// RotateE2ERoomKey avanza key_version con LWT y después guarda la clave nueva envuelta. Si
+// otra rotación gana el LWT se reintenta con la versión siguiente: la clave de este cliente
+// nunca se guarda en la versión de otro. Si falla la escritura de las claves, la versión
+// queda sin claves y el cliente puede volver a rotar.
+func (r *ScyllaRoomRepository) RotateE2ERoomKey(ctx context.Context, userId int, roomId string, deviceId string, keys []*chatv1.WrappedRoomKey) (int32, error) {
+	if err := validateWrappedKeys(keys); err != nil {
+		return 0, err
+	}
+	roomUUID, err := gocql.ParseUUID(roomId)
+	if err != nil {
+		return 0, fmt.Errorf("ID de sala inválido: %w", err)
+	}
+
+	version, err := r.readE2ERoom(ctx, roomUUID)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.checkE2EDevices(ctx, roomUUID, userId, deviceId, keys); err != nil {
+		return 0, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt == e2eRotateAttempts {
+			return 0, fmt.Errorf("no se pudo rotar la clave de la sala %s tras %d intentos", roomId, attempt)
+		}
+		var observed *int
+		applied, err := r.session.Query(`UPDATE room_key_state SET key_version = ? WHERE room_id = ? IF key_version = ?`, version+1, roomUUID, version).
+			WithContext(ctx).ScanCAS(&observed)
+		if err != nil {
+			return 0, fmt.Errorf("error al rotar la clave de la sala: %w", err)
+		}
+		if applied {
+			break
+		}
+		if observed == nil {
+			return 0, fmt.Errorf("error al rotar la clave de la sala: %w", gocql.ErrNotFound)
+		}
+		version = roomKeyVersion(int32(*observed))
+	}
+
+	now := time.Now()
+	err = r.session.Query(`UPDATE room_details SET updated_at = ? WHERE room_id = ?`, now, roomUUID).WithContext(ctx).Exec()
+	if err != nil {
+		fmt.Printf("Error al actualizar updated_at de la sala %s: %v\n", roomId, err)
+	}
+
+	batch := r.session.Batch(gocql.LoggedBatch)
+	addWrappedKeys(batch, roomUUID, version+1, userId, deviceId, keys, now)
+	if err := addOutboxEvents(batch, newRoomKeyRotatedEvent(roomId, userId)); err != nil {
+		return 0, err
+	}
+	if err := r.session.ExecuteBatch(batch); err != nil {
+		return 0, fmt.Errorf("error al guardar las claves envueltas: %w", err)
+	}
+
+	DeleteRoomCacheByRoomID(ctx, roomId)
+
+	return version + 1, nil
+}
+
+func (r *ScyllaRoomRepository) ShareRoomKey(ctx context.Context, userId int, roomId string, version int32, deviceId string, keys []*chatv1.WrappedRoomKey) error {
+	if err := validateWrappedKeys(keys); err != nil {
+		return err
+	}
+	roomUUID, err := gocql.ParseUUID(roomId)
+	if err != nil {
+		return fmt.Errorf("ID de sala inválido: %w", err)
+	}
+
+	current, err := r.readE2ERoom(ctx, roomUUID)
+	if err != nil {
+		return err
+	}
+	if version < 1 || version > current {
+		return ErrUnknownKeyVersion
+	}
+	if err := r.checkE2EDevices(ctx, roomUUID, userId, deviceId, keys); err != nil {
+		return err
+	}
+
+	batch := r.session.Batch(gocql.LoggedBatch)
+	addWrappedKeys(batch, roomUUID, version, userId, deviceId, keys, time.Now())
+	if err := addOutboxEvents(batch, newRoomKeySharedEvent(roomId, userId)); err != nil {
+		return err
+	}
+	if err := r.session.ExecuteBatch(batch); err != nil {
+		return fmt.Errorf("error al guardar las claves envueltas: %w", err)
+	}
+	return nil
+}
+
+func (r *ScyllaRoomRepository) GetWrappedRoomKeys(ctx context.Context, roomId string, userId int, deviceId string) ([]*chatv1.RoomKey, error) {
+	roomUUID, err := gocql.ParseUUID(roomId)
+	if err != nil {
+		return nil, fmt.Errorf("ID de sala inválido: %w", err)
+	}
+
+	iter := r.session.Query(`SELECT version, wrapped_key, sender_user_id, sender_device_id FROM room_device_keys_by_room WHERE room_id = ? AND user_id = ? AND device_id = ?`, roomUUID, userId, deviceId).
+		WithContext(ctx).Iter()
+	var keys []*chatv1.RoomKey
+	var version, senderID int
+	var wrappedKey, senderDeviceID string
+	for iter.Scan(&version, &wrappedKey, &senderID, &senderDeviceID) {
+		keys = append(keys, &chatv1.RoomKey{
+			Version:           int32(version),
+			EncryptionData:    wrappedKey,
+			WrappedByUserId:   int32(senderID),
+			WrappedByDeviceId: senderDeviceID,
+		})
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error al leer las claves envueltas: %w", err)
+	}
+	return keys, nil
+}
+
+// readE2ERoom devuelve la versión actual de una sala e2e.
+func (r *ScyllaRoomRepository) readE2ERoom(ctx context.Context, roomUUID gocql.UUID) (int32, error) {
+	var e2e bool
+	err := r.session.Query(`SELECT e2e FROM room_details WHERE room_id = ?`, roomUUID).
+		WithContext(ctx).Scan(&e2e)
+	if err != nil {
+		return 0, fmt.Errorf("error al leer la sala: %w", err)
+	}
+	if !e2e {
+		return 0, ErrNotE2ERoom
+	}
+	state, err := loadRoomKeyState(ctx, r.session, roomUUID)
+	if err != nil {
+		return 0, fmt.Errorf("error al leer la clave de la sala: %w", err)
+	}
+	return state.version, nil
+}
+
+// checkE2EDevices comprueba que el dispositivo que envuelve y los de destino estén
+// registrados y sean de participantes de la sala.
+func (r *ScyllaRoomRepository) checkE2EDevices(ctx context.Context, roomUUID gocql.UUID, userId int, deviceId string, keys []*chatv1.WrappedRoomKey) error {
+	users := append(wrappedKeyUsers(keys), userId)
+
+	members := make(map[int]bool)
+	iter := r.session.Query(`SELECT user_id FROM participants_by_room WHERE room_id = ? AND user_id IN ?`, roomUUID, users).WithContext(ctx).Iter()
+	var memberID int
+	for iter.Scan(&memberID) {
+		members[memberID] = true
+	}
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("error al leer los participantes: %w", err)
+	}
+
+	registered := make(map[string]bool)
+	iter = r.session.Query(`SELECT user_id, device_id FROM device_keys_by_user WHERE user_id IN ?`, users).WithContext(ctx).Iter()
+	var deviceUser int
+	var device string
+	for iter.Scan(&deviceUser, &device) {
+		if members[deviceUser] {
+			registered[deviceKeyID(deviceUser, device)] = true
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("error al leer las claves de los dispositivos: %w", err)
+	}
+
+	if !registered[deviceKeyID(userId, deviceId)] {
+		return ErrUnknownDevice
+	}
+	for _, key := range keys {
+		if !registered[deviceKeyID(int(key.UserId), key.DeviceId)] {
+			return ErrUnknownDevice
+		}
+	}
+	return nil
+}
+
+func addWrappedKeys(batch *gocql.Batch, roomUUID gocql.UUID, version int32, senderId int, senderDeviceId string, keys []*chatv1.WrappedRoomKey, now time.Time) {
+	for _, key := range keys {
+		batch.Query(`INSERT INTO room_device_keys_by_room (room_id, user_id, device_id, version, wrapped_key, sender_user_id, sender_device_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			roomUUID, key.UserId, key.DeviceId, version, key.WrappedKey, senderId, senderDeviceId, now)
+	}
+}
@@ -0,0 +1,52 @@
+package roomsrepository
+
+import (
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// Borrado de los datos de un usuario (derecho al olvido).
+//
+// EraseUserData anonimiza los mensajes del usuario (quedan como tombstone, igual que en la
+// retención, para no romper respuestas ni la secuencia seq), borra sus reacciones, menciones
+// y estados de lectura, y lo saca de todas sus salas:
+//   - las salas p2p se cierran para el otro participante;
+//   - en los grupos, si era el único owner, se promueve al miembro más antiguo; si no queda
+//     nadie, la sala se cierra.
+//
+// Cada sala activa recibe un RoomLeave con reason "account_deleted" para que los clientes
+// retiren al usuario y descarten su copia local de sus mensajes. La operación es idempotente:
+// si falla a medias se puede repetir. Los tokens de notificaciones viven en el repositorio de
+// tokens y los borra quien orquesta el proceso.
+
+// UserErasedReason es el reason del RoomLeave que se publica al borrar un usuario.
+const UserErasedReason = "account_deleted"
+
+// UserErasureReport resume lo que EraseUserData hizo con un usuario.
+type UserErasureReport struct {
+	UserID             int
+	MessagesAnonymised int
+	Rooms              []string       // salas en las que participa o participó; VerifyUserErasure las revisa
+	RoomsLeft          []string       // grupos de los que se le retiró
+	RoomsClosed        []string       // p2p, y grupos que se quedaron sin miembros
+	OwnersPromoted     map[string]int // sala → nuevo owner, cuando era el único owner
+}
+
+func newUserErasureReport(userID int) *UserErasureReport {
+	return &UserErasureReport{UserID: userID, OwnersPromoted: map[string]int{}}
+}
+
+// newUserErasedEvent construye el RoomLeave de una sala de la que se borró al usuario. Lo
+// origina el sistema, así que el usuario del evento es 0.
+func newUserErasedEvent(roomID string, userID int, at time.Time) OutboxEvent {
+	reason := UserErasedReason
+	return newOutboxEvent(roomID, 0, OutboxRoomLeave, "", &chatv1.MessageEvent{
+		RoomId: roomID,
+		Event: &chatv1.MessageEvent_RoomLeave{RoomLeave: &chatv1.RoomLeaveEvent{
+			UsersId: []int32{int32(userID)},
+			LeftAt:  at.UTC().Format(time.RFC3339),
+			Reason:  &reason,
+		}},
+	})
+}
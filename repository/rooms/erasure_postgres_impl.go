@@ -0,0 +1,337 @@
+package roomsrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	dbpq "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+)
+
+type erasureMembership struct {
+	roomID, roomType, role string
+	active, roomActive     bool
+}
+
+// EraseUserData borra los datos del usuario en una sola transacción.
+func (r *SQLRoomRepository) EraseUserData(ctx context.Context, userId int) (*UserErasureReport, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := newUserErasureReport(userId)
+	now := time.Now()
+
+	rows, err := dbpq.QueryBuilder().
+		Select("room_member.room_id", "room.type", "room_member.role", "room_member.removed_at IS NULL", "room.deleted_at IS NULL").
+		From("room_member").
+		InnerJoin("room ON room.id = room_member.room_id").
+		Where(sq.Eq{"room_member.user_id": userId}).
+		OrderBy("room_member.created_at ASC").
+		RunWith(tx).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var memberships []erasureMembership
+	for rows.Next() {
+		var m erasureMembership
+		if err := rows.Scan(&m.roomID, &m.roomType, &m.role, &m.active, &m.roomActive); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		memberships = append(memberships, m)
+		report.Rooms = append(report.Rooms, m.roomID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Reacciones, menciones y estados de lectura: los del usuario y los de sus mensajes
+	ownMessages := "IN (SELECT id FROM room_message WHERE sender_id = ?)"
+	for _, table := range []struct{ name, message, user string }{
+		{"room_message_reaction", "\"messageId\"", "\"reactedById\""},
+		{"room_message_tag", "message_id", "user_id"},
+		{"room_message_meta", "message_id", "user_id"},
+	} {
+		_, err = dbpq.QueryBuilder().
+			Delete(table.name).
+			Where(sq.Or{sq.Eq{table.user: userId}, sq.Expr(table.message+" "+ownMessages, userId)}).
+			RunWith(tx).
+			ExecContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to erase %s: %w", table.name, err)
+		}
+	}
+
+	rows, err = dbpq.QueryBuilder().
+		Update("room_message").
+		Set("content", nil).
+		Set("content_decrypted", nil).
+		Set("audio_transcription", nil).
+		Set("file", nil).
+		Set("location_name", nil).
+		Set("location_latitude", nil).
+		Set("location_longitude", nil).
+		Set("contact_id", nil).
+		Set("contact_name", nil).
+		Set("contact_phone", nil).
+		Set("sender_message_id", nil).
+		Set("\"isDeleted\"", true).
+		Set("deleted_at", sq.Expr("COALESCE(deleted_at, ?)", now)).
+		Set("updated_at", now).
+		Where(sq.Eq{"sender_id": userId}).
+		Suffix("RETURNING id, room_id").
+		RunWith(tx).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var messageIds []string
+	messageRooms := map[string]bool{}
+	for rows.Next() {
+		var id, roomId string
+		if err := rows.Scan(&id, &roomId); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		messageIds = append(messageIds, id)
+		messageRooms[roomId] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	report.MessagesAnonymised = len(messageIds)
+
+	// Los reenvíos de sus mensajes dejan de apuntar al usuario
+	_, err = dbpq.QueryBuilder().
+		Update("room_message").
+		Set("forwarded_message_original_sender", nil).
+		Where(sq.Eq{"forwarded_message_original_sender": userId}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Claves de sus dispositivos y las claves de sala envueltas para ellos (salas e2e)
+	for _, table := range []string{"user_device_key", "room_device_key"} {
+		_, err = dbpq.QueryBuilder().
+			Delete(table).
+			Where(sq.Eq{"user_id": userId}).
+			RunWith(tx).
+			ExecContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to erase %s: %w", table, err)
+		}
+	}
+
+	for _, m := range memberships {
+		if err := r.eraseMembership(ctx, tx, userId, m, now, report); err != nil {
+			return nil, fmt.Errorf("failed to erase membership in room %s: %w", m.roomID, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// Los contadores de no leídos viven fuera de la transacción; si fallan, el borrado se
+	// reintenta entero
+	if r.counters != nil {
+		if err := r.counters.erase(ctx, userId); err != nil {
+			return nil, fmt.Errorf("failed to erase unread counters: %w", err)
+		}
+	}
+
+	for _, roomId := range report.Rooms {
+		DeleteRoomCacheByRoomID(ctx, roomId)
+		delete(messageRooms, roomId)
+	}
+	for roomId := range messageRooms {
+		DeleteRoomCacheByRoomID(ctx, roomId)
+	}
+	for _, id := range messageIds {
+		DeleteCache(ctx, messageSimpleCacheKey(id))
+	}
+
+	return report, nil
+}
+
+func (r *SQLRoomRepository) eraseMembership(ctx context.Context, tx *sql.Tx, userId int, m erasureMembership, now time.Time, report *UserErasureReport) error {
+	_, err := dbpq.QueryBuilder().
+		Delete("room_member").
+		Where(sq.Eq{"room_id": m.roomID}).
+		Where(sq.Eq{"user_id": userId}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !m.active || !m.roomActive {
+		return nil
+	}
+
+	closeRoom := m.roomType == "p2p"
+	if !closeRoom {
+		report.RoomsLeft = append(report.RoomsLeft, m.roomID)
+		if m.role == "OWNER" {
+			promoted, err := r.promoteErasedOwner(ctx, tx, m.roomID, now)
+			if err != nil {
+				return err
+			}
+			if promoted > 0 {
+				report.OwnersPromoted[m.roomID] = promoted
+			}
+		}
+
+		var remaining int
+		err = dbpq.QueryBuilder().
+			Select("COUNT(*)").
+			From("room_member").
+			Where(sq.Eq{"room_id": m.roomID}).
+			Where(sq.Eq{"removed_at": nil}).
+			RunWith(tx).
+			QueryRowContext(ctx).
+			Scan(&remaining)
+		if err != nil {
+			return err
+		}
+		closeRoom = remaining == 0
+	}
+
+	if closeRoom {
+		report.RoomsClosed = append(report.RoomsClosed, m.roomID)
+		_, err = dbpq.QueryBuilder().
+			Update("room").
+			Set("updated_at", now).
+			Set("deleted_at", now).
+			Where(sq.Eq{"id": m.roomID}).
+			RunWith(tx).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = dbpq.QueryBuilder().
+			Update("room_member").
+			Set("updated_at", now).
+			Set("removed_at", now).
+			Where(sq.Eq{"room_id": m.roomID}).
+			Where(sq.Eq{"removed_at": nil}).
+			RunWith(tx).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return insertOutboxEvents(ctx, tx, newUserErasedEvent(m.roomID, userId, now))
+}
+
+// promoteErasedOwner nombra owner al miembro activo más antiguo si la sala se quedó sin
+// owner. Devuelve el usuario promovido, o 0 si no hizo falta o no queda nadie.
+func (r *SQLRoomRepository) promoteErasedOwner(ctx context.Context, tx *sql.Tx, roomId string, now time.Time) (int, error) {
+	var owners int
+	err := dbpq.QueryBuilder().
+		Select("COUNT(*)").
+		From("room_member").
+		Where(sq.Eq{"room_id": roomId}).
+		Where(sq.Eq{"removed_at": nil}).
+		Where(sq.Eq{"role": "OWNER"}).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&owners)
+	if err != nil || owners > 0 {
+		return 0, err
+	}
+
+	var next int
+	err = dbpq.QueryBuilder().
+		Select("user_id").
+		From("room_member").
+		Where(sq.Eq{"room_id": roomId}).
+		Where(sq.Eq{"removed_at": nil}).
+		OrderBy("created_at ASC", "user_id ASC").
+		Limit(1).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&next)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = dbpq.QueryBuilder().
+		Update("room_member").
+		Set("role", "OWNER").
+		Set("updated_at", now).
+		Where(sq.Eq{"room_id": roomId}).
+		Where(sq.Eq{"user_id": next}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+// VerifyUserErasure cuenta lo que queda del usuario en cada tabla y, con contadores, en
+// Redis. En Postgres todo se puede buscar por usuario, así que roomIds no hace falta.
+func (r *SQLRoomRepository) VerifyUserErasure(ctx context.Context, userId int, roomIds []string) ([]string, error) {
+	checks := []struct {
+		name  string
+		table string
+		where sq.Sqlizer
+	}{
+		{"messages with content", "room_message", sq.And{
+			sq.Eq{"sender_id": userId},
+			sq.Or{
+				sq.NotEq{"content": nil}, sq.NotEq{"content_decrypted": nil}, sq.NotEq{"audio_transcription": nil},
+				sq.NotEq{"file": nil}, sq.NotEq{"location_name": nil}, sq.NotEq{"contact_phone": nil},
+				sq.NotEq{"sender_message_id": nil},
+			},
+		}},
+		{"forwarded message references", "room_message", sq.Eq{"forwarded_message_original_sender": userId}},
+		{"reactions", "room_message_reaction", sq.Eq{"\"reactedById\"": userId}},
+		{"mentions", "room_message_tag", sq.Eq{"user_id": userId}},
+		{"message meta", "room_message_meta", sq.Eq{"user_id": userId}},
+		{"room memberships", "room_member", sq.Eq{"user_id": userId}},
+		{"device keys", "user_device_key", sq.Eq{"user_id": userId}},
+		{"wrapped room keys", "room_device_key", sq.Eq{"user_id": userId}},
+	}
+
+	var remaining []string
+	for _, check := range checks {
+		var count int
+		err := dbpq.QueryBuilder().
+			Select("COUNT(*)").
+			From(check.table).
+			Where(check.where).
+			RunWith(r.db).
+			QueryRowContext(ctx).
+			Scan(&count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify %s: %w", check.name, err)
+		}
+		if count > 0 {
+			remaining = append(remaining, fmt.Sprintf("%s: %d", check.name, count))
+		}
+	}
+
+	if r.counters != nil {
+		counters, err := r.counters.remaining(ctx, userId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify unread counters: %w", err)
+		}
+		remaining = append(remaining, counters...)
+	}
+	return remaining, nil
+}
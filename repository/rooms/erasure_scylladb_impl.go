@@ -0,0 +1,378 @@
+package roomsrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	"github.com/scylladb-solutions/gocql/v2"
+)
+
+// EraseUserData recorre las salas del usuario (las actuales en room_membership_lookup y
+// las que dejó en deleted_rooms_by_user) y borra sus datos sala por sala. Scylla no tiene
+// transacciones: si falla a medias, se vuelve a ejecutar.
+func (r *ScyllaRoomRepository) EraseUserData(ctx context.Context, userId int) (*UserErasureReport, error) {
+	report := newUserErasureReport(userId)
+	now := time.Now()
+
+	rooms, err := r.userRoomIDs(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	report.Rooms = append(report.Rooms, rooms...)
+
+	for _, roomId := range report.Rooms {
+		if err := r.eraseUserFromRoom(ctx, userId, roomId, now, report); err != nil {
+			return nil, fmt.Errorf("failed to erase user %d from room %s: %w", userId, roomId, err)
+		}
+	}
+
+	// Particiones propias del usuario
+	for _, query := range []string{
+		`DELETE FROM rooms_by_user WHERE user_id = ?`,
+		`DELETE FROM room_membership_lookup WHERE user_id = ?`,
+		`DELETE FROM deleted_rooms_by_user WHERE user_id = ?`,
+		`DELETE FROM room_counters_by_user WHERE user_id = ?`,
+		`DELETE FROM p2p_room_by_users WHERE user1_id = ?`,
+		`DELETE FROM device_keys_by_user WHERE user_id = ?`,
+	} {
+		if err := r.session.Query(query, userId).WithContext(ctx).Exec(); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// userRoomIDs devuelve las salas actuales del usuario (room_membership_lookup) y las que
+// dejó o eliminó (deleted_rooms_by_user), sin repetir.
+func (r *ScyllaRoomRepository) userRoomIDs(ctx context.Context, userId int) ([]string, error) {
+	var rooms []string
+	seen := map[gocql.UUID]bool{}
+	for _, query := range []string{
+		`SELECT room_id FROM room_membership_lookup WHERE user_id = ?`,
+		`SELECT room_id FROM deleted_rooms_by_user WHERE user_id = ?`,
+	} {
+		iter := r.session.Query(query, userId).WithContext(ctx).Iter()
+		var roomUUID gocql.UUID
+		for iter.Scan(&roomUUID) {
+			if !seen[roomUUID] {
+				seen[roomUUID] = true
+				rooms = append(rooms, roomUUID.String())
+			}
+		}
+		if err := iter.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return rooms, nil
+}
+
+func (r *ScyllaRoomRepository) eraseUserFromRoom(ctx context.Context, userId int, roomId string, now time.Time, report *UserErasureReport) error {
+	roomUUID, err := gocql.ParseUUID(roomId)
+	if err != nil {
+		return err
+	}
+
+	var roomType string
+	err = r.session.Query(`SELECT type FROM room_details WHERE room_id = ?`, roomUUID).WithContext(ctx).Scan(&roomType)
+	if err == gocql.ErrNotFound {
+		// Sala ya eliminada: solo quedan las particiones del usuario
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var role string
+	active := true
+	err = r.session.Query(`SELECT role FROM participants_by_room WHERE room_id = ? AND user_id = ?`, roomUUID, userId).WithContext(ctx).Scan(&role)
+	if err == gocql.ErrNotFound {
+		active = false
+	} else if err != nil {
+		return err
+	}
+
+	// En Scylla cerrar una sala p2p borra también sus mensajes (ver DeleteRoom)
+	if roomType == "p2p" {
+		if !active {
+			return nil
+		}
+		iter := r.session.Query(`SELECT user_id FROM participants_by_room WHERE room_id = ?`, roomUUID).WithContext(ctx).Iter()
+		var partnerID int
+		for iter.Scan(&partnerID) {
+			if partnerID != userId {
+				r.session.Query(`DELETE FROM p2p_room_by_users WHERE user1_id = ? AND user2_id = ?`, partnerID, userId).WithContext(ctx).Exec()
+			}
+		}
+		if err := iter.Close(); err != nil {
+			return err
+		}
+		if err := r.DeleteRoom(ctx, userId, roomId, nil); err != nil {
+			return err
+		}
+		report.RoomsClosed = append(report.RoomsClosed, roomId)
+		return r.addUserErasedEvent(ctx, roomId, userId, now)
+	}
+
+	if err := r.anonymiseRoomMessages(ctx, userId, roomUUID, now, report); err != nil {
+		return err
+	}
+	if err := r.clearErasedLastMessages(ctx, userId, roomUUID); err != nil {
+		return err
+	}
+	if err := r.session.Query(`DELETE FROM message_status_by_user WHERE user_id = ? AND room_id = ?`, userId, roomUUID).WithContext(ctx).Exec(); err != nil {
+		return err
+	}
+	if err := r.session.Query(`DELETE FROM room_device_keys_by_room WHERE room_id = ? AND user_id = ?`, roomUUID, userId).WithContext(ctx).Exec(); err != nil {
+		return err
+	}
+	if !active {
+		DeleteRoomCacheByRoomID(ctx, roomId)
+		return nil
+	}
+
+	if err := r.session.Query(`DELETE FROM participants_by_room WHERE room_id = ? AND user_id = ?`, roomUUID, userId).WithContext(ctx).Exec(); err != nil {
+		return err
+	}
+	report.RoomsLeft = append(report.RoomsLeft, roomId)
+
+	// Miembros restantes, para promover al más antiguo si se quedó sin owner
+	iter := r.session.Query(`SELECT user_id, role, joined_at FROM participants_by_room WHERE room_id = ?`, roomUUID).WithContext(ctx).Iter()
+	var memberID, oldestID int
+	var memberRole string
+	var joinedAt, oldestJoinedAt time.Time
+	remaining, owners := 0, 0
+	for iter.Scan(&memberID, &memberRole, &joinedAt) {
+		remaining++
+		if memberRole == "OWNER" {
+			owners++
+		}
+		if oldestID == 0 || joinedAt.Before(oldestJoinedAt) || (joinedAt.Equal(oldestJoinedAt) && memberID < oldestID) {
+			oldestID, oldestJoinedAt = memberID, joinedAt
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	switch {
+	case remaining == 0:
+		if err := r.DeleteRoom(ctx, userId, roomId, nil); err != nil {
+			return err
+		}
+		report.RoomsClosed = append(report.RoomsClosed, roomId)
+	case role == "OWNER" && owners == 0:
+		err := r.UpdateParticipantRoom(ctx, userId, &chatv1.UpdateParticipantRoomRequest{Id: roomId, Participant: int32(oldestID), Role: "OWNER"})
+		if err != nil {
+			return err
+		}
+		report.OwnersPromoted[roomId] = oldestID
+	}
+
+	DeleteRoomCacheByRoomID(ctx, roomId)
+	return r.addUserErasedEvent(ctx, roomId, userId, now)
+}
+
+// anonymiseRoomMessages recorre los mensajes de la sala: los del usuario quedan como
+// tombstone y en los del resto se borran su reacción, su mención y su lectura. Un batch
+// por mensaje, como en la retención.
+func (r *ScyllaRoomRepository) anonymiseRoomMessages(ctx context.Context, userId int, roomUUID gocql.UUID, now time.Time, report *UserErasureReport) error {
+	iter := r.session.Query(`SELECT message_id, sender_id, sender_message_id FROM messages_by_room WHERE room_id = ?`, roomUUID).WithContext(ctx).Iter()
+	var messageUUID gocql.UUID
+	var senderID int
+	var senderMessageID *string
+	for iter.Scan(&messageUUID, &senderID, &senderMessageID) {
+		batch := r.session.Batch(gocql.UnloggedBatch)
+		if senderID == userId {
+			batch.Query(`UPDATE messages_by_room SET content = null, content_decrypted = null, audio_transcription = null, file_url = null, location_name = null, location_latitude = null, location_longitude = null, contact_id = null, contact_name = null, contact_phone = null, sender_message_id = null, is_deleted = true, updated_at = ? WHERE room_id = ? AND message_id = ?`, now, roomUUID, messageUUID)
+			batch.Query(`DELETE FROM reactions_by_message WHERE message_id = ?`, messageUUID)
+			batch.Query(`DELETE FROM read_receipts_by_message WHERE message_id = ?`, messageUUID)
+			batch.Query(`DELETE FROM mentions_by_message WHERE message_id = ?`, messageUUID)
+			if senderMessageID != nil && *senderMessageID != "" {
+				batch.Query(`DELETE FROM message_by_sender_message_id WHERE sender_message_id = ?`, *senderMessageID)
+			}
+			report.MessagesAnonymised++
+		} else {
+			batch.Query(`DELETE FROM reactions_by_message WHERE message_id = ? AND user_id = ?`, messageUUID, userId)
+			batch.Query(`DELETE FROM read_receipts_by_message WHERE message_id = ? AND user_id = ?`, messageUUID, userId)
+			batch.Query(`DELETE FROM mentions_by_message WHERE message_id = ? AND user_id = ?`, messageUUID, userId)
+		}
+		if err := r.session.ExecuteBatch(batch.WithContext(ctx)); err != nil {
+			iter.Close()
+			return err
+		}
+		if senderID == userId {
+			DeleteCache(ctx, messageSimpleCacheKey(messageUUID.String()))
+		}
+		senderMessageID = nil
+	}
+	return iter.Close()
+}
+
+// clearErasedLastMessages borra la vista previa, el nombre y el teléfono del usuario de las
+// filas de rooms_by_user del resto de participantes cuyo último mensaje es suyo. El mensaje
+// sigue siendo el último (ahora como tombstone), así que last_message_id no cambia.
+func (r *ScyllaRoomRepository) clearErasedLastMessages(ctx context.Context, userId int, roomUUID gocql.UUID) error {
+	members, err := r.otherParticipants(ctx, userId, roomUUID)
+	if err != nil {
+		return err
+	}
+	for _, memberID := range members {
+		isPinned, lastMessageAt, found, err := r.erasedLastMessage(ctx, memberID, roomUUID, userId)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		err = r.session.Query(`DELETE last_message_preview, last_message_sender_name, last_message_sender_phone FROM rooms_by_user WHERE user_id = ? AND is_pinned = ? AND last_message_at = ? AND room_id = ?`,
+			memberID, isPinned, lastMessageAt, roomUUID).WithContext(ctx).Exec()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ScyllaRoomRepository) otherParticipants(ctx context.Context, userId int, roomUUID gocql.UUID) ([]int, error) {
+	var members []int
+	iter := r.session.Query(`SELECT user_id FROM participants_by_room WHERE room_id = ?`, roomUUID).WithContext(ctx).Iter()
+	var memberID int
+	for iter.Scan(&memberID) {
+		if memberID != userId {
+			members = append(members, memberID)
+		}
+	}
+	return members, iter.Close()
+}
+
+// erasedLastMessage devuelve la clave de la fila de rooms_by_user de memberId en la sala y
+// si su último mensaje es de userId y todavía lleva sus datos.
+func (r *ScyllaRoomRepository) erasedLastMessage(ctx context.Context, memberId int, roomUUID gocql.UUID, userId int) (bool, time.Time, bool, error) {
+	var isPinned bool
+	var lastMessageAt time.Time
+	err := r.session.Query(`SELECT is_pinned, last_message_at FROM room_membership_lookup WHERE user_id = ? AND room_id = ?`, memberId, roomUUID).WithContext(ctx).Scan(&isPinned, &lastMessageAt)
+	if err == gocql.ErrNotFound {
+		return false, time.Time{}, false, nil
+	}
+	if err != nil {
+		return false, time.Time{}, false, err
+	}
+
+	var senderID *int
+	var preview, senderName, senderPhone *string
+	err = r.session.Query(`SELECT last_message_sender_id, last_message_preview, last_message_sender_name, last_message_sender_phone FROM rooms_by_user WHERE user_id = ? AND is_pinned = ? AND last_message_at = ? AND room_id = ?`,
+		memberId, isPinned, lastMessageAt, roomUUID).WithContext(ctx).Scan(&senderID, &preview, &senderName, &senderPhone)
+	if err == gocql.ErrNotFound {
+		return false, time.Time{}, false, nil
+	}
+	if err != nil {
+		return false, time.Time{}, false, err
+	}
+
+	found := senderID != nil && *senderID == userId && (preview != nil || senderName != nil || senderPhone != nil)
+	return isPinned, lastMessageAt, found, nil
+}
+
+func (r *ScyllaRoomRepository) addUserErasedEvent(ctx context.Context, roomId string, userId int, now time.Time) error {
+	batch := r.session.Batch(gocql.LoggedBatch)
+	if err := addOutboxEvents(batch, newUserErasedEvent(roomId, userId, now)); err != nil {
+		return err
+	}
+	return r.session.ExecuteBatch(batch.WithContext(ctx))
+}
+
+// VerifyUserErasure revisa las particiones del usuario y, en las salas indicadas, su
+// participación, el contenido de sus mensajes y la vista previa de su último mensaje en
+// las salas del resto de participantes. Las reacciones y lecturas en mensajes de
+// otros no se revisan: exigiría leer una partición por mensaje.
+func (r *ScyllaRoomRepository) VerifyUserErasure(ctx context.Context, userId int, roomIds []string) ([]string, error) {
+	var remaining []string
+
+	for _, check := range []struct{ name, query string }{
+		{"rooms_by_user", `SELECT COUNT(*) FROM rooms_by_user WHERE user_id = ?`},
+		{"room_membership_lookup", `SELECT COUNT(*) FROM room_membership_lookup WHERE user_id = ?`},
+		{"deleted_rooms_by_user", `SELECT COUNT(*) FROM deleted_rooms_by_user WHERE user_id = ?`},
+		{"room_counters_by_user", `SELECT COUNT(*) FROM room_counters_by_user WHERE user_id = ?`},
+		{"p2p_room_by_users", `SELECT COUNT(*) FROM p2p_room_by_users WHERE user1_id = ?`},
+		{"device_keys_by_user", `SELECT COUNT(*) FROM device_keys_by_user WHERE user_id = ?`},
+	} {
+		var count int64
+		if err := r.session.Query(check.query, userId).WithContext(ctx).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to verify %s: %w", check.name, err)
+		}
+		if count > 0 {
+			remaining = append(remaining, fmt.Sprintf("%s: %d", check.name, count))
+		}
+	}
+
+	for _, roomId := range roomIds {
+		roomUUID, err := gocql.ParseUUID(roomId)
+		if err != nil {
+			return nil, err
+		}
+
+		var role string
+		err = r.session.Query(`SELECT role FROM participants_by_room WHERE room_id = ? AND user_id = ?`, roomUUID, userId).WithContext(ctx).Scan(&role)
+		if err == nil {
+			remaining = append(remaining, fmt.Sprintf("participants_by_room %s", roomId))
+		} else if err != gocql.ErrNotFound {
+			return nil, err
+		}
+
+		var statuses int64
+		if err := r.session.Query(`SELECT COUNT(*) FROM message_status_by_user WHERE user_id = ? AND room_id = ?`, userId, roomUUID).WithContext(ctx).Scan(&statuses); err != nil {
+			return nil, err
+		}
+		if statuses > 0 {
+			remaining = append(remaining, fmt.Sprintf("message_status_by_user %s: %d", roomId, statuses))
+		}
+
+		var wrappedKeys int64
+		if err := r.session.Query(`SELECT COUNT(*) FROM room_device_keys_by_room WHERE room_id = ? AND user_id = ?`, roomUUID, userId).WithContext(ctx).Scan(&wrappedKeys); err != nil {
+			return nil, err
+		}
+		if wrappedKeys > 0 {
+			remaining = append(remaining, fmt.Sprintf("room_device_keys_by_room %s: %d", roomId, wrappedKeys))
+		}
+
+		iter := r.session.Query(`SELECT sender_id, content, file_url, audio_transcription FROM messages_by_room WHERE room_id = ?`, roomUUID).WithContext(ctx).Iter()
+		var senderID int
+		var content, fileURL, transcription *string
+		withContent := 0
+		for iter.Scan(&senderID, &content, &fileURL, &transcription) {
+			if senderID == userId && (content != nil || fileURL != nil || transcription != nil) {
+				withContent++
+			}
+			content, fileURL, transcription = nil, nil, nil
+		}
+		if err := iter.Close(); err != nil {
+			return nil, err
+		}
+		if withContent > 0 {
+			remaining = append(remaining, fmt.Sprintf("messages with content in %s: %d", roomId, withContent))
+		}
+
+		members, err := r.otherParticipants(ctx, userId, roomUUID)
+		if err != nil {
+			return nil, err
+		}
+		previews := 0
+		for _, memberID := range members {
+			_, _, found, err := r.erasedLastMessage(ctx, memberID, roomUUID, userId)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				previews++
+			}
+		}
+		if previews > 0 {
+			remaining = append(remaining, fmt.Sprintf("rooms_by_user last message previews in %s: %d", roomId, previews))
+		}
+	}
+
+	return remaining, nil
+}
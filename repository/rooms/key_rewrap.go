@@ -0,0 +1,282 @@
+package roomsrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
+	dbpq "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+	"github.com/scylladb-solutions/gocql/v2"
+)
+
+const rewrapRoomPageSize = 100
+
+// KeyRewrap vuelve a cifrar el encryption_data de las salas (el actual y el del historial de
+// claves) con la versión actual de la clave maestra (ver utils/keyprovider.go). La clave de
+// cada sala no cambia, así que los mensajes no se tocan y el servicio sigue funcionando
+// mientras tanto: cada fila se reemplaza solo si sigue teniendo el texto que se leyó, y una
+// fila que cambió en medio (una rotación) se cuenta como pendiente para la siguiente corrida.
+type KeyRewrap struct {
+	db      *sql.DB
+	session *gocql.Session
+}
+
+func NewKeyRewrap(db *sql.DB, session *gocql.Session) *KeyRewrap {
+	return &KeyRewrap{
+		db:      db,
+		session: session,
+	}
+}
+
+type RewrapOptions struct {
+	DryRun bool // Solo cuenta las claves pendientes
+	// AfterRoom se llama después de cada sala de Postgres que cambió; con dual-write refleja la
+	// sala en Scylla (ScyllaBackfill.MirrorRoom) para que los dos stores tengan el mismo texto.
+	AfterRoom func(ctx context.Context, roomID string) error
+	Progress  func(RewrapProgress)
+}
+
+type RewrapProgress struct {
+	Scanned   int64 // encryption_data revisados
+	Rewrapped int64 // Cifrados de nuevo con la versión actual
+	Pending   int64 // Con otra versión que no se reemplazaron (dry run o cambiaron en medio)
+	Elapsed   time.Duration
+}
+
+// RewrapPostgres recorre las salas en orden de id y reemplaza room.encription_data y
+// room_key.encryption_data.
+func (k *KeyRewrap) RewrapPostgres(ctx context.Context, opts RewrapOptions) (RewrapProgress, error) {
+	progress := RewrapProgress{}
+	startedAt := time.Now()
+	lastRoomID := ""
+	for {
+		query := dbpq.QueryBuilder().
+			Select("id", "encription_data").
+			From("public.room").
+			Where(sq.NotEq{"encription_data": nil}).
+			OrderBy("id").
+			Limit(rewrapRoomPageSize)
+		if lastRoomID != "" {
+			query = query.Where(sq.Gt{"id": lastRoomID})
+		}
+
+		rows, err := query.RunWith(k.db).QueryContext(ctx)
+		if err != nil {
+			return progress, fmt.Errorf("error al listar las salas: %w", err)
+		}
+		type roomKeyRow struct{ roomID, encryptionData string }
+		var rooms []roomKeyRow
+		for rows.Next() {
+			var room roomKeyRow
+			if err := rows.Scan(&room.roomID, &room.encryptionData); err != nil {
+				rows.Close()
+				return progress, err
+			}
+			rooms = append(rooms, room)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return progress, err
+		}
+		if len(rooms) == 0 {
+			break
+		}
+
+		for _, room := range rooms {
+			if err := ctx.Err(); err != nil {
+				return progress, err
+			}
+			changed, err := k.rewrapPostgresRoom(ctx, room.roomID, room.encryptionData, opts.DryRun, &progress)
+			if err != nil {
+				return progress, fmt.Errorf("sala %s: %w", room.roomID, err)
+			}
+			if changed {
+				DeleteRoomCacheByRoomID(ctx, room.roomID)
+				if opts.AfterRoom != nil {
+					if err := opts.AfterRoom(ctx, room.roomID); err != nil {
+						return progress, fmt.Errorf("sala %s: %w", room.roomID, err)
+					}
+				}
+			}
+			lastRoomID = room.roomID
+		}
+
+		progress.Elapsed = time.Since(startedAt)
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+
+	progress.Elapsed = time.Since(startedAt)
+	return progress, nil
+}
+
+func (k *KeyRewrap) rewrapPostgresRoom(ctx context.Context, roomID, current string, dryRun bool, progress *RewrapProgress) (bool, error) {
+	changed, err := rewrapValue(current, dryRun, progress, func(rewrapped string) (bool, error) {
+		result, err := dbpq.QueryBuilder().
+			Update("public.room").
+			Set("encription_data", rewrapped).
+			Where(sq.Eq{"id": roomID, "encription_data": current}).
+			RunWith(k.db).
+			ExecContext(ctx)
+		if err != nil {
+			return false, err
+		}
+		affected, err := result.RowsAffected()
+		return affected > 0, err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := dbpq.QueryBuilder().
+		Select("version", "encryption_data").
+		From("public.room_key").
+		Where(sq.Eq{"room_id": roomID}).
+		RunWith(k.db).
+		QueryContext(ctx)
+	if err != nil {
+		return changed, err
+	}
+	type retiredKey struct {
+		version        int32
+		encryptionData string
+	}
+	var retired []retiredKey
+	for rows.Next() {
+		var key retiredKey
+		if err := rows.Scan(&key.version, &key.encryptionData); err != nil {
+			rows.Close()
+			return changed, err
+		}
+		retired = append(retired, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return changed, err
+	}
+
+	for _, key := range retired {
+		replaced, err := rewrapValue(key.encryptionData, dryRun, progress, func(rewrapped string) (bool, error) {
+			result, err := dbpq.QueryBuilder().
+				Update("public.room_key").
+				Set("encryption_data", rewrapped).
+				Where(sq.Eq{"room_id": roomID, "version": key.version, "encryption_data": key.encryptionData}).
+				RunWith(k.db).
+				ExecContext(ctx)
+			if err != nil {
+				return false, err
+			}
+			affected, err := result.RowsAffected()
+			return affected > 0, err
+		})
+		if err != nil {
+			return changed, fmt.Errorf("versión %d: %w", key.version, err)
+		}
+		changed = changed || replaced
+	}
+
+	return changed, nil
+}
+
+// RewrapScylla recorre las salas de room_details y room_keys_by_room y reemplaza cada
+// encryption_data (el actual está en room_key_state) con LWT sobre el texto leído.
+func (k *KeyRewrap) RewrapScylla(ctx context.Context, opts RewrapOptions) (RewrapProgress, error) {
+	progress := RewrapProgress{}
+	startedAt := time.Now()
+	report := func() {
+		progress.Elapsed = time.Since(startedAt)
+		if opts.Progress != nil && progress.Scanned%rewrapRoomPageSize == 0 {
+			opts.Progress(progress)
+		}
+	}
+
+	iter := k.session.Query(`SELECT room_id FROM room_details`).
+		WithContext(ctx).PageSize(rewrapRoomPageSize).Iter()
+	var roomID gocql.UUID
+	var current string
+	for iter.Scan(&roomID) {
+		roomUUID := roomID
+		// loadRoomKeyState copia a room_key_state la clave de las salas que aún no la tienen
+		state, err := loadRoomKeyState(ctx, k.session, roomUUID)
+		if err == gocql.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			iter.Close()
+			return progress, fmt.Errorf("sala %s: %w", roomUUID, err)
+		}
+		encryptionData := state.key()
+		changed, err := rewrapValue(encryptionData, opts.DryRun, &progress, func(rewrapped string) (bool, error) {
+			return k.session.Query(`UPDATE room_key_state SET encryption_data = ? WHERE room_id = ? IF encryption_data = ?`, rewrapped, roomUUID, encryptionData).
+				WithContext(ctx).MapScanCAS(map[string]interface{}{})
+		})
+		if err != nil {
+			iter.Close()
+			return progress, fmt.Errorf("sala %s: %w", roomUUID, err)
+		}
+		if changed {
+			DeleteRoomCacheByRoomID(ctx, roomUUID.String())
+		}
+		report()
+	}
+	if err := iter.Close(); err != nil {
+		return progress, fmt.Errorf("error al recorrer room_details: %w", err)
+	}
+
+	iter = k.session.Query(`SELECT room_id, version, encryption_data FROM room_keys_by_room`).
+		WithContext(ctx).PageSize(rewrapRoomPageSize).Iter()
+	var version int
+	for iter.Scan(&roomID, &version, &current) {
+		roomUUID, keyVersion, encryptionData := roomID, version, current
+		_, err := rewrapValue(encryptionData, opts.DryRun, &progress, func(rewrapped string) (bool, error) {
+			return k.session.Query(`UPDATE room_keys_by_room SET encryption_data = ? WHERE room_id = ? AND version = ? IF encryption_data = ?`, rewrapped, roomUUID, keyVersion, encryptionData).
+				WithContext(ctx).MapScanCAS(map[string]interface{}{})
+		})
+		if err != nil {
+			iter.Close()
+			return progress, fmt.Errorf("sala %s, versión %d: %w", roomUUID, keyVersion, err)
+		}
+		report()
+	}
+	if err := iter.Close(); err != nil {
+		return progress, fmt.Errorf("error al recorrer room_keys_by_room: %w", err)
+	}
+
+	progress.Elapsed = time.Since(startedAt)
+	if opts.Progress != nil {
+		opts.Progress(progress)
+	}
+	return progress, nil
+}
+
+// rewrapValue cifra de nuevo encryptionData si no está en la versión actual y lo guarda con
+// replace, que devuelve false si la fila cambió desde que se leyó. Las salas sin clave se
+// ignoran.
+func rewrapValue(encryptionData string, dryRun bool, progress *RewrapProgress, replace func(string) (bool, error)) (bool, error) {
+	if encryptionData == "" {
+		return false, nil
+	}
+	progress.Scanned++
+	rewrapped, changed, err := utils.RewrapKeyEncript(encryptionData)
+	if err != nil || !changed {
+		return false, err
+	}
+	if dryRun {
+		progress.Pending++
+		return false, nil
+	}
+	applied, err := replace(rewrapped)
+	if err != nil {
+		return false, err
+	}
+	if !applied {
+		progress.Pending++
+		return false, nil
+	}
+	progress.Rewrapped++
+	return true, nil
+}
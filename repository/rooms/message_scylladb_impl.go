@@ -0,0 +1,246 @@
+package roomsrepository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	"github.com/scylladb-solutions/gocql/v2"
+)
+
+// Columnas de messages_by_room que se leen para armar un MessageData (ver scanScyllaMessages)
+const scyllaMessageColumns = `message_id, room_id, sender_id, content, type, created_at, updated_at, edited, is_deleted, seq, key_version,
+	reply_to_message_id, forwarded_from_message_id, forwarded_message_sender_id, file_url, event, sender_message_id,
+	audio_transcription, lifetime, location_name, location_latitude, location_longitude, origin,
+	contact_id, contact_name, contact_phone`
+
+// scanScyllaMessages lee las filas de scyllaMessageColumns. La respuesta queda con
+// Reply.Id como marcador; enrichMessages completa reply, usuarios, menciones y reacciones.
+func scanScyllaMessages(iter *gocql.Iter) ([]*chatv1.MessageData, error) {
+	var messages []*chatv1.MessageData
+
+	scanner := iter.Scanner()
+	for scanner.Next() {
+		msg := &chatv1.MessageData{}
+		var msgID, roomID, replyID, forwardID gocql.UUID
+		var createdAt, updatedAt time.Time
+		var contactID *int
+		var keyVersion int
+		err := scanner.Scan(&msgID, &roomID, &msg.SenderId, &msg.Content, &msg.Type, &createdAt, &updatedAt, &msg.Edited, &msg.IsDeleted, &msg.Seq, &keyVersion,
+			&replyID, &forwardID, &msg.ForwardedMessageSenderId, &msg.File, &msg.Event, &msg.SenderMessageId,
+			&msg.AudioTranscription, &msg.Lifetime, &msg.LocationName, &msg.LocationLatitude, &msg.LocationLongitude, &msg.Origin,
+			&contactID, &msg.ContactName, &msg.ContactPhone)
+		if err != nil {
+			return nil, err
+		}
+
+		msg.Id = msgID.String()
+		msg.RoomId = roomID.String()
+		msg.CreatedAt = createdAt.Format(time.RFC3339)
+		// Los mensajes anteriores a la columna updated_at usan created_at
+		if updatedAt.IsZero() {
+			updatedAt = createdAt
+		}
+		msg.UpdatedAt = updatedAt.Format(time.RFC3339)
+		msg.KeyVersion = roomKeyVersion(int32(keyVersion))
+		if replyID != (gocql.UUID{}) {
+			msg.Reply = &chatv1.MessageData{Id: replyID.String()}
+		}
+		if forwardID != (gocql.UUID{}) {
+			msg.ForwardedMessageId = &[]string{forwardID.String()}[0]
+		}
+		if contactID != nil {
+			msg.ContactId = &[]string{strconv.Itoa(*contactID)}[0]
+		}
+		messages = append(messages, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// enrichMessages completa los mensajes de una sala igual que SQLRoomRepository: datos
+// del remitente, reply, remitente reenviado, menciones, reacciones y estado del usuario.
+func (r *ScyllaRoomRepository) enrichMessages(ctx context.Context, userId int, roomUUID gocql.UUID, messages []*chatv1.MessageData) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	messageIDs := make([]gocql.UUID, 0, len(messages))
+	var replyIDs []gocql.UUID
+	for _, msg := range messages {
+		msgUUID, err := gocql.ParseUUID(msg.Id)
+		if err != nil {
+			return err
+		}
+		messageIDs = append(messageIDs, msgUUID)
+		if msg.Reply != nil {
+			replyUUID, err := gocql.ParseUUID(msg.Reply.Id)
+			if err == nil {
+				replyIDs = append(replyIDs, replyUUID)
+			}
+		}
+	}
+
+	// Reply (siempre dentro de la misma sala)
+	replies := map[string]*chatv1.MessageData{}
+	if len(replyIDs) > 0 {
+		iter := r.session.Query(`SELECT `+scyllaMessageColumns+` FROM messages_by_room WHERE room_id = ? AND message_id IN ?`, roomUUID, replyIDs).
+			WithContext(ctx).Iter()
+		found, err := scanScyllaMessages(iter)
+		iter.Close()
+		if err != nil {
+			return fmt.Errorf("error al leer los mensajes respondidos: %w", err)
+		}
+		for _, reply := range found {
+			replies[reply.Id] = reply
+		}
+	}
+
+	// Menciones
+	type mentionRow struct {
+		messageID string
+		userID    int
+		tag       string
+	}
+	var mentions []mentionRow
+	iter := r.session.Query(`SELECT message_id, user_id, tag FROM mentions_by_message WHERE message_id IN ?`, messageIDs).WithContext(ctx).Iter()
+	var msgID gocql.UUID
+	var mentionUserID int
+	var tag string
+	for iter.Scan(&msgID, &mentionUserID, &tag) {
+		mentions = append(mentions, mentionRow{messageID: msgID.String(), userID: mentionUserID, tag: tag})
+	}
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("error al leer las menciones: %w", err)
+	}
+
+	// Usuarios de remitentes, replies, reenvíos y menciones en una sola consulta
+	userIDsSet := map[int]bool{}
+	for _, msg := range messages {
+		userIDsSet[int(msg.SenderId)] = true
+		if msg.ForwardedMessageSenderId != nil {
+			userIDsSet[int(*msg.ForwardedMessageSenderId)] = true
+		}
+	}
+	for _, reply := range replies {
+		userIDsSet[int(reply.SenderId)] = true
+	}
+	for _, mention := range mentions {
+		userIDsSet[mention.userID] = true
+	}
+	userIDs := make([]int, 0, len(userIDsSet))
+	for id := range userIDsSet {
+		userIDs = append(userIDs, id)
+	}
+	users, err := r.userFetcher.GetUsersByID(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	userMap := make(map[int]User, len(users))
+	for _, u := range users {
+		userMap[u.ID] = u
+	}
+	avatarOf := func(u User) string {
+		if u.Avatar != nil {
+			return *u.Avatar
+		}
+		return ""
+	}
+
+	messageMap := make(map[string]*chatv1.MessageData, len(messages))
+	for _, msg := range messages {
+		messageMap[msg.Id] = msg
+
+		if user, ok := userMap[int(msg.SenderId)]; ok {
+			msg.SenderName = user.Name
+			msg.SenderPhone = user.Phone
+			msg.SenderAvatar = avatarOf(user)
+		}
+
+		if msg.ForwardedMessageSenderId != nil {
+			if user, ok := userMap[int(*msg.ForwardedMessageSenderId)]; ok {
+				msg.ForwardedMessageSenderName = &user.Name
+				msg.ForwardedMessageSenderPhone = &user.Phone
+				msg.ForwardedMessageSenderAvatar = &[]string{avatarOf(user)}[0]
+			}
+		}
+
+		if msg.Reply != nil {
+			if reply, ok := replies[msg.Reply.Id]; ok {
+				msg.Reply = &chatv1.MessageData{
+					Id:         reply.Id,
+					SenderId:   reply.SenderId,
+					Content:    reply.Content,
+					Type:       reply.Type,
+					RoomId:     reply.RoomId,
+					CreatedAt:  reply.CreatedAt,
+					UpdatedAt:  reply.UpdatedAt,
+					KeyVersion: reply.KeyVersion,
+				}
+				if user, ok := userMap[int(reply.SenderId)]; ok {
+					msg.Reply.SenderName = user.Name
+					msg.Reply.SenderPhone = user.Phone
+					msg.Reply.SenderAvatar = avatarOf(user)
+				}
+			}
+		}
+	}
+
+	for _, mention := range mentions {
+		user, ok := userMap[mention.userID]
+		msg, found := messageMap[mention.messageID]
+		if !ok || !found {
+			continue
+		}
+		msg.Mentions = append(msg.Mentions, &chatv1.Mention{
+			Id:        strconv.Itoa(mention.userID),
+			Name:      user.Name,
+			Phone:     user.Phone,
+			Tag:       mention.tag,
+			MessageId: mention.messageID,
+		})
+	}
+
+	// Reacciones
+	iter = r.session.Query(`SELECT message_id, user_id, reaction FROM reactions_by_message WHERE message_id IN ?`, messageIDs).WithContext(ctx).Iter()
+	var reactedBy int
+	var reaction string
+	for iter.Scan(&msgID, &reactedBy, &reaction) {
+		if msg, ok := messageMap[msgID.String()]; ok {
+			msg.Reactions = append(msg.Reactions, &chatv1.Reaction{
+				ReactedById: strconv.Itoa(reactedBy),
+				Reaction:    reaction,
+				MessageId:   msgID.String(),
+			})
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("error al leer las reacciones: %w", err)
+	}
+
+	return r.enrichMessagesWithStatus(ctx, messages, userId, roomUUID)
+}
+
+// messageUUID convierte el id de mensaje que envía el cliente al timeuuid de Scylla. Los
+// mensajes migrados desde Postgres conservan su id original (UUID v4) en
+// message_id_by_legacy_id; si no existe el mapeo se devuelve gocql.ErrNotFound.
+func (r *ScyllaRoomRepository) messageUUID(ctx context.Context, messageId string) (gocql.UUID, error) {
+	id, err := gocql.ParseUUID(messageId)
+	if err != nil {
+		return gocql.UUID{}, fmt.Errorf("ID de mensaje inválido: %w", err)
+	}
+	if id.Version() == 1 {
+		return id, nil
+	}
+
+	var messageUUID gocql.UUID
+	err = r.session.Query(`SELECT message_id FROM message_id_by_legacy_id WHERE legacy_id = ?`, id).WithContext(ctx).Scan(&messageUUID)
+	if err != nil {
+		return gocql.UUID{}, err
+	}
+	return messageUUID, nil
+}
@@ -0,0 +1,236 @@
+package roomsrepository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	"github.com/scylladb-solutions/gocql/v2"
+)
+
+const (
+	// Reintentos máximos del compare-and-set cuando varios remitentes compiten por el seq
+	roomSeqMaxAttempts = 32
+	// Tiempo para anular un seq cuando la petición que lo reservó ya terminó
+	roomSeqVoidTimeout = 5 * time.Second
+)
+
+// nextRoomSeq reserva el siguiente seq de la sala con LWT. Cada intento parte del último
+// valor leído (o devuelto por el CAS fallido), así que el número reservado es exactamente
+// last_seq + 1 y no quedan huecos entre remitentes concurrentes.
+//
+// La reserva y el batch del mensaje no son atómicos. Un error del batch se cubre con
+// voidRoomSeq; si el proceso muere entre los dos, o el CAS vence después de aplicarse, el seq
+// queda sin fila en messages_by_room_seq hasta que VoidAbandonedSeqs lo anula. Para eso el
+// mismo CAS guarda reserved_at, la hora de la última reserva.
+func (r *ScyllaRoomRepository) nextRoomSeq(ctx context.Context, roomUUID gocql.UUID) (int64, error) {
+	var current int64
+	err := r.session.Query(`SELECT last_seq FROM room_sequences WHERE room_id = ?`, roomUUID).
+		WithContext(ctx).Consistency(gocql.Serial).Scan(&current)
+	if err != nil && err != gocql.ErrNotFound {
+		return 0, fmt.Errorf("error al leer la secuencia de la sala: %w", err)
+	}
+
+	if err == gocql.ErrNotFound {
+		var existing int64
+		applied, err := r.session.Query(`INSERT INTO room_sequences (room_id, last_seq, reserved_at) VALUES (?, ?, ?) IF NOT EXISTS`, roomUUID, 1, time.Now()).
+			WithContext(ctx).MapScanCAS(map[string]any{"last_seq": &existing})
+		if err != nil {
+			return 0, fmt.Errorf("error al iniciar la secuencia de la sala: %w", err)
+		}
+		if applied {
+			return 1, nil
+		}
+		current = existing
+	}
+
+	for attempt := 0; attempt < roomSeqMaxAttempts; attempt++ {
+		var observed int64
+		applied, err := r.session.Query(`UPDATE room_sequences SET last_seq = ?, reserved_at = ? WHERE room_id = ? IF last_seq = ?`, current+1, time.Now(), roomUUID, current).
+			WithContext(ctx).ScanCAS(&observed)
+		if err != nil {
+			return 0, fmt.Errorf("error al reservar la secuencia de la sala: %w", err)
+		}
+		if applied {
+			return current + 1, nil
+		}
+		current = observed
+	}
+
+	return 0, fmt.Errorf("no se pudo reservar la secuencia de la sala %s: demasiada contención", roomUUID)
+}
+
+// voidRoomSeq marca un seq reservado cuyo mensaje no se pudo escribir. La fila queda sin
+// message_id y el historial la devuelve como mensaje eliminado para que el rango siga completo.
+// No usa la cancelación de ctx: el batch suele fallar justo porque la petición se canceló o
+// venció, y sin esta fila el seq quedaría como hueco.
+func (r *ScyllaRoomRepository) voidRoomSeq(ctx context.Context, roomUUID gocql.UUID, seq int64) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), roomSeqVoidTimeout)
+	defer cancel()
+	err := r.session.Query(`INSERT INTO messages_by_room_seq (room_id, seq) VALUES (?, ?)`, roomUUID, seq).WithContext(ctx).Exec()
+	if err != nil {
+		fmt.Printf("Error al anular el seq %d de la sala %s: %v\n", seq, roomUUID, err)
+	}
+}
+
+// VoidAbandonedSeqs anula los seq reservados que no tienen fila en messages_by_room_seq, en
+// las salas cuya última reserva es anterior a reservedBefore. Todos los seq hasta last_seq se
+// reservaron antes que el último, así que pasado ese margen ninguno tiene un batch en vuelo.
+// Cada sala se revisa desde reconciled_seq, que avanza con LWT como el resto de room_sequences.
+// Un batch que llegue tarde sigue escribiendo su message_id sobre la fila anulada.
+func (r *ScyllaRoomRepository) VoidAbandonedSeqs(ctx context.Context, reservedBefore time.Time) (int, error) {
+	voided := 0
+
+	iter := r.session.Query(`SELECT room_id, last_seq, reserved_at, reconciled_seq FROM room_sequences`).WithContext(ctx).Iter()
+	var roomUUID gocql.UUID
+	var lastSeq int64
+	var reservedAt time.Time
+	var reconciledSeq int64
+	for iter.Scan(&roomUUID, &lastSeq, &reservedAt, &reconciledSeq) {
+		if lastSeq > reconciledSeq && reservedAt.Before(reservedBefore) {
+			count, err := r.voidRoomSeqGaps(ctx, roomUUID, reconciledSeq, lastSeq)
+			if err != nil {
+				fmt.Printf("Error al anular los seq abandonados de la sala %s: %v\n", roomUUID, err)
+			}
+			voided += count
+		}
+		reservedAt = time.Time{}
+		reconciledSeq = 0
+	}
+	if err := iter.Close(); err != nil {
+		return voided, err
+	}
+
+	return voided, nil
+}
+
+// voidRoomSeqGaps escribe la fila anulada de cada seq en (from, to] sin fila en
+// messages_by_room_seq y avanza reconciled_seq hasta to.
+func (r *ScyllaRoomRepository) voidRoomSeqGaps(ctx context.Context, roomUUID gocql.UUID, from int64, to int64) (int, error) {
+	voided := 0
+	expected := from + 1
+	void := func(until int64) error {
+		for ; expected < until; expected++ {
+			err := r.session.Query(`INSERT INTO messages_by_room_seq (room_id, seq) VALUES (?, ?)`, roomUUID, expected).WithContext(ctx).Exec()
+			if err != nil {
+				return err
+			}
+			voided++
+		}
+		return nil
+	}
+
+	iter := r.session.Query(`SELECT seq FROM messages_by_room_seq WHERE room_id = ? AND seq > ? AND seq <= ? ORDER BY seq ASC`, roomUUID, from, to).
+		WithContext(ctx).Iter()
+	var seq int64
+	for iter.Scan(&seq) {
+		if err := void(seq); err != nil {
+			iter.Close()
+			return voided, err
+		}
+		expected = seq + 1
+	}
+	if err := iter.Close(); err != nil {
+		return voided, err
+	}
+	if err := void(to + 1); err != nil {
+		return voided, err
+	}
+
+	// Otra instancia pudo avanzarlo a la vez: como mucho, la próxima pasada repite el rango
+	_, err := r.session.Query(`UPDATE room_sequences SET reconciled_seq = ? WHERE room_id = ? IF EXISTS`, to, roomUUID).
+		WithContext(ctx).MapScanCAS(map[string]any{})
+	if err != nil {
+		return voided, err
+	}
+	return voided, nil
+}
+
+// getMessagesBySeq pagina el historial de una sala por seq: after_seq en orden ascendente,
+// before_seq (o ambos) en orden descendente.
+func (r *ScyllaRoomRepository) getMessagesBySeq(ctx context.Context, userId int, roomUUID gocql.UUID, req *chatv1.GetMessageHistoryRequest) ([]*chatv1.MessageData, *chatv1.PaginationMeta, error) {
+	query := `SELECT seq, message_id FROM messages_by_room_seq WHERE room_id = ?`
+	args := []any{roomUUID}
+	if req.AfterSeq != nil {
+		query += " AND seq > ?"
+		args = append(args, *req.AfterSeq)
+	}
+	if req.BeforeSeq != nil {
+		query += " AND seq < ?"
+		args = append(args, *req.BeforeSeq)
+	}
+	ascending := req.BeforeSeq == nil
+	if ascending {
+		query += " ORDER BY seq ASC"
+	}
+	paged, err := pagedQuery(r.session.Query(query, args...), req.Cursor, cursorMessagesSeq, req.Limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var seqs []int64
+	var messageIDs []gocql.UUID
+	iter := paged.WithContext(ctx).Iter()
+	nextCursor := nextPageCursor(iter, cursorMessagesSeq, req.Cursor, req.Limit)
+	var seq int64
+	var messageID gocql.UUID
+	for iter.Scan(&seq, &messageID) {
+		seqs = append(seqs, seq)
+		if messageID != (gocql.UUID{}) {
+			messageIDs = append(messageIDs, messageID)
+		}
+		messageID = gocql.UUID{}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	messages := []*chatv1.MessageData{}
+	if len(messageIDs) > 0 {
+		iter := r.session.Query(`SELECT `+scyllaMessageColumns+` FROM messages_by_room WHERE room_id = ? AND message_id IN ?`, roomUUID, messageIDs).
+			WithContext(ctx).Iter()
+		found, err := scanScyllaMessages(iter)
+		iter.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := r.enrichMessages(ctx, userId, roomUUID, found); err != nil {
+			return nil, nil, err
+		}
+		for _, msg := range found {
+			// Los eliminados se devuelven sin contenido para mantener el rango completo
+			if msg.IsDeleted {
+				msg.Content = ""
+				msg.File = nil
+			}
+		}
+		messages = append(messages, found...)
+	}
+
+	// Seq anulados o cuyo mensaje ya no existe: se devuelven como eliminados
+	present := map[int64]bool{}
+	for _, msg := range messages {
+		present[msg.Seq] = true
+	}
+	for _, seq := range seqs {
+		if !present[seq] {
+			messages = append(messages, &chatv1.MessageData{
+				RoomId:    roomUUID.String(),
+				Seq:       seq,
+				IsDeleted: true,
+			})
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		if ascending {
+			return messages[i].Seq < messages[j].Seq
+		}
+		return messages[i].Seq > messages[j].Seq
+	})
+
+	meta := &chatv1.PaginationMeta{ItemCount: uint32(len(messages)), NextCursor: nextCursor}
+	return messages, meta, nil
+}
@@ -0,0 +1,159 @@
+package roomsrepository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// Tipos de eventos del outbox. Los eventos de mensajes nuevos o editados se guardan
+// solo con la referencia al mensaje y el relay los hidrata con GetMessage al publicarlos.
+const (
+	OutboxMessageCreated = "message_created"
+	OutboxMessageUpdated = "message_updated"
+	OutboxMessageDeleted = "message_deleted"
+	OutboxRoomLeave      = "room_leave"
+	OutboxHistoryPurged  = "history_purged"
+	OutboxRoomJoin       = "room_join"
+	OutboxRoomUpdated    = "room_updated"
+	OutboxMessageRead    = "message_read"
+	// El relay completa unread_count del evento read_state al publicarlo
+	OutboxReadState = "read_state"
+)
+
+const (
+	// Tiempo que un evento reclamado queda reservado para la instancia que lo reclamó
+	outboxClaimLease = 30 * time.Second
+	// Espera máxima entre reintentos de un evento que falla al publicarse
+	outboxMaxBackoff = 5 * time.Minute
+)
+
+// OutboxEvent es un evento de chat escrito en la misma transacción (o batch) que la
+// mutación que lo origina. El relay lo publica en JetStream y lo marca como enviado.
+type OutboxEvent struct {
+	ID        string // también se usa como Nats-Msg-Id para deduplicar reintentos
+	RoomID    string
+	UserID    int // usuario que originó el evento
+	Kind      string
+	MessageID string               // vacío si el evento no es de un mensaje
+	Event     *chatv1.MessageEvent // evento completo cuando no hace falta hidratarlo
+	Attempts  int
+	CreatedAt time.Time
+
+	partition outboxPartition // partición de Scylla de la que se reclamó
+}
+
+func newOutboxEvent(roomID string, userID int, kind string, messageID string, event *chatv1.MessageEvent) OutboxEvent {
+	return OutboxEvent{
+		ID:        uuid.NewString(),
+		RoomID:    roomID,
+		UserID:    userID,
+		Kind:      kind,
+		MessageID: messageID,
+		Event:     event,
+		CreatedAt: time.Now(),
+	}
+}
+
+// newRoomJoinEvents construye un evento RoomJoin por cada usuario que entró en la sala.
+// ownerUserID solo se indica al crearla.
+func newRoomJoinEvents(roomID string, userID int, joined []int, ownerUserID int, joinedAt time.Time) []OutboxEvent {
+	events := make([]OutboxEvent, 0, len(joined))
+	for _, id := range joined {
+		events = append(events, newOutboxEvent(roomID, userID, OutboxRoomJoin, "", &chatv1.MessageEvent{
+			RoomId: roomID,
+			Event: &chatv1.MessageEvent_RoomJoin{RoomJoin: &chatv1.RoomJoinEvent{
+				JoinedAt:    joinedAt.UTC().Format(time.RFC3339),
+				UserId:      int32(id),
+				OwnerUserId: int32(ownerUserID),
+			}},
+		}))
+	}
+	return events
+}
+
+// newRoomUpdatedEvent avisa a los clientes de que vuelvan a pedir la sala.
+func newRoomUpdatedEvent(roomID string, userID int) OutboxEvent {
+	return newOutboxEvent(roomID, userID, OutboxRoomUpdated, "", &chatv1.MessageEvent{
+		RoomId: roomID,
+		Event:  &chatv1.MessageEvent_IsRoomUpdated{IsRoomUpdated: true},
+	})
+}
+
+// readMessage es un mensaje que el cliente pidió marcar como leído.
+type readMessage struct {
+	id       string
+	senderID int32
+	seq      int64
+}
+
+// newMessagesReadEvents construye el estado READ de cada mensaje pedido y el read_state con
+// la marca de lectura, que sincroniza las demás sesiones del usuario. La marca es el mensaje de
+// mayor seq: los IDs no llegan ordenados y una marca más antigua haría retroceder a las otras
+// sesiones.
+func newMessagesReadEvents(roomID string, userID int, messages []readMessage, readAt time.Time) []OutboxEvent {
+	if len(messages) == 0 {
+		return nil
+	}
+	at := readAt.UTC().Format(time.RFC3339)
+	events := make([]OutboxEvent, 0, len(messages)+1)
+	mark := messages[0]
+	for _, msg := range messages {
+		events = append(events, newOutboxEvent(roomID, userID, OutboxMessageRead, msg.id, &chatv1.MessageEvent{
+			RoomId: roomID,
+			Event: &chatv1.MessageEvent_StatusUpdate{StatusUpdate: &chatv1.MessageStatusUpdate{
+				MessageId: msg.id,
+				UpdatedAt: at,
+				UserId:    int32(userID),
+				Status:    chatv1.MessageStatus_MESSAGE_STATUS_READ,
+				SenderId:  msg.senderID,
+			}},
+		}))
+		if msg.seq > mark.seq {
+			mark = msg
+		}
+	}
+	return append(events, newOutboxEvent(roomID, userID, OutboxReadState, mark.id, &chatv1.MessageEvent{
+		RoomId: roomID,
+		Event: &chatv1.MessageEvent_ReadState{ReadState: &chatv1.ReadStateEvent{
+			UserId:            int32(userID),
+			RoomId:            roomID,
+			ReadAt:            at,
+			LastReadMessageId: mark.id,
+			LastReadSeq:       mark.seq,
+		}},
+	}))
+}
+
+func (e OutboxEvent) payload() ([]byte, error) {
+	if e.Event == nil {
+		return nil, nil
+	}
+	return proto.Marshal(e.Event)
+}
+
+func decodeOutboxPayload(payload []byte) (*chatv1.MessageEvent, error) {
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	event := &chatv1.MessageEvent{}
+	if err := proto.Unmarshal(payload, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// outboxBackoff calcula la espera antes del siguiente intento (exponencial con tope).
+func outboxBackoff(attempts int) time.Duration {
+	if attempts > 8 {
+		return outboxMaxBackoff
+	}
+	backoff := time.Second << attempts
+	if backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
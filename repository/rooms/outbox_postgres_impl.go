@@ -0,0 +1,190 @@
+package roomsrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	dbpq "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+)
+
+// insertOutboxEvents escribe los eventos dentro de la transacción de la mutación.
+func insertOutboxEvents(ctx context.Context, tx *sql.Tx, events ...OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	query := dbpq.QueryBuilder().
+		Insert("public.chat_outbox").
+		Columns("id", "room_id", "user_id", "kind", "message_id", "payload", "created_at", "next_attempt_at")
+
+	for _, event := range events {
+		payload, err := event.payload()
+		if err != nil {
+			return fmt.Errorf("failed to encode outbox event: %w", err)
+		}
+		var messageId sql.NullString
+		if event.MessageID != "" {
+			messageId = sql.NullString{String: event.MessageID, Valid: true}
+		}
+		query = query.Values(event.ID, event.RoomID, event.UserID, event.Kind, messageId, payload, sq.Expr("NOW()"), sq.Expr("NOW()"))
+	}
+
+	if _, err := query.RunWith(tx).ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to insert outbox events: %w", err)
+	}
+	return nil
+}
+
+// Clave del advisory lock que serializa los claims de las instancias del relay
+const outboxClaimLockKey = 0x636861745f6f7574 // "chat_out"
+
+// ClaimOutboxEvents reserva hasta limit eventos pendientes, en orden de creación. Un evento
+// solo se reclama si ningún evento anterior de su sala sigue sin enviar y fuera de plazo
+// (en reintento o reservado por otra instancia): así un evento nunca se publica antes que su
+// predecesor. Los claims de las instancias se serializan con un advisory lock; con SKIP
+// LOCKED una instancia podía saltarse un predecesor que otra estaba reclamando.
+func (r *SQLRoomRepository) ClaimOutboxEvents(ctx context.Context, limit int) ([]OutboxEvent, error) {
+	queryString := `
+		UPDATE public.chat_outbox
+		SET next_attempt_at = NOW() + make_interval(secs => $1), attempts = attempts + 1
+		WHERE seq IN (
+			SELECT o.seq FROM public.chat_outbox o
+			WHERE o.sent_at IS NULL AND o.next_attempt_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM public.chat_outbox prev
+				WHERE prev.room_id = o.room_id AND prev.sent_at IS NULL AND prev.seq < o.seq AND prev.next_attempt_at > NOW()
+			)
+			ORDER BY o.seq
+			LIMIT $2
+		)
+		RETURNING seq, id, room_id, user_id, kind, message_id, payload, attempts, created_at`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, outboxClaimLockKey); err != nil {
+		return nil, fmt.Errorf("failed to lock the outbox: %w", err)
+	}
+	rows, err := tx.QueryContext(ctx, queryString, outboxClaimLease.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type claimed struct {
+		seq   int64
+		event OutboxEvent
+	}
+	var result []claimed
+	dead := map[string]error{}
+	for rows.Next() {
+		var c claimed
+		var messageId sql.NullString
+		var payload []byte
+		if err := rows.Scan(&c.seq, &c.event.ID, &c.event.RoomID, &c.event.UserID, &c.event.Kind, &messageId, &payload, &c.event.Attempts, &c.event.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.event.MessageID = messageId.String
+		if c.event.Event, err = decodeOutboxPayload(payload); err != nil {
+			dead[c.event.ID] = err
+			continue
+		}
+		result = append(result, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// Un evento que no se puede decodificar no se publicará nunca: sale del outbox para
+	// no reclamarlo en cada sondeo
+	for id, cause := range dead {
+		fmt.Printf("Outbox event %s cannot be decoded, moving it to chat_outbox_dead_letter: %v\n", id, cause)
+		if err := r.deadLetterOutboxEvent(ctx, id, cause); err != nil {
+			return nil, err
+		}
+	}
+
+	// RETURNING no garantiza el orden
+	sort.Slice(result, func(i, j int) bool { return result[i].seq < result[j].seq })
+
+	events := make([]OutboxEvent, len(result))
+	for i, c := range result {
+		events[i] = c.event
+	}
+	return events, nil
+}
+
+func (r *SQLRoomRepository) deadLetterOutboxEvent(ctx context.Context, id string, cause error) error {
+	queryString := `
+		WITH dead AS (
+			DELETE FROM public.chat_outbox WHERE id = $1
+			RETURNING id, room_id, user_id, kind, message_id, payload, attempts, created_at
+		)
+		INSERT INTO public.chat_outbox_dead_letter (id, room_id, user_id, kind, message_id, payload, attempts, error, created_at)
+		SELECT id, room_id, user_id, kind, message_id, payload, attempts, $2, created_at FROM dead
+		ON CONFLICT (id) DO NOTHING`
+
+	if _, err := r.db.ExecContext(ctx, queryString, id, cause.Error()); err != nil {
+		return fmt.Errorf("failed to dead-letter outbox event %s: %w", id, err)
+	}
+	return nil
+}
+
+func (r *SQLRoomRepository) MarkOutboxEventSent(ctx context.Context, event OutboxEvent) error {
+	queryString, args, err := dbpq.QueryBuilder().
+		Update("public.chat_outbox").
+		Set("sent_at", sq.Expr("NOW()")).
+		Set("last_error", nil).
+		Where(sq.Eq{"id": event.ID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, queryString, args...)
+	return err
+}
+
+func (r *SQLRoomRepository) MarkOutboxEventFailed(ctx context.Context, event OutboxEvent, cause error) error {
+	queryString, args, err := dbpq.QueryBuilder().
+		Update("public.chat_outbox").
+		Set("next_attempt_at", time.Now().Add(outboxBackoff(event.Attempts))).
+		Set("last_error", cause.Error()).
+		Where(sq.Eq{"id": event.ID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, queryString, args...)
+	return err
+}
+
+// PurgeOutboxEvents elimina los eventos ya enviados antes de sentBefore.
+func (r *SQLRoomRepository) PurgeOutboxEvents(ctx context.Context, sentBefore time.Time) (int64, error) {
+	queryString, args, err := dbpq.QueryBuilder().
+		Delete("public.chat_outbox").
+		Where(sq.NotEq{"sent_at": nil}).
+		Where(sq.Lt{"sent_at": sentBefore}).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := r.db.ExecContext(ctx, queryString, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
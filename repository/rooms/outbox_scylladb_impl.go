@@ -0,0 +1,412 @@
+package roomsrepository
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/scylladb-solutions/gocql/v2"
+)
+
+// El outbox se reparte en particiones (bucket, shard): el bucket es el minuto en que se
+// escribió el evento y el shard sale de la sala, así que todos los eventos de una sala
+// caen en el mismo shard y las escrituras de un minuto se reparten entre outboxShards
+// particiones. Cada shard lo publica una sola instancia (outbox_shard_leases, con LWT),
+// que lo recorre en orden desde el bucket más antiguo que puede tener filas.
+const (
+	outboxShards      = 16
+	outboxBucketWidth = time.Minute
+	// Margen antes de dar un bucket por cerrado: una escritura lenta puede llegar con un
+	// created_at de hace unos segundos
+	outboxBucketSettle = time.Minute
+	// Buckets que se leen por shard en cada llamada; el barrido sigue en la siguiente
+	outboxBucketsPerClaim = 60
+	// Duración de la reserva de un shard; se renueva a la mitad
+	outboxShardLease = 30 * time.Second
+	// Partición de outbox_by_bucket, la tabla anterior a 0009_outbox_shards
+	outboxLegacyBucket = 0
+)
+
+// outboxPartition identifica la partición de la que se reclamó un evento.
+type outboxPartition struct {
+	legacy bool // outbox_by_bucket
+	bucket time.Time
+	shard  int
+}
+
+func (p outboxPartition) table() string {
+	if p.legacy {
+		return "outbox_by_bucket"
+	}
+	return "outbox_by_shard"
+}
+
+func (p outboxPartition) key() (string, []any) {
+	if p.legacy {
+		return "bucket = ?", []any{outboxLegacyBucket}
+	}
+	return "bucket = ? AND shard = ?", []any{p.bucket, p.shard}
+}
+
+func outboxShard(roomUUID gocql.UUID) int {
+	h := fnv.New32a()
+	h.Write(roomUUID[:])
+	return int(h.Sum32() % outboxShards)
+}
+
+func outboxBucketOf(t time.Time) time.Time {
+	return t.UTC().Truncate(outboxBucketWidth)
+}
+
+// scyllaOutboxState es el estado del relay de este proceso. Es compartido por todos los
+// repositorios del proceso: la reserva de un shard es del proceso, no del repositorio.
+type scyllaOutboxState struct {
+	mu            sync.Mutex
+	owner         gocql.UUID
+	shards        [outboxShards]outboxShardState
+	legacyDrained bool
+}
+
+type outboxShardState struct {
+	leaseUntil time.Time
+	retryAt    time.Time // el shard es de otra instancia: no se vuelve a pedir antes
+	watermark  time.Time // primer bucket que puede tener filas; cero si hay que buscarlo
+	cursor     time.Time // siguiente bucket del barrido en curso
+	clean      bool      // todos los buckets del barrido hasta cursor estaban vacíos
+	// Salas con un evento anterior pendiente en el barrido: sus eventos siguientes esperan
+	blocked map[string]bool
+}
+
+var scyllaOutbox = &scyllaOutboxState{owner: gocql.TimeUUID()}
+
+// addOutboxEvents agrega los eventos al batch LOGGED de la mutación, de forma que se
+// escriben de manera atómica junto con ella.
+func addOutboxEvents(batch *gocql.Batch, events ...OutboxEvent) error {
+	for _, event := range events {
+		payload, err := event.payload()
+		if err != nil {
+			return fmt.Errorf("error al codificar evento del outbox: %w", err)
+		}
+		roomUUID, err := gocql.ParseUUID(event.RoomID)
+		if err != nil {
+			return fmt.Errorf("ID de sala inválido: %w", err)
+		}
+		eventUUID, err := gocql.ParseUUID(event.ID)
+		if err != nil {
+			return fmt.Errorf("ID de evento inválido: %w", err)
+		}
+		batch.Query(`INSERT INTO outbox_by_shard (bucket, shard, created_at, id, room_id, user_id, kind, message_id, payload, attempts, next_attempt_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			outboxBucketOf(event.CreatedAt), outboxShard(roomUUID), event.CreatedAt, eventUUID, roomUUID, event.UserID, event.Kind, event.MessageID, payload, 0, event.CreatedAt)
+	}
+	return nil
+}
+
+// outboxClaim acumula los eventos reclamados en una llamada a ClaimOutboxEvents.
+type outboxClaim struct {
+	now    time.Time
+	limit  int
+	events []OutboxEvent
+	// Primer bucket con filas de cada shard; se busca una vez por llamada si hace falta
+	firstBuckets map[int]time.Time
+}
+
+func (c *outboxClaim) full() bool { return len(c.events) >= c.limit }
+
+// ClaimOutboxEvents devuelve hasta limit eventos pendientes de los shards de esta
+// instancia, en orden de creación dentro de cada sala. Una sala con un evento anterior
+// pendiente (en reintento o reclamado) no entrega los siguientes.
+func (r *ScyllaRoomRepository) ClaimOutboxEvents(ctx context.Context, limit int) ([]OutboxEvent, error) {
+	state := scyllaOutbox
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	claim := &outboxClaim{now: time.Now(), limit: limit}
+
+	// Los eventos escritos antes de 0009_outbox_shards se publican primero; las salas que
+	// aún tienen alguno no entregan sus eventos nuevos
+	var legacyRooms map[string]bool
+	if !state.legacyDrained {
+		var err error
+		if legacyRooms, err = r.claimLegacyOutbox(ctx, claim); err != nil {
+			return nil, err
+		}
+	}
+
+	for shard := 0; shard < outboxShards && !claim.full(); shard++ {
+		owned, err := r.holdOutboxShard(ctx, shard, claim.now)
+		if err != nil {
+			return nil, err
+		}
+		if !owned {
+			continue
+		}
+		if err := r.sweepOutboxShard(ctx, shard, claim, legacyRooms); err != nil {
+			return nil, err
+		}
+	}
+
+	return claim.events, nil
+}
+
+// claimLegacyOutbox publica desde outbox_by_bucket (solo quien tiene el shard 0) y
+// devuelve las salas que todavía tienen filas ahí.
+func (r *ScyllaRoomRepository) claimLegacyOutbox(ctx context.Context, claim *outboxClaim) (map[string]bool, error) {
+	rooms := map[string]bool{}
+	iter := r.session.Query(`SELECT room_id FROM outbox_by_bucket WHERE bucket = ?`, outboxLegacyBucket).WithContext(ctx).Iter()
+	var roomUUID gocql.UUID
+	for iter.Scan(&roomUUID) {
+		rooms[roomUUID.String()] = true
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	if len(rooms) == 0 {
+		scyllaOutbox.legacyDrained = true
+		return nil, nil
+	}
+
+	owned, err := r.holdOutboxShard(ctx, 0, claim.now)
+	if err != nil || !owned {
+		return rooms, err
+	}
+	if _, _, err := r.claimOutboxPartition(ctx, outboxPartition{legacy: true}, claim, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+// sweepOutboxShard continúa el barrido del shard. El barrido va del watermark al bucket
+// actual; al terminarlo vuelve a empezar, y el watermark avanza sobre los buckets cerrados
+// que quedaron vacíos, así que un bucket vaciado no se vuelve a leer.
+func (r *ScyllaRoomRepository) sweepOutboxShard(ctx context.Context, shard int, claim *outboxClaim, legacyRooms map[string]bool) error {
+	st := &scyllaOutbox.shards[shard]
+	if st.watermark.IsZero() {
+		if claim.firstBuckets == nil {
+			firstBuckets, err := r.firstOutboxBuckets(ctx)
+			if err != nil {
+				return err
+			}
+			claim.firstBuckets = firstBuckets
+		}
+		st.watermark = outboxBucketOf(claim.now)
+		if first, ok := claim.firstBuckets[shard]; ok && first.Before(st.watermark) {
+			st.watermark = first
+		}
+		st.restartSweep()
+	}
+	mergeRooms(st.blocked, legacyRooms)
+
+	current := outboxBucketOf(claim.now)
+	claimedRooms := map[string]bool{}
+	for i := 0; i < outboxBucketsPerClaim && !claim.full(); i++ {
+		if st.cursor.After(current) {
+			st.restartSweep()
+			return nil
+		}
+
+		before := len(claim.events)
+		empty, complete, err := r.claimOutboxPartition(ctx, outboxPartition{bucket: st.cursor, shard: shard}, claim, st.blocked)
+		if err != nil {
+			return err
+		}
+		for _, event := range claim.events[before:] {
+			claimedRooms[event.RoomID] = true
+		}
+		if !complete {
+			// Quedan filas en el bucket: la siguiente llamada lo vuelve a leer
+			break
+		}
+
+		settled := !st.cursor.Add(outboxBucketWidth + outboxBucketSettle).After(claim.now)
+		if empty && st.clean && settled {
+			st.watermark = st.cursor.Add(outboxBucketWidth)
+		} else {
+			st.clean = false
+		}
+		st.cursor = st.cursor.Add(outboxBucketWidth)
+	}
+
+	// Lo reclamado en esta llamada queda detrás del cursor: si falla, sus eventos
+	// siguientes tienen que esperar al próximo barrido
+	mergeRooms(st.blocked, claimedRooms)
+	return nil
+}
+
+func (st *outboxShardState) restartSweep() {
+	st.cursor = st.watermark
+	st.clean = true
+	st.blocked = map[string]bool{}
+}
+
+func mergeRooms(dst map[string]bool, src map[string]bool) {
+	for room := range src {
+		dst[room] = true
+	}
+}
+
+// firstOutboxBuckets busca el bucket más antiguo con filas de cada shard. Recorre las
+// claves de partición de toda la tabla, así que solo se usa al tomar un shard.
+func (r *ScyllaRoomRepository) firstOutboxBuckets(ctx context.Context) (map[int]time.Time, error) {
+	first := map[int]time.Time{}
+	iter := r.session.Query(`SELECT DISTINCT bucket, shard FROM outbox_by_shard`).WithContext(ctx).Iter()
+	var bucket time.Time
+	var shard int
+	for iter.Scan(&bucket, &shard) {
+		if current, ok := first[shard]; !ok || bucket.Before(current) {
+			first[shard] = bucket.UTC()
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error al buscar los buckets pendientes del outbox: %w", err)
+	}
+	return first, nil
+}
+
+// holdOutboxShard reserva el shard para esta instancia o renueva la reserva. Solo quien
+// tiene el shard publica sus eventos, así los eventos de una sala no salen a la vez desde
+// dos instancias. La tabla solo se escribe con LWT.
+func (r *ScyllaRoomRepository) holdOutboxShard(ctx context.Context, shard int, now time.Time) (bool, error) {
+	st := &scyllaOutbox.shards[shard]
+	if now.Before(st.leaseUntil.Add(-outboxShardLease / 2)) {
+		return true, nil
+	}
+	if now.Before(st.retryAt) {
+		return false, nil
+	}
+
+	ttl := int(outboxShardLease.Seconds())
+	applied, err := r.session.Query(`UPDATE outbox_shard_leases USING TTL ? SET owner = ? WHERE shard = ? IF owner = ?`, ttl, scyllaOutbox.owner, shard, scyllaOutbox.owner).
+		WithContext(ctx).MapScanCAS(map[string]any{})
+	if err == nil && !applied {
+		applied, err = r.session.Query(`INSERT INTO outbox_shard_leases (shard, owner) VALUES (?, ?) IF NOT EXISTS USING TTL ?`, shard, scyllaOutbox.owner, ttl).
+			WithContext(ctx).MapScanCAS(map[string]any{})
+	}
+	if err != nil {
+		return false, fmt.Errorf("error al reservar el shard %d del outbox: %w", shard, err)
+	}
+
+	if !applied {
+		// Otra instancia publica el shard; si se recupera, el barrido empieza de nuevo
+		*st = outboxShardState{retryAt: now.Add(outboxShardLease / 2)}
+		return false, nil
+	}
+	if now.After(st.leaseUntil) {
+		// La reserva había vencido: otra instancia pudo avanzar el shard entretanto
+		st.watermark = time.Time{}
+	}
+	st.leaseUntil = now.Add(outboxShardLease)
+	return true, nil
+}
+
+// claimOutboxPartition reclama las filas vencidas de la partición en orden de creación.
+// Una sala con una fila que aún no vence queda en blocked y sus filas siguientes se
+// saltan. Devuelve si la partición estaba vacía y si se leyó completa.
+func (r *ScyllaRoomRepository) claimOutboxPartition(ctx context.Context, p outboxPartition, claim *outboxClaim, blocked map[string]bool) (bool, bool, error) {
+	where, args := p.key()
+	iter := r.session.Query(`SELECT created_at, id, room_id, user_id, kind, message_id, payload, attempts, next_attempt_at FROM `+p.table()+` WHERE `+where, args...).
+		WithContext(ctx).Iter()
+
+	empty := true
+	var createdAt time.Time
+	var id, roomUUID gocql.UUID
+	var userId, attempts int
+	var kind, messageId string
+	var payload []byte
+	var nextAttemptAt time.Time
+	for !claim.full() && iter.Scan(&createdAt, &id, &roomUUID, &userId, &kind, &messageId, &payload, &attempts, &nextAttemptAt) {
+		empty = false
+		roomID := roomUUID.String()
+		if blocked[roomID] || nextAttemptAt.After(claim.now) {
+			blocked[roomID] = true
+			continue
+		}
+
+		event := OutboxEvent{
+			ID:        id.String(),
+			RoomID:    roomID,
+			UserID:    userId,
+			Kind:      kind,
+			MessageID: messageId,
+			Attempts:  attempts,
+			CreatedAt: createdAt,
+			partition: p,
+		}
+		decoded, err := decodeOutboxPayload(payload)
+		if err != nil {
+			// No se publicará nunca: pasa a outbox_dead_letter para no leerla en cada barrido
+			fmt.Printf("Evento del outbox %s ilegible, se mueve a outbox_dead_letter: %v\n", id, err)
+			if err := r.deadLetterOutboxEvent(ctx, event, payload, err); err != nil {
+				iter.Close()
+				return false, false, err
+			}
+			continue
+		}
+		event.Event = decoded
+		event.Attempts++
+
+		// Reserva el evento durante el lease para no reintentarlo mientras se publica
+		if err := r.session.Query(`UPDATE `+p.table()+` SET attempts = ?, next_attempt_at = ? WHERE `+where+` AND created_at = ? AND id = ?`,
+			append([]any{attempts + 1, claim.now.Add(outboxClaimLease)}, append(args, createdAt, id)...)...).WithContext(ctx).Exec(); err != nil {
+			iter.Close()
+			return false, false, err
+		}
+		claim.events = append(claim.events, event)
+	}
+	complete := !claim.full() || !iter.Scan(&createdAt, &id, &roomUUID, &userId, &kind, &messageId, &payload, &attempts, &nextAttemptAt)
+	if err := iter.Close(); err != nil {
+		return false, false, err
+	}
+
+	return empty, complete, nil
+}
+
+func (r *ScyllaRoomRepository) deadLetterOutboxEvent(ctx context.Context, event OutboxEvent, payload []byte, cause error) error {
+	eventUUID, err := gocql.ParseUUID(event.ID)
+	if err != nil {
+		return err
+	}
+	roomUUID, err := gocql.ParseUUID(event.RoomID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	where, args := event.partition.key()
+	batch := r.session.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`INSERT INTO outbox_dead_letter (day, created_at, id, room_id, user_id, kind, message_id, payload, attempts, error, dead_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		now, event.CreatedAt, eventUUID, roomUUID, event.UserID, event.Kind, event.MessageID, payload, event.Attempts, cause.Error(), now)
+	batch.Query(`DELETE FROM `+event.partition.table()+` WHERE `+where+` AND created_at = ? AND id = ?`, append(args, event.CreatedAt, eventUUID)...)
+	if err := r.session.ExecuteBatch(batch); err != nil {
+		return fmt.Errorf("error al mover el evento %s a outbox_dead_letter: %w", event.ID, err)
+	}
+	return nil
+}
+
+// MarkOutboxEventSent elimina la fila: en Scylla el evento enviado no se conserva.
+func (r *ScyllaRoomRepository) MarkOutboxEventSent(ctx context.Context, event OutboxEvent) error {
+	eventUUID, err := gocql.ParseUUID(event.ID)
+	if err != nil {
+		return err
+	}
+	where, args := event.partition.key()
+	return r.session.Query(`DELETE FROM `+event.partition.table()+` WHERE `+where+` AND created_at = ? AND id = ?`, append(args, event.CreatedAt, eventUUID)...).
+		WithContext(ctx).Exec()
+}
+
+func (r *ScyllaRoomRepository) MarkOutboxEventFailed(ctx context.Context, event OutboxEvent, cause error) error {
+	eventUUID, err := gocql.ParseUUID(event.ID)
+	if err != nil {
+		return err
+	}
+	where, args := event.partition.key()
+	return r.session.Query(`UPDATE `+event.partition.table()+` SET next_attempt_at = ?, last_error = ? WHERE `+where+` AND created_at = ? AND id = ?`,
+		append([]any{time.Now().Add(outboxBackoff(event.Attempts)), cause.Error()}, append(args, event.CreatedAt, eventUUID)...)...).
+		WithContext(ctx).Exec()
+}
+
+// PurgeOutboxEvents no tiene trabajo en Scylla porque las filas se eliminan al enviarse.
+func (r *ScyllaRoomRepository) PurgeOutboxEvents(ctx context.Context, sentBefore time.Time) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,65 @@
+package roomsrepository
+
+import (
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// Retención del historial por sala.
+//
+// room.retention_days sin valor aplica la política global (chat.retentionDays), 0 conserva el
+// historial para siempre y N > 0 conserva N días. PurgeExpiredMessages elimina el contenido de
+// los mensajes vencidos (quedan como tombstone para no romper respuestas ni secuencias), borra
+// sus reacciones, menciones y lecturas, avanza history_purged_before de la sala y escribe en el
+// outbox un evento HistoryPurged para que los clientes borren su copia local.
+//
+// En Scylla también se usa el job en lugar de TTL: el TTL se fija al escribir y no se puede
+// cambiar cuando el owner modifica la retención, y los clientes necesitan el evento de purga.
+
+// RetentionPurge describe los mensajes eliminados de una sala en una pasada del job.
+type RetentionPurge struct {
+	RoomID     string
+	Before     time.Time // los mensajes anteriores a esta fecha ya no existen
+	MessageIDs []string
+	// Complete es false cuando la sala tiene más mensajes vencidos que el tamaño del lote
+	Complete bool
+}
+
+// effectiveRetentionDays resuelve la retención de una sala; 0 significa sin límite.
+func effectiveRetentionDays(roomDays *int, defaultDays int) int {
+	days := defaultDays
+	if roomDays != nil {
+		days = *roomDays
+	}
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+func retentionCutoff(now time.Time, days int) time.Time {
+	return now.AddDate(0, 0, -days)
+}
+
+// newHistoryPurgedEvent construye el evento de outbox de una purga. El usuario es 0 porque lo
+// origina el sistema y no un participante de la sala.
+func newHistoryPurgedEvent(roomID string, before time.Time) OutboxEvent {
+	return newOutboxEvent(roomID, 0, OutboxHistoryPurged, "", &chatv1.MessageEvent{
+		RoomId: roomID,
+		Event: &chatv1.MessageEvent_HistoryPurged{HistoryPurged: &chatv1.HistoryPurgedEvent{
+			RoomId: roomID,
+			Before: before.UTC().Format(time.RFC3339Nano),
+		}},
+	})
+}
+
+// retentionDaysUpdate traduce UpdateRoomRequest.retention_days al valor que se guarda:
+// nil vuelve a la política global.
+func retentionDaysUpdate(value int32) *int {
+	if value < 0 {
+		return nil
+	}
+	days := int(value)
+	return &days
+}
@@ -0,0 +1,207 @@
+package roomsrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	dbpq "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+)
+
+// PurgeExpiredMessages procesa un lote de mensajes vencidos por cada sala con retención.
+// Cada sala se purga en su propia transacción; FOR UPDATE SKIP LOCKED evita que dos
+// instancias del job procesen la misma sala a la vez.
+func (r *SQLRoomRepository) PurgeExpiredMessages(ctx context.Context, defaultRetentionDays int, now time.Time, batchSize int) ([]RetentionPurge, error) {
+	queryString, args, err := dbpq.QueryBuilder().
+		Select("id").
+		From("room").
+		Where(sq.Eq{"deleted_at": nil}).
+		Where("COALESCE(retention_days, ?) > 0", defaultRetentionDays).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, queryString, args...)
+	if err != nil {
+		return nil, err
+	}
+	var roomIds []string
+	for rows.Next() {
+		var roomId string
+		if err := rows.Scan(&roomId); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		roomIds = append(roomIds, roomId)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var purges []RetentionPurge
+	for _, roomId := range roomIds {
+		purge, err := r.purgeRoomHistory(ctx, roomId, defaultRetentionDays, now, batchSize)
+		if err != nil {
+			fmt.Printf("error purging history of room %s: %v\n", roomId, err)
+			continue
+		}
+		if purge != nil {
+			purges = append(purges, *purge)
+		}
+	}
+
+	return purges, nil
+}
+
+func (r *SQLRoomRepository) purgeRoomHistory(ctx context.Context, roomId string, defaultRetentionDays int, now time.Time, batchSize int) (*RetentionPurge, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var retentionDays sql.NullInt32
+	var purgedBefore sql.NullTime
+	var purgedAfterId sql.NullString
+	err = dbpq.QueryBuilder().
+		Select("retention_days", "history_purged_before", "history_purged_after_id::text").
+		From("room").
+		Where(sq.Eq{"id": roomId}).
+		Where(sq.Eq{"deleted_at": nil}).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&retentionDays, &purgedBefore, &purgedAfterId)
+	if err == sql.ErrNoRows {
+		// Otra instancia la está purgando o la sala se eliminó
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var roomDays *int
+	if retentionDays.Valid {
+		days := int(retentionDays.Int32)
+		roomDays = &days
+	}
+	days := effectiveRetentionDays(roomDays, defaultRetentionDays)
+	if days == 0 {
+		return nil, nil
+	}
+	cutoff := retentionCutoff(now, days)
+
+	query := dbpq.QueryBuilder().
+		Select("id", "created_at").
+		From("room_message").
+		Where(sq.Eq{"room_id": roomId}).
+		Where(sq.Lt{"created_at": cutoff}).
+		OrderBy("created_at ASC", "id ASC").
+		Limit(uint64(batchSize))
+	// Tras un lote lleno se sigue después del último mensaje purgado: varios mensajes pueden
+	// compartir created_at y, sin el id, la pasada siguiente volvería a elegir los mismos
+	switch {
+	case purgedBefore.Valid && purgedAfterId.Valid:
+		query = query.Where("(created_at, id) > (?, ?::uuid)", purgedBefore.Time, purgedAfterId.String)
+	case purgedBefore.Valid:
+		query = query.Where(sq.GtOrEq{"created_at": purgedBefore.Time})
+	}
+
+	rows, err := query.RunWith(tx).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var messageIds []string
+	var lastCreatedAt time.Time
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id, &lastCreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		messageIds = append(messageIds, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(messageIds) == 0 {
+		return nil, nil
+	}
+
+	// Si el lote está lleno la marca avanza solo hasta el último mensaje purgado; la
+	// siguiente pasada continúa después de él
+	purge := &RetentionPurge{RoomID: roomId, Before: cutoff, MessageIDs: messageIds, Complete: len(messageIds) < batchSize}
+	var purgedAfter any
+	if !purge.Complete {
+		purge.Before = lastCreatedAt
+		purgedAfter = messageIds[len(messageIds)-1]
+	}
+
+	_, err = dbpq.QueryBuilder().
+		Update("room_message").
+		Set("content", nil).
+		Set("content_decrypted", nil).
+		Set("audio_transcription", nil).
+		Set("file", nil).
+		Set("location_name", nil).
+		Set("location_latitude", nil).
+		Set("location_longitude", nil).
+		Set("contact_id", nil).
+		Set("contact_name", nil).
+		Set("contact_phone", nil).
+		Set("\"isDeleted\"", true).
+		Set("deleted_at", sq.Expr("COALESCE(deleted_at, ?)", now)).
+		Set("updated_at", now).
+		Where(sq.Eq{"id": messageIds}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, table := range []struct{ name, column string }{
+		{"room_message_reaction", "\"messageId\""},
+		{"room_message_tag", "message_id"},
+		{"room_message_meta", "message_id"},
+	} {
+		_, err = dbpq.QueryBuilder().
+			Delete(table.name).
+			Where(sq.Eq{table.column: messageIds}).
+			RunWith(tx).
+			ExecContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge %s: %w", table.name, err)
+		}
+	}
+
+	_, err = dbpq.QueryBuilder().
+		Update("room").
+		Set("history_purged_before", purge.Before).
+		Set("history_purged_after_id", purgedAfter).
+		Where(sq.Eq{"id": roomId}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = insertOutboxEvents(ctx, tx, newHistoryPurgedEvent(roomId, purge.Before)); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	DeleteRoomCacheByRoomID(ctx, roomId)
+	for _, id := range messageIds {
+		DeleteCache(ctx, messageSimpleCacheKey(id))
+	}
+
+	return purge, nil
+}
@@ -0,0 +1,165 @@
+package roomsrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scylladb-solutions/gocql/v2"
+)
+
+// PurgeExpiredMessages recorre room_details y purga un lote de mensajes vencidos por sala.
+// No hay bloqueo entre instancias: la purga es idempotente y, como mucho, dos instancias
+// publican el mismo evento HistoryPurged.
+func (r *ScyllaRoomRepository) PurgeExpiredMessages(ctx context.Context, defaultRetentionDays int, now time.Time, batchSize int) ([]RetentionPurge, error) {
+	var purges []RetentionPurge
+
+	iter := r.session.Query(`SELECT room_id, retention_days, history_purged_before, history_purged_after FROM room_details`).WithContext(ctx).Iter()
+	var roomUUID gocql.UUID
+	var retentionDays *int
+	var purgedBefore time.Time
+	var purgedAfter *gocql.UUID
+	for iter.Scan(&roomUUID, &retentionDays, &purgedBefore, &purgedAfter) {
+		days := effectiveRetentionDays(retentionDays, defaultRetentionDays)
+		if days > 0 {
+			purge, err := r.purgeRoomHistory(ctx, roomUUID, retentionCutoff(now, days), purgedBefore, purgedAfter, now, batchSize)
+			if err != nil {
+				fmt.Printf("error purging history of room %s: %v\n", roomUUID, err)
+			} else if purge != nil {
+				purges = append(purges, *purge)
+			}
+		}
+		retentionDays = nil
+		purgedBefore = time.Time{}
+		purgedAfter = nil
+	}
+	if err := iter.Close(); err != nil {
+		return purges, err
+	}
+
+	return purges, nil
+}
+
+func (r *ScyllaRoomRepository) purgeRoomHistory(ctx context.Context, roomUUID gocql.UUID, cutoff time.Time, purgedBefore time.Time, purgedAfter *gocql.UUID, now time.Time, batchSize int) (*RetentionPurge, error) {
+	query := `SELECT message_id FROM messages_by_room WHERE room_id = ? AND message_id < maxTimeuuid(?)`
+	args := []any{roomUUID, cutoff}
+	// Tras un lote lleno se sigue después del último mensaje purgado: history_purged_before
+	// tiene precisión de milisegundos y varios mensajes pueden compartirlo
+	switch {
+	case purgedAfter != nil:
+		query += ` AND message_id > ?`
+		args = append(args, *purgedAfter)
+	case !purgedBefore.IsZero():
+		query += ` AND message_id >= minTimeuuid(?)`
+		args = append(args, purgedBefore)
+	}
+	query += ` ORDER BY message_id ASC LIMIT ?`
+	args = append(args, batchSize)
+
+	iter := r.session.Query(query, args...).WithContext(ctx).Iter()
+	var messageUUIDs []gocql.UUID
+	var messageUUID gocql.UUID
+	for iter.Scan(&messageUUID) {
+		messageUUIDs = append(messageUUIDs, messageUUID)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	if len(messageUUIDs) == 0 {
+		return nil, nil
+	}
+
+	roomId := roomUUID.String()
+	purge := &RetentionPurge{RoomID: roomId, Before: cutoff, Complete: len(messageUUIDs) < batchSize}
+	last := messageUUIDs[len(messageUUIDs)-1]
+	var cursor *gocql.UUID
+	if !purge.Complete {
+		purge.Before = last.Time()
+		cursor = &last
+	}
+
+	// Un batch por mensaje: las tablas de reacciones, lecturas y menciones están particionadas
+	// por mensaje y un único batch con todo el lote superaría el tamaño recomendado
+	for _, id := range messageUUIDs {
+		batch := r.session.Batch(gocql.UnloggedBatch)
+		batch.Query(`UPDATE messages_by_room SET content = null, content_decrypted = null, audio_transcription = null, file_url = null, location_name = null, location_latitude = null, location_longitude = null, contact_id = null, contact_name = null, contact_phone = null, is_deleted = true, updated_at = ? WHERE room_id = ? AND message_id = ?`, now, roomUUID, id)
+		batch.Query(`DELETE FROM reactions_by_message WHERE message_id = ?`, id)
+		batch.Query(`DELETE FROM read_receipts_by_message WHERE message_id = ?`, id)
+		batch.Query(`DELETE FROM mentions_by_message WHERE message_id = ?`, id)
+		if err := r.session.ExecuteBatch(batch.WithContext(ctx)); err != nil {
+			return nil, err
+		}
+		purge.MessageIDs = append(purge.MessageIDs, id.String())
+	}
+
+	// Si el lote llegó al último mensaje de la sala, la lista de salas de cada participante
+	// tiene su vista previa y hay que quitarla, como en Postgres, donde la sala queda sin
+	// último mensaje
+	var newest gocql.UUID
+	err := r.session.Query(`SELECT message_id FROM messages_by_room WHERE room_id = ? ORDER BY message_id DESC LIMIT 1`, roomUUID).WithContext(ctx).Scan(&newest)
+	if err != nil && err != gocql.ErrNotFound {
+		return nil, err
+	}
+	purgedLast := err == nil && !newest.Time().After(last.Time())
+
+	// El estado por usuario está particionado por (usuario, sala): se borra por rango
+	participants := r.session.Query(`SELECT user_id FROM participants_by_room WHERE room_id = ?`, roomUUID).WithContext(ctx).Iter()
+	var participantID int
+	for participants.Scan(&participantID) {
+		err := r.session.Query(`DELETE FROM message_status_by_user WHERE user_id = ? AND room_id = ? AND message_id <= ?`, participantID, roomUUID, last).WithContext(ctx).Exec()
+		if err != nil {
+			fmt.Printf("error purging message status of user %d in room %s: %v\n", participantID, roomId, err)
+		}
+		if purgedLast {
+			if err := r.clearPurgedLastMessage(ctx, participantID, roomUUID, last); err != nil {
+				fmt.Printf("error clearing the last message of user %d in room %s: %v\n", participantID, roomId, err)
+			}
+		}
+	}
+	if err := participants.Close(); err != nil {
+		return nil, err
+	}
+
+	batch := r.session.Batch(gocql.LoggedBatch)
+	batch.Query(`UPDATE room_details SET history_purged_before = ?, history_purged_after = ? WHERE room_id = ?`, purge.Before, cursor, roomUUID)
+	if err := addOutboxEvents(batch, newHistoryPurgedEvent(roomId, purge.Before)); err != nil {
+		return nil, err
+	}
+	if err := r.session.ExecuteBatch(batch.WithContext(ctx)); err != nil {
+		return nil, err
+	}
+
+	DeleteRoomCacheByRoomID(ctx, roomId)
+	return purge, nil
+}
+
+// clearPurgedLastMessage borra las columnas last_message_* de la fila del usuario en
+// rooms_by_user si su último mensaje quedó purgado (no es posterior a last). Se borran las
+// columnas y no se reescribe la fila: si un mensaje nuevo la movió entretanto, el borrado
+// no crea una fila fantasma con la clave antigua.
+func (r *ScyllaRoomRepository) clearPurgedLastMessage(ctx context.Context, userId int, roomUUID gocql.UUID, last gocql.UUID) error {
+	var isPinned bool
+	var lastMessageAt time.Time
+	err := r.session.Query(`SELECT is_pinned, last_message_at FROM room_membership_lookup WHERE user_id = ? AND room_id = ?`, userId, roomUUID).WithContext(ctx).Scan(&isPinned, &lastMessageAt)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+
+	var lastMessageID gocql.UUID
+	err = r.session.Query(`SELECT last_message_id FROM rooms_by_user WHERE user_id = ? AND is_pinned = ? AND last_message_at = ? AND room_id = ?`, userId, isPinned, lastMessageAt, roomUUID).WithContext(ctx).Scan(&lastMessageID)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	if lastMessageID == (gocql.UUID{}) || lastMessageID.Time().After(last.Time()) {
+		return nil
+	}
+
+	return r.session.Query(`DELETE last_message_id, last_message_preview, last_message_type, last_message_sender_id, last_message_sender_name, last_message_sender_phone, last_message_status, last_message_updated_at FROM rooms_by_user WHERE user_id = ? AND is_pinned = ? AND last_message_at = ? AND room_id = ?`,
+		userId, isPinned, lastMessageAt, roomUUID).WithContext(ctx).Exec()
+}
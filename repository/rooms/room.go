@@ -2,6 +2,7 @@ package roomsrepository
 
 import (
 	"context"
+	"time"
 
 	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
 )
@@ -34,10 +35,14 @@ type RoomsRepository interface {
 	SaveMessage(ctx context.Context, userId int, req *chatv1.SendMessageRequest, room *chatv1.Room, contentDecrypted *string) (*chatv1.MessageData, error)
 	GetMessage(ctx context.Context, userId int, messageId string) (*chatv1.MessageData, error)
 	GetMessageSimple(ctx context.Context, userId int, messageId string) (*chatv1.MessageData, error)
-	UpdateMessage(ctx context.Context, userId int, messageId string, content string) error
+	// UpdateMessage guarda el contenido editado junto con la versión de la clave con la que
+	// se cifró (req.KeyVersion) y su content_decrypted
+	UpdateMessage(ctx context.Context, userId int, req *chatv1.EditMessageRequest, contentDecrypted *string) error
 	DeleteMessage(ctx context.Context, userId int, messageId []string) error
 	ReactToMessage(ctx context.Context, userId int, messageId string, reaction string) error
 	GetMessagesFromRoom(ctx context.Context, userId int, req *chatv1.GetMessageHistoryRequest) ([]*chatv1.MessageData, *chatv1.PaginationMeta, error)
+	// MarkMessagesAsRead escribe en el outbox el estado READ de los messageIds pedidos y el
+	// read_state con su marca de lectura (ver newMessagesReadEvents)
 	MarkMessagesAsRead(ctx context.Context, userId int, roomId string, messageIds []string, since string) (int32, error)
 	GetMessageRead(ctx context.Context, req *chatv1.GetMessageReadRequest) ([]*chatv1.MessageUserRead, *chatv1.PaginationMeta, error)
 	GetMessageReactions(ctx context.Context, req *chatv1.GetMessageReactionsRequest) ([]*chatv1.Reaction, *chatv1.PaginationMeta, error)
@@ -46,10 +51,57 @@ type RoomsRepository interface {
 	GetMessageSender(ctx context.Context, userId int, senderMessageId string) (*chatv1.MessageData, error)
 	CreateMessageMetaForParticipants(ctx context.Context, roomID string, messageID string, senderID int) error
 	IsPartnerMuted(ctx context.Context, userId int, roomId string) (bool, error)
+
+	// Outbox de eventos de chat (ver outbox.go)
+	ClaimOutboxEvents(ctx context.Context, limit int) ([]OutboxEvent, error)
+	MarkOutboxEventSent(ctx context.Context, event OutboxEvent) error
+	MarkOutboxEventFailed(ctx context.Context, event OutboxEvent, cause error) error
+	PurgeOutboxEvents(ctx context.Context, sentBefore time.Time) (int64, error)
+
+	// Retención del historial por sala (ver retention.go)
+	PurgeExpiredMessages(ctx context.Context, defaultRetentionDays int, now time.Time, batchSize int) ([]RetentionPurge, error)
+
+	// Borrado de los datos de un usuario (ver erasure.go)
+	EraseUserData(ctx context.Context, userId int) (*UserErasureReport, error)
+	// VerifyUserErasure devuelve los rastros del usuario que siguen en el store (vacío si no
+	// queda ninguno); roomIds son las salas de UserErasureReport.Rooms
+	VerifyUserErasure(ctx context.Context, userId int, roomIds []string) ([]string, error)
+
+	// Lecturas y mensajes enviados del usuario, para la exportación de sus datos (ver
+	// user_data.go). Incluyen las salas que dejó o eliminó
+	GetUserReadReceipts(ctx context.Context, userId int, roomId string) ([]UserReadReceipt, error)
+	GetUserMessageRooms(ctx context.Context, userId int) ([]string, error)
+	GetUserSentMessages(ctx context.Context, userId int, roomId string) ([]*chatv1.MessageData, error)
+
+	// Reconciliación de los contadores de no leídos en Redis (ver unread_counters.go); sin
+	// contadores no hace nada
+	ReconcileUnreadCounters(ctx context.Context, batchSize int) (*UnreadReconcileReport, error)
+
+	// VoidAbandonedSeqs anula los seq reservados cuyo mensaje no llegó a escribirse, en las
+	// salas cuya última reserva es anterior a reservedBefore; devuelve cuántos anuló
+	VoidAbandonedSeqs(ctx context.Context, reservedBefore time.Time) (int, error)
+
+	// Versiones de la clave de cifrado de la sala (ver room_keys.go)
+	RotateRoomKey(ctx context.Context, userId int, roomId string) (int32, error)
+	// GetRoomKeys devuelve la clave actual y las retiradas, de la más reciente a la más
+	// antigua; nil si la sala no existe
+	GetRoomKeys(ctx context.Context, roomId string) ([]*chatv1.RoomKey, error)
+
+	// Salas cifradas de extremo a extremo (ver e2e.go)
+	RegisterDeviceKey(ctx context.Context, userId int, deviceId string, publicKey string) error
+	// GetDeviceKeys devuelve las claves públicas de los dispositivos de los usuarios o, con
+	// roomId, de los participantes de la sala
+	GetDeviceKeys(ctx context.Context, userIds []int, roomId string) ([]*chatv1.DeviceKey, error)
+	RotateE2ERoomKey(ctx context.Context, userId int, roomId string, deviceId string, keys []*chatv1.WrappedRoomKey) (int32, error)
+	ShareRoomKey(ctx context.Context, userId int, roomId string, version int32, deviceId string, keys []*chatv1.WrappedRoomKey) error
+	// GetWrappedRoomKeys devuelve las versiones de la clave envueltas para el dispositivo, de
+	// la más reciente a la más antigua
+	GetWrappedRoomKeys(ctx context.Context, roomId string, userId int, deviceId string) ([]*chatv1.RoomKey, error)
 }
 
 type UserFetcher interface {
 	GetUserByID(ctx context.Context, id int) (*User, error)
 	GetUsersByID(ctx context.Context, ids []int) ([]User, error)
+	GetUserByPhone(ctx context.Context, phone string) (*User, error)
 	GetAllUserIDs(ctx context.Context) ([]int, error)
 }
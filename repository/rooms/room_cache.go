@@ -2,143 +2,109 @@ package roomsrepository
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"sync"
-	"time"
 
 	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
-	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/cache"
 )
 
-type CachedRoomResponse struct {
-	Data *chatv1.Room `json:"data"`
-}
-
-type CachedMessageResponse struct {
-	Data *chatv1.MessageData `json:"data"`
-}
-
-var (
-	roomCacheLocks   = make(map[string]*sync.Mutex)
-	roomCacheLocksMu sync.Mutex
-)
+// roomCache es la caché de dos niveles de salas y mensajes (ver cache_tier.go).
+var roomCache = newTieredCache(redisCache{}, localCacheCapacity, localCacheTTL)
 
-func getRoomLock(roomID string) *sync.Mutex {
-	roomCacheLocksMu.Lock()
-	defer roomCacheLocksMu.Unlock()
-
-	lock, exists := roomCacheLocks[roomID]
-	if !exists {
-		lock = &sync.Mutex{}
-		roomCacheLocks[roomID] = lock
+// roomCacheKey es la sala vista por un usuario; sin allData, en formato de compatibilidad.
+func roomCacheKey(roomId string, userId int, allData bool) string {
+	if allData {
+		return fmt.Sprintf("endpoint:chat:room:{%s}:user:%d", roomId, userId)
 	}
-	return lock
+	return fmt.Sprintf("endpoint:chat:room:{%s}:shim:user:%d", roomId, userId)
 }
 
-func GetCachedRoom(ctx context.Context, cacheKey string) (*chatv1.Room, bool) {
-	cacheValue, err := cache.Get(ctx, cacheKey)
-	if err != nil || cacheValue == "" {
-		return nil, false
-	}
+func messageSimpleCacheKey(messageId string) string {
+	return fmt.Sprintf("endpoint:chat:messagesimple:messageId:{%s}", messageId)
+}
 
-	var cachedResponse CachedRoomResponse
-	if err := json.Unmarshal([]byte(cacheValue), &cachedResponse); err != nil {
-		return nil, false
-	}
+// roomCacheMembersKey es el set de Redis con las claves cacheadas de una sala. No se borra al
+// invalidar: una clave que se registra mientras se invalida la sala debe seguir en el set
+// para la siguiente invalidación.
+func roomCacheMembersKey(roomId string) string {
+	return fmt.Sprintf("endpoint:chat:room:{%s}:members", roomId)
+}
 
-	return cachedResponse.Data, true
+// GetCachedRoom devuelve la sala cacheada o, si no está, el cacheFill que SetCachedRoom
+// necesita para guardarla después de leerla de la base de datos.
+func GetCachedRoom(ctx context.Context, cacheKey string) (*chatv1.Room, cacheFill, bool) {
+	room, fill, tier := lookupCached(ctx, roomCache, cacheKey, func() *chatv1.Room { return &chatv1.Room{} })
+	roomCacheCounters.record(tier)
+	return room, fill, tier != cacheMiss
 }
 
-func SetCachedRoom(ctx context.Context, roomId string, cacheKey string, data *chatv1.Room) {
-	cachedResponse := CachedRoomResponse{
-		Data: data,
+func SetCachedRoom(ctx context.Context, roomId string, fill cacheFill, data *chatv1.Room) {
+	if fill.key == "" {
+		return
 	}
-
-	cacheData, err := json.Marshal(cachedResponse)
-	if err == nil {
-		cache.Set(ctx, cacheKey, string(cacheData), 1*time.Hour)
-		setKey := fmt.Sprintf("endpoint:chat:room:{%s}:members", roomId)
-		cache.SAdd(ctx, setKey, cacheKey)
+	// La clave se registra antes de escribirla para que una invalidación concurrente la borre
+	if err := roomCache.remote.SAdd(ctx, roomCacheMembersKey(roomId), fill.key); err != nil {
+		fmt.Println("error setting room cache", err)
+		return
+	}
+	if err := roomCache.fill(ctx, fill, data); err != nil {
+		fmt.Println("error setting room cache", err)
 	}
 }
 
-// UpdateRoomCacheWithNewMessage atomically updates the LastMessage for all cached versions of a room.
+// UpdateRoomCacheWithNewMessage invalida la sala tras un mensaje nuevo. No reescribe
+// LastMessage en las copias cacheadas: dos réplicas enviando a la vez podrían dejar el mensaje
+// más antiguo. La siguiente lectura vuelve a cargar la sala de la base de datos.
 func UpdateRoomCacheWithNewMessage(ctx context.Context, message *chatv1.MessageData) {
 	if message == nil || message.RoomId == "" {
 		return
 	}
+	DeleteRoomCacheByRoomID(ctx, message.RoomId)
+}
 
-	roomLock := getRoomLock(message.RoomId)
-	roomLock.Lock()
-	defer roomLock.Unlock()
-
-	setKey := fmt.Sprintf("endpoint:chat:room:{%s}:members", message.RoomId)
-	cacheKeys, err := cache.SMembers(ctx, setKey)
-	if err != nil {
-		fmt.Println("error getting cache members for update", err)
-		return
-	}
-
-	for _, key := range cacheKeys {
-		cachedRoom, exists := GetCachedRoom(ctx, key)
-		if exists {
-			cachedRoom.LastMessage = message
-			cachedRoom.LastMessageAt = message.CreatedAt
-			// SetCachedRoom will re-add the key to the set, which is fine.
-			SetCachedRoom(ctx, message.RoomId, key, cachedRoom)
-		}
-	}
+func GetCachedMessageSimple(ctx context.Context, cacheKey string) (*chatv1.MessageData, cacheFill, bool) {
+	message, fill, tier := lookupCached(ctx, roomCache, cacheKey, func() *chatv1.MessageData { return &chatv1.MessageData{} })
+	messageCacheCounters.record(tier)
+	return message, fill, tier != cacheMiss
 }
 
-func GetCachedMessageSimple(ctx context.Context, cacheKey string) (*chatv1.MessageData, bool) {
-	cacheValue, err := cache.Get(ctx, cacheKey)
-	if err != nil || cacheValue == "" {
-		return nil, false
+func SetCachedMessageSimple(ctx context.Context, fill cacheFill, data *chatv1.MessageData) {
+	if err := roomCache.fill(ctx, fill, data); err != nil {
+		fmt.Println("error setting message cache", err)
 	}
+}
 
-	var cachedResponse CachedMessageResponse
-	if err := json.Unmarshal([]byte(cacheValue), &cachedResponse); err != nil {
-		return nil, false
+func DeleteRoomCacheByRoomID(ctx context.Context, roomId string) {
+	if _, err := FlushRoomCache(ctx, roomId); err != nil {
+		fmt.Println("error deleting cache", err)
 	}
-
-	return cachedResponse.Data, true
 }
 
-func SetCachedMessageSimple(ctx context.Context, cacheKey string, data *chatv1.MessageData) {
-	cachedResponse := CachedMessageResponse{
-		Data: data,
-	}
+// FlushRoomCache invalida todas las versiones cacheadas de la sala y devuelve cuántas claves
+// tenía registradas.
+func FlushRoomCache(ctx context.Context, roomId string) (int, error) {
+	defer roomCache.invalidate([]string{roomId}, nil)
 
-	cacheData, err := json.Marshal(cachedResponse)
-	if err == nil {
-		cache.Set(ctx, cacheKey, string(cacheData), 1*time.Hour)
-	}
-}
+	roomCache.bumpVersion(ctx, roomId)
 
-func DeleteRoomCacheByRoomID(ctx context.Context, roomId string) {
-	setKey := fmt.Sprintf("endpoint:chat:room:{%s}:members", roomId)
-	keys, err := cache.SMembers(ctx, setKey)
+	keys, err := roomCache.remote.SMembers(ctx, roomCacheMembersKey(roomId))
 	if err != nil {
-		fmt.Println("error getting cache members", err)
-		return
+		return 0, fmt.Errorf("failed to get cache members: %w", err)
 	}
 
 	if len(keys) > 0 {
-		err := cache.Del(ctx, keys...)
-		if err != nil {
-			fmt.Println("error deleting cache", err)
+		if err := roomCache.remote.Del(ctx, keys...); err != nil {
+			return 0, err
 		}
 	}
-
-	err = cache.Del(ctx, setKey)
-	if err != nil {
-		fmt.Println("error deleting cache set", err)
-	}
+	return len(keys), nil
 }
 
 func DeleteCache(ctx context.Context, key string) {
-	err := cache.Del(ctx, key)
+	defer roomCache.invalidate(nil, []string{key})
+
+	roomCache.bumpVersion(ctx, cacheGroup(key))
+
+	err := roomCache.remote.Del(ctx, key)
 	if err != nil {
 		fmt.Println("error deleting cache", err)
 	}
@@ -0,0 +1,122 @@
+package roomsrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// Cuánto se recuerda que un usuario no pertenece a una sala. Corto, porque entrar en la sala
+// solo lo descarta en las réplicas que reciben la invalidación
+const notMemberCacheTTL = 5 * time.Second
+
+// CoalescingRoomRepository agrupa las lecturas concurrentes de la misma sala, mensaje o
+// usuario en una sola consulta. Casi todos los handlers empiezan por GetRoom, así que una
+// ráfaga de mensajes a una sala sin caché lanzaría la misma consulta una vez por mensaje.
+//
+// También recuerda durante notMemberCacheTTL que un usuario no pertenece a una sala, para que
+// las peticiones repetidas de quien no es miembro no lleguen a la base de datos. Ese
+// recuerdo vive en el LRU local con el grupo de la sala, así que cualquier invalidación de la
+// sala (entrar, salir, borrar) lo descarta en todas las réplicas.
+type CoalescingRoomRepository struct {
+	RoomsRepository
+	flights singleflight.Group
+}
+
+func NewCoalescingRoomRepository(repo RoomsRepository) RoomsRepository {
+	return &CoalescingRoomRepository{RoomsRepository: repo}
+}
+
+func notMemberCacheKey(userId int, roomId string) string {
+	return fmt.Sprintf("endpoint:chat:room:{%s}:notmember:user:%d", roomId, userId)
+}
+
+// coalesce ejecuta fn una sola vez para todas las llamadas concurrentes con la misma clave.
+// La consulta no se cancela si se va quien la lanzó, porque otros pueden estar esperándola;
+// cada llamada deja de esperar cuando su propio contexto termina. Los resultados compartidos
+// se copian con clone para que nadie modifique el de otro.
+func coalesce[T any](ctx context.Context, flights *singleflight.Group, key string, clone func(T) T, fn func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	ch := flights.DoChan(key, func() (any, error) {
+		return fn(context.WithoutCancel(ctx))
+	})
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case result := <-ch:
+		if result.Err != nil {
+			return zero, result.Err
+		}
+		value := result.Val.(T)
+		if result.Shared {
+			value = clone(value)
+		}
+		return value, nil
+	}
+}
+
+func cloneRoom(room *chatv1.Room) *chatv1.Room {
+	if room == nil {
+		return nil
+	}
+	return proto.Clone(room).(*chatv1.Room)
+}
+
+func cloneMessage(message *chatv1.MessageData) *chatv1.MessageData {
+	if message == nil {
+		return nil
+	}
+	return proto.Clone(message).(*chatv1.MessageData)
+}
+
+func cloneUser(user *User) *User {
+	if user == nil {
+		return nil
+	}
+	copied := *user
+	return &copied
+}
+
+// GetRoom agrupa las lecturas con caché. Las que piden saltarse la caché quieren ver una
+// escritura reciente y no se agrupan con lecturas que pudieron empezar antes.
+func (r *CoalescingRoomRepository) GetRoom(ctx context.Context, userId int, roomId string, allData bool, cache bool) (*chatv1.Room, error) {
+	if !cache {
+		return r.RoomsRepository.GetRoom(ctx, userId, roomId, allData, cache)
+	}
+
+	notMemberKey := notMemberCacheKey(userId, roomId)
+	if _, ok := roomCache.local.get(notMemberKey); ok {
+		return nil, nil
+	}
+
+	key := fmt.Sprintf("room:%s:%d:%t", roomId, userId, allData)
+	return coalesce(ctx, &r.flights, key, cloneRoom, func(ctx context.Context) (*chatv1.Room, error) {
+		generation := roomCache.local.generation(roomId)
+		room, err := r.RoomsRepository.GetRoom(ctx, userId, roomId, allData, cache)
+		if err == nil && room == nil {
+			roomCache.setLocal(notMemberKey, &emptypb.Empty{}, generation, notMemberCacheTTL)
+		}
+		return room, err
+	})
+}
+
+func (r *CoalescingRoomRepository) GetMessageSimple(ctx context.Context, userId int, messageId string) (*chatv1.MessageData, error) {
+	key := fmt.Sprintf("message:%s:%d", messageId, userId)
+	return coalesce(ctx, &r.flights, key, cloneMessage, func(ctx context.Context) (*chatv1.MessageData, error) {
+		return r.RoomsRepository.GetMessageSimple(ctx, userId, messageId)
+	})
+}
+
+func (r *CoalescingRoomRepository) GetUserByID(ctx context.Context, id int) (*User, error) {
+	key := fmt.Sprintf("user:%d", id)
+	return coalesce(ctx, &r.flights, key, cloneUser, func(ctx context.Context) (*User, error) {
+		return r.RoomsRepository.GetUserByID(ctx, id)
+	})
+}
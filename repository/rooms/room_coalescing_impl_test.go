@@ -0,0 +1,114 @@
+package roomsrepository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// countingRoomRepository cuenta las lecturas de salas que llegan al repositorio y, con
+// release, las retiene hasta que el test las suelta.
+type countingRoomRepository struct {
+	RoomsRepository
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (r *countingRoomRepository) GetRoom(ctx context.Context, userId int, roomId string, allData bool, cache bool) (*chatv1.Room, error) {
+	r.calls.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+	return r.RoomsRepository.GetRoom(ctx, userId, roomId, allData, cache)
+}
+
+func newCoalescingFixture(t *testing.T) (*countingRoomRepository, RoomsRepository, *chatv1.Room) {
+	t.Helper()
+	memory := NewMemoryRoomRepository([]User{{ID: 1, Name: "Ana"}, {ID: 2, Name: "Luis"}, {ID: 3, Name: "Eva"}}).(*MemoryRoomRepository)
+	memory.SetKeyGenerator(func() (string, error) { return "coalescing-key", nil })
+	room, err := memory.CreateRoom(context.Background(), 1, &chatv1.CreateRoomRequest{Type: "group", Participants: []int32{2}})
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	counting := &countingRoomRepository{RoomsRepository: memory}
+	return counting, NewCoalescingRoomRepository(counting), room
+}
+
+func TestCoalescingRoomRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("las lecturas concurrentes comparten consulta y reciben copias", func(t *testing.T) {
+		counting, repo, room := newCoalescingFixture(t)
+		counting.release = make(chan struct{})
+
+		results := make([]*chatv1.Room, 10)
+		var wg sync.WaitGroup
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got, err := repo.GetRoom(ctx, 1, room.Id, true, true)
+				if err != nil {
+					t.Errorf("GetRoom: %v", err)
+				}
+				results[i] = got
+			}()
+		}
+
+		// Esperar a que la primera lectura llegue al repositorio y el resto se una a ella
+		for counting.calls.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(counting.release)
+		wg.Wait()
+
+		if calls := counting.calls.Load(); calls != 1 {
+			t.Fatalf("%d consultas para 10 lecturas concurrentes", calls)
+		}
+		for i, got := range results {
+			if got == nil || got.Id != room.Id {
+				t.Fatalf("lectura %d = %v", i, got)
+			}
+			if i > 0 && got == results[0] {
+				t.Fatalf("dos lecturas recibieron el mismo puntero")
+			}
+		}
+	})
+
+	t.Run("quien no es miembro se recuerda hasta que la sala se invalida", func(t *testing.T) {
+		counting, repo, room := newCoalescingFixture(t)
+
+		for range 3 {
+			if got, err := repo.GetRoom(ctx, 3, room.Id, true, true); err != nil || got != nil {
+				t.Fatalf("GetRoom de quien no es miembro = %v %v", got, err)
+			}
+		}
+		if calls := counting.calls.Load(); calls != 1 {
+			t.Fatalf("%d consultas para un usuario que no es miembro", calls)
+		}
+
+		// Entrar en la sala invalida su caché
+		if _, err := repo.AddParticipantToRoom(ctx, 1, room.Id, []int{3}); err != nil {
+			t.Fatalf("AddParticipantToRoom: %v", err)
+		}
+		roomCache.invalidate([]string{room.Id}, nil)
+		if got, err := repo.GetRoom(ctx, 3, room.Id, true, true); err != nil || got == nil {
+			t.Fatalf("GetRoom tras entrar en la sala = %v %v", got, err)
+		}
+
+		// Las lecturas sin caché no usan el recuerdo
+		if _, err := repo.GetRoom(ctx, 2, "no-existe", true, false); err != nil {
+			t.Fatalf("GetRoom: %v", err)
+		}
+		before := counting.calls.Load()
+		repo.GetRoom(ctx, 2, "no-existe", true, false)
+		if counting.calls.Load() != before+1 {
+			t.Fatalf("una lectura sin caché no llegó al repositorio")
+		}
+	})
+}
@@ -0,0 +1,549 @@
+package roomsrepository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+const (
+	dualWriteMirrorTimeout  = 30 * time.Second
+	dualWriteVerifyTimeout  = 10 * time.Second
+	dualWriteVerifyInFlight = 16
+	// Margen sobre el inicio de MarkMessagesAsRead por la diferencia de reloj con Postgres
+	dualWriteReadStateSkew = time.Minute
+)
+
+// RoomMirror refleja en el store secundario lo que quedó confirmado en el primario.
+// ScyllaBackfill lo implementa copiando desde Postgres. Cada método copia solo lo que toca
+// la operación: MirrorRoom es el único que recorre todos los miembros de la sala.
+type RoomMirror interface {
+	MirrorRoom(ctx context.Context, roomID string) error
+	MirrorMembership(ctx context.Context, roomID string, userID int) error
+	MirrorNewMessage(ctx context.Context, messageID string) error
+	MirrorMessages(ctx context.Context, messageIDs []string) error
+	MirrorReadState(ctx context.Context, roomID string, userID int, since time.Time) error
+}
+
+// DualWriteRoomRepository escribe en el primario (fuente de verdad) y refleja cada mutación
+// en el secundario mediante el RoomMirror. Todas las lecturas, el outbox y los usuarios
+// salen del primario. Con verify, cada lectura se repite en segundo plano contra el
+// secundario y las diferencias se registran en el log con el prefijo [dual-verify].
+type DualWriteRoomRepository struct {
+	RoomsRepository
+	secondary RoomsRepository
+	mirror    RoomMirror
+	verify    bool
+	inFlight  chan struct{}
+}
+
+func NewDualWriteRoomRepository(primary RoomsRepository, secondary RoomsRepository, mirror RoomMirror, verify bool) RoomsRepository {
+	return &DualWriteRoomRepository{
+		RoomsRepository: primary,
+		secondary:       secondary,
+		mirror:          mirror,
+		verify:          verify,
+		inFlight:        make(chan struct{}, dualWriteVerifyInFlight),
+	}
+}
+
+// mirrorWrite ejecuta el reflejo después de una escritura exitosa en el primario. Un fallo
+// no se propaga: el primario ya confirmó y la sala se puede volver a copiar con el backfill.
+func (r *DualWriteRoomRepository) mirrorWrite(op string, key string, fn func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dualWriteMirrorTimeout)
+	defer cancel()
+	if err := fn(ctx); err != nil {
+		fmt.Printf("[dual-write] Error al reflejar %s (%s) en el store secundario: %v\n", op, key, err)
+	}
+}
+
+// verifyRead compara en segundo plano la lectura del primario con la del secundario. Si ya
+// hay demasiadas verificaciones en curso la lectura se omite para no frenar las peticiones.
+func (r *DualWriteRoomRepository) verifyRead(op string, key string, compare func(ctx context.Context) ([]string, error)) {
+	if !r.verify {
+		return
+	}
+	select {
+	case r.inFlight <- struct{}{}:
+	default:
+		return
+	}
+
+	go func() {
+		defer func() { <-r.inFlight }()
+		ctx, cancel := context.WithTimeout(context.Background(), dualWriteVerifyTimeout)
+		defer cancel()
+
+		diffs, err := compare(ctx)
+		if err != nil {
+			fmt.Printf("[dual-verify] %s %s: error al leer el store secundario: %v\n", op, key, err)
+			return
+		}
+		for _, diff := range diffs {
+			fmt.Printf("[dual-verify] %s %s: %s\n", op, key, diff)
+		}
+	}()
+}
+
+// --- Escrituras ---
+
+func (r *DualWriteRoomRepository) CreateRoom(ctx context.Context, userId int, req *chatv1.CreateRoomRequest) (*chatv1.Room, error) {
+	room, err := r.RoomsRepository.CreateRoom(ctx, userId, req)
+	if err != nil || room == nil {
+		return room, err
+	}
+	r.mirrorWrite("CreateRoom", room.Id, func(ctx context.Context) error { return r.mirror.MirrorRoom(ctx, room.Id) })
+	return room, nil
+}
+
+func (r *DualWriteRoomRepository) LeaveRoom(ctx context.Context, userId int, roomId string, participants []int32, leaveAll bool) ([]User, error) {
+	users, err := r.RoomsRepository.LeaveRoom(ctx, userId, roomId, participants, leaveAll)
+	if err != nil {
+		return nil, err
+	}
+	r.mirrorWrite("LeaveRoom", roomId, func(ctx context.Context) error { return r.mirror.MirrorRoom(ctx, roomId) })
+	return users, nil
+}
+
+func (r *DualWriteRoomRepository) DeleteRoom(ctx context.Context, userId int, roomId string, partner *int) error {
+	if err := r.RoomsRepository.DeleteRoom(ctx, userId, roomId, partner); err != nil {
+		return err
+	}
+	r.mirrorWrite("DeleteRoom", roomId, func(ctx context.Context) error { return r.mirror.MirrorRoom(ctx, roomId) })
+	return nil
+}
+
+func (r *DualWriteRoomRepository) PinRoom(ctx context.Context, userId int, roomId string, pin bool) error {
+	if err := r.RoomsRepository.PinRoom(ctx, userId, roomId, pin); err != nil {
+		return err
+	}
+	r.mirrorWrite("PinRoom", roomId, func(ctx context.Context) error { return r.mirror.MirrorMembership(ctx, roomId, userId) })
+	return nil
+}
+
+func (r *DualWriteRoomRepository) MuteRoom(ctx context.Context, userId int, roomId string, mute bool) error {
+	if err := r.RoomsRepository.MuteRoom(ctx, userId, roomId, mute); err != nil {
+		return err
+	}
+	r.mirrorWrite("MuteRoom", roomId, func(ctx context.Context) error { return r.mirror.MirrorMembership(ctx, roomId, userId) })
+	return nil
+}
+
+func (r *DualWriteRoomRepository) BlockUser(ctx context.Context, userId int, roomId string, block bool, partner *int) error {
+	if err := r.RoomsRepository.BlockUser(ctx, userId, roomId, block, partner); err != nil {
+		return err
+	}
+	r.mirrorWrite("BlockUser", roomId, func(ctx context.Context) error { return r.mirror.MirrorRoom(ctx, roomId) })
+	return nil
+}
+
+func (r *DualWriteRoomRepository) UpdateRoom(ctx context.Context, userId int, roomId string, req *chatv1.UpdateRoomRequest) error {
+	if err := r.RoomsRepository.UpdateRoom(ctx, userId, roomId, req); err != nil {
+		return err
+	}
+	r.mirrorWrite("UpdateRoom", roomId, func(ctx context.Context) error { return r.mirror.MirrorRoom(ctx, roomId) })
+	return nil
+}
+
+func (r *DualWriteRoomRepository) AddParticipantToRoom(ctx context.Context, userId int, roomId string, participants []int) ([]User, error) {
+	users, err := r.RoomsRepository.AddParticipantToRoom(ctx, userId, roomId, participants)
+	if err != nil {
+		return nil, err
+	}
+	r.mirrorWrite("AddParticipantToRoom", roomId, func(ctx context.Context) error { return r.mirror.MirrorRoom(ctx, roomId) })
+	return users, nil
+}
+
+func (r *DualWriteRoomRepository) UpdateParticipantRoom(ctx context.Context, userId int, req *chatv1.UpdateParticipantRoomRequest) error {
+	if err := r.RoomsRepository.UpdateParticipantRoom(ctx, userId, req); err != nil {
+		return err
+	}
+	r.mirrorWrite("UpdateParticipantRoom", req.Id, func(ctx context.Context) error { return r.mirror.MirrorRoom(ctx, req.Id) })
+	return nil
+}
+
+func (r *DualWriteRoomRepository) SaveMessage(ctx context.Context, userId int, req *chatv1.SendMessageRequest, room *chatv1.Room, contentDecrypted *string) (*chatv1.MessageData, error) {
+	msg, err := r.RoomsRepository.SaveMessage(ctx, userId, req, room, contentDecrypted)
+	if err != nil || msg == nil {
+		return msg, err
+	}
+	r.mirrorWrite("SaveMessage", msg.RoomId, func(ctx context.Context) error { return r.mirror.MirrorNewMessage(ctx, msg.Id) })
+	return msg, nil
+}
+
+func (r *DualWriteRoomRepository) UpdateMessage(ctx context.Context, userId int, req *chatv1.EditMessageRequest, contentDecrypted *string) error {
+	if err := r.RoomsRepository.UpdateMessage(ctx, userId, req, contentDecrypted); err != nil {
+		return err
+	}
+	messageId := req.MessageId
+	r.mirrorWrite("UpdateMessage", messageId, func(ctx context.Context) error { return r.mirror.MirrorMessages(ctx, []string{messageId}) })
+	return nil
+}
+
+func (r *DualWriteRoomRepository) DeleteMessage(ctx context.Context, userId int, messageId []string) error {
+	if err := r.RoomsRepository.DeleteMessage(ctx, userId, messageId); err != nil {
+		return err
+	}
+	r.mirrorWrite("DeleteMessage", fmt.Sprint(messageId), func(ctx context.Context) error { return r.mirror.MirrorMessages(ctx, messageId) })
+	return nil
+}
+
+// RotateRoomKey rota en el primario y copia la sala con su historial de claves; el evento de
+// outbox sale solo del primario.
+func (r *DualWriteRoomRepository) RotateRoomKey(ctx context.Context, userId int, roomId string) (int32, error) {
+	version, err := r.RoomsRepository.RotateRoomKey(ctx, userId, roomId)
+	if err != nil {
+		return 0, err
+	}
+	r.mirrorWrite("RotateRoomKey", roomId, func(ctx context.Context) error { return r.mirror.MirrorRoom(ctx, roomId) })
+	return version, nil
+}
+
+// RegisterDeviceKey registra la clave en ambos stores: las claves son del usuario y no de una
+// sala, así que MirrorRoom no las copia.
+func (r *DualWriteRoomRepository) RegisterDeviceKey(ctx context.Context, userId int, deviceId string, publicKey string) error {
+	if err := r.RoomsRepository.RegisterDeviceKey(ctx, userId, deviceId, publicKey); err != nil {
+		return err
+	}
+	r.mirrorWrite("RegisterDeviceKey", deviceKeyID(userId, deviceId), func(ctx context.Context) error {
+		return r.secondary.RegisterDeviceKey(ctx, userId, deviceId, publicKey)
+	})
+	return nil
+}
+
+// RotateE2ERoomKey y ShareRoomKey copian la sala con sus claves envueltas; el evento de
+// outbox sale solo del primario.
+func (r *DualWriteRoomRepository) RotateE2ERoomKey(ctx context.Context, userId int, roomId string, deviceId string, keys []*chatv1.WrappedRoomKey) (int32, error) {
+	version, err := r.RoomsRepository.RotateE2ERoomKey(ctx, userId, roomId, deviceId, keys)
+	if err != nil {
+		return 0, err
+	}
+	r.mirrorWrite("RotateE2ERoomKey", roomId, func(ctx context.Context) error { return r.mirror.MirrorRoom(ctx, roomId) })
+	return version, nil
+}
+
+func (r *DualWriteRoomRepository) ShareRoomKey(ctx context.Context, userId int, roomId string, version int32, deviceId string, keys []*chatv1.WrappedRoomKey) error {
+	if err := r.RoomsRepository.ShareRoomKey(ctx, userId, roomId, version, deviceId, keys); err != nil {
+		return err
+	}
+	r.mirrorWrite("ShareRoomKey", roomId, func(ctx context.Context) error { return r.mirror.MirrorRoom(ctx, roomId) })
+	return nil
+}
+
+// PurgeExpiredMessages purga en el primario y copia al secundario los tombstones y la sala,
+// con su history_purged_before y el último mensaje de cada miembro. El evento de outbox
+// sale solo del primario.
+func (r *DualWriteRoomRepository) PurgeExpiredMessages(ctx context.Context, defaultRetentionDays int, now time.Time, batchSize int) ([]RetentionPurge, error) {
+	purges, err := r.RoomsRepository.PurgeExpiredMessages(ctx, defaultRetentionDays, now, batchSize)
+	for _, purge := range purges {
+		r.mirrorWrite("PurgeExpiredMessages", purge.RoomID, func(ctx context.Context) error {
+			if err := r.mirror.MirrorMessages(ctx, purge.MessageIDs); err != nil {
+				return err
+			}
+			return r.mirror.MirrorRoom(ctx, purge.RoomID)
+		})
+	}
+	return purges, err
+}
+
+// EraseUserData borra en el primario y repite el borrado en el secundario: copiar desde
+// el primario no eliminaría lo que el secundario tiene del usuario. A diferencia de
+// mirrorWrite, un fallo en el secundario se devuelve para que el borrado se reintente
+// (es idempotente).
+func (r *DualWriteRoomRepository) EraseUserData(ctx context.Context, userId int) (*UserErasureReport, error) {
+	report, err := r.RoomsRepository.EraseUserData(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.secondary.EraseUserData(ctx, userId); err != nil {
+		return report, fmt.Errorf("secondary: %w", err)
+	}
+	return report, nil
+}
+
+// VerifyUserErasure revisa ambos stores; los rastros del secundario llevan el prefijo
+// "secondary".
+func (r *DualWriteRoomRepository) VerifyUserErasure(ctx context.Context, userId int, roomIds []string) ([]string, error) {
+	remaining, err := r.RoomsRepository.VerifyUserErasure(ctx, userId, roomIds)
+	if err != nil {
+		return nil, err
+	}
+	secondary, err := r.secondary.VerifyUserErasure(ctx, userId, roomIds)
+	if err != nil {
+		return nil, fmt.Errorf("secondary: %w", err)
+	}
+	for _, trace := range secondary {
+		remaining = append(remaining, "secondary "+trace)
+	}
+	return remaining, nil
+}
+
+func (r *DualWriteRoomRepository) ReactToMessage(ctx context.Context, userId int, messageId string, reaction string) error {
+	if err := r.RoomsRepository.ReactToMessage(ctx, userId, messageId, reaction); err != nil {
+		return err
+	}
+	r.mirrorWrite("ReactToMessage", messageId, func(ctx context.Context) error { return r.mirror.MirrorMessages(ctx, []string{messageId}) })
+	return nil
+}
+
+func (r *DualWriteRoomRepository) MarkMessagesAsRead(ctx context.Context, userId int, roomId string, messageIds []string, since string) (int32, error) {
+	startedAt := time.Now()
+	count, err := r.RoomsRepository.MarkMessagesAsRead(ctx, userId, roomId, messageIds, since)
+	if err != nil {
+		return count, err
+	}
+	r.mirrorWrite("MarkMessagesAsRead", roomId, func(ctx context.Context) error {
+		return r.mirror.MirrorReadState(ctx, roomId, userId, startedAt.Add(-dualWriteReadStateSkew))
+	})
+	return count, nil
+}
+
+func (r *DualWriteRoomRepository) CreateMessageMetaForParticipants(ctx context.Context, roomID string, messageID string, senderID int) error {
+	if err := r.RoomsRepository.CreateMessageMetaForParticipants(ctx, roomID, messageID, senderID); err != nil {
+		return err
+	}
+	r.mirrorWrite("CreateMessageMetaForParticipants", roomID, func(ctx context.Context) error { return r.mirror.MirrorMessages(ctx, []string{messageID}) })
+	return nil
+}
+
+// --- Lecturas (verificadas contra el secundario) ---
+
+func (r *DualWriteRoomRepository) GetRoom(ctx context.Context, userId int, roomId string, allData bool, cache bool) (*chatv1.Room, error) {
+	room, err := r.RoomsRepository.GetRoom(ctx, userId, roomId, allData, cache)
+	if err == nil {
+		// Ambos stores comparten las claves de la caché de salas: el secundario se lee sin caché
+		r.verifyRead("GetRoom", fmt.Sprintf("room=%s user=%d", roomId, userId), func(ctx context.Context) ([]string, error) {
+			other, err := r.secondary.GetRoom(ctx, userId, roomId, allData, false)
+			if err != nil {
+				return nil, err
+			}
+			return diffRooms(room, other), nil
+		})
+	}
+	return room, err
+}
+
+func (r *DualWriteRoomRepository) GetRoomList(ctx context.Context, userId int, pagination *chatv1.GetRoomsRequest) ([]*chatv1.Room, *chatv1.PaginationMeta, error) {
+	rooms, meta, err := r.RoomsRepository.GetRoomList(ctx, userId, pagination)
+	// Los cursores son propios de cada store, así que las páginas con cursor no se comparan
+	if err == nil && pagination.GetCursor() == "" {
+		r.verifyRead("GetRoomList", fmt.Sprintf("user=%d", userId), func(ctx context.Context) ([]string, error) {
+			other, _, err := r.secondary.GetRoomList(ctx, userId, pagination)
+			if err != nil {
+				return nil, err
+			}
+			return diffRoomLists(rooms, other), nil
+		})
+	}
+	return rooms, meta, err
+}
+
+func (r *DualWriteRoomRepository) GetRoomListDeleted(ctx context.Context, userId int, since string) ([]string, error) {
+	roomIDs, err := r.RoomsRepository.GetRoomListDeleted(ctx, userId, since)
+	if err == nil {
+		r.verifyRead("GetRoomListDeleted", fmt.Sprintf("user=%d", userId), func(ctx context.Context) ([]string, error) {
+			other, err := r.secondary.GetRoomListDeleted(ctx, userId, since)
+			if err != nil {
+				return nil, err
+			}
+			return diffIDSets("salas eliminadas", roomIDs, other), nil
+		})
+	}
+	return roomIDs, err
+}
+
+func (r *DualWriteRoomRepository) GetRoomParticipants(ctx context.Context, pagination *chatv1.GetRoomParticipantsRequest) ([]*chatv1.RoomParticipant, *chatv1.PaginationMeta, error) {
+	participants, meta, err := r.RoomsRepository.GetRoomParticipants(ctx, pagination)
+	if err == nil && pagination.Cursor == "" {
+		r.verifyRead("GetRoomParticipants", "room="+pagination.Id, func(ctx context.Context) ([]string, error) {
+			other, _, err := r.secondary.GetRoomParticipants(ctx, pagination)
+			if err != nil {
+				return nil, err
+			}
+			return diffParticipants(participants, other), nil
+		})
+	}
+	return participants, meta, err
+}
+
+func (r *DualWriteRoomRepository) GetMessage(ctx context.Context, userId int, messageId string) (*chatv1.MessageData, error) {
+	msg, err := r.RoomsRepository.GetMessage(ctx, userId, messageId)
+	if err == nil {
+		r.verifyRead("GetMessage", "message="+messageId, func(ctx context.Context) ([]string, error) {
+			other, err := r.secondary.GetMessage(ctx, userId, messageId)
+			if err != nil {
+				return nil, err
+			}
+			return diffMessages(msg, other), nil
+		})
+	}
+	return msg, err
+}
+
+func (r *DualWriteRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int, req *chatv1.GetMessageHistoryRequest) ([]*chatv1.MessageData, *chatv1.PaginationMeta, error) {
+	messages, meta, err := r.RoomsRepository.GetMessagesFromRoom(ctx, userId, req)
+	// Solo el historial por seq es comparable: la paginación por id depende del formato de id de cada store
+	if err == nil && req.Id != "" && (req.AfterSeq != nil || req.BeforeSeq != nil) && req.Cursor == "" {
+		r.verifyRead("GetMessagesFromRoom", fmt.Sprintf("room=%s user=%d", req.Id, userId), func(ctx context.Context) ([]string, error) {
+			other, _, err := r.secondary.GetMessagesFromRoom(ctx, userId, req)
+			if err != nil {
+				return nil, err
+			}
+			return diffMessageLists(messages, other), nil
+		})
+	}
+	return messages, meta, err
+}
+
+// --- Comparaciones ---
+
+func diffRooms(primary, secondary *chatv1.Room) []string {
+	if primary == nil || secondary == nil {
+		if primary != secondary {
+			return []string{fmt.Sprintf("sala presente en primario=%t secundario=%t", primary != nil, secondary != nil)}
+		}
+		return nil
+	}
+
+	var diffs []string
+	check := func(field string, a, b any) {
+		if fmt.Sprint(a) != fmt.Sprint(b) {
+			diffs = append(diffs, fmt.Sprintf("%s: primario=%v secundario=%v", field, a, b))
+		}
+	}
+	check("type", primary.Type, secondary.Type)
+	check("name", primary.GetName(), secondary.GetName())
+	check("photo_url", primary.GetPhotoUrl(), secondary.GetPhotoUrl())
+	check("encryption_data", primary.EncryptionData, secondary.EncryptionData)
+	check("key_version", primary.KeyVersion, secondary.KeyVersion)
+	check("e2e", primary.E2E, secondary.E2E)
+	check("unread_count", primary.UnreadCount, secondary.UnreadCount)
+	check("is_pinned", primary.IsPinned, secondary.IsPinned)
+	check("is_muted", primary.IsMuted, secondary.IsMuted)
+	check("role", primary.Role, secondary.Role)
+	check("retention_days", primary.GetRetentionDays(), secondary.GetRetentionDays())
+	check("last_message.content", primary.GetLastMessage().GetContent(), secondary.GetLastMessage().GetContent())
+	if len(primary.Participants) > 0 || len(secondary.Participants) > 0 {
+		diffs = append(diffs, diffParticipants(primary.Participants, secondary.Participants)...)
+	}
+	return diffs
+}
+
+func diffRoomLists(primary, secondary []*chatv1.Room) []string {
+	secondaryByID := make(map[string]*chatv1.Room, len(secondary))
+	for _, room := range secondary {
+		secondaryByID[room.Id] = room
+	}
+
+	var primaryIDs, secondaryIDs []string
+	for _, room := range secondary {
+		secondaryIDs = append(secondaryIDs, room.Id)
+	}
+	diffs := []string{}
+	for _, room := range primary {
+		primaryIDs = append(primaryIDs, room.Id)
+		if other, ok := secondaryByID[room.Id]; ok {
+			for _, diff := range diffRooms(room, other) {
+				diffs = append(diffs, "sala "+room.Id+": "+diff)
+			}
+		}
+	}
+	return append(diffIDSets("salas", primaryIDs, secondaryIDs), diffs...)
+}
+
+func diffParticipants(primary, secondary []*chatv1.RoomParticipant) []string {
+	roles := func(participants []*chatv1.RoomParticipant) []string {
+		result := make([]string, 0, len(participants))
+		for _, p := range participants {
+			result = append(result, fmt.Sprintf("%d:%s", p.Id, p.Role))
+		}
+		return result
+	}
+	return diffIDSets("participantes", roles(primary), roles(secondary))
+}
+
+func diffIDSets(label string, primary, secondary []string) []string {
+	a := slices.Clone(primary)
+	b := slices.Clone(secondary)
+	sort.Strings(a)
+	sort.Strings(b)
+	if slices.Equal(a, b) {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: primario=%v secundario=%v", label, a, b)}
+}
+
+// diffMessages compara los campos que ambos stores guardan igual. Los ids de mensajes
+// anteriores a la migración cambian al copiarse a Scylla, así que se comparan normalizados.
+func diffMessages(primary, secondary *chatv1.MessageData) []string {
+	if primary == nil || secondary == nil {
+		if primary != secondary {
+			return []string{fmt.Sprintf("mensaje presente en primario=%t secundario=%t", primary != nil, secondary != nil)}
+		}
+		return nil
+	}
+
+	var diffs []string
+	check := func(field string, a, b any) {
+		if fmt.Sprint(a) != fmt.Sprint(b) {
+			diffs = append(diffs, fmt.Sprintf("%s: primario=%v secundario=%v", field, a, b))
+		}
+	}
+	check("id", normalizedMessageID(primary.Id, primary.CreatedAt), normalizedMessageID(secondary.Id, secondary.CreatedAt))
+	check("room_id", primary.RoomId, secondary.RoomId)
+	check("seq", primary.Seq, secondary.Seq)
+	check("key_version", primary.KeyVersion, secondary.KeyVersion)
+	check("sender_id", primary.SenderId, secondary.SenderId)
+	check("type", primary.Type, secondary.Type)
+	check("content", primary.Content, secondary.Content)
+	check("file", primary.GetFile(), secondary.GetFile())
+	check("edited", primary.Edited, secondary.Edited)
+	check("is_deleted", primary.IsDeleted, secondary.IsDeleted)
+	check("reply", normalizedMessageID(primary.GetReply().GetId(), primary.GetReply().GetCreatedAt()), normalizedMessageID(secondary.GetReply().GetId(), secondary.GetReply().GetCreatedAt()))
+	check("mentions", len(primary.Mentions), len(secondary.Mentions))
+	check("reactions", len(primary.Reactions), len(secondary.Reactions))
+	return diffs
+}
+
+func diffMessageLists(primary, secondary []*chatv1.MessageData) []string {
+	secondaryBySeq := make(map[int64]*chatv1.MessageData, len(secondary))
+	for _, msg := range secondary {
+		secondaryBySeq[msg.Seq] = msg
+	}
+
+	var diffs []string
+	if len(primary) != len(secondary) {
+		diffs = append(diffs, fmt.Sprintf("cantidad de mensajes: primario=%d secundario=%d", len(primary), len(secondary)))
+	}
+	for _, msg := range primary {
+		other, ok := secondaryBySeq[msg.Seq]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("seq %d: falta en el secundario", msg.Seq))
+			continue
+		}
+		for _, diff := range diffMessages(msg, other) {
+			diffs = append(diffs, fmt.Sprintf("seq %d: %s", msg.Seq, diff))
+		}
+	}
+	return diffs
+}
+
+// normalizedMessageID devuelve el id con el que el mensaje quedó (o quedará) en Scylla.
+func normalizedMessageID(id string, createdAt string) string {
+	if id == "" {
+		return ""
+	}
+	created, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return id
+	}
+	messageUUID, err := scyllaMessageUUID(id, created)
+	if err != nil {
+		return id
+	}
+	return messageUUID.String()
+}
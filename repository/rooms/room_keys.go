@@ -0,0 +1,50 @@
+package roomsrepository
+
+import (
+	"errors"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+)
+
+// Versiones de la clave de cifrado de las salas.
+//
+// La clave actual es el encryption_data de la sala, con su número en key_version (las salas
+// anteriores a la rotación tienen la versión 1): en Postgres son columnas de room y en Scylla
+// viven en room_key_state, que solo se escribe con LWT. RotateRoomKey guarda la clave actual en
+// el historial de la sala (room_key en Postgres, room_keys_by_room en Scylla), genera una nueva
+// y aumenta la versión. Cada mensaje guarda la versión con la que el cliente lo cifró, así que
+// el historial se lee con GetRoomKeys aunque la clave haya cambiado. La rotación escribe en el
+// outbox un evento is_room_updated para que los clientes pidan la clave nueva.
+
+// OutboxRoomKeyRotated es el evento de outbox de una rotación de clave.
+const OutboxRoomKeyRotated = "room_key_rotated"
+
+// ErrUnknownKeyVersion indica una versión de clave que la sala no tiene.
+var ErrUnknownKeyVersion = errors.New("unknown room key version")
+
+// newRoomKeyRotatedEvent construye el evento de outbox de una rotación.
+func newRoomKeyRotatedEvent(roomID string, userID int) OutboxEvent {
+	return newOutboxEvent(roomID, userID, OutboxRoomKeyRotated, "", &chatv1.MessageEvent{
+		RoomId: roomID,
+		Event:  &chatv1.MessageEvent_IsRoomUpdated{IsRoomUpdated: true},
+	})
+}
+
+// roomKeyVersion normaliza la versión guardada: las salas sin versión están en la 1.
+func roomKeyVersion(version int32) int32 {
+	if version <= 0 {
+		return 1
+	}
+	return version
+}
+
+// RoomKeyFor devuelve el encryption_data de la versión en keys (como las devuelve
+// GetRoomKeys).
+func RoomKeyFor(keys []*chatv1.RoomKey, version int32) (string, error) {
+	for _, key := range keys {
+		if key.Version == version {
+			return key.EncryptionData, nil
+		}
+	}
+	return "", ErrUnknownKeyVersion
+}
@@ -0,0 +1,124 @@
+package roomsrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
+	dbpq "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+)
+
+// RotateRoomKey retira la clave actual a room_key y deja una nueva en la sala. El FOR UPDATE
+// sobre la sala serializa rotaciones concurrentes: cada una retira una versión distinta.
+func (r *SQLRoomRepository) RotateRoomKey(ctx context.Context, userId int, roomId string) (int32, error) {
+	encryptionData, err := utils.GenerateKeyEncript()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var current sql.NullString
+	var version int32
+	var e2e bool
+	err = dbpq.QueryBuilder().
+		Select("encription_data", "key_version", "e2e").
+		From("room").
+		Where(sq.Eq{"id": roomId}).
+		Where(sq.Eq{"deleted_at": nil}).
+		Suffix("FOR UPDATE").
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&current, &version, &e2e)
+	if err != nil {
+		return 0, err
+	}
+	if e2e {
+		return 0, ErrE2ERoom
+	}
+	version = roomKeyVersion(version)
+
+	_, err = dbpq.QueryBuilder().
+		Insert("public.room_key").
+		Columns("room_id", "version", "encryption_data", "retired_at").
+		Values(roomId, version, current.String, sq.Expr("NOW()")).
+		Suffix("ON CONFLICT (room_id, version) DO NOTHING").
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retire room key: %w", err)
+	}
+
+	_, err = dbpq.QueryBuilder().
+		Update("room").
+		Set("encription_data", encryptionData).
+		Set("key_version", version+1).
+		Set("updated_at", sq.Expr("NOW()")).
+		Where(sq.Eq{"id": roomId}).
+		RunWith(tx).
+		ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = insertOutboxEvents(ctx, tx, newRoomKeyRotatedEvent(roomId, userId)); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	DeleteRoomCacheByRoomID(ctx, roomId)
+
+	return version + 1, nil
+}
+
+func (r *SQLRoomRepository) GetRoomKeys(ctx context.Context, roomId string) ([]*chatv1.RoomKey, error) {
+	var current sql.NullString
+	var version int32
+	err := dbpq.QueryBuilder().
+		Select("encription_data", "key_version").
+		From("room").
+		Where(sq.Eq{"id": roomId}).
+		Where(sq.Eq{"deleted_at": nil}).
+		RunWith(r.db).
+		QueryRowContext(ctx).
+		Scan(&current, &version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys := []*chatv1.RoomKey{{Version: roomKeyVersion(version), EncryptionData: current.String}}
+
+	rows, err := dbpq.QueryBuilder().
+		Select("version", "encryption_data", "retired_at").
+		From("public.room_key").
+		Where(sq.Eq{"room_id": roomId}).
+		OrderBy("version DESC").
+		RunWith(r.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key := &chatv1.RoomKey{}
+		if err := rows.Scan(&key.Version, &key.EncryptionData, &key.RetiredAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
@@ -0,0 +1,198 @@
+package roomsrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
+	"github.com/scylladb-solutions/gocql/v2"
+)
+
+// roomKeyStateAttempts limita los reintentos de las escrituras en room_key_state cuando otra
+// escritura gana el LWT.
+const roomKeyStateAttempts = 5
+
+// roomKeyState es la clave actual de una sala. Vive en room_key_state, que solo se escribe con
+// LWT: room_details se escribe sin él (UpdateRoom, retención, backfill) y mezclar ambos en una
+// partición no es seguro.
+type roomKeyState struct {
+	encryptionData *string // nil en salas e2e
+	version        int32
+}
+
+func (s roomKeyState) key() string {
+	if s.encryptionData == nil {
+		return ""
+	}
+	return *s.encryptionData
+}
+
+// loadRoomKeyState lee la clave actual de la sala. Las salas anteriores a room_key_state se
+// copian la primera vez desde room_details con INSERT ... IF NOT EXISTS; si otra escritura gana
+// el LWT se vuelve a leer. Devuelve gocql.ErrNotFound si la sala no existe.
+func loadRoomKeyState(ctx context.Context, session *gocql.Session, roomUUID gocql.UUID) (roomKeyState, error) {
+	for attempt := 0; attempt < roomKeyStateAttempts; attempt++ {
+		var state roomKeyState
+		var version int
+		err := session.Query(`SELECT encryption_data, key_version FROM room_key_state WHERE room_id = ?`, roomUUID).
+			WithContext(ctx).Scan(&state.encryptionData, &version)
+		if err == nil {
+			state.version = roomKeyVersion(int32(version))
+			return state, nil
+		}
+		if err != gocql.ErrNotFound {
+			return roomKeyState{}, err
+		}
+
+		// Un key_version nulo (salas anteriores a la rotación) es la versión 1
+		var stored *int
+		err = session.Query(`SELECT encryption_data, key_version FROM room_details WHERE room_id = ?`, roomUUID).
+			WithContext(ctx).Scan(&state.encryptionData, &stored)
+		if err != nil {
+			return roomKeyState{}, err
+		}
+		state.version = 1
+		if stored != nil {
+			state.version = roomKeyVersion(int32(*stored))
+		}
+		applied, err := session.Query(`INSERT INTO room_key_state (room_id, encryption_data, key_version) VALUES (?, ?, ?) IF NOT EXISTS`,
+			roomUUID, state.encryptionData, state.version).WithContext(ctx).MapScanCAS(map[string]interface{}{})
+		if err != nil {
+			return roomKeyState{}, err
+		}
+		if applied {
+			return state, nil
+		}
+	}
+	return roomKeyState{}, fmt.Errorf("no se pudo leer la clave de la sala %s tras %d intentos", roomUUID, roomKeyStateAttempts)
+}
+
+// putRoomKeyState reemplaza la clave actual de la sala (o la crea) con LWT. Lo usa el backfill,
+// que copia el estado de Postgres tal cual.
+func putRoomKeyState(ctx context.Context, session *gocql.Session, roomUUID gocql.UUID, state roomKeyState) error {
+	for attempt := 0; attempt < roomKeyStateAttempts; attempt++ {
+		applied, err := session.Query(`UPDATE room_key_state SET encryption_data = ?, key_version = ? WHERE room_id = ? IF EXISTS`,
+			state.encryptionData, state.version, roomUUID).WithContext(ctx).MapScanCAS(map[string]interface{}{})
+		if err != nil || applied {
+			return err
+		}
+		applied, err = session.Query(`INSERT INTO room_key_state (room_id, encryption_data, key_version) VALUES (?, ?, ?) IF NOT EXISTS`,
+			roomUUID, state.encryptionData, state.version).WithContext(ctx).MapScanCAS(map[string]interface{}{})
+		if err != nil || applied {
+			return err
+		}
+	}
+	return fmt.Errorf("no se pudo escribir la clave de la sala %s tras %d intentos", roomUUID, roomKeyStateAttempts)
+}
+
+// deleteRoomKeyState borra la clave actual de la sala. Se llama después de borrar room_details
+// para que loadRoomKeyState no vuelva a copiarla.
+func deleteRoomKeyState(ctx context.Context, session *gocql.Session, roomUUID gocql.UUID) error {
+	_, err := session.Query(`DELETE FROM room_key_state WHERE room_id = ? IF EXISTS`, roomUUID).
+		WithContext(ctx).MapScanCAS(map[string]interface{}{})
+	return err
+}
+
+// RotateRoomKey guarda la clave actual en room_keys_by_room y cambia la de room_key_state con
+// LWT sobre key_version. La fila del historial se escribe antes (es idempotente: dos rotaciones
+// concurrentes retiran la misma clave) para que ningún mensaje quede sin su clave. Si otra
+// rotación gana el LWT se devuelve la versión que dejó esa rotación.
+func (r *ScyllaRoomRepository) RotateRoomKey(ctx context.Context, userId int, roomId string) (int32, error) {
+	roomUUID, err := gocql.ParseUUID(roomId)
+	if err != nil {
+		return 0, fmt.Errorf("ID de sala inválido: %w", err)
+	}
+
+	var e2e bool
+	err = r.session.Query(`SELECT e2e FROM room_details WHERE room_id = ?`, roomUUID).
+		WithContext(ctx).Scan(&e2e)
+	if err != nil {
+		return 0, fmt.Errorf("error al leer la sala: %w", err)
+	}
+	if e2e {
+		return 0, ErrE2ERoom
+	}
+	state, err := loadRoomKeyState(ctx, r.session, roomUUID)
+	if err != nil {
+		return 0, fmt.Errorf("error al leer la clave de la sala: %w", err)
+	}
+	version := state.version
+
+	encryptionData, err := utils.GenerateKeyEncript()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	err = r.session.Query(`INSERT INTO room_keys_by_room (room_id, version, encryption_data, retired_at) VALUES (?, ?, ?, ?)`, roomUUID, version, state.key(), now).
+		WithContext(ctx).Exec()
+	if err != nil {
+		return 0, fmt.Errorf("error al retirar la clave de la sala: %w", err)
+	}
+
+	var observed *int
+	applied, err := r.session.Query(`UPDATE room_key_state SET encryption_data = ?, key_version = ? WHERE room_id = ? IF key_version = ?`,
+		encryptionData, version+1, roomUUID, version).WithContext(ctx).ScanCAS(&observed)
+	if err != nil {
+		return 0, fmt.Errorf("error al rotar la clave de la sala: %w", err)
+	}
+	if !applied {
+		if observed == nil {
+			return 0, fmt.Errorf("error al rotar la clave de la sala: %w", gocql.ErrNotFound)
+		}
+		return roomKeyVersion(int32(*observed)), nil
+	}
+
+	err = r.session.Query(`UPDATE room_details SET updated_at = ? WHERE room_id = ?`, now, roomUUID).WithContext(ctx).Exec()
+	if err != nil {
+		fmt.Printf("Error al actualizar updated_at de la sala %s: %v\n", roomId, err)
+	}
+
+	batch := r.session.Batch(gocql.LoggedBatch)
+	if err := addOutboxEvents(batch, newRoomKeyRotatedEvent(roomId, userId)); err != nil {
+		return 0, err
+	}
+	if err := r.session.ExecuteBatch(batch); err != nil {
+		return 0, fmt.Errorf("error al registrar la rotación en el outbox: %w", err)
+	}
+
+	DeleteRoomCacheByRoomID(ctx, roomId)
+
+	return version + 1, nil
+}
+
+func (r *ScyllaRoomRepository) GetRoomKeys(ctx context.Context, roomId string) ([]*chatv1.RoomKey, error) {
+	roomUUID, err := gocql.ParseUUID(roomId)
+	if err != nil {
+		return nil, fmt.Errorf("ID de sala inválido: %w", err)
+	}
+
+	state, err := loadRoomKeyState(ctx, r.session, roomUUID)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al leer la clave de la sala: %w", err)
+	}
+	keys := []*chatv1.RoomKey{{Version: state.version, EncryptionData: state.key()}}
+
+	iter := r.session.Query(`SELECT version, encryption_data, retired_at FROM room_keys_by_room WHERE room_id = ?`, roomUUID).
+		WithContext(ctx).Iter()
+	var retiredVersion int
+	var encryptionData string
+	var retiredAt time.Time
+	for iter.Scan(&retiredVersion, &encryptionData, &retiredAt) {
+		keys = append(keys, &chatv1.RoomKey{
+			Version:        int32(retiredVersion),
+			EncryptionData: encryptionData,
+			RetiredAt:      retiredAt.Format(time.RFC3339),
+		})
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error al leer el historial de claves: %w", err)
+	}
+
+	return keys, nil
+}
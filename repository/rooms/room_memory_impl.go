@@ -0,0 +1,2327 @@
+package roomsrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
+)
+
+// MemoryRoomRepository guarda salas, mensajes, usuarios y outbox en memoria con la misma
+// semántica que SQLRoomRepository (incluidas sus rarezas: por ejemplo DeleteRoom solo
+// borra salas p2p pero saca a los miembros de cualquier sala). Sirve para tests de
+// handlers y para desarrollo local sin Postgres, Scylla ni Redis; no usa la caché.
+type MemoryRoomRepository struct {
+	mu          sync.Mutex
+	generateKey func() (string, error)
+	lastTime    time.Time
+	users       map[int]User
+	rooms       map[string]*memoryRoom
+	members     map[string]map[int]*memoryMember // room → usuario
+	messages    map[string]*memoryMessage
+	metas       map[string]map[int]*memoryMeta // mensaje → usuario
+	tags        map[string][]memoryTag
+	reactions   map[string][]*memoryReaction
+	outbox      []*memoryOutboxEntry
+	outboxSeq   int64
+	deviceKeys  map[int]map[string]*memoryDeviceKey // usuario → dispositivo
+}
+
+// Los instantes nulos de SQL se representan con time.Time{}.
+type memoryRoom struct {
+	id, name, image, description, kind, encryptionData string
+	joinAllUser, sendMessage, addMember, editGroup     bool
+	createdAt, updatedAt, deletedAt                    time.Time
+	lastSeq                                            int64
+	retentionDays                                      *int
+	historyPurgedBefore                                time.Time
+	historyPurgedAfter                                 string // último mensaje de un lote de retención lleno
+	keyVersion                                         int32
+	retiredKeys                                        []memoryRoomKey // de la más antigua a la más reciente
+	e2e                                                bool
+	wrappedKeys                                        map[memoryWrappedKeyID]memoryWrappedKey
+}
+
+type memoryRoomKey struct {
+	version        int32
+	encryptionData string
+	retiredAt      time.Time
+}
+
+type memoryWrappedKeyID struct {
+	userID   int
+	deviceID string
+	version  int32
+}
+
+type memoryWrappedKey struct {
+	wrappedKey     string
+	senderID       int
+	senderDeviceID string
+}
+
+type memoryDeviceKey struct {
+	publicKey string
+	updatedAt time.Time
+}
+
+type memoryMember struct {
+	userID                          int
+	role                            string
+	muted, pinned, partnerBlocked   bool
+	createdAt, updatedAt, removedAt time.Time
+	deletedAt                       time.Time
+}
+
+type memoryMessage struct {
+	id, roomID                          string
+	senderID                            int
+	content, contentDecrypted, kind     string
+	status                              chatv1.MessageStatus
+	createdAt, updatedAt, deletedAt     time.Time
+	lifetime, locationName, origin      *string
+	locationLatitude, locationLongitude *float64
+	contactID                           *int
+	contactName, contactPhone, file     *string
+	edited, isDeleted                   bool
+	replyID, forwardID                  *string
+	forwardSenderID                     *int
+	event, senderMessageID              *string
+	seq                                 int64
+	keyVersion                          int32
+}
+
+type memoryMeta struct {
+	readAt                   time.Time
+	isDeleted, senderBlocked bool
+}
+
+type memoryTag struct {
+	userID int
+	tag    string
+}
+
+type memoryReaction struct {
+	userID                          int
+	reaction                        string
+	createdAt, updatedAt, deletedAt time.Time
+}
+
+type memoryOutboxEntry struct {
+	seq                   int64
+	event                 OutboxEvent
+	nextAttemptAt, sentAt time.Time
+	lastError             string
+}
+
+func NewMemoryRoomRepository(users []User) RoomsRepository {
+	r := &MemoryRoomRepository{
+		generateKey: utils.GenerateKeyEncript,
+		users:       make(map[int]User),
+		rooms:       make(map[string]*memoryRoom),
+		members:     make(map[string]map[int]*memoryMember),
+		messages:    make(map[string]*memoryMessage),
+		metas:       make(map[string]map[int]*memoryMeta),
+		tags:        make(map[string][]memoryTag),
+		reactions:   make(map[string][]*memoryReaction),
+		deviceKeys:  make(map[int]map[string]*memoryDeviceKey),
+	}
+	for _, user := range users {
+		r.AddUser(user)
+	}
+	return r
+}
+
+// AddUser registra (o reemplaza) un usuario; equivale a sembrar public."user".
+func (r *MemoryRoomRepository) AddUser(user User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = user
+}
+
+// SetKeyGenerator reemplaza utils.GenerateKeyEncript al crear salas, para poder usar el
+// repositorio sin chat.key/chat.iv configurados.
+func (r *MemoryRoomRepository) SetKeyGenerator(generateKey func() (string, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generateKey = generateKey
+}
+
+// now devuelve instantes estrictamente crecientes con la precisión de Postgres, para que
+// el orden por created_at sea el mismo que el de inserción.
+func (r *MemoryRoomRepository) now() time.Time {
+	t := time.Now().Truncate(time.Microsecond)
+	if !t.After(r.lastTime) {
+		t = r.lastTime.Add(time.Microsecond)
+	}
+	r.lastTime = t
+	return t
+}
+
+func formatMemoryTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// parseMemoryTime acepta los formatos que Postgres admite en los filtros since/before/after.
+func parseMemoryTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999", time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid input syntax for type timestamp: %q", s)
+}
+
+func memoryContains(value, search string) bool {
+	value, _ = removeAccents(strings.ToLower(value))
+	search, _ = removeAccents(strings.ToLower(search))
+	return strings.Contains(value, search)
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+func cloneFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	v := *f
+	return &v
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// paginate aplica OFFSET/LIMIT como las consultas SQL: solo si page y limit son positivos.
+func paginate[T any](items []T, page, limit uint32) []T {
+	if page == 0 || limit == 0 {
+		return items
+	}
+	start := int((page - 1) * limit)
+	if start >= len(items) {
+		return items[:0]
+	}
+	return items[start:min(start+int(limit), len(items))]
+}
+
+// memoryPage pagina una lista ya ordenada como las consultas SQL: con cursor devuelve los
+// elementos que van detrás de su clave (after) y si no aplica OFFSET. En ambos casos, con
+// limit, devuelve también el cursor de la página siguiente construido con key.
+func memoryPage[T any](items []T, list string, cursor *pageCursor, page, limit uint32, after func(T) bool, key func(T) []string) ([]T, string) {
+	if cursor != nil {
+		start := len(items)
+		for i, item := range items {
+			if after(item) {
+				start = i
+				break
+			}
+		}
+		items = items[start:]
+	} else if page > 0 && limit > 0 {
+		items = items[min(int((page-1)*limit), len(items)):]
+	} else {
+		return items, ""
+	}
+
+	if limit == 0 || len(items) <= int(limit) {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, encodeCursor(list, key(items[len(items)-1])...)
+}
+
+// memoryCursorTime interpreta una clave de fecha de un cursor del repositorio en memoria.
+func memoryCursorTime(key string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, key)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return t, nil
+}
+
+func memoryPaginationMeta(total, items int, page, limit uint32) *chatv1.PaginationMeta {
+	return &chatv1.PaginationMeta{
+		TotalItems:   uint32(total),
+		ItemCount:    uint32(items),
+		ItemsPerPage: limit,
+		TotalPages:   uint32(math.Ceil(float64(total) / float64(limit))),
+		CurrentPage:  page,
+	}
+}
+
+func (m *memoryMember) active() bool {
+	return m.removedAt.IsZero() && m.deletedAt.IsZero()
+}
+
+func (r *MemoryRoomRepository) activeMember(roomID string, userID int) *memoryMember {
+	member := r.members[roomID][userID]
+	if member == nil || !member.active() {
+		return nil
+	}
+	return member
+}
+
+// partnerOf devuelve el otro miembro activo de una p2p.
+func (r *MemoryRoomRepository) partnerOf(room *memoryRoom, userID int) (*memoryMember, *User) {
+	if room.kind != "p2p" {
+		return nil, nil
+	}
+	for id, member := range r.members[room.id] {
+		if id == userID || !member.active() {
+			continue
+		}
+		if user, ok := r.users[id]; ok {
+			return member, &user
+		}
+		return member, nil
+	}
+	return nil, nil
+}
+
+func (r *MemoryRoomRepository) lastMessage(room *memoryRoom, userID int) *chatv1.MessageData {
+	var last *memoryMessage
+	for _, msg := range r.messages {
+		if msg.roomID != room.id || !msg.deletedAt.IsZero() {
+			continue
+		}
+		meta := r.metas[msg.id][userID]
+		if meta == nil || meta.isDeleted || meta.senderBlocked {
+			continue
+		}
+		if last == nil || msg.createdAt.After(last.createdAt) {
+			last = msg
+		}
+	}
+	if last == nil {
+		return nil
+	}
+	sender := r.users[last.senderID]
+	return &chatv1.MessageData{
+		Id:          last.id,
+		Content:     last.content,
+		Type:        last.kind,
+		CreatedAt:   formatMemoryTime(last.createdAt),
+		SenderName:  sender.Name,
+		SenderPhone: sender.Phone,
+		Status:      last.status,
+		UpdatedAt:   formatMemoryTime(last.updatedAt),
+	}
+}
+
+func (r *MemoryRoomRepository) unreadCount(room *memoryRoom, userID int) int32 {
+	var count int32
+	for _, msg := range r.messages {
+		if msg.roomID != room.id || !msg.deletedAt.IsZero() {
+			continue
+		}
+		if meta := r.metas[msg.id][userID]; meta == nil || meta.isDeleted || meta.readAt.IsZero() {
+			count++
+		}
+	}
+	return count
+}
+
+// roomView arma la sala tal como la ve userId (GetRoom y GetRoomList).
+func (r *MemoryRoomRepository) roomView(room *memoryRoom, userID int, member *memoryMember, withPartnerMuted bool) *chatv1.Room {
+	item := &chatv1.Room{
+		Id:               room.id,
+		CreatedAt:        formatMemoryTime(room.createdAt),
+		UpdatedAt:        formatMemoryTime(room.updatedAt),
+		PhotoUrl:         room.image,
+		Name:             room.name,
+		Description:      room.description,
+		Type:             room.kind,
+		EncryptionData:   room.encryptionData,
+		KeyVersion:       roomKeyVersion(room.keyVersion),
+		E2E:              room.e2e,
+		JoinAllUser:      room.joinAllUser,
+		SendMessage:      room.sendMessage,
+		AddMember:        room.addMember,
+		EditGroup:        room.editGroup,
+		Role:             member.role,
+		IsPinned:         member.pinned,
+		IsMuted:          member.muted,
+		IsPartnerBlocked: member.partnerBlocked,
+		LastMessage:      r.lastMessage(room, userID),
+		UnreadCount:      r.unreadCount(room, userID),
+	}
+	if room.retentionDays != nil {
+		days := int32(*room.retentionDays)
+		item.RetentionDays = &days
+	}
+	item.HistoryPurgedBefore = formatMemoryTime(room.historyPurgedBefore)
+	if partnerMember, partner := r.partnerOf(room, userID); partner != nil {
+		item.Partner = &chatv1.RoomParticipant{
+			Id:               int32(partner.ID),
+			Name:             partner.Name,
+			Phone:            partner.Phone,
+			Avatar:           stringOrEmpty(partner.Avatar),
+			IsPartnerBlocked: partnerMember.partnerBlocked,
+		}
+		if withPartnerMuted {
+			item.Partner.IsPartnerMuted = partnerMember.muted
+		}
+	}
+	return item
+}
+
+// participants devuelve los miembros activos con usuario existente, ordenados por nombre e id.
+func (r *MemoryRoomRepository) participants(roomID string, search string) []*chatv1.RoomParticipant {
+	data := []*chatv1.RoomParticipant{}
+	for id, member := range r.members[roomID] {
+		user, ok := r.users[id]
+		if !ok || !member.removedAt.IsZero() {
+			continue
+		}
+		if search != "" && !memoryContains(user.Name, search) {
+			continue
+		}
+		data = append(data, &chatv1.RoomParticipant{
+			Id:     int32(user.ID),
+			Role:   member.role,
+			Name:   user.Name,
+			Phone:  user.Phone,
+			Avatar: stringOrEmpty(user.Avatar),
+		})
+	}
+	sort.Slice(data, func(i, j int) bool {
+		if data[i].Name != data[j].Name {
+			return data[i].Name < data[j].Name
+		}
+		return data[i].Id < data[j].Id
+	})
+	return data
+}
+
+func (r *MemoryRoomRepository) usersByID(ids []int) []User {
+	users := make([]User, 0)
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok && !seen[id] {
+			seen[id] = true
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+func (r *MemoryRoomRepository) addOutbox(events ...OutboxEvent) {
+	for _, event := range events {
+		r.outboxSeq++
+		r.outbox = append(r.outbox, &memoryOutboxEntry{seq: r.outboxSeq, event: event, nextAttemptAt: event.CreatedAt})
+	}
+}
+
+func (r *MemoryRoomRepository) CreateRoom(ctx context.Context, userId int, room *chatv1.CreateRoomRequest) (*chatv1.Room, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if room.Type == "p2p" {
+		for _, existing := range r.rooms {
+			if existing.kind != "p2p" || !existing.deletedAt.IsZero() {
+				continue
+			}
+			me := r.activeMember(existing.id, userId)
+			partnerMember := r.activeMember(existing.id, int(room.Participants[0]))
+			partner, partnerExists := r.users[int(room.Participants[0])]
+			if _, meExists := r.users[userId]; me == nil || partnerMember == nil || !meExists || !partnerExists {
+				continue
+			}
+			item := &chatv1.Room{
+				Id:             existing.id,
+				CreatedAt:      formatMemoryTime(existing.createdAt),
+				UpdatedAt:      formatMemoryTime(existing.updatedAt),
+				PhotoUrl:       existing.image,
+				Name:           existing.name,
+				Description:    existing.description,
+				Type:           existing.kind,
+				EncryptionData: existing.encryptionData,
+				KeyVersion:     roomKeyVersion(existing.keyVersion),
+				E2E:            existing.e2e,
+				JoinAllUser:    existing.joinAllUser,
+				SendMessage:    existing.sendMessage,
+				AddMember:      existing.addMember,
+				EditGroup:      existing.editGroup,
+				Role:           me.role,
+				Partner: &chatv1.RoomParticipant{
+					Id:     int32(partner.ID),
+					Name:   partner.Name,
+					Phone:  partner.Phone,
+					Avatar: stringOrEmpty(partner.Avatar),
+				},
+			}
+			return utils.FormatRoom(item), nil
+		}
+	}
+
+	// Las FK de room_member exigen que todos los usuarios existan
+	if _, ok := r.users[userId]; !ok {
+		return nil, fmt.Errorf("user %d does not exist", userId)
+	}
+	for _, participant := range room.Participants {
+		if _, ok := r.users[int(participant)]; !ok {
+			return nil, fmt.Errorf("user %d does not exist", participant)
+		}
+	}
+
+	// Las salas e2e no tienen clave en el servidor (ver e2e.go)
+	var encryptionData string
+	if !room.GetE2E() {
+		var err error
+		if encryptionData, err = r.generateKey(); err != nil {
+			fmt.Println("error", err)
+			return nil, err
+		}
+	}
+
+	if room.Type == "p2p" {
+		room.SendMessage = &[]bool{true}[0]
+		room.AddMember = &[]bool{false}[0]
+		room.EditGroup = &[]bool{false}[0]
+	}
+	if room.SendMessage == nil {
+		room.SendMessage = &[]bool{true}[0]
+	}
+	if room.AddMember == nil {
+		room.AddMember = &[]bool{false}[0]
+	}
+	if room.EditGroup == nil {
+		room.EditGroup = &[]bool{false}[0]
+	}
+	if room.Name == nil {
+		room.Name = &[]string{""}[0]
+	}
+	if room.PhotoUrl == nil {
+		room.PhotoUrl = &[]string{""}[0]
+	}
+	if room.Description == nil {
+		room.Description = &[]string{""}[0]
+	}
+
+	now := r.now()
+	stored := &memoryRoom{
+		id:             uuid.NewString(),
+		name:           *room.Name,
+		image:          *room.PhotoUrl,
+		description:    *room.Description,
+		kind:           room.Type,
+		encryptionData: encryptionData,
+		keyVersion:     1,
+		e2e:            room.GetE2E(),
+		sendMessage:    *room.SendMessage,
+		addMember:      *room.AddMember,
+		editGroup:      *room.EditGroup,
+		createdAt:      now,
+		updatedAt:      now,
+	}
+	r.rooms[stored.id] = stored
+	r.members[stored.id] = map[int]*memoryMember{
+		userId: {userID: userId, role: "OWNER", createdAt: now, updatedAt: now},
+	}
+	var joined []int
+	for _, participant := range room.Participants {
+		if int(participant) != userId && r.members[stored.id][int(participant)] == nil {
+			r.members[stored.id][int(participant)] = &memoryMember{userID: int(participant), role: "MEMBER", createdAt: now, updatedAt: now}
+			joined = append(joined, int(participant))
+		}
+	}
+	r.addOutbox(newRoomJoinEvents(stored.id, userId, append(joined, userId), userId, now)...)
+
+	newRoom := &chatv1.Room{
+		Id:             stored.id,
+		Name:           stored.name,
+		PhotoUrl:       stored.image,
+		Description:    stored.description,
+		SendMessage:    stored.sendMessage,
+		AddMember:      stored.addMember,
+		EditGroup:      stored.editGroup,
+		EncryptionData: encryptionData,
+		KeyVersion:     1,
+		E2E:            stored.e2e,
+		CreatedAt:      now.Format("2006-01-02T15:04:05.000000-07:00"),
+		UpdatedAt:      now.Format("2006-01-02T15:04:05.000000-07:00"),
+		Type:           stored.kind,
+		Role:           "OWNER",
+	}
+
+	if newRoom.Type == "p2p" {
+		partner := r.users[int(room.Participants[0])]
+		newRoom.Partner = &chatv1.RoomParticipant{
+			Id:     int32(partner.ID),
+			Name:   partner.Name,
+			Phone:  partner.Phone,
+			Avatar: stringOrEmpty(partner.Avatar),
+		}
+	}
+	if newRoom.Type == "group" {
+		newRoom.Participants = paginate(r.participants(stored.id, ""), 1, 5)
+	}
+
+	return newRoom, nil
+}
+
+func (r *MemoryRoomRepository) GetRoom(ctx context.Context, userId int, roomId string, allData bool, cache bool) (*chatv1.Room, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	room := r.rooms[roomId]
+	member := r.activeMember(roomId, userId)
+	if _, ok := r.users[userId]; room == nil || member == nil || !ok || !room.deletedAt.IsZero() {
+		return nil, nil
+	}
+
+	item := r.roomView(room, userId, member, true)
+	if item.Type == "group" && allData {
+		item.Participants = paginate(r.participants(roomId, ""), 1, 5)
+	}
+
+	return utils.FormatRoom(item), nil
+}
+
+func (r *MemoryRoomRepository) GetRoomList(ctx context.Context, userId int, pagination *chatv1.GetRoomsRequest) ([]*chatv1.Room, *chatv1.PaginationMeta, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Clave del cursor: is_pinned, created_at e id de la última sala
+	cursor, err := decodeCursor(pagination.GetCursor(), cursorRooms, 3)
+	if err != nil {
+		return nil, nil, err
+	}
+	var afterPinned bool
+	var afterCreatedAt time.Time
+	if cursor != nil {
+		if afterPinned, err = strconv.ParseBool(cursor.Keys[0]); err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+		if afterCreatedAt, err = memoryCursorTime(cursor.Keys[1]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var since time.Time
+	if pagination != nil && pagination.Since != "" {
+		if since, err = parseMemoryTime(pagination.Since); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	type candidate struct {
+		room   *memoryRoom
+		member *memoryMember
+	}
+	var matched []candidate
+	for _, room := range r.rooms {
+		member := r.activeMember(room.id, userId)
+		if member == nil || !room.deletedAt.IsZero() {
+			continue
+		}
+		if pagination != nil {
+			if pagination.Type != "" && room.kind != pagination.Type {
+				continue
+			}
+			if pagination.Search != "" {
+				_, partner := r.partnerOf(room, userId)
+				if !memoryContains(room.name, pagination.Search) && (partner == nil || !memoryContains(partner.Name, pagination.Search)) {
+					continue
+				}
+			}
+			if !since.IsZero() && !room.updatedAt.After(since) && !member.updatedAt.After(since) {
+				continue
+			}
+		}
+		matched = append(matched, candidate{room, member})
+	}
+
+	// "lastMessageAt" no se actualiza nunca en Postgres, así que el orden efectivo es
+	// fijadas primero y luego por fecha de creación
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].member.pinned != matched[j].member.pinned {
+			return matched[i].member.pinned
+		}
+		if !matched[i].room.createdAt.Equal(matched[j].room.createdAt) {
+			return matched[i].room.createdAt.After(matched[j].room.createdAt)
+		}
+		return matched[i].room.id > matched[j].room.id
+	})
+
+	var page, limit uint32
+	if pagination != nil {
+		page, limit = pagination.GetPage(), pagination.GetLimit()
+	}
+
+	selected, nextCursor := memoryPage(matched, cursorRooms, cursor, page, limit,
+		func(c candidate) bool {
+			if c.member.pinned != afterPinned {
+				return !c.member.pinned
+			}
+			if !c.room.createdAt.Equal(afterCreatedAt) {
+				return c.room.createdAt.Before(afterCreatedAt)
+			}
+			return c.room.id < cursor.Keys[2]
+		},
+		func(c candidate) []string {
+			return []string{strconv.FormatBool(c.member.pinned), formatMemoryTime(c.room.createdAt), c.room.id}
+		})
+
+	data := []*chatv1.Room{}
+	for _, c := range selected {
+		item := utils.FormatRoom(r.roomView(c.room, userId, c.member, false))
+		if item.Type == "group" {
+			// Los 5 miembros más recientes, como en la consulta con ROW_NUMBER()
+			var recent []*memoryMember
+			for id, member := range r.members[item.Id] {
+				if _, ok := r.users[id]; ok && member.removedAt.IsZero() {
+					recent = append(recent, member)
+				}
+			}
+			sort.Slice(recent, func(i, j int) bool { return recent[i].createdAt.After(recent[j].createdAt) })
+			for _, member := range recent[:min(5, len(recent))] {
+				user := r.users[member.userID]
+				item.Participants = append(item.Participants, &chatv1.RoomParticipant{
+					Id:     int32(user.ID),
+					Role:   member.role,
+					Name:   user.Name,
+					Phone:  user.Phone,
+					Avatar: stringOrEmpty(user.Avatar),
+				})
+			}
+		}
+		data = append(data, item)
+	}
+
+	meta := memoryPaginationMeta(len(matched), len(data), page, limit)
+	meta.NextCursor = nextCursor
+	return data, meta, nil
+}
+
+func (r *MemoryRoomRepository) GetRoomListDeleted(ctx context.Context, userId int, since string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sinceTime time.Time
+	if since != "" {
+		var err error
+		if sinceTime, err = parseMemoryTime(since); err != nil {
+			return nil, err
+		}
+	}
+
+	type candidate struct {
+		room   *memoryRoom
+		member *memoryMember
+	}
+	var matched []candidate
+	for _, room := range r.rooms {
+		member := r.members[room.id][userId]
+		if member == nil || (room.deletedAt.IsZero() && member.removedAt.IsZero()) {
+			continue
+		}
+		if !sinceTime.IsZero() && !room.deletedAt.After(sinceTime) && !member.removedAt.After(sinceTime) {
+			continue
+		}
+		matched = append(matched, candidate{room, member})
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].member.pinned != matched[j].member.pinned {
+			return matched[i].member.pinned
+		}
+		return matched[i].room.createdAt.After(matched[j].room.createdAt)
+	})
+
+	var data []string
+	for _, c := range matched {
+		data = append(data, c.room.id)
+	}
+	return data, nil
+}
+
+func (r *MemoryRoomRepository) LeaveRoom(ctx context.Context, userId int, roomId string, participants []int32, leaveAll bool) ([]User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if leaveAll {
+		var all []int32
+		for id, member := range r.members[roomId] {
+			if member.removedAt.IsZero() {
+				all = append(all, int32(id))
+			}
+		}
+		slices.Sort(all)
+		participants = append(participants, all...)
+	}
+
+	participants = slices.Compact(participants)
+
+	now := r.now()
+	for _, participant := range participants {
+		if member := r.members[roomId][int(participant)]; member != nil && member.removedAt.IsZero() {
+			member.removedAt = now
+			member.updatedAt = now
+		}
+	}
+
+	r.addOutbox(newOutboxEvent(roomId, userId, OutboxRoomLeave, "", &chatv1.MessageEvent{
+		RoomId: roomId,
+		Event: &chatv1.MessageEvent_RoomLeave{RoomLeave: &chatv1.RoomLeaveEvent{
+			UsersId: participants,
+		}},
+	}))
+
+	ids := make([]int, len(participants))
+	for i, v := range participants {
+		ids[i] = int(v)
+	}
+
+	return r.usersByID(ids), nil
+}
+
+func (r *MemoryRoomRepository) DeleteRoom(ctx context.Context, userId int, roomId string, partner *int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if room := r.rooms[roomId]; room != nil && room.kind == "p2p" && room.deletedAt.IsZero() {
+		room.updatedAt = now
+		room.deletedAt = now
+	}
+	for _, member := range r.members[roomId] {
+		if member.removedAt.IsZero() {
+			member.updatedAt = now
+			member.removedAt = now
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryRoomRepository) GetRoomParticipants(ctx context.Context, pagination *chatv1.GetRoomParticipantsRequest) ([]*chatv1.RoomParticipant, *chatv1.PaginationMeta, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cursor, err := decodeCursor(pagination.Cursor, cursorParticipants, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	var afterID int
+	if cursor != nil {
+		if afterID, err = strconv.Atoi(cursor.Keys[1]); err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+	}
+
+	all := r.participants(pagination.Id, pagination.Search)
+	data, nextCursor := memoryPage(all, cursorParticipants, cursor, pagination.Page, pagination.Limit,
+		func(p *chatv1.RoomParticipant) bool {
+			return p.Name > cursor.Keys[0] || p.Name == cursor.Keys[0] && int(p.Id) > afterID
+		},
+		func(p *chatv1.RoomParticipant) []string { return []string{p.Name, strconv.Itoa(int(p.Id))} })
+
+	meta := memoryPaginationMeta(len(all), len(data), pagination.GetPage(), pagination.GetLimit())
+	meta.NextCursor = nextCursor
+	return data, meta, nil
+}
+
+// updateMember aplica fn al miembro (activo o no), como los UPDATE sobre room_member.
+func (r *MemoryRoomRepository) updateMember(roomId string, userId int, fn func(*memoryMember)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if member := r.members[roomId][userId]; member != nil {
+		fn(member)
+	}
+}
+
+func (r *MemoryRoomRepository) PinRoom(ctx context.Context, userId int, roomId string, pin bool) error {
+	r.updateMember(roomId, userId, func(m *memoryMember) {
+		m.pinned = pin
+	})
+	return nil
+}
+
+func (r *MemoryRoomRepository) MuteRoom(ctx context.Context, userId int, roomId string, mute bool) error {
+	r.updateMember(roomId, userId, func(m *memoryMember) {
+		m.muted = mute
+		m.updatedAt = r.now()
+		r.addOutbox(newRoomUpdatedEvent(roomId, userId))
+	})
+	return nil
+}
+
+func (r *MemoryRoomRepository) BlockUser(ctx context.Context, userId int, roomId string, block bool, partner *int) error {
+	r.updateMember(roomId, userId, func(m *memoryMember) {
+		m.partnerBlocked = block
+		m.updatedAt = r.now()
+	})
+	return nil
+}
+
+func (r *MemoryRoomRepository) UpdateParticipantRoom(ctx context.Context, userId int, req *chatv1.UpdateParticipantRoomRequest) error {
+	r.updateMember(req.Id, int(req.Participant), func(m *memoryMember) {
+		m.role = req.Role
+		m.updatedAt = r.now()
+		r.addOutbox(newRoomUpdatedEvent(req.Id, userId))
+	})
+	return nil
+}
+
+func (r *MemoryRoomRepository) UpdateRoom(ctx context.Context, userId int, roomId string, room *chatv1.UpdateRoomRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.rooms[roomId]
+	if stored == nil || !stored.deletedAt.IsZero() {
+		return nil
+	}
+
+	stored.updatedAt = r.now()
+	if room.Name != nil {
+		stored.name = *room.Name
+	}
+	if room.Description != nil {
+		stored.description = *room.Description
+	}
+	if room.PhotoUrl != nil {
+		stored.image = *room.PhotoUrl
+	}
+	if room.SendMessage != nil {
+		stored.sendMessage = *room.SendMessage
+	}
+	if room.AddMember != nil {
+		stored.addMember = *room.AddMember
+	}
+	if room.EditGroup != nil {
+		stored.editGroup = *room.EditGroup
+	}
+	if room.RetentionDays != nil {
+		stored.retentionDays = retentionDaysUpdate(*room.RetentionDays)
+	}
+	r.addOutbox(newRoomUpdatedEvent(roomId, userId))
+
+	return nil
+}
+
+func (r *MemoryRoomRepository) AddParticipantToRoom(ctx context.Context, userId int, roomId string, participants []int) ([]User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	newParticipantsData := r.usersByID(participants)
+
+	isNeedToUpdateRoomMember := false
+	var newParticipants []int
+	for _, participant := range participants {
+		member := r.members[roomId][participant]
+		if member == nil {
+			if _, ok := r.users[participant]; !ok {
+				return nil, fmt.Errorf("user %d does not exist", participant)
+			}
+			if !slices.Contains(newParticipants, participant) {
+				newParticipants = append(newParticipants, participant)
+			}
+			continue
+		}
+		if !member.active() {
+			isNeedToUpdateRoomMember = true
+			continue
+		}
+		newParticipantsData = slices.DeleteFunc(newParticipantsData, func(u User) bool { return u.ID == participant })
+	}
+
+	// Como en SQL, se reactivan todas las filas existentes de los participantes indicados
+	if isNeedToUpdateRoomMember {
+		for _, participant := range participants {
+			if member := r.members[roomId][participant]; member != nil {
+				member.removedAt = time.Time{}
+				member.deletedAt = time.Time{}
+			}
+		}
+	}
+
+	if len(newParticipants) > 0 {
+		if r.members[roomId] == nil {
+			r.members[roomId] = make(map[int]*memoryMember)
+		}
+		now := r.now()
+		for _, participant := range newParticipants {
+			r.members[roomId][participant] = &memoryMember{userID: participant, role: "MEMBER", createdAt: now, updatedAt: now}
+		}
+	}
+
+	joined := make([]int, len(newParticipantsData))
+	for i, participant := range newParticipantsData {
+		joined[i] = participant.ID
+	}
+	r.addOutbox(newRoomJoinEvents(roomId, userId, joined, 0, r.now())...)
+
+	return newParticipantsData, nil
+}
+
+// SaveMessage guarda el mensaje y la meta del remitente; la meta del resto de participantes
+// se crea aparte con CreateMessageMetaForParticipants, igual que en SQL.
+func (r *MemoryRoomRepository) SaveMessage(ctx context.Context, userId int, req *chatv1.SendMessageRequest, room *chatv1.Room, contentDecrypted *string) (*chatv1.MessageData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.rooms[req.RoomId]
+	if stored == nil {
+		return nil, fmt.Errorf("failed to reserve message seq: %w", sql.ErrNoRows)
+	}
+	if _, ok := r.users[userId]; !ok {
+		return nil, fmt.Errorf("failed to insert message: user %d does not exist", userId)
+	}
+
+	var contactId *int
+	if req.Type == "contact" && req.ContactPhone != nil {
+		for _, user := range r.users {
+			if user.Phone == *req.ContactPhone {
+				id := user.ID
+				contactId = &id
+				break
+			}
+		}
+	}
+
+	var forwardUserId *int
+	if req.ForwardId != nil {
+		if forwarded := r.messages[*req.ForwardId]; forwarded != nil && forwarded.deletedAt.IsZero() {
+			id := forwarded.senderID
+			forwardUserId = &id
+		}
+	}
+
+	var mentions []memoryTag
+	for _, mention := range req.Mentions {
+		id, err := strconv.Atoi(mention.User)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert mentions: %w", err)
+		}
+		if _, ok := r.users[id]; !ok {
+			return nil, fmt.Errorf("failed to insert mentions: user %d does not exist", id)
+		}
+		mentions = append(mentions, memoryTag{userID: id, tag: mention.Tag})
+	}
+
+	if req.Lifetime == nil {
+		req.Lifetime = &[]string{"normal"}[0]
+	}
+	if req.Origin == nil {
+		req.Origin = &[]string{"app"}[0]
+	}
+	if req.Type == "" {
+		req.Type = "message"
+	}
+	if contentDecrypted == nil {
+		contentDecrypted = &[]string{""}[0]
+	}
+
+	newMessageId, err := uuid.NewUUID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate message id: %w", err)
+	}
+
+	stored.lastSeq++
+	now := r.now()
+	msg := &memoryMessage{
+		id:                newMessageId.String(),
+		roomID:            req.RoomId,
+		senderID:          userId,
+		content:           req.Content,
+		contentDecrypted:  *contentDecrypted,
+		status:            chatv1.MessageStatus_MESSAGE_STATUS_SENT,
+		createdAt:         now,
+		updatedAt:         now,
+		kind:              req.Type,
+		lifetime:          cloneString(req.Lifetime),
+		locationName:      cloneString(req.LocationName),
+		locationLatitude:  cloneFloat(req.LocationLatitude),
+		locationLongitude: cloneFloat(req.LocationLongitude),
+		origin:            cloneString(req.Origin),
+		contactID:         contactId,
+		contactName:       cloneString(req.ContactName),
+		contactPhone:      cloneString(req.ContactPhone),
+		file:              cloneString(req.File),
+		replyID:           cloneString(req.ReplyId),
+		forwardID:         cloneString(req.ForwardId),
+		forwardSenderID:   forwardUserId,
+		event:             cloneString(req.Event),
+		senderMessageID:   cloneString(req.SenderMessageId),
+		seq:               stored.lastSeq,
+		keyVersion:        req.GetKeyVersion(),
+	}
+	r.messages[msg.id] = msg
+	if len(mentions) > 0 {
+		r.tags[msg.id] = mentions
+	}
+	r.metas[msg.id] = map[int]*memoryMeta{userId: {readAt: now}}
+
+	r.addOutbox(newOutboxEvent(req.RoomId, userId, OutboxMessageCreated, msg.id, nil))
+
+	return r.messageData(msg, userId, false), nil
+}
+
+// messageData arma el MessageData de GetMessage; forHistory aplica además los ajustes de
+// GetMessagesFromRoom (tipo de la respuesta, contenido vacío si se eliminó y estado por lector).
+func (r *MemoryRoomRepository) messageData(msg *memoryMessage, userId int, forHistory bool) *chatv1.MessageData {
+	sender, ok := r.users[msg.senderID]
+	if !ok {
+		return nil
+	}
+
+	message := &chatv1.MessageData{
+		Id:                 msg.id,
+		RoomId:             msg.roomID,
+		SenderId:           int32(msg.senderID),
+		SenderName:         sender.Name,
+		SenderPhone:        sender.Phone,
+		SenderAvatar:       stringOrEmpty(sender.Avatar),
+		Content:            msg.content,
+		Status:             msg.status,
+		CreatedAt:          formatMemoryTime(msg.createdAt),
+		UpdatedAt:          formatMemoryTime(msg.updatedAt),
+		Type:               msg.kind,
+		Lifetime:           cloneString(msg.lifetime),
+		LocationName:       cloneString(msg.locationName),
+		LocationLatitude:   cloneFloat(msg.locationLatitude),
+		LocationLongitude:  cloneFloat(msg.locationLongitude),
+		Origin:             cloneString(msg.origin),
+		ContactName:        cloneString(msg.contactName),
+		ContactPhone:       cloneString(msg.contactPhone),
+		File:               cloneString(msg.file),
+		Edited:             msg.edited,
+		IsDeleted:          msg.isDeleted,
+		Event:              cloneString(msg.event),
+		SenderMessageId:    cloneString(msg.senderMessageID),
+		Seq:                msg.seq,
+		KeyVersion:         roomKeyVersion(msg.keyVersion),
+		ForwardedMessageId: cloneString(msg.forwardID),
+	}
+	if msg.contactID != nil {
+		contactId := strconv.Itoa(*msg.contactID)
+		message.ContactId = &contactId
+	}
+	if msg.forwardSenderID != nil {
+		if forwarded, ok := r.users[*msg.forwardSenderID]; ok {
+			id := int32(forwarded.ID)
+			message.ForwardedMessageSenderId = &id
+			message.ForwardedMessageSenderName = &forwarded.Name
+			message.ForwardedMessageSenderPhone = &forwarded.Phone
+			message.ForwardedMessageSenderAvatar = cloneString(forwarded.Avatar)
+		}
+	}
+	if msg.replyID != nil {
+		message.Reply = &chatv1.MessageData{Id: *msg.replyID}
+		if reply := r.messages[*msg.replyID]; reply != nil {
+			replySender := r.users[reply.senderID]
+			message.Reply.SenderId = int32(reply.senderID)
+			message.Reply.SenderName = replySender.Name
+			message.Reply.SenderPhone = replySender.Phone
+			message.Reply.SenderAvatar = stringOrEmpty(replySender.Avatar)
+			message.Reply.Content = reply.content
+			message.Reply.RoomId = reply.roomID
+			message.Reply.CreatedAt = formatMemoryTime(reply.createdAt)
+			message.Reply.UpdatedAt = formatMemoryTime(reply.updatedAt)
+			message.Reply.KeyVersion = roomKeyVersion(reply.keyVersion)
+			if forHistory {
+				message.Reply.Type = reply.kind
+			}
+		}
+	}
+
+	for _, tag := range r.tags[msg.id] {
+		if user, ok := r.users[tag.userID]; ok {
+			message.Mentions = append(message.Mentions, &chatv1.Mention{
+				Id:        strconv.Itoa(tag.userID),
+				Name:      user.Name,
+				Phone:     user.Phone,
+				Tag:       tag.tag,
+				MessageId: msg.id,
+			})
+		}
+	}
+	for _, reaction := range r.reactions[msg.id] {
+		if reaction.deletedAt.IsZero() {
+			message.Reactions = append(message.Reactions, &chatv1.Reaction{
+				ReactedById: strconv.Itoa(reaction.userID),
+				Reaction:    reaction.reaction,
+				MessageId:   msg.id,
+			})
+		}
+	}
+
+	if forHistory {
+		if message.IsDeleted {
+			message.Content = ""
+			message.File = nil
+		}
+		if message.SenderId != int32(userId) {
+			if meta := r.metas[msg.id][userId]; meta != nil && !meta.isDeleted && !meta.readAt.IsZero() {
+				message.Status = chatv1.MessageStatus_MESSAGE_STATUS_READ
+			} else {
+				message.Status = chatv1.MessageStatus_MESSAGE_STATUS_SENT
+			}
+		}
+	}
+
+	return message
+}
+
+func (r *MemoryRoomRepository) GetMessage(ctx context.Context, userId int, messageId string) (*chatv1.MessageData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg := r.messages[messageId]
+	if msg == nil {
+		return nil, nil
+	}
+	return r.messageData(msg, userId, false), nil
+}
+
+func (r *MemoryRoomRepository) GetMessageSimple(ctx context.Context, userId int, messageId string) (*chatv1.MessageData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg := r.messages[messageId]
+	if msg == nil || !msg.deletedAt.IsZero() {
+		return nil, nil
+	}
+	return &chatv1.MessageData{
+		Id:        msg.id,
+		CreatedAt: formatMemoryTime(msg.createdAt),
+		RoomId:    msg.roomID,
+		SenderId:  int32(msg.senderID),
+		Content:   msg.content,
+		File:      cloneString(msg.file),
+		Type:      msg.kind,
+	}, nil
+}
+
+func (r *MemoryRoomRepository) UpdateMessage(ctx context.Context, userId int, req *chatv1.EditMessageRequest, contentDecrypted *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	messageId := req.MessageId
+	msg := r.messages[messageId]
+	if msg == nil {
+		return sql.ErrNoRows
+	}
+	msg.content = req.NewContent
+	msg.contentDecrypted = ""
+	if contentDecrypted != nil {
+		msg.contentDecrypted = *contentDecrypted
+	}
+	if req.KeyVersion != nil {
+		msg.keyVersion = *req.KeyVersion
+	}
+	msg.updatedAt = r.now()
+	msg.edited = true
+
+	r.addOutbox(newOutboxEvent(msg.roomID, userId, OutboxMessageUpdated, messageId, nil))
+	return nil
+}
+
+func (r *MemoryRoomRepository) DeleteMessage(ctx context.Context, userId int, messageId []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	var outboxEvents []OutboxEvent
+	for _, id := range slices.Compact(slices.Sorted(slices.Values(messageId))) {
+		msg := r.messages[id]
+		if msg == nil {
+			continue
+		}
+		msg.deletedAt = now
+		msg.updatedAt = now
+		msg.isDeleted = true
+		outboxEvents = append(outboxEvents, newOutboxEvent(msg.roomID, userId, OutboxMessageDeleted, id, &chatv1.MessageEvent{
+			RoomId: msg.roomID,
+			Event:  &chatv1.MessageEvent_DeleteMessage{DeleteMessage: id},
+		}))
+	}
+
+	r.addOutbox(outboxEvents...)
+	return nil
+}
+
+func (r *MemoryRoomRepository) ReactToMessage(ctx context.Context, userId int, messageId string, reaction string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg := r.messages[messageId]
+	if msg == nil {
+		return fmt.Errorf("message %s does not exist", messageId)
+	}
+
+	now := r.now()
+	var existing *memoryReaction
+	for _, stored := range r.reactions[messageId] {
+		if stored.userID == userId && stored.deletedAt.IsZero() {
+			existing = stored
+			break
+		}
+	}
+
+	switch {
+	case existing != nil && reaction == "":
+		existing.deletedAt = now
+	case existing != nil:
+		existing.reaction = reaction
+		existing.updatedAt = now
+	default:
+		r.reactions[messageId] = append(r.reactions[messageId], &memoryReaction{userID: userId, reaction: reaction, createdAt: now, updatedAt: now})
+	}
+
+	msg.updatedAt = now
+	return nil
+}
+
+func (r *MemoryRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int, req *chatv1.GetMessageHistoryRequest) ([]*chatv1.MessageData, *chatv1.PaginationMeta, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var beforeCreatedAt, afterCreatedAt, beforeDate, afterDate time.Time
+	if req.BeforeMessageId != nil && *req.BeforeMessageId != "" {
+		if msg := r.messages[*req.BeforeMessageId]; msg != nil {
+			beforeCreatedAt = msg.createdAt
+		}
+	}
+	if req.AfterMessageId != nil && *req.AfterMessageId != "" {
+		if msg := r.messages[*req.AfterMessageId]; msg != nil {
+			afterCreatedAt = msg.createdAt
+		}
+	}
+	if req.BeforeDate != nil && *req.BeforeDate != "" {
+		var err error
+		if beforeDate, err = parseMemoryTime(*req.BeforeDate); err != nil {
+			return nil, nil, err
+		}
+	}
+	if req.AfterDate != nil && *req.AfterDate != "" {
+		var err error
+		if afterDate, err = parseMemoryTime(*req.AfterDate); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Paginación por seq: se incluyen los mensajes eliminados (sin contenido) para que el
+	// rango de seq llegue completo y el cliente pueda detectar huecos reales
+	bySeq := req.Id != "" && (req.AfterSeq != nil || req.BeforeSeq != nil)
+
+	// El cursor no aplica a messages_per_room: cada sala tiene su propia ventana
+	var cursor *pageCursor
+	var afterSeq int64
+	var afterMessageAt time.Time
+	if req.Cursor != "" {
+		if req.MessagesPerRoom > 0 {
+			return nil, nil, ErrInvalidCursor
+		}
+		var err error
+		if bySeq {
+			if cursor, err = decodeCursor(req.Cursor, cursorMessagesSeq, 1); err == nil {
+				afterSeq, err = strconv.ParseInt(cursor.Keys[0], 10, 64)
+			}
+		} else if cursor, err = decodeCursor(req.Cursor, cursorMessages, 2); err == nil {
+			afterMessageAt, err = memoryCursorTime(cursor.Keys[0])
+		}
+		if err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+	}
+
+	// matches aplica los filtros comunes a la consulta de datos y a la de total
+	matches := func(msg *memoryMessage) bool {
+		if _, ok := r.users[msg.senderID]; !ok {
+			return false
+		}
+		if member := r.members[msg.roomID][userId]; member == nil || !member.removedAt.IsZero() {
+			return false
+		}
+		if req.Id != "" && msg.roomID != req.Id {
+			return false
+		}
+		if !beforeDate.IsZero() && !msg.updatedAt.Before(beforeDate) {
+			return false
+		}
+		if !beforeCreatedAt.IsZero() && !msg.createdAt.Before(beforeCreatedAt) {
+			return false
+		}
+		if !afterDate.IsZero() && !msg.updatedAt.After(afterDate) {
+			return false
+		}
+		if !afterCreatedAt.IsZero() && !msg.createdAt.After(afterCreatedAt) {
+			return false
+		}
+		return true
+	}
+
+	var selected []*memoryMessage
+	total := 0
+	for _, msg := range r.messages {
+		if !matches(msg) {
+			continue
+		}
+		meta := r.metas[msg.id][userId]
+		if meta != nil && meta.isDeleted {
+			meta = nil
+		}
+		if meta != nil && meta.senderBlocked {
+			continue
+		}
+		if meta != nil && msg.deletedAt.IsZero() {
+			total++
+		}
+		if !bySeq && !msg.deletedAt.IsZero() {
+			continue
+		}
+		if req.AfterSeq != nil && msg.seq <= *req.AfterSeq {
+			continue
+		}
+		if req.BeforeSeq != nil && msg.seq >= *req.BeforeSeq {
+			continue
+		}
+		selected = append(selected, msg)
+	}
+
+	switch {
+	case bySeq && req.BeforeSeq == nil:
+		// after_seq: los siguientes mensajes al último conocido, en orden
+		sort.Slice(selected, func(i, j int) bool { return selected[i].seq < selected[j].seq })
+	case bySeq:
+		sort.Slice(selected, func(i, j int) bool { return selected[i].seq > selected[j].seq })
+	default:
+		sort.Slice(selected, func(i, j int) bool {
+			if !selected[i].createdAt.Equal(selected[j].createdAt) {
+				return selected[i].createdAt.After(selected[j].createdAt)
+			}
+			return selected[i].id > selected[j].id
+		})
+	}
+
+	var nextCursor string
+	if req.MessagesPerRoom > 0 {
+		perRoom := make(map[string]uint32)
+		ranked := selected[:0:0]
+		for _, msg := range selected {
+			if perRoom[msg.roomID] < req.MessagesPerRoom {
+				perRoom[msg.roomID]++
+				ranked = append(ranked, msg)
+			}
+		}
+		selected = ranked
+	} else if bySeq {
+		if cursor != nil {
+			ascending := req.BeforeSeq == nil
+			selected = slices.DeleteFunc(selected, func(msg *memoryMessage) bool {
+				return ascending && msg.seq <= afterSeq || !ascending && msg.seq >= afterSeq
+			})
+		}
+		if req.Limit > 0 && len(selected) > int(req.Limit) {
+			selected = selected[:req.Limit]
+			nextCursor = encodeCursor(cursorMessagesSeq, strconv.FormatInt(selected[len(selected)-1].seq, 10))
+		}
+	} else {
+		selected, nextCursor = memoryPage(selected, cursorMessages, cursor, req.Page, req.Limit,
+			func(msg *memoryMessage) bool {
+				return msg.createdAt.Before(afterMessageAt) || msg.createdAt.Equal(afterMessageAt) && msg.id < cursor.Keys[1]
+			},
+			func(msg *memoryMessage) []string { return []string{formatMemoryTime(msg.createdAt), msg.id} })
+	}
+
+	data := []*chatv1.MessageData{}
+	for _, msg := range selected {
+		data = append(data, r.messageData(msg, userId, true))
+	}
+
+	if req.MessagesPerRoom == 0 {
+		meta := memoryPaginationMeta(total, len(data), req.Page, req.Limit)
+		meta.NextCursor = nextCursor
+		return data, meta, nil
+	}
+	return data, &chatv1.PaginationMeta{
+		TotalItems:   0,
+		ItemCount:    uint32(len(data)),
+		ItemsPerPage: req.Limit,
+		TotalPages:   1,
+		CurrentPage:  req.Page,
+	}, nil
+}
+
+func (r *MemoryRoomRepository) MarkMessagesAsRead(ctx context.Context, userId int, roomId string, messageIds []string, since string) (int32, error) {
+	// Si no hay IDs de mensajes, no hay nada que hacer.
+	if len(messageIds) == 0 {
+		return 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var requested []readMessage
+	seen := make(map[string]bool, len(messageIds))
+	for _, id := range messageIds {
+		if msg := r.messages[id]; msg != nil && msg.roomID == roomId && !seen[id] {
+			seen[id] = true
+			requested = append(requested, readMessage{id: id, senderID: int32(msg.senderID), seq: msg.seq})
+		}
+	}
+
+	if since != "" {
+		sinceTime, err := parseMemoryTime(since)
+		if err != nil {
+			return 0, fmt.Errorf("error executing select query: %w", err)
+		}
+		for _, msg := range r.messages {
+			if msg.roomID != roomId || !msg.createdAt.Before(sinceTime) {
+				continue
+			}
+			if meta := r.metas[msg.id][userId]; meta == nil || meta.readAt.IsZero() {
+				messageIds = append(messageIds, msg.id)
+			}
+		}
+	}
+	messageIds = slices.Compact(slices.Sorted(slices.Values(messageIds)))
+
+	for _, id := range messageIds {
+		if r.messages[id] == nil {
+			return 0, fmt.Errorf("error executing insert query: message %s does not exist", id)
+		}
+	}
+
+	now := r.now()
+	var marked int32
+	for _, id := range messageIds {
+		if r.metas[id] == nil {
+			r.metas[id] = make(map[int]*memoryMeta)
+		}
+		meta := r.metas[id][userId]
+		switch {
+		case meta == nil:
+			r.metas[id][userId] = &memoryMeta{readAt: now}
+			marked++
+		case meta.readAt.IsZero():
+			meta.readAt = now
+			marked++
+		}
+		r.messages[id].status = chatv1.MessageStatus_MESSAGE_STATUS_READ
+	}
+
+	r.addOutbox(newMessagesReadEvents(roomId, userId, requested, now)...)
+
+	return marked, nil
+}
+
+func (r *MemoryRoomRepository) GetMessageRead(ctx context.Context, req *chatv1.GetMessageReadRequest) ([]*chatv1.MessageUserRead, *chatv1.PaginationMeta, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type read struct {
+		user   User
+		readAt time.Time
+	}
+	var reads []read
+	total := 0
+	for id, meta := range r.metas[req.Id] {
+		if meta.readAt.IsZero() {
+			continue
+		}
+		total++
+		if user, ok := r.users[id]; ok {
+			reads = append(reads, read{user, meta.readAt})
+		}
+	}
+	sort.Slice(reads, func(i, j int) bool {
+		if !reads[i].readAt.Equal(reads[j].readAt) {
+			return reads[i].readAt.Before(reads[j].readAt)
+		}
+		return reads[i].user.ID < reads[j].user.ID
+	})
+
+	cursor, err := decodeCursor(req.Cursor, cursorReads, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	var afterReadAt time.Time
+	var afterUserID int
+	if cursor != nil {
+		if afterReadAt, err = memoryCursorTime(cursor.Keys[0]); err != nil {
+			return nil, nil, err
+		}
+		if afterUserID, err = strconv.Atoi(cursor.Keys[1]); err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+	}
+	page, nextCursor := memoryPage(reads, cursorReads, cursor, req.Page, req.Limit,
+		func(read read) bool {
+			return read.readAt.After(afterReadAt) || read.readAt.Equal(afterReadAt) && read.user.ID > afterUserID
+		},
+		func(read read) []string { return []string{formatMemoryTime(read.readAt), strconv.Itoa(read.user.ID)} })
+
+	items := make([]*chatv1.MessageUserRead, 0)
+	for _, read := range page {
+		items = append(items, &chatv1.MessageUserRead{
+			UserId:     int32(read.user.ID),
+			UserName:   read.user.Name,
+			UserAvatar: stringOrEmpty(read.user.Avatar),
+			UserPhone:  read.user.Phone,
+			ReadAt:     formatMemoryTime(read.readAt),
+		})
+	}
+
+	meta := memoryPaginationMeta(total, len(items), req.GetPage(), req.Limit)
+	meta.NextCursor = nextCursor
+	return items, meta, nil
+}
+
+func (r *MemoryRoomRepository) GetMessageReactions(ctx context.Context, req *chatv1.GetMessageReactionsRequest) ([]*chatv1.Reaction, *chatv1.PaginationMeta, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var active []*memoryReaction
+	total := 0
+	for _, reaction := range r.reactions[req.Id] {
+		if !reaction.deletedAt.IsZero() {
+			continue
+		}
+		total++
+		if _, ok := r.users[reaction.userID]; ok {
+			active = append(active, reaction)
+		}
+	}
+	// Cada usuario tiene una sola reacción activa por mensaje, así que su id desempata
+	sort.Slice(active, func(i, j int) bool {
+		if !active[i].createdAt.Equal(active[j].createdAt) {
+			return active[i].createdAt.After(active[j].createdAt)
+		}
+		return active[i].userID > active[j].userID
+	})
+
+	cursor, err := decodeCursor(req.Cursor, cursorReactions, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	var afterCreatedAt time.Time
+	var afterUserID int
+	if cursor != nil {
+		if afterCreatedAt, err = memoryCursorTime(cursor.Keys[0]); err != nil {
+			return nil, nil, err
+		}
+		if afterUserID, err = strconv.Atoi(cursor.Keys[1]); err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+	}
+	page, nextCursor := memoryPage(active, cursorReactions, cursor, req.Page, req.Limit,
+		func(reaction *memoryReaction) bool {
+			return reaction.createdAt.Before(afterCreatedAt) || reaction.createdAt.Equal(afterCreatedAt) && reaction.userID < afterUserID
+		},
+		func(reaction *memoryReaction) []string {
+			return []string{formatMemoryTime(reaction.createdAt), strconv.Itoa(reaction.userID)}
+		})
+
+	items := make([]*chatv1.Reaction, 0)
+	for _, reaction := range page {
+		user := r.users[reaction.userID]
+		items = append(items, &chatv1.Reaction{
+			Reaction:        reaction.reaction,
+			ReactedById:     strconv.Itoa(user.ID),
+			ReactedByName:   user.Name,
+			ReactedByAvatar: stringOrEmpty(user.Avatar),
+			ReactedByPhone:  user.Phone,
+			MessageId:       req.Id,
+		})
+	}
+
+	meta := memoryPaginationMeta(total, len(items), req.Page, req.Limit)
+	meta.NextCursor = nextCursor
+	return items, meta, nil
+}
+
+func (r *MemoryRoomRepository) GetUserByID(ctx context.Context, id int) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.users[id]; ok {
+		return &user, nil
+	}
+	return nil, nil
+}
+
+func (r *MemoryRoomRepository) GetUsersByID(ctx context.Context, ids []int) ([]User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.usersByID(ids), nil
+}
+
+func (r *MemoryRoomRepository) GetUserByPhone(ctx context.Context, phone string) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Phone == phone {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryRoomRepository) GetAllUserIDs(ctx context.Context) ([]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := make([]int, 0, len(r.users))
+	for id := range r.users {
+		users = append(users, id)
+	}
+	slices.Sort(users)
+	return users, nil
+}
+
+func (r *MemoryRoomRepository) GetMessageSender(ctx context.Context, userId int, senderMessageId string) (*chatv1.MessageData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, msg := range r.messages {
+		if msg.senderID != userId || !msg.deletedAt.IsZero() || msg.senderMessageID == nil || *msg.senderMessageID != senderMessageId {
+			continue
+		}
+		return &chatv1.MessageData{
+			Id:              msg.id,
+			CreatedAt:       formatMemoryTime(msg.createdAt),
+			RoomId:          msg.roomID,
+			SenderId:        int32(msg.senderID),
+			File:            cloneString(msg.file),
+			Type:            msg.kind,
+			SenderMessageId: cloneString(msg.senderMessageID),
+			Status:          msg.status,
+		}, nil
+	}
+	return nil, nil
+}
+
+func (r *MemoryRoomRepository) CreateMessageMetaForParticipants(ctx context.Context, roomID string, messageID string, senderID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.messages[messageID] == nil {
+		return nil
+	}
+	if r.metas[messageID] == nil {
+		r.metas[messageID] = make(map[int]*memoryMeta)
+	}
+	for _, p := range r.participants(roomID, "") {
+		if int(p.Id) == senderID {
+			continue
+		}
+		if _, exists := r.metas[messageID][int(p.Id)]; !exists {
+			r.metas[messageID][int(p.Id)] = &memoryMeta{}
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryRoomRepository) IsPartnerMuted(ctx context.Context, userId int, roomId string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if member := r.members[roomId][userId]; member != nil && member.deletedAt.IsZero() {
+		return member.muted, nil
+	}
+	return false, nil
+}
+
+func (r *MemoryRoomRepository) ClaimOutboxEvents(ctx context.Context, limit int) ([]OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var events []OutboxEvent
+	// Como en SQL: una sala con un evento anterior pendiente no entrega los siguientes
+	blocked := map[string]bool{}
+	for _, entry := range r.outbox {
+		if len(events) >= limit {
+			break
+		}
+		if !entry.sentAt.IsZero() {
+			continue
+		}
+		if blocked[entry.event.RoomID] || entry.nextAttemptAt.After(now) {
+			blocked[entry.event.RoomID] = true
+			continue
+		}
+		entry.nextAttemptAt = now.Add(outboxClaimLease)
+		entry.event.Attempts++
+		events = append(events, entry.event)
+	}
+	return events, nil
+}
+
+func (r *MemoryRoomRepository) MarkOutboxEventSent(ctx context.Context, event OutboxEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range r.outbox {
+		if entry.event.ID == event.ID {
+			entry.sentAt = time.Now()
+			entry.lastError = ""
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRoomRepository) MarkOutboxEventFailed(ctx context.Context, event OutboxEvent, cause error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range r.outbox {
+		if entry.event.ID == event.ID {
+			entry.nextAttemptAt = time.Now().Add(outboxBackoff(event.Attempts))
+			entry.lastError = cause.Error()
+		}
+	}
+	return nil
+}
+
+// PurgeExpiredMessages aplica la retención de cada sala igual que SQLRoomRepository.
+func (r *MemoryRoomRepository) PurgeExpiredMessages(ctx context.Context, defaultRetentionDays int, now time.Time, batchSize int) ([]RetentionPurge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purges []RetentionPurge
+	for _, roomID := range slices.Sorted(maps.Keys(r.rooms)) {
+		room := r.rooms[roomID]
+		days := effectiveRetentionDays(room.retentionDays, defaultRetentionDays)
+		if !room.deletedAt.IsZero() || days == 0 {
+			continue
+		}
+		cutoff := retentionCutoff(now, days)
+
+		var expired []*memoryMessage
+		for _, msg := range r.messages {
+			if msg.roomID == roomID && msg.createdAt.Before(cutoff) && room.pendingPurge(msg) {
+				expired = append(expired, msg)
+			}
+		}
+		if len(expired) == 0 {
+			continue
+		}
+		slices.SortFunc(expired, func(a, b *memoryMessage) int {
+			if c := a.createdAt.Compare(b.createdAt); c != 0 {
+				return c
+			}
+			return strings.Compare(a.id, b.id)
+		})
+		if len(expired) > batchSize {
+			expired = expired[:batchSize]
+		}
+
+		purge := RetentionPurge{RoomID: roomID, Before: cutoff, Complete: len(expired) < batchSize}
+		if !purge.Complete {
+			purge.Before = expired[len(expired)-1].createdAt
+		}
+		updatedAt := r.now()
+		for _, msg := range expired {
+			msg.content, msg.contentDecrypted = "", ""
+			msg.file, msg.locationName, msg.contactName, msg.contactPhone = nil, nil, nil, nil
+			msg.locationLatitude, msg.locationLongitude, msg.contactID = nil, nil, nil
+			msg.isDeleted = true
+			if msg.deletedAt.IsZero() {
+				msg.deletedAt = now
+			}
+			msg.updatedAt = updatedAt
+			delete(r.metas, msg.id)
+			delete(r.tags, msg.id)
+			delete(r.reactions, msg.id)
+			purge.MessageIDs = append(purge.MessageIDs, msg.id)
+		}
+		room.historyPurgedBefore, room.historyPurgedAfter = purge.Before, ""
+		if !purge.Complete {
+			room.historyPurgedAfter = expired[len(expired)-1].id
+		}
+		r.addOutbox(newHistoryPurgedEvent(roomID, purge.Before))
+		purges = append(purges, purge)
+	}
+
+	return purges, nil
+}
+
+// pendingPurge indica si el mensaje sigue después de la marca de la retención: tras un lote
+// lleno, después del último mensaje purgado en orden (created_at, id).
+func (room *memoryRoom) pendingPurge(msg *memoryMessage) bool {
+	if room.historyPurgedAfter == "" {
+		return !msg.createdAt.Before(room.historyPurgedBefore)
+	}
+	if c := msg.createdAt.Compare(room.historyPurgedBefore); c != 0 {
+		return c > 0
+	}
+	return msg.id > room.historyPurgedAfter
+}
+
+// RotateRoomKey retira la clave actual al historial de la sala y genera una nueva.
+func (r *MemoryRoomRepository) RotateRoomKey(ctx context.Context, userId int, roomId string) (int32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	room := r.rooms[roomId]
+	if room == nil || !room.deletedAt.IsZero() {
+		return 0, sql.ErrNoRows
+	}
+	if room.e2e {
+		return 0, ErrE2ERoom
+	}
+	encryptionData, err := r.generateKey()
+	if err != nil {
+		return 0, err
+	}
+
+	now := r.now()
+	version := roomKeyVersion(room.keyVersion)
+	room.retiredKeys = append(room.retiredKeys, memoryRoomKey{version: version, encryptionData: room.encryptionData, retiredAt: now})
+	room.encryptionData = encryptionData
+	room.keyVersion = version + 1
+	room.updatedAt = now
+	r.addOutbox(newRoomKeyRotatedEvent(roomId, userId))
+
+	return room.keyVersion, nil
+}
+
+// GetRoomKeys devuelve la clave actual y las retiradas, de la más reciente a la más antigua.
+func (r *MemoryRoomRepository) GetRoomKeys(ctx context.Context, roomId string) ([]*chatv1.RoomKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	room := r.rooms[roomId]
+	if room == nil || !room.deletedAt.IsZero() {
+		return nil, nil
+	}
+	keys := []*chatv1.RoomKey{{Version: roomKeyVersion(room.keyVersion), EncryptionData: room.encryptionData}}
+	for i := len(room.retiredKeys) - 1; i >= 0; i-- {
+		key := room.retiredKeys[i]
+		keys = append(keys, &chatv1.RoomKey{
+			Version:        key.version,
+			EncryptionData: key.encryptionData,
+			RetiredAt:      formatMemoryTime(key.retiredAt),
+		})
+	}
+	return keys, nil
+}
+
+func (r *MemoryRoomRepository) RegisterDeviceKey(ctx context.Context, userId int, deviceId string, publicKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.deviceKeys[userId] == nil {
+		r.deviceKeys[userId] = make(map[string]*memoryDeviceKey)
+	}
+	r.deviceKeys[userId][deviceId] = &memoryDeviceKey{publicKey: publicKey, updatedAt: r.now()}
+	return nil
+}
+
+// GetDeviceKeys devuelve las claves por usuario y dispositivo, en orden.
+func (r *MemoryRoomRepository) GetDeviceKeys(ctx context.Context, userIds []int, roomId string) ([]*chatv1.DeviceKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if roomId != "" {
+		userIds = nil
+		for userID, member := range r.members[roomId] {
+			if member.active() {
+				userIds = append(userIds, userID)
+			}
+		}
+	}
+	userIds = slices.Clone(userIds)
+	slices.Sort(userIds)
+
+	var keys []*chatv1.DeviceKey
+	for _, userID := range slices.Compact(userIds) {
+		devices := r.deviceKeys[userID]
+		for _, deviceID := range slices.Sorted(maps.Keys(devices)) {
+			keys = append(keys, &chatv1.DeviceKey{
+				UserId:    int32(userID),
+				DeviceId:  deviceID,
+				PublicKey: devices[deviceID].publicKey,
+				UpdatedAt: formatMemoryTime(devices[deviceID].updatedAt),
+			})
+		}
+	}
+	return keys, nil
+}
+
+func (r *MemoryRoomRepository) RotateE2ERoomKey(ctx context.Context, userId int, roomId string, deviceId string, keys []*chatv1.WrappedRoomKey) (int32, error) {
+	if err := validateWrappedKeys(keys); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	room, err := r.e2eRoom(roomId)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.checkE2EDevices(roomId, userId, deviceId, keys); err != nil {
+		return 0, err
+	}
+
+	room.keyVersion = roomKeyVersion(room.keyVersion) + 1
+	room.updatedAt = r.now()
+	r.putWrappedKeys(room, room.keyVersion, userId, deviceId, keys)
+	r.addOutbox(newRoomKeyRotatedEvent(roomId, userId))
+
+	return room.keyVersion, nil
+}
+
+func (r *MemoryRoomRepository) ShareRoomKey(ctx context.Context, userId int, roomId string, version int32, deviceId string, keys []*chatv1.WrappedRoomKey) error {
+	if err := validateWrappedKeys(keys); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	room, err := r.e2eRoom(roomId)
+	if err != nil {
+		return err
+	}
+	if version < 1 || version > roomKeyVersion(room.keyVersion) {
+		return ErrUnknownKeyVersion
+	}
+	if err := r.checkE2EDevices(roomId, userId, deviceId, keys); err != nil {
+		return err
+	}
+
+	r.putWrappedKeys(room, version, userId, deviceId, keys)
+	r.addOutbox(newRoomKeySharedEvent(roomId, userId))
+	return nil
+}
+
+func (r *MemoryRoomRepository) GetWrappedRoomKeys(ctx context.Context, roomId string, userId int, deviceId string) ([]*chatv1.RoomKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	room := r.rooms[roomId]
+	if room == nil {
+		return nil, nil
+	}
+	var keys []*chatv1.RoomKey
+	for id, key := range room.wrappedKeys {
+		if id.userID != userId || id.deviceID != deviceId {
+			continue
+		}
+		keys = append(keys, &chatv1.RoomKey{
+			Version:           id.version,
+			EncryptionData:    key.wrappedKey,
+			WrappedByUserId:   int32(key.senderID),
+			WrappedByDeviceId: key.senderDeviceID,
+		})
+	}
+	slices.SortFunc(keys, func(a, b *chatv1.RoomKey) int { return int(b.Version - a.Version) })
+	return keys, nil
+}
+
+// e2eRoom devuelve la sala si existe y es e2e; se llama con r.mu tomado.
+func (r *MemoryRoomRepository) e2eRoom(roomId string) (*memoryRoom, error) {
+	room := r.rooms[roomId]
+	if room == nil || !room.deletedAt.IsZero() {
+		return nil, sql.ErrNoRows
+	}
+	if !room.e2e {
+		return nil, ErrNotE2ERoom
+	}
+	return room, nil
+}
+
+// checkE2EDevices comprueba que el dispositivo que envuelve y los de destino estén
+// registrados y sean de participantes de la sala; se llama con r.mu tomado.
+func (r *MemoryRoomRepository) checkE2EDevices(roomId string, userId int, deviceId string, keys []*chatv1.WrappedRoomKey) error {
+	registered := func(userID int, deviceID string) bool {
+		return r.activeMember(roomId, userID) != nil && r.deviceKeys[userID][deviceID] != nil
+	}
+	if !registered(userId, deviceId) {
+		return ErrUnknownDevice
+	}
+	for _, key := range keys {
+		if !registered(int(key.UserId), key.DeviceId) {
+			return ErrUnknownDevice
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRoomRepository) putWrappedKeys(room *memoryRoom, version int32, senderId int, senderDeviceId string, keys []*chatv1.WrappedRoomKey) {
+	if room.wrappedKeys == nil {
+		room.wrappedKeys = make(map[memoryWrappedKeyID]memoryWrappedKey)
+	}
+	for _, key := range keys {
+		id := memoryWrappedKeyID{userID: int(key.UserId), deviceID: key.DeviceId, version: version}
+		room.wrappedKeys[id] = memoryWrappedKey{wrappedKey: key.WrappedKey, senderID: senderId, senderDeviceID: senderDeviceId}
+	}
+}
+
+// GetUserReadReceipts devuelve las lecturas del usuario en la sala, por fecha de lectura.
+func (r *MemoryRoomRepository) GetUserReadReceipts(ctx context.Context, userId int, roomId string) ([]UserReadReceipt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var receipts []UserReadReceipt
+	for messageID, metas := range r.metas {
+		msg := r.messages[messageID]
+		meta := metas[userId]
+		if msg == nil || msg.roomID != roomId || meta == nil || meta.readAt.IsZero() {
+			continue
+		}
+		receipts = append(receipts, UserReadReceipt{MessageID: messageID, ReadAt: meta.readAt})
+	}
+	slices.SortFunc(receipts, func(a, b UserReadReceipt) int {
+		if c := a.ReadAt.Compare(b.ReadAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.MessageID, b.MessageID)
+	})
+	return receipts, nil
+}
+
+// EraseUserData borra los datos del usuario igual que SQLRoomRepository.
+func (r *MemoryRoomRepository) EraseUserData(ctx context.Context, userId int) (*UserErasureReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := newUserErasureReport(userId)
+	now := r.now()
+
+	// Salas en orden de ingreso, como en Postgres
+	type membership struct {
+		roomID string
+		member *memoryMember
+	}
+	var memberships []membership
+	for roomID, members := range r.members {
+		if member := members[userId]; member != nil {
+			memberships = append(memberships, membership{roomID, member})
+		}
+	}
+	slices.SortFunc(memberships, func(a, b membership) int {
+		if c := a.member.createdAt.Compare(b.member.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.roomID, b.roomID)
+	})
+
+	for messageID, msg := range r.messages {
+		if msg.forwardSenderID != nil && *msg.forwardSenderID == userId {
+			msg.forwardSenderID = nil
+		}
+		if msg.senderID == userId {
+			msg.content, msg.contentDecrypted = "", ""
+			msg.file, msg.locationName, msg.contactName, msg.contactPhone = nil, nil, nil, nil
+			msg.locationLatitude, msg.locationLongitude, msg.contactID = nil, nil, nil
+			msg.senderMessageID = nil
+			msg.isDeleted = true
+			if msg.deletedAt.IsZero() {
+				msg.deletedAt = now
+			}
+			msg.updatedAt = now
+			delete(r.metas, messageID)
+			delete(r.tags, messageID)
+			delete(r.reactions, messageID)
+			report.MessagesAnonymised++
+			continue
+		}
+		delete(r.metas[messageID], userId)
+		r.tags[messageID] = slices.DeleteFunc(r.tags[messageID], func(tag memoryTag) bool { return tag.userID == userId })
+		r.reactions[messageID] = slices.DeleteFunc(r.reactions[messageID], func(reaction *memoryReaction) bool { return reaction.userID == userId })
+	}
+
+	delete(r.deviceKeys, userId)
+	for _, room := range r.rooms {
+		maps.DeleteFunc(room.wrappedKeys, func(id memoryWrappedKeyID, _ memoryWrappedKey) bool { return id.userID == userId })
+	}
+
+	for _, m := range memberships {
+		report.Rooms = append(report.Rooms, m.roomID)
+		delete(r.members[m.roomID], userId)
+
+		room := r.rooms[m.roomID]
+		if !m.member.removedAt.IsZero() || room == nil || !room.deletedAt.IsZero() {
+			continue
+		}
+
+		closeRoom := room.kind == "p2p"
+		if !closeRoom {
+			report.RoomsLeft = append(report.RoomsLeft, m.roomID)
+
+			var oldest *memoryMember
+			owners := 0
+			for _, member := range r.members[m.roomID] {
+				if !member.removedAt.IsZero() {
+					continue
+				}
+				if member.role == "OWNER" {
+					owners++
+				}
+				if oldest == nil || member.createdAt.Before(oldest.createdAt) || (member.createdAt.Equal(oldest.createdAt) && member.userID < oldest.userID) {
+					oldest = member
+				}
+			}
+			if m.member.role == "OWNER" && owners == 0 && oldest != nil {
+				oldest.role = "OWNER"
+				oldest.updatedAt = now
+				report.OwnersPromoted[m.roomID] = oldest.userID
+			}
+			closeRoom = oldest == nil
+		}
+
+		if closeRoom {
+			report.RoomsClosed = append(report.RoomsClosed, m.roomID)
+			room.updatedAt, room.deletedAt = now, now
+			for _, member := range r.members[m.roomID] {
+				if member.removedAt.IsZero() {
+					member.updatedAt, member.removedAt = now, now
+				}
+			}
+		}
+
+		r.addOutbox(newUserErasedEvent(m.roomID, userId, now))
+	}
+
+	return report, nil
+}
+
+// VerifyUserErasure busca lo que queda del usuario, con los mismos criterios que Postgres.
+func (r *MemoryRoomRepository) VerifyUserErasure(ctx context.Context, userId int, roomIds []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := map[string]int{}
+	for messageID, msg := range r.messages {
+		if msg.senderID == userId && (msg.content != "" || msg.contentDecrypted != "" || msg.file != nil || msg.locationName != nil || msg.contactPhone != nil || msg.senderMessageID != nil) {
+			counts["messages with content"]++
+		}
+		if msg.forwardSenderID != nil && *msg.forwardSenderID == userId {
+			counts["forwarded message references"]++
+		}
+		for _, reaction := range r.reactions[messageID] {
+			if reaction.userID == userId {
+				counts["reactions"]++
+			}
+		}
+		for _, tag := range r.tags[messageID] {
+			if tag.userID == userId {
+				counts["mentions"]++
+			}
+		}
+		if _, ok := r.metas[messageID][userId]; ok {
+			counts["message meta"]++
+		}
+	}
+	for _, members := range r.members {
+		if _, ok := members[userId]; ok {
+			counts["room memberships"]++
+		}
+	}
+	if devices := len(r.deviceKeys[userId]); devices > 0 {
+		counts["device keys"] = devices
+	}
+	for _, room := range r.rooms {
+		for id := range room.wrappedKeys {
+			if id.userID == userId {
+				counts["wrapped room keys"]++
+			}
+		}
+	}
+
+	var remaining []string
+	for _, name := range slices.Sorted(maps.Keys(counts)) {
+		remaining = append(remaining, fmt.Sprintf("%s: %d", name, counts[name]))
+	}
+	return remaining, nil
+}
+
+// PurgeOutboxEvents elimina los eventos ya enviados antes de sentBefore.
+func (r *MemoryRoomRepository) PurgeOutboxEvents(ctx context.Context, sentBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before := len(r.outbox)
+	r.outbox = slices.DeleteFunc(r.outbox, func(entry *memoryOutboxEntry) bool {
+		return !entry.sentAt.IsZero() && entry.sentAt.Before(sentBefore)
+	})
+	return int64(before - len(r.outbox)), nil
+}
+
+// ReconcileUnreadCounters no hace nada: los conteos se calculan al leer.
+func (r *MemoryRoomRepository) ReconcileUnreadCounters(ctx context.Context, batchSize int) (*UnreadReconcileReport, error) {
+	return &UnreadReconcileReport{}, nil
+}
+
+// VoidAbandonedSeqs no hace nada: el seq se asigna con el mensaje bajo el mismo lock.
+func (r *MemoryRoomRepository) VoidAbandonedSeqs(ctx context.Context, reservedBefore time.Time) (int, error) {
+	return 0, nil
+}
+
+// GetUserMessageRooms devuelve las salas con mensajes enviados por el usuario, incluidas
+// las eliminadas y las que dejó.
+func (r *MemoryRoomRepository) GetUserMessageRooms(ctx context.Context, userId int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var rooms []string
+	for _, msg := range r.messages {
+		if msg.senderID == userId && !slices.Contains(rooms, msg.roomID) {
+			rooms = append(rooms, msg.roomID)
+		}
+	}
+	slices.Sort(rooms)
+	return rooms, nil
+}
+
+// GetUserSentMessages devuelve los mensajes que el usuario envió en la sala, por orden de
+// envío, aunque ya no participe en ella.
+func (r *MemoryRoomRepository) GetUserSentMessages(ctx context.Context, userId int, roomId string) ([]*chatv1.MessageData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sent []*memoryMessage
+	for _, msg := range r.messages {
+		if msg.senderID == userId && msg.roomID == roomId {
+			sent = append(sent, msg)
+		}
+	}
+	slices.SortFunc(sent, func(a, b *memoryMessage) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.id, b.id)
+	})
+
+	messages := make([]*chatv1.MessageData, 0, len(sent))
+	for _, msg := range sent {
+		if message := r.messageData(msg, userId, true); message != nil {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
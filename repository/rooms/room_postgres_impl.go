@@ -6,16 +6,19 @@ import (
 	"fmt"
 	"math"
 	"slices"
+	"strconv"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
 	"github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/utils"
 	dbpq "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+	"github.com/google/uuid"
 )
 
 type SQLRoomRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	counters *unreadCounters // contadores de no leídos en Redis; nil los cuenta en la base de datos
 }
 
 func NewSQLRoomRepository(db *sql.DB) RoomsRepository {
@@ -28,7 +31,7 @@ func (r *SQLRoomRepository) CreateRoom(ctx context.Context, userId int, room *ch
 
 	if room.Type == "p2p" {
 		query := dbpq.QueryBuilder().
-			Select("room.id", "room.created_at", "room.updated_at", "room.image", "room.name", "room.description", "room.type", "room.encription_data", "room.join_all_user", "room.\"lastMessageAt\"", "room.send_message", "room.add_member", "room.	edit_group", "partner.id", "partner.name", "partner.phone", "partner.avatar", "me.id", "me.name", "me.phone", "mm.is_muted", "mm.\"is_pinned\"", "mm.is_partner_blocked", "mm.role").
+			Select("room.id", "room.created_at", "room.updated_at", "room.image", "room.name", "room.description", "room.type", "room.encription_data", "room.key_version", "room.e2e", "room.join_all_user", "room.\"lastMessageAt\"", "room.send_message", "room.add_member", "room.	edit_group", "partner.id", "partner.name", "partner.phone", "partner.avatar", "me.id", "me.name", "me.phone", "mm.is_muted", "mm.\"is_pinned\"", "mm.is_partner_blocked", "mm.role").
 			From("room").
 			InnerJoin("room_member AS pm ON room.id = pm.room_id AND pm.user_id = ? AND pm.removed_at IS NULL AND pm.deleted_at IS NULL", room.Participants[0]).
 			InnerJoin(`public."user" AS partner ON pm.user_id = partner.id`).
@@ -69,7 +72,7 @@ func (r *SQLRoomRepository) CreateRoom(ctx context.Context, userId int, room *ch
 			var isPartnerBlocked sql.NullBool
 			var role sql.NullString
 
-			var err = rows.Scan(&item.Id, &item.CreatedAt, &item.UpdatedAt, &photoURL, &name, &description, &item.Type, &encryptionData, &joinAllUser, &lastMessageAt, &item.SendMessage, &item.AddMember, &item.EditGroup, &partnerID, &partnerName, &partnerPhone, &partnerAvatar, &meID, &meName, &mePhone, &isMuted, &isPinned, &isPartnerBlocked, &role)
+			var err = rows.Scan(&item.Id, &item.CreatedAt, &item.UpdatedAt, &photoURL, &name, &description, &item.Type, &encryptionData, &item.KeyVersion, &item.E2E, &joinAllUser, &lastMessageAt, &item.SendMessage, &item.AddMember, &item.EditGroup, &partnerID, &partnerName, &partnerPhone, &partnerAvatar, &meID, &meName, &mePhone, &isMuted, &isPinned, &isPartnerBlocked, &role)
 			if err != nil {
 				return nil, err
 			}
@@ -95,10 +98,15 @@ func (r *SQLRoomRepository) CreateRoom(ctx context.Context, userId int, room *ch
 	}
 
 	//generate key and iv
-	encryptionData, err := utils.GenerateKeyEncript()
-	if err != nil {
-		fmt.Println("error", err)
-		return nil, err
+	// Las salas e2e no tienen clave en el servidor (ver e2e.go)
+	var encryptionData *string
+	if !room.GetE2E() {
+		key, err := utils.GenerateKeyEncript()
+		if err != nil {
+			fmt.Println("error", err)
+			return nil, err
+		}
+		encryptionData = &key
 	}
 
 	tx, err := r.db.BeginTx(ctx, nil)
@@ -143,6 +151,7 @@ func (r *SQLRoomRepository) CreateRoom(ctx context.Context, userId int, room *ch
 			"add_member":      room.AddMember,
 			"edit_group":      room.EditGroup,
 			"encription_data": encryptionData,
+			"e2e":             room.GetE2E(),
 			"created_at":      sq.Expr("NOW()"),
 			"type":            room.Type,
 		}).
@@ -171,7 +180,11 @@ func (r *SQLRoomRepository) CreateRoom(ctx context.Context, userId int, room *ch
 		newRoom.SendMessage = *room.SendMessage
 		newRoom.AddMember = *room.AddMember
 		newRoom.EditGroup = *room.EditGroup
-		newRoom.EncryptionData = encryptionData
+		if encryptionData != nil {
+			newRoom.EncryptionData = *encryptionData
+		}
+		newRoom.KeyVersion = 1
+		newRoom.E2E = room.GetE2E()
 		newRoom.CreatedAt = time.Now().Format("2006-01-02T15:04:05.000000-07:00")
 		newRoom.UpdatedAt = time.Now().Format("2006-01-02T15:04:05.000000-07:00")
 		newRoom.Type = room.Type
@@ -224,6 +237,18 @@ func (r *SQLRoomRepository) CreateRoom(ctx context.Context, userId int, room *ch
 		return nil, err
 	}
 
+	// RoomJoin de cada participante y del creador
+	joined := make([]int, 0, len(room.Participants)+1)
+	for _, participant := range room.Participants {
+		if participant != int32(userId) {
+			joined = append(joined, int(participant))
+		}
+	}
+	joined = append(joined, userId)
+	if err = insertOutboxEvents(ctx, tx, newRoomJoinEvents(newRoom.Id, userId, joined, userId, time.Now())...); err != nil {
+		return nil, err
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return nil, err
@@ -278,17 +303,15 @@ func (r *SQLRoomRepository) CreateRoom(ctx context.Context, userId int, room *ch
 
 func (r *SQLRoomRepository) GetRoom(ctx context.Context, userId int, roomId string, allData bool, cache bool) (*chatv1.Room, error) {
 
-	cacheKey := fmt.Sprintf("endpoint:chat:room:{%s}:shim:user:%d", roomId, userId)
-	if allData {
-		cacheKey = fmt.Sprintf("endpoint:chat:room:{%s}:user:%d", roomId, userId)
-	}
-	dataCached, existsCached := GetCachedRoom(ctx, cacheKey)
+	cacheKey := roomCacheKey(roomId, userId, allData)
+	dataCached, fill, existsCached := GetCachedRoom(ctx, cacheKey)
 	if existsCached {
 		return dataCached, nil
 	}
 
+	unreadColumn, unreadArgs := r.unreadCountColumn(userId)
 	query := dbpq.QueryBuilder().
-		Select("room.id", "room.created_at", "room.updated_at", "room.image", "room.name", "room.description", "room.type", "room.encription_data", "room.join_all_user", "room.\"lastMessageAt\"", "room.send_message", "room.add_member", "room.edit_group", "partner.id", "partner.name", "partner.phone", "partner.avatar", "pm.is_partner_blocked", "pm.is_muted", "me.id", "me.name", "me.phone", "mm.is_muted", "mm.\"is_pinned\"", "mm.is_partner_blocked", "mm.role",
+		Select("room.id", "room.created_at", "room.updated_at", "room.image", "room.name", "room.description", "room.type", "room.encription_data", "room.key_version", "room.e2e", "room.join_all_user", "room.\"lastMessageAt\"", "room.send_message", "room.add_member", "room.edit_group", "partner.id", "partner.name", "partner.phone", "partner.avatar", "pm.is_partner_blocked", "pm.is_muted", "me.id", "me.name", "me.phone", "mm.is_muted", "mm.\"is_pinned\"", "mm.is_partner_blocked", "mm.role", "room.retention_days", "room.history_purged_before",
 			// Último mensaje
 			"last_msg.id AS last_message_id",
 			"last_msg.content AS last_message_content",
@@ -299,7 +322,7 @@ func (r *SQLRoomRepository) GetRoom(ctx context.Context, userId int, roomId stri
 			"last_msg.status AS last_message_status",
 			"last_msg.updated_at AS last_message_updated_at",
 			// Conteo de mensajes no leídos
-			"(SELECT COUNT(*) FROM room_message AS unread_msg LEFT JOIN room_message_meta AS unread_meta ON unread_msg.id = unread_meta.message_id AND unread_meta.user_id = ? AND (unread_meta.\"isDeleted\" = false OR unread_meta.\"isDeleted\" IS NULL) WHERE unread_msg.room_id = room.id AND unread_msg.deleted_at IS NULL AND unread_meta.read_at IS NULL) AS unread_count").
+			unreadColumn).
 		From("room_member AS mm").
 		InnerJoin("room ON room.id = mm.room_id AND mm.user_id = ? AND mm.removed_at IS NULL AND mm.deleted_at IS NULL", userId).
 		InnerJoin("public.\"user\" AS me ON mm.user_id = me.id").
@@ -323,7 +346,7 @@ func (r *SQLRoomRepository) GetRoom(ctx context.Context, userId int, roomId stri
 		return nil, err
 	}
 
-	args = append([]any{userId}, args...)
+	args = append(unreadArgs, args...)
 
 	rows, err := r.db.QueryContext(ctx, queryString, args...)
 	if err != nil {
@@ -352,6 +375,8 @@ func (r *SQLRoomRepository) GetRoom(ctx context.Context, userId int, roomId stri
 		var isPinned sql.NullBool
 		var isPartnerBlocked sql.NullBool
 		var role sql.NullString
+		var retentionDays sql.NullInt32
+		var historyPurgedBefore sql.NullString
 		// Campos del último mensaje
 		var lastMessageId sql.NullString
 		var lastMessageContent sql.NullString
@@ -364,7 +389,7 @@ func (r *SQLRoomRepository) GetRoom(ctx context.Context, userId int, roomId stri
 		// Conteo de mensajes no leídos
 		var unreadCount sql.NullInt32
 
-		var err = rows.Scan(&item.Id, &item.CreatedAt, &updatedAt, &photoURL, &name, &description, &item.Type, &encryptionData, &joinAllUser, &lastMessageAt, &item.SendMessage, &item.AddMember, &item.EditGroup, &partnerID, &partnerName, &partnerPhone, &partnerAvatar, &partnerBlocked, &partnerMuted, &meID, &meName, &mePhone, &isMuted, &isPinned, &isPartnerBlocked, &role,
+		var err = rows.Scan(&item.Id, &item.CreatedAt, &updatedAt, &photoURL, &name, &description, &item.Type, &encryptionData, &item.KeyVersion, &item.E2E, &joinAllUser, &lastMessageAt, &item.SendMessage, &item.AddMember, &item.EditGroup, &partnerID, &partnerName, &partnerPhone, &partnerAvatar, &partnerBlocked, &partnerMuted, &meID, &meName, &mePhone, &isMuted, &isPinned, &isPartnerBlocked, &role, &retentionDays, &historyPurgedBefore,
 			&lastMessageId, &lastMessageContent, &lastMessageType, &lastMessageCreatedAt, &lastMessageSenderName, &lastMessageSenderPhone, &lastMessageStatus, &lastMessageUpdatedAt, &unreadCount)
 		if err != nil {
 			return nil, err
@@ -380,6 +405,10 @@ func (r *SQLRoomRepository) GetRoom(ctx context.Context, userId int, roomId stri
 		item.IsPinned = isPinned.Bool
 		item.IsMuted = isMuted.Bool
 		item.IsPartnerBlocked = isPartnerBlocked.Bool
+		if retentionDays.Valid {
+			item.RetentionDays = &retentionDays.Int32
+		}
+		item.HistoryPurgedBefore = historyPurgedBefore.String
 
 		// Agregar el último mensaje si existe
 		if lastMessageId.Valid {
@@ -422,7 +451,11 @@ func (r *SQLRoomRepository) GetRoom(ctx context.Context, userId int, roomId stri
 
 		item = utils.FormatRoom(item)
 
-		SetCachedRoom(ctx, roomId, cacheKey, item)
+		if err := r.fillUnreadCounts(ctx, userId, []*chatv1.Room{item}); err != nil {
+			return nil, err
+		}
+
+		SetCachedRoom(ctx, roomId, fill, item)
 
 		return item, nil
 	}
@@ -431,9 +464,14 @@ func (r *SQLRoomRepository) GetRoom(ctx context.Context, userId int, roomId stri
 }
 
 func (r *SQLRoomRepository) GetRoomList(ctx context.Context, userId int, pagination *chatv1.GetRoomsRequest) ([]*chatv1.Room, *chatv1.PaginationMeta, error) {
+	cursor, err := decodeCursor(pagination.GetCursor(), cursorRooms, 4)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	unreadColumn, unreadArgs := r.unreadCountColumn(userId)
 	query := dbpq.QueryBuilder().
-		Select("room.id", "room.created_at", "room.updated_at", "room.image", "room.name", "room.description", "room.type", "room.encription_data", "room.join_all_user", "room.\"lastMessageAt\"", "room.send_message", "room.add_member", "room.edit_group", "partner.id", "partner.name", "partner.phone", "partner.avatar", "pm.is_partner_blocked", "me.id", "me.name", "me.phone", "mm.is_muted", "mm.\"is_pinned\"", "mm.is_partner_blocked", "mm.role",
+		Select("room.id", "room.created_at", "room.updated_at", "room.image", "room.name", "room.description", "room.type", "room.encription_data", "room.key_version", "room.e2e", "room.join_all_user", "room.\"lastMessageAt\"", "room.send_message", "room.add_member", "room.edit_group", "partner.id", "partner.name", "partner.phone", "partner.avatar", "pm.is_partner_blocked", "me.id", "me.name", "me.phone", "mm.is_muted", "mm.\"is_pinned\"", "mm.is_partner_blocked", "mm.role",
 			// Último mensaje
 			"last_msg.id AS last_message_id",
 			"last_msg.content AS last_message_content",
@@ -444,7 +482,7 @@ func (r *SQLRoomRepository) GetRoomList(ctx context.Context, userId int, paginat
 			"last_msg.status AS last_message_status",
 			"last_msg.updated_at AS last_message_updated_at",
 			// Conteo de mensajes no leídos
-			"(SELECT COUNT(*) FROM room_message AS unread_msg LEFT JOIN room_message_meta AS unread_meta ON unread_msg.id = unread_meta.message_id AND unread_meta.user_id = ? AND (unread_meta.\"isDeleted\" = false OR unread_meta.\"isDeleted\" IS NULL) WHERE unread_msg.room_id = room.id AND unread_msg.deleted_at IS NULL AND unread_meta.read_at IS NULL) AS unread_count").
+			unreadColumn).
 		From("room_member AS mm").
 		InnerJoin("room ON room.id = mm.room_id AND mm.user_id = ? AND mm.removed_at IS NULL AND mm.deleted_at IS NULL", userId).
 		InnerJoin("public.\"user\" AS me ON mm.user_id = me.id").
@@ -460,13 +498,24 @@ func (r *SQLRoomRepository) GetRoomList(ctx context.Context, userId int, paginat
 		LeftJoin("public.\"user\" AS last_sender ON last_msg.sender_id = last_sender.id").
 		Where(sq.Eq{"room.deleted_at": nil})
 
+	pageLimit := 0
 	if pagination != nil {
 		if pagination.Search != "" {
-			query = query.Where("unaccent(room.name) ILIKE unaccent(?) OR unaccent(partner.name) ILIKE unaccent(?)", "%"+pagination.Search+"%", "%"+pagination.Search+"%")
+			query = query.Where("(unaccent(room.name) ILIKE unaccent(?) OR unaccent(partner.name) ILIKE unaccent(?))", "%"+pagination.Search+"%", "%"+pagination.Search+"%")
 		}
 
-		if pagination.Page > 0 && pagination.Limit > 0 {
-			query = query.Offset(uint64((pagination.Page - 1) * pagination.Limit)).Limit(uint64(pagination.Limit))
+		if pagination.Limit > 0 && (cursor != nil || pagination.Page > 0) {
+			pageLimit = int(pagination.Limit)
+		}
+		if cursor != nil {
+			query = query.Where(`(COALESCE(mm."is_pinned", false), COALESCE(room."lastMessageAt", 'infinity'), room.created_at, room.id) < (?::boolean, ?::timestamptz, ?::timestamptz, ?::uuid)`,
+				cursor.Keys[0], cursor.Keys[1], cursor.Keys[2], cursor.Keys[3])
+		} else if pageLimit > 0 {
+			query = query.Offset(uint64((pagination.Page - 1) * pagination.Limit))
+		}
+		// Se pide un elemento de más para saber si hay página siguiente
+		if pageLimit > 0 {
+			query = query.Limit(uint64(pageLimit) + 1)
 		}
 
 		if pagination.Type != "" {
@@ -474,11 +523,14 @@ func (r *SQLRoomRepository) GetRoomList(ctx context.Context, userId int, paginat
 		}
 
 		if pagination.Since != "" {
-			query = query.Where("room.updated_at > ? OR mm.updated_at > ?", pagination.Since, pagination.Since)
+			query = query.Where("(room.updated_at > ? OR mm.updated_at > ?)", pagination.Since, pagination.Since)
 		}
 	}
 
-	query = query.OrderBy("mm.\"is_pinned\" DESC, room.\"lastMessageAt\" DESC, room.created_at DESC")
+	// COALESCE(..., 'infinity') DESC equivale a NULLS FIRST y permite comparar la clave
+	// completa en el cursor; room.id desempata salas creadas en el mismo instante. is_pinned
+	// NULL cuenta como false, igual que en la clave que se guarda en el cursor
+	query = query.OrderBy(`COALESCE(mm."is_pinned", false) DESC, COALESCE(room."lastMessageAt", 'infinity') DESC, room.created_at DESC, room.id DESC`)
 
 	queryString, args, err := query.ToSql()
 	if err != nil {
@@ -486,7 +538,7 @@ func (r *SQLRoomRepository) GetRoomList(ctx context.Context, userId int, paginat
 	}
 
 	// Agregar el userId para la subconsulta de conteo de no leídos
-	args = append([]any{userId}, args...)
+	args = append(unreadArgs, args...)
 
 	rows, err := r.db.QueryContext(ctx, queryString, args...)
 	if err != nil {
@@ -494,6 +546,8 @@ func (r *SQLRoomRepository) GetRoomList(ctx context.Context, userId int, paginat
 	}
 
 	data := []*chatv1.Room{}
+	var lastKey []string
+	var nextCursor string
 
 	for rows.Next() {
 		item := &chatv1.Room{}
@@ -528,7 +582,7 @@ func (r *SQLRoomRepository) GetRoomList(ctx context.Context, userId int, paginat
 		// Conteo de mensajes no leídos
 		var unreadCount sql.NullInt32
 
-		var err = rows.Scan(&item.Id, &item.CreatedAt, &updatedAt, &photoURL, &name, &description, &item.Type, &encryptionData, &joinAllUser, &lastMessageAt, &item.SendMessage, &item.AddMember, &item.EditGroup, &partnerID, &partnerName, &partnerPhone, &partnerAvatar, &partnerBlocked, &meID, &meName, &mePhone, &isMuted, &isPinned, &isPartnerBlocked, &role,
+		var err = rows.Scan(&item.Id, &item.CreatedAt, &updatedAt, &photoURL, &name, &description, &item.Type, &encryptionData, &item.KeyVersion, &item.E2E, &joinAllUser, &lastMessageAt, &item.SendMessage, &item.AddMember, &item.EditGroup, &partnerID, &partnerName, &partnerPhone, &partnerAvatar, &partnerBlocked, &meID, &meName, &mePhone, &isMuted, &isPinned, &isPartnerBlocked, &role,
 			&lastMessageId, &lastMessageContent, &lastMessageType, &lastMessageCreatedAt, &lastMessageSenderName, &lastMessageSenderPhone, &lastMessageStatus, &lastMessageUpdatedAt, &unreadCount)
 		if err != nil {
 			return nil, nil, err
@@ -576,10 +630,26 @@ func (r *SQLRoomRepository) GetRoomList(ctx context.Context, userId int, paginat
 			}
 		}
 
+		if pageLimit > 0 && len(data) == pageLimit {
+			// Fila de más: la página siguiente empieza después de la última devuelta
+			nextCursor = encodeCursor(cursorRooms, lastKey...)
+			break
+		}
+		lastKeyAt := "infinity"
+		if lastMessageAt.Valid {
+			lastKeyAt = lastMessageAt.String
+		}
+		lastKey = []string{strconv.FormatBool(isPinned.Bool), lastKeyAt, item.CreatedAt, item.Id}
+
 		item = utils.FormatRoom(item)
 
 		data = append(data, item)
 	}
+	rows.Close()
+
+	if err := r.fillUnreadCounts(ctx, userId, data); err != nil {
+		return nil, nil, err
+	}
 
 	//get room participants (max 5) only if type is group
 	allRoomIds := []string{}
@@ -652,11 +722,11 @@ func (r *SQLRoomRepository) GetRoomList(ctx context.Context, userId int, paginat
 		}
 
 		if pagination.Search != "" {
-			queryTotal = queryTotal.Where("unaccent(room.name) ILIKE unaccent(?) OR unaccent(partner.name) ILIKE unaccent(?)", "%"+pagination.Search+"%", "%"+pagination.Search+"%")
+			queryTotal = queryTotal.Where("(unaccent(room.name) ILIKE unaccent(?) OR unaccent(partner.name) ILIKE unaccent(?))", "%"+pagination.Search+"%", "%"+pagination.Search+"%")
 		}
 
 		if pagination.Since != "" {
-			queryTotal = queryTotal.Where("room.updated_at > ? OR mm.updated_at > ?", pagination.Since, pagination.Since)
+			queryTotal = queryTotal.Where("(room.updated_at > ? OR mm.updated_at > ?)", pagination.Since, pagination.Since)
 		}
 	}
 
@@ -694,6 +764,7 @@ func (r *SQLRoomRepository) GetRoomList(ctx context.Context, userId int, paginat
 		ItemsPerPage: limit,
 		TotalPages:   uint32(math.Ceil(float64(totalItemsCount) / float64(limit))),
 		CurrentPage:  page,
+		NextCursor:   nextCursor,
 	}
 
 	return data, &meta, nil
@@ -770,24 +841,39 @@ func (r *SQLRoomRepository) LeaveRoom(ctx context.Context, userId int, roomId st
 
 	participants = slices.Compact(participants)
 
-	query := dbpq.QueryBuilder().
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = dbpq.QueryBuilder().
 		Update("room_member").
 		Set("removed_at", sq.Expr("NOW()")).
 		Set("updated_at", sq.Expr("NOW()")).
 		Where(sq.Eq{"room_id": roomId}).
 		Where(sq.Eq{"user_id": participants}).
-		Where(sq.Eq{"removed_at": nil})
-
-	queryString, args, err := query.ToSql()
+		Where(sq.Eq{"removed_at": nil}).
+		RunWith(tx).
+		ExecContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = r.db.ExecContext(ctx, queryString, args...)
+	err = insertOutboxEvents(ctx, tx, newOutboxEvent(roomId, userId, OutboxRoomLeave, "", &chatv1.MessageEvent{
+		RoomId: roomId,
+		Event: &chatv1.MessageEvent_RoomLeave{RoomLeave: &chatv1.RoomLeaveEvent{
+			UsersId: participants,
+		}},
+	}))
 	if err != nil {
 		return nil, err
 	}
 
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	DeleteRoomCacheByRoomID(ctx, roomId)
 
 	// Convert []int32 a []int para que sea compatible con GetUsersByID
@@ -795,6 +881,7 @@ func (r *SQLRoomRepository) LeaveRoom(ctx context.Context, userId int, roomId st
 	for i, v := range participants {
 		ids[i] = int(v)
 	}
+	r.forgetUnreadCounters(ctx, roomId, ids)
 
 	users, err := r.GetUsersByID(ctx, ids)
 	if err != nil {
@@ -854,6 +941,10 @@ func (r *SQLRoomRepository) DeleteRoom(ctx context.Context, userId int, roomId s
 }
 
 func (r *SQLRoomRepository) GetRoomParticipants(ctx context.Context, pagination *chatv1.GetRoomParticipantsRequest) ([]*chatv1.RoomParticipant, *chatv1.PaginationMeta, error) {
+	cursor, err := decodeCursor(pagination.Cursor, cursorParticipants, 2)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	query := dbpq.QueryBuilder().
 		Select("room_member.user_id", "room_member.role", "uu.name", "uu.phone", "uu.avatar").
@@ -867,11 +958,21 @@ func (r *SQLRoomRepository) GetRoomParticipants(ctx context.Context, pagination
 		query = query.Where("unaccent(uu.name) ILIKE unaccent(?)", "%"+pagination.Search+"%")
 	}
 
-	if pagination.Page > 0 && pagination.Limit > 0 {
-		query = query.Offset(uint64((pagination.Page - 1) * pagination.Limit)).Limit(uint64(pagination.Limit))
+	pageLimit := 0
+	if pagination.Limit > 0 && (cursor != nil || pagination.Page > 0) {
+		pageLimit = int(pagination.Limit)
+	}
+	if cursor != nil {
+		query = query.Where("(uu.name, uu.id) > (?, ?::int)", cursor.Keys[0], cursor.Keys[1])
+	} else if pageLimit > 0 {
+		query = query.Offset(uint64((pagination.Page - 1) * pagination.Limit))
+	}
+	// Se pide un elemento de más para saber si hay página siguiente
+	if pageLimit > 0 {
+		query = query.Limit(uint64(pageLimit) + 1)
 	}
 
-	query = query.OrderBy("uu.name ASC")
+	query = query.OrderBy("uu.name ASC", "uu.id ASC")
 
 	queryString, args, err := query.ToSql()
 	if err != nil {
@@ -884,8 +985,15 @@ func (r *SQLRoomRepository) GetRoomParticipants(ctx context.Context, pagination
 	}
 
 	data := []*chatv1.RoomParticipant{}
+	var nextCursor string
 
 	for rows.Next() {
+		if pageLimit > 0 && len(data) == pageLimit {
+			last := data[len(data)-1]
+			nextCursor = encodeCursor(cursorParticipants, last.Name, strconv.Itoa(int(last.Id)))
+			break
+		}
+
 		item := &chatv1.RoomParticipant{}
 		var name sql.NullString
 		var phone sql.NullString
@@ -901,6 +1009,7 @@ func (r *SQLRoomRepository) GetRoomParticipants(ctx context.Context, pagination
 
 		data = append(data, item)
 	}
+	rows.Close()
 
 	queryTotal := dbpq.QueryBuilder().
 		Select("COUNT(*)").
@@ -948,6 +1057,7 @@ func (r *SQLRoomRepository) GetRoomParticipants(ctx context.Context, pagination
 		ItemsPerPage: limit,
 		TotalPages:   uint32(math.Ceil(float64(totalItemsCount) / float64(limit))),
 		CurrentPage:  page,
+		NextCursor:   nextCursor,
 	}
 
 	return data, &meta, nil
@@ -978,6 +1088,12 @@ func (r *SQLRoomRepository) PinRoom(ctx context.Context, userId int, roomId stri
 
 func (r *SQLRoomRepository) MuteRoom(ctx context.Context, userId int, roomId string, mute bool) error {
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := dbpq.QueryBuilder().
 		Update("room_member").
 		Set("\"is_muted\"", mute).
@@ -990,11 +1106,18 @@ func (r *SQLRoomRepository) MuteRoom(ctx context.Context, userId int, roomId str
 		return err
 	}
 
-	_, err = r.db.ExecContext(ctx, queryString, args...)
+	_, err = tx.ExecContext(ctx, queryString, args...)
 	if err != nil {
 		return err
 	}
 
+	if err = insertOutboxEvents(ctx, tx, newRoomUpdatedEvent(roomId, userId)); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
 	DeleteRoomCacheByRoomID(ctx, roomId)
 
 	return nil
@@ -1024,6 +1147,9 @@ func (r *SQLRoomRepository) UpdateRoom(ctx context.Context, userId int, roomId s
 	if room.EditGroup != nil {
 		query = query.Set("edit_group", room.EditGroup)
 	}
+	if room.RetentionDays != nil {
+		query = query.Set("retention_days", retentionDaysUpdate(*room.RetentionDays))
+	}
 
 	query = query.Where(sq.Eq{"id": roomId})
 	query = query.Where(sq.Eq{"deleted_at": nil})
@@ -1033,11 +1159,24 @@ func (r *SQLRoomRepository) UpdateRoom(ctx context.Context, userId int, roomId s
 		return err
 	}
 
-	_, err = r.db.ExecContext(ctx, queryString, args...)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, queryString, args...)
 	if err != nil {
 		return err
 	}
 
+	if err = insertOutboxEvents(ctx, tx, newRoomUpdatedEvent(roomId, userId)); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
 	DeleteRoomCacheByRoomID(ctx, roomId)
 
 	return nil
@@ -1207,6 +1346,14 @@ func (r *SQLRoomRepository) AddParticipantToRoom(ctx context.Context, userId int
 		}
 	}
 
+	joined := make([]int, len(newParticipantsData))
+	for i, participant := range newParticipantsData {
+		joined[i] = participant.ID
+	}
+	if err = insertOutboxEvents(ctx, tx, newRoomJoinEvents(roomId, userId, joined, 0, time.Now())...); err != nil {
+		return nil, err
+	}
+
 	// Commit de la transacción
 	err = tx.Commit()
 	if err != nil {
@@ -1214,6 +1361,7 @@ func (r *SQLRoomRepository) AddParticipantToRoom(ctx context.Context, userId int
 	}
 
 	DeleteRoomCacheByRoomID(ctx, roomId)
+	r.forgetUnreadCounters(ctx, roomId, participants)
 
 	return newParticipantsData, nil
 }
@@ -1231,10 +1379,23 @@ func (r *SQLRoomRepository) UpdateParticipantRoom(ctx context.Context, userId in
 		return err
 	}
 
-	_, err = r.db.ExecContext(ctx, queryString, args...)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, queryString, args...)
+	if err != nil {
+		return err
+	}
+
+	if err = insertOutboxEvents(ctx, tx, newRoomUpdatedEvent(req.GetId(), userId)); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
 
 	DeleteRoomCacheByRoomID(ctx, req.GetId())
 
@@ -1311,11 +1472,32 @@ func (r *SQLRoomRepository) SaveMessage(ctx context.Context, userId int, req *ch
 		contentDecrypted = &[]string{""}[0]
 	}
 
-	// 1. Insertar el mensaje principal
+	// 1. Reservar el siguiente seq de la sala. El lock de la fila serializa a los remitentes
+	// concurrentes hasta el commit y un rollback libera el número, así no quedan huecos.
+	var seq int64
+	err = dbpq.QueryBuilder().
+		Update("public.room").
+		Set("last_seq", sq.Expr("last_seq + 1")).
+		Where(sq.Eq{"id": req.RoomId}).
+		Suffix("RETURNING last_seq").
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&seq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve message seq: %w", err)
+	}
+
+	// 2. Insertar el mensaje principal. El id es basado en tiempo (v1) para que también sea
+	// un timeuuid válido en Scylla y el mensaje conserve su id al migrar de store.
+	newMessageId, err := uuid.NewUUID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate message id: %w", err)
+	}
 	var messageId string
 	insertMessageQuery := dbpq.QueryBuilder().
 		Insert("public.room_message").
 		SetMap(sq.Eq{
+			"id":                                newMessageId.String(),
 			"room_id":                           req.RoomId,
 			"sender_id":                         userId,
 			"content":                           req.Content,
@@ -1340,6 +1522,8 @@ func (r *SQLRoomRepository) SaveMessage(ctx context.Context, userId int, req *ch
 			"forwarded_message_original_sender": forwardUserId,
 			"event":                             req.Event,
 			"sender_message_id":                 req.SenderMessageId,
+			"seq":                               seq,
+			"key_version":                       req.KeyVersion,
 		}).
 		Suffix("RETURNING id").
 		RunWith(tx)
@@ -1349,7 +1533,7 @@ func (r *SQLRoomRepository) SaveMessage(ctx context.Context, userId int, req *ch
 		return nil, fmt.Errorf("failed to insert message: %w", err)
 	}
 
-	// 2. Insertar menciones si existen
+	// 3. Insertar menciones si existen
 	if len(req.Mentions) > 0 {
 
 		mentionQuery := dbpq.QueryBuilder().
@@ -1367,7 +1551,7 @@ func (r *SQLRoomRepository) SaveMessage(ctx context.Context, userId int, req *ch
 		}
 	}
 
-	// 3. Insertar metadatos solo para el remitente
+	// 4. Insertar metadatos solo para el remitente
 	_, err = dbpq.QueryBuilder().
 		Insert("public.room_message_meta").
 		Columns("message_id", "user_id", "read_at", "\"isDeleted\"", "\"isSenderBlocked\"").
@@ -1378,12 +1562,18 @@ func (r *SQLRoomRepository) SaveMessage(ctx context.Context, userId int, req *ch
 		return nil, fmt.Errorf("failed to insert sender message meta: %w", err)
 	}
 
-	// 4. Confirmar la transacción
+	// 5. Registrar el evento en el outbox (el relay lo hidrata y publica)
+	if err = insertOutboxEvents(ctx, tx, newOutboxEvent(req.RoomId, userId, OutboxMessageCreated, messageId, nil)); err != nil {
+		return nil, err
+	}
+
+	// 6. Confirmar la transacción
 	if err = tx.Commit(); err != nil {
 		return nil, err
 	}
+	r.countNewMessage(ctx, req.RoomId, userId, req.Mentions)
 
-	// 5. Obtener y devolver el mensaje completo (fuera de la transacción)
+	// 7. Obtener y devolver el mensaje completo (fuera de la transacción)
 	message, err := r.GetMessage(ctx, userId, messageId)
 	if err != nil {
 		// The message was saved, but we couldn't fetch it.
@@ -1396,10 +1586,16 @@ func (r *SQLRoomRepository) SaveMessage(ctx context.Context, userId int, req *ch
 	return message, nil
 }
 
+// VoidAbandonedSeqs no hace nada: el seq se reserva en la transacción del mensaje y un rollback
+// lo libera.
+func (r *SQLRoomRepository) VoidAbandonedSeqs(ctx context.Context, reservedBefore time.Time) (int, error) {
+	return 0, nil
+}
+
 func (r *SQLRoomRepository) GetMessageSimple(ctx context.Context, userId int, messageId string) (*chatv1.MessageData, error) {
 
-	cacheKey := fmt.Sprintf("endpoint:chat:messagesimple:messageId:{%s}", messageId)
-	dataCached, existsCached := GetCachedMessageSimple(ctx, cacheKey)
+	cacheKey := messageSimpleCacheKey(messageId)
+	dataCached, fill, existsCached := GetCachedMessageSimple(ctx, cacheKey)
 	if existsCached {
 		return dataCached, nil
 	}
@@ -1449,7 +1645,7 @@ func (r *SQLRoomRepository) GetMessageSimple(ctx context.Context, userId int, me
 			return nil, err
 		}
 
-		SetCachedMessageSimple(ctx, cacheKey, &message)
+		SetCachedMessageSimple(ctx, fill, &message)
 
 		return &message, nil
 	}
@@ -1487,6 +1683,8 @@ func (r *SQLRoomRepository) GetMessage(ctx context.Context, userId int, messageI
 			"room_message.\"isDeleted\"",
 			"room_message.event",
 			"room_message.sender_message_id",
+			"COALESCE(room_message.seq, 0)",
+			"COALESCE(room_message.key_version, 1)",
 
 			"room_message.forwarded_message_id",
 			"forwarded_user.id AS forwarded_user_id",
@@ -1504,6 +1702,7 @@ func (r *SQLRoomRepository) GetMessage(ctx context.Context, userId int, messageI
 			"reply_message.room_id AS reply_message_room_id",
 			"reply_message.created_at AS reply_message_created_at",
 			"reply_message.updated_at AS reply_message_updated_at",
+			"COALESCE(reply_message.key_version, 1) AS reply_message_key_version",
 		).
 		From("room_message").
 		InnerJoin("public.\"user\" ON room_message.sender_id = public.\"user\".id").
@@ -1535,18 +1734,19 @@ func (r *SQLRoomRepository) GetMessage(ctx context.Context, userId int, messageI
 		replyTypeNull := sql.NullString{}
 		replyMessageRoomIdNull := sql.NullString{}
 		replyMessageCreatedAtNull := sql.NullString{}
+		var replyKeyVersion int32
 		replyMessageUpdatedAtNull := sql.NullString{}
 
 		err = rows.Scan(
 			&message.Id, &message.RoomId, &message.SenderId, &message.SenderName, &message.SenderPhone, &message.SenderAvatar, &message.Content, &message.Status,
 			&message.CreatedAt, &message.UpdatedAt, &message.Type, &message.Lifetime, &message.LocationName, &message.LocationLatitude,
 			&message.LocationLongitude, &message.Origin, &message.ContactId, &message.ContactName, &message.ContactPhone, &message.File,
-			&message.Edited, &message.IsDeleted, &message.Event, &message.SenderMessageId,
+			&message.Edited, &message.IsDeleted, &message.Event, &message.SenderMessageId, &message.Seq, &message.KeyVersion,
 
 			&message.ForwardedMessageId, &message.ForwardedMessageSenderId, &message.ForwardedMessageSenderName, &message.ForwardedMessageSenderPhone, &message.ForwardedMessageSenderAvatar,
 
 			&replyIdNull, &replySenderIdNull, &replySenderNameNull, &replySenderPhoneNull, &replySenderAvatarNull, &replyContentNull, &replyTypeNull,
-			&replyMessageRoomIdNull, &replyMessageCreatedAtNull, &replyMessageUpdatedAtNull)
+			&replyMessageRoomIdNull, &replyMessageCreatedAtNull, &replyMessageUpdatedAtNull, &replyKeyVersion)
 
 		if err != nil {
 			fmt.Println("error scanning message", err)
@@ -1582,6 +1782,7 @@ func (r *SQLRoomRepository) GetMessage(ctx context.Context, userId int, messageI
 			if replyMessageUpdatedAtNull.Valid {
 				message.Reply.UpdatedAt = replyMessageUpdatedAtNull.String
 			}
+			message.Reply.KeyVersion = replyKeyVersion
 		}
 
 		//tags
@@ -1649,48 +1850,89 @@ func (r *SQLRoomRepository) GetMessage(ctx context.Context, userId int, messageI
 	return nil, nil
 }
 
-func (r *SQLRoomRepository) UpdateMessage(ctx context.Context, userId int, messageId string, content string) error {
+func (r *SQLRoomRepository) UpdateMessage(ctx context.Context, userId int, req *chatv1.EditMessageRequest, contentDecrypted *string) error {
+	if contentDecrypted == nil {
+		contentDecrypted = &[]string{""}[0]
+	}
 
-	query := dbpq.QueryBuilder().
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	messageId := req.MessageId
+	var roomId string
+	err = dbpq.QueryBuilder().
 		Update("room_message").
-		Set("content", content).
+		Set("content", req.NewContent).
+		Set("content_decrypted", contentDecrypted).
+		Set("key_version", req.KeyVersion).
 		Set("updated_at", time.Now()).
 		Set("edited", true).
-		Where(sq.Eq{"id": messageId})
-
-	queryString, args, err := query.ToSql()
+		Where(sq.Eq{"id": messageId}).
+		Suffix("RETURNING room_id").
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&roomId)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.db.ExecContext(ctx, queryString, args...)
-	if err != nil {
+	if err = insertOutboxEvents(ctx, tx, newOutboxEvent(roomId, userId, OutboxMessageUpdated, messageId, nil)); err != nil {
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	// GetMessageSimple guarda el contenido en caché
+	DeleteCache(ctx, messageSimpleCacheKey(messageId))
 	return nil
 }
 
 func (r *SQLRoomRepository) DeleteMessage(ctx context.Context, userId int, messageId []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	query := dbpq.QueryBuilder().
+	rows, err := dbpq.QueryBuilder().
 		Update("room_message").
 		Set("deleted_at", time.Now()).
 		Set("updated_at", time.Now()).
 		Set("\"isDeleted\"", true).
-		Where(sq.Eq{"id": messageId})
-
-	queryString, args, err := query.ToSql()
+		Where(sq.Eq{"id": messageId}).
+		Suffix("RETURNING id, room_id").
+		RunWith(tx).
+		QueryContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.db.ExecContext(ctx, queryString, args...)
-	if err != nil {
+	var outboxEvents []OutboxEvent
+	for rows.Next() {
+		var id, roomId string
+		if err := rows.Scan(&id, &roomId); err != nil {
+			rows.Close()
+			return err
+		}
+		outboxEvents = append(outboxEvents, newOutboxEvent(roomId, userId, OutboxMessageDeleted, id, &chatv1.MessageEvent{
+			RoomId: roomId,
+			Event:  &chatv1.MessageEvent_DeleteMessage{DeleteMessage: id},
+		}))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
 		return err
 	}
 
-	return nil
+	if err = insertOutboxEvents(ctx, tx, outboxEvents...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (r *SQLRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int, req *chatv1.GetMessageHistoryRequest) ([]*chatv1.MessageData, *chatv1.PaginationMeta, error) {
@@ -1734,6 +1976,31 @@ func (r *SQLRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int,
 		}
 	}
 
+	// Paginación por seq: se incluyen los mensajes eliminados (sin contenido) para que el
+	// rango de seq llegue completo y el cliente pueda detectar huecos reales
+	bySeq := req != nil && req.Id != "" && (req.AfterSeq != nil || req.BeforeSeq != nil)
+
+	// El cursor no aplica a messages_per_room: cada sala tiene su propia ventana
+	var cursor *pageCursor
+	var cursorSeq int64
+	if req != nil && req.Cursor != "" {
+		if req.MessagesPerRoom > 0 {
+			return nil, nil, ErrInvalidCursor
+		}
+		var err error
+		if bySeq {
+			cursor, err = decodeCursor(req.Cursor, cursorMessagesSeq, 1)
+			if err == nil {
+				cursorSeq, err = strconv.ParseInt(cursor.Keys[0], 10, 64)
+			}
+		} else {
+			cursor, err = decodeCursor(req.Cursor, cursorMessages, 2)
+		}
+		if err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+	}
+
 	rowNumber := "1"
 	if req != nil {
 		if req.MessagesPerRoom > 0 {
@@ -1748,10 +2015,10 @@ func (r *SQLRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int,
 			"msg.content", "msg.status", "msg.created_at", "msg.updated_at", "msg.type",
 			"msg.lifetime", "msg.location_name", "msg.location_latitude", "msg.location_longitude",
 			"msg.origin", "msg.contact_id", "msg.contact_name", "msg.contact_phone", "msg.file", "msg.edited", "msg.\"isDeleted\"",
-			"msg.event",
+			"msg.event", "COALESCE(msg.seq, 0)", "COALESCE(msg.key_version, 1) AS key_version",
 			"msg.forwarded_message_id", "fwd_sender.id", "fwd_sender.name", "fwd_sender.phone", "fwd_sender.avatar",
 			"msg.replied_message_id", "reply.sender_id", "reply_sender.name", "reply_sender.phone", "reply_sender.avatar", "reply.content", "reply.type",
-			"reply.room_id", "reply.created_at", "reply.updated_at", "meta.read_at",
+			"reply.room_id", "reply.created_at", "reply.updated_at", "COALESCE(reply.key_version, 1) AS reply_key_version", "meta.read_at",
 			rowNumber,
 		).
 		From("room_message AS msg").
@@ -1762,9 +2029,13 @@ func (r *SQLRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int,
 		LeftJoin("room_message AS reply ON msg.replied_message_id = reply.id").
 		LeftJoin("public.\"user\" AS reply_sender ON reply.sender_id = reply_sender.id").
 		Where("(meta.\"isSenderBlocked\" IS NULL OR meta.\"isSenderBlocked\" = false)").
-		Where(sq.Eq{"msg.deleted_at": nil}).
 		Where(sq.Eq{"member.removed_at": nil})
 
+	if !bySeq {
+		query = query.Where(sq.Eq{"msg.deleted_at": nil})
+	}
+
+	pageLimit := 0
 	if req != nil {
 		if req.Id != "" {
 			query = query.Where(sq.Eq{"msg.room_id": req.Id})
@@ -1789,13 +2060,40 @@ func (r *SQLRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int,
 				query = query.Where(sq.Gt{"msg.created_at": afterCreatedAt})
 			}
 		}
-		if req.Page > 0 && req.Limit > 0 && req.MessagesPerRoom == 0 {
-			query = query.Offset(uint64((req.Page - 1) * req.Limit)).
-				Limit(uint64(req.Limit))
+		if req.AfterSeq != nil {
+			query = query.Where(sq.Gt{"msg.seq": *req.AfterSeq})
+		}
+		if req.BeforeSeq != nil {
+			query = query.Where(sq.Lt{"msg.seq": *req.BeforeSeq})
+		}
+		if req.Limit > 0 && req.MessagesPerRoom == 0 && (req.Page > 0 || bySeq || cursor != nil) {
+			pageLimit = int(req.Limit)
+		}
+		switch {
+		case cursor != nil && bySeq && req.BeforeSeq == nil:
+			query = query.Where(sq.Gt{"msg.seq": cursorSeq})
+		case cursor != nil && bySeq:
+			query = query.Where(sq.Lt{"msg.seq": cursorSeq})
+		case cursor != nil:
+			query = query.Where("(msg.created_at, msg.id) < (?::timestamptz, ?::uuid)", cursor.Keys[0], cursor.Keys[1])
+		case req.Page > 0 && pageLimit > 0 && !bySeq:
+			query = query.Offset(uint64((req.Page - 1) * req.Limit))
+		}
+		// Se pide un elemento de más para saber si hay página siguiente
+		if pageLimit > 0 {
+			query = query.Limit(uint64(pageLimit) + 1)
 		}
 	}
 
-	query = query.OrderBy("msg.created_at DESC")
+	switch {
+	case bySeq && req.BeforeSeq == nil:
+		// after_seq: los siguientes mensajes al último conocido, en orden
+		query = query.OrderBy("msg.seq ASC")
+	case bySeq:
+		query = query.OrderBy("msg.seq DESC")
+	default:
+		query = query.OrderBy("msg.created_at DESC", "msg.id DESC")
+	}
 
 	queryString, args, err := query.ToSql()
 	if err != nil {
@@ -1825,7 +2123,18 @@ func (r *SQLRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int,
 	defer rows.Close()
 
 	data := []*chatv1.MessageData{}
+	var nextCursor string
 	for rows.Next() {
+		if pageLimit > 0 && len(data) == pageLimit {
+			last := data[len(data)-1]
+			if bySeq {
+				nextCursor = encodeCursor(cursorMessagesSeq, strconv.FormatInt(last.Seq, 10))
+			} else {
+				nextCursor = encodeCursor(cursorMessages, last.CreatedAt, last.Id)
+			}
+			break
+		}
+
 		var message chatv1.MessageData
 		replyIdNull := sql.NullString{}
 		replySenderIdNull := sql.NullInt32{}
@@ -1837,6 +2146,7 @@ func (r *SQLRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int,
 		replyMessageRoomIdNull := sql.NullString{}
 		replyMessageCreatedAtNull := sql.NullString{}
 		replyMessageUpdatedAtNull := sql.NullString{}
+		var replyKeyVersion int32
 		rowNumberNull := sql.NullString{}
 
 		senderIdNull := sql.NullInt32{}
@@ -1851,10 +2161,10 @@ func (r *SQLRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int,
 			&message.Content, &message.Status, &message.CreatedAt, &message.UpdatedAt, &message.Type,
 			&message.Lifetime, &message.LocationName, &message.LocationLatitude, &message.LocationLongitude,
 			&message.Origin, &message.ContactId, &message.ContactName, &message.ContactPhone, &message.File, &message.Edited, &message.IsDeleted,
-			&message.Event,
+			&message.Event, &message.Seq, &message.KeyVersion,
 			&message.ForwardedMessageId, &message.ForwardedMessageSenderId, &message.ForwardedMessageSenderName, &message.ForwardedMessageSenderPhone, &message.ForwardedMessageSenderAvatar,
 			&replyIdNull, &replySenderIdNull, &replySenderNameNull, &replySenderPhoneNull, &replySenderAvatarNull, &replyContentNull, &replyTypeNull,
-			&replyMessageRoomIdNull, &replyMessageCreatedAtNull, &replyMessageUpdatedAtNull, &readAtNull,
+			&replyMessageRoomIdNull, &replyMessageCreatedAtNull, &replyMessageUpdatedAtNull, &replyKeyVersion, &readAtNull,
 			&rowNumberNull,
 		)
 		if err != nil {
@@ -1886,9 +2196,15 @@ func (r *SQLRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int,
 				RoomId:       replyMessageRoomIdNull.String,
 				CreatedAt:    replyMessageCreatedAtNull.String,
 				UpdatedAt:    replyMessageUpdatedAtNull.String,
+				KeyVersion:   replyKeyVersion,
 			}
 		}
 
+		if message.IsDeleted {
+			message.Content = ""
+			message.File = nil
+		}
+
 		if message.SenderId != int32(userId) {
 			if readAtNull.Valid && readAtNull.String != "" {
 				message.Status = chatv1.MessageStatus_MESSAGE_STATUS_READ
@@ -1900,6 +2216,7 @@ func (r *SQLRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int,
 		data = append(data, &message)
 
 	}
+	rows.Close()
 
 	if len(data) > 0 {
 
@@ -2044,6 +2361,7 @@ func (r *SQLRoomRepository) GetMessagesFromRoom(ctx context.Context, userId int,
 			ItemsPerPage: req.Limit,
 			TotalPages:   uint32(math.Ceil(float64(totalItemsCount) / float64(req.Limit))),
 			CurrentPage:  req.Page,
+			NextCursor:   nextCursor,
 		}
 
 		return data, &meta, nil
@@ -2200,6 +2518,11 @@ func (r *SQLRoomRepository) MarkMessagesAsRead(ctx context.Context, userId int,
 	}
 	defer tx.Rollback()
 
+	requested, err := readMessages(ctx, tx, roomId, messageIds)
+	if err != nil {
+		return 0, fmt.Errorf("error selecting read messages: %w", err)
+	}
+
 	// Since
 	if since != "" {
 		query := dbpq.QueryBuilder().
@@ -2340,18 +2663,77 @@ func (r *SQLRoomRepository) MarkMessagesAsRead(ctx context.Context, userId int,
 		return 0, fmt.Errorf("error executing update query: %w", err)
 	}
 
+	var read unreadCount
+	if r.counters != nil {
+		read, err = countRead(ctx, tx, userId, roomId, append(messagesToUpdate, messagesToCreate...))
+		if err != nil {
+			return 0, fmt.Errorf("error counting read messages: %w", err)
+		}
+	}
+
+	if err = insertOutboxEvents(ctx, tx, newMessagesReadEvents(roomId, userId, requested, time.Now())...); err != nil {
+		return 0, err
+	}
+
 	// Commit de la transacción
 	err = tx.Commit()
 	if err != nil {
 		return 0, fmt.Errorf("error committing transaction: %w", err)
 	}
 
+	if r.counters != nil {
+		if err := r.counters.decrement(ctx, userId, roomId, read); err != nil {
+			fmt.Println("error decrementing unread counters", err)
+		}
+	}
 	DeleteRoomCacheByRoomID(ctx, roomId)
 
 	return int32(len(messagesToCreate) + len(messagesToUpdate)), nil
 
 }
 
+// readMessages lee en una sola consulta el remitente y el seq de los mensajes de la sala
+// pedidos, en el orden de messageIds. Los mensajes anteriores a seq (NULL) tienen seq 0.
+func readMessages(ctx context.Context, tx *sql.Tx, roomId string, messageIds []string) ([]readMessage, error) {
+	query := dbpq.QueryBuilder().
+		Select("id", "sender_id", "COALESCE(seq, 0)").
+		From("room_message").
+		Where(sq.Eq{"room_id": roomId}).
+		Where(sq.Eq{"id": messageIds})
+
+	queryString, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, queryString, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]readMessage, len(messageIds))
+	for rows.Next() {
+		var msg readMessage
+		if err := rows.Scan(&msg.id, &msg.senderID, &msg.seq); err != nil {
+			return nil, err
+		}
+		found[msg.id] = msg
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	messages := make([]readMessage, 0, len(found))
+	for _, id := range messageIds {
+		if msg, ok := found[id]; ok {
+			messages = append(messages, msg)
+			delete(found, id)
+		}
+	}
+	return messages, nil
+}
+
 // Función auxiliar para obtener los últimos mensajes de múltiples salas
 // Útil cuando se necesita una alternativa a LATERAL JOIN para listas grandes
 /*func (r *SQLRoomRepository) getLastMessagesForRooms(ctx context.Context, roomIds []string) (map[string]*chatv1.MessageData, error) {
@@ -2397,6 +2779,10 @@ func (r *SQLRoomRepository) MarkMessagesAsRead(ctx context.Context, userId int,
 }*/
 
 func (r *SQLRoomRepository) GetMessageRead(ctx context.Context, req *chatv1.GetMessageReadRequest) ([]*chatv1.MessageUserRead, *chatv1.PaginationMeta, error) {
+	cursor, err := decodeCursor(req.Cursor, cursorReads, 2)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	query := dbpq.QueryBuilder().
 		Select("uu.id", "uu.name", "uu.avatar", "uu.phone", "room_message_meta.read_at").
@@ -2406,11 +2792,21 @@ func (r *SQLRoomRepository) GetMessageRead(ctx context.Context, req *chatv1.GetM
 		Where(sq.Eq{"room_message_meta.deleted_at": nil}).
 		Where(sq.Expr("room_message_meta.read_at IS NOT NULL"))
 
-	if req.Page > 0 && req.Limit > 0 {
-		query = query.Offset(uint64((req.Page - 1) * req.Limit)).Limit(uint64(req.Limit))
+	pageLimit := 0
+	if req.Limit > 0 && (cursor != nil || req.Page > 0) {
+		pageLimit = int(req.Limit)
+	}
+	if cursor != nil {
+		query = query.Where("(room_message_meta.read_at, uu.id) > (?::timestamptz, ?::int)", cursor.Keys[0], cursor.Keys[1])
+	} else if pageLimit > 0 {
+		query = query.Offset(uint64((req.Page - 1) * req.Limit))
+	}
+	// Se pide un elemento de más para saber si hay página siguiente
+	if pageLimit > 0 {
+		query = query.Limit(uint64(pageLimit) + 1)
 	}
 
-	query = query.OrderBy("room_message_meta.read_at ASC")
+	query = query.OrderBy("room_message_meta.read_at ASC", "uu.id ASC")
 
 	queryString, args, err := query.ToSql()
 	if err != nil {
@@ -2423,7 +2819,14 @@ func (r *SQLRoomRepository) GetMessageRead(ctx context.Context, req *chatv1.GetM
 	}
 
 	items := make([]*chatv1.MessageUserRead, 0)
+	var nextCursor string
 	for rows.Next() {
+		if pageLimit > 0 && len(items) == pageLimit {
+			last := items[len(items)-1]
+			nextCursor = encodeCursor(cursorReads, last.ReadAt, strconv.Itoa(int(last.UserId)))
+			break
+		}
+
 		var item chatv1.MessageUserRead
 		err = rows.Scan(&item.UserId, &item.UserName, &item.UserAvatar, &item.UserPhone, &item.ReadAt)
 		if err != nil {
@@ -2431,6 +2834,7 @@ func (r *SQLRoomRepository) GetMessageRead(ctx context.Context, req *chatv1.GetM
 		}
 		items = append(items, &item)
 	}
+	rows.Close()
 
 	queryTotal := dbpq.QueryBuilder().
 		Select("COUNT(*)").
@@ -2456,25 +2860,42 @@ func (r *SQLRoomRepository) GetMessageRead(ctx context.Context, req *chatv1.GetM
 		ItemsPerPage: req.Limit,
 		TotalPages:   uint32(math.Ceil(float64(totalItemsCount) / float64(req.GetLimit()))),
 		CurrentPage:  req.GetPage(),
+		NextCursor:   nextCursor,
 	}
 
 	return items, &meta, nil
 }
 
 func (r *SQLRoomRepository) GetMessageReactions(ctx context.Context, req *chatv1.GetMessageReactionsRequest) ([]*chatv1.Reaction, *chatv1.PaginationMeta, error) {
+	cursor, err := decodeCursor(req.Cursor, cursorReactions, 2)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	// created_at admite NULL: se ordena como 'infinity' para que la clave del cursor sea comparable
 	query := dbpq.QueryBuilder().
-		Select("reaction", "uu.id", "uu.name", "uu.avatar", "uu.phone", "room_message_reaction.\"messageId\"").
+		Select("reaction", "uu.id", "uu.name", "uu.avatar", "uu.phone", "room_message_reaction.\"messageId\"",
+			"COALESCE(room_message_reaction.created_at, 'infinity')::text", "room_message_reaction.id").
 		From("room_message_reaction").
 		InnerJoin(`public."user" AS uu ON room_message_reaction."reactedById" = uu.id`).
 		Where(sq.Eq{`room_message_reaction."messageId"`: req.Id}).
 		Where(sq.Eq{"room_message_reaction.deleted_at": nil})
 
-	if req.Page > 0 && req.Limit > 0 {
-		query = query.Offset(uint64((req.Page - 1) * req.Limit)).Limit(uint64(req.Limit))
+	pageLimit := 0
+	if req.Limit > 0 && (cursor != nil || req.Page > 0) {
+		pageLimit = int(req.Limit)
+	}
+	if cursor != nil {
+		query = query.Where("(COALESCE(room_message_reaction.created_at, 'infinity'), room_message_reaction.id) < (?::timestamptz, ?::uuid)", cursor.Keys[0], cursor.Keys[1])
+	} else if pageLimit > 0 {
+		query = query.Offset(uint64((req.Page - 1) * req.Limit))
+	}
+	// Se pide un elemento de más para saber si hay página siguiente
+	if pageLimit > 0 {
+		query = query.Limit(uint64(pageLimit) + 1)
 	}
 
-	query = query.OrderBy("room_message_reaction.created_at DESC")
+	query = query.OrderBy("COALESCE(room_message_reaction.created_at, 'infinity') DESC", "room_message_reaction.id DESC")
 
 	queryString, args, err := query.ToSql()
 	if err != nil {
@@ -2487,14 +2908,24 @@ func (r *SQLRoomRepository) GetMessageReactions(ctx context.Context, req *chatv1
 	}
 
 	items := make([]*chatv1.Reaction, 0)
+	var nextCursor string
+	var lastKey []string
 	for rows.Next() {
+		if pageLimit > 0 && len(items) == pageLimit {
+			nextCursor = encodeCursor(cursorReactions, lastKey...)
+			break
+		}
+
 		var item chatv1.Reaction
-		err = rows.Scan(&item.Reaction, &item.ReactedById, &item.ReactedByName, &item.ReactedByAvatar, &item.ReactedByPhone, &item.MessageId)
+		var createdAt, id string
+		err = rows.Scan(&item.Reaction, &item.ReactedById, &item.ReactedByName, &item.ReactedByAvatar, &item.ReactedByPhone, &item.MessageId, &createdAt, &id)
 		if err != nil {
 			return nil, nil, err
 		}
+		lastKey = []string{createdAt, id}
 		items = append(items, &item)
 	}
+	rows.Close()
 
 	queryTotal := dbpq.QueryBuilder().
 		Select("COUNT(*)").
@@ -2519,6 +2950,7 @@ func (r *SQLRoomRepository) GetMessageReactions(ctx context.Context, req *chatv1
 		ItemsPerPage: req.Limit,
 		TotalPages:   uint32(math.Ceil(float64(totalItemsCount) / float64(req.Limit))),
 		CurrentPage:  req.Page,
+		NextCursor:   nextCursor,
 	}
 
 	return items, &meta, nil
@@ -2577,8 +3009,7 @@ func (r *SQLRoomRepository) GetUsersByID(ctx context.Context, ids []int) ([]User
 		Select(`public.user."id"`, `public.user."name"`, `public.user."phone"`, `public.user."email"`, `public.user."avatar"`, `public.user."created_at"`, `public.user."dni"`).
 		From("public.user").
 		Where(sq.Eq{"public.user.\"id\"": ids}).
-		Where(sq.Eq{"public.user.\"deleted_at\"": nil}).
-		Limit(1)
+		Where(sq.Eq{"public.user.\"deleted_at\"": nil})
 
 	queryString, args, err := query.ToSql()
 	if err != nil {
@@ -2604,12 +3035,36 @@ func (r *SQLRoomRepository) GetUsersByID(ctx context.Context, ids []int) ([]User
 	return users, nil
 }
 
+func (r *SQLRoomRepository) GetUserByPhone(ctx context.Context, phone string) (*User, error) {
+	queryString, args, err := dbpq.QueryBuilder().
+		Select(`public.user."id"`, `public.user."name"`, `public.user."phone"`, `public.user."email"`, `public.user."avatar"`, `public.user."created_at"`, `public.user."dni"`).
+		From("public.user").
+		Where(sq.Eq{"public.user.\"phone\"": phone}).
+		Where(sq.Eq{"public.user.\"removed_at\"": nil}).
+		Where(sq.Eq{"public.user.\"deleted_at\"": nil}).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var user User
+	err = r.db.QueryRowContext(ctx, queryString, args...).
+		Scan(&user.ID, &user.Name, &user.Phone, &user.Email, &user.Avatar, &user.CreatedAt, &user.Dni)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *SQLRoomRepository) GetAllUserIDs(ctx context.Context) ([]int, error) {
 	query := dbpq.QueryBuilder().
 		Select(`public.user."id"`).
 		From("public.user").
-		Where(sq.Eq{"public.user.\"deleted_at\"": nil}).
-		Limit(1)
+		Where(sq.Eq{"public.user.\"deleted_at\"": nil})
 
 	queryString, args, err := query.ToSql()
 	if err != nil {
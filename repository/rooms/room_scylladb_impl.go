@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -61,16 +63,29 @@ func (r *ScyllaRoomRepository) CreateRoom(ctx context.Context, userId int, req *
 	}
 	joinAllUser := false
 
-	encryptionData, err := utils.GenerateKeyEncript()
+	// Las salas e2e no tienen clave en el servidor (ver e2e.go)
+	var encryptionData *string
+	if !req.GetE2E() {
+		key, err := utils.GenerateKeyEncript()
+		if err != nil {
+			fmt.Println("error", err)
+			return nil, err
+		}
+		encryptionData = &key
+	}
+
+	// La clave va en room_key_state, que solo se escribe con LWT y no puede ir en el batch. Se
+	// escribe antes para que la sala nunca exista sin ella.
+	_, err := r.session.Query(`INSERT INTO room_key_state (room_id, encryption_data, key_version) VALUES (?, ?, ?) IF NOT EXISTS`, roomID, encryptionData, 1).
+		WithContext(ctx).MapScanCAS(map[string]interface{}{})
 	if err != nil {
-		fmt.Println("error", err)
-		return nil, err
+		return nil, fmt.Errorf("error al guardar la clave de la sala: %w", err)
 	}
 
 	// --- PASO 1: Batch para operaciones que no son de contador ---
 	batch := r.session.Batch(gocql.LoggedBatch)
-	batch.Query(`INSERT INTO room_details (room_id, name, description, image, type, encryption_data, created_at, updated_at, join_all_user, send_message, add_member, edit_group) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		roomID, req.Name, req.Description, req.PhotoUrl, req.Type, encryptionData, now, now, joinAllUser, sendMessage, addMember, editGroup)
+	batch.Query(`INSERT INTO room_details (room_id, name, description, image, type, e2e, created_at, updated_at, join_all_user, send_message, add_member, edit_group) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		roomID, req.Name, req.Description, req.PhotoUrl, req.Type, req.GetE2E(), now, now, joinAllUser, sendMessage, addMember, editGroup)
 
 	for participantID := range participantsSet {
 		role := "MEMBER"
@@ -95,6 +110,18 @@ func (r *ScyllaRoomRepository) CreateRoom(ctx context.Context, userId int, req *
 		batch.Query(`INSERT INTO p2p_room_by_users (user1_id, user2_id, room_id) VALUES (?, ?, ?)`, user1, user2, roomID)
 	}
 
+	// RoomJoin de cada participante y del creador
+	var joined []int
+	for _, participantID := range req.Participants {
+		if int(participantID) != userId && !slices.Contains(joined, int(participantID)) {
+			joined = append(joined, int(participantID))
+		}
+	}
+	joined = append(joined, userId)
+	if err := addOutboxEvents(batch, newRoomJoinEvents(roomID.String(), userId, joined, userId, now)...); err != nil {
+		return nil, err
+	}
+
 	if err := r.session.ExecuteBatch(batch); err != nil {
 		return nil, fmt.Errorf("error en batch de creación de sala: %w", err)
 	}
@@ -141,14 +168,14 @@ func (r *ScyllaRoomRepository) addAllSystemUsersToChannel(ctx context.Context, r
 }
 
 func (r *ScyllaRoomRepository) GetRoom(ctx context.Context, userId int, roomId string, allData bool, useCache bool) (*chatv1.Room, error) {
-	cacheKey := fmt.Sprintf("endpoint:chat:room:{%s}:shim:user:%d", roomId, userId)
-	if allData {
-		cacheKey = fmt.Sprintf("endpoint:chat:room:{%s}:user:%d", roomId, userId)
-	}
+	cacheKey := roomCacheKey(roomId, userId, allData)
+	var fill cacheFill
 	if useCache {
-		if dataCached, existsCached := GetCachedRoom(ctx, cacheKey); existsCached {
+		dataCached, roomFill, existsCached := GetCachedRoom(ctx, cacheKey)
+		if existsCached {
 			return dataCached, nil
 		}
+		fill = roomFill
 	}
 
 	roomUUID, err := gocql.ParseUUID(roomId)
@@ -157,17 +184,34 @@ func (r *ScyllaRoomRepository) GetRoom(ctx context.Context, userId int, roomId s
 	}
 
 	room := &chatv1.Room{Id: roomId}
-	var createdAt, updatedAt time.Time
-	err = r.session.Query(`SELECT name, description, image, type, encryption_data, created_at, updated_at, join_all_user, send_message, add_member, edit_group FROM room_details WHERE room_id = ? LIMIT 1`, roomUUID).
-		WithContext(ctx).Scan(&room.Name, &room.Description, &room.PhotoUrl, &room.Type, &room.EncryptionData, &createdAt, &updatedAt, &room.JoinAllUser, &room.SendMessage, &room.AddMember, &room.EditGroup)
+	var createdAt, updatedAt, historyPurgedBefore time.Time
+	var retentionDays *int
+	err = r.session.Query(`SELECT name, description, image, type, e2e, created_at, updated_at, join_all_user, send_message, add_member, edit_group, retention_days, history_purged_before FROM room_details WHERE room_id = ? LIMIT 1`, roomUUID).
+		WithContext(ctx).Scan(&room.Name, &room.Description, &room.PhotoUrl, &room.Type, &room.E2E, &createdAt, &updatedAt, &room.JoinAllUser, &room.SendMessage, &room.AddMember, &room.EditGroup, &retentionDays, &historyPurgedBefore)
 	if err != nil {
 		if err == gocql.ErrNotFound {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("error al obtener detalles de la sala: %w", err)
 	}
+	keyState, err := loadRoomKeyState(ctx, r.session, roomUUID)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error al obtener la clave de la sala: %w", err)
+	}
+	room.EncryptionData = keyState.key()
+	room.KeyVersion = keyState.version
 	room.CreatedAt = createdAt.Format(time.RFC3339)
 	room.UpdatedAt = updatedAt.Format(time.RFC3339)
+	if retentionDays != nil {
+		days := int32(*retentionDays)
+		room.RetentionDays = &days
+	}
+	if !historyPurgedBefore.IsZero() {
+		room.HistoryPurgedBefore = historyPurgedBefore.Format(time.RFC3339Nano)
+	}
 
 	var isPinned bool
 	var lastMessageAt time.Time
@@ -224,12 +268,17 @@ func (r *ScyllaRoomRepository) GetRoom(ctx context.Context, userId int, roomId s
 	}
 
 	if useCache {
-		SetCachedRoom(ctx, roomId, cacheKey, room)
+		SetCachedRoom(ctx, roomId, fill, room)
 	}
 	return room, nil
 }
 
 func (r *ScyllaRoomRepository) GetRoomList(ctx context.Context, userId int, pagination *chatv1.GetRoomsRequest) ([]*chatv1.Room, *chatv1.PaginationMeta, error) {
+	cursor, after, err := decodeScyllaRoomCursor(pagination.GetCursor())
+	if err != nil {
+		return nil, nil, err
+	}
+
 	baseQuery := `SELECT room_id, room_name, room_image, room_type, last_message_at, is_muted, is_pinned, role, last_message_id, last_message_preview, last_message_type, last_message_sender_id, last_message_sender_name, last_message_sender_phone, last_message_status, last_message_updated_at FROM rooms_by_user WHERE user_id = ?`
 	args := []any{userId}
 
@@ -238,6 +287,7 @@ func (r *ScyllaRoomRepository) GetRoomList(ctx context.Context, userId int, pagi
 
 	var allRooms []*chatv1.Room
 	roomMap := make(map[string]*chatv1.Room)
+	roomKeys := make(map[string]scyllaRoomKey)
 	var roomIDs []gocql.UUID
 
 	scanner := iter.Scanner()
@@ -276,6 +326,7 @@ func (r *ScyllaRoomRepository) GetRoomList(ctx context.Context, userId int, pagi
 		allRooms = append(allRooms, room)
 		roomIDs = append(roomIDs, roomID)
 		roomMap[roomID.String()] = room
+		roomKeys[roomID.String()] = scyllaRoomKey{pinned: isPinned, lastMessageAt: lastMessageAt}
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, nil, fmt.Errorf("error del scanner al leer lista de salas: %w", err)
@@ -350,40 +401,88 @@ func (r *ScyllaRoomRepository) GetRoomList(ctx context.Context, userId int, pagi
 		filteredRooms = allRooms
 	}
 
-	// Paginación en la aplicación
-	if pagination != nil && pagination.Page > 0 && pagination.Limit > 0 {
+	// Paginación en la aplicación. rooms_by_user ya viene en el orden de la lista, así que
+	// el cursor es la clave de clustering de la última sala devuelta
+	var nextCursor string
+	if cursor != nil {
+		filteredRooms = scyllaRoomsAfter(filteredRooms, roomKeys, after, cursor.Keys[2])
+	} else if pagination != nil && pagination.Page > 0 && pagination.Limit > 0 {
 		start := (pagination.Page - 1) * pagination.Limit
-		end := start + pagination.Limit
 		if start > uint32(len(filteredRooms)) {
 			filteredRooms = []*chatv1.Room{}
 		} else {
-			if end > uint32(len(filteredRooms)) {
-				end = uint32(len(filteredRooms))
-			}
-			filteredRooms = filteredRooms[start:end]
+			filteredRooms = filteredRooms[start:]
 		}
 	}
+	if pagination != nil && pagination.Limit > 0 && (cursor != nil || pagination.Page > 0) && len(filteredRooms) > int(pagination.Limit) {
+		filteredRooms = filteredRooms[:pagination.Limit]
+		last := filteredRooms[len(filteredRooms)-1]
+		key := roomKeys[last.Id]
+		nextCursor = encodeCursor(cursorRooms, strconv.FormatBool(key.pinned), strconv.FormatInt(key.lastMessageAt.UnixMilli(), 10), last.Id)
+	}
 
-	meta := &chatv1.PaginationMeta{TotalItems: uint32(len(allRooms)), ItemCount: uint32(len(filteredRooms))}
+	meta := &chatv1.PaginationMeta{TotalItems: uint32(len(allRooms)), ItemCount: uint32(len(filteredRooms)), NextCursor: nextCursor}
 	return filteredRooms, meta, nil
 }
 
+// scyllaRoomKey es la parte de la clave de clustering de rooms_by_user que ordena la lista.
+type scyllaRoomKey struct {
+	pinned        bool
+	lastMessageAt time.Time
+}
+
+// El cursor de salas en Scylla lleva is_pinned, last_message_at (ms) y room_id; no es
+// intercambiable con el de Postgres, que ordena también por fecha de creación.
+func decodeScyllaRoomCursor(value string) (*pageCursor, scyllaRoomKey, error) {
+	cursor, err := decodeCursor(value, cursorRooms, 3)
+	if err != nil || cursor == nil {
+		return nil, scyllaRoomKey{}, err
+	}
+	pinned, err := strconv.ParseBool(cursor.Keys[0])
+	if err != nil {
+		return nil, scyllaRoomKey{}, ErrInvalidCursor
+	}
+	millis, err := strconv.ParseInt(cursor.Keys[1], 10, 64)
+	if err != nil {
+		return nil, scyllaRoomKey{}, ErrInvalidCursor
+	}
+	return cursor, scyllaRoomKey{pinned: pinned, lastMessageAt: time.UnixMilli(millis)}, nil
+}
+
+// scyllaRoomsAfter devuelve las salas que van detrás de la clave del cursor. Si la sala del
+// cursor cambió de posición (nuevo mensaje) se continúa igualmente desde su clave anterior.
+func scyllaRoomsAfter(rooms []*chatv1.Room, keys map[string]scyllaRoomKey, after scyllaRoomKey, afterId string) []*chatv1.Room {
+	for i, room := range rooms {
+		key := keys[room.Id]
+		at := key.lastMessageAt.Truncate(time.Millisecond)
+		switch {
+		case key.pinned != after.pinned:
+			if !key.pinned {
+				return rooms[i:]
+			}
+		case at.Before(after.lastMessageAt):
+			return rooms[i:]
+		case at.Equal(after.lastMessageAt) && room.Id == afterId:
+			return rooms[i+1:]
+		}
+	}
+	return []*chatv1.Room{}
+}
+
 func (r *ScyllaRoomRepository) GetRoomParticipants(ctx context.Context, pagination *chatv1.GetRoomParticipantsRequest) ([]*chatv1.RoomParticipant, *chatv1.PaginationMeta, error) {
 	roomUUID, err := gocql.ParseUUID(pagination.Id)
 	if err != nil {
 		return nil, nil, fmt.Errorf("ID de sala inválido: %w", err)
 	}
 
-	baseQuery := `SELECT user_id, role FROM participants_by_room WHERE room_id = ?`
-	args := []any{roomUUID}
-
-	if pagination != nil && pagination.Limit > 0 {
-		baseQuery += " LIMIT ?"
-		args = append(args, int(pagination.Limit))
+	query, err := pagedQuery(r.session.Query(`SELECT user_id, role FROM participants_by_room WHERE room_id = ?`, roomUUID), pagination.Cursor, cursorParticipants, pagination.Limit)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	iter := r.session.Query(baseQuery, args...).WithContext(ctx).Iter()
+	iter := query.WithContext(ctx).Iter()
 	defer iter.Close()
+	nextCursor := nextPageCursor(iter, cursorParticipants, pagination.Cursor, pagination.Limit)
 
 	var userIDs []int
 	participantMap := make(map[int]*chatv1.RoomParticipant)
@@ -422,7 +521,7 @@ func (r *ScyllaRoomRepository) GetRoomParticipants(ctx context.Context, paginati
 		}
 	}
 
-	meta := &chatv1.PaginationMeta{TotalItems: uint32(len(finalParticipants)), ItemCount: uint32(len(finalParticipants))}
+	meta := &chatv1.PaginationMeta{TotalItems: uint32(len(finalParticipants)), ItemCount: uint32(len(finalParticipants)), NextCursor: nextCursor}
 	return finalParticipants, meta, nil
 }
 
@@ -463,6 +562,15 @@ func (r *ScyllaRoomRepository) LeaveRoom(ctx context.Context, userId int, roomId
 	for _, pID := range participants {
 		batchParticipants.Query(`DELETE FROM participants_by_room WHERE room_id = ? AND user_id = ?`, roomUUID, pID)
 	}
+	err = addOutboxEvents(batchParticipants, newOutboxEvent(roomId, userId, OutboxRoomLeave, "", &chatv1.MessageEvent{
+		RoomId: roomId,
+		Event: &chatv1.MessageEvent_RoomLeave{RoomLeave: &chatv1.RoomLeaveEvent{
+			UsersId: participants,
+		}},
+	}))
+	if err != nil {
+		return nil, err
+	}
 	if err := r.session.ExecuteBatch(batchParticipants); err != nil {
 		return nil, fmt.Errorf("error al eliminar de la tabla de participantes: %w", err)
 	}
@@ -476,9 +584,16 @@ func (r *ScyllaRoomRepository) UpdateRoom(ctx context.Context, userId int, roomI
 	if err != nil {
 		return err
 	}
-	err = r.session.Query(`UPDATE room_details SET name = ?, description = ?, image = ?, send_message = ?, add_member = ?, edit_group = ?, updated_at = ? WHERE room_id = ?`,
-		req.Name, req.Description, req.PhotoUrl, req.SendMessage, req.AddMember, req.EditGroup, time.Now(), roomUUID).WithContext(ctx).Exec()
-	if err != nil {
+	batch := r.session.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`UPDATE room_details SET name = ?, description = ?, image = ?, send_message = ?, add_member = ?, edit_group = ?, updated_at = ? WHERE room_id = ?`,
+		req.Name, req.Description, req.PhotoUrl, req.SendMessage, req.AddMember, req.EditGroup, time.Now(), roomUUID)
+	if req.RetentionDays != nil {
+		batch.Query(`UPDATE room_details SET retention_days = ? WHERE room_id = ?`, retentionDaysUpdate(*req.RetentionDays), roomUUID)
+	}
+	if err := addOutboxEvents(batch, newRoomUpdatedEvent(roomId, userId)); err != nil {
+		return err
+	}
+	if err := r.session.ExecuteBatch(batch); err != nil {
 		return err
 	}
 
@@ -513,6 +628,13 @@ func (r *ScyllaRoomRepository) AddParticipantToRoom(ctx context.Context, userId
 		batch.Query(`INSERT INTO room_membership_lookup (user_id, room_id, is_pinned, last_message_at) VALUES (?, ?, ?, ?)`,
 			user.ID, roomUUID, false, now)
 	}
+	joined := make([]int, len(users))
+	for i, user := range users {
+		joined[i] = user.ID
+	}
+	if err := addOutboxEvents(batch, newRoomJoinEvents(roomId, userId, joined, 0, now)...); err != nil {
+		return nil, err
+	}
 
 	if err := r.session.ExecuteBatch(batch); err != nil {
 		return nil, fmt.Errorf("error al añadir participantes en batch: %w", err)
@@ -567,12 +689,83 @@ func (r *ScyllaRoomRepository) SaveMessage(ctx context.Context, userId int, req
 		return nil, fmt.Errorf("ID de sala inválido: %w", err)
 	}
 
+	// Mismos valores por defecto que SQLRoomRepository
+	if req.Lifetime == nil {
+		req.Lifetime = &[]string{"normal"}[0]
+	}
+	if req.Origin == nil {
+		req.Origin = &[]string{"app"}[0]
+	}
+	if req.Type == "" {
+		req.Type = "message"
+	}
+
+	var replyUUID, forwardUUID *gocql.UUID
+	if req.ReplyId != nil && *req.ReplyId != "" {
+		id, err := r.messageUUID(ctx, *req.ReplyId)
+		if err != nil {
+			return nil, fmt.Errorf("ID de mensaje respondido inválido: %w", err)
+		}
+		replyUUID = &id
+	}
+
+	var forwardSenderId *int
+	if req.ForwardId != nil && *req.ForwardId != "" {
+		id, err := r.messageUUID(ctx, *req.ForwardId)
+		if err != nil {
+			return nil, fmt.Errorf("ID de mensaje reenviado inválido: %w", err)
+		}
+		forwardUUID = &id
+
+		var forwardRoomUUID gocql.UUID
+		var senderId int
+		err = r.session.Query(`SELECT room_id FROM room_by_message WHERE message_id = ?`, id).WithContext(ctx).Scan(&forwardRoomUUID)
+		if err == nil {
+			err = r.session.Query(`SELECT sender_id FROM messages_by_room WHERE room_id = ? AND message_id = ?`, forwardRoomUUID, id).WithContext(ctx).Scan(&senderId)
+		}
+		if err != nil && err != gocql.ErrNotFound {
+			return nil, err
+		}
+		if err == nil {
+			forwardSenderId = &senderId
+		}
+	}
+
+	var contactId *int
+	if req.Type == "contact" && req.ContactPhone != nil {
+		contact, err := r.userFetcher.GetUserByPhone(ctx, *req.ContactPhone)
+		if err != nil {
+			return nil, err
+		}
+		if contact != nil {
+			contactId = &contact.ID
+		}
+	}
+
+	seq, err := r.nextRoomSeq(ctx, roomUUID)
+	if err != nil {
+		return nil, err
+	}
+
 	messageID := gocql.TimeUUID()
 	now := time.Now()
 	batch := r.session.Batch(gocql.LoggedBatch)
 
-	batch.Query(`INSERT INTO messages_by_room (room_id, message_id, sender_id, content, content_decrypted, type, created_at, sender_message_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		roomUUID, messageID, userId, req.Content, contentDecrypted, req.Type, now, req.SenderMessageId)
+	batch.Query(`INSERT INTO messages_by_room (room_id, message_id, sender_id, content, content_decrypted, type, created_at, updated_at, edited, is_deleted, sender_message_id, seq, key_version,
+		reply_to_message_id, forwarded_from_message_id, forwarded_message_sender_id, file_url, event, lifetime, location_name, location_latitude, location_longitude, origin,
+		contact_id, contact_name, contact_phone) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		roomUUID, messageID, userId, req.Content, contentDecrypted, req.Type, now, now, false, false, req.SenderMessageId, seq, req.KeyVersion,
+		replyUUID, forwardUUID, forwardSenderId, req.File, req.Event, req.Lifetime, req.LocationName, req.LocationLatitude, req.LocationLongitude, req.Origin,
+		contactId, req.ContactName, req.ContactPhone)
+	batch.Query(`INSERT INTO messages_by_room_seq (room_id, seq, message_id) VALUES (?, ?, ?)`, roomUUID, seq, messageID)
+
+	for _, mention := range req.Mentions {
+		mentionUserId, err := strconv.Atoi(mention.User)
+		if err != nil {
+			continue
+		}
+		batch.Query(`INSERT INTO mentions_by_message (message_id, user_id, tag) VALUES (?, ?, ?)`, messageID, mentionUserId, mention.Tag)
+	}
 
 	if req.SenderMessageId != nil && *req.SenderMessageId != "" {
 		batch.Query(`INSERT INTO message_by_sender_message_id (sender_message_id, room_id, message_id) VALUES (?, ?, ?)`,
@@ -581,7 +774,12 @@ func (r *ScyllaRoomRepository) SaveMessage(ctx context.Context, userId int, req
 
 	batch.Query(`INSERT INTO room_by_message (message_id, room_id) VALUES (?, ?)`, messageID, roomUUID)
 
+	if err := addOutboxEvents(batch, newOutboxEvent(req.RoomId, userId, OutboxMessageCreated, messageID.String(), nil)); err != nil {
+		return nil, err
+	}
+
 	if err := r.session.ExecuteBatch(batch); err != nil {
+		r.voidRoomSeq(ctx, roomUUID, seq)
 		return nil, fmt.Errorf("error al ejecutar el batch de guardado de mensaje: %w", err)
 	}
 
@@ -611,14 +809,10 @@ func (r *ScyllaRoomRepository) SaveMessage(ctx context.Context, userId int, req
 		}
 	}
 
-	msg := &chatv1.MessageData{
-		Id:         messageID.String(),
-		RoomId:     req.RoomId,
-		SenderId:   int32(userId),
-		SenderName: sender.Name,
-		Content:    req.Content,
-		Status:     chatv1.MessageStatus_MESSAGE_STATUS_SENT,
-		CreatedAt:  now.Format(time.RFC3339),
+	// Devuelve el mensaje completo, igual que SQLRoomRepository
+	msg, err := r.GetMessage(ctx, userId, messageID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message after saving: %w", err)
 	}
 
 	UpdateRoomCacheWithNewMessage(ctx, msg)
@@ -669,11 +863,15 @@ func (r *ScyllaRoomRepository) getMessagesFromSingleRoom(ctx context.Context, us
 		return nil, nil, fmt.Errorf("ID de sala inválido: %w", err)
 	}
 
-	baseQuery := `SELECT message_id, sender_id, content, type, created_at, edited FROM messages_by_room WHERE room_id = ?`
+	if req.AfterSeq != nil || req.BeforeSeq != nil {
+		return r.getMessagesBySeq(ctx, userId, roomUUID, req)
+	}
+
+	baseQuery := `SELECT ` + scyllaMessageColumns + ` FROM messages_by_room WHERE room_id = ?`
 	args := []any{roomUUID}
 
 	if req.BeforeMessageId != nil && *req.BeforeMessageId != "" {
-		beforeUUID, err := gocql.ParseUUID(*req.BeforeMessageId)
+		beforeUUID, err := r.messageUUID(ctx, *req.BeforeMessageId)
 		if err != nil {
 			return nil, nil, fmt.Errorf("before_message_id inválido: %w", err)
 		}
@@ -681,7 +879,7 @@ func (r *ScyllaRoomRepository) getMessagesFromSingleRoom(ctx context.Context, us
 		args = append(args, beforeUUID)
 	}
 	if req.AfterMessageId != nil && *req.AfterMessageId != "" {
-		afterUUID, err := gocql.ParseUUID(*req.AfterMessageId)
+		afterUUID, err := r.messageUUID(ctx, *req.AfterMessageId)
 		if err != nil {
 			return nil, nil, fmt.Errorf("after_message_id inválido: %w", err)
 		}
@@ -689,30 +887,34 @@ func (r *ScyllaRoomRepository) getMessagesFromSingleRoom(ctx context.Context, us
 		args = append(args, afterUUID)
 	}
 
-	if req.Limit > 0 {
-		baseQuery += " LIMIT ?"
-		args = append(args, int(req.Limit))
+	query, err := pagedQuery(r.session.Query(baseQuery, args...), req.Cursor, cursorMessages, req.Limit)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	iter := r.session.Query(baseQuery, args...).WithContext(ctx).Iter()
+	iter := query.WithContext(ctx).Iter()
 	defer iter.Close()
+	nextCursor := nextPageCursor(iter, cursorMessages, req.Cursor, req.Limit)
 
-	messages, userIDs, err := r.scanMessagesAndCollectUserIDs(iter)
+	scanned, err := scanScyllaMessages(iter)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	err = r.enrichMessagesWithUserDetails(ctx, messages, userIDs)
-	if err != nil {
-		return nil, nil, err
+	// Igual que en Postgres, el historial no incluye los mensajes eliminados
+	messages := make([]*chatv1.MessageData, 0, len(scanned))
+	for _, msg := range scanned {
+		if !msg.IsDeleted {
+			messages = append(messages, msg)
+		}
 	}
 
-	err = r.enrichMessagesWithStatus(ctx, messages, userId, roomUUID)
+	err = r.enrichMessages(ctx, userId, roomUUID, messages)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	meta := &chatv1.PaginationMeta{ItemCount: uint32(len(messages))}
+	meta := &chatv1.PaginationMeta{ItemCount: uint32(len(messages)), NextCursor: nextCursor}
 	return messages, meta, nil
 }
 
@@ -775,59 +977,6 @@ func (r *ScyllaRoomRepository) getMessagesFromAllRooms(ctx context.Context, user
 	return allMessages, meta, nil
 }
 
-func (r *ScyllaRoomRepository) scanMessagesAndCollectUserIDs(iter *gocql.Iter) ([]*chatv1.MessageData, []int, error) {
-	var messages []*chatv1.MessageData
-	userIDsMap := make(map[int]bool)
-	var userIDs []int
-
-	scanner := iter.Scanner()
-	for scanner.Next() {
-		msg := &chatv1.MessageData{}
-		var msgID gocql.UUID
-		var createdAt time.Time
-		err := scanner.Scan(&msgID, &msg.SenderId, &msg.Content, &msg.Type, &createdAt, &msg.Edited)
-		if err != nil {
-			return nil, nil, err
-		}
-		msg.Id = msgID.String()
-		msg.CreatedAt = createdAt.Format(time.RFC3339)
-		messages = append(messages, msg)
-
-		if !userIDsMap[int(msg.SenderId)] {
-			userIDs = append(userIDs, int(msg.SenderId))
-			userIDsMap[int(msg.SenderId)] = true
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, nil, err
-	}
-	return messages, userIDs, nil
-}
-
-func (r *ScyllaRoomRepository) enrichMessagesWithUserDetails(ctx context.Context, messages []*chatv1.MessageData, userIDs []int) error {
-	if len(userIDs) == 0 {
-		return nil
-	}
-	users, err := r.userFetcher.GetUsersByID(ctx, userIDs)
-	if err != nil {
-		return err
-	}
-	userMap := make(map[int32]User)
-	for _, u := range users {
-		userMap[int32(u.ID)] = u
-	}
-
-	for _, msg := range messages {
-		if user, ok := userMap[msg.SenderId]; ok {
-			msg.SenderName = user.Name
-			if user.Avatar != nil {
-				msg.SenderAvatar = *user.Avatar
-			}
-		}
-	}
-	return nil
-}
-
 func (r *ScyllaRoomRepository) enrichMessagesWithStatus(ctx context.Context, messages []*chatv1.MessageData, userId int, roomUUID gocql.UUID) error {
 	if len(messages) == 0 {
 		return nil
@@ -859,6 +1008,11 @@ func (r *ScyllaRoomRepository) MarkMessagesAsRead(ctx context.Context, userId in
 		return 0, err
 	}
 
+	requested := make(map[string]bool, len(messageIds))
+	for _, id := range messageIds {
+		requested[id] = true
+	}
+
 	// Lógica para 'since': obtener todos los mensajes no leídos antes de una fecha
 	if since != "" {
 		sinceTime, err := time.Parse(time.RFC3339Nano, since)
@@ -893,18 +1047,35 @@ func (r *ScyllaRoomRepository) MarkMessagesAsRead(ctx context.Context, userId in
 		}
 	}
 
-	if len(finalMessageIds) > 0 {
-		batch := r.session.Batch(gocql.LoggedBatch)
-		now := time.Now()
-		for _, msgIdStr := range finalMessageIds {
-			msgUUID, err := gocql.ParseUUID(msgIdStr)
-			if err != nil {
-				continue
-			}
-			batch.Query(`INSERT INTO read_receipts_by_message (message_id, user_id, read_at) VALUES (?, ?, ?)`, msgUUID, userId, now)
-			// Actualizar el estado general a LEÍDO
-			batch.Query(`INSERT INTO message_status_by_user (user_id, room_id, message_id, status) VALUES (?, ?, ?, ?)`, userId, roomUUID, msgUUID, chatv1.MessageStatus_MESSAGE_STATUS_READ)
+	// IDs pedidos por el cliente, para los eventos de lectura
+	requestedUUIDs := make(map[gocql.UUID]string)
+	var markedUUIDs []gocql.UUID
+	for _, msgIdStr := range finalMessageIds {
+		msgUUID, err := r.messageUUID(ctx, msgIdStr)
+		if err != nil {
+			continue
 		}
+		if requested[msgIdStr] {
+			requestedUUIDs[msgUUID] = msgIdStr
+		}
+		markedUUIDs = append(markedUUIDs, msgUUID)
+	}
+	readMessages, err := r.readMessages(ctx, roomUUID, requestedUUIDs, messageIds)
+	if err != nil {
+		return 0, fmt.Errorf("error al leer los mensajes marcados: %w", err)
+	}
+
+	batch := r.session.Batch(gocql.LoggedBatch)
+	now := time.Now()
+	for _, msgUUID := range markedUUIDs {
+		batch.Query(`INSERT INTO read_receipts_by_message (message_id, user_id, read_at) VALUES (?, ?, ?)`, msgUUID, userId, now)
+		// Actualizar el estado general a LEÍDO
+		batch.Query(`INSERT INTO message_status_by_user (user_id, room_id, message_id, status) VALUES (?, ?, ?, ?)`, userId, roomUUID, msgUUID, chatv1.MessageStatus_MESSAGE_STATUS_READ)
+	}
+	if err := addOutboxEvents(batch, newMessagesReadEvents(roomId, userId, readMessages, now)...); err != nil {
+		return 0, err
+	}
+	if batch.Size() > 0 {
 		if err := r.session.ExecuteBatch(batch); err != nil {
 			return 0, fmt.Errorf("error al marcar mensajes como leídos: %w", err)
 		}
@@ -919,8 +1090,46 @@ func (r *ScyllaRoomRepository) MarkMessagesAsRead(ctx context.Context, userId in
 	return int32(len(finalMessageIds)), nil
 }
 
+// readMessages lee en una sola consulta el remitente y el seq de los mensajes pedidos (UUID ->
+// ID pedido), en el orden de messageIds. Los mensajes sin seq tienen seq 0.
+func (r *ScyllaRoomRepository) readMessages(ctx context.Context, roomUUID gocql.UUID, ids map[gocql.UUID]string, messageIds []string) ([]readMessage, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	uuids := make([]gocql.UUID, 0, len(ids))
+	for id := range ids {
+		uuids = append(uuids, id)
+	}
+
+	found := make(map[string]readMessage, len(ids))
+	iter := r.session.Query(`SELECT message_id, sender_id, seq FROM messages_by_room WHERE room_id = ? AND message_id IN ?`, roomUUID, uuids).
+		WithContext(ctx).Iter()
+	var messageUUID gocql.UUID
+	var senderID int
+	var seq *int64
+	for iter.Scan(&messageUUID, &senderID, &seq) {
+		msg := readMessage{id: ids[messageUUID], senderID: int32(senderID)}
+		if seq != nil {
+			msg.seq = *seq
+		}
+		found[msg.id] = msg
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	messages := make([]readMessage, 0, len(found))
+	for _, id := range messageIds {
+		if msg, ok := found[id]; ok {
+			messages = append(messages, msg)
+			delete(found, id)
+		}
+	}
+	return messages, nil
+}
+
 func (r *ScyllaRoomRepository) ReactToMessage(ctx context.Context, userId int, messageId string, reaction string) error {
-	messageUUID, err := gocql.ParseUUID(messageId)
+	messageUUID, err := r.messageUUID(ctx, messageId)
 	if err != nil {
 		return err
 	}
@@ -936,13 +1145,7 @@ func (r *ScyllaRoomRepository) ReactToMessage(ctx context.Context, userId int, m
 }
 
 func (r *ScyllaRoomRepository) GetMessage(ctx context.Context, userId int, messageId string) (*chatv1.MessageData, error) {
-	messageUUID, err := gocql.ParseUUID(messageId)
-	if err != nil {
-		return nil, err
-	}
-
-	var roomUUID gocql.UUID
-	err = r.session.Query(`SELECT room_id FROM room_by_message WHERE message_id = ?`, messageUUID).WithContext(ctx).Scan(&roomUUID)
+	messageUUID, err := r.messageUUID(ctx, messageId)
 	if err != nil {
 		if err == gocql.ErrNotFound {
 			return nil, nil
@@ -950,42 +1153,37 @@ func (r *ScyllaRoomRepository) GetMessage(ctx context.Context, userId int, messa
 		return nil, err
 	}
 
-	msg := &chatv1.MessageData{}
-	var createdAt time.Time
-	err = r.session.Query(`SELECT sender_id, content, type, created_at, edited FROM messages_by_room WHERE room_id = ? AND message_id = ?`, roomUUID, messageUUID).
-		WithContext(ctx).Scan(&msg.SenderId, &msg.Content, &msg.Type, &createdAt, &msg.Edited)
+	var roomUUID gocql.UUID
+	err = r.session.Query(`SELECT room_id FROM room_by_message WHERE message_id = ?`, messageUUID).WithContext(ctx).Scan(&roomUUID)
 	if err != nil {
 		if err == gocql.ErrNotFound {
 			return nil, nil
 		}
 		return nil, err
 	}
-	msg.Id = messageId
-	msg.RoomId = roomUUID.String()
-	msg.CreatedAt = createdAt.Format(time.RFC3339)
 
-	user, err := r.userFetcher.GetUserByID(ctx, int(msg.SenderId))
+	iter := r.session.Query(`SELECT `+scyllaMessageColumns+` FROM messages_by_room WHERE room_id = ? AND message_id = ?`, roomUUID, messageUUID).
+		WithContext(ctx).Iter()
+	messages, err := scanScyllaMessages(iter)
+	iter.Close()
 	if err != nil {
 		return nil, err
 	}
-	msg.SenderName = user.Name
-	if user.Avatar != nil {
-		msg.SenderAvatar = *user.Avatar
+	if len(messages) == 0 {
+		return nil, nil
 	}
 
-	// Obtener el estado para el usuario actual
-	var status int
-	err = r.session.Query(`SELECT status FROM message_status_by_user WHERE user_id = ? AND room_id = ? AND message_id = ?`, userId, roomUUID, messageUUID).WithContext(ctx).Scan(&status)
-	if err != nil && err != gocql.ErrNotFound {
+	// Datos del remitente, reply, menciones, reacciones y estado para el usuario actual
+	if err := r.enrichMessages(ctx, userId, roomUUID, messages); err != nil {
 		return nil, err
 	}
-	msg.Status = chatv1.MessageStatus(status)
 
-	return msg, nil
+	return messages[0], nil
 }
 
-func (r *ScyllaRoomRepository) UpdateMessage(ctx context.Context, userId int, messageId string, content string) error {
-	messageUUID, err := gocql.ParseUUID(messageId)
+func (r *ScyllaRoomRepository) UpdateMessage(ctx context.Context, userId int, req *chatv1.EditMessageRequest, contentDecrypted *string) error {
+	messageId := req.MessageId
+	messageUUID, err := r.messageUUID(ctx, messageId)
 	if err != nil {
 		return err
 	}
@@ -996,13 +1194,20 @@ func (r *ScyllaRoomRepository) UpdateMessage(ctx context.Context, userId int, me
 		return err
 	}
 
-	return r.session.Query(`UPDATE messages_by_room SET content = ?, edited = true WHERE room_id = ? AND message_id = ?`, content, roomUUID, messageUUID).WithContext(ctx).Exec()
+	batch := r.session.Batch(gocql.LoggedBatch)
+	batch.Query(`UPDATE messages_by_room SET content = ?, content_decrypted = ?, key_version = ?, edited = true, updated_at = ? WHERE room_id = ? AND message_id = ?`,
+		req.NewContent, contentDecrypted, req.KeyVersion, time.Now(), roomUUID, messageUUID)
+	if err := addOutboxEvents(batch, newOutboxEvent(roomUUID.String(), userId, OutboxMessageUpdated, messageId, nil)); err != nil {
+		return err
+	}
+
+	return r.session.ExecuteBatch(batch)
 }
 
 func (r *ScyllaRoomRepository) DeleteMessage(ctx context.Context, userId int, messageIds []string) error {
 	batch := r.session.Batch(gocql.LoggedBatch)
 	for _, msgIdStr := range messageIds {
-		messageUUID, err := gocql.ParseUUID(msgIdStr)
+		messageUUID, err := r.messageUUID(ctx, msgIdStr)
 		if err != nil {
 			continue
 		}
@@ -1013,28 +1218,34 @@ func (r *ScyllaRoomRepository) DeleteMessage(ctx context.Context, userId int, me
 			continue
 		}
 
-		batch.Query(`UPDATE messages_by_room SET is_deleted = true WHERE room_id = ? AND message_id = ?`, roomUUID, messageUUID)
+		batch.Query(`UPDATE messages_by_room SET is_deleted = true, updated_at = ? WHERE room_id = ? AND message_id = ?`, time.Now(), roomUUID, messageUUID)
+
+		err = addOutboxEvents(batch, newOutboxEvent(roomUUID.String(), userId, OutboxMessageDeleted, msgIdStr, &chatv1.MessageEvent{
+			RoomId: roomUUID.String(),
+			Event:  &chatv1.MessageEvent_DeleteMessage{DeleteMessage: msgIdStr},
+		}))
+		if err != nil {
+			return err
+		}
 	}
 
 	return r.session.ExecuteBatch(batch)
 }
 
 func (r *ScyllaRoomRepository) GetMessageRead(ctx context.Context, req *chatv1.GetMessageReadRequest) ([]*chatv1.MessageUserRead, *chatv1.PaginationMeta, error) {
-	messageUUID, err := gocql.ParseUUID(req.Id)
+	messageUUID, err := r.messageUUID(ctx, req.Id)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	baseQuery := `SELECT user_id, read_at FROM read_receipts_by_message WHERE message_id = ?`
-	args := []any{messageUUID}
-
-	if req != nil && req.Limit > 0 {
-		baseQuery += " LIMIT ?"
-		args = append(args, int(req.Limit))
+	query, err := pagedQuery(r.session.Query(`SELECT user_id, read_at FROM read_receipts_by_message WHERE message_id = ?`, messageUUID), req.Cursor, cursorReads, req.Limit)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	iter := r.session.Query(baseQuery, args...).WithContext(ctx).Iter()
+	iter := query.WithContext(ctx).Iter()
 	defer iter.Close()
+	nextCursor := nextPageCursor(iter, cursorReads, req.Cursor, req.Limit)
 
 	var userReads []*chatv1.MessageUserRead
 	var userIDs []int
@@ -1069,26 +1280,24 @@ func (r *ScyllaRoomRepository) GetMessageRead(ctx context.Context, req *chatv1.G
 		}
 	}
 
-	meta := &chatv1.PaginationMeta{ItemCount: uint32(len(userReads))}
+	meta := &chatv1.PaginationMeta{ItemCount: uint32(len(userReads)), NextCursor: nextCursor}
 	return userReads, meta, nil
 }
 
 func (r *ScyllaRoomRepository) GetMessageReactions(ctx context.Context, req *chatv1.GetMessageReactionsRequest) ([]*chatv1.Reaction, *chatv1.PaginationMeta, error) {
-	messageUUID, err := gocql.ParseUUID(req.Id)
+	messageUUID, err := r.messageUUID(ctx, req.Id)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	baseQuery := `SELECT user_id, reaction FROM reactions_by_message WHERE message_id = ?`
-	args := []any{messageUUID}
-
-	if req != nil && req.Limit > 0 {
-		baseQuery += " LIMIT ?"
-		args = append(args, int(req.Limit))
+	query, err := pagedQuery(r.session.Query(`SELECT user_id, reaction FROM reactions_by_message WHERE message_id = ?`, messageUUID), req.Cursor, cursorReactions, req.Limit)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	iter := r.session.Query(baseQuery, args...).WithContext(ctx).Iter()
+	iter := query.WithContext(ctx).Iter()
 	defer iter.Close()
+	nextCursor := nextPageCursor(iter, cursorReactions, req.Cursor, req.Limit)
 
 	var reactions []*chatv1.Reaction
 	var userIDs []int
@@ -1123,7 +1332,7 @@ func (r *ScyllaRoomRepository) GetMessageReactions(ctx context.Context, req *cha
 		}
 	}
 
-	meta := &chatv1.PaginationMeta{ItemCount: uint32(len(reactions))}
+	meta := &chatv1.PaginationMeta{ItemCount: uint32(len(reactions)), NextCursor: nextCursor}
 	return reactions, meta, nil
 }
 
@@ -1151,6 +1360,10 @@ func (r *ScyllaRoomRepository) GetUsersByID(ctx context.Context, ids []int) ([]U
 	return r.userFetcher.GetUsersByID(ctx, ids)
 }
 
+func (r *ScyllaRoomRepository) GetUserByPhone(ctx context.Context, phone string) (*User, error) {
+	return r.userFetcher.GetUserByPhone(ctx, phone)
+}
+
 func (r *ScyllaRoomRepository) GetAllUserIDs(ctx context.Context) ([]int, error) {
 	return r.userFetcher.GetAllUserIDs(ctx)
 }
@@ -1193,11 +1406,16 @@ func (r *ScyllaRoomRepository) DeleteRoom(ctx context.Context, userId int, roomI
 	batch := r.session.Batch(gocql.LoggedBatch)
 	batch.Query(`DELETE FROM participants_by_room WHERE room_id = ?`, roomUUID)
 	batch.Query(`DELETE FROM room_details WHERE room_id = ?`, roomUUID)
+	batch.Query(`DELETE FROM room_keys_by_room WHERE room_id = ?`, roomUUID)
+	batch.Query(`DELETE FROM room_device_keys_by_room WHERE room_id = ?`, roomUUID)
 	batch.Query(`DELETE FROM messages_by_room WHERE room_id = ?`, roomUUID)
 
 	if err := r.session.ExecuteBatch(batch); err != nil {
 		return fmt.Errorf("error en el batch de eliminación de datos de la sala: %w", err)
 	}
+	if err := deleteRoomKeyState(ctx, r.session, roomUUID); err != nil {
+		return fmt.Errorf("error al borrar la clave de la sala: %w", err)
+	}
 
 	DeleteRoomCacheByRoomID(ctx, roomId)
 	return nil
@@ -1262,6 +1480,9 @@ func (r *ScyllaRoomRepository) MuteRoom(ctx context.Context, userId int, roomId
 	batch.Query(`UPDATE rooms_by_user SET is_muted = ? WHERE user_id = ? AND is_pinned = ? AND last_message_at = ? AND room_id = ?`,
 		mute, userId, isPinned, lastMessageAt, roomUUID)
 	batch.Query(`UPDATE participants_by_room SET is_muted = ? WHERE room_id = ? AND user_id = ?`, mute, roomUUID, userId)
+	if err := addOutboxEvents(batch, newRoomUpdatedEvent(roomId, userId)); err != nil {
+		return err
+	}
 
 	if err := r.session.ExecuteBatch(batch); err != nil {
 		return fmt.Errorf("error en batch de mute/unmute: %w", err)
@@ -1308,6 +1529,9 @@ func (r *ScyllaRoomRepository) UpdateParticipantRoom(ctx context.Context, userId
 	batch.Query(`UPDATE participants_by_room SET role = ? WHERE room_id = ? AND user_id = ?`, req.Role, roomUUID, participantID)
 	batch.Query(`UPDATE rooms_by_user SET role = ? WHERE user_id = ? AND is_pinned = ? AND last_message_at = ? AND room_id = ?`,
 		req.Role, participantID, isPinned, lastMessageAt, roomUUID)
+	if err := addOutboxEvents(batch, newRoomUpdatedEvent(req.Id, userId)); err != nil {
+		return err
+	}
 
 	if err := r.session.ExecuteBatch(batch); err != nil {
 		return fmt.Errorf("error en el batch de actualización de participante: %w", err)
@@ -1354,3 +1578,38 @@ func (r *ScyllaRoomRepository) IsPartnerMuted(ctx context.Context, userId int, r
 
 	return isMuted, nil
 }
+
+// pagedQuery limita la consulta a una página de limit filas y la reanuda desde el paging
+// state del cursor. Sin limit ni cursor la consulta se deja como está (todas las filas).
+func pagedQuery(query *gocql.Query, cursor string, list string, limit uint32) (*gocql.Query, error) {
+	c, err := decodeCursor(cursor, list, 0)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil && limit == 0 {
+		return query, nil
+	}
+	if limit > 0 {
+		query = query.PageSize(int(limit))
+	}
+	// PageState desactiva además la paginación automática: el iterador se queda en una página
+	var state []byte
+	if c != nil {
+		state = c.State
+	}
+	return query.PageState(state), nil
+}
+
+// nextPageCursor devuelve el cursor de la página siguiente a la del iterador. Scylla puede
+// devolver un paging state aunque no queden filas, así que la última página puede venir vacía.
+func nextPageCursor(iter *gocql.Iter, list string, cursor string, limit uint32) string {
+	if cursor == "" && limit == 0 {
+		return ""
+	}
+	return encodeStateCursor(list, iter.PageState())
+}
+
+// ReconcileUnreadCounters no hace nada: los no leídos viven en room_counters_by_user.
+func (r *ScyllaRoomRepository) ReconcileUnreadCounters(ctx context.Context, batchSize int) (*UnreadReconcileReport, error) {
+	return &UnreadReconcileReport{}, nil
+}
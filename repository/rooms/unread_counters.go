@@ -0,0 +1,269 @@
+package roomsrepository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Contadores de mensajes sin leer en Redis (backend Postgres).
+//
+// Contar en room_message_meta los mensajes sin read_at se vuelve lento a medida que crece el
+// historial, así que con Redis configurado el repositorio mantiene, por usuario, un hash con
+// los mensajes sin leer de cada sala (campo roomId) y los que además lo mencionan (campo
+// roomId:mentions). SaveMessage los incrementa para los demás miembros y MarkMessagesAsRead
+// los decrementa con lo que se acaba de leer.
+//
+// Un campo que no existe significa "desconocido", no cero: la lectura lo calcula en la base
+// de datos y lo guarda. Por eso los incrementos solo tocan campos existentes (un miembro
+// nuevo no empieza en 1 con el historial sin contar) y entrar o salir de una sala borra el
+// campo. Lo que los contadores no siguen (mensajes borrados, purgas de retención) y lo que se
+// pierda por carreras lo corrige ReconcileUnreadCounters comparando con la base de datos.
+
+const (
+	// Los hashes de usuarios inactivos expiran; al volver se recalculan
+	unreadCountersTTL = 30 * 24 * time.Hour
+
+	// Usuarios con contadores, para la reconciliación
+	unreadCountersUsersKey = "endpoint:chat:unread:users"
+)
+
+// UnreadReconcileReport resume una pasada de ReconcileUnreadCounters.
+type UnreadReconcileReport struct {
+	Users    int // usuarios revisados
+	Repaired int // contadores corregidos
+}
+
+type unreadCount struct {
+	Unread   int32
+	Mentions int32
+}
+
+func unreadCountersKey(userId int) string {
+	return fmt.Sprintf("endpoint:chat:unread:{%d}", userId)
+}
+
+func unreadMentionsField(roomId string) string {
+	return roomId + ":mentions"
+}
+
+// KEYS[1] hash del usuario; ARGV[1] sala; ARGV[2] "1" si el mensaje menciona al usuario
+var unreadIncrementScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+  return 0
+end
+redis.call('HINCRBY', KEYS[1], ARGV[1], 1)
+if ARGV[2] == '1' then
+  redis.call('HINCRBY', KEYS[1], ARGV[1] .. ':mentions', 1)
+end
+return 1
+`)
+
+// KEYS[1] hash del usuario; ARGV[1] sala; ARGV[2] leídos; ARGV[3] menciones leídas
+var unreadDecrementScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+  return 0
+end
+local fields = {ARGV[1], ARGV[1] .. ':mentions'}
+local amounts = {tonumber(ARGV[2]), tonumber(ARGV[3])}
+for i = 1, 2 do
+  local value = tonumber(redis.call('HGET', KEYS[1], fields[i]) or '0') - amounts[i]
+  if value < 0 then
+    value = 0
+  end
+  redis.call('HSET', KEYS[1], fields[i], value)
+end
+return 1
+`)
+
+// KEYS[1] hash del usuario; ARGV en tríos campo, valor leído, valor nuevo. Solo corrige los
+// campos que no cambiaron desde que se leyeron, para no pisar un incremento concurrente.
+var unreadRepairScript = redis.NewScript(`
+local repaired = 0
+for i = 1, #ARGV, 3 do
+  if redis.call('HGET', KEYS[1], ARGV[i]) == ARGV[i + 1] then
+    redis.call('HSET', KEYS[1], ARGV[i], ARGV[i + 2])
+    repaired = repaired + 1
+  end
+end
+return repaired
+`)
+
+// unreadCounters envuelve el cliente de Redis. Un unreadCounters nil deja los conteos en la
+// base de datos.
+type unreadCounters struct {
+	client redis.UniversalClient
+}
+
+// get devuelve los contadores conocidos de las salas y las salas sin contador.
+func (c *unreadCounters) get(ctx context.Context, userId int, roomIds []string) (map[string]unreadCount, []string, error) {
+	fields := make([]string, 0, len(roomIds)*2)
+	for _, roomId := range roomIds {
+		fields = append(fields, roomId, unreadMentionsField(roomId))
+	}
+	values, err := c.client.HMGet(ctx, unreadCountersKey(userId), fields...).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	counts := make(map[string]unreadCount, len(roomIds))
+	var missing []string
+	for i, roomId := range roomIds {
+		unread, okUnread := parseUnreadValue(values[2*i])
+		mentions, okMentions := parseUnreadValue(values[2*i+1])
+		if !okUnread || !okMentions {
+			missing = append(missing, roomId)
+			continue
+		}
+		counts[roomId] = unreadCount{Unread: unread, Mentions: mentions}
+	}
+	return counts, missing, nil
+}
+
+func parseUnreadValue(value any) (int32, bool) {
+	text, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(text, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(n), true
+}
+
+// set guarda los contadores calculados en la base de datos.
+func (c *unreadCounters) set(ctx context.Context, userId int, counts map[string]unreadCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	values := make([]any, 0, len(counts)*4)
+	for roomId, count := range counts {
+		values = append(values, roomId, count.Unread, unreadMentionsField(roomId), count.Mentions)
+	}
+
+	key := unreadCountersKey(userId)
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, values...)
+		pipe.Expire(ctx, key, unreadCountersTTL)
+		pipe.SAdd(ctx, unreadCountersUsersKey, userId)
+		return nil
+	})
+	return err
+}
+
+// increment suma un mensaje nuevo de la sala a los destinatarios.
+func (c *unreadCounters) increment(ctx context.Context, roomId string, recipients []int, mentioned map[int]bool) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, userId := range recipients {
+			mention := "0"
+			if mentioned[userId] {
+				mention = "1"
+			}
+			unreadIncrementScript.Eval(ctx, pipe, []string{unreadCountersKey(userId)}, roomId, mention)
+		}
+		return nil
+	})
+	return err
+}
+
+// decrement resta los mensajes que el usuario acaba de leer, sin bajar de cero.
+func (c *unreadCounters) decrement(ctx context.Context, userId int, roomId string, read unreadCount) error {
+	if read.Unread == 0 && read.Mentions == 0 {
+		return nil
+	}
+	return unreadDecrementScript.Eval(ctx, c.client, []string{unreadCountersKey(userId)}, roomId, read.Unread, read.Mentions).Err()
+}
+
+// forget borra los contadores de la sala de los usuarios para que se recalculen.
+func (c *unreadCounters) forget(ctx context.Context, roomId string, userIds []int) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, userId := range userIds {
+			pipe.HDel(ctx, unreadCountersKey(userId), roomId, unreadMentionsField(roomId))
+		}
+		return nil
+	})
+	return err
+}
+
+// erase borra los contadores del usuario y lo quita de la reconciliación.
+func (c *unreadCounters) erase(ctx context.Context, userId int) error {
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, unreadCountersKey(userId))
+		pipe.SRem(ctx, unreadCountersUsersKey, userId)
+		return nil
+	})
+	return err
+}
+
+// remaining devuelve lo que queda del usuario en Redis: su hash y su entrada en el set de la
+// reconciliación.
+func (c *unreadCounters) remaining(ctx context.Context, userId int) ([]string, error) {
+	var exists *redis.IntCmd
+	var member *redis.BoolCmd
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		exists = pipe.Exists(ctx, unreadCountersKey(userId))
+		member = pipe.SIsMember(ctx, unreadCountersUsersKey, userId)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var remaining []string
+	if exists.Val() > 0 {
+		remaining = append(remaining, "unread counters")
+	}
+	if member.Val() {
+		remaining = append(remaining, "unread counters reconciliation entry")
+	}
+	return remaining, nil
+}
+
+// reconcile compara los contadores de un usuario con los que devuelve count y corrige los
+// que difieren. Devuelve cuántos corrigió.
+func (c *unreadCounters) reconcile(ctx context.Context, userId int, count func(roomIds []string) (map[string]unreadCount, error)) (int, error) {
+	key := unreadCountersKey(userId)
+	stored, err := c.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(stored) == 0 {
+		// El hash expiró: el usuario deja de revisarse hasta que vuelva a tener contadores
+		return 0, c.client.SRem(ctx, unreadCountersUsersKey, userId).Err()
+	}
+
+	var roomIds []string
+	for field := range stored {
+		if _, isRoom := stored[unreadMentionsField(field)]; isRoom {
+			roomIds = append(roomIds, field)
+		}
+	}
+	counts, err := count(roomIds)
+	if err != nil {
+		return 0, err
+	}
+
+	var args []any
+	for _, roomId := range roomIds {
+		actual := counts[roomId]
+		for field, value := range map[string]int32{roomId: actual.Unread, unreadMentionsField(roomId): actual.Mentions} {
+			if stored[field] != strconv.Itoa(int(value)) {
+				args = append(args, field, stored[field], value)
+			}
+		}
+	}
+	if len(args) == 0 {
+		return 0, nil
+	}
+	return unreadRepairScript.Eval(ctx, c.client, []string{key}, args...).Int()
+}
@@ -0,0 +1,231 @@
+package roomsrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/redis/go-redis/v9"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	dbpq "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+)
+
+// Conteo de no leídos de la sala para las consultas de GetRoom y GetRoomList. El ? es el
+// usuario.
+const unreadCountSubquery = "(SELECT COUNT(*) FROM room_message AS unread_msg LEFT JOIN room_message_meta AS unread_meta ON unread_msg.id = unread_meta.message_id AND unread_meta.user_id = ? AND (unread_meta.\"isDeleted\" = false OR unread_meta.\"isDeleted\" IS NULL) WHERE unread_msg.room_id = room.id AND unread_msg.deleted_at IS NULL AND unread_meta.read_at IS NULL) AS unread_count"
+
+// NewSQLRoomRepositoryWithUnreadCounters crea el repositorio Postgres con los contadores de
+// no leídos en Redis. Con client nil equivale a NewSQLRoomRepository.
+func NewSQLRoomRepositoryWithUnreadCounters(db *sql.DB, client redis.UniversalClient) RoomsRepository {
+	repo := &SQLRoomRepository{db: db}
+	if client != nil {
+		repo.counters = &unreadCounters{client: client}
+	}
+	return repo
+}
+
+// unreadCountColumn devuelve la columna unread_count y sus argumentos. Con contadores la
+// consulta no cuenta nada y fillUnreadCounts rellena los conteos después.
+func (r *SQLRoomRepository) unreadCountColumn(userId int) (string, []any) {
+	if r.counters != nil {
+		return "NULL::integer AS unread_count", nil
+	}
+	return unreadCountSubquery, []any{userId}
+}
+
+// countUnread cuenta en la base de datos los mensajes sin leer y las menciones sin leer del
+// usuario en cada sala, con la misma definición que unreadCountSubquery.
+func (r *SQLRoomRepository) countUnread(ctx context.Context, userId int, roomIds []string) (map[string]unreadCount, error) {
+	counts := make(map[string]unreadCount, len(roomIds))
+	if len(roomIds) == 0 {
+		return counts, nil
+	}
+	for _, roomId := range roomIds {
+		counts[roomId] = unreadCount{}
+	}
+
+	query := dbpq.QueryBuilder().
+		Select("m.room_id", "COUNT(DISTINCT m.id)", "COUNT(DISTINCT m.id) FILTER (WHERE tag.message_id IS NOT NULL)").
+		From("room_message AS m").
+		LeftJoin("room_message_meta AS meta ON m.id = meta.message_id AND meta.user_id = ? AND (meta.\"isDeleted\" = false OR meta.\"isDeleted\" IS NULL)", userId).
+		LeftJoin("room_message_tag AS tag ON m.id = tag.message_id AND tag.user_id = ? AND tag.deleted_at IS NULL", userId).
+		Where(sq.Eq{"m.room_id": roomIds}).
+		Where(sq.Eq{"m.deleted_at": nil}).
+		Where(sq.Eq{"meta.read_at": nil}).
+		GroupBy("m.room_id")
+
+	queryString, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, queryString, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var roomId string
+		var count unreadCount
+		if err := rows.Scan(&roomId, &count.Unread, &count.Mentions); err != nil {
+			return nil, err
+		}
+		counts[roomId] = count
+	}
+	return counts, rows.Err()
+}
+
+// fillUnreadCounts pone en las salas los conteos de los contadores. Los que faltan se cuentan
+// en la base de datos y se guardan; si Redis falla, se cuenta todo en la base de datos.
+func (r *SQLRoomRepository) fillUnreadCounts(ctx context.Context, userId int, rooms []*chatv1.Room) error {
+	if r.counters == nil || len(rooms) == 0 {
+		return nil
+	}
+
+	roomIds := make([]string, len(rooms))
+	for i, room := range rooms {
+		roomIds[i] = room.Id
+	}
+
+	counts, missing, err := r.counters.get(ctx, userId, roomIds)
+	if err != nil {
+		fmt.Println("error reading unread counters", err)
+		counts, missing = map[string]unreadCount{}, roomIds
+	}
+	if len(missing) > 0 {
+		fromDB, err := r.countUnread(ctx, userId, missing)
+		if err != nil {
+			return err
+		}
+		for roomId, count := range fromDB {
+			counts[roomId] = count
+		}
+		if err := r.counters.set(ctx, userId, fromDB); err != nil {
+			fmt.Println("error saving unread counters", err)
+		}
+	}
+
+	for _, room := range rooms {
+		count := counts[room.Id]
+		room.UnreadCount = count.Unread
+		room.UnreadMentionCount = count.Mentions
+	}
+	return nil
+}
+
+// countNewMessage incrementa los contadores de los demás miembros activos de la sala.
+func (r *SQLRoomRepository) countNewMessage(ctx context.Context, roomId string, senderId int, mentions []*chatv1.CreateMention) {
+	if r.counters == nil {
+		return
+	}
+
+	queryString, args, err := dbpq.QueryBuilder().
+		Select("user_id").
+		From("room_member").
+		Where(sq.Eq{"room_id": roomId}).
+		Where(sq.NotEq{"user_id": senderId}).
+		Where(sq.Eq{"removed_at": nil}).
+		Where(sq.Eq{"deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		fmt.Println("error building unread recipients query", err)
+		return
+	}
+	rows, err := r.db.QueryContext(ctx, queryString, args...)
+	if err != nil {
+		fmt.Println("error getting unread recipients", err)
+		return
+	}
+	defer rows.Close()
+
+	var recipients []int
+	for rows.Next() {
+		var userId int
+		if err := rows.Scan(&userId); err != nil {
+			fmt.Println("error scanning unread recipient", err)
+			return
+		}
+		recipients = append(recipients, userId)
+	}
+
+	mentioned := make(map[int]bool, len(mentions))
+	for _, mention := range mentions {
+		if userId, err := strconv.Atoi(mention.User); err == nil {
+			mentioned[userId] = true
+		}
+	}
+
+	if err := r.counters.increment(ctx, roomId, recipients, mentioned); err != nil {
+		fmt.Println("error incrementing unread counters", err)
+	}
+}
+
+// countRead cuenta, dentro de la transacción de MarkMessagesAsRead, cuántos de los mensajes
+// recién leídos son de la sala y cuántos mencionan al usuario.
+func countRead(ctx context.Context, tx *sql.Tx, userId int, roomId string, messageIds []string) (unreadCount, error) {
+	var count unreadCount
+	if len(messageIds) == 0 {
+		return count, nil
+	}
+
+	queryString, args, err := dbpq.QueryBuilder().
+		Select("COUNT(DISTINCT m.id)", "COUNT(DISTINCT m.id) FILTER (WHERE tag.message_id IS NOT NULL)").
+		From("room_message AS m").
+		LeftJoin("room_message_tag AS tag ON m.id = tag.message_id AND tag.user_id = ? AND tag.deleted_at IS NULL", userId).
+		Where(sq.Eq{"m.id": messageIds}).
+		Where(sq.Eq{"m.room_id": roomId}).
+		Where(sq.Eq{"m.deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return count, err
+	}
+	err = tx.QueryRowContext(ctx, queryString, args...).Scan(&count.Unread, &count.Mentions)
+	return count, err
+}
+
+// forgetUnreadCounters borra los contadores de la sala de los usuarios que entran o salen.
+func (r *SQLRoomRepository) forgetUnreadCounters(ctx context.Context, roomId string, userIds []int) {
+	if r.counters == nil {
+		return
+	}
+	if err := r.counters.forget(ctx, roomId, userIds); err != nil {
+		fmt.Println("error forgetting unread counters", err)
+	}
+}
+
+// ReconcileUnreadCounters revisa los contadores de hasta batchSize usuarios elegidos al azar
+// y corrige los que no coinciden con la base de datos.
+func (r *SQLRoomRepository) ReconcileUnreadCounters(ctx context.Context, batchSize int) (*UnreadReconcileReport, error) {
+	report := &UnreadReconcileReport{}
+	if r.counters == nil {
+		return report, nil
+	}
+
+	members, err := r.counters.client.SRandMemberN(ctx, unreadCountersUsersKey, int64(batchSize)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range members {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		userId, err := strconv.Atoi(member)
+		if err != nil {
+			continue
+		}
+		repaired, err := r.counters.reconcile(ctx, userId, func(roomIds []string) (map[string]unreadCount, error) {
+			return r.countUnread(ctx, userId, roomIds)
+		})
+		if err != nil {
+			return report, fmt.Errorf("user %d: %w", userId, err)
+		}
+		report.Users++
+		report.Repaired += repaired
+	}
+	return report, nil
+}
@@ -0,0 +1,20 @@
+package roomsrepository
+
+import "time"
+
+// Exportación de los datos de un usuario (portabilidad).
+//
+// La exportación se arma en el handler con los métodos de lectura habituales (salas,
+// historial, reacciones). Las lecturas del usuario no aparecen en el historial, que solo
+// trae el estado de cada mensaje, así que GetUserReadReceipts las devuelve por sala.
+//
+// El historial solo es accesible en las salas en las que el usuario sigue participando.
+// Sus mensajes en las salas que dejó o eliminó salen de GetUserMessageRooms, que devuelve
+// las salas en las que pudo enviar mensajes, y GetUserSentMessages, que devuelve los que
+// envió en una sala por orden de envío, sin comprobar que siga en ella.
+
+// UserReadReceipt es la lectura de un mensaje por parte de un usuario.
+type UserReadReceipt struct {
+	MessageID string
+	ReadAt    time.Time
+}
@@ -0,0 +1,118 @@
+package roomsrepository
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	dbpq "github.com/Venqis-NolaTech/campaing-app-core-go/pkg/db/postgres"
+)
+
+// GetUserReadReceipts devuelve los mensajes de la sala que el usuario leyó, por fecha de lectura.
+func (r *SQLRoomRepository) GetUserReadReceipts(ctx context.Context, userId int, roomId string) ([]UserReadReceipt, error) {
+	rows, err := dbpq.QueryBuilder().
+		Select("room_message_meta.message_id", "room_message_meta.read_at").
+		From("room_message_meta").
+		InnerJoin("room_message ON room_message.id = room_message_meta.message_id").
+		Where(sq.Eq{"room_message.room_id": roomId}).
+		Where(sq.Eq{"room_message_meta.user_id": userId}).
+		Where(sq.NotEq{"room_message_meta.read_at": nil}).
+		OrderBy("room_message_meta.read_at ASC", "room_message_meta.message_id ASC").
+		RunWith(r.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receipts []UserReadReceipt
+	for rows.Next() {
+		var receipt UserReadReceipt
+		var readAt time.Time
+		if err := rows.Scan(&receipt.MessageID, &readAt); err != nil {
+			return nil, err
+		}
+		receipt.ReadAt = readAt
+		receipts = append(receipts, receipt)
+	}
+	return receipts, rows.Err()
+}
+
+// GetUserMessageRooms devuelve las salas con mensajes enviados por el usuario, incluidas
+// las eliminadas y las que dejó.
+func (r *SQLRoomRepository) GetUserMessageRooms(ctx context.Context, userId int) ([]string, error) {
+	rows, err := dbpq.QueryBuilder().
+		Select("DISTINCT room_message.room_id").
+		From("room_message").
+		Where(sq.Eq{"room_message.sender_id": userId}).
+		RunWith(r.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []string
+	for rows.Next() {
+		var roomID string
+		if err := rows.Scan(&roomID); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, roomID)
+	}
+	return rooms, rows.Err()
+}
+
+// GetUserSentMessages devuelve los mensajes que el usuario envió en la sala, por orden de
+// envío. Los eliminados se devuelven sin contenido.
+func (r *SQLRoomRepository) GetUserSentMessages(ctx context.Context, userId int, roomId string) ([]*chatv1.MessageData, error) {
+	rows, err := dbpq.QueryBuilder().
+		Select(
+			"room_message.id",
+			"room_message.room_id",
+			"room_message.sender_id",
+			"public.\"user\".name",
+			"public.\"user\".phone",
+			"room_message.content",
+			"room_message.type",
+			"room_message.created_at",
+			"room_message.updated_at",
+			"room_message.edited",
+			"room_message.\"isDeleted\" OR room_message.deleted_at IS NOT NULL",
+			"room_message.event",
+			"room_message.file",
+			"room_message.audio_transcription",
+			"COALESCE(room_message.seq, 0)",
+			"COALESCE(room_message.key_version, 1)",
+		).
+		From("room_message").
+		InnerJoin("public.\"user\" ON room_message.sender_id = public.\"user\".id").
+		Where(sq.Eq{"room_message.room_id": roomId}).
+		Where(sq.Eq{"room_message.sender_id": userId}).
+		OrderBy("room_message.created_at ASC", "room_message.id ASC").
+		RunWith(r.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*chatv1.MessageData
+	for rows.Next() {
+		var message chatv1.MessageData
+		err := rows.Scan(&message.Id, &message.RoomId, &message.SenderId, &message.SenderName, &message.SenderPhone, &message.Content, &message.Type,
+			&message.CreatedAt, &message.UpdatedAt, &message.Edited, &message.IsDeleted, &message.Event, &message.File, &message.AudioTranscription,
+			&message.Seq, &message.KeyVersion)
+		if err != nil {
+			return nil, err
+		}
+		if message.IsDeleted {
+			message.Content = ""
+			message.File = nil
+			message.AudioTranscription = nil
+		}
+		messages = append(messages, &message)
+	}
+	return messages, rows.Err()
+}
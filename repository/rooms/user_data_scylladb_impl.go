@@ -0,0 +1,111 @@
+package roomsrepository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	chatv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/chat/v1"
+	"github.com/scylladb-solutions/gocql/v2"
+)
+
+// Filas por página al recorrer la partición de una sala en GetUserSentMessages
+const userSentMessagesPageSize = 500
+
+// GetUserReadReceipts toma los mensajes leídos de message_status_by_user y la fecha de
+// read_receipts_by_message, que está particionada por mensaje: una consulta por mensaje.
+func (r *ScyllaRoomRepository) GetUserReadReceipts(ctx context.Context, userId int, roomId string) ([]UserReadReceipt, error) {
+	roomUUID, err := gocql.ParseUUID(roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.session.Query(`SELECT message_id, status FROM message_status_by_user WHERE user_id = ? AND room_id = ?`, userId, roomUUID).WithContext(ctx).Iter()
+	var messageUUID gocql.UUID
+	var status int
+	var read []gocql.UUID
+	for iter.Scan(&messageUUID, &status) {
+		if chatv1.MessageStatus(status) == chatv1.MessageStatus_MESSAGE_STATUS_READ {
+			read = append(read, messageUUID)
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	receipts := make([]UserReadReceipt, 0, len(read))
+	for _, messageUUID := range read {
+		var readAt time.Time
+		err := r.session.Query(`SELECT read_at FROM read_receipts_by_message WHERE message_id = ? AND user_id = ?`, messageUUID, userId).WithContext(ctx).Scan(&readAt)
+		if err == gocql.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, UserReadReceipt{MessageID: messageUUID.String(), ReadAt: readAt})
+	}
+	sort.Slice(receipts, func(i, j int) bool {
+		if receipts[i].ReadAt.Equal(receipts[j].ReadAt) {
+			return receipts[i].MessageID < receipts[j].MessageID
+		}
+		return receipts[i].ReadAt.Before(receipts[j].ReadAt)
+	})
+	return receipts, nil
+}
+
+// GetUserMessageRooms devuelve las salas actuales del usuario y las que dejó o eliminó:
+// messages_by_room no tiene índice por remitente, así que no se filtran las salas en las
+// que no envió nada.
+func (r *ScyllaRoomRepository) GetUserMessageRooms(ctx context.Context, userId int) ([]string, error) {
+	return r.userRoomIDs(ctx, userId)
+}
+
+// GetUserSentMessages recorre la partición de la sala por páginas y se queda con los
+// mensajes del usuario, por orden de envío. Los eliminados se devuelven sin contenido.
+func (r *ScyllaRoomRepository) GetUserSentMessages(ctx context.Context, userId int, roomId string) ([]*chatv1.MessageData, error) {
+	roomUUID, err := gocql.ParseUUID(roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*chatv1.MessageData
+	var state []byte
+	for {
+		iter := r.session.Query(`SELECT `+scyllaMessageColumns+` FROM messages_by_room WHERE room_id = ?`, roomUUID).
+			PageSize(userSentMessagesPageSize).PageState(state).WithContext(ctx).Iter()
+		state = iter.PageState()
+		scanned, err := scanScyllaMessages(iter)
+		if closeErr := iter.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		var sent []*chatv1.MessageData
+		for _, msg := range scanned {
+			if int(msg.SenderId) != userId {
+				continue
+			}
+			if msg.IsDeleted {
+				msg.Content = ""
+				msg.File = nil
+				msg.AudioTranscription = nil
+			}
+			sent = append(sent, msg)
+		}
+		// Se completan por página para no armar consultas IN con todo el historial
+		if err := r.enrichMessages(ctx, userId, roomUUID, sent); err != nil {
+			return nil, err
+		}
+		messages = append(messages, sent...)
+		if len(state) == 0 {
+			break
+		}
+	}
+
+	// messages_by_room está ordenada del más reciente al más antiguo
+	slices.Reverse(messages)
+	return messages, nil
+}
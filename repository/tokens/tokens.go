@@ -8,4 +8,9 @@ import (
 
 type TokensRepository interface {
 	SaveToken(ctx context.Context, userId int, room *tokensv1.SaveTokenRequest) error
+	// DeleteUserTokens elimina todos los tokens del usuario y devuelve cuántos borró
+	DeleteUserTokens(ctx context.Context, userId int) (int64, error)
+	CountUserTokens(ctx context.Context, userId int) (int64, error)
+	// GetUserTokens devuelve los tokens registrados por el usuario, en orden de registro
+	GetUserTokens(ctx context.Context, userId int) ([]*tokensv1.SaveTokenRequest, error)
 }
@@ -59,3 +59,59 @@ func (r *SQLTokensRepository) SaveToken(ctx context.Context, userId int, room *t
 
 	return nil
 }
+
+func (r *SQLTokensRepository) DeleteUserTokens(ctx context.Context, userId int) (int64, error) {
+	result, err := dbpq.QueryBuilder().
+		Delete("public.messaging_token").
+		Where(sq.Eq{"user_id": userId}).
+		RunWith(r.db).
+		ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *SQLTokensRepository) CountUserTokens(ctx context.Context, userId int) (int64, error) {
+	var count int64
+	err := dbpq.QueryBuilder().
+		Select("COUNT(*)").
+		From("public.messaging_token").
+		Where(sq.Eq{"user_id": userId}).
+		RunWith(r.db).
+		QueryRowContext(ctx).
+		Scan(&count)
+	return count, err
+}
+
+func (r *SQLTokensRepository) GetUserTokens(ctx context.Context, userId int) ([]*tokensv1.SaveTokenRequest, error) {
+	rows, err := dbpq.QueryBuilder().
+		Select("token", "platform", "platform_version", "device", "lang", "is_voip", "debug").
+		From("public.messaging_token").
+		Where(sq.Eq{"user_id": userId}).
+		OrderBy("created_at ASC", "id ASC").
+		RunWith(r.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*tokensv1.SaveTokenRequest
+	for rows.Next() {
+		var token tokensv1.SaveTokenRequest
+		var platform, platformVersion, device, lang sql.NullString
+		var isVoip, debug sql.NullBool
+		if err := rows.Scan(&token.Token, &platform, &platformVersion, &device, &lang, &isVoip, &debug); err != nil {
+			return nil, err
+		}
+		token.Platform = platform.String
+		token.PlatformVersion = platformVersion.String
+		token.Device = device.String
+		token.Lang = lang.String
+		token.IsVoip = isVoip.Bool
+		token.Debug = debug.Bool
+		tokens = append(tokens, &token)
+	}
+	return tokens, rows.Err()
+}
@@ -0,0 +1,63 @@
+package tokensrepository
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	tokensv1 "github.com/Venqis-NolaTech/campaing-app-chat-messages-api-go/proto/generated/services/tokens/v1"
+)
+
+// MemoryTokensRepository guarda los tokens en memoria, para tests y desarrollo local.
+// Como la tabla messaging_token, no deduplica: cada SaveToken añade un registro.
+type MemoryTokensRepository struct {
+	mu     sync.Mutex
+	tokens map[int][]*tokensv1.SaveTokenRequest
+}
+
+func NewMemoryTokensRepository() TokensRepository {
+	return &MemoryTokensRepository{
+		tokens: make(map[int][]*tokensv1.SaveTokenRequest),
+	}
+}
+
+func (r *MemoryTokensRepository) SaveToken(ctx context.Context, userId int, room *tokensv1.SaveTokenRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[userId] = append(r.tokens[userId], proto.Clone(room).(*tokensv1.SaveTokenRequest))
+	return nil
+}
+
+func (r *MemoryTokensRepository) DeleteUserTokens(ctx context.Context, userId int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := int64(len(r.tokens[userId]))
+	delete(r.tokens, userId)
+	return deleted, nil
+}
+
+func (r *MemoryTokensRepository) CountUserTokens(ctx context.Context, userId int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return int64(len(r.tokens[userId])), nil
+}
+
+func (r *MemoryTokensRepository) GetUserTokens(ctx context.Context, userId int) ([]*tokensv1.SaveTokenRequest, error) {
+	return r.Tokens(userId), nil
+}
+
+// Tokens devuelve los tokens guardados por el usuario, en orden de registro.
+func (r *MemoryTokensRepository) Tokens(userId int) []*tokensv1.SaveTokenRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tokens := make([]*tokensv1.SaveTokenRequest, 0, len(r.tokens[userId]))
+	for _, token := range r.tokens[userId] {
+		tokens = append(tokens, proto.Clone(token).(*tokensv1.SaveTokenRequest))
+	}
+	return tokens
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Formato versionado de los textos cifrados (mensajes y encryption_data de las salas).
+//
+//	v1:<base64(nonce || AES-GCM(texto) || tag)>
+//
+// El nonce es aleatorio (12 bytes) en cada cifrado y el tag de GCM autentica el texto, así que
+// un cifrado alterado o con otra clave falla en lugar de devolver basura. Los textos sin
+// prefijo son del formato anterior: AES-CBC con el IV fijo de la sala y padding PKCS#7, en
+// base64. Se siguen descifrando, pero ya no se generan. El prefijo no puede confundirse con
+// un texto anterior porque ":" no es parte del alfabeto de base64.
+
+const envelopeV1Prefix = "v1:"
+
+// Todos los fallos al descifrar devuelven el mismo error, para no dar pistas sobre el padding
+// de los textos CBC.
+var errInvalidCiphertext = errors.New("invalid ciphertext")
+
+func isEnvelopeV1(data string) bool {
+	return strings.HasPrefix(data, envelopeV1Prefix)
+}
+
+// sealEnvelope cifra plaintext con AES-GCM y un nonce aleatorio.
+func sealEnvelope(key, plaintext []byte) (string, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return envelopeV1Prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openEnvelope descifra y autentica un texto v1.
+func openEnvelope(key []byte, envelope string) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(envelope, envelopeV1Prefix))
+	if err != nil || len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, errInvalidCiphertext
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptLegacyCBC descifra un texto del formato anterior y valida su padding.
+func decryptLegacyCBC(key, iv, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, errInvalidCiphertext
+	}
+	decrypted := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, ciphertext)
+	return pkcs7Unpadding(decrypted, block.BlockSize())
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// encryptLegacyCBC cifra como lo hacía la versión anterior: CBC con IV fijo y PKCS#7.
+func encryptLegacyCBC(t *testing.T, keyHex, ivHex string, plaintext []byte) string {
+	t.Helper()
+	key, _ := hex.DecodeString(keyHex)
+	iv, _ := hex.DecodeString(ivHex)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	padded := pkcs7Padding(plaintext, aes.BlockSize)
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+	return base64.StdEncoding.EncodeToString(encrypted)
+}
+
+const (
+	masterKey = "abababababababababababababababababababababababababababababababab"
+	masterIv  = "cdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcd"
+)
+
+func useTestMasterKey(t *testing.T, extra ...MasterKey) {
+	t.Helper()
+	keyring, err := NewKeyring(0, append([]MasterKey{{Version: 1, Key: mustHex(masterKey), IV: mustHex(masterIv)}}, extra...)...)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	previous := keyProvider()
+	SetKeyProvider(keyring)
+	t.Cleanup(func() { SetKeyProvider(previous) })
+}
+
+func TestEncryption(t *testing.T) {
+	useTestMasterKey(t)
+
+	t.Run("los mensajes nuevos usan GCM con nonce aleatorio", func(t *testing.T) {
+		encryptionData, err := GenerateKeyEncript()
+		if err != nil || !isEnvelopeV1(encryptionData) {
+			t.Fatalf("GenerateKeyEncript = %q %v", encryptionData, err)
+		}
+
+		first, err := EncryptMessage("hola", encryptionData)
+		if err != nil || !isEnvelopeV1(first) {
+			t.Fatalf("EncryptMessage = %q %v", first, err)
+		}
+		second, _ := EncryptMessage("hola", encryptionData)
+		if first == second {
+			t.Fatalf("dos cifrados del mismo mensaje son iguales")
+		}
+
+		if got, err := DecryptMessage(first, encryptionData); err != nil || got != "hola" {
+			t.Fatalf("DecryptMessage = %q %v", got, err)
+		}
+	})
+
+	t.Run("los cifrados anteriores en CBC se siguen descifrando", func(t *testing.T) {
+		roomKey, roomIv := strings.Repeat("01", 32), strings.Repeat("02", 16)
+		keys, _ := json.Marshal(map[string]string{"key": roomKey, "iv": roomIv})
+		encryptionData := encryptLegacyCBC(t, masterKey, masterIv, keys)
+		message := encryptLegacyCBC(t, roomKey, roomIv, []byte("mensaje antiguo"))
+
+		if got, err := DecryptMessage(message, encryptionData); err != nil || got != "mensaje antiguo" {
+			t.Fatalf("DecryptMessage = %q %v", got, err)
+		}
+		// Una sala antigua recibe mensajes nuevos en v1
+		encrypted, err := EncryptMessage("nuevo", encryptionData)
+		if err != nil || !isEnvelopeV1(encrypted) {
+			t.Fatalf("EncryptMessage = %q %v", encrypted, err)
+		}
+		if got, err := DecryptMessage(encrypted, encryptionData); err != nil || got != "nuevo" {
+			t.Fatalf("DecryptMessage = %q %v", got, err)
+		}
+	})
+
+	t.Run("los cifrados alterados o malformados fallan sin pánico", func(t *testing.T) {
+		encryptionData, _ := GenerateKeyEncript()
+		otherRoom, _ := GenerateKeyEncript()
+		encrypted, _ := EncryptMessage("hola", encryptionData)
+
+		sealed, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, envelopeV1Prefix))
+		sealed[len(sealed)-1] ^= 1
+		tampered := envelopeV1Prefix + base64.StdEncoding.EncodeToString(sealed)
+
+		roomKey, roomIv, _ := makePublicDecryptUtil(encryptionData)
+		badPadding := encryptLegacyCBC(t, roomKey, roomIv, []byte("x"))
+		raw, _ := base64.StdEncoding.DecodeString(badPadding)
+		block, _ := aes.NewCipher(mustHex(roomKey))
+		plain := make([]byte, len(raw))
+		cipher.NewCBCDecrypter(block, mustHex(roomIv)).CryptBlocks(plain, raw)
+		plain[len(plain)-1] = 0x20
+		reencrypted := make([]byte, len(plain))
+		cipher.NewCBCEncrypter(block, mustHex(roomIv)).CryptBlocks(reencrypted, plain)
+
+		for name, message := range map[string]string{
+			"tag alterado":     tampered,
+			"v1 truncado":      envelopeV1Prefix + "AAAA",
+			"CBC sin bloques":  base64.StdEncoding.EncodeToString([]byte("corto")),
+			"padding inválido": base64.StdEncoding.EncodeToString(reencrypted),
+		} {
+			if _, err := DecryptMessage(message, encryptionData); err == nil {
+				t.Errorf("%s: se descifró", name)
+			}
+		}
+		if _, err := DecryptMessage(encrypted, otherRoom); err == nil {
+			t.Errorf("se descifró con la clave de otra sala")
+		}
+	})
+
+	t.Run("los blobs se cifran con la clave maestra y solo en v1", func(t *testing.T) {
+		sealed, err := SealBlob([]byte("archivo exportado"))
+		if err != nil || !isEnvelopeV1(sealed) || strings.Contains(sealed, "archivo") {
+			t.Fatalf("SealBlob = %q %v", sealed, err)
+		}
+		if got, err := OpenBlob(sealed); err != nil || string(got) != "archivo exportado" {
+			t.Fatalf("OpenBlob = %q %v", got, err)
+		}
+		legacy := encryptLegacyCBC(t, masterKey, masterIv, []byte("archivo exportado"))
+		if _, err := OpenBlob(legacy); err == nil {
+			t.Errorf("OpenBlob aceptó un texto CBC")
+		}
+	})
+}
+
+func mustHex(value string) []byte {
+	decoded, _ := hex.DecodeString(value)
+	return decoded
+}
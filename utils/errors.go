@@ -21,6 +21,7 @@ var ERRORS = struct {
 	FILE_ALREADY_EXISTS          string
 	INTERNAL_SERVER_ERROR        string
 	SMS_OTP_PROVIDER_ERROR       string
+	PERMISSION_DENIED            string
 }{
 	NOT_FOUND:                    "not_found",
 	INVALID_REQUEST_DATA:         "invalid_request_data",
@@ -42,4 +43,5 @@ var ERRORS = struct {
 	FILE_ALREADY_EXISTS:          "file_already_exists",
 	INTERNAL_SERVER_ERROR:        "internal_server_error",
 	SMS_OTP_PROVIDER_ERROR:       "sms_otp_provider_error",
+	PERMISSION_DENIED:            "permission_denied",
 }
@@ -1,22 +1,18 @@
 package utils
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
-	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/config"
 	"golang.org/x/crypto/scrypt"
 )
 
-var masterKey = config.GetString("chat.key")
-var masterIv = config.GetString("chat.iv")
-
 func GenerateKeyEncript() (string, error) {
 	password := "some password"
 	ivBuffer := make([]byte, 16)
@@ -35,18 +31,11 @@ func GenerateKeyEncript() (string, error) {
 	}
 	key := hex.EncodeToString(keyBuffer)
 	iv := hex.EncodeToString(ivBuffer)
-	encriptionData, err := makePublicEncryptUtil(map[string]string{
+	// El IV solo lo usan los clientes que aún cifran con el formato anterior
+	return makePublicEncryptUtil(map[string]string{
 		"key": key,
 		"iv":  iv,
 	})
-	if err != nil {
-		return "", err
-	}
-	encriptionDataBase64, err := toBase64(encriptionData)
-	if err != nil {
-		return "", err
-	}
-	return encriptionDataBase64, nil
 }
 
 func GenerateRandomKeyAndIV() (string, string, error) {
@@ -71,87 +60,147 @@ func GenerateRandomKeyAndIV() (string, string, error) {
 	return keyHex, ivHex, nil
 }
 
+// makePublicEncryptUtil cifra la clave de la sala con la versión actual de la clave maestra
+// (ver keyprovider.go) en el formato v1.
 func makePublicEncryptUtil(data any) (string, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return "", err
 	}
-	bufferData := []byte(jsonData)
-
-	// Convertir la clave hexadecimal a bytes
-	keyBytes, err := hex.DecodeString(masterKey)
-	if err != nil {
-		fmt.Printf("Error decodificando key: %v\n", err)
-		return "", err
-	}
 
-	// Convertir el IV hexadecimal a bytes
-	ivBytes, err := hex.DecodeString(masterIv)
+	master, err := keyProvider().Current()
 	if err != nil {
-		fmt.Printf("Error decodificando IV: %v\n", err)
+		fmt.Printf("Error obteniendo la clave maestra: %v\n", err)
 		return "", err
 	}
 
-	// Hacer padding del input para que sea múltiplo de 16 bytes
-	paddedData := pkcs7Padding(bufferData, 16)
+	return sealMasterEnvelope(master, jsonData)
+}
 
-	block, err := aes.NewCipher(keyBytes)
+// makePublicDecryptUtil devuelve la clave y el IV (en hex) de la sala a partir de su
+// encryption_data, en formato v1 o en el anterior (CBC con chat.key y chat.iv, en base64).
+func makePublicDecryptUtil(encriptionData string) (string, string, error) {
+	decrypted, _, err := openMasterEnvelope(encriptionData)
 	if err != nil {
-		fmt.Printf("Error creando cipher: %v\n", err)
-		return "", err
+		return "", "", err
 	}
 
-	cipher := cipher.NewCBCEncrypter(block, ivBytes)
-	encrypted := make([]byte, len(paddedData))
-	cipher.CryptBlocks(encrypted, paddedData)
+	var dataJSON map[string]string
+	if err := json.Unmarshal(decrypted, &dataJSON); err != nil {
+		return "", "", err
+	}
 
-	return hex.EncodeToString(encrypted), nil
+	return dataJSON["key"], dataJSON["iv"], nil
 }
 
-func makePublicDecryptUtil(data string) (string, string, error) {
-	paddedData, err := hex.DecodeString(data)
+// RewrapKeyEncript vuelve a cifrar el encryption_data de una sala con la versión actual de la
+// clave maestra, sin cambiar la clave de la sala. Devuelve false (y el mismo texto) si ya
+// estaba cifrado con la versión actual en formato v1.
+func RewrapKeyEncript(encriptionData string) (string, bool, error) {
+	master, err := keyProvider().Current()
 	if err != nil {
-		return "", "", err
+		return "", false, err
 	}
-
-	keyBytes, err := hex.DecodeString(masterKey)
+	decrypted, version, err := openMasterEnvelope(encriptionData)
 	if err != nil {
-		return "", "", err
+		return "", false, err
 	}
-
-	ivBytes, err := hex.DecodeString(masterIv)
+	if version == master.Version && (version > 1 || isEnvelopeV1(encriptionData)) {
+		return encriptionData, false, nil
+	}
+	rewrapped, err := sealMasterEnvelope(master, decrypted)
 	if err != nil {
-		fmt.Printf("Error creando cipher: %v\n", err)
-		return "", "", err
+		return "", false, err
 	}
-	block, err := aes.NewCipher(keyBytes)
+	return rewrapped, true, nil
+}
+
+// SealBlob cifra datos que el servicio guarda fuera de las bases, como los archivos de las
+// exportaciones en la caché, con la versión actual de la clave maestra y el mismo formato
+// que el encryption_data de las salas.
+func SealBlob(plaintext []byte) (string, error) {
+	master, err := keyProvider().Current()
 	if err != nil {
-		fmt.Printf("Error creando cipher: %v\n", err)
-		return "", "", err
+		return "", err
 	}
-	cipher := cipher.NewCBCDecrypter(block, ivBytes)
-	decrypted := make([]byte, len(paddedData))
-	cipher.CryptBlocks(decrypted, paddedData)
+	return sealMasterEnvelope(master, plaintext)
+}
 
-	unpaddedData := pkcs7Unpadding(decrypted)
+// OpenBlob descifra un texto de SealBlob. Solo acepta el formato v1: los blobs nunca se
+// cifraron con CBC.
+func OpenBlob(sealed string) ([]byte, error) {
+	if !isEnvelopeV1(sealed) && !strings.HasPrefix(sealed, masterVersionPrefix) {
+		return nil, errInvalidCiphertext
+	}
+	decrypted, _, err := openMasterEnvelope(sealed)
+	return decrypted, err
+}
 
-	//return key and iv
+// masterVersionPrefix antecede al encryption_data cifrado con una versión de la clave maestra
+// distinta de la 1: k<versión>:v1:...
+const masterVersionPrefix = "k"
 
-	unpaddedDataString := string(unpaddedData)
+func sealMasterEnvelope(master MasterKey, plaintext []byte) (string, error) {
+	sealed, err := sealEnvelope(master.Key, plaintext)
+	if err != nil || master.Version == 1 {
+		return sealed, err
+	}
+	return masterVersionPrefix + strconv.Itoa(master.Version) + ":" + sealed, nil
+}
 
-	//unpaddedDataString is a json string
-	var dataJSON map[string]string
-	err = json.Unmarshal([]byte(unpaddedDataString), &dataJSON)
+// openMasterEnvelope descifra un encryption_data y devuelve también la versión de la clave
+// maestra con la que estaba cifrado.
+func openMasterEnvelope(encriptionData string) ([]byte, int, error) {
+	version, envelope := 1, encriptionData
+	if strings.HasPrefix(encriptionData, masterVersionPrefix) {
+		versionText, rest, ok := strings.Cut(strings.TrimPrefix(encriptionData, masterVersionPrefix), ":")
+		parsed, err := strconv.Atoi(versionText)
+		if !ok || err != nil || parsed <= 1 || !isEnvelopeV1(rest) {
+			return nil, 0, errInvalidCiphertext
+		}
+		version, envelope = parsed, rest
+	}
+
+	master, err := keyProvider().Key(version)
 	if err != nil {
-		return "", "", err
+		return nil, 0, err
 	}
 
-	return dataJSON["key"], dataJSON["iv"], nil
+	var decrypted []byte
+	if isEnvelopeV1(envelope) {
+		decrypted, err = openEnvelope(master.Key, envelope)
+	} else {
+		decrypted, err = decryptLegacyPublic(master, envelope)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return decrypted, version, nil
 }
 
-func pkcs7Unpadding(data []byte) []byte {
+func decryptLegacyPublic(master MasterKey, encriptionData string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encriptionData)
+	if err != nil {
+		return nil, err
+	}
+	return decryptLegacyCBC(master.Key, master.IV, ciphertext)
+}
+
+// pkcs7Unpadding quita el padding PKCS#7 después de comprobar que es válido.
+func pkcs7Unpadding(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, errInvalidCiphertext
+	}
 	padding := int(data[len(data)-1])
-	return data[:len(data)-padding]
+	if padding == 0 || padding > blockSize {
+		return nil, errInvalidCiphertext
+	}
+	for _, b := range data[len(data)-padding:] {
+		if int(b) != padding {
+			return nil, errInvalidCiphertext
+		}
+	}
+	return data[:len(data)-padding], nil
 }
 
 // Función para hacer padding PKCS7
@@ -164,51 +213,26 @@ func pkcs7Padding(data []byte, blockSize int) []byte {
 	return append(data, padtext...)
 }
 
+// EncryptMessage cifra el mensaje con la clave de la sala en el formato v1.
 func EncryptMessage(message string, encriptionData string) (string, error) {
-	encriptionData, err := fromBase64(encriptionData)
-	if err != nil {
-		return "", err
-	}
-	key, iv, err := makePublicDecryptUtil(encriptionData)
+	key, _, err := makePublicDecryptUtil(encriptionData)
 	if err != nil {
 		return "", err
 	}
 	if message == "" {
 		return "", errors.New("message is empty")
 	}
-	bufferData := []byte(message)
-
-	// Aplicar padding PKCS7
-	paddedData := pkcs7Padding(bufferData, 16)
 
 	keyBytes, err := hex.DecodeString(key)
 	if err != nil {
 		return "", err
 	}
-	ivBytes, err := hex.DecodeString(iv)
-	if err != nil {
-		return "", err
-	}
-	block, err := aes.NewCipher(keyBytes)
-	if err != nil {
-		return "", err
-	}
-	cipher := cipher.NewCBCEncrypter(block, ivBytes)
-	encrypted := make([]byte, len(paddedData))
-	cipher.CryptBlocks(encrypted, paddedData)
-
-	encryptedBase64, err := toBase64(hex.EncodeToString(encrypted))
-	if err != nil {
-		return "", err
-	}
-	return encryptedBase64, nil
+	return sealEnvelope(keyBytes, []byte(message))
 }
 
+// DecryptMessage descifra un mensaje en formato v1 o en el anterior (CBC con el IV de la
+// sala).
 func DecryptMessage(message string, encriptionData string) (string, error) {
-	encriptionData, err := fromBase64(encriptionData)
-	if err != nil {
-		return "", err
-	}
 	key, iv, err := makePublicDecryptUtil(encriptionData)
 	if err != nil {
 		return "", err
@@ -216,59 +240,32 @@ func DecryptMessage(message string, encriptionData string) (string, error) {
 	if message == "" {
 		return "", errors.New("message is empty")
 	}
-	messageBase64, err := fromBase64(message)
-	if err != nil {
-		return "", err
-	}
-	encryptedBuffer, err := hex.DecodeString(messageBase64)
-	if err != nil {
-		return "", err
-	}
-
-	// Validar que el buffer tenga el tamaño correcto para AES (múltiplo de 16 bytes)
-	if len(encryptedBuffer) == 0 {
-		return "", err
-	}
-	if len(encryptedBuffer)%16 != 0 {
-		return "", err
-	}
 
 	keyBytes, err := hex.DecodeString(key)
 	if err != nil {
 		return "", err
 	}
-	ivBytes, err := hex.DecodeString(iv)
-	if err != nil {
-		return "", err
-	}
-	block, err := aes.NewCipher(keyBytes)
-	if err != nil {
-		return "", err
-	}
-	decipher := cipher.NewCBCDecrypter(block, ivBytes)
-	decrypted := make([]byte, len(encryptedBuffer))
-	decipher.CryptBlocks(decrypted, encryptedBuffer)
-
-	// Remover padding PKCS7
-	unpaddedData := pkcs7Unpadding(decrypted)
-
-	return string(unpaddedData), nil
-}
 
-func toBase64(message string) (string, error) {
-	decodedBytes, err := hex.DecodeString(message)
-	if err != nil {
-		return "", err
+	var decrypted []byte
+	if isEnvelopeV1(message) {
+		decrypted, err = openEnvelope(keyBytes, message)
+	} else {
+		var encryptedBuffer, ivBytes []byte
+		encryptedBuffer, err = base64.StdEncoding.DecodeString(message)
+		if err != nil {
+			return "", err
+		}
+		ivBytes, err = hex.DecodeString(iv)
+		if err != nil {
+			return "", err
+		}
+		decrypted, err = decryptLegacyCBC(keyBytes, ivBytes, encryptedBuffer)
 	}
-	return base64.StdEncoding.EncodeToString(decodedBytes), nil
-}
-
-func fromBase64(message string) (string, error) {
-	decodedBytes, err := base64.StdEncoding.DecodeString(message)
 	if err != nil {
 		return "", err
 	}
-	return hex.EncodeToString(decodedBytes), nil
+
+	return string(decrypted), nil
 }
 
 /*
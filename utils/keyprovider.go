@@ -0,0 +1,320 @@
+package utils
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Venqis-NolaTech/campaing-app-core-go/pkg/config"
+)
+
+// Claves maestras con las que se cifra el encryption_data de las salas.
+//
+// Cada clave tiene un número de versión. El encryption_data nuevo se cifra con la versión
+// actual del KeyProvider y lleva la versión delante (k<versión>:v1:...); el de la versión 1 se
+// sigue escribiendo sin prefijo, igual que antes de que existieran las versiones, y el de
+// formato CBC es siempre de la versión 1 (chat.key y chat.iv). Para cambiar la clave maestra
+// sin parar el servicio:
+//
+//  1. Se añade la versión nueva al keyring de todas las instancias, sin hacerla actual.
+//  2. Se marca como actual: las salas y rotaciones nuevas ya la usan.
+//  3. Se ejecuta cmd/campaing-app-chat-rewrap hasta que no queden claves pendientes.
+//  4. Se quita la versión anterior del keyring.
+
+// MasterKey es una versión de la clave maestra. IV solo hace falta en la versión 1, para
+// descifrar el encryption_data del formato CBC.
+type MasterKey struct {
+	Version int
+	Key     []byte
+	IV      []byte
+}
+
+// KeyProvider entrega las versiones de la clave maestra.
+type KeyProvider interface {
+	// Current devuelve la versión con la que se cifra el encryption_data nuevo.
+	Current() (MasterKey, error)
+	// Key devuelve una versión concreta, para descifrar.
+	Key(version int) (MasterKey, error)
+}
+
+// ErrUnknownMasterKey indica un encryption_data cifrado con una versión que no está en el
+// keyring.
+var ErrUnknownMasterKey = errors.New("unknown master key version")
+
+var (
+	masterKeysOnce sync.Once
+	masterKeysMu   sync.RWMutex
+	masterKeys     KeyProvider
+)
+
+// SetKeyProvider reemplaza el KeyProvider por defecto, que entonces ya no se carga.
+func SetKeyProvider(provider KeyProvider) {
+	masterKeysOnce.Do(func() {})
+	masterKeysMu.Lock()
+	defer masterKeysMu.Unlock()
+	masterKeys = provider
+}
+
+// keyProvider devuelve el KeyProvider en uso. El de por defecto se carga en el primer uso,
+// cuando la configuración ya está inicializada; si falla, cada uso devuelve el error.
+func keyProvider() KeyProvider {
+	masterKeysOnce.Do(func() {
+		provider := defaultKeyProvider()
+		masterKeysMu.Lock()
+		defer masterKeysMu.Unlock()
+		masterKeys = provider
+	})
+	masterKeysMu.RLock()
+	defer masterKeysMu.RUnlock()
+	return masterKeys
+}
+
+// CurrentMasterKeyVersion devuelve la versión con la que se cifra el encryption_data nuevo.
+// Los procesos la llaman al arrancar para no empezar a servir sin claves.
+func CurrentMasterKeyVersion() (int, error) {
+	master, err := keyProvider().Current()
+	if err != nil {
+		return 0, err
+	}
+	return master.Version, nil
+}
+
+// defaultKeyProvider usa el keyring de CHAT_KEYRING_FILE si está definido y, si no, la
+// configuración.
+func defaultKeyProvider() KeyProvider {
+	if path := os.Getenv("CHAT_KEYRING_FILE"); path != "" {
+		provider, err := NewFileKeyProvider(path)
+		if err != nil {
+			return failedKeyProvider{err: fmt.Errorf("loading keyring %s: %w", path, err)}
+		}
+		return provider
+	}
+	provider, err := NewConfigKeyProvider()
+	if err != nil {
+		return failedKeyProvider{err: fmt.Errorf("loading master keys: %w", err)}
+	}
+	return provider
+}
+
+// Keyring es un KeyProvider con las claves en memoria.
+type Keyring struct {
+	current int
+	keys    map[int]MasterKey
+}
+
+// NewKeyring crea un keyring con las claves dadas. current es la versión actual; con 0 se usa
+// la más alta.
+func NewKeyring(current int, keys ...MasterKey) (*Keyring, error) {
+	keyring := &Keyring{current: current, keys: make(map[int]MasterKey, len(keys))}
+	for _, key := range keys {
+		if key.Version <= 0 {
+			return nil, fmt.Errorf("invalid master key version %d", key.Version)
+		}
+		if _, ok := keyring.keys[key.Version]; ok {
+			return nil, fmt.Errorf("duplicated master key version %d", key.Version)
+		}
+		if n := len(key.Key); n != 16 && n != 24 && n != 32 {
+			return nil, fmt.Errorf("master key version %d: invalid key size %d", key.Version, n)
+		}
+		keyring.keys[key.Version] = key
+		if current == 0 && key.Version > keyring.current {
+			keyring.current = key.Version
+		}
+	}
+	if _, ok := keyring.keys[keyring.current]; !ok && len(keys) > 0 {
+		return nil, fmt.Errorf("current master key version %d is not in the keyring", keyring.current)
+	}
+	return keyring, nil
+}
+
+func (k *Keyring) Current() (MasterKey, error) {
+	return k.Key(k.current)
+}
+
+func (k *Keyring) Key(version int) (MasterKey, error) {
+	key, ok := k.keys[version]
+	if !ok {
+		return MasterKey{}, fmt.Errorf("%w: %d", ErrUnknownMasterKey, version)
+	}
+	return key, nil
+}
+
+// Versions devuelve las versiones del keyring en orden.
+func (k *Keyring) Versions() []int {
+	versions := make([]int, 0, len(k.keys))
+	for version := range k.keys {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// NewConfigKeyProvider lee las claves de la configuración o del entorno (el entorno tiene
+// prioridad):
+//   - chat.key / CHAT_KEY y chat.iv / CHAT_IV: la versión 1.
+//   - chat.masterKeys / CHAT_MASTER_KEYS: versiones adicionales, "2:<hex>,3:<hex>".
+//   - chat.masterKeyVersion / CHAT_MASTER_KEY_VERSION: la versión actual (por defecto la más
+//     alta).
+func NewConfigKeyProvider() (*Keyring, error) {
+	var keys []MasterKey
+	if value := configOrEnv("chat.key", "CHAT_KEY"); value != "" {
+		key, err := parseMasterKey(1, value, configOrEnv("chat.iv", "CHAT_IV"))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	for _, entry := range strings.Split(configOrEnv("chat.masterKeys", "CHAT_MASTER_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		versionText, value, ok := strings.Cut(entry, ":")
+		version, err := strconv.Atoi(versionText)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid master key entry %q (expected <version>:<hex>)", versionText)
+		}
+		key, err := parseMasterKey(version, value, "")
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	current := 0
+	if value := configOrEnv("chat.masterKeyVersion", "CHAT_MASTER_KEY_VERSION"); value != "" {
+		version, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key version %q", value)
+		}
+		current = version
+	}
+	return NewKeyring(current, keys...)
+}
+
+func configOrEnv(configKey, envKey string) string {
+	if value := os.Getenv(envKey); value != "" {
+		return value
+	}
+	return config.GetString(configKey)
+}
+
+func parseMasterKey(version int, keyHex, ivHex string) (MasterKey, error) {
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return MasterKey{}, fmt.Errorf("master key version %d: %w", version, err)
+	}
+	var iv []byte
+	if ivHex != "" {
+		if iv, err = hex.DecodeString(ivHex); err != nil {
+			return MasterKey{}, fmt.Errorf("master key version %d: invalid iv: %w", version, err)
+		}
+	}
+	return MasterKey{Version: version, Key: key, IV: iv}, nil
+}
+
+// fileKeyringReloadInterval es cada cuánto se comprueba si el fichero del keyring cambió.
+const fileKeyringReloadInterval = 30 * time.Second
+
+// FileKeyProvider lee las claves de un fichero JSON local:
+//
+//	{"current": 2, "keys": [{"version": 1, "key": "<hex>", "iv": "<hex>"}, {"version": 2, "key": "<hex>"}]}
+//
+// Vuelve a leer el fichero cuando cambia, así que una versión nueva se añade o se hace actual
+// sin reiniciar. Si el fichero nuevo no es válido se sigue usando el anterior.
+type FileKeyProvider struct {
+	path string
+
+	mu        sync.Mutex
+	keyring   *Keyring
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+type fileKeyring struct {
+	Current int `json:"current"`
+	Keys    []struct {
+		Version int    `json:"version"`
+		Key     string `json:"key"`
+		IV      string `json:"iv"`
+	} `json:"keys"`
+}
+
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	provider := &FileKeyProvider{path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+func (p *FileKeyProvider) Current() (MasterKey, error) {
+	return p.current().Current()
+}
+
+func (p *FileKeyProvider) Key(version int) (MasterKey, error) {
+	return p.current().Key(version)
+}
+
+func (p *FileKeyProvider) current() *Keyring {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.checkedAt) >= fileKeyringReloadInterval {
+		p.checkedAt = time.Now()
+		if info, err := os.Stat(p.path); err == nil && !info.ModTime().Equal(p.modTime) {
+			if err := p.load(info.ModTime()); err != nil {
+				fmt.Printf("Error recargando el keyring %s: %v\n", p.path, err)
+			}
+		}
+	}
+	return p.keyring
+}
+
+// load lee el fichero; se llama con p.mu tomado (o antes de publicar el provider).
+func (p *FileKeyProvider) load(modTime time.Time) error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	var file fileKeyring
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	keys := make([]MasterKey, 0, len(file.Keys))
+	for _, entry := range file.Keys {
+		key, err := parseMasterKey(entry.Version, entry.Key, entry.IV)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return errors.New("keyring without keys")
+	}
+	keyring, err := NewKeyring(file.Current, keys...)
+	if err != nil {
+		return err
+	}
+	p.keyring, p.modTime, p.checkedAt = keyring, modTime, time.Now()
+	return nil
+}
+
+// failedKeyProvider devuelve siempre el error con el que falló la carga de las claves.
+type failedKeyProvider struct {
+	err error
+}
+
+func (p failedKeyProvider) Current() (MasterKey, error) { return MasterKey{}, p.err }
+func (p failedKeyProvider) Key(int) (MasterKey, error)  { return MasterKey{}, p.err }
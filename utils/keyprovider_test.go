@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMasterKeyRotation(t *testing.T) {
+	second := MasterKey{Version: 2, Key: mustHex(strings.Repeat("ef", 32))}
+
+	t.Run("rewrap cambia la clave maestra y conserva la de la sala", func(t *testing.T) {
+		useTestMasterKey(t)
+		roomKeys, _ := json.Marshal(map[string]string{"key": strings.Repeat("01", 32), "iv": strings.Repeat("02", 16)})
+		legacy := encryptLegacyCBC(t, masterKey, masterIv, roomKeys)
+		v1, _ := GenerateKeyEncript()
+		message, _ := EncryptMessage("hola", v1)
+
+		useTestMasterKey(t, second)
+		created, _ := GenerateKeyEncript()
+		if !strings.HasPrefix(created, "k2:v1:") {
+			t.Fatalf("encryption_data nuevo = %q", created)
+		}
+
+		for name, encryptionData := range map[string]string{"v1": v1, "CBC": legacy} {
+			rewrapped, changed, err := RewrapKeyEncript(encryptionData)
+			if err != nil || !changed || !strings.HasPrefix(rewrapped, "k2:v1:") {
+				t.Fatalf("%s: RewrapKeyEncript = %q %v %v", name, rewrapped, changed, err)
+			}
+			before, beforeIv, _ := makePublicDecryptUtil(encryptionData)
+			after, afterIv, err := makePublicDecryptUtil(rewrapped)
+			if err != nil || before != after || beforeIv != afterIv {
+				t.Fatalf("%s: la clave de la sala cambió: %v", name, err)
+			}
+		}
+		rewrapped, _, _ := RewrapKeyEncript(v1)
+		if got, err := DecryptMessage(message, rewrapped); err != nil || got != "hola" {
+			t.Fatalf("DecryptMessage tras rewrap = %q %v", got, err)
+		}
+		if same, changed, err := RewrapKeyEncript(created); err != nil || changed || same != created {
+			t.Fatalf("rewrap de la versión actual = %q %v %v", same, changed, err)
+		}
+
+		// Sin la versión 2 en el keyring no se puede leer
+		useTestMasterKey(t)
+		if _, _, err := makePublicDecryptUtil(created); !errors.Is(err, ErrUnknownMasterKey) {
+			t.Fatalf("versión desconocida: %v", err)
+		}
+		for _, malformed := range []string{"k:v1:AAAA", "kx:v1:AAAA", "k1:v1:AAAA", "k2:AAAA"} {
+			if _, _, err := makePublicDecryptUtil(malformed); err == nil {
+				t.Errorf("%q: se descifró", malformed)
+			}
+		}
+	})
+
+	t.Run("el keyring del fichero se recarga al cambiar", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keyring.json")
+		writeKeyring := func(current int, keys string) {
+			t.Helper()
+			content := `{"current": ` + strconv.Itoa(current) + `, "keys": [` + keys + `]}`
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		first := `{"version": 1, "key": "` + masterKey + `", "iv": "` + masterIv + `"}`
+		writeKeyring(1, first)
+
+		provider, err := NewFileKeyProvider(path)
+		if err != nil {
+			t.Fatalf("NewFileKeyProvider: %v", err)
+		}
+		if key, err := provider.Current(); err != nil || key.Version != 1 || len(key.IV) != 16 {
+			t.Fatalf("Current = %+v %v", key, err)
+		}
+
+		writeKeyring(2, first+`, {"version": 2, "key": "`+strings.Repeat("ef", 32)+`"}`)
+		later := time.Now().Add(time.Minute)
+		os.Chtimes(path, later, later)
+		provider.checkedAt = time.Time{}
+		if key, err := provider.Current(); err != nil || key.Version != 2 {
+			t.Fatalf("Current tras recargar = %+v %v", key, err)
+		}
+
+		// Un fichero inválido no reemplaza al anterior
+		writeKeyring(3, first)
+		os.Chtimes(path, later.Add(time.Minute), later.Add(time.Minute))
+		provider.checkedAt = time.Time{}
+		if key, err := provider.Current(); err != nil || key.Version != 2 {
+			t.Fatalf("Current tras un fichero inválido = %+v %v", key, err)
+		}
+	})
+
+	t.Run("la versión 1 se lee del entorno", func(t *testing.T) {
+		t.Setenv("CHAT_KEY", strings.Repeat("ab", 32))
+		t.Setenv("CHAT_IV", strings.Repeat("cd", 16))
+		keyring, err := NewConfigKeyProvider()
+		if err != nil {
+			t.Fatalf("NewConfigKeyProvider: %v", err)
+		}
+		key, err := keyring.Key(1)
+		if err != nil || hex.EncodeToString(key.Key) != strings.Repeat("ab", 32) || hex.EncodeToString(key.IV) != strings.Repeat("cd", 16) {
+			t.Fatalf("Key(1) = %+v %v", key, err)
+		}
+	})
+
+	t.Run("el provider por defecto se carga en el primer uso", func(t *testing.T) {
+		previous := keyProvider()
+		t.Cleanup(func() { SetKeyProvider(previous) })
+		reset := func() {
+			masterKeysOnce = sync.Once{}
+			masterKeys = nil
+		}
+
+		reset()
+		t.Setenv("CHAT_KEYRING_FILE", "")
+		t.Setenv("CHAT_KEY", strings.Repeat("ab", 32))
+		if version, err := CurrentMasterKeyVersion(); err != nil || version != 1 {
+			t.Fatalf("CurrentMasterKeyVersion = %d %v", version, err)
+		}
+
+		reset()
+		t.Setenv("CHAT_KEYRING_FILE", filepath.Join(t.TempDir(), "no-existe.json"))
+		if _, err := CurrentMasterKeyVersion(); err == nil {
+			t.Fatalf("un keyring que no se puede cargar debe devolver error")
+		}
+	})
+
+	t.Run("las versiones adicionales se leen del entorno", func(t *testing.T) {
+		t.Setenv("CHAT_MASTER_KEYS", "2:"+strings.Repeat("ef", 32)+", 3:"+strings.Repeat("12", 32))
+		t.Setenv("CHAT_MASTER_KEY_VERSION", "2")
+		keyring, err := NewConfigKeyProvider()
+		if err != nil {
+			t.Fatalf("NewConfigKeyProvider: %v", err)
+		}
+		if key, err := keyring.Current(); err != nil || key.Version != 2 {
+			t.Fatalf("Current = %+v %v", key, err)
+		}
+		if _, err := keyring.Key(3); err != nil {
+			t.Fatalf("Key(3): %v", err)
+		}
+
+		t.Setenv("CHAT_MASTER_KEY_VERSION", "4")
+		if _, err := NewConfigKeyProvider(); err == nil {
+			t.Fatalf("se aceptó una versión actual que no está en el keyring")
+		}
+	})
+}